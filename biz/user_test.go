@@ -3,14 +3,12 @@ package biz
 import (
 	"context"
 	"fmt"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/JustinRoc/mongodbL/mongo"
+	"github.com/JustinRoc/mongodbL/testsupport"
 	"github.com/JustinRoc/pkg/slogw"
-	"github.com/JustinRoc/pkg/util"
-	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -22,41 +20,15 @@ type Suite struct {
 	userbiz *UserBiz
 }
 
+func TestMain(m *testing.M) {
+	testsupport.Main(m)
+}
+
 func TestSuite(t *testing.T) {
 	slogw.Init("", "info", nil)
 	ctx := context.Background()
-	// 加载 .env 文件
-	err := godotenv.Load("../.env")
-	if err != nil {
-		slogw.ErrorContext(ctx, "加载 .env 文件失败", "err", err)
-		return
-	}
-	// 创建 MongoDB 客户端配置
-	config := &mongo.Config{
-		URI:            os.Getenv("MongoAddress"),
-		Database:       "testdb",
-		ConnectTimeout: 10 * time.Second,
-		MaxPoolSize:    100,
-		MinPoolSize:    5,
-	}
-	slogw.Init("", "info", nil)
-	slogw.InfoContext(ctx, "Connecting to MongoDB", "config", util.ToJSONStr(config))
-
-	// 连接到 MongoDB
-	client, err := mongo.NewClient(config)
-	if err != nil {
-		slogw.ErrorContext(ctx, "Failed to connect to MongoDB", "err", err)
-		return
-	}
-	defer func() {
-		if err := client.Close(); err != nil {
-			slogw.ErrorContext(ctx, "Error closing MongoDB connection", "err", err)
-		}
-	}()
-	if config.URI != "mongodb://localhost:27017" {
-		slogw.WarnContext(ctx, "don't test in corporate DB")
-		return
-	}
+	// 连接测试用的 MongoDB，每次运行使用独立的临时数据库，结束后自动删除
+	client := testsupport.NewClient(t, nil)
 
 	suite := &Suite{
 		ctx:    ctx,
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// benchMix 各操作的权重
+type benchMix map[string]int
+
+// benchOperations 支持的操作，按输出顺序排列
+var benchOperations = []string{"insert", "find", "update", "aggregate"}
+
+// parseMix 解析 insert=50,find=30 形式的负载比例
+func parseMix(s string) (benchMix, error) {
+	mix := benchMix{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected name=weight", part)
+		}
+		known := false
+		for _, op := range benchOperations {
+			known = known || op == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown operation %q, supported: %s", name, strings.Join(benchOperations, ", "))
+		}
+		weight, err := strconv.Atoi(value)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for %s: %q", name, value)
+		}
+		mix[name] = weight
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("mix is empty")
+	}
+	return mix, nil
+}
+
+// runBench 压测子命令。文档形如 {k: <0..keys>, v: <随机字符串>, n: <计数>, created_at: <时间>}，
+// 查询和更新按 k 随机命中，聚合按 k 的区间分组统计；建议在 k 上建立索引后对比结果
+//
+//	mongoctl bench -uri mongodb://localhost:27017 -database bench -collection docs -duration 1m -concurrency 32 \
+//		-mix insert=20,find=60,update=15,aggregate=5
+func runBench(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	var conn connectionFlags
+	conn.register(fs)
+	collection := fs.String("collection", "bench", "压测使用的集合")
+	duration := fs.Duration("duration", 30*time.Second, "压测时长")
+	requests := fs.Int64("requests", 0, "总请求数，0 表示只按时长结束")
+	concurrency := fs.Int("concurrency", 10, "并发数")
+	mixFlag := fs.String("mix", "insert=25,find=50,update=20,aggregate=5", "各操作的权重")
+	keys := fs.Int("keys", 100000, "k 字段的取值范围，决定查询和更新的命中分布")
+	payload := fs.Int("payload", 256, "v 字段的字节数")
+	seed := fs.Int64("seed", 0, "随机种子，0 表示使用当前时间")
+	asJSON := fs.Bool("json", false, "以 JSON 输出结果")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		return err
+	}
+
+	client, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	coll := mongo.NewCollection(client, *collection)
+
+	report, err := mongo.RunLoadTest(ctx, &mongo.LoadTestOptions{
+		Operations:  benchLoadOperations(coll, mix, *keys, *payload),
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Requests:    *requests,
+		Seed:        *seed,
+	})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	fmt.Fprintf(stdout, "%s.%s: %d workers, %s\n\n", client.GetDatabaseName(), *collection, report.Concurrency, report.Elapsed.Round(time.Millisecond))
+	return report.WriteTable(stdout)
+}
+
+// benchLoadOperations 按比例构造压测操作，权重为 0 的操作不参与
+func benchLoadOperations(coll *mongo.Collection, mix benchMix, keys, payload int) []mongo.LoadOperation {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	randomKey := func(rng *rand.Rand) bson.M { return bson.M{"k": rng.Intn(keys)} }
+
+	var ops []mongo.LoadOperation
+	for _, name := range benchOperations {
+		weight := mix[name]
+		if weight == 0 {
+			continue
+		}
+		switch name {
+		case "insert":
+			ops = append(ops, mongo.InsertOperation(coll, weight, func(rng *rand.Rand) interface{} {
+				v := make([]byte, payload)
+				for i := range v {
+					v[i] = letters[rng.Intn(len(letters))]
+				}
+				return bson.M{"k": rng.Intn(keys), "v": string(v), "n": 0, "created_at": time.Now()}
+			}))
+		case "find":
+			ops = append(ops, mongo.FindOperation(coll, weight, randomKey, options.Find().SetLimit(10)))
+		case "update":
+			ops = append(ops, mongo.UpdateOperation(coll, weight, randomKey, func(rng *rand.Rand) bson.M {
+				return bson.M{"$inc": bson.M{"n": 1}}
+			}))
+		case "aggregate":
+			ops = append(ops, mongo.AggregateOperation(coll, weight, func(rng *rand.Rand) []bson.M {
+				from := rng.Intn(keys)
+				return []bson.M{
+					{"$match": bson.M{"k": bson.M{"$gte": from, "$lt": from + keys/100 + 1}}},
+					{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "n": bson.M{"$sum": "$n"}}},
+				}
+			}))
+		}
+	}
+	return ops
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// errIndexDrift diff -fail-on-diff 发现差异时返回，使 CI 流水线失败
+var errIndexDrift = errors.New("indexes differ from spec")
+
+// runIndexes 索引管理子命令，索引文件格式见 mongo.LoadIndexSpecs
+//
+//	mongoctl indexes list -collection users
+//	mongoctl indexes diff -file indexes.yaml -fail-on-diff
+//	mongoctl indexes apply -file indexes.yaml
+//	mongoctl indexes drop-unused -collection users -min-age 168h -yes
+func runIndexes(ctx context.Context, args []string, stdout io.Writer) error {
+	actions := map[string]func(context.Context, []string, io.Writer) error{
+		"list":        runIndexesList,
+		"diff":        runIndexesDiff,
+		"apply":       runIndexesApply,
+		"drop-unused": runIndexesDropUnused,
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing action, expected one of: list, diff, apply, drop-unused")
+	}
+	action, ok := actions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown action %q, expected one of: list, diff, apply, drop-unused", args[0])
+	}
+	return action(ctx, args[1:], stdout)
+}
+
+// runIndexesList 列出集合的索引，未指定 -collection 时列出数据库中的所有集合
+func runIndexesList(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("indexes list", flag.ContinueOnError)
+	var conn connectionFlags
+	conn.register(fs)
+	collection := fs.String("collection", "", "集合名称，为空时列出所有集合")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	names := []string{*collection}
+	if *collection == "" {
+		if names, err = client.GetDatabase().ListCollectionNames(ctx, bson.M{}); err != nil {
+			return fmt.Errorf("failed to list collections: %w", err)
+		}
+		sort.Strings(names)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "collection\tname\tkeys\toptions")
+	for _, name := range names {
+		indexes, err := mongo.NewIndexManager(client, name).Indexes(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, index := range indexes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, index.Name, index.KeyString(), indexOptionsString(index))
+		}
+	}
+	return tw.Flush()
+}
+
+// runIndexesDiff 比较索引文件与数据库中的实际索引
+func runIndexesDiff(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("indexes diff", flag.ContinueOnError)
+	var conn connectionFlags
+	conn.register(fs)
+	file := fs.String("file", "", "索引定义文件（JSON 或 YAML）")
+	failOnDiff := fs.Bool("fail-on-diff", false, "存在差异时以非零状态退出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	specs, err := loadIndexFile(*file)
+	if err != nil {
+		return err
+	}
+
+	client, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	drift := false
+	for _, name := range sortedKeys(specs) {
+		diff, err := mongo.NewIndexManager(client, name).Diff(ctx, specs[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		drift = drift || !diff.Empty()
+		writeIndexDiff(stdout, name, diff)
+	}
+	if drift && *failOnDiff {
+		return errIndexDrift
+	}
+	return nil
+}
+
+// runIndexesApply 创建索引文件中缺少的索引；选项不一致和多余的索引只报告，不会删除
+func runIndexesApply(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("indexes apply", flag.ContinueOnError)
+	var conn connectionFlags
+	conn.register(fs)
+	file := fs.String("file", "", "索引定义文件（JSON 或 YAML）")
+	dryRun := fs.Bool("dry-run", false, "只输出将要创建的索引")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	specs, err := loadIndexFile(*file)
+	if err != nil {
+		return err
+	}
+
+	client, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	for _, name := range sortedKeys(specs) {
+		manager := mongo.NewIndexManager(client, name)
+		diff, err := manager.Diff(ctx, specs[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		writeIndexDiff(stdout, name, diff)
+		if *dryRun || len(diff.Missing) == 0 {
+			continue
+		}
+		created, err := manager.ApplySpecs(ctx, specs[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(stdout, "%s: created %s\n", name, strings.Join(created, ", "))
+	}
+	return nil
+}
+
+// runIndexesDropUnused 删除 $indexStats 显示长期未使用的索引，默认只输出，-yes 时才删除
+func runIndexesDropUnused(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("indexes drop-unused", flag.ContinueOnError)
+	var conn connectionFlags
+	conn.register(fs)
+	collection := fs.String("collection", "", "集合名称")
+	minAge := fs.Duration("min-age", 7*24*time.Hour, "统计时间至少达到该时长的索引才被视为未使用")
+	yes := fs.Bool("yes", false, "确认删除，否则只输出未使用的索引")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *collection == "" {
+		return fmt.Errorf("-collection is required")
+	}
+
+	client, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	manager := mongo.NewIndexManager(client, *collection)
+	unused, err := manager.UnusedIndexes(ctx, *minAge)
+	if err != nil {
+		return err
+	}
+	if len(unused) == 0 {
+		fmt.Fprintf(stdout, "%s: no unused indexes\n", *collection)
+		return nil
+	}
+	for _, usage := range unused {
+		if !*yes {
+			fmt.Fprintf(stdout, "%s: unused %s (since %s)\n", *collection, usage.Name, usage.Since.Format(time.RFC3339))
+			continue
+		}
+		if err := manager.DropIndex(ctx, usage.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%s: dropped %s\n", *collection, usage.Name)
+	}
+	if !*yes {
+		fmt.Fprintln(stdout, "statistics cover only the connected node; re-run with -yes to drop")
+	}
+	return nil
+}
+
+func loadIndexFile(path string) (map[string][]mongo.IndexSpec, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-file is required")
+	}
+	return mongo.LoadIndexSpecs(path)
+}
+
+func sortedKeys(specs map[string][]mongo.IndexSpec) []string {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeIndexDiff 输出差异：+ 缺少，~ 选项不一致，- 多余
+func writeIndexDiff(w io.Writer, collection string, diff *mongo.IndexDiff) {
+	if diff.Empty() {
+		fmt.Fprintf(w, "%s: up to date\n", collection)
+		return
+	}
+	fmt.Fprintf(w, "%s:\n", collection)
+	for _, spec := range diff.Missing {
+		name := spec.Name
+		if name == "" {
+			name = "(default name)"
+		}
+		fmt.Fprintf(w, "  + %s %s\n", name, spec.Keys)
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(w, "  ~ %s %s: %s differs\n", change.Existing.Name, change.Existing.KeyString(), change.Reason)
+	}
+	for _, index := range diff.Extra {
+		fmt.Fprintf(w, "  - %s %s\n", index.Name, index.KeyString())
+	}
+}
+
+func indexOptionsString(index mongo.IndexInfo) string {
+	var opts []string
+	if index.Unique {
+		opts = append(opts, "unique")
+	}
+	if index.Sparse {
+		opts = append(opts, "sparse")
+	}
+	if index.ExpireAfterSeconds != nil {
+		opts = append(opts, fmt.Sprintf("ttl=%ds", *index.ExpireAfterSeconds))
+	}
+	if len(index.PartialFilterExpression) > 0 {
+		opts = append(opts, fmt.Sprintf("partial=%v", index.PartialFilterExpression))
+	}
+	return strings.Join(opts, " ")
+}
@@ -0,0 +1,123 @@
+// Command mongoctl MongoDB 运维命令行工具
+//
+//	mongoctl <command> [flags]
+//
+// 连接参数对所有子命令通用：-config 指定 JSON/YAML 配置文件，MONGO_ 前缀的环境变量（例如 MONGO_URI、MONGO_DATABASE）
+// 覆盖配置文件，-uri、-database 覆盖前两者，规则与 mongo.LoadConfig 相同
+//
+// 子命令：
+//
+//	bench    对集合运行混合负载压测，输出吞吐量和延迟分位数
+//	indexes  按索引定义文件列出、比较、创建索引，删除长期未使用的索引
+//	migrate  执行、回滚迁移，查看迁移状态，生成迁移文件
+//	query    以 Extended JSON 条件或聚合管道查询、修改集合，输出 JSON 或表格
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+)
+
+// command 子命令
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"bench":   {summary: "对集合运行混合负载压测，输出吞吐量和延迟分位数", run: runBench},
+	"indexes": {summary: "索引管理：list、diff、apply、drop-unused", run: runIndexes},
+	"migrate": {summary: "数据库迁移：up、down、status、create", run: runMigrate},
+	"query":   {summary: "查询和修改集合：find、count、aggregate、insert、update、delete", run: runQuery},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 分发子命令并返回退出码
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "mongoctl: unknown command %q\n\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	if err := cmd.run(ctx, args[1:], stdout); err != nil {
+		if err == flag.ErrHelp {
+			return 2
+		}
+		fmt.Fprintf(stderr, "mongoctl %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: mongoctl <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run 'mongoctl <command> -h' for command flags")
+}
+
+// connectionFlags 所有子命令共用的连接参数
+type connectionFlags struct {
+	config   string
+	uri      string
+	database string
+}
+
+func (c *connectionFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.config, "config", "", "JSON 或 YAML 配置文件")
+	fs.StringVar(&c.uri, "uri", "", "MongoDB 连接地址，覆盖配置文件和 MONGO_URI")
+	fs.StringVar(&c.database, "database", "", "数据库名称，覆盖配置文件和 MONGO_DATABASE")
+}
+
+// loadConfig 按 配置文件 → 环境变量 → 命令行参数 的顺序得到配置
+func (c *connectionFlags) loadConfig() (*mongo.Config, error) {
+	config, err := mongo.LoadConfig(&mongo.LoadConfigOptions{File: c.config, EnvPrefix: "MONGO_"})
+	if err != nil {
+		return nil, err
+	}
+	if c.uri != "" {
+		config.URI = c.uri
+	}
+	if c.database != "" {
+		config.Database = c.database
+	}
+	return config, config.Validate()
+}
+
+// connect 建立客户端，只把警告以上的日志输出到标准错误，避免混入命令的输出
+func (c *connectionFlags) connect(ctx context.Context) (*mongo.Client, error) {
+	config, err := c.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return mongo.NewClientWithContext(ctx, config)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 2, run(context.Background(), nil, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "bench")
+
+	stderr.Reset()
+	assert.Equal(t, 2, run(context.Background(), []string{"nope"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown command "nope"`)
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("insert=20, find=70,update=10,aggregate=0")
+	require.NoError(t, err)
+	assert.Equal(t, benchMix{"insert": 20, "find": 70, "update": 10, "aggregate": 0}, mix)
+
+	for _, bad := range []string{"", "insert", "delete=1", "find=-1", "find=x"} {
+		_, err := parseMix(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestBenchLoadOperations(t *testing.T) {
+	ops := benchLoadOperations(nil, benchMix{"find": 3, "update": 0, "aggregate": 1}, 100, 16)
+	require.Len(t, ops, 2)
+	assert.Equal(t, "find", ops[0].Name)
+	assert.Equal(t, 3, ops[0].Weight)
+	assert.Equal(t, "aggregate", ops[1].Name)
+}
+
+func TestRunIndexesActions(t *testing.T) {
+	var stdout bytes.Buffer
+	assert.ErrorContains(t, runIndexes(context.Background(), nil, &stdout), "missing action")
+	assert.ErrorContains(t, runIndexes(context.Background(), []string{"rebuild"}, &stdout), `unknown action "rebuild"`)
+	assert.ErrorContains(t, runIndexes(context.Background(), []string{"diff"}, &stdout), "-file is required")
+	assert.ErrorContains(t, runIndexes(context.Background(), []string{"drop-unused"}, &stdout), "-collection is required")
+}
+
+func TestWriteIndexDiff(t *testing.T) {
+	var out bytes.Buffer
+	writeIndexDiff(&out, "users", &mongo.IndexDiff{})
+	assert.Equal(t, "users: up to date\n", out.String())
+
+	out.Reset()
+	writeIndexDiff(&out, "users", &mongo.IndexDiff{
+		Missing: []mongo.IndexSpec{{Keys: "title:text"}},
+		Changed: []mongo.IndexChange{{
+			Existing: mongo.IndexInfo{Name: "idx_email", Key: bson.D{{Key: "email", Value: int32(1)}}},
+			Reason:   "unique",
+		}},
+		Extra: []mongo.IndexInfo{{Name: "legacy_1", Key: bson.D{{Key: "legacy", Value: int32(-1)}}}},
+	})
+	assert.Equal(t, "users:\n  + (default name) title:text\n  ~ idx_email email: unique differs\n  - legacy_1 -legacy\n", out.String())
+}
+
+func TestRunMigrateCreate(t *testing.T) {
+	dir := t.TempDir()
+	var stdout bytes.Buffer
+	require.NoError(t, runMigrate(context.Background(), []string{"create", "-dir", dir, "add", "email", "index"}, &stdout))
+	assert.Regexp(t, `created .*/\d{14}_add_email_index\.json`, stdout.String())
+
+	assert.ErrorContains(t, runMigrate(context.Background(), []string{"create", "-dir", dir}, &stdout), "missing migration name")
+	assert.ErrorContains(t, runMigrate(context.Background(), []string{"redo"}, &stdout), `unknown action "redo"`)
+	assert.ErrorContains(t, runMigrate(context.Background(), []string{"down", "-steps", "0"}, &stdout), "-steps must be positive")
+}
+
+func TestWriteMigrationStatus(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, writeMigrationStatus(&out, []mongo.MigrationStatus{
+		{Version: "20240102150405", Description: "add email index", Applied: true, AppliedAt: time.Now()},
+		{Version: "20240103000000", Description: "backfill"},
+		{Version: "20240104000000", Description: "removed", Applied: true, Missing: true},
+	}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "applied")
+	assert.Contains(t, lines[2], "pending")
+	assert.Contains(t, lines[3], "missing")
+}
+
+func TestParseQueryArguments(t *testing.T) {
+	filter, err := parseExtJSONDocument(`{"_id": {"$oid": "665f1c2e9b1d8a3f4c2e1a01"}, "n": {"$gte": 2}}`)
+	require.NoError(t, err)
+	assert.Equal(t, "665f1c2e9b1d8a3f4c2e1a01", filter["_id"].(primitive.ObjectID).Hex())
+
+	pipeline, err := parsePipeline(`[{"$match": {"status": "active"}}, {"$sort": {"b": -1, "a": 1}}]`)
+	require.NoError(t, err)
+	require.Len(t, pipeline, 2)
+	assert.Equal(t, bson.D{{Key: "b", Value: int32(-1)}, {Key: "a", Value: int32(1)}}, pipeline[1]["$sort"], "stage field order is preserved")
+	_, err = parsePipeline(`[{"$match": {}, "$limit": 1}]`)
+	assert.Error(t, err)
+	_, err = parsePipeline("")
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "docs.json")
+	require.NoError(t, os.WriteFile(path, []byte(` [{"a": 1}, {"a": 2}]`), 0o644))
+	docs, err := parseDocuments("@" + path)
+	require.NoError(t, err)
+	assert.Len(t, docs, 2)
+	docs, err = parseDocuments(`{"a": 1}`)
+	require.NoError(t, err)
+	assert.Len(t, docs, 1)
+	_, err = parseDocuments("[]")
+	assert.Error(t, err)
+}
+
+func TestRunQueryValidation(t *testing.T) {
+	var stdout bytes.Buffer
+	assert.ErrorContains(t, runQuery(context.Background(), []string{"drop"}, &stdout), `unknown action "drop"`)
+	assert.ErrorContains(t, runQuery(context.Background(), []string{"find"}, &stdout), "-collection is required")
+	assert.ErrorContains(t, runQuery(context.Background(), []string{"find", "-collection", "users", "-filter", "{"}, &stdout), "invalid -filter")
+	assert.ErrorContains(t, runQuery(context.Background(), []string{"aggregate", "-collection", "users"}, &stdout), "invalid -pipeline")
+	assert.ErrorContains(t, runQuery(context.Background(), []string{"find", "-collection", "users", "-format", "csv"}, &stdout), "unknown format")
+}
+
+func TestWriteDocuments(t *testing.T) {
+	raw := func(doc bson.D) bson.Raw {
+		data, err := bson.Marshal(doc)
+		require.NoError(t, err)
+		return data
+	}
+	docs := []bson.Raw{
+		raw(bson.D{{Key: "name", Value: "alice"}, {Key: "tags", Value: bson.A{"a", "b"}}}),
+		raw(bson.D{{Key: "name", Value: "bob"}, {Key: "age", Value: 30}}),
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeDocuments(&out, docs, "table"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"name", "tags", "age"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"alice", `["a","b"]`}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"bob", "30"}, strings.Fields(lines[2]))
+
+	out.Reset()
+	require.NoError(t, writeDocuments(&out, docs, "json"))
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "bob", decoded[1]["name"])
+
+	out.Reset()
+	require.NoError(t, writeDocuments(&out, nil, "json"))
+	assert.Equal(t, "[]\n", out.String())
+}
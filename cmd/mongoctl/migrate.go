@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+)
+
+// runMigrate 迁移子命令，迁移文件格式见 mongo.LoadMigrations
+//
+//	mongoctl migrate create -dir migrations add_email_index
+//	mongoctl migrate status -dir migrations
+//	mongoctl migrate up -dir migrations [-to 20240102150405]
+//	mongoctl migrate down -dir migrations [-steps 1]
+func runMigrate(ctx context.Context, args []string, stdout io.Writer) error {
+	actions := map[string]func(context.Context, []string, io.Writer) error{
+		"create": runMigrateCreate,
+		"status": runMigrateStatus,
+		"up":     runMigrateUp,
+		"down":   runMigrateDown,
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing action, expected one of: up, down, status, create")
+	}
+	action, ok := actions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown action %q, expected one of: up, down, status, create", args[0])
+	}
+	return action(ctx, args[1:], stdout)
+}
+
+// migrateFlags up、down、status 共用的参数
+type migrateFlags struct {
+	conn       connectionFlags
+	dir        string
+	collection string
+}
+
+func (f *migrateFlags) register(fs *flag.FlagSet) {
+	f.conn.register(fs)
+	fs.StringVar(&f.dir, "dir", "migrations", "迁移文件目录")
+	fs.StringVar(&f.collection, "collection", "schema_migrations", "记录已执行迁移的集合")
+}
+
+// migrator 读取迁移文件并连接数据库，调用方负责关闭客户端
+func (f *migrateFlags) migrator(ctx context.Context) (*mongo.Migrator, *mongo.Client, error) {
+	migrations, err := mongo.LoadMigrations(f.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := f.conn.connect(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrator, err := mongo.NewMigrator(client, migrations, &mongo.MigratorOptions{Collection: f.collection})
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return migrator, client, nil
+}
+
+// runMigrateCreate 生成迁移文件骨架，不需要连接数据库
+func runMigrateCreate(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	dir := fs.String("dir", "migrations", "迁移文件目录")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("missing migration name")
+	}
+	path, err := mongo.CreateMigrationFile(*dir, strings.Join(fs.Args(), " "), time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "created %s\n", path)
+	return nil
+}
+
+// runMigrateStatus 输出已执行和待执行的迁移
+func runMigrateStatus(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("migrate status", flag.ContinueOnError)
+	var f migrateFlags
+	f.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	migrator, client, err := f.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return writeMigrationStatus(stdout, statuses)
+}
+
+// runMigrateUp 执行待执行的迁移
+func runMigrateUp(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	var f migrateFlags
+	f.register(fs)
+	target := fs.String("to", "", "只执行版本不大于该值的迁移，为空时执行全部")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	migrator, client, err := f.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	applied, err := migrator.Up(ctx, *target)
+	for _, version := range applied {
+		fmt.Fprintf(stdout, "applied %s\n", version)
+	}
+	if err == nil && len(applied) == 0 {
+		fmt.Fprintln(stdout, "no pending migrations")
+	}
+	return err
+}
+
+// runMigrateDown 回滚最近执行的迁移
+func runMigrateDown(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	var f migrateFlags
+	f.register(fs)
+	steps := fs.Int("steps", 1, "回滚的迁移个数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *steps <= 0 {
+		return fmt.Errorf("-steps must be positive")
+	}
+	migrator, client, err := f.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	reverted, err := migrator.Down(ctx, *steps)
+	for _, version := range reverted {
+		fmt.Fprintf(stdout, "reverted %s\n", version)
+	}
+	if err == nil && len(reverted) == 0 {
+		fmt.Fprintln(stdout, "no applied migrations")
+	}
+	return err
+}
+
+func writeMigrationStatus(w io.Writer, statuses []mongo.MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "version\tstatus\tapplied_at\tdescription")
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Local().Format(time.DateTime)
+		}
+		if s.Missing {
+			state = "missing"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Version, state, appliedAt, s.Description)
+	}
+	return tw.Flush()
+}
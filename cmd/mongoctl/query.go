@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// runQuery 对集合执行查询、聚合和写操作，参数使用 Extended JSON，以 @ 开头时从文件读取
+//
+//	mongoctl query find -collection users -filter '{"status": "active"}' -sort -created_at -limit 5
+//	mongoctl query count -collection users -filter '{"created_at": {"$gte": {"$date": "2024-01-01T00:00:00Z"}}}'
+//	mongoctl query aggregate -collection orders -pipeline @pipeline.json -format table
+//	mongoctl query insert -collection users -doc '[{"username": "alice"}, {"username": "bob"}]'
+//	mongoctl query update -collection users -filter '{"username": "alice"}' -update '{"$set": {"active": false}}'
+//	mongoctl query delete -collection sessions -filter '{"expired": true}'
+func runQuery(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing action, expected one of: %s", strings.Join(queryActions, ", "))
+	}
+	action := args[0]
+	known := false
+	for _, a := range queryActions {
+		known = known || a == action
+	}
+	if !known {
+		return fmt.Errorf("unknown action %q, expected one of: %s", action, strings.Join(queryActions, ", "))
+	}
+
+	fs := flag.NewFlagSet("query "+action, flag.ContinueOnError)
+	var conn connectionFlags
+	conn.register(fs)
+	collection := fs.String("collection", "", "集合名称")
+	filterFlag := fs.String("filter", "{}", "查询条件（Extended JSON）")
+	projectionFlag := fs.String("projection", "", "find 返回的字段，例如 {\"name\": 1}")
+	sortFlag := fs.String("sort", "", "find 的排序，例如 -created_at,name")
+	limit := fs.Int64("limit", 20, "find 返回的最大文档数，0 表示不限制")
+	skip := fs.Int64("skip", 0, "find 跳过的文档数")
+	pipelineFlag := fs.String("pipeline", "", "aggregate 的聚合管道（Extended JSON 数组）")
+	docFlag := fs.String("doc", "", "insert 的文档或文档数组")
+	updateFlag := fs.String("update", "", "update 的更新操作")
+	all := fs.Bool("all", false, "允许 update、delete 使用空条件作用于整个集合")
+	format := fs.String("format", "json", "输出格式：json 或 table")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *collection == "" {
+		return fmt.Errorf("-collection is required")
+	}
+	if *format != "json" && *format != "table" {
+		return fmt.Errorf("unknown format %q, expected json or table", *format)
+	}
+	filter, err := parseExtJSONDocument(*filterFlag)
+	if err != nil {
+		return fmt.Errorf("invalid -filter: %w", err)
+	}
+
+	// 连接前先校验各操作的参数，避免参数错误时等待连接超时
+	var (
+		findOpts  = options.Find().SetLimit(*limit).SetSkip(*skip)
+		pipeline  []bson.M
+		documents []interface{}
+		update    bson.M
+	)
+	switch action {
+	case "find":
+		if *projectionFlag != "" {
+			projection, err := parseExtJSONDocument(*projectionFlag)
+			if err != nil {
+				return fmt.Errorf("invalid -projection: %w", err)
+			}
+			findOpts.SetProjection(projection)
+		}
+		if *sortFlag != "" {
+			sort, err := mongo.ParseSort(*sortFlag)
+			if err != nil {
+				return fmt.Errorf("invalid -sort: %w", err)
+			}
+			findOpts.SetSort(sort)
+		}
+	case "aggregate":
+		if pipeline, err = parsePipeline(*pipelineFlag); err != nil {
+			return fmt.Errorf("invalid -pipeline: %w", err)
+		}
+	case "insert":
+		if documents, err = parseDocuments(*docFlag); err != nil {
+			return fmt.Errorf("invalid -doc: %w", err)
+		}
+	case "update":
+		if update, err = parseExtJSONDocument(*updateFlag); err != nil {
+			return fmt.Errorf("invalid -update: %w", err)
+		}
+	}
+
+	client, err := conn.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	coll := mongo.NewCollection(client, *collection)
+
+	var massWrite []*mongo.MassWriteOptions
+	if *all {
+		massWrite = append(massWrite, mongo.AllowFullCollection())
+	}
+	switch action {
+	case "find":
+		docs, err := coll.FindRaw(ctx, filter, findOpts)
+		if err != nil {
+			return err
+		}
+		return writeDocuments(stdout, docs, *format)
+	case "aggregate":
+		docs, err := coll.AggregateRaw(ctx, pipeline)
+		if err != nil {
+			return err
+		}
+		return writeDocuments(stdout, docs, *format)
+	case "count":
+		n, err := coll.Count(ctx, filter)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, n)
+	case "insert":
+		result, err := coll.InsertMany(ctx, documents)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "inserted %d\n", len(result.InsertedIDs))
+	case "update":
+		result, err := coll.UpdateMany(ctx, filter, update, massWrite...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "matched %d, modified %d\n", result.MatchedCount, result.ModifiedCount)
+	case "delete":
+		result, err := coll.DeleteMany(ctx, filter, massWrite...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "deleted %d\n", result.DeletedCount)
+	}
+	return nil
+}
+
+// queryActions query 支持的操作
+var queryActions = []string{"find", "count", "aggregate", "insert", "update", "delete"}
+
+// readArgument 以 @ 开头的参数从文件读取
+func readArgument(s string) ([]byte, error) {
+	if path, ok := strings.CutPrefix(s, "@"); ok {
+		return os.ReadFile(path)
+	}
+	return []byte(s), nil
+}
+
+// parseExtJSONDocument 解析 Extended JSON 文档，支持 {"$oid": ...}、{"$date": ...} 等类型
+func parseExtJSONDocument(s string) (bson.M, error) {
+	data, err := readArgument(s)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.UnmarshalExtJSON(data, false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// parseExtJSONArray 解析 Extended JSON 数组，元素保持为 bson.D 以保留字段顺序（例如 $sort 阶段）
+func parseExtJSONArray(s string) ([]bson.D, error) {
+	data, err := readArgument(s)
+	if err != nil {
+		return nil, err
+	}
+	var wrapper struct {
+		Items []bson.D `bson:"items"`
+	}
+	data = append(append([]byte(`{"items": `), bytes.TrimSpace(data)...), '}')
+	if err := bson.UnmarshalExtJSON(data, false, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Items, nil
+}
+
+// parsePipeline 解析聚合管道，每个阶段必须只有一个 $ 开头的字段
+func parsePipeline(s string) ([]bson.M, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, fmt.Errorf("pipeline is empty")
+	}
+	stages, err := parseExtJSONArray(s)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := make([]bson.M, 0, len(stages))
+	for i, stage := range stages {
+		if len(stage) != 1 || !strings.HasPrefix(stage[0].Key, "$") {
+			return nil, fmt.Errorf("stage %d must have exactly one $ operator", i+1)
+		}
+		pipeline = append(pipeline, bson.M{stage[0].Key: stage[0].Value})
+	}
+	return pipeline, nil
+}
+
+// parseDocuments 解析单个文档或文档数组
+func parseDocuments(s string) ([]interface{}, error) {
+	data, err := readArgument(s)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("document is empty")
+	}
+	if data[0] != '[' {
+		doc, err := parseExtJSONDocument(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{doc}, nil
+	}
+	items, err := parseExtJSONArray(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("document array is empty")
+	}
+	docs := make([]interface{}, len(items))
+	for i, item := range items {
+		docs[i] = item
+	}
+	return docs, nil
+}
+
+// writeDocuments 以 JSON 数组（Relaxed Extended JSON）或表格输出文档
+func writeDocuments(w io.Writer, docs []bson.Raw, format string) error {
+	if format == "table" {
+		return writeDocumentTable(w, docs)
+	}
+	if len(docs) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	fmt.Fprintln(w, "[")
+	for i, doc := range docs {
+		data, err := bson.MarshalExtJSONIndent(doc, false, false, "  ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format document: %w", err)
+		}
+		sep := ","
+		if i == len(docs)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(w, "  %s%s\n", data, sep)
+	}
+	_, err := fmt.Fprintln(w, "]")
+	return err
+}
+
+// writeDocumentTable 以顶层字段为列输出表格，列按首次出现的顺序排列，嵌套值以紧凑 JSON 显示
+func writeDocumentTable(w io.Writer, docs []bson.Raw) error {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, doc := range docs {
+		elements, err := doc.Elements()
+		if err != nil {
+			return fmt.Errorf("failed to read document: %w", err)
+		}
+		for _, e := range elements {
+			if key := e.Key(); !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, doc := range docs {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = formatCell(doc.Lookup(column))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatCell(v bson.RawValue) string {
+	switch v.Type {
+	case 0:
+		return ""
+	case bsontype.String:
+		return v.StringValue()
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex()
+	case bsontype.DateTime:
+		return v.Time().UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, false, false)
+	if err != nil {
+		return v.String()
+	}
+	// 去掉包装文档 {"v":...}
+	return strings.TrimSuffix(strings.TrimPrefix(string(data), `{"v":`), "}")
+}
@@ -0,0 +1,398 @@
+// Command mongofields 根据结构体的 bson 标签生成类型安全的字段名常量
+//
+//	//go:generate go run github.com/JustinRoc/mongodbL/cmd/mongofields -type User,Article -out ./fields
+//
+// 每个类型生成一个 <类型名小写>fields 包，例如 userfields.Email、userfields.ProfileFirstName，
+// 嵌套结构体展开为点号路径，bson:",inline" 的嵌入结构体展开到当前层级
+// 在结构体注释中添加指令可以生成复合键函数，- 表示降序：
+//
+//	//mongofields:compound status,-created_at
+//
+// 生成 articlefields.StatusCreatedAt()，返回 bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}}
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// compoundDirective 复合键指令前缀
+const compoundDirective = "//mongofields:compound "
+
+func main() {
+	typeNames := flag.String("type", "", "逗号分隔的结构体名称，为空时处理所有带 bson 标签的结构体")
+	dir := flag.String("dir", ".", "结构体所在的包目录")
+	out := flag.String("out", ".", "生成包的输出目录")
+	flag.Parse()
+
+	if err := run(*dir, *out, splitList(*typeNames)); err != nil {
+		log.Fatalf("mongofields: %v", err)
+	}
+}
+
+// run 解析 dir 中的结构体并为每个类型生成字段包
+func run(dir, out string, typeNames []string) error {
+	pkg, err := parsePackage(dir)
+	if err != nil {
+		return err
+	}
+	if len(typeNames) == 0 {
+		typeNames = pkg.taggedTypes()
+	}
+	for _, name := range typeNames {
+		m, err := pkg.model(name)
+		if err != nil {
+			return err
+		}
+		src, err := generate(m)
+		if err != nil {
+			return err
+		}
+		pkgDir := filepath.Join(out, m.Package)
+		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(pkgDir, "fields_gen.go"), src, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourcePackage 源码包中的结构体定义
+type sourcePackage struct {
+	structs map[string]*ast.StructType
+	docs    map[string]*ast.CommentGroup
+	order   []string
+}
+
+// parsePackage 解析目录下的非测试 Go 文件
+func parsePackage(dir string) (*sourcePackage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	pkg := &sourcePackage{
+		structs: map[string]*ast.StructType{},
+		docs:    map[string]*ast.CommentGroup{},
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.addFile(file)
+	}
+	return pkg, nil
+}
+
+// addFile 收集文件中的结构体类型
+func (p *sourcePackage) addFile(file *ast.File) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			p.structs[ts.Name.Name] = st
+			p.order = append(p.order, ts.Name.Name)
+			if ts.Doc != nil {
+				p.docs[ts.Name.Name] = ts.Doc
+			} else if len(gen.Specs) == 1 {
+				p.docs[ts.Name.Name] = gen.Doc
+			}
+		}
+	}
+}
+
+// taggedTypes 返回所有直接带 bson 标签的导出结构体
+func (p *sourcePackage) taggedTypes() []string {
+	var names []string
+	for _, name := range p.order {
+		if !ast.IsExported(name) {
+			continue
+		}
+		for _, f := range p.structs[name].Fields.List {
+			if _, ok := bsonTag(f); ok {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// field 生成的字段常量
+type field struct {
+	Name string
+	Path string
+}
+
+// compoundKey 复合键中的一个字段
+type compoundKey struct {
+	Const string
+	Path  string
+	Order int
+}
+
+// compound 复合键函数
+type compound struct {
+	Name string
+	Keys []compoundKey
+}
+
+// model 一个类型的生成内容
+type model struct {
+	Type      string
+	Package   string
+	Fields    []field
+	Compounds []compound
+}
+
+// model 收集类型的字段和复合键
+func (p *sourcePackage) model(name string) (*model, error) {
+	st, ok := p.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("struct %s not found", name)
+	}
+	m := &model{Type: name, Package: strings.ToLower(name) + "fields"}
+	if err := p.collect(st, "", "", map[string]bool{name: true}, &m.Fields); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	byName := map[string]string{}
+	byPath := map[string]string{}
+	for _, f := range m.Fields {
+		if existing, ok := byName[f.Name]; ok {
+			return nil, fmt.Errorf("%s: fields %s and %s both map to constant %s", name, existing, f.Path, f.Name)
+		}
+		byName[f.Name] = f.Path
+		byPath[f.Path] = f.Name
+	}
+
+	if doc := p.docs[name]; doc != nil {
+		for _, comment := range doc.List {
+			if !strings.HasPrefix(comment.Text, compoundDirective) {
+				continue
+			}
+			c, err := parseCompound(strings.TrimPrefix(comment.Text, compoundDirective), byPath)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if existing, ok := byName[c.Name]; ok {
+				return nil, fmt.Errorf("%s: compound key %s conflicts with field %s", name, c.Name, existing)
+			}
+			byName[c.Name] = c.Name
+			m.Compounds = append(m.Compounds, c)
+		}
+	}
+	return m, nil
+}
+
+// collect 递归收集结构体字段，visiting 用于避免自引用类型无限展开
+func (p *sourcePackage) collect(st *ast.StructType, namePrefix, pathPrefix string, visiting map[string]bool, fields *[]field) error {
+	for _, f := range st.Fields.List {
+		tag, _ := bsonTag(f)
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+
+		names := f.Names
+		if len(names) == 0 {
+			// 嵌入字段
+			ident := typeIdent(f.Type)
+			if ident == "" {
+				continue
+			}
+			if contains(parts[1:], "inline") {
+				if err := p.collectNamed(ident, namePrefix, pathPrefix, visiting, fields); err != nil {
+					return err
+				}
+				continue
+			}
+			names = []*ast.Ident{ast.NewIdent(ident)}
+		}
+
+		for _, ident := range names {
+			if !ast.IsExported(ident.Name) {
+				continue
+			}
+			bsonName := parts[0]
+			if bsonName == "" || len(f.Names) > 1 {
+				bsonName = strings.ToLower(ident.Name)
+			}
+			name := namePrefix + ident.Name
+			path := joinPath(pathPrefix, bsonName)
+			*fields = append(*fields, field{Name: name, Path: path})
+
+			if err := p.collectType(f.Type, name, path, visiting, fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectType 展开匿名结构体、同一包中的结构体及其指针和切片
+func (p *sourcePackage) collectType(expr ast.Expr, namePrefix, pathPrefix string, visiting map[string]bool, fields *[]field) error {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return p.collectType(t.X, namePrefix, pathPrefix, visiting, fields)
+	case *ast.ArrayType:
+		return p.collectType(t.Elt, namePrefix, pathPrefix, visiting, fields)
+	case *ast.StructType:
+		return p.collect(t, namePrefix, pathPrefix, visiting, fields)
+	case *ast.Ident:
+		return p.collectNamed(t.Name, namePrefix, pathPrefix, visiting, fields)
+	}
+	return nil
+}
+
+// collectNamed 展开同一包中的具名结构体
+func (p *sourcePackage) collectNamed(typeName, namePrefix, pathPrefix string, visiting map[string]bool, fields *[]field) error {
+	st, ok := p.structs[typeName]
+	if !ok || visiting[typeName] {
+		return nil
+	}
+	visiting[typeName] = true
+	defer delete(visiting, typeName)
+	return p.collect(st, namePrefix, pathPrefix, visiting, fields)
+}
+
+// parseCompound 解析复合键指令，例如 status,-created_at
+func parseCompound(spec string, byPath map[string]string) (compound, error) {
+	var c compound
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		key := compoundKey{Order: 1}
+		if strings.HasPrefix(part, "-") {
+			key.Order = -1
+			part = part[1:]
+		}
+		name, ok := byPath[part]
+		if !ok {
+			return c, fmt.Errorf("compound key references unknown field %q", part)
+		}
+		key.Const = name
+		key.Path = part
+		c.Name += name
+		c.Keys = append(c.Keys, key)
+	}
+	if len(c.Keys) < 2 {
+		return c, fmt.Errorf("compound key %q needs at least two fields", spec)
+	}
+	return c, nil
+}
+
+// bsonTag 返回字段的 bson 标签
+func bsonTag(f *ast.Field) (string, bool) {
+	if f.Tag == nil {
+		return "", false
+	}
+	raw, err := strconv.Unquote(f.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(raw).Lookup("bson")
+}
+
+// typeIdent 返回嵌入字段的类型名，其它包中的类型返回选择器名
+func typeIdent(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return typeIdent(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+var fileTemplate = template.Must(template.New("fields").Parse(`// Code generated by mongofields. DO NOT EDIT.
+
+// Package {{.Package}} {{.Type}} 文档的字段名
+package {{.Package}}
+{{if .Compounds}}
+import "go.mongodb.org/mongo-driver/bson"
+{{end}}
+// {{.Type}} 文档字段
+const (
+{{- range .Fields}}
+	{{.Name}} = {{printf "%q" .Path}}
+{{- end}}
+)
+{{range .Compounds}}
+// {{.Name}} 复合键 { {{- range $i, $k := .Keys}}{{if $i}}, {{end}}{{$k.Path}}: {{$k.Order}}{{end -}} }
+func {{.Name}}() bson.D {
+	return bson.D{ {{- range $i, $k := .Keys}}{{if $i}}, {{end}}{Key: {{$k.Const}}, Value: {{$k.Order}}}{{end -}} }
+}
+{{end}}`))
+
+// generate 生成并格式化源码
+func generate(m *model) ([]byte, error) {
+	if len(m.Fields) == 0 {
+		return nil, errors.New(m.Type + " has no fields")
+	}
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, m); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code for %s: %w", m.Type, err)
+	}
+	return src, nil
+}
+
+// splitList 拆分逗号分隔的列表
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// joinPath 拼接点号路径
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// contains 检查字符串切片是否包含指定字符串
+func contains(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `package models
+
+type Base struct {
+	ID string ` + "`bson:\"_id,omitempty\"`" + `
+}
+
+type Address struct {
+	City string ` + "`bson:\"city\"`" + `
+}
+
+// Order 订单
+//
+//mongofields:compound status,-shipping.city
+type Order struct {
+	Base     ` + "`bson:\",inline\"`" + `
+	Status   string   ` + "`bson:\"status\"`" + `
+	Shipping *Address ` + "`bson:\"shipping\"`" + `
+	Items    []struct {
+		SKU string ` + "`bson:\"sku\"`" + `
+	} ` + "`bson:\"items\"`" + `
+	Parent   *Order ` + "`bson:\"parent\"`" + `
+	Secret   string ` + "`bson:\"-\"`" + `
+	internal string
+}
+`
+
+func parseTestPackage(t *testing.T, src string) *sourcePackage {
+	file, err := parser.ParseFile(token.NewFileSet(), "models.go", src, parser.ParseComments)
+	require.NoError(t, err)
+	pkg := &sourcePackage{structs: map[string]*ast.StructType{}, docs: map[string]*ast.CommentGroup{}}
+	pkg.addFile(file)
+	return pkg
+}
+
+func TestModel(t *testing.T) {
+	pkg := parseTestPackage(t, testSource)
+	assert.Equal(t, []string{"Base", "Address", "Order"}, pkg.taggedTypes())
+
+	m, err := pkg.model("Order")
+	require.NoError(t, err)
+	assert.Equal(t, "orderfields", m.Package)
+	assert.Equal(t, []field{
+		{Name: "ID", Path: "_id"},
+		{Name: "Status", Path: "status"},
+		{Name: "Shipping", Path: "shipping"},
+		{Name: "ShippingCity", Path: "shipping.city"},
+		{Name: "Items", Path: "items"},
+		{Name: "ItemsSKU", Path: "items.sku"},
+		{Name: "Parent", Path: "parent"},
+	}, m.Fields)
+	require.Len(t, m.Compounds, 1)
+	assert.Equal(t, "StatusShippingCity", m.Compounds[0].Name)
+
+	src, err := generate(m)
+	require.NoError(t, err)
+	code := string(src)
+	assert.True(t, strings.HasPrefix(code, "// Code generated by mongofields. DO NOT EDIT."))
+	assert.Contains(t, code, `ShippingCity = "shipping.city"`)
+	assert.Contains(t, code, "return bson.D{{Key: Status, Value: 1}, {Key: ShippingCity, Value: -1}}")
+}
+
+func TestModelErrors(t *testing.T) {
+	pkg := parseTestPackage(t, testSource)
+	_, err := pkg.model("Missing")
+	assert.Error(t, err)
+
+	bad := parseTestPackage(t, strings.Replace(testSource, "-shipping.city", "unknown", 1))
+	_, err = bad.model("Order")
+	assert.ErrorContains(t, err, "unknown field")
+}
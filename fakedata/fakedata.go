@@ -0,0 +1,485 @@
+// Package fakedata 生成逼真的测试数据，用于压测和填充演示环境
+//
+// 同一个种子总是生成相同的数据（包括 ObjectID 和时间），便于复现问题：
+//
+//	g := fakedata.New(42)
+//	users := g.Users(1000)
+//	categories := g.Categories(20)
+//	articles := g.Articles(5000, users, categories)
+//
+// 自定义结构体通过 fake 标签描述字段内容，参见 Generator.Fill
+package fakedata
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// epoch 生成时间的基准，生成的时间落在它之前的一年内
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Generator 确定性的数据生成器，不能并发使用
+type Generator struct {
+	rng *rand.Rand
+	seq uint32
+}
+
+// New 使用指定种子创建生成器
+func New(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Intn 返回 [0, n) 内的随机整数
+func (g *Generator) Intn(n int) int {
+	return g.rng.Intn(n)
+}
+
+// IntRange 返回 [lo, hi] 内的随机整数
+func (g *Generator) IntRange(lo, hi int) int {
+	if hi <= lo {
+		return lo
+	}
+	return lo + g.rng.Intn(hi-lo+1)
+}
+
+// Pick 随机返回一个元素
+func (g *Generator) Pick(values []string) string {
+	return values[g.rng.Intn(len(values))]
+}
+
+// ObjectID 生成确定性的 ObjectID，时间部分递增，保证按 _id 排序与生成顺序一致
+func (g *Generator) ObjectID() primitive.ObjectID {
+	var id primitive.ObjectID
+	g.seq++
+	binary.BigEndian.PutUint32(id[0:4], uint32(epoch.Unix())-365*24*3600+g.seq)
+	g.rng.Read(id[4:8])
+	binary.BigEndian.PutUint32(id[8:12], g.seq)
+	return id
+}
+
+// Time 返回基准时间之前一年内的随机时间
+func (g *Generator) Time() time.Time {
+	return epoch.Add(-time.Duration(g.rng.Int63n(int64(365 * 24 * time.Hour)))).Truncate(time.Millisecond)
+}
+
+// FirstName 返回英文名
+func (g *Generator) FirstName() string { return g.Pick(firstNames) }
+
+// LastName 返回英文姓
+func (g *Generator) LastName() string { return g.Pick(lastNames) }
+
+// Username 返回形如 alice.smith42 的用户名
+func (g *Generator) Username() string {
+	return strings.ToLower(g.FirstName()+"."+g.LastName()) + strconv.Itoa(g.rng.Intn(1000))
+}
+
+// Email 返回 example 域名下的邮箱地址，不会发送到真实用户
+func (g *Generator) Email() string {
+	return g.Username() + "@" + g.Pick(domains)
+}
+
+// Word 返回一个单词
+func (g *Generator) Word() string { return g.Pick(words) }
+
+// Sentence 返回 n 个单词组成的句子，首字母大写并以句号结尾
+func (g *Generator) Sentence(n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = g.Word()
+	}
+	s := strings.Join(parts, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// Paragraph 返回 sentences 个句子组成的段落
+func (g *Generator) Paragraph(sentences int) string {
+	parts := make([]string, sentences)
+	for i := range parts {
+		parts[i] = g.Sentence(g.IntRange(6, 14))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Title 返回标题风格的短语
+func (g *Generator) Title() string {
+	parts := make([]string, g.IntRange(3, 7))
+	for i := range parts {
+		w := g.Word()
+		parts[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(parts, " ")
+}
+
+// URL 返回 example.com 下的地址
+func (g *Generator) URL() string {
+	return fmt.Sprintf("https://example.com/%s/%s-%d", g.Word(), g.Word(), g.rng.Intn(100000))
+}
+
+// Phone 返回格式为 +1-555-xxx-xxxx 的虚构电话号码
+func (g *Generator) Phone() string {
+	return fmt.Sprintf("+1-555-%03d-%04d", g.rng.Intn(1000), g.rng.Intn(10000))
+}
+
+// User 生成一个用户，Password 为占位的 bcrypt 格式字符串
+func (g *Generator) User() *mongo.User {
+	first, last := g.FirstName(), g.LastName()
+	user := &mongo.User{
+		Username: strings.ToLower(first+"."+last) + strconv.Itoa(int(g.seq)),
+		Status:   g.weighted(userStatuses),
+		Password: "$2a$10$" + strings.Repeat("x", 53),
+	}
+	user.ID = g.ObjectID()
+	user.CreatedAt = g.Time()
+	user.UpdatedAt = user.CreatedAt
+	user.Email = user.Username + "@" + g.Pick(domains)
+	user.Profile.FirstName = first
+	user.Profile.LastName = last
+	user.Profile.Avatar = fmt.Sprintf("https://example.com/avatars/%s.png", user.ID.Hex())
+	user.Profile.Bio = g.Sentence(g.IntRange(5, 12))
+	return user
+}
+
+// Users 生成 n 个用户，用户名唯一
+func (g *Generator) Users(n int) []*mongo.User {
+	users := make([]*mongo.User, n)
+	for i := range users {
+		users[i] = g.User()
+	}
+	return users
+}
+
+// Category 生成一个分类，parent 为 nil 时为顶级分类
+func (g *Generator) Category(parent *mongo.Category) *mongo.Category {
+	category := &mongo.Category{
+		Name:        g.Title(),
+		Description: g.Sentence(g.IntRange(6, 12)),
+		Sort:        g.rng.Intn(100),
+		IsActive:    g.rng.Intn(10) > 0,
+	}
+	category.ID = g.ObjectID()
+	category.CreatedAt = g.Time()
+	category.UpdatedAt = category.CreatedAt
+	if parent != nil {
+		id := parent.ID
+		category.ParentID = &id
+	}
+	return category
+}
+
+// Categories 生成 n 个分类，约三分之一为前面某个顶级分类的子分类
+func (g *Generator) Categories(n int) []*mongo.Category {
+	categories := make([]*mongo.Category, 0, n)
+	var roots []*mongo.Category
+	for i := 0; i < n; i++ {
+		var parent *mongo.Category
+		if len(roots) > 0 && g.rng.Intn(3) == 0 {
+			parent = roots[g.rng.Intn(len(roots))]
+		}
+		category := g.Category(parent)
+		if parent == nil {
+			roots = append(roots, category)
+		}
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+// Article 生成一篇文章，author 和 category 可以为 nil
+func (g *Generator) Article(author *mongo.User, category *mongo.Category) *mongo.Article {
+	article := &mongo.Article{
+		Title:    g.Title(),
+		Content:  g.Paragraph(g.IntRange(3, 8)),
+		Status:   g.weighted(articleStatuses),
+		Comments: []primitive.ObjectID{},
+	}
+	article.ID = g.ObjectID()
+	article.CreatedAt = g.Time()
+	article.UpdatedAt = article.CreatedAt.Add(time.Duration(g.rng.Int63n(int64(30 * 24 * time.Hour))))
+	if author != nil {
+		article.AuthorID = author.ID
+	}
+	if category != nil {
+		article.CategoryID = category.ID
+	}
+	tags := make([]string, g.IntRange(1, 4))
+	for i := range tags {
+		tags[i] = g.Word()
+	}
+	article.Tags = tags
+	if article.Status == "published" {
+		// 浏览量呈长尾分布，少数文章非常热门
+		article.ViewCount = int64(g.rng.ExpFloat64() * 500)
+		article.LikeCount = article.ViewCount / int64(g.IntRange(5, 50))
+	}
+	return article
+}
+
+// Articles 生成 n 篇文章，作者和分类从给定的列表中随机选择，列表为空时不设置对应字段
+func (g *Generator) Articles(n int, authors []*mongo.User, categories []*mongo.Category) []*mongo.Article {
+	articles := make([]*mongo.Article, n)
+	for i := range articles {
+		var author *mongo.User
+		if len(authors) > 0 {
+			author = authors[g.rng.Intn(len(authors))]
+		}
+		var category *mongo.Category
+		if len(categories) > 0 {
+			category = categories[g.rng.Intn(len(categories))]
+		}
+		articles[i] = g.Article(author, category)
+	}
+	return articles
+}
+
+// Documents 将生成的文档转换为 InsertMany 使用的切片
+//
+//	_, err := users.InsertMany(ctx, fakedata.Documents(g.Users(1000)))
+func Documents[T any](docs []T) []interface{} {
+	out := make([]interface{}, len(docs))
+	for i, doc := range docs {
+		out[i] = doc
+	}
+	return out
+}
+
+// weightedValue 带权重的候选值
+type weightedValue struct {
+	value  string
+	weight int
+}
+
+var (
+	userStatuses    = []weightedValue{{"active", 80}, {"inactive", 15}, {"banned", 5}}
+	articleStatuses = []weightedValue{{"published", 70}, {"draft", 20}, {"archived", 10}}
+)
+
+func (g *Generator) weighted(values []weightedValue) string {
+	total := 0
+	for _, v := range values {
+		total += v.weight
+	}
+	n := g.rng.Intn(total)
+	for _, v := range values {
+		if n < v.weight {
+			return v.value
+		}
+		n -= v.weight
+	}
+	return values[len(values)-1].value
+}
+
+// Fill 按 fake 标签填充结构体指针 v 的字段，没有标签的字段保持不变，嵌套结构体（包括内嵌字段）递归处理
+//
+//	type Product struct {
+//		ID    primitive.ObjectID `bson:"_id" fake:"objectid"`
+//		Name  string             `bson:"name" fake:"title"`
+//		Price float64            `bson:"price" fake:"float=1,500"`
+//		Tags  []string           `bson:"tags" fake:"slice=1,3;word"`
+//	}
+//
+// 支持的标签：
+//   - 字符串：first_name、last_name、name、username、email、word、sentence、paragraph、title、url、phone、oneof=a|b|c
+//   - 数字：int=min,max（默认 0,100）、float=min,max（默认 0,1）
+//   - 其他：bool、time（time.Time）、objectid（primitive.ObjectID）
+//   - 切片：在上述标签前加 slice=min,max; 生成对应数量的元素，例如 fake:"slice=1,3;word"
+//   - "-"：跳过字段
+func (g *Generator) Fill(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("fakedata: Fill requires a pointer to struct, got %T", v)
+	}
+	return g.fillStruct(rv.Elem())
+}
+
+// Fakes 生成 n 个按 fake 标签填充的 T
+func Fakes[T any](g *Generator, n int) ([]T, error) {
+	out := make([]T, n)
+	for i := range out {
+		if err := g.Fill(&out[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+func (g *Generator) fillStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := field.Tag.Lookup("fake")
+		if tag == "-" {
+			continue
+		}
+		if !ok {
+			if field.Type.Kind() == reflect.Struct && field.Type != timeType {
+				if err := g.fillStruct(v.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := g.fillField(v.Field(i), tag); err != nil {
+			return fmt.Errorf("fakedata: field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func (g *Generator) fillField(v reflect.Value, tag string) error {
+	if spec, rest, ok := strings.Cut(tag, ";"); ok && strings.HasPrefix(spec, "slice=") {
+		if v.Kind() != reflect.Slice {
+			return fmt.Errorf("slice tag on %s", v.Type())
+		}
+		lo, hi, err := parseRange(strings.TrimPrefix(spec, "slice="), 1, 3)
+		if err != nil {
+			return err
+		}
+		n := g.IntRange(int(lo), int(hi))
+		slice := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := g.fillField(slice.Index(i), rest); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	kind, arg, _ := strings.Cut(tag, "=")
+	switch kind {
+	case "int":
+		lo, hi, err := parseRange(arg, 0, 100)
+		if err != nil {
+			return err
+		}
+		n := g.IntRange(int(lo), int(hi))
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(int64(n))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(uint64(n))
+		default:
+			return fmt.Errorf("int tag on %s", v.Type())
+		}
+	case "float":
+		lo, hi, err := parseRange(arg, 0, 1)
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return fmt.Errorf("float tag on %s", v.Type())
+		}
+		v.SetFloat(lo + g.rng.Float64()*(hi-lo))
+	case "bool":
+		if v.Kind() != reflect.Bool {
+			return fmt.Errorf("bool tag on %s", v.Type())
+		}
+		v.SetBool(g.rng.Intn(2) == 0)
+	case "time":
+		if v.Type() != timeType {
+			return fmt.Errorf("time tag on %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(g.Time()))
+	case "objectid":
+		if v.Type() != objectIDType {
+			return fmt.Errorf("objectid tag on %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(g.ObjectID()))
+	default:
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("%s tag on %s", kind, v.Type())
+		}
+		s, err := g.fakeString(kind, arg)
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	}
+	return nil
+}
+
+func (g *Generator) fakeString(kind, arg string) (string, error) {
+	switch kind {
+	case "first_name":
+		return g.FirstName(), nil
+	case "last_name":
+		return g.LastName(), nil
+	case "name":
+		return g.FirstName() + " " + g.LastName(), nil
+	case "username":
+		return g.Username(), nil
+	case "email":
+		return g.Email(), nil
+	case "word":
+		return g.Word(), nil
+	case "sentence":
+		return g.Sentence(g.IntRange(6, 12)), nil
+	case "paragraph":
+		return g.Paragraph(g.IntRange(3, 6)), nil
+	case "title":
+		return g.Title(), nil
+	case "url":
+		return g.URL(), nil
+	case "phone":
+		return g.Phone(), nil
+	case "oneof":
+		if arg == "" {
+			return "", fmt.Errorf("oneof requires values")
+		}
+		return g.Pick(strings.Split(arg, "|")), nil
+	}
+	return "", fmt.Errorf("unknown fake tag %q", kind)
+}
+
+// parseRange 解析 "min,max"，为空时返回默认值
+func parseRange(s string, defLo, defHi float64) (float64, float64, error) {
+	if s == "" {
+		return defLo, defHi, nil
+	}
+	a, b, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	lo, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+	hi, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid range %q: %w", s, err)
+	}
+	if hi < lo {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	return lo, hi, nil
+}
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Henry", "Iris", "Jack",
+		"Kate", "Liam", "Mia", "Noah", "Olivia", "Peter", "Quinn", "Ruby", "Sam", "Tina", "Uma", "Victor",
+		"Wendy", "Xavier", "Yara", "Zoe", "Wei", "Fang", "Lei", "Ming"}
+	lastNames = []string{"Smith", "Johnson", "Brown", "Taylor", "Miller", "Wilson", "Moore", "Clark", "Lewis",
+		"Walker", "Hall", "Young", "King", "Wright", "Green", "Baker", "Adams", "Nelson", "Wang", "Li", "Zhang",
+		"Chen", "Liu", "Yang", "Zhao", "Huang"}
+	domains = []string{"example.com", "example.org", "example.net"}
+	words   = []string{"mongo", "index", "query", "cluster", "shard", "replica", "cache", "stream", "schema",
+		"document", "cursor", "pipeline", "driver", "latency", "backup", "migration", "tenant", "audit",
+		"design", "scale", "cloud", "service", "metric", "release", "feature", "guide", "deploy", "review",
+		"storage", "network", "security", "search", "report", "data", "model", "update", "insight", "sample"}
+)
@@ -0,0 +1,76 @@
+package fakedata
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	a, b := New(7), New(7)
+	usersA, usersB := a.Users(20), b.Users(20)
+	assert.Equal(t, usersA, usersB)
+	assert.Equal(t, a.Articles(10, usersA, a.Categories(5)), b.Articles(10, usersB, b.Categories(5)))
+	assert.NotEqual(t, usersA[0].Username, New(8).User().Username)
+
+	seen := map[string]bool{}
+	for i, user := range usersA {
+		assert.False(t, seen[user.Username], user.Username)
+		seen[user.Username] = true
+		assert.Contains(t, []string{"active", "inactive", "banned"}, user.Status)
+		if i > 0 {
+			assert.Greater(t, user.ID.Hex(), usersA[i-1].ID.Hex())
+		}
+	}
+}
+
+func TestGeneratorRelations(t *testing.T) {
+	g := New(1)
+	users := g.Users(3)
+	categories := g.Categories(10)
+	for _, article := range g.Articles(20, users, categories) {
+		assert.Contains(t, []primitive.ObjectID{users[0].ID, users[1].ID, users[2].ID}, article.AuthorID)
+		assert.False(t, article.CategoryID.IsZero())
+		assert.NotEmpty(t, article.Tags)
+		assert.False(t, article.UpdatedAt.Before(article.CreatedAt))
+	}
+}
+
+type fakeProduct struct {
+	ID      primitive.ObjectID `fake:"objectid"`
+	Name    string             `fake:"title"`
+	Price   float64            `fake:"float=1,500"`
+	Stock   int                `fake:"int=0,10"`
+	Kind    string             `fake:"oneof=book|game"`
+	Tags    []string           `fake:"slice=2,2;word"`
+	Created time.Time          `fake:"time"`
+	Seller  struct {
+		Email string `fake:"email"`
+	}
+	Note string
+}
+
+func TestFill(t *testing.T) {
+	products, err := Fakes[fakeProduct](New(3), 50)
+	require.NoError(t, err)
+	for _, p := range products {
+		assert.False(t, p.ID.IsZero())
+		assert.NotEmpty(t, p.Name)
+		assert.True(t, p.Price >= 1 && p.Price <= 500)
+		assert.True(t, p.Stock >= 0 && p.Stock <= 10)
+		assert.Contains(t, []string{"book", "game"}, p.Kind)
+		assert.Len(t, p.Tags, 2)
+		assert.False(t, p.Created.IsZero())
+		assert.Contains(t, p.Seller.Email, "@example.")
+		assert.Empty(t, p.Note)
+	}
+
+	var bad struct {
+		Count int `fake:"email"`
+	}
+	assert.Error(t, New(1).Fill(&bad))
+	assert.Error(t, New(1).Fill(bad))
+}
@@ -0,0 +1,149 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ViewInfo 视图定义
+type ViewInfo struct {
+	Name     string   `bson:"name" json:"name"`
+	ViewOn   string   `bson:"viewOn" json:"view_on"`
+	Pipeline []bson.M `bson:"pipeline" json:"pipeline"`
+}
+
+// CollectionAdmin 集合管理
+type CollectionAdmin struct {
+	client *Client
+}
+
+// NewCollectionAdmin 创建集合管理器
+func NewCollectionAdmin(client *Client) *CollectionAdmin {
+	return &CollectionAdmin{
+		client: client,
+	}
+}
+
+// CreateView 在 source 集合上创建只读视图，视图的内容由服务端在查询时通过 pipeline 计算
+// 例如只包含已发布文章且不返回正文的视图：
+//
+//	admin.CreateView(ctx, "published_articles", "articles", []bson.M{
+//		{"$match": bson.M{"status": "published"}},
+//		{"$project": ExcludeFields("content")},
+//	})
+//
+// 视图可以像普通集合一样通过 NewCollection 查询，但不支持写入
+func (ca *CollectionAdmin) CreateView(ctx context.Context, name, source string, pipeline []bson.M, opts ...*options.CreateViewOptions) error {
+	if err := ca.client.checkWritable("create", name); err != nil {
+		return err
+	}
+	if pipeline == nil {
+		pipeline = []bson.M{}
+	}
+	if err := ca.client.GetDatabase().CreateView(ctx, name, source, pipeline, opts...); err != nil {
+		return fmt.Errorf("failed to create view %s on %s: %w", name, source, err)
+	}
+	ca.client.logger.InfoContext(ctx, "Created view", "name", name, "source", source)
+	return nil
+}
+
+// DropView 删除视图，name 不是视图时返回错误，避免误删普通集合；视图不存在时不做任何操作
+func (ca *CollectionAdmin) DropView(ctx context.Context, name string) error {
+	if err := ca.client.checkWritable("drop", name); err != nil {
+		return err
+	}
+	collectionType, err := ca.collectionType(ctx, name)
+	if err != nil {
+		return err
+	}
+	if collectionType == "" {
+		return nil
+	}
+	if collectionType != "view" {
+		return fmt.Errorf("%s is not a view", name)
+	}
+	if err := ca.client.GetDatabase().Collection(name).Drop(ctx); err != nil {
+		return fmt.Errorf("failed to drop view %s: %w", name, err)
+	}
+	ca.client.logger.InfoContext(ctx, "Dropped view", "name", name)
+	return nil
+}
+
+// ListViews 列出数据库中的所有视图
+func (ca *CollectionAdmin) ListViews(ctx context.Context) ([]ViewInfo, error) {
+	cursor, err := ca.client.GetDatabase().ListCollections(ctx, bson.M{"type": "view"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list views: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var specs []struct {
+		Name    string   `bson:"name"`
+		Options ViewInfo `bson:"options"`
+	}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, fmt.Errorf("failed to decode views: %w", err)
+	}
+	views := make([]ViewInfo, 0, len(specs))
+	for _, spec := range specs {
+		view := spec.Options
+		view.Name = spec.Name
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// CreateCappedCollection 创建固定大小的集合，sizeBytes 为集合最大字节数，maxDocuments 大于 0 时同时限制文档数量
+// 写满后最早插入的文档会被覆盖，适合配合 Collection.Tail 实现日志、消息流等场景
+func (ca *CollectionAdmin) CreateCappedCollection(ctx context.Context, name string, sizeBytes, maxDocuments int64) error {
+	if err := ca.client.checkWritable("create", name); err != nil {
+		return err
+	}
+	if sizeBytes <= 0 {
+		return fmt.Errorf("capped collection %s requires a positive size", name)
+	}
+	opts := options.CreateCollection().SetCapped(true).SetSizeInBytes(sizeBytes)
+	if maxDocuments > 0 {
+		opts.SetMaxDocuments(maxDocuments)
+	}
+	if err := ca.client.GetDatabase().CreateCollection(ctx, name, opts); err != nil {
+		return fmt.Errorf("failed to create capped collection %s: %w", name, err)
+	}
+	ca.client.logger.InfoContext(ctx, "Created capped collection", "name", name, "size", sizeBytes, "max", maxDocuments)
+	return nil
+}
+
+// IsCapped 返回集合是否为固定大小集合
+func (ca *CollectionAdmin) IsCapped(ctx context.Context, name string) (bool, error) {
+	var stats struct {
+		Capped bool `bson:"capped"`
+	}
+	err := ca.client.GetDatabase().RunCommand(ctx, bson.D{{Key: "collStats", Value: name}}).Decode(&stats)
+	if err != nil {
+		return false, fmt.Errorf("failed to get stats of %s: %w", name, err)
+	}
+	return stats.Capped, nil
+}
+
+// collectionType 返回集合类型（collection、view、timeseries），不存在时返回空字符串
+func (ca *CollectionAdmin) collectionType(ctx context.Context, name string) (string, error) {
+	cursor, err := ca.client.GetDatabase().ListCollections(ctx, bson.M{"name": name}, options.ListCollections().SetNameOnly(false))
+	if err != nil {
+		return "", fmt.Errorf("failed to list collections: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var specs []struct {
+		Type string `bson:"type"`
+	}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return "", fmt.Errorf("failed to decode collection specs: %w", err)
+	}
+	if len(specs) == 0 {
+		return "", nil
+	}
+	return specs[0].Type, nil
+}
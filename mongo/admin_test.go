@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// collectionsServer 模拟 listCollections，按过滤条件中的 name 或 type 返回 specs 中的集合
+func collectionsServer(t *testing.T, specs ...bson.M) *fakeServer {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "listCollections" {
+			return nil
+		}
+		var matched []interface{}
+		for _, spec := range specs {
+			if n, ok := cmd.Lookup("filter", "name").StringValueOK(); ok && n != spec["name"] {
+				continue
+			}
+			if typ, ok := cmd.Lookup("filter", "type").StringValueOK(); ok && typ != spec["type"] {
+				continue
+			}
+			matched = append(matched, spec)
+		}
+		return fakeCursor(cmd, matched...)
+	})
+	return server
+}
+
+func TestCollectionAdminCreateView(t *testing.T) {
+	server := newFakeServer(t, false)
+	admin := NewCollectionAdmin(server.client(t))
+
+	pipeline := []bson.M{{"$match": bson.M{"status": "published"}}}
+	require.NoError(t, admin.CreateView(t.Context(), "published_articles", "articles", pipeline))
+	require.NoError(t, admin.CreateView(t.Context(), "all_articles", "articles", nil))
+
+	creates := server.Commands("create")
+	require.Len(t, creates, 2)
+	assert.Equal(t, "published_articles", creates[0].Lookup("create").StringValue())
+	assert.Equal(t, "articles", creates[0].Lookup("viewOn").StringValue())
+	assert.Equal(t, "published", creates[0].Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match", "status").StringValue())
+	// nil 管道按空管道发送
+	values, err := creates[1].Lookup("pipeline").Array().Values()
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestCollectionAdminDropView(t *testing.T) {
+	server := collectionsServer(t,
+		bson.M{"name": "articles", "type": "collection"},
+		bson.M{"name": "published_articles", "type": "view", "options": bson.M{"viewOn": "articles"}},
+	)
+	admin := NewCollectionAdmin(server.client(t))
+
+	require.NoError(t, admin.DropView(t.Context(), "published_articles"))
+	drops := server.Commands("drop")
+	require.Len(t, drops, 1)
+	assert.Equal(t, "published_articles", drops[0].Lookup("drop").StringValue())
+
+	// 普通集合不会被误删，不存在的视图不做任何操作
+	assert.ErrorContains(t, admin.DropView(t.Context(), "articles"), "articles is not a view")
+	require.NoError(t, admin.DropView(t.Context(), "missing"))
+	assert.Len(t, server.Commands("drop"), 1)
+}
+
+func TestCollectionAdminListViews(t *testing.T) {
+	server := collectionsServer(t,
+		bson.M{"name": "articles", "type": "collection"},
+		bson.M{"name": "published_articles", "type": "view", "options": bson.M{
+			"viewOn":   "articles",
+			"pipeline": bson.A{bson.M{"$match": bson.M{"status": "published"}}},
+		}},
+	)
+	views, err := NewCollectionAdmin(server.client(t)).ListViews(t.Context())
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, "published_articles", views[0].Name)
+	assert.Equal(t, "articles", views[0].ViewOn)
+	require.Len(t, views[0].Pipeline, 1)
+	assert.Equal(t, bson.M{"status": "published"}, views[0].Pipeline[0]["$match"])
+}
+
+func TestCollectionAdminCappedCollection(t *testing.T) {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "collStats" {
+			return bson.D{{Key: "capped", Value: cmd.Lookup("collStats").StringValue() == "logs"}, {Key: "ok", Value: 1}}
+		}
+		return nil
+	})
+	admin := NewCollectionAdmin(server.client(t))
+
+	require.NoError(t, admin.CreateCappedCollection(t.Context(), "logs", 1<<20, 1000))
+	require.NoError(t, admin.CreateCappedCollection(t.Context(), "events", 1<<20, 0))
+	assert.Error(t, admin.CreateCappedCollection(t.Context(), "broken", 0, 0))
+
+	creates := server.Commands("create")
+	require.Len(t, creates, 2, "invalid sizes are rejected before contacting the server")
+	assert.True(t, creates[0].Lookup("capped").Boolean())
+	assert.Equal(t, int64(1<<20), creates[0].Lookup("size").AsInt64())
+	assert.Equal(t, int64(1000), creates[0].Lookup("max").AsInt64())
+	_, hasMax := creates[1].Lookup("max").AsInt64OK()
+	assert.False(t, hasMax, "max is only set when positive")
+
+	capped, err := admin.IsCapped(t.Context(), "logs")
+	require.NoError(t, err)
+	assert.True(t, capped)
+	capped, err = admin.IsCapped(t.Context(), "users")
+	require.NoError(t, err)
+	assert.False(t, capped)
+}
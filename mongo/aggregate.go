@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AggregateAs 执行聚合查询并将结果解码为指定类型
+// 例如：stats, err := AggregateAs[TagStat](ctx, articleCol, pipeline)
+func AggregateAs[T any](ctx context.Context, c *Collection, pipeline []bson.M, opts ...*options.AggregateOptions) ([]T, error) {
+	results := []T{}
+	if err := c.Aggregate(ctx, pipeline, &results, opts...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GroupCount 按字段分组统计文档数量，返回 分组值 -> 数量
+// 分组值为 ObjectID 时使用十六进制字符串，字段不存在或为 null 的文档归入空字符串
+func GroupCount(ctx context.Context, c *Collection, field string, filter bson.M) (map[string]int64, error) {
+	return groupCount(ctx, c, field, filter, false)
+}
+
+// GroupCountUnwind 按数组字段的每个元素分组统计文档数量，例如统计每个标签的文章数
+func GroupCountUnwind(ctx context.Context, c *Collection, field string, filter bson.M) (map[string]int64, error) {
+	return groupCount(ctx, c, field, filter, true)
+}
+
+// groupCount 构建分组统计管道并解码为 map
+func groupCount(ctx context.Context, c *Collection, field string, filter bson.M, unwind bool) (map[string]int64, error) {
+	pipeline := groupCountPipeline(field, filter, unwind)
+	rows, err := AggregateAs[bson.M](ctx, c, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return GroupRowsToMap(rows, "count"), nil
+}
+
+// groupCountPipeline 构建分组统计管道
+func groupCountPipeline(field string, filter bson.M, unwind bool) []bson.M {
+	var pipeline []bson.M
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.M{"$match": filter})
+	}
+	if unwind {
+		pipeline = append(pipeline, bson.M{"$unwind": "$" + field})
+	}
+	return append(pipeline, bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}})
+}
+
+// GroupRowsToMap 将 $group 的结果行转换为 _id -> 数值字段 的 map，数值统一转换为 int64
+// 适用于自定义的分组管道，例如 {"$group": {"_id": "$status", "total": {"$sum": "$view_count"}}}
+func GroupRowsToMap(rows []bson.M, valueField string) map[string]int64 {
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[groupKey(row["_id"])] += toInt64(row[valueField])
+	}
+	return result
+}
+
+// groupKey 将分组的 _id 转换为字符串键
+func groupKey(id interface{}) string {
+	switch v := id.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case primitive.ObjectID:
+		return v.Hex()
+	default:
+		return fmt.Sprint(v)
+	}
+}
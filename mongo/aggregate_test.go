@@ -0,0 +1,85 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestGroupRowsToMap(t *testing.T) {
+	id := primitive.NewObjectID()
+	rows := []bson.M{
+		{"_id": "published", "count": int32(3)},
+		{"_id": id, "count": int64(2)},
+		{"_id": nil, "count": 1.0},
+		{"_id": int32(7), "count": int32(4)},
+	}
+	assert.Equal(t, map[string]int64{
+		"published": 3,
+		id.Hex():    2,
+		"":          1,
+		"7":         4,
+	}, GroupRowsToMap(rows, "count"))
+}
+
+func TestGroupCountPipeline(t *testing.T) {
+	assert.Equal(t, []bson.M{
+		{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+	}, groupCountPipeline("status", nil, false))
+
+	assert.Equal(t, []bson.M{
+		{"$match": bson.M{"status": "published"}},
+		{"$unwind": "$tags"},
+		{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+	}, groupCountPipeline("tags", bson.M{"status": "published"}, true))
+}
+
+func TestAggregateOptions(t *testing.T) {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "aggregate" {
+			return fakeCursor(cmd, bson.M{"_id": "go", "count": 2})
+		}
+		return nil
+	})
+	client := server.client(t)
+	articles := NewCollection(client, "articles")
+	ctx := t.Context()
+	pipeline := []bson.M{{"$group": bson.M{"_id": "$tag", "count": bson.M{"$sum": 1}}}}
+	opts := options.Aggregate().SetAllowDiskUse(true).SetMaxTime(30 * time.Second)
+
+	var results []bson.M
+	require.NoError(t, articles.Aggregate(ctx, pipeline, &results, opts))
+	assert.Len(t, results, 1)
+
+	type tagStat struct {
+		Tag   string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	stats, err := AggregateAs[tagStat](ctx, articles, pipeline, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []tagStat{{Tag: "go", Count: 2}}, stats)
+
+	raws, err := articles.AggregateRaw(ctx, pipeline, opts)
+	require.NoError(t, err)
+	assert.Len(t, raws, 1)
+	for _, err := range articles.AggregateRawIter(ctx, pipeline, opts) {
+		require.NoError(t, err)
+	}
+
+	notes := NewTenantCollection(client, "notes", TenantOptions{})
+	require.NoError(t, notes.Aggregate(WithTenant(ctx, "acme"), pipeline, &results, opts))
+
+	// 每个变体都把选项传给服务端
+	commands := server.Commands("aggregate")
+	require.Len(t, commands, 5)
+	for _, cmd := range commands {
+		assert.True(t, cmd.Lookup("allowDiskUse").Boolean())
+		assert.Equal(t, int64(30000), cmd.Lookup("maxTimeMS").AsInt64())
+	}
+}
@@ -0,0 +1,339 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPolicyNotFound 归档策略未注册
+var ErrPolicyNotFound = errors.New("archive policy not found")
+
+// ArchiveAction 过期数据的处理方式
+type ArchiveAction string
+
+const (
+	// ArchiveMove 移动到归档集合
+	ArchiveMove ArchiveAction = "move"
+	// ArchiveDelete 直接删除
+	ArchiveDelete ArchiveAction = "delete"
+)
+
+// ArchivePolicy 单个集合的归档策略，例如超过 2 年的文章移动到 articles_archive
+type ArchivePolicy struct {
+	// Name 策略名称，必填且唯一
+	Name string
+	// Collection 源集合，必填
+	Collection string
+	// AgeField 判断数据年龄的时间字段，默认 created_at
+	AgeField string
+	// OlderThan AgeField 早于当前时间减去该时长的文档视为过期，必填
+	OlderThan time.Duration
+	// Filter 额外的过滤条件，例如只归档已发布的文章
+	Filter bson.M
+	// Action 处理方式，默认 ArchiveMove
+	Action ArchiveAction
+	// ArchiveCollection 归档集合，默认为源集合名加 _archive 后缀
+	ArchiveCollection string
+	// BatchSize 每批处理的文档数，默认 500
+	BatchSize int
+	// Schedule 定时执行的 cron 表达式，通过 Archiver.Schedule 注册到调度器
+	Schedule string
+}
+
+// ArchiveRun 归档执行记录，保存在 archive_runs 集合中，执行过程中按批更新进度
+type ArchiveRun struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Policy     string             `bson:"policy" json:"policy"`
+	Collection string             `bson:"collection" json:"collection"`
+	Action     ArchiveAction      `bson:"action" json:"action"`
+	DryRun     bool               `bson:"dry_run" json:"dry_run"`
+	Cutoff     time.Time          `bson:"cutoff" json:"cutoff"`
+	// Matched 开始执行时匹配的文档数
+	Matched int64 `bson:"matched" json:"matched"`
+	// Archived 已复制到归档集合的文档数
+	Archived int64 `bson:"archived" json:"archived"`
+	// Deleted 已从源集合删除的文档数
+	Deleted    int64      `bson:"deleted" json:"deleted"`
+	Status     string     `bson:"status" json:"status"`
+	Error      string     `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt  time.Time  `bson:"started_at" json:"started_at"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// 归档执行状态
+const (
+	ArchiveStatusRunning = "running"
+	ArchiveStatusSuccess = "success"
+	ArchiveStatusFailed  = "failed"
+)
+
+// ArchiverOptions 归档引擎配置
+type ArchiverOptions struct {
+	// RunsCollection 执行记录集合，默认 archive_runs
+	RunsCollection string
+}
+
+// Archiver 冷数据归档引擎：按策略分批将过期数据移动到归档集合或删除，每次执行都会写入执行记录
+// 移动时先按 _id 覆盖写入归档集合再从源集合删除，中途失败后重新执行不会产生重复数据
+type Archiver struct {
+	client *Client
+	runs   *Collection
+
+	mu       sync.RWMutex
+	policies map[string]ArchivePolicy
+}
+
+// NewArchiver 创建归档引擎
+func NewArchiver(client *Client, opts *ArchiverOptions) *Archiver {
+	o := ArchiverOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.RunsCollection == "" {
+		o.RunsCollection = "archive_runs"
+	}
+	return &Archiver{
+		client:   client,
+		runs:     NewCollection(client, o.RunsCollection),
+		policies: make(map[string]ArchivePolicy),
+	}
+}
+
+// Register 注册归档策略，同名策略会被覆盖
+func (a *Archiver) Register(policy ArchivePolicy) error {
+	if policy.Name == "" || policy.Collection == "" {
+		return fmt.Errorf("archive policy requires name and collection")
+	}
+	if policy.OlderThan <= 0 {
+		return fmt.Errorf("archive policy %s requires a positive OlderThan", policy.Name)
+	}
+	if policy.AgeField == "" {
+		policy.AgeField = "created_at"
+	}
+	if policy.Action == "" {
+		policy.Action = ArchiveMove
+	}
+	if policy.Action != ArchiveMove && policy.Action != ArchiveDelete {
+		return fmt.Errorf("archive policy %s has unsupported action %q", policy.Name, policy.Action)
+	}
+	if policy.ArchiveCollection == "" {
+		policy.ArchiveCollection = policy.Collection + "_archive"
+	}
+	if policy.BatchSize <= 0 {
+		policy.BatchSize = 500
+	}
+	if policy.Schedule != "" {
+		if _, err := ParseCron(policy.Schedule, nil); err != nil {
+			return fmt.Errorf("archive policy %s: %w", policy.Name, err)
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.policies[policy.Name] = policy
+	return nil
+}
+
+// Policies 返回已注册的策略，按名称排序
+func (a *Archiver) Policies() []ArchivePolicy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	policies := make([]ArchivePolicy, 0, len(a.policies))
+	for _, policy := range a.policies {
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Name < policies[j].Name })
+	return policies
+}
+
+// Schedule 将配置了 Schedule 的策略注册为调度器任务，任务名为 archive:<策略名>
+func (a *Archiver) Schedule(scheduler *Scheduler) error {
+	for _, policy := range a.Policies() {
+		if policy.Schedule == "" {
+			continue
+		}
+		name := policy.Name
+		err := scheduler.Register("archive:"+name, policy.Schedule, func(ctx context.Context) error {
+			_, err := a.Run(ctx, name, false)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunAll 按名称顺序执行所有策略，某个策略失败时继续执行其它策略，返回合并的错误
+func (a *Archiver) RunAll(ctx context.Context, dryRun bool) ([]*ArchiveRun, error) {
+	var (
+		runs []*ArchiveRun
+		errs []error
+	)
+	for _, policy := range a.Policies() {
+		run, err := a.Run(ctx, policy.Name, dryRun)
+		if run != nil {
+			runs = append(runs, run)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return runs, errors.Join(errs...)
+}
+
+// Run 执行一个策略；dryRun 时只统计匹配的文档数，不修改数据，同样会写入执行记录
+func (a *Archiver) Run(ctx context.Context, name string, dryRun bool) (*ArchiveRun, error) {
+	a.mu.RLock()
+	policy, ok := a.policies[name]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyNotFound, name)
+	}
+
+	source := NewCollection(a.client, policy.Collection)
+	run := &ArchiveRun{
+		ID:         primitive.NewObjectID(),
+		Policy:     policy.Name,
+		Collection: policy.Collection,
+		Action:     policy.Action,
+		DryRun:     dryRun,
+		Cutoff:     time.Now().Add(-policy.OlderThan),
+		Status:     ArchiveStatusRunning,
+		StartedAt:  time.Now(),
+	}
+	filter := archiveFilter(policy, run.Cutoff)
+
+	matched, err := source.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents for archive policy %s: %w", name, err)
+	}
+	run.Matched = matched
+	if _, err := a.runs.collection.InsertOne(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to record archive run: %w", err)
+	}
+
+	if !dryRun && matched > 0 {
+		err = a.archive(ctx, policy, source, filter, run)
+	}
+	a.finish(ctx, run, err)
+	if err != nil {
+		return run, fmt.Errorf("archive policy %s failed: %w", name, err)
+	}
+	return run, nil
+}
+
+// archive 分批处理过期文档，每批处理后更新执行记录中的进度
+func (a *Archiver) archive(ctx context.Context, policy ArchivePolicy, source *Collection, filter bson.M, run *ArchiveRun) error {
+	target := a.client.database.Collection(policy.ArchiveCollection)
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(policy.BatchSize))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		cursor, err := source.collection.Find(ctx, filter, findOpts)
+		if err != nil {
+			return fmt.Errorf("failed to load batch: %w", err)
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			return fmt.Errorf("failed to load batch: %w", err)
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		ids := make([]interface{}, 0, len(docs))
+		models := make([]mongo.WriteModel, 0, len(docs))
+		for _, doc := range docs {
+			ids = append(ids, doc["_id"])
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": doc["_id"]}).
+				SetReplacement(doc).
+				SetUpsert(true))
+		}
+		if policy.Action == ArchiveMove {
+			if _, err := target.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+				return fmt.Errorf("failed to write archive batch: %w", err)
+			}
+			run.Archived += int64(len(docs))
+		}
+		// 删除时带上原过滤条件，避免删除批次读取后被更新为不再过期的文档
+		result, err := source.collection.DeleteMany(ctx, bson.M{"$and": []bson.M{filter, {"_id": bson.M{"$in": ids}}}})
+		if err != nil {
+			return fmt.Errorf("failed to delete archived batch: %w", err)
+		}
+		run.Deleted += result.DeletedCount
+		a.progress(ctx, run)
+
+		if len(docs) < policy.BatchSize {
+			return nil
+		}
+	}
+}
+
+// progress 更新执行记录中的进度，失败时只记录日志
+func (a *Archiver) progress(ctx context.Context, run *ArchiveRun) {
+	_, err := a.runs.collection.UpdateOne(ctx, bson.M{"_id": run.ID}, bson.M{"$set": bson.M{
+		"archived": run.Archived,
+		"deleted":  run.Deleted,
+	}})
+	if err != nil {
+		a.client.logger.ErrorContext(ctx, "Failed to update archive progress", "policy", run.Policy, "err", err)
+	}
+}
+
+// finish 写入执行结果
+func (a *Archiver) finish(ctx context.Context, run *ArchiveRun, runErr error) {
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Status = ArchiveStatusSuccess
+	if runErr != nil {
+		run.Status = ArchiveStatusFailed
+		run.Error = runErr.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultOperationTimeout)
+	defer cancel()
+	if _, err := a.runs.collection.ReplaceOne(ctx, bson.M{"_id": run.ID}, run); err != nil {
+		a.client.logger.ErrorContext(ctx, "Failed to record archive run", "policy", run.Policy, "err", err)
+	}
+}
+
+// History 查询策略的执行记录，按开始时间倒序；name 为空时查询所有策略
+func (a *Archiver) History(ctx context.Context, name string, limit int64) ([]ArchiveRun, error) {
+	filter := bson.M{}
+	if name != "" {
+		filter["policy"] = name
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	var runs []ArchiveRun
+	if err := a.runs.Find(ctx, filter, &runs, opts); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// archiveFilter 构造策略的过期文档过滤条件
+func archiveFilter(policy ArchivePolicy, cutoff time.Time) bson.M {
+	expired := bson.M{policy.AgeField: bson.M{"$lt": cutoff}}
+	if len(policy.Filter) == 0 {
+		return expired
+	}
+	return bson.M{"$and": []bson.M{policy.Filter, expired}}
+}
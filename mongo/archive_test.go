@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestArchiverRegister(t *testing.T) {
+	a := NewArchiver(newLazyClient(t), nil)
+
+	assert.Error(t, a.Register(ArchivePolicy{Name: "x"}))
+	assert.Error(t, a.Register(ArchivePolicy{Name: "x", Collection: "articles"}))
+	assert.Error(t, a.Register(ArchivePolicy{Name: "x", Collection: "articles", OlderThan: time.Hour, Action: "anonymize"}))
+	assert.Error(t, a.Register(ArchivePolicy{Name: "x", Collection: "articles", OlderThan: time.Hour, Schedule: "bad"}))
+
+	require.NoError(t, a.Register(ArchivePolicy{Name: "old-logs", Collection: "logs", OlderThan: time.Hour, Action: ArchiveDelete}))
+	require.NoError(t, a.Register(ArchivePolicy{Name: "old-articles", Collection: "articles", OlderThan: 2 * 365 * 24 * time.Hour}))
+
+	policies := a.Policies()
+	require.Len(t, policies, 2)
+	assert.Equal(t, "old-articles", policies[0].Name)
+	assert.Equal(t, "created_at", policies[0].AgeField)
+	assert.Equal(t, ArchiveMove, policies[0].Action)
+	assert.Equal(t, "articles_archive", policies[0].ArchiveCollection)
+	assert.Equal(t, 500, policies[0].BatchSize)
+
+	_, err := a.Run(context.Background(), "missing", true)
+	assert.True(t, errors.Is(err, ErrPolicyNotFound))
+}
+
+func TestArchiveFilter(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	policy := ArchivePolicy{AgeField: "published_at"}
+	assert.Equal(t, bson.M{"published_at": bson.M{"$lt": cutoff}}, archiveFilter(policy, cutoff))
+
+	policy.Filter = bson.M{"status": "published"}
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"status": "published"},
+		{"published_at": bson.M{"$lt": cutoff}},
+	}}, archiveFilter(policy, cutoff))
+}
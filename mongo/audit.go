@@ -0,0 +1,455 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditOperation 审计记录的操作类型
+type AuditOperation string
+
+const (
+	AuditInsert  AuditOperation = "insert"
+	AuditUpdate  AuditOperation = "update"
+	AuditReplace AuditOperation = "replace"
+	AuditDelete  AuditOperation = "delete"
+)
+
+// AuditMode 审计记录的内容
+type AuditMode int
+
+const (
+	// AuditModeDiff 更新只记录字段变更，插入记录插入后的文档，删除记录删除前的文档
+	AuditModeDiff AuditMode = iota
+	// AuditModeFull 除字段变更外，始终记录修改前后的完整文档
+	AuditModeFull
+)
+
+// AuditChange 单个字段的变更，嵌套字段使用点号路径
+type AuditChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After  interface{} `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditEntry 审计日志
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Collection string             `bson:"collection" json:"collection"`
+	Operation  AuditOperation     `bson:"operation" json:"operation"`
+	DocumentID interface{}        `bson:"document_id" json:"document_id"`
+	Actor      string             `bson:"actor,omitempty" json:"actor,omitempty"`
+	Before     bson.M             `bson:"before,omitempty" json:"before,omitempty"`
+	After      bson.M             `bson:"after,omitempty" json:"after,omitempty"`
+	Changes    []AuditChange      `bson:"changes,omitempty" json:"changes,omitempty"`
+	Timestamp  time.Time          `bson:"timestamp" json:"timestamp"`
+}
+
+// actorContextKey 操作人上下文键
+type actorContextKey struct{}
+
+// WithActor 将操作人写入上下文，审计日志会记录该操作人
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext 从上下文中获取操作人
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	return actor, ok && actor != ""
+}
+
+// AuditOptions 审计配置
+type AuditOptions struct {
+	// Collection 审计日志集合名称，默认 audit_logs
+	Collection string
+	// Mode 记录的内容，默认 AuditModeDiff
+	Mode AuditMode
+	// ActorFunc 从上下文中解析操作人，默认读取 WithActor 写入的值
+	ActorFunc func(ctx context.Context) string
+	// IgnoreFields 计算字段变更时忽略的字段，为 nil 时忽略 updated_at
+	IgnoreFields []string
+	// Retention 审计日志保留时间，大于 0 时 EnsureIndexes 会创建 TTL 索引
+	Retention time.Duration
+	// FailOnError 写入审计日志失败时是否让原操作返回错误，默认只记录警告日志
+	// 在事务中使用时返回错误会使整个事务回滚
+	FailOnError bool
+}
+
+// Auditor 审计器
+// 通过 Collection.WithAuditor 绑定到集合后，该集合的插入、更新、替换和删除都会写入审计日志：
+//
+//	auditor := NewAuditor(client, nil)
+//	articles := auditor.Collection("articles")
+//	_, err := articles.UpdateByID(WithActor(ctx, "user:42"), id, bson.M{"$set": bson.M{"status": "published"}})
+//
+// 审计日志与原操作使用相同的上下文写入，在事务中会随事务一起提交或回滚。
+// 更新和删除需要先读取匹配的文档，UpdateMany/DeleteMany 会读取全部匹配文档，
+// 对批量写入频繁的集合可以通过 Disable 关闭审计
+type Auditor struct {
+	client     *Client
+	collection *mongo.Collection
+	opts       AuditOptions
+	ignore     map[string]bool
+
+	mu       sync.RWMutex
+	disabled map[string]bool
+}
+
+// NewAuditor 创建审计器，opts 为 nil 时使用默认配置
+func NewAuditor(client *Client, opts *AuditOptions) *Auditor {
+	a := &Auditor{
+		client:   client,
+		ignore:   make(map[string]bool),
+		disabled: make(map[string]bool),
+	}
+	if opts != nil {
+		a.opts = *opts
+	}
+	if a.opts.Collection == "" {
+		a.opts.Collection = "audit_logs"
+	}
+	if a.opts.ActorFunc == nil {
+		a.opts.ActorFunc = func(ctx context.Context) string {
+			actor, _ := ActorFromContext(ctx)
+			return actor
+		}
+	}
+	if a.opts.IgnoreFields == nil {
+		a.opts.IgnoreFields = []string{"updated_at"}
+	}
+	for _, field := range a.opts.IgnoreFields {
+		a.ignore[field] = true
+	}
+	a.collection = client.GetCollection(a.opts.Collection)
+	return a
+}
+
+// Collection 返回绑定了审计器的集合
+func (a *Auditor) Collection(collectionName string) *Collection {
+	return NewCollection(a.client, collectionName).WithAuditor(a)
+}
+
+// Enable 开启指定集合的审计，集合默认开启
+func (a *Auditor) Enable(collectionName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.disabled, collectionName)
+}
+
+// Disable 关闭指定集合的审计
+func (a *Auditor) Disable(collectionName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.disabled[collectionName] = true
+}
+
+// Enabled 返回指定集合是否开启审计
+func (a *Auditor) Enabled(collectionName string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return !a.disabled[collectionName]
+}
+
+// EnsureIndexes 创建按文档和按操作人查询审计日志的索引，配置了 Retention 时同时创建 TTL 索引
+func (a *Auditor) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "collection", Value: 1}, {Key: "document_id", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("idx_collection_document_id_timestamp"),
+		},
+		{
+			Keys:    bson.D{{Key: "actor", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("idx_actor_timestamp"),
+		},
+	}
+	if a.opts.Retention > 0 {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys:    bson.D{{Key: "timestamp", Value: 1}},
+			Options: options.Index().SetName("idx_timestamp_ttl").SetExpireAfterSeconds(int32(a.opts.Retention.Seconds())),
+		})
+	}
+	if _, err := a.collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+	return nil
+}
+
+// History 按时间倒序返回文档的审计日志，limit 小于等于 0 时不限制数量
+func (a *Auditor) History(ctx context.Context, collectionName string, documentID interface{}, limit int64) ([]AuditEntry, error) {
+	return a.Find(ctx, bson.M{"collection": collectionName, "document_id": documentID}, limit)
+}
+
+// Find 按时间倒序查询审计日志，limit 小于等于 0 时不限制数量
+func (a *Auditor) Find(ctx context.Context, filter bson.M, limit int64) ([]AuditEntry, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := a.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit entries: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// record 写入审计日志，before 和 after 按 _id 配对
+func (a *Auditor) record(ctx context.Context, collectionName string, op AuditOperation, before, after []bson.M) error {
+	beforeByID := make(map[interface{}]bson.M, len(before))
+	afterByID := make(map[interface{}]bson.M, len(after))
+	ids := make([]interface{}, 0, len(before)+len(after))
+	for _, doc := range before {
+		beforeByID[auditKey(doc["_id"])] = doc
+		ids = append(ids, doc["_id"])
+	}
+	for _, doc := range after {
+		key := auditKey(doc["_id"])
+		afterByID[key] = doc
+		if _, ok := beforeByID[key]; !ok {
+			ids = append(ids, doc["_id"])
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	actor := a.opts.ActorFunc(ctx)
+	entries := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		oldDoc := beforeByID[auditKey(id)]
+		newDoc := afterByID[auditKey(id)]
+		entry := AuditEntry{
+			Collection: collectionName,
+			Operation:  op,
+			DocumentID: id,
+			Actor:      actor,
+			Timestamp:  now,
+		}
+		switch {
+		case oldDoc == nil:
+			// upsert 插入的文档按插入记录
+			entry.Operation = AuditInsert
+			entry.After = newDoc
+		case newDoc == nil:
+			entry.Before = oldDoc
+		default:
+			entry.Changes = diffDocuments(oldDoc, newDoc, a.ignore)
+			if len(entry.Changes) == 0 {
+				continue
+			}
+			if a.opts.Mode == AuditModeFull {
+				entry.Before = oldDoc
+				entry.After = newDoc
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if _, err := a.collection.InsertMany(ctx, entries); err != nil {
+		return fmt.Errorf("failed to write audit entries: %w", err)
+	}
+	return nil
+}
+
+// auditKey 将 _id 转换为可以作为 map 键的值
+func auditKey(id interface{}) interface{} {
+	if id == nil || reflect.TypeOf(id).Comparable() {
+		return id
+	}
+	return fmt.Sprintf("%v", id)
+}
+
+// diffDocuments 计算两个文档之间的字段变更，嵌套文档递归比较，数组整体比较
+func diffDocuments(before, after bson.M, ignore map[string]bool) []AuditChange {
+	var changes []AuditChange
+	collectChanges("", before, after, ignore, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// collectChanges 递归收集字段变更
+func collectChanges(prefix string, before, after bson.M, ignore map[string]bool, changes *[]AuditChange) {
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for key := range before {
+		keys[key] = struct{}{}
+	}
+	for key := range after {
+		keys[key] = struct{}{}
+	}
+
+	for key := range keys {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+		if ignore[field] {
+			continue
+		}
+		oldValue, hasOld := before[key]
+		newValue, hasNew := after[key]
+		oldDoc, oldIsDoc := oldValue.(bson.M)
+		newDoc, newIsDoc := newValue.(bson.M)
+		if oldIsDoc && newIsDoc {
+			collectChanges(field, oldDoc, newDoc, ignore, changes)
+			continue
+		}
+		if hasOld && hasNew && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		*changes = append(*changes, AuditChange{Field: field, Before: oldValue, After: newValue})
+	}
+}
+
+// WithAuditor 返回绑定审计器的集合副本，之后的写操作都会记录审计日志
+func (c *Collection) WithAuditor(auditor *Auditor) *Collection {
+	cp := *c
+	cp.auditor = auditor
+	return &cp
+}
+
+// auditing 返回当前集合是否需要记录审计日志
+func (c *Collection) auditing() bool {
+	return c.auditor != nil && c.auditor.Enabled(c.collection.Name())
+}
+
+// snapshot 审计或版本历史开启时读取过滤条件匹配的文档，many 为 false 时只读取第一个
+func (c *Collection) snapshot(ctx context.Context, filter bson.M, many bool) ([]bson.M, error) {
+	if !c.auditing() && c.revisions == nil {
+		return nil, nil
+	}
+	return c.loadDocuments(ctx, filter, many)
+}
+
+// auditSnapshot 审计开启时读取过滤条件匹配的文档，用于删除操作
+func (c *Collection) auditSnapshot(ctx context.Context, filter bson.M, many bool) ([]bson.M, error) {
+	if !c.auditing() {
+		return nil, nil
+	}
+	return c.loadDocuments(ctx, filter, many)
+}
+
+// loadDocuments 读取过滤条件匹配的原始文档
+func (c *Collection) loadDocuments(ctx context.Context, filter bson.M, many bool) ([]bson.M, error) {
+	opts := options.Find()
+	if !many {
+		opts.SetLimit(1)
+	}
+	cursor, err := c.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load document snapshot: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode document snapshot: %w", err)
+	}
+	return docs, nil
+}
+
+// auditReload 按 _id 重新读取文档，用于获取更新后的文档
+func (c *Collection) auditReload(ctx context.Context, ids []interface{}) ([]bson.M, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return c.loadDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}}, true)
+}
+
+// auditWrite 写操作完成后记录审计日志
+// 更新类操作传入 before，函数会按 _id 重新读取修改后的文档；upsertedID 不为空时一并读取
+func (c *Collection) auditWrite(ctx context.Context, op AuditOperation, before []bson.M, upsertedID interface{}) error {
+	if !c.auditing() {
+		return nil
+	}
+	var after []bson.M
+	if op != AuditDelete {
+		ids := make([]interface{}, 0, len(before)+1)
+		for _, doc := range before {
+			ids = append(ids, doc["_id"])
+		}
+		if upsertedID != nil {
+			ids = append(ids, upsertedID)
+		}
+		var err error
+		if after, err = c.auditReload(ctx, ids); err != nil {
+			return c.auditFailed(ctx, op, err)
+		}
+	}
+	return c.auditDocuments(ctx, op, before, after)
+}
+
+// auditDocuments 记录已知修改前后文档的审计日志
+func (c *Collection) auditDocuments(ctx context.Context, op AuditOperation, before, after []bson.M) error {
+	if !c.auditing() {
+		return nil
+	}
+	if err := c.auditor.record(ctx, c.collection.Name(), op, before, after); err != nil {
+		return c.auditFailed(ctx, op, err)
+	}
+	return nil
+}
+
+// auditFailed 处理审计日志写入失败，FailOnError 关闭时只记录警告
+func (c *Collection) auditFailed(ctx context.Context, op AuditOperation, err error) error {
+	if c.auditor.opts.FailOnError {
+		return err
+	}
+	c.cli.logger.WarnContext(ctx, "Failed to record audit entry", "collection", c.collection.Name(), "operation", op, "err", err)
+	return nil
+}
+
+// auditInserted 将插入的文档转换为审计用的文档，补全驱动生成的 _id
+func auditInserted(document interface{}, insertedID interface{}) (bson.M, error) {
+	doc, err := toBsonM(document)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = insertedID
+	}
+	return doc, nil
+}
+
+// auditInserts 记录插入的文档，ids 为驱动返回的插入 ID，与 documents 一一对应
+func (c *Collection) auditInserts(ctx context.Context, documents []interface{}, ids []interface{}) error {
+	if !c.auditing() {
+		return nil
+	}
+	after := make([]bson.M, 0, len(documents))
+	for i, document := range documents {
+		var insertedID interface{}
+		if i < len(ids) {
+			insertedID = ids[i]
+		}
+		doc, err := auditInserted(document, insertedID)
+		if err != nil {
+			return c.auditFailed(ctx, AuditInsert, err)
+		}
+		after = append(after, doc)
+	}
+	return c.auditDocuments(ctx, AuditInsert, nil, after)
+}
@@ -0,0 +1,43 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	before := bson.M{
+		"_id":        1,
+		"title":      "draft title",
+		"status":     "draft",
+		"tags":       bson.A{"go"},
+		"profile":    bson.M{"bio": "old", "avatar": "a.png"},
+		"updated_at": 1,
+		"removed":    true,
+	}
+	after := bson.M{
+		"_id":        1,
+		"title":      "draft title",
+		"status":     "published",
+		"tags":       bson.A{"go", "mongo"},
+		"profile":    bson.M{"bio": "new", "avatar": "a.png"},
+		"updated_at": 2,
+		"added":      int32(1),
+	}
+
+	changes := diffDocuments(before, after, map[string]bool{"updated_at": true})
+	assert.Equal(t, []AuditChange{
+		{Field: "added", After: int32(1)},
+		{Field: "profile.bio", Before: "old", After: "new"},
+		{Field: "removed", Before: true},
+		{Field: "status", Before: "draft", After: "published"},
+		{Field: "tags", Before: bson.A{"go"}, After: bson.A{"go", "mongo"}},
+	}, changes)
+}
+
+func TestDiffDocumentsUnchanged(t *testing.T) {
+	doc := bson.M{"_id": 1, "name": "same", "nested": bson.M{"a": 1}}
+	assert.Empty(t, diffDocuments(doc, bson.M{"_id": 1, "name": "same", "nested": bson.M{"a": 1}}, nil))
+}
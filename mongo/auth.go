@@ -0,0 +1,103 @@
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 支持的认证机制
+const (
+	AuthMechanismSCRAMSHA1   = "SCRAM-SHA-1"
+	AuthMechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	AuthMechanismX509        = "MONGODB-X509"
+	AuthMechanismAWS         = "MONGODB-AWS"
+	AuthMechanismPLAIN       = "PLAIN" // LDAP 代理认证
+	AuthMechanismGSSAPI      = "GSSAPI"
+)
+
+// AuthConfig 认证配置，避免将凭据拼接在 URI 中
+type AuthConfig struct {
+	// Mechanism 认证机制，为空时由服务端协商（SCRAM）
+	Mechanism string `json:"mechanism,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	// Source 认证数据库，为空时 SCRAM 使用 admin，X.509/AWS/LDAP/Kerberos 使用 $external
+	Source string `json:"source,omitempty"`
+	// MechanismProperties 认证机制附加属性，例如 MONGODB-AWS 的 AWS_SESSION_TOKEN、GSSAPI 的 SERVICE_NAME
+	MechanismProperties map[string]string `json:"mechanism_properties,omitempty"`
+}
+
+// MarshalJSON 序列化时隐藏密码，避免配置被打印到日志时泄露凭据
+func (a AuthConfig) MarshalJSON() ([]byte, error) {
+	type plain AuthConfig
+	masked := plain(a)
+	if masked.Password != "" {
+		masked.Password = "******"
+	}
+	if token, ok := masked.MechanismProperties["AWS_SESSION_TOKEN"]; ok && token != "" {
+		props := make(map[string]string, len(masked.MechanismProperties))
+		for k, v := range masked.MechanismProperties {
+			props[k] = v
+		}
+		props["AWS_SESSION_TOKEN"] = "******"
+		masked.MechanismProperties = props
+	}
+	return json.Marshal(masked)
+}
+
+// buildCredential 根据认证配置构建驱动凭据
+func buildCredential(auth *AuthConfig) (options.Credential, error) {
+	credential := options.Credential{
+		AuthMechanism:           auth.Mechanism,
+		AuthMechanismProperties: auth.MechanismProperties,
+		AuthSource:              auth.Source,
+		Username:                auth.Username,
+		Password:                auth.Password,
+		PasswordSet:             auth.Password != "",
+	}
+
+	switch auth.Mechanism {
+	case "", AuthMechanismSCRAMSHA1, AuthMechanismSCRAMSHA256:
+		if auth.Username == "" {
+			return credential, fmt.Errorf("username is required for %s authentication", mechanismName(auth.Mechanism))
+		}
+	case AuthMechanismX509:
+		if auth.Password != "" {
+			return credential, fmt.Errorf("password must not be set for %s authentication", auth.Mechanism)
+		}
+	case AuthMechanismAWS:
+		// 用户名和密码为空时驱动从环境变量或实例元数据获取 AWS 凭据
+		if (auth.Username == "") != (auth.Password == "") {
+			return credential, fmt.Errorf("username and password must be set together for %s authentication", auth.Mechanism)
+		}
+	case AuthMechanismPLAIN:
+		if auth.Username == "" || auth.Password == "" {
+			return credential, fmt.Errorf("username and password are required for %s authentication", auth.Mechanism)
+		}
+	case AuthMechanismGSSAPI:
+		if auth.Username == "" {
+			return credential, fmt.Errorf("username is required for %s authentication", auth.Mechanism)
+		}
+	default:
+		return credential, fmt.Errorf("unsupported auth mechanism %q", auth.Mechanism)
+	}
+
+	// 外部认证机制的认证数据库固定为 $external
+	if credential.AuthSource == "" {
+		switch auth.Mechanism {
+		case AuthMechanismX509, AuthMechanismAWS, AuthMechanismPLAIN, AuthMechanismGSSAPI:
+			credential.AuthSource = "$external"
+		}
+	}
+	return credential, nil
+}
+
+// mechanismName 返回认证机制的展示名称
+func mechanismName(mechanism string) string {
+	if mechanism == "" {
+		return "SCRAM"
+	}
+	return mechanism
+}
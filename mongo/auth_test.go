@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCredential(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     AuthConfig
+		source   string
+		password bool
+	}{
+		{"scram default", AuthConfig{Username: "app", Password: "secret"}, "", true},
+		{"scram sha256", AuthConfig{Mechanism: AuthMechanismSCRAMSHA256, Username: "app", Password: "secret", Source: "admin"}, "admin", true},
+		{"x509", AuthConfig{Mechanism: AuthMechanismX509}, "$external", false},
+		{"aws from environment", AuthConfig{Mechanism: AuthMechanismAWS}, "$external", false},
+		{"aws keys", AuthConfig{Mechanism: AuthMechanismAWS, Username: "AKIA", Password: "key"}, "$external", true},
+		{"plain", AuthConfig{Mechanism: AuthMechanismPLAIN, Username: "ldap", Password: "secret"}, "$external", true},
+		{"gssapi", AuthConfig{Mechanism: AuthMechanismGSSAPI, Username: "app@EXAMPLE.COM", MechanismProperties: map[string]string{"SERVICE_NAME": "mongodb"}}, "$external", false},
+		{"explicit source", AuthConfig{Mechanism: AuthMechanismPLAIN, Username: "ldap", Password: "secret", Source: "ldap"}, "ldap", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := buildCredential(&tt.auth)
+			require.NoError(t, err)
+			assert.Equal(t, tt.auth.Mechanism, credential.AuthMechanism)
+			assert.Equal(t, tt.auth.Username, credential.Username)
+			assert.Equal(t, tt.auth.MechanismProperties, credential.AuthMechanismProperties)
+			assert.Equal(t, tt.source, credential.AuthSource)
+			assert.Equal(t, tt.password, credential.PasswordSet)
+		})
+	}
+}
+
+func TestBuildCredentialErrors(t *testing.T) {
+	tests := map[string]AuthConfig{
+		"scram without username": {Password: "secret"},
+		"x509 with password":     {Mechanism: AuthMechanismX509, Password: "secret"},
+		"aws username only":      {Mechanism: AuthMechanismAWS, Username: "AKIA"},
+		"aws password only":      {Mechanism: AuthMechanismAWS, Password: "key"},
+		"plain without password": {Mechanism: AuthMechanismPLAIN, Username: "ldap"},
+		"gssapi without user":    {Mechanism: AuthMechanismGSSAPI},
+		"unknown mechanism":      {Mechanism: "MONGODB-CR", Username: "app"},
+	}
+	for name, auth := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildCredential(&auth)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAuthConfigMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(AuthConfig{
+		Mechanism:           AuthMechanismAWS,
+		Username:            "AKIA",
+		Password:            "key",
+		MechanismProperties: map[string]string{"AWS_SESSION_TOKEN": "token"},
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"key"`)
+	assert.NotContains(t, string(data), `"token"`)
+	assert.Contains(t, string(data), "AKIA")
+}
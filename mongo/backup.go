@@ -0,0 +1,545 @@
+package mongo
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrBackupNotFound 备份集不存在或不完整（缺少 manifest.json）
+var ErrBackupNotFound = errors.New("backup not found")
+
+// backupTimeFormat 备份集目录名的时间格式
+const backupTimeFormat = "20060102T150405Z"
+
+// BackupOptions 备份配置
+type BackupOptions struct {
+	// Dir 备份根目录，每次备份在其中创建一个以 UTC 时间命名的备份集目录
+	Dir string
+	// Collections 备份的集合，为空时备份数据库中除 system.* 以外的所有集合
+	Collections []string
+	// Gzip 使用 gzip 压缩集合数据文件
+	Gzip bool
+	// BatchSize 恢复时每批插入的文档数，也是进度回调的间隔，默认 1000
+	BatchSize int
+	// KeepLast 保留最近的备份集个数，0 表示不按个数清理
+	KeepLast int
+	// MaxAge 备份集的最长保留时间，0 表示不按时间清理；最新的备份集总是保留
+	MaxAge time.Duration
+	// Schedule 通过 Schedule 注册定时备份使用的 cron 表达式
+	Schedule string
+	// Progress 每个集合每处理 BatchSize 个文档以及完成时回调
+	Progress func(BackupProgress)
+}
+
+// BackupProgress 备份或恢复进度
+type BackupProgress struct {
+	Collection string
+	Documents  int64
+	// Total 备份开始时的估算文档数，恢复时为备份中的文档数
+	Total int64
+	Done  bool
+}
+
+// BackupCollection 备份集中的一个集合
+type BackupCollection struct {
+	Name      string `json:"name"`
+	File      string `json:"file"`
+	Documents int64  `json:"documents"`
+	Bytes     int64  `json:"bytes"`
+	Indexes   int    `json:"indexes"`
+}
+
+// BackupSet 一次备份，manifest.json 在所有集合写入完成后生成，没有 manifest 的目录视为未完成的备份
+type BackupSet struct {
+	Name        string             `json:"name"`
+	Database    string             `json:"database"`
+	Path        string             `json:"-"`
+	StartedAt   time.Time          `json:"started_at"`
+	FinishedAt  time.Time          `json:"finished_at"`
+	Gzip        bool               `json:"gzip"`
+	Collections []BackupCollection `json:"collections"`
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	// Collections 恢复的集合，为空时恢复备份集中的所有集合
+	Collections []string
+	// Drop 恢复前删除目标集合；否则与已有文档 _id 冲突时恢复失败
+	Drop bool
+	// SkipIndexes 不重建备份中记录的索引
+	SkipIndexes bool
+}
+
+// BackupManager 原生备份与恢复，不需要安装 mongodump：
+// 每个集合按 _id 顺序导出为连续的 BSON 文档（与 mongodump 的 .bson 文件格式相同），索引定义保存在 <集合>.metadata.json 中；
+// 集合逐个导出，备份集不是某一时刻的一致快照，需要一致性时应在从节点或停止写入后备份
+type BackupManager struct {
+	client *Client
+	opts   BackupOptions
+}
+
+// NewBackupManager 创建备份管理器
+func NewBackupManager(client *Client, opts *BackupOptions) *BackupManager {
+	m := &BackupManager{client: client}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.Dir == "" {
+		m.opts.Dir = "backups"
+	}
+	if m.opts.BatchSize <= 0 {
+		m.opts.BatchSize = 1000
+	}
+	return m
+}
+
+// Backup 创建新的备份集，成功后按 KeepLast、MaxAge 清理旧备份集
+func (m *BackupManager) Backup(ctx context.Context) (*BackupSet, error) {
+	started := time.Now().UTC()
+	set := &BackupSet{
+		Name:      started.Format(backupTimeFormat),
+		Database:  m.client.GetDatabaseName(),
+		StartedAt: started,
+		Gzip:      m.opts.Gzip,
+	}
+	set.Path = filepath.Join(m.opts.Dir, set.Name)
+	if err := os.MkdirAll(set.Path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	complete := false
+	defer func() {
+		// 失败的备份不保留部分文件
+		if !complete {
+			os.RemoveAll(set.Path)
+		}
+	}()
+
+	names := m.opts.Collections
+	if len(names) == 0 {
+		var err error
+		names, err = m.client.GetDatabase().ListCollectionNames(ctx, bson.M{
+			"type": "collection",
+			"name": bson.M{"$not": bson.M{"$regex": "^system\\."}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list collections: %w", err)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		collection, err := m.backupCollection(ctx, set.Path, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s: %w", name, err)
+		}
+		set.Collections = append(set.Collections, *collection)
+	}
+
+	set.FinishedAt = time.Now().UTC()
+	if err := writeJSONFile(filepath.Join(set.Path, "manifest.json"), set); err != nil {
+		return nil, err
+	}
+	complete = true
+	if _, err := m.Prune(); err != nil {
+		return set, err
+	}
+	return set, nil
+}
+
+func (m *BackupManager) backupCollection(ctx context.Context, dir, name string) (*BackupCollection, error) {
+	coll := m.client.GetCollection(name)
+	result := &BackupCollection{Name: name, File: url.PathEscape(name) + ".bson"}
+	if m.opts.Gzip {
+		result.File += ".gz"
+	}
+
+	cursor, err := coll.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	var indexes []bson.Raw
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+	result.Indexes = len(indexes)
+	if err := writeBackupMetadata(filepath.Join(dir, url.PathEscape(name)+".metadata.json"), indexes); err != nil {
+		return nil, err
+	}
+
+	total, _ := coll.EstimatedDocumentCount(ctx)
+	file, err := os.Create(filepath.Join(dir, result.File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer file.Close()
+	counter := &countingWriter{w: file}
+	var w io.Writer = counter
+	var gz *gzip.Writer
+	if m.opts.Gzip {
+		gz = gzip.NewWriter(counter)
+		w = gz
+	}
+	buffered := bufio.NewWriterSize(w, 1<<20)
+
+	cursor, err = coll.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		if _, err := buffered.Write(cursor.Current); err != nil {
+			return nil, fmt.Errorf("failed to write backup file: %w", err)
+		}
+		result.Documents++
+		if result.Documents%int64(m.opts.BatchSize) == 0 {
+			m.progress(BackupProgress{Collection: name, Documents: result.Documents, Total: total})
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return nil, fmt.Errorf("failed to write backup file: %w", err)
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync backup file: %w", err)
+	}
+	result.Bytes = counter.n
+	m.progress(BackupProgress{Collection: name, Documents: result.Documents, Total: total, Done: true})
+	return result, nil
+}
+
+// Restore 将备份集恢复到客户端当前的数据库，name 为空时恢复最新的备份集
+func (m *BackupManager) Restore(ctx context.Context, name string, opts *RestoreOptions) (*BackupSet, error) {
+	o := RestoreOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	set, err := m.find(name)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(o.Collections))
+	for _, c := range o.Collections {
+		selected[c] = true
+	}
+
+	restored := *set
+	restored.Collections = nil
+	for _, collection := range set.Collections {
+		if len(selected) > 0 && !selected[collection.Name] {
+			continue
+		}
+		if err := m.restoreCollection(ctx, set.Path, collection, o); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", collection.Name, err)
+		}
+		restored.Collections = append(restored.Collections, collection)
+	}
+	return &restored, nil
+}
+
+func (m *BackupManager) restoreCollection(ctx context.Context, dir string, collection BackupCollection, o RestoreOptions) error {
+	coll := m.client.GetCollection(collection.Name)
+	if o.Drop {
+		if err := coll.Drop(ctx); err != nil {
+			return fmt.Errorf("failed to drop collection: %w", err)
+		}
+	}
+
+	file, err := os.Open(filepath.Join(dir, collection.File))
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(collection.File, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to open backup file: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	reader := bufio.NewReaderSize(r, 1<<20)
+
+	var restored int64
+	batch := make([]interface{}, 0, m.opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := coll.InsertMany(ctx, batch); err != nil {
+			return fmt.Errorf("failed to insert documents: %w", err)
+		}
+		restored += int64(len(batch))
+		batch = batch[:0]
+		m.progress(BackupProgress{Collection: collection.Name, Documents: restored, Total: collection.Documents})
+		return nil
+	}
+	for {
+		doc, err := readBSONDocument(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		batch = append(batch, doc)
+		if len(batch) == m.opts.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if !o.SkipIndexes {
+		if err := m.restoreIndexes(ctx, filepath.Join(dir, url.PathEscape(collection.Name)+".metadata.json"), collection.Name); err != nil {
+			return err
+		}
+	}
+	m.progress(BackupProgress{Collection: collection.Name, Documents: restored, Total: collection.Documents, Done: true})
+	return nil
+}
+
+// restoreIndexes 以 createIndexes 命令重建备份的索引，保留所有索引选项
+func (m *BackupManager) restoreIndexes(ctx context.Context, path, collection string) error {
+	indexes, err := readBackupMetadata(path)
+	if err != nil {
+		return err
+	}
+	specs := bson.A{}
+	for _, index := range indexes {
+		if index.Lookup("name").StringValue() == "_id_" {
+			continue
+		}
+		var spec bson.D
+		if err := bson.Unmarshal(index, &spec); err != nil {
+			return fmt.Errorf("failed to decode index: %w", err)
+		}
+		// v 和 ns 由服务端生成
+		spec = removeKeys(spec, "v", "ns")
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	command := bson.D{{Key: "createIndexes", Value: collection}, {Key: "indexes", Value: specs}}
+	if err := m.client.GetDatabase().RunCommand(ctx, command).Err(); err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+	return nil
+}
+
+// List 返回已完成的备份集，最新的在前
+func (m *BackupManager) List() ([]BackupSet, error) {
+	entries, err := os.ReadDir(m.opts.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	var sets []BackupSet
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(m.opts.Dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(path, "manifest.json"))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+		}
+		var set BackupSet
+		if err := json.Unmarshal(data, &set); err != nil {
+			return nil, fmt.Errorf("failed to parse backup manifest %s: %w", entry.Name(), err)
+		}
+		set.Path = path
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name > sets[j].Name })
+	return sets, nil
+}
+
+func (m *BackupManager) find(name string) (*BackupSet, error) {
+	sets, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range sets {
+		if name == "" || sets[i].Name == name {
+			return &sets[i], nil
+		}
+	}
+	if name == "" {
+		return nil, ErrBackupNotFound
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+}
+
+// Prune 按 KeepLast、MaxAge 删除过期的备份集，返回删除的备份集名称
+func (m *BackupManager) Prune() ([]string, error) {
+	sets, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, set := range expiredBackups(sets, m.opts.KeepLast, m.opts.MaxAge, time.Now()) {
+		if err := os.RemoveAll(set.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", set.Name, err)
+		}
+		removed = append(removed, set.Name)
+	}
+	return removed, nil
+}
+
+// expiredBackups sets 按时间倒序排列，最新的备份集总是保留
+func expiredBackups(sets []BackupSet, keepLast int, maxAge time.Duration, now time.Time) []BackupSet {
+	var expired []BackupSet
+	for i, set := range sets {
+		if i == 0 {
+			continue
+		}
+		if (keepLast > 0 && i >= keepLast) || (maxAge > 0 && now.Sub(set.StartedAt) > maxAge) {
+			expired = append(expired, set)
+		}
+	}
+	return expired
+}
+
+// Schedule 按 Schedule 注册定时备份任务，任务名为 backup:<数据库名>；
+// 调度器的 LockTTL 同时是单次执行的超时时间，应当大于一次备份的耗时
+func (m *BackupManager) Schedule(scheduler *Scheduler) error {
+	if m.opts.Schedule == "" {
+		return fmt.Errorf("backup schedule is not configured")
+	}
+	return scheduler.Register("backup:"+m.client.GetDatabaseName(), m.opts.Schedule, func(ctx context.Context) error {
+		_, err := m.Backup(ctx)
+		return err
+	})
+}
+
+func (m *BackupManager) progress(p BackupProgress) {
+	if m.opts.Progress != nil {
+		m.opts.Progress(p)
+	}
+}
+
+// countingWriter 统计写入文件的字节数（压缩后）
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// maxBSONDocumentSize 读取备份时允许的最大文档长度，比服务端 16MB 的限制略大
+const maxBSONDocumentSize = 17 * 1024 * 1024
+
+// readBSONDocument 从连续的 BSON 文档流中读取一个文档，流结束时返回 io.EOF
+func readBSONDocument(r io.Reader) (bson.Raw, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	size := int(binary.LittleEndian.Uint32(header[:]))
+	if size < 5 || size > maxBSONDocumentSize {
+		return nil, fmt.Errorf("invalid document length %d in backup file", size)
+	}
+	doc := make([]byte, size)
+	copy(doc, header[:])
+	if _, err := io.ReadFull(r, doc[4:]); err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+	if err := bson.Raw(doc).Validate(); err != nil {
+		return nil, fmt.Errorf("invalid document in backup file: %w", err)
+	}
+	return doc, nil
+}
+
+// backupMetadata <集合>.metadata.json 的内容，字段与 mongodump 相同
+type backupMetadata struct {
+	Indexes []bson.Raw `bson:"indexes"`
+}
+
+func writeBackupMetadata(path string, indexes []bson.Raw) error {
+	if indexes == nil {
+		indexes = []bson.Raw{}
+	}
+	data, err := bson.MarshalExtJSON(backupMetadata{Indexes: indexes}, true, false)
+	if err != nil {
+		return fmt.Errorf("failed to encode backup metadata: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write backup metadata: %w", err)
+	}
+	return nil
+}
+
+func readBackupMetadata(path string) ([]bson.Raw, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup metadata: %w", err)
+	}
+	var metadata backupMetadata
+	if err := bson.UnmarshalExtJSON(data, true, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse backup metadata: %w", err)
+	}
+	return metadata.Indexes, nil
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+func removeKeys(doc bson.D, keys ...string) bson.D {
+	result := doc[:0:0]
+	for _, e := range doc {
+		drop := false
+		for _, key := range keys {
+			drop = drop || e.Key == key
+		}
+		if !drop {
+			result = append(result, e)
+		}
+	}
+	return result
+}
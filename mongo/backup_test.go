@@ -0,0 +1,108 @@
+package mongo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestReadBSONDocument(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 3; i++ {
+		data, err := bson.Marshal(bson.D{{Key: "_id", Value: i}, {Key: "name", Value: "doc"}})
+		require.NoError(t, err)
+		buf.Write(data)
+	}
+
+	var ids []int32
+	for {
+		doc, err := readBSONDocument(&buf)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, doc.Lookup("_id").Int32())
+	}
+	assert.Equal(t, []int32{0, 1, 2}, ids)
+
+	_, err := readBSONDocument(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0x7f}))
+	assert.ErrorContains(t, err, "invalid document length")
+	_, err = readBSONDocument(bytes.NewReader([]byte{20, 0, 0, 0, 1}))
+	assert.ErrorContains(t, err, "failed to read backup file")
+}
+
+func TestBackupMetadata(t *testing.T) {
+	index, err := bson.Marshal(bson.D{
+		{Key: "v", Value: 2},
+		{Key: "key", Value: bson.D{{Key: "b", Value: -1}, {Key: "a", Value: 1}}},
+		{Key: "name", Value: "b_-1_a_1"},
+		{Key: "unique", Value: true},
+	})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "users.metadata.json")
+	require.NoError(t, writeBackupMetadata(path, []bson.Raw{index}))
+
+	indexes, err := readBackupMetadata(path)
+	require.NoError(t, err)
+	require.Len(t, indexes, 1)
+	assert.Equal(t, bson.Raw(index), indexes[0])
+
+	var spec bson.D
+	require.NoError(t, bson.Unmarshal(indexes[0], &spec))
+	spec = removeKeys(spec, "v", "ns")
+	assert.Equal(t, "key", spec[0].Key)
+	assert.Len(t, spec, 3)
+}
+
+func TestBackupListAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	for i, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, 24 * time.Hour, time.Hour} {
+		started := now.Add(-age)
+		set := BackupSet{Name: started.Format(backupTimeFormat), Database: "app", StartedAt: started}
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, set.Name), 0o755))
+		require.NoError(t, writeJSONFile(filepath.Join(dir, set.Name, "manifest.json"), set), i)
+	}
+	// 没有 manifest 的目录是未完成的备份
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "partial"), 0o755))
+
+	manager := NewBackupManager(nil, &BackupOptions{Dir: dir, KeepLast: 3, MaxAge: 36 * time.Hour})
+	sets, err := manager.List()
+	require.NoError(t, err)
+	require.Len(t, sets, 4)
+	assert.True(t, sets[0].StartedAt.After(sets[1].StartedAt), "newest first")
+
+	removed, err := manager.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, []string{sets[2].Name, sets[3].Name}, removed)
+
+	remaining, err := manager.List()
+	require.NoError(t, err)
+	assert.Len(t, remaining, 2)
+
+	_, err = manager.find("19990101T000000Z")
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+	latest, err := manager.find("")
+	require.NoError(t, err)
+	assert.Equal(t, sets[0].Name, latest.Name)
+
+	empty := NewBackupManager(nil, &BackupOptions{Dir: filepath.Join(dir, "missing")})
+	_, err = empty.find("")
+	assert.ErrorIs(t, err, ErrBackupNotFound)
+}
+
+func TestExpiredBackupsKeepsNewest(t *testing.T) {
+	now := time.Now()
+	sets := []BackupSet{{Name: "b", StartedAt: now.Add(-48 * time.Hour)}, {Name: "a", StartedAt: now.Add(-72 * time.Hour)}}
+	expired := expiredBackups(sets, 0, time.Hour, now)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "a", expired[0].Name)
+	assert.Empty(t, expiredBackups(sets, 0, 0, now))
+}
@@ -0,0 +1,393 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultInsertBatchSize InsertMany 每批插入的默认文档数
+const DefaultInsertBatchSize = 1000
+
+// InsertBatchOptions 分批插入配置
+type InsertBatchOptions struct {
+	// BatchSize 每批最多插入的文档数，默认 DefaultInsertBatchSize
+	BatchSize int
+	// MaxBatchBytes 每批文档 BSON 编码后的最大字节数，0 表示不限制
+	// 设置后每个文档会额外编码一次用于计算大小，适合单个文档较大的场景
+	MaxBatchBytes int
+	// Parallelism 同时提交的批次数，默认 1 按顺序提交；集合或 ctx 绑定了会话时强制为 1
+	Parallelism int
+	// ContinueOnError 某一批失败后是否继续提交后续批次，默认遇到失败即停止
+	// 并行提交时已经开始的批次不受影响
+	ContinueOnError bool
+}
+
+// BatchFailure 失败的批次
+type BatchFailure struct {
+	// Start 批次第一个文档在原切片中的下标
+	Start int
+	// End 批次最后一个文档之后的下标
+	End int
+	// Inserted 该批次中失败前已经插入的文档数（有序插入，即 documents[Start:Start+Inserted]）
+	Inserted int
+	// Err 插入错误
+	Err error
+}
+
+// InsertBatchError 分批插入部分失败，Inserted 为成功插入的文档数
+// 成功插入的文档已经回填 ID，InsertManyBatched 返回的结果中只包含这些文档的 ID
+type InsertBatchError struct {
+	Total    int
+	Inserted int
+	Failures []BatchFailure
+}
+
+// Error 实现 error 接口
+func (e *InsertBatchError) Error() string {
+	return fmt.Sprintf("failed to insert %d of %d documents in %d batch(es): %v",
+		e.Total-e.Inserted, e.Total, len(e.Failures), e.Failures[0].Err)
+}
+
+// Unwrap 返回各批次的错误，errors.Is(err, ErrDuplicateKey) 等判断可以穿透
+func (e *InsertBatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// NotInserted 返回未插入的文档下标，便于调用方重试
+func (e *InsertBatchError) NotInserted() []int {
+	var indexes []int
+	for _, failure := range e.Failures {
+		for i := failure.Start + failure.Inserted; i < failure.End; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// InsertManyBatched 分批插入文档，避免超大切片超出单次请求 16MB / 100000 条的限制或占用过多内存
+// 所有文档在提交前统一生成 ID 并校验，任何一个文档校验失败时不会写入数据
+// 部分批次失败时返回已插入文档的结果和 *InsertBatchError；Config.OperationTimeout 作用于每个批次而不是整个调用
+//
+//	result, err := logs.InsertManyBatched(ctx, docs, &InsertBatchOptions{BatchSize: 500, Parallelism: 4})
+//	var batchErr *InsertBatchError
+//	if errors.As(err, &batchErr) {
+//		retry := batchErr.NotInserted()
+//	}
+func (c *Collection) InsertManyBatched(ctx context.Context, documents []interface{}, opts *InsertBatchOptions) (*mongo.InsertManyResult, error) {
+	ctx = c.sessionContext(ctx)
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("failed to insert documents: %w", mongo.ErrEmptySlice)
+	}
+	if err := c.cli.checkWritable("insert", c.collection.Name()); err != nil {
+		return nil, err
+	}
+	o := InsertBatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultInsertBatchSize
+	}
+	if o.Parallelism <= 0 || mongo.SessionFromContext(ctx) != nil {
+		o.Parallelism = 1
+	}
+
+	// 为每个文档生成 ID 并调用 BeforeInsert 钩子，加密字段在写入完成后恢复为明文
+	for _, doc := range documents {
+		if err := c.prepareInsert(doc); err != nil {
+			return nil, err
+		}
+		restore, err := c.encryptDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+	batches, err := splitInsertBatches(documents, o.BatchSize, o.MaxBatchBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([][]interface{}, len(batches))
+	failures := make([]*BatchFailure, len(batches))
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped bool
+	)
+	sem := make(chan struct{}, o.Parallelism)
+	for i, batch := range batches {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, batch [2]int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			inserted, failure := c.insertBatch(ctx, documents, batch[0], batch[1])
+			ids[i] = inserted
+			if failure != nil {
+				failures[i] = failure
+				if !o.ContinueOnError {
+					mu.Lock()
+					stopped = true
+					mu.Unlock()
+				}
+			}
+		}(i, batch)
+	}
+	wg.Wait()
+
+	result := &mongo.InsertManyResult{}
+	batchErr := &InsertBatchError{Total: len(documents)}
+	for i, batch := range batches {
+		result.InsertedIDs = append(result.InsertedIDs, ids[i]...)
+		switch {
+		case failures[i] != nil:
+			batchErr.Failures = append(batchErr.Failures, *failures[i])
+		case ids[i] == nil:
+			// 因前面的批次失败或 ctx 取消而未提交
+			cause := ctx.Err()
+			if cause == nil {
+				cause = errors.New("skipped after previous batch failed")
+			}
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Start: batch[0], End: batch[1], Err: cause})
+		}
+	}
+	batchErr.Inserted = len(result.InsertedIDs)
+	if len(batchErr.Failures) > 0 {
+		return result, batchErr
+	}
+	return result, nil
+}
+
+// insertBatch 插入 documents[start:end]，回填 ID 并记录审计日志
+func (c *Collection) insertBatch(ctx context.Context, documents []interface{}, start, end int) ([]interface{}, *BatchFailure) {
+	batch := documents[start:end]
+	ctx, done, err := c.writeContext(ctx, "insert")
+	if err != nil {
+		return nil, &BatchFailure{Start: start, End: end, Err: err}
+	}
+	defer done()
+	result, err := c.collection.InsertMany(ctx, batch)
+
+	inserted := 0
+	if err == nil {
+		inserted = len(batch)
+	} else {
+		// 有序插入在第一个失败的文档处停止，之前的文档已经写入
+		var bwe mongo.BulkWriteException
+		if errors.As(err, &bwe) && len(bwe.WriteErrors) > 0 {
+			inserted = bwe.WriteErrors[0].Index
+			for _, we := range bwe.WriteErrors {
+				if we.Index < inserted {
+					inserted = we.Index
+				}
+			}
+		}
+	}
+
+	var ids []interface{}
+	if result != nil && inserted > 0 {
+		ids = result.InsertedIDs[:inserted]
+	} else {
+		ids = []interface{}{}
+	}
+	for i, id := range ids {
+		if setErr := setInsertedID(batch[i], id); setErr != nil && err == nil {
+			err = setErr
+		}
+	}
+	if mirrorErr := c.mirrorInsert(ctx, batch, ids); mirrorErr != nil && err == nil {
+		err = mirrorErr
+	}
+	if auditErr := c.auditInserts(ctx, batch[:len(ids)], ids); auditErr != nil && err == nil {
+		err = auditErr
+	}
+	if err != nil {
+		return ids, &BatchFailure{
+			Start:    start,
+			End:      end,
+			Inserted: len(ids),
+			Err:      fmt.Errorf("failed to insert documents: %w", translateWriteError(err)),
+		}
+	}
+	return ids, nil
+}
+
+// splitInsertBatches 按文档数和编码大小切分批次，返回每批的 [start, end)
+func splitInsertBatches(documents []interface{}, batchSize, maxBytes int) ([][2]int, error) {
+	var batches [][2]int
+	start, size := 0, 0
+	for i, doc := range documents {
+		docSize := 0
+		if maxBytes > 0 {
+			raw, err := bson.Marshal(doc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode document %d: %w", i, err)
+			}
+			docSize = len(raw)
+			if docSize > maxBytes {
+				return nil, fmt.Errorf("document %d is %d bytes, larger than MaxBatchBytes %d", i, docSize, maxBytes)
+			}
+		}
+		if i > start && (i-start >= batchSize || (maxBytes > 0 && size+docSize > maxBytes)) {
+			batches = append(batches, [2]int{start, i})
+			start, size = i, 0
+		}
+		size += docSize
+	}
+	return append(batches, [2]int{start, len(documents)}), nil
+}
+
+// UpsertMany 按 keyFields 的取值同步一组文档：匹配到的文档被整体替换，匹配不到时插入，
+// 常用于将外部数据集同步到集合；所有操作通过一次无序 BulkWrite 提交，单个文档失败不影响其它文档
+//
+//	result, err := products.UpsertMany(ctx, items, "vendor", "sku")
+//
+// 替换文档不包含 _id，已存在文档的 _id 保持不变，新插入文档的 ID 回填到对应的 document；
+// created_at 为零值时写入当前时间，updated_at 总是刷新；keyFields 上应当建立唯一索引
+func (c *Collection) UpsertMany(ctx context.Context, documents []interface{}, keyFields ...string) (*mongo.BulkWriteResult, error) {
+	ctx, done, err := c.writeContext(ctx, "bulkWrite")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if len(documents) == 0 {
+		return nil, fmt.Errorf("failed to upsert documents: %w", mongo.ErrEmptySlice)
+	}
+	if len(keyFields) == 0 {
+		return nil, fmt.Errorf("at least one key field is required")
+	}
+
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(documents))
+	filters := make([]interface{}, 0, len(documents))
+	for i, document := range documents {
+		replacement, filter, err := c.prepareUpsert(document, keyFields, now)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(filter).
+			SetReplacement(replacement).
+			SetUpsert(true))
+		filters = append(filters, filter)
+	}
+
+	before, err := c.snapshot(ctx, bson.M{"$or": filters}, true)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		err = fmt.Errorf("failed to upsert documents: %w", translateWriteError(err))
+		if result == nil {
+			return nil, err
+		}
+	}
+
+	primary := MirrorOutcome{Matched: result.MatchedCount, Modified: result.ModifiedCount, Upserted: result.UpsertedCount}
+	mirrorErr := c.mirrorWrite(ctx, "upsertMany", primary, func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error) {
+		result, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if result == nil {
+			return MirrorOutcome{}, err
+		}
+		return MirrorOutcome{Matched: result.MatchedCount, Modified: result.ModifiedCount, Upserted: result.UpsertedCount}, err
+	})
+	if mirrorErr != nil && err == nil {
+		err = mirrorErr
+	}
+
+	for index, id := range result.UpsertedIDs {
+		if setErr := setInsertedID(documents[index], id); setErr != nil && err == nil {
+			err = setErr
+		}
+	}
+	for _, document := range documents {
+		if doc, ok := document.(Document); ok {
+			doc.SetUpdatedAt(now)
+		}
+	}
+	if result.ModifiedCount > 0 {
+		if revErr := c.saveRevisions(ctx, before); revErr != nil && err == nil {
+			err = revErr
+		}
+	}
+	if auditErr := c.auditUpserts(ctx, before, result.UpsertedIDs); auditErr != nil && err == nil {
+		err = auditErr
+	}
+	return result, err
+}
+
+// prepareUpsert 写入默认值并校验文档，返回去掉 _id 的替换文档和按 keyFields 构造的过滤条件
+func (c *Collection) prepareUpsert(document interface{}, keyFields []string, now time.Time) (bson.M, bson.M, error) {
+	if err := ApplyDefaults(document); err != nil {
+		return nil, nil, err
+	}
+	if err := c.validate(document); err != nil {
+		return nil, nil, err
+	}
+	restore, err := c.encryptDocument(document)
+	if err != nil {
+		return nil, nil, err
+	}
+	replacement, err := toBsonM(document)
+	restore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filter := bson.M{}
+	for _, field := range keyFields {
+		value, ok := lookupPath(replacement, field)
+		if !ok {
+			return nil, nil, fmt.Errorf("key field %s is missing", field)
+		}
+		filter[field] = value
+	}
+
+	delete(replacement, "_id")
+	if t, ok := replacement["created_at"].(primitive.DateTime); !ok || t.Time().IsZero() {
+		replacement["created_at"] = now
+	}
+	replacement["updated_at"] = now
+	return replacement, filter, nil
+}
+
+// auditUpserts 记录 UpsertMany 的审计日志，before 为替换前匹配到的文档
+func (c *Collection) auditUpserts(ctx context.Context, before []bson.M, upsertedIDs map[int64]interface{}) error {
+	if !c.auditing() {
+		return nil
+	}
+	ids := make([]interface{}, 0, len(before)+len(upsertedIDs))
+	for _, doc := range before {
+		ids = append(ids, doc["_id"])
+	}
+	for _, id := range upsertedIDs {
+		ids = append(ids, id)
+	}
+	after, err := c.auditReload(ctx, ids)
+	if err != nil {
+		return c.auditFailed(ctx, AuditUpdate, err)
+	}
+	return c.auditDocuments(ctx, AuditUpdate, before, after)
+}
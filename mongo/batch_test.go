@@ -0,0 +1,75 @@
+package mongo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSplitInsertBatches(t *testing.T) {
+	docs := make([]interface{}, 7)
+	for i := range docs {
+		docs[i] = bson.M{"n": i}
+	}
+
+	batches, err := splitInsertBatches(docs, 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{0, 3}, {3, 6}, {6, 7}}, batches)
+
+	batches, err = splitInsertBatches(docs[:2], 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{0, 2}}, batches)
+
+	big := []interface{}{
+		bson.M{"s": strings.Repeat("a", 40)},
+		bson.M{"s": strings.Repeat("b", 40)},
+		bson.M{"s": "c"},
+	}
+	batches, err = splitInsertBatches(big, 10, 80)
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{0, 1}, {1, 3}}, batches)
+
+	_, err = splitInsertBatches(big, 10, 20)
+	assert.Error(t, err)
+}
+
+func TestInsertBatchError(t *testing.T) {
+	err := &InsertBatchError{
+		Total:    10,
+		Inserted: 6,
+		Failures: []BatchFailure{
+			{Start: 3, End: 6, Inserted: 1, Err: &DuplicateKeyError{Fields: []string{"email"}}},
+			{Start: 9, End: 10, Err: errors.New("skipped after previous batch failed")},
+		},
+	}
+	assert.Equal(t, []int{4, 5, 9}, err.NotInserted())
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.Contains(t, err.Error(), "failed to insert 4 of 10 documents in 2 batch(es)")
+}
+
+func TestPrepareUpsert(t *testing.T) {
+	c := &Collection{}
+	now := time.Now()
+	doc := &validatedDoc{Title: "phone", Address: validatedAddress{City: "Beijing"}}
+	doc.ID = primitive.NewObjectID()
+
+	replacement, filter, err := c.prepareUpsert(doc, []string{"title", "address.city"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"title": "phone", "address.city": "Beijing"}, filter)
+	assert.NotContains(t, replacement, "_id")
+	assert.Equal(t, "draft", replacement["status"])
+	assert.Equal(t, now, replacement["created_at"])
+	assert.Equal(t, now, replacement["updated_at"])
+
+	_, _, err = c.prepareUpsert(bson.M{"title": "x"}, []string{"sku"}, now)
+	assert.EqualError(t, err, "key field sku is missing")
+
+	_, _, err = c.prepareUpsert(&validatedDoc{}, []string{"title"}, now)
+	assert.True(t, errors.Is(err, ErrValidation))
+}
@@ -0,0 +1,439 @@
+package mongo
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Cache 查询结果缓存，值为文档的 BSON 编码；内置 LRUCache，也可以基于 Redis 等外部缓存实现
+type Cache interface {
+	// Get 读取缓存，不存在或已过期时 ok 为 false
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 写入缓存，ttl 为过期时间
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete 删除缓存，键不存在时不返回错误
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// lruEntry LRU 缓存条目
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// LRUCache 进程内的 LRU 缓存，超过容量时淘汰最久未访问的条目
+type LRUCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewLRUCache 创建容量为 capacity 个条目的 LRU 缓存，capacity 小于等于 0 时为 10000
+func NewLRUCache(capacity int) *LRUCache {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &LRUCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get 实现 Cache
+func (l *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		l.order.Remove(elem)
+		delete(l.entries, key)
+		return nil, false, nil
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set 实现 Cache，ttl 小于等于 0 时不过期
+func (l *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.entries[key]; ok {
+		elem.Value = &lruEntry{key: key, value: value, expiresAt: expiresAt}
+		l.order.MoveToFront(elem)
+		return nil
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// Delete 实现 Cache
+func (l *LRUCache) Delete(_ context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if elem, ok := l.entries[key]; ok {
+			l.order.Remove(elem)
+			delete(l.entries, key)
+		}
+	}
+	return nil
+}
+
+// Len 返回缓存中的条目数，包括尚未清理的过期条目
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// CacheOptions 查询缓存配置
+type CacheOptions struct {
+	// TTL 缓存过期时间，默认 5 分钟
+	TTL time.Duration
+	// Prefix 缓存键前缀，默认为数据库名和集合名；多个服务共享 Redis 时需要保证唯一
+	Prefix string
+	// WatchChanges 为 true 时 Start 启动变更流，其它进程或绕过缓存的写操作也会使缓存失效
+	WatchChanges bool
+}
+
+// CacheMetrics 缓存命中统计
+type CacheMetrics struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// CachedCollection 带读穿透缓存的集合：FindByID 和 FindOne 的结果按 TTL 缓存，
+// 通过 UpdateByID、ReplaceByID、DeleteByID 写入时使对应文档的缓存失效。
+// FindOne 的缓存键包含查询代数，任意经过缓存的写操作或变更流事件都会使所有 FindOne 缓存失效；
+// 直接通过 Collection 写入时需要开启 WatchChanges 或调用 Invalidate
+//
+//	users := NewCachedCollection(NewCollection(client, "users"), NewLRUCache(10000), &CacheOptions{TTL: time.Minute})
+//	var user User
+//	err := users.FindByID(ctx, id, &user)
+type CachedCollection struct {
+	collection *Collection
+	cache      Cache
+	opts       CacheOptions
+
+	// queryGen 查询缓存代数，idGen 文档缓存代数（只在集合被删除或重命名时增加）
+	queryGen atomic.Int64
+	idGen    atomic.Int64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	invalidator *CacheInvalidator
+}
+
+// NewCachedCollection 创建带缓存的集合
+func NewCachedCollection(collection *Collection, cache Cache, opts *CacheOptions) *CachedCollection {
+	c := &CachedCollection{collection: collection, cache: cache}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.TTL <= 0 {
+		c.opts.TTL = 5 * time.Minute
+	}
+	if c.opts.Prefix == "" {
+		c.opts.Prefix = collection.collection.Database().Name() + "." + collection.collection.Name()
+	}
+	return c
+}
+
+// Collection 返回不经过缓存的集合
+func (c *CachedCollection) Collection() *Collection {
+	return c.collection
+}
+
+// Metrics 返回缓存命中统计
+func (c *CachedCollection) Metrics() CacheMetrics {
+	return CacheMetrics{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// FindByID 根据 ID 查找文档，优先读取缓存
+func (c *CachedCollection) FindByID(ctx context.Context, id interface{}, result interface{}) error {
+	docID, err := c.collection.documentID(id)
+	if err != nil {
+		return err
+	}
+	return c.find(ctx, c.idKey(docID), bson.M{"_id": docID}, result)
+}
+
+// FindOne 查找单个文档，优先读取缓存；没有找到的结果不缓存
+func (c *CachedCollection) FindOne(ctx context.Context, filter bson.M, result interface{}) error {
+	key, err := c.queryKey(filter)
+	if err != nil {
+		return err
+	}
+	return c.find(ctx, key, filter, result)
+}
+
+// find 读取缓存，未命中时查询数据库并写入缓存；缓存读写失败只记录日志
+func (c *CachedCollection) find(ctx context.Context, key string, filter bson.M, result interface{}) error {
+	raw, ok, err := c.cache.Get(ctx, key)
+	if err != nil {
+		c.collection.cli.logger.WarnContext(ctx, "Failed to read cache", "key", key, "err", err)
+	}
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+		raw, err = c.collection.collection.FindOne(c.collection.sessionContext(ctx), filter).Raw()
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return fmt.Errorf("document not found")
+			}
+			return fmt.Errorf("failed to find document: %w", err)
+		}
+		if err := c.cache.Set(ctx, key, raw, c.opts.TTL); err != nil {
+			c.collection.cli.logger.WarnContext(ctx, "Failed to write cache", "key", key, "err", err)
+		}
+	}
+	if err := bson.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	return c.collection.decryptResult(result)
+}
+
+// UpdateByID 更新文档并使其缓存失效
+func (c *CachedCollection) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	result, err := c.collection.UpdateByID(ctx, id, update, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return result, c.Invalidate(ctx, id)
+}
+
+// ReplaceByID 替换文档并使其缓存失效
+func (c *CachedCollection) ReplaceByID(ctx context.Context, id interface{}, replacement interface{}) (*mongo.UpdateResult, error) {
+	docID, err := c.collection.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.ReplaceOne(ctx, bson.M{"_id": docID}, replacement)
+	if err != nil {
+		return nil, err
+	}
+	return result, c.Invalidate(ctx, docID)
+}
+
+// UpdateChanged 只写入变化的字段并使文档缓存失效，参见 Collection.UpdateChanged
+func (c *CachedCollection) UpdateChanged(ctx context.Context, original, modified interface{}) (*mongo.UpdateResult, error) {
+	result, err := c.collection.UpdateChanged(ctx, original, modified)
+	if err != nil {
+		return nil, err
+	}
+	before, err := toBsonM(original)
+	if err != nil {
+		return result, err
+	}
+	return result, c.Invalidate(ctx, before["_id"])
+}
+
+// DeleteByID 删除文档并使其缓存失效
+func (c *CachedCollection) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	result, err := c.collection.DeleteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return result, c.Invalidate(ctx, id)
+}
+
+// Invalidate 使指定文档的缓存和所有 FindOne 缓存失效
+func (c *CachedCollection) Invalidate(ctx context.Context, ids ...interface{}) error {
+	c.queryGen.Add(1)
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		docID, err := c.collection.documentID(id)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, c.idKey(docID))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := c.cache.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
+// InvalidateAll 使该集合的所有缓存失效，旧条目由 TTL 或 LRU 淘汰
+func (c *CachedCollection) InvalidateAll() {
+	c.idGen.Add(1)
+	c.queryGen.Add(1)
+}
+
+// idKey 文档缓存键
+func (c *CachedCollection) idKey(id interface{}) string {
+	return c.opts.Prefix + ":id:" + strconv.FormatInt(c.idGen.Load(), 10) + ":" + fmt.Sprintf("%T:%s", id, IDString(id))
+}
+
+// queryKey 查询缓存键，过滤条件按与字段顺序无关的哈希编码
+func (c *CachedCollection) queryKey(filter bson.M) (string, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	raw, err := bson.Marshal(filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode filter: %w", err)
+	}
+	h := sha256.New()
+	if err := hashDocument(h, raw); err != nil {
+		return "", err
+	}
+	return c.opts.Prefix + ":q:" + strconv.FormatInt(c.idGen.Load(), 10) + "." + strconv.FormatInt(c.queryGen.Load(), 10) +
+		":" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Start 开启 WatchChanges 时在后台监听集合的变更流，按事件使缓存失效；
+// 多个缓存集合可以共用一个 CacheInvalidator，通过 CacheInvalidator.RegisterCache 注册
+func (c *CachedCollection) Start(ctx context.Context) error {
+	if !c.opts.WatchChanges || c.invalidator != nil {
+		return nil
+	}
+	invalidator := NewCacheInvalidator(c.collection.cli, &InvalidatorOptions{Name: "cache:" + c.opts.Prefix})
+	invalidator.RegisterCache(c)
+	if err := invalidator.Start(ctx); err != nil {
+		return err
+	}
+	c.invalidator = invalidator
+	return nil
+}
+
+// Stop 停止变更流监听
+func (c *CachedCollection) Stop() {
+	if c.invalidator != nil {
+		c.invalidator.Stop()
+	}
+}
+
+// invalidateEvent 按变更事件使缓存失效，集合被删除或重命名时使所有缓存失效
+func (c *CachedCollection) invalidateEvent(ctx context.Context, event *ChangeEvent) error {
+	switch event.OperationType {
+	case "drop", "rename", "dropDatabase", "invalidate":
+		c.InvalidateAll()
+		return nil
+	}
+	id, ok := event.DocumentKey["_id"]
+	if !ok {
+		c.queryGen.Add(1)
+		return nil
+	}
+	if event.OperationType == "insert" {
+		c.queryGen.Add(1)
+		return nil
+	}
+	return c.Invalidate(ctx, id)
+}
+
+// localCheckpointStore 只保存在内存中的恢复令牌，变更流中断后从上次处理的事件之后继续，进程重启后从当前时间开始
+type localCheckpointStore struct {
+	mu    sync.Mutex
+	token bson.Raw
+}
+
+// Load 实现 CheckpointStore
+func (s *localCheckpointStore) Load(context.Context, string) (bson.Raw, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// Save 实现 CheckpointStore
+func (s *localCheckpointStore) Save(_ context.Context, _ string, token bson.Raw) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+// CachedRepository 带读穿透缓存的仓库，FindByID 和 FindOne 读取缓存，UpdateByID、UpdateChanged 和 DeleteByID 使缓存失效，
+// 其它方法与 Repository 相同且不经过缓存
+type CachedRepository[T any] struct {
+	*Repository[T]
+	cached *CachedCollection
+}
+
+// NewCachedRepository 为仓库添加缓存
+func NewCachedRepository[T any](repo *Repository[T], cache Cache, opts *CacheOptions) *CachedRepository[T] {
+	return &CachedRepository[T]{
+		Repository: repo,
+		cached:     NewCachedCollection(repo.Collection(), cache, opts),
+	}
+}
+
+// Cache 返回底层的缓存集合，用于 Start、Invalidate 和 Metrics
+func (r *CachedRepository[T]) Cache() *CachedCollection {
+	return r.cached
+}
+
+// FindByID 根据 ID 查找文档，优先读取缓存
+func (r *CachedRepository[T]) FindByID(ctx context.Context, id interface{}) (*T, error) {
+	var doc T
+	if err := r.cached.FindByID(ctx, id, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// FindOne 查找单个文档，优先读取缓存
+func (r *CachedRepository[T]) FindOne(ctx context.Context, filter bson.M) (*T, error) {
+	var doc T
+	if err := r.cached.FindOne(ctx, filter, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// UpdateByID 更新文档并使其缓存失效
+func (r *CachedRepository[T]) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return r.cached.UpdateByID(ctx, id, update, opts...)
+}
+
+// UpdateChanged 执行更新钩子后只写入变化的字段，并使文档缓存失效
+func (r *CachedRepository[T]) UpdateChanged(ctx context.Context, original, modified *T) (*mongo.UpdateResult, error) {
+	result, err := r.Repository.UpdateChanged(ctx, original, modified)
+	if err != nil {
+		return nil, err
+	}
+	before, err := toBsonM(original)
+	if err != nil {
+		return result, err
+	}
+	return result, r.cached.Invalidate(ctx, before["_id"])
+}
+
+// DeleteByID 删除文档并使其缓存失效
+func (r *CachedRepository[T]) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	return r.cached.DeleteByID(ctx, id)
+}
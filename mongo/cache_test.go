@@ -0,0 +1,77 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := t.Context()
+	cache := NewLRUCache(2)
+	require.NoError(t, cache.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, cache.Set(ctx, "b", []byte("2"), 0))
+	_, ok, _ := cache.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// 超过容量时淘汰最久未访问的 b
+	require.NoError(t, cache.Set(ctx, "c", []byte("3"), 0))
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, cache.Len())
+
+	require.NoError(t, cache.Set(ctx, "d", []byte("4"), time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	_, ok, _ = cache.Get(ctx, "d")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Delete(ctx, "a", "missing"))
+	_, ok, _ = cache.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestCachedCollectionKeys(t *testing.T) {
+	c := NewCachedCollection(NewCollection(newLazyClient(t), "users"), NewLRUCache(0), nil)
+
+	k1, err := c.queryKey(bson.M{"email": "a@example.com", "status": "active"})
+	require.NoError(t, err)
+	k2, err := c.queryKey(bson.M{"status": "active", "email": "a@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	id := primitive.NewObjectID()
+	idKey := c.idKey(id)
+	require.NoError(t, c.Invalidate(t.Context(), id))
+	k3, err := c.queryKey(bson.M{"email": "a@example.com", "status": "active"})
+	require.NoError(t, err)
+	assert.NotEqual(t, k1, k3)
+	assert.Equal(t, idKey, c.idKey(id))
+
+	c.InvalidateAll()
+	assert.NotEqual(t, idKey, c.idKey(id))
+}
+
+func TestCachedCollectionReadThrough(t *testing.T) {
+	ctx := t.Context()
+	cache := NewLRUCache(0)
+	c := NewCachedCollection(NewCollection(newLazyClient(t), "users"), cache, &CacheOptions{TTL: time.Minute})
+
+	id := primitive.NewObjectID()
+	raw, err := bson.Marshal(bson.M{"_id": id, "username": "alice"})
+	require.NoError(t, err)
+	require.NoError(t, cache.Set(ctx, c.idKey(id), raw, time.Minute))
+
+	var user User
+	require.NoError(t, c.FindByID(ctx, id.Hex(), &user))
+	assert.Equal(t, "alice", user.Username)
+	assert.Equal(t, CacheMetrics{Hits: 1}, c.Metrics())
+
+	// 变更事件使文档缓存失效
+	require.NoError(t, c.invalidateEvent(ctx, &ChangeEvent{OperationType: "update", DocumentKey: bson.M{"_id": id}}))
+	_, ok, _ := cache.Get(ctx, c.idKey(id))
+	assert.False(t, ok)
+}
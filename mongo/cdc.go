@@ -0,0 +1,356 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CDCEventType CDC 事件类型
+type CDCEventType string
+
+const (
+	CDCInsert  CDCEventType = "insert"
+	CDCUpdate  CDCEventType = "update"
+	CDCReplace CDCEventType = "replace"
+	CDCDelete  CDCEventType = "delete"
+)
+
+// CDCEvent 数据变更事件
+type CDCEvent struct {
+	Type      CDCEventType
+	Namespace ChangeNamespace
+	// ID 文档的 _id
+	ID interface{}
+	// FullDocument 变更后的完整文档；update 事件为查询时的最新版本，文档已被删除时为空，delete 事件为空
+	FullDocument bson.Raw
+	// Before 变更前的文档，需要开启 CDCOptions.BeforeImage 且集合启用了 changeStreamPreAndPostImages
+	Before bson.Raw
+	// UpdatedFields、RemovedFields update 事件修改和删除的字段
+	UpdatedFields bson.M
+	RemovedFields []string
+	ClusterTime   primitive.Timestamp
+	WallTime      time.Time
+	// ResumeToken 该事件之后的恢复令牌，通过 CDC.Commit 保存
+	ResumeToken bson.Raw
+}
+
+// Decode 将 FullDocument 解码到 v，没有完整文档时返回错误
+func (e *CDCEvent) Decode(v interface{}) error {
+	if len(e.FullDocument) == 0 {
+		return fmt.Errorf("%s event on %s.%s has no full document", e.Type, e.Namespace.Database, e.Namespace.Collection)
+	}
+	return bson.Unmarshal(e.FullDocument, v)
+}
+
+// cdcChange 变更流原始事件
+type cdcChange struct {
+	OperationType            string              `bson:"operationType"`
+	Namespace                ChangeNamespace     `bson:"ns"`
+	DocumentKey              bson.Raw            `bson:"documentKey"`
+	FullDocument             bson.RawValue       `bson:"fullDocument"`
+	FullDocumentBeforeChange bson.RawValue       `bson:"fullDocumentBeforeChange"`
+	UpdateDescription        *UpdateDescription  `bson:"updateDescription"`
+	ClusterTime              primitive.Timestamp `bson:"clusterTime"`
+	WallTime                 time.Time           `bson:"wallTime"`
+}
+
+// CDCOptions CDC 配置
+type CDCOptions struct {
+	// Name 名称，用作检查点的键，必填
+	Name string
+	// Namespaces 监听的命名空间，格式为 db.coll 或 db.*（整个数据库），为空时监听整个部署
+	Namespaces []string
+	// Exclude 排除的命名空间，格式同 Namespaces，例如排除检查点集合自身
+	Exclude []string
+	// Operations 监听的事件类型，默认 insert、update、replace、delete
+	Operations []CDCEventType
+	// BeforeImage 同时读取变更前的文档（MongoDB 6.0+，集合需启用 changeStreamPreAndPostImages）
+	BeforeImage bool
+	// BatchSize 变更流批量大小
+	BatchSize int32
+	// Buffer 事件通道的容量，默认 256
+	Buffer int
+	// RetryBackoff 变更流出错后的首次重试间隔，之后按指数增长，默认 1 秒
+	RetryBackoff time.Duration
+	// MaxRetryBackoff 重试间隔上限，默认 30 秒
+	MaxRetryBackoff time.Duration
+}
+
+// CDC 监听整个部署（或指定命名空间）的变更流，以类型化的事件通道输出插入、更新、替换和删除，
+// 是复制和搜索索引同步的基础组件
+//
+// 消费方处理完事件后调用 Commit 保存恢复令牌，进程重启后从最后提交的事件之后继续，
+// 因此保证至少一次投递；同一进程内变更流中断时从最后输出的事件之后恢复，不会重复
+//
+//	cdc, _ := NewCDC(client, nil, CDCOptions{Name: "search", Namespaces: []string{"app.articles"}})
+//	cdc.Start(ctx)
+//	for event := range cdc.Events() {
+//		index(event)
+//		cdc.Commit(ctx, event)
+//	}
+type CDC struct {
+	client      *Client
+	checkpoints CheckpointStore
+	opts        CDCOptions
+	pipeline    []bson.M
+	events      chan *CDCEvent
+
+	// lastToken 最后输出的事件的恢复令牌，只由 Run 所在的 goroutine 访问
+	lastToken bson.Raw
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	doneCh    chan struct{}
+}
+
+// NewCDC 创建 CDC，checkpoints 为空时使用 change_stream_checkpoints 集合，并自动排除该集合的事件
+func NewCDC(client *Client, checkpoints CheckpointStore, opts CDCOptions) (*CDC, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("cdc name is required")
+	}
+	if checkpoints == nil {
+		checkpoints = NewMongoCheckpointStore(client, "")
+		// 默认检查点集合的写入不应再产生事件
+		opts.Exclude = append(opts.Exclude[:len(opts.Exclude):len(opts.Exclude)], client.GetDatabaseName()+".change_stream_checkpoints")
+	}
+	if len(opts.Operations) == 0 {
+		opts.Operations = []CDCEventType{CDCInsert, CDCUpdate, CDCReplace, CDCDelete}
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 256
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = time.Second
+	}
+	if opts.MaxRetryBackoff <= 0 {
+		opts.MaxRetryBackoff = 30 * time.Second
+	}
+	pipeline, err := cdcPipeline(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &CDC{
+		client:      client,
+		checkpoints: checkpoints,
+		opts:        opts,
+		pipeline:    pipeline,
+		events:      make(chan *CDCEvent, opts.Buffer),
+		doneCh:      make(chan struct{}),
+	}, nil
+}
+
+// cdcPipeline 在服务端按事件类型和命名空间过滤
+func cdcPipeline(opts CDCOptions) ([]bson.M, error) {
+	operations := make(bson.A, 0, len(opts.Operations))
+	for _, op := range opts.Operations {
+		switch op {
+		case CDCInsert, CDCUpdate, CDCReplace, CDCDelete:
+			operations = append(operations, string(op))
+		default:
+			return nil, fmt.Errorf("unsupported cdc operation %q", op)
+		}
+	}
+	match := bson.M{"operationType": bson.M{"$in": operations}}
+
+	include, err := namespaceFilters(opts.Namespaces)
+	if err != nil {
+		return nil, err
+	}
+	if len(include) > 0 {
+		match["$or"] = include
+	}
+	exclude, err := namespaceFilters(opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(exclude) > 0 {
+		match["$nor"] = exclude
+	}
+	return []bson.M{{"$match": match}}, nil
+}
+
+func namespaceFilters(namespaces []string) (bson.A, error) {
+	filters := bson.A{}
+	for _, ns := range namespaces {
+		db, coll, _ := strings.Cut(ns, ".")
+		if db == "" {
+			return nil, fmt.Errorf("invalid namespace %q, expected db.coll or db.*", ns)
+		}
+		if coll == "" || coll == "*" {
+			filters = append(filters, bson.M{"ns.db": db})
+		} else {
+			filters = append(filters, bson.M{"ns.db": db, "ns.coll": coll})
+		}
+	}
+	return filters, nil
+}
+
+// Events 事件通道，Run 返回后关闭
+func (c *CDC) Events() <-chan *CDCEvent {
+	return c.events
+}
+
+// Commit 保存事件的恢复令牌，之后重启会从该事件之后继续
+func (c *CDC) Commit(ctx context.Context, event *CDCEvent) error {
+	return c.checkpoints.Save(ctx, c.opts.Name, event.ResumeToken)
+}
+
+// Run 持续读取变更流直到 ctx 取消，出错时自动恢复，返回前关闭事件通道；Run 只能调用一次；
+// 部署不支持变更流时直接返回 *UnsupportedFeatureError
+func (c *CDC) Run(ctx context.Context) error {
+	defer close(c.events)
+	for attempt := 0; ; attempt++ {
+		delivered, err := c.tail(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrUnsupportedFeature) {
+			c.client.logger.WarnContext(ctx, "CDC stopped", "cdc", c.opts.Name, "err", err)
+			return err
+		}
+		if delivered {
+			attempt = 0
+		}
+		c.client.logger.WarnContext(ctx, "CDC change stream interrupted, resuming", "cdc", c.opts.Name, "err", err)
+		if err := c.wait(ctx, attempt); err != nil {
+			return nil
+		}
+	}
+}
+
+// Start 在后台运行
+func (c *CDC) Start(ctx context.Context) {
+	c.startOnce.Do(func() {
+		c.client.RegisterShutdown(c)
+		ctx, c.cancel = context.WithCancel(ctx)
+		go func() {
+			defer close(c.doneCh)
+			_ = c.Run(ctx)
+		}()
+	})
+}
+
+// Stop 停止后台运行并关闭事件通道，未被消费的事件会在下次启动时从检查点重新读取
+func (c *CDC) Stop() {
+	c.stopOnce.Do(func() {
+		c.startOnce.Do(func() {
+			close(c.events)
+			close(c.doneCh)
+		})
+		if c.cancel != nil {
+			c.cancel()
+		}
+		<-c.doneCh
+	})
+}
+
+// wait 按指数退避等待
+func (c *CDC) wait(ctx context.Context, attempt int) error {
+	backoff := c.opts.RetryBackoff << attempt
+	if backoff <= 0 || backoff > c.opts.MaxRetryBackoff {
+		backoff = c.opts.MaxRetryBackoff
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tail 打开部署级变更流并输出事件，返回是否输出过事件
+func (c *CDC) tail(ctx context.Context) (bool, error) {
+	if err := c.client.requireFeature(ctx, FeatureChangeStreams); err != nil {
+		return false, err
+	}
+	token := c.lastToken
+	if token == nil {
+		var err error
+		if token, err = c.checkpoints.Load(ctx, c.opts.Name); err != nil {
+			return false, err
+		}
+	}
+
+	streamOpts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if c.opts.BeforeImage {
+		streamOpts.SetFullDocumentBeforeChange(options.WhenAvailable)
+	}
+	if c.opts.BatchSize > 0 {
+		streamOpts.SetBatchSize(c.opts.BatchSize)
+	}
+	if token != nil {
+		streamOpts.SetStartAfter(token)
+	}
+	stream, err := c.client.client.Watch(ctx, c.pipeline, streamOpts)
+	if err != nil {
+		return false, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	delivered := false
+	for stream.Next(ctx) {
+		event, err := decodeCDCEvent(stream)
+		if err != nil {
+			return delivered, err
+		}
+		select {
+		case c.events <- event:
+		case <-ctx.Done():
+			return delivered, ctx.Err()
+		}
+		c.lastToken = event.ResumeToken
+		delivered = true
+	}
+	if err := stream.Err(); err != nil {
+		return delivered, fmt.Errorf("change stream failed: %w", err)
+	}
+	return delivered, nil
+}
+
+func decodeCDCEvent(stream *mongo.ChangeStream) (*CDCEvent, error) {
+	var change cdcChange
+	if err := stream.Decode(&change); err != nil {
+		return nil, fmt.Errorf("failed to decode change event: %w", err)
+	}
+	event := &CDCEvent{
+		Type:         CDCEventType(change.OperationType),
+		Namespace:    change.Namespace,
+		FullDocument: rawDocument(change.FullDocument),
+		Before:       rawDocument(change.FullDocumentBeforeChange),
+		ClusterTime:  change.ClusterTime,
+		WallTime:     change.WallTime,
+		ResumeToken:  append(bson.Raw(nil), stream.ResumeToken()...),
+	}
+	if id, err := change.DocumentKey.LookupErr("_id"); err == nil {
+		var value interface{}
+		if err := id.Unmarshal(&value); err != nil {
+			return nil, fmt.Errorf("failed to decode document key: %w", err)
+		}
+		event.ID = value
+	}
+	if change.UpdateDescription != nil {
+		event.UpdatedFields = change.UpdateDescription.UpdatedFields
+		event.RemovedFields = change.UpdateDescription.RemovedFields
+	}
+	return event, nil
+}
+
+// rawDocument 文档不存在时变更流返回 null 或省略字段
+func rawDocument(v bson.RawValue) bson.Raw {
+	if doc, ok := v.DocumentOK(); ok {
+		return doc
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type memoryCheckpoints map[string]bson.Raw
+
+func (m memoryCheckpoints) Load(ctx context.Context, name string) (bson.Raw, error) {
+	return m[name], nil
+}
+
+func (m memoryCheckpoints) Save(ctx context.Context, name string, token bson.Raw) error {
+	m[name] = token
+	return nil
+}
+
+func TestCDCPipeline(t *testing.T) {
+	pipeline, err := cdcPipeline(CDCOptions{
+		Operations: []CDCEventType{CDCInsert, CDCDelete},
+		Namespaces: []string{"app.articles", "search.*", "audit"},
+		Exclude:    []string{"app.change_stream_checkpoints"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bson.M{{"$match": bson.M{
+		"operationType": bson.M{"$in": bson.A{"insert", "delete"}},
+		"$or": bson.A{
+			bson.M{"ns.db": "app", "ns.coll": "articles"},
+			bson.M{"ns.db": "search"},
+			bson.M{"ns.db": "audit"},
+		},
+		"$nor": bson.A{bson.M{"ns.db": "app", "ns.coll": "change_stream_checkpoints"}},
+	}}}, pipeline)
+
+	_, err = cdcPipeline(CDCOptions{Operations: []CDCEventType{"drop"}})
+	assert.ErrorContains(t, err, "unsupported cdc operation")
+	_, err = cdcPipeline(CDCOptions{Operations: []CDCEventType{CDCInsert}, Namespaces: []string{".users"}})
+	assert.ErrorContains(t, err, "invalid namespace")
+}
+
+func TestNewCDC(t *testing.T) {
+	client := newLazyClient(t)
+	_, err := NewCDC(client, nil, CDCOptions{})
+	assert.Error(t, err)
+
+	cdc, err := NewCDC(client, nil, CDCOptions{Name: "search", Exclude: []string{"app.logs"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.logs", client.GetDatabaseName() + ".change_stream_checkpoints"}, cdc.opts.Exclude)
+	assert.Len(t, cdc.opts.Operations, 4)
+
+	checkpoints := memoryCheckpoints{}
+	cdc, err = NewCDC(client, checkpoints, CDCOptions{Name: "search"})
+	require.NoError(t, err)
+	assert.Empty(t, cdc.opts.Exclude)
+	token := bson.Raw(bsonDoc(t, bson.D{{Key: "_data", Value: "8264"}}))
+	require.NoError(t, cdc.Commit(context.Background(), &CDCEvent{ResumeToken: token}))
+	assert.Equal(t, token, checkpoints["search"])
+
+	// 未启动时 Stop 关闭事件通道
+	cdc.Stop()
+	_, open := <-cdc.Events()
+	assert.False(t, open)
+}
+
+func TestCDCEventDecode(t *testing.T) {
+	doc := bsonDoc(t, bson.D{{Key: "_id", Value: 1}, {Key: "title", Value: "hello"}})
+	event := &CDCEvent{Type: CDCUpdate, FullDocument: rawDocument(bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: doc})}
+	var article struct {
+		Title string `bson:"title"`
+	}
+	require.NoError(t, event.Decode(&article))
+	assert.Equal(t, "hello", article.Title)
+
+	deleted := &CDCEvent{Type: CDCDelete, Namespace: ChangeNamespace{Database: "app", Collection: "articles"},
+		FullDocument: rawDocument(bson.RawValue{Type: bson.TypeNull})}
+	assert.Nil(t, deleted.FullDocument)
+	assert.ErrorContains(t, deleted.Decode(&article), "delete event on app.articles has no full document")
+}
+
+func bsonDoc(t *testing.T, doc bson.D) []byte {
+	t.Helper()
+	data, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	return data
+}
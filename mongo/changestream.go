@@ -0,0 +1,349 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPermanentDelivery 事件无法投递且重试也不会成功，例如 Webhook 返回 4xx；
+// Sink 返回包装了该错误的错误时转发器不再重试，跳过该事件并保存检查点
+var ErrPermanentDelivery = errors.New("permanent delivery failure")
+
+// ChangeNamespace 变更事件所属的命名空间
+type ChangeNamespace struct {
+	Database   string `bson:"db" json:"db"`
+	Collection string `bson:"coll" json:"coll"`
+}
+
+// UpdateDescription update 事件的字段变更
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields" json:"updated_fields"`
+	RemovedFields []string `bson:"removedFields" json:"removed_fields"`
+}
+
+// ChangeEvent 变更流事件
+type ChangeEvent struct {
+	ResumeToken       bson.Raw            `bson:"_id" json:"-"`
+	OperationType     string              `bson:"operationType" json:"operation_type"`
+	Namespace         ChangeNamespace     `bson:"ns" json:"ns"`
+	DocumentKey       bson.M              `bson:"documentKey,omitempty" json:"document_key,omitempty"`
+	FullDocument      bson.M              `bson:"fullDocument,omitempty" json:"full_document,omitempty"`
+	UpdateDescription *UpdateDescription  `bson:"updateDescription,omitempty" json:"update_description,omitempty"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime" json:"cluster_time"`
+	WallTime          *time.Time          `bson:"wallTime,omitempty" json:"wall_time,omitempty"`
+}
+
+// Sink 变更事件的投递目标，例如 Kafka、NATS 或 Webhook
+// Publish 返回 nil 表示事件已经被目标确认接收
+type Sink interface {
+	Publish(ctx context.Context, event *ChangeEvent) error
+}
+
+// SinkFunc 函数形式的 Sink
+type SinkFunc func(ctx context.Context, event *ChangeEvent) error
+
+// Publish 实现 Sink
+func (f SinkFunc) Publish(ctx context.Context, event *ChangeEvent) error {
+	return f(ctx, event)
+}
+
+// CheckpointStore 变更流恢复令牌存储
+type CheckpointStore interface {
+	// Load 读取恢复令牌，没有检查点时返回 nil
+	Load(ctx context.Context, name string) (bson.Raw, error)
+	// Save 保存恢复令牌
+	Save(ctx context.Context, name string, token bson.Raw) error
+}
+
+// checkpointDocument 检查点文档
+type checkpointDocument struct {
+	Name        string    `bson:"_id"`
+	ResumeToken bson.Raw  `bson:"resume_token"`
+	UpdatedAt   time.Time `bson:"updated_at"`
+}
+
+// MongoCheckpointStore 将恢复令牌保存在集合中的检查点存储
+type MongoCheckpointStore struct {
+	collection *Collection
+}
+
+// NewMongoCheckpointStore 创建检查点存储，collectionName 为空时使用 change_stream_checkpoints 集合
+func NewMongoCheckpointStore(client *Client, collectionName string) *MongoCheckpointStore {
+	if collectionName == "" {
+		collectionName = "change_stream_checkpoints"
+	}
+	return &MongoCheckpointStore{collection: NewCollection(client, collectionName)}
+}
+
+// Load 读取恢复令牌
+func (s *MongoCheckpointStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	var doc checkpointDocument
+	err := s.collection.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load checkpoint %s: %w", name, err)
+	}
+	return doc.ResumeToken, nil
+}
+
+// Save 保存恢复令牌
+func (s *MongoCheckpointStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	ctx, done, err := s.collection.writeContext(ctx, "update")
+	if err != nil {
+		return err
+	}
+	defer done()
+	_, err = s.collection.collection.UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$set": bson.M{"resume_token": token, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// ForwarderOptions 变更流转发器配置
+type ForwarderOptions struct {
+	// Name 转发器名称，用作检查点的键，必填
+	Name string
+	// Pipeline 变更流过滤管道，例如 []bson.M{{"$match": bson.M{"operationType": "insert"}}}
+	Pipeline []bson.M
+	// FullDocument update 事件是否查询完整文档，默认 updateLookup
+	FullDocument options.FullDocument
+	// BatchSize 变更流批量大小
+	BatchSize int32
+	// RetryBackoff 投递或变更流出错后的首次重试间隔，之后按指数增长，默认 1 秒
+	RetryBackoff time.Duration
+	// MaxRetryBackoff 重试间隔上限，默认 30 秒
+	MaxRetryBackoff time.Duration
+	// MaxPublishAttempts 单个事件的最大投递次数，超过后与 ErrPermanentDelivery 一样跳过，0 表示一直重试
+	MaxPublishAttempts int
+	// DeadLetter 事件被跳过（无法解码或永久投递失败）时调用，raw 为变更流返回的原始事件，
+	// 可以用于写入死信集合后人工处理；为空时只记录日志
+	DeadLetter func(ctx context.Context, raw bson.Raw, err error)
+}
+
+// ChangeStreamForwarder 变更流转发器
+// 消费集合（或整个数据库）的变更流并投递到 Sink，事件投递成功后才保存恢复令牌，
+// 因此保证至少一次投递：进程重启后会从最后一个已确认的事件之后继续，Sink 需要自行处理重复事件
+type ChangeStreamForwarder struct {
+	client      *Client
+	collection  string
+	sink        Sink
+	checkpoints CheckpointStore
+	opts        ForwarderOptions
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	doneCh    chan struct{}
+}
+
+// NewChangeStreamForwarder 创建变更流转发器，collectionName 为空时监听整个数据库
+func NewChangeStreamForwarder(client *Client, collectionName string, sink Sink, checkpoints CheckpointStore, opts ForwarderOptions) (*ChangeStreamForwarder, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("forwarder name is required")
+	}
+	if sink == nil {
+		return nil, fmt.Errorf("sink is required")
+	}
+	if checkpoints == nil {
+		checkpoints = NewMongoCheckpointStore(client, "")
+	}
+	if opts.FullDocument == "" {
+		opts.FullDocument = options.UpdateLookup
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = time.Second
+	}
+	if opts.MaxRetryBackoff <= 0 {
+		opts.MaxRetryBackoff = 30 * time.Second
+	}
+	return &ChangeStreamForwarder{
+		client:      client,
+		collection:  collectionName,
+		sink:        sink,
+		checkpoints: checkpoints,
+		opts:        opts,
+		doneCh:      make(chan struct{}),
+	}, nil
+}
+
+// Run 持续转发变更事件直到 ctx 取消，变更流出错时自动从检查点恢复，ctx 取消后返回 nil；
+// 部署不支持变更流时直接返回 *UnsupportedFeatureError
+// 变更流正常结束时（例如集合被删除或重命名后的 invalidate 事件）从检查点重新打开，不计为失败
+func (f *ChangeStreamForwarder) Run(ctx context.Context) error {
+	attempt := 0
+	for {
+		progressed, err := f.forward(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrUnsupportedFeature) {
+			f.client.logger.WarnContext(ctx, "Change stream forwarder stopped", "forwarder", f.opts.Name, "err", err)
+			return err
+		}
+		// 恢复后处理过事件说明之前的中断已经恢复，重新从最短的间隔开始退避
+		if progressed {
+			attempt = 0
+		}
+		if err == nil {
+			// 检查点使用 startAfter，可以越过 invalidate 事件继续监听；没有处理过事件时等待最短间隔，避免反复打开立即结束的变更流
+			f.client.logger.InfoContext(ctx, "Change stream ended, reopening from checkpoint", "forwarder", f.opts.Name)
+			if !progressed && f.wait(ctx, 0) != nil {
+				return nil
+			}
+			continue
+		}
+		f.client.logger.WarnContext(ctx, "Change stream interrupted, resuming", "forwarder", f.opts.Name, "err", err)
+		if err := f.wait(ctx, attempt); err != nil {
+			return nil
+		}
+		attempt++
+	}
+}
+
+// Start 在后台运行转发器
+func (f *ChangeStreamForwarder) Start(ctx context.Context) {
+	f.startOnce.Do(func() {
+		f.client.RegisterShutdown(f)
+		ctx, f.cancel = context.WithCancel(ctx)
+		go func() {
+			defer close(f.doneCh)
+			_ = f.Run(ctx)
+		}()
+	})
+}
+
+// Stop 停止后台转发，等待当前事件处理结束
+func (f *ChangeStreamForwarder) Stop() {
+	f.stopOnce.Do(func() {
+		f.startOnce.Do(func() {
+			close(f.doneCh)
+		})
+		if f.cancel != nil {
+			f.cancel()
+		}
+		<-f.doneCh
+	})
+}
+
+// forward 打开变更流并逐个投递事件，返回是否保存过检查点
+func (f *ChangeStreamForwarder) forward(ctx context.Context) (bool, error) {
+	if err := f.client.requireFeature(ctx, FeatureChangeStreams); err != nil {
+		return false, err
+	}
+	token, err := f.checkpoints.Load(ctx, f.opts.Name)
+	if err != nil {
+		return false, err
+	}
+
+	streamOpts := options.ChangeStream().SetFullDocument(f.opts.FullDocument)
+	if f.opts.BatchSize > 0 {
+		streamOpts.SetBatchSize(f.opts.BatchSize)
+	}
+	if token != nil {
+		streamOpts.SetStartAfter(token)
+	}
+	pipeline := f.opts.Pipeline
+	if pipeline == nil {
+		pipeline = []bson.M{}
+	}
+
+	var stream *mongo.ChangeStream
+	if f.collection == "" {
+		stream, err = f.client.database.Watch(ctx, pipeline, streamOpts)
+	} else {
+		stream, err = f.client.GetCollection(f.collection).Watch(ctx, pipeline, streamOpts)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	progressed := false
+	for stream.Next(ctx) {
+		if err := f.deliver(ctx, stream.Current); err != nil {
+			return progressed, err
+		}
+		if err := f.checkpoints.Save(ctx, f.opts.Name, stream.ResumeToken()); err != nil {
+			return progressed, err
+		}
+		progressed = true
+	}
+	if err := stream.Err(); err != nil {
+		return progressed, fmt.Errorf("change stream failed: %w", err)
+	}
+	return progressed, nil
+}
+
+// deliver 解码并投递单个事件；无法解码或永久投递失败的事件交给 DeadLetter 后返回 nil，
+// 由调用方保存检查点，避免一个有问题的事件永远阻塞变更流
+func (f *ChangeStreamForwarder) deliver(ctx context.Context, raw bson.Raw) error {
+	var event ChangeEvent
+	if err := bson.Unmarshal(raw, &event); err != nil {
+		f.skip(ctx, raw, fmt.Errorf("%w: failed to decode change event: %v", ErrPermanentDelivery, err))
+		return nil
+	}
+	err := f.publish(ctx, &event)
+	if errors.Is(err, ErrPermanentDelivery) {
+		f.skip(ctx, raw, err)
+		return nil
+	}
+	return err
+}
+
+// publish 投递事件，临时错误按退避重试直到成功、达到 MaxPublishAttempts 或 ctx 取消
+func (f *ChangeStreamForwarder) publish(ctx context.Context, event *ChangeEvent) error {
+	for attempt := 0; ; attempt++ {
+		err := f.sink.Publish(ctx, event)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, ErrPermanentDelivery) {
+			return err
+		}
+		if f.opts.MaxPublishAttempts > 0 && attempt+1 >= f.opts.MaxPublishAttempts {
+			return fmt.Errorf("%w: gave up after %d attempts: %v", ErrPermanentDelivery, attempt+1, err)
+		}
+		f.client.logger.WarnContext(ctx, "Failed to publish change event", "forwarder", f.opts.Name, "operation", event.OperationType, "attempt", attempt+1, "err", err)
+		if werr := f.wait(ctx, attempt); werr != nil {
+			return fmt.Errorf("failed to publish change event: %w", err)
+		}
+	}
+}
+
+// skip 记录并丢弃无法投递的事件
+func (f *ChangeStreamForwarder) skip(ctx context.Context, raw bson.Raw, err error) {
+	f.client.logger.ErrorContext(ctx, "Skipping undeliverable change event", "forwarder", f.opts.Name, "err", err)
+	if f.opts.DeadLetter != nil {
+		f.opts.DeadLetter(ctx, raw, err)
+	}
+}
+
+// wait 按指数退避等待
+func (f *ChangeStreamForwarder) wait(ctx context.Context, attempt int) error {
+	backoff := f.opts.RetryBackoff << attempt
+	if backoff <= 0 || backoff > f.opts.MaxRetryBackoff {
+		backoff = f.opts.MaxRetryBackoff
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
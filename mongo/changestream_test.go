@@ -0,0 +1,118 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestChangeStreamForwarderDeliver(t *testing.T) {
+	var published []string
+	var failures []error
+	sink := SinkFunc(func(ctx context.Context, event *ChangeEvent) error {
+		published = append(published, event.OperationType)
+		if len(failures) == 0 {
+			return nil
+		}
+		err := failures[0]
+		failures = failures[1:]
+		return err
+	})
+	var dead []error
+	f, err := NewChangeStreamForwarder(newLazyClient(t), "users", sink, memoryCheckpoints{}, ForwarderOptions{
+		Name:               "users",
+		RetryBackoff:       time.Millisecond,
+		MaxPublishAttempts: 3,
+		DeadLetter:         func(ctx context.Context, raw bson.Raw, err error) { dead = append(dead, err) },
+	})
+	require.NoError(t, err)
+	ctx := t.Context()
+	event := bson.Raw(bsonDoc(t, bson.D{{Key: "_id", Value: bson.D{{Key: "_data", Value: "1"}}}, {Key: "operationType", Value: "insert"}}))
+
+	// 临时错误重试后成功
+	failures = []error{errors.New("unavailable")}
+	require.NoError(t, f.deliver(ctx, event))
+	assert.Len(t, published, 2)
+	assert.Empty(t, dead)
+
+	// 永久错误不重试，交给 DeadLetter 后继续
+	published, failures = nil, []error{ErrPermanentDelivery}
+	require.NoError(t, f.deliver(ctx, event))
+	assert.Len(t, published, 1)
+	require.Len(t, dead, 1)
+
+	// 超过最大投递次数按永久错误处理
+	published, failures = nil, []error{errors.New("a"), errors.New("b"), errors.New("c")}
+	require.NoError(t, f.deliver(ctx, event))
+	assert.Len(t, published, 3)
+	require.Len(t, dead, 2)
+	assert.ErrorIs(t, dead[1], ErrPermanentDelivery)
+
+	// 无法解码的事件直接跳过
+	published = nil
+	require.NoError(t, f.deliver(ctx, bson.Raw(bsonDoc(t, bson.D{{Key: "operationType", Value: 1}}))))
+	assert.Empty(t, published)
+	require.Len(t, dead, 3)
+
+	// 没有最大次数时临时错误一直重试，ctx 取消后返回错误
+	f.opts.MaxPublishAttempts = 0
+	cancelled, cancel := context.WithCancel(ctx)
+	f.sink = SinkFunc(func(ctx context.Context, event *ChangeEvent) error {
+		cancel()
+		return errors.New("unavailable")
+	})
+	assert.Error(t, f.deliver(cancelled, event))
+	assert.Len(t, dead, 3)
+}
+
+func TestChangeStreamForwarderReopensAfterInvalidate(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	insert := bson.D{{Key: "_id", Value: bson.D{{Key: "_data", Value: "01"}}}, {Key: "operationType", Value: "insert"}}
+	invalidate := bson.D{{Key: "_id", Value: bson.D{{Key: "_data", Value: "02"}}}, {Key: "operationType", Value: "invalidate"}}
+
+	server := newFakeServer(t, true)
+	var opened atomic.Int32
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "aggregate" {
+			return nil
+		}
+		// 第一次打开收到 invalidate 后服务端关闭游标，第二次打开后结束测试
+		if opened.Add(1) == 1 {
+			return fakeCursor(cmd, insert, invalidate)
+		}
+		cancel()
+		return fakeCursor(cmd)
+	})
+	client := server.client(t)
+	var logs bytes.Buffer
+	client.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	var published []string
+	sink := SinkFunc(func(ctx context.Context, event *ChangeEvent) error {
+		published = append(published, event.OperationType)
+		return nil
+	})
+	checkpoints := memoryCheckpoints{}
+	f, err := NewChangeStreamForwarder(client, "users", sink, checkpoints, ForwarderOptions{Name: "users", RetryBackoff: time.Hour})
+	require.NoError(t, err)
+
+	require.NoError(t, f.Run(ctx))
+	assert.Equal(t, []string{"insert", "invalidate"}, published)
+
+	// 从 invalidate 事件的令牌重新打开，不按失败退避
+	streams := server.Commands("aggregate")
+	require.Len(t, streams, 2)
+	startAfter := streams[1].Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$changeStream", "startAfter", "_data")
+	assert.Equal(t, "02", startAfter.StringValue())
+	assert.Contains(t, logs.String(), "Change stream ended, reopening from checkpoint")
+	assert.NotContains(t, logs.String(), "interrupted")
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/JustinRoc/pkg/slogw"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -16,6 +16,16 @@ type Client struct {
 	client   *mongo.Client
 	database *mongo.Database
 	dbName   string
+	logger   Logger
+
+	idStrategy       IDStrategy
+	operationTimeout time.Duration
+	limiter          *Limiter
+	monitor          *clientMonitor
+	readOnly         bool
+	server           *serverInfoCache
+
+	lifecycle lifecycle
 }
 
 // Config MongoDB 连接配置
@@ -25,6 +35,55 @@ type Config struct {
 	ConnectTimeout time.Duration `json:"connect_timeout"`
 	MaxPoolSize    uint64        `json:"max_pool_size"`
 	MinPoolSize    uint64        `json:"min_pool_size"`
+
+	ReplicaSet             string        `json:"replica_set,omitempty"`
+	ReadPreference         string        `json:"read_preference,omitempty"` // primary、primaryPreferred、secondary、secondaryPreferred、nearest
+	WriteConcern           string        `json:"write_concern,omitempty"`   // majority、节点数量或自定义标签
+	WriteConcernJournal    *bool         `json:"write_concern_journal,omitempty"`
+	RetryWrites            *bool         `json:"retry_writes,omitempty"`
+	RetryReads             *bool         `json:"retry_reads,omitempty"`
+	Compressors            []string      `json:"compressors,omitempty"` // snappy、zlib、zstd，按顺序与服务端协商
+	ServerSelectionTimeout time.Duration `json:"server_selection_timeout,omitempty"`
+	AppName                string        `json:"app_name,omitempty"`
+	DirectConnection       *bool         `json:"direct_connection,omitempty"`
+
+	// ZlibLevel、ZstdLevel 压缩级别，仅在 Compressors 包含对应算法时生效；
+	// zlib 取值 -1 到 9（-1 为库默认值），zstd 取值 1 到 20（默认 6），跨机房链路可以适当调高以节省带宽
+	ZlibLevel *int `json:"zlib_level,omitempty"`
+	ZstdLevel *int `json:"zstd_level,omitempty"`
+
+	// MaxStaleness、ReadTags、HedgedReads 为 ReadPreference 的附加选项，不能与 primary 同时使用，参见 ReadPreferenceOptions
+	MaxStaleness time.Duration       `json:"max_staleness,omitempty"`
+	ReadTags     []map[string]string `json:"read_tags,omitempty"`
+	HedgedReads  *bool               `json:"hedged_reads,omitempty"`
+
+	// OperationTimeout CRUD 操作的默认超时，ctx 没有 deadline 时生效，并据此设置查询的服务端 maxTimeMS，
+	// 避免失控的查询长期占用连接；为 0 时不限制，单个集合可以通过 Collection.WithTimeout 覆盖
+	OperationTimeout time.Duration `json:"operation_timeout,omitempty"`
+
+	// TLS 不为空时启用 TLS 连接
+	TLS *TLSConfig `json:"tls,omitempty"`
+	// Auth 不为空时使用显式认证配置，覆盖 URI 中的凭据
+	Auth *AuthConfig `json:"auth,omitempty"`
+	// Encryption 不为空时启用客户端字段级加密，插入和查询时自动加解密
+	Encryption *EncryptionConfig `json:"encryption,omitempty"`
+
+	// ClientOptions 额外的驱动客户端选项，在其它配置之后应用，用于覆盖或补充未暴露的选项
+	ClientOptions []*options.ClientOptions `json:"-"`
+	// Logger 内部日志（连接、索引创建删除、事务重试等）输出的目标，为空时使用 slog.Default()
+	Logger Logger `json:"-"`
+	// CommandMonitor 命令监控，用于查询日志和 APM，内置实现见 NewCommandLogger
+	CommandMonitor *event.CommandMonitor `json:"-"`
+	// ReadOnly 只读模式，集合的写操作以及索引、校验规则、视图等管理操作返回 *ReadOnlyError，
+	// 适合分析服务和连接生产环境的管理工具；通过 GetDatabase/GetCollection 直接使用驱动的操作不受限制，
+	// 需要严格保证时应同时使用只读权限的数据库账号
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Monitor 连接池和拓扑事件回调，连接池统计通过 Client.PoolStats 读取
+	Monitor *MonitorHooks `json:"-"`
+	// Limiter 所有集合默认的并发限制器，为空时不限制，单个集合可以通过 Collection.WithLimiter 覆盖
+	Limiter *Limiter `json:"-"`
+	// IDStrategy 所有集合默认的文档 ID 策略，例如 NewULIDGenerator()，为空时使用 ObjectID
+	IDStrategy IDStrategy `json:"-"`
 }
 
 // DefaultConfig 返回默认配置
@@ -38,47 +97,97 @@ func DefaultConfig() *Config {
 	}
 }
 
+// defaultOperationTimeout 未指定 deadline 时 Ping/Close 使用的超时时间
+const defaultOperationTimeout = 5 * time.Second
+
 // NewClient 创建新的 MongoDB 客户端
 func NewClient(config *Config) (*Client, error) {
+	return NewClientWithContext(context.Background(), config)
+}
+
+// NewClientWithContext 使用指定上下文创建新的 MongoDB 客户端
+// ctx 控制连接和首次 Ping 的取消与超时，ctx 没有 deadline 时 Ping 默认 5 秒超时
+func NewClientWithContext(ctx context.Context, config *Config) (*Client, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	logger := config.Logger
+	if logger == nil {
+		logger = defaultLogger()
+	}
 
 	// 设置客户端选项
-	clientOptions := options.Client().
-		ApplyURI(config.URI).
-		SetConnectTimeout(config.ConnectTimeout).
-		SetMaxPoolSize(config.MaxPoolSize).
-		SetMinPoolSize(config.MinPoolSize)
+	clientOptions, err := buildClientOptions(config)
+	if err != nil {
+		return nil, err
+	}
+	// 事件监控设置在基础选项上，Config.ClientOptions 中的 PoolMonitor/ServerMonitor 仍然可以覆盖
+	monitor := newClientMonitor(config.Monitor, logger)
+	clientOptions[0].SetPoolMonitor(monitor.poolMonitor()).SetServerMonitor(monitor.serverMonitor())
 
 	// 连接到 MongoDB
-	client, err := mongo.Connect(context.Background(), clientOptions)
+	client, err := mongo.Connect(ctx, clientOptions...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
 
 	// 测试连接
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	pingCtx, cancel := withDefaultTimeout(ctx)
 	defer cancel()
 
-	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+	if err := client.Ping(pingCtx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(context.Background())
 		return nil, fmt.Errorf("failed to ping MongoDB: %w", err)
 	}
 
-	slogw.Info("Successfully connected to MongoDB", "uri", config.URI, "database", config.Database)
+	logger.InfoContext(ctx, "Successfully connected to MongoDB", "uri", config.URI, "database", config.Database)
 
 	return &Client{
 		client:   client,
 		database: client.Database(config.Database),
 		dbName:   config.Database,
+		logger:   logger,
+
+		idStrategy:       config.IDStrategy,
+		operationTimeout: config.OperationTimeout,
+		limiter:          config.Limiter,
+		monitor:          monitor,
+		readOnly:         config.ReadOnly,
+		server:           &serverInfoCache{},
 	}, nil
 }
 
+// withDefaultTimeout ctx 没有 deadline 时附加默认超时
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, defaultOperationTimeout)
+}
+
 // GetDatabase 获取数据库实例
 func (c *Client) GetDatabase() *mongo.Database {
 	return c.database
 }
 
+// WithDatabase 返回共享同一连接池、绑定到另一个数据库的客户端，其他设置与 c 相同；
+// 关闭返回的客户端会断开共享的连接，Shutdown 只等待通过返回的客户端发起的操作
+func (c *Client) WithDatabase(name string) *Client {
+	return &Client{
+		client:   c.client,
+		database: c.client.Database(name),
+		dbName:   name,
+		logger:   c.logger,
+
+		idStrategy:       c.idStrategy,
+		operationTimeout: c.operationTimeout,
+		limiter:          c.limiter,
+		monitor:          c.monitor,
+		readOnly:         c.readOnly,
+		server:           c.server,
+	}
+}
+
 // GetCollection 获取集合实例
 func (c *Client) GetCollection(name string) *mongo.Collection {
 	return c.database.Collection(name)
@@ -86,8 +195,13 @@ func (c *Client) GetCollection(name string) *mongo.Collection {
 
 // Close 关闭客户端连接
 func (c *Client) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext 使用指定上下文关闭客户端连接，ctx 没有 deadline 时默认 5 秒超时
+func (c *Client) CloseContext(ctx context.Context) error {
 	if c.client != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		ctx, cancel := withDefaultTimeout(ctx)
 		defer cancel()
 		return c.client.Disconnect(ctx)
 	}
@@ -96,7 +210,12 @@ func (c *Client) Close() error {
 
 // Ping 测试连接
 func (c *Client) Ping() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return c.PingContext(context.Background())
+}
+
+// PingContext 使用指定上下文测试连接，ctx 没有 deadline 时默认 5 秒超时
+func (c *Client) PingContext(ctx context.Context) error {
+	ctx, cancel := withDefaultTimeout(ctx)
 	defer cancel()
 	return c.client.Ping(ctx, readpref.Primary())
 }
@@ -104,4 +223,9 @@ func (c *Client) Ping() error {
 // GetDatabaseName 获取数据库名称
 func (c *Client) GetDatabaseName() string {
 	return c.dbName
-}
\ No newline at end of file
+}
+
+// Logger 获取客户端使用的日志实现
+func (c *Client) Logger() Logger {
+	return c.logger
+}
@@ -0,0 +1,181 @@
+package mongo
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/tag"
+)
+
+// buildClientOptions 根据配置构建驱动的客户端选项
+// Config.ClientOptions 追加在最后，驱动合并时会覆盖前面的同名选项
+func buildClientOptions(config *Config) ([]*options.ClientOptions, error) {
+	clientOptions := options.Client().
+		ApplyURI(config.URI).
+		SetConnectTimeout(config.ConnectTimeout).
+		SetMaxPoolSize(config.MaxPoolSize).
+		SetMinPoolSize(config.MinPoolSize)
+
+	if config.ReplicaSet != "" {
+		clientOptions.SetReplicaSet(config.ReplicaSet)
+	}
+	if config.ReadPreference != "" {
+		rp, err := NewReadPreference(config.ReadPreference, &ReadPreferenceOptions{
+			MaxStaleness: config.MaxStaleness,
+			TagSets:      config.ReadTags,
+			Hedged:       config.HedgedReads,
+		})
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetReadPreference(rp)
+	} else if config.MaxStaleness > 0 || len(config.ReadTags) > 0 || config.HedgedReads != nil {
+		return nil, fmt.Errorf("read preference mode is required with max staleness, read tags or hedged reads")
+	}
+	if config.WriteConcern != "" || config.WriteConcernJournal != nil {
+		clientOptions.SetWriteConcern(parseWriteConcern(config.WriteConcern, config.WriteConcernJournal))
+	}
+	if config.RetryWrites != nil {
+		clientOptions.SetRetryWrites(*config.RetryWrites)
+	}
+	if config.RetryReads != nil {
+		clientOptions.SetRetryReads(*config.RetryReads)
+	}
+	if len(config.Compressors) > 0 {
+		if err := validateCompressors(config); err != nil {
+			return nil, err
+		}
+		clientOptions.SetCompressors(config.Compressors)
+		if config.ZlibLevel != nil {
+			clientOptions.SetZlibLevel(*config.ZlibLevel)
+		}
+		if config.ZstdLevel != nil {
+			clientOptions.SetZstdLevel(*config.ZstdLevel)
+		}
+	}
+	if config.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(config.ServerSelectionTimeout)
+	}
+	if config.AppName != "" {
+		clientOptions.SetAppName(config.AppName)
+	}
+	if config.DirectConnection != nil {
+		clientOptions.SetDirect(*config.DirectConnection)
+	}
+	if config.CommandMonitor != nil {
+		clientOptions.SetMonitor(config.CommandMonitor)
+	}
+	if config.TLS != nil {
+		tlsConfig, err := buildTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetTLSConfig(tlsConfig)
+	}
+	if config.Auth != nil {
+		credential, err := buildCredential(config.Auth)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetAuth(credential)
+	}
+	if config.Encryption != nil {
+		autoEncryption, err := buildAutoEncryptionOptions(config.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetAutoEncryptionOptions(autoEncryption)
+	}
+
+	return append([]*options.ClientOptions{clientOptions}, config.ClientOptions...), nil
+}
+
+// MinMaxStaleness 服务端允许的最小 maxStalenessSeconds
+const MinMaxStaleness = 90 * time.Second
+
+// ReadPreferenceOptions 读偏好的附加选项，只能用于 primary 以外的模式
+type ReadPreferenceOptions struct {
+	// MaxStaleness 从节点允许落后主节点的最长时间，超过的从节点不会被选中，不能小于 90 秒；为 0 时不限制
+	MaxStaleness time.Duration
+	// TagSets 按顺序匹配的节点标签集合，第一个有匹配节点的标签集合生效，空标签集合 {} 匹配任意节点，
+	// 例如 [{"workload": "analytics"}, {}] 优先读取分析节点，没有时退回任意从节点
+	TagSets []map[string]string
+	// Hedged 在分片集群上启用对冲读，mongos 同时向两个副本发送读请求并使用先返回的结果；
+	// 为空时使用服务端默认值（nearest 模式默认启用）
+	Hedged *bool
+}
+
+// NewReadPreference 根据模式和附加选项创建读偏好，模式为 primary、primaryPreferred、secondary、
+// secondaryPreferred、nearest；适合与 Collection.WithReadPreference 配合将分析类查询分流到从节点：
+//
+//	rp, err := NewReadPreference("secondaryPreferred", &ReadPreferenceOptions{
+//		MaxStaleness: 2 * time.Minute,
+//		TagSets:      []map[string]string{{"workload": "analytics"}, {}},
+//	})
+//	reports := articles.WithReadPreference(rp)
+func NewReadPreference(mode string, opts *ReadPreferenceOptions) (*readpref.ReadPref, error) {
+	m, err := readpref.ModeFromString(mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read preference %q: %w", mode, err)
+	}
+
+	var rpOpts []readpref.Option
+	if opts != nil {
+		if opts.MaxStaleness > 0 {
+			if opts.MaxStaleness < MinMaxStaleness {
+				return nil, fmt.Errorf("invalid read preference %q: max staleness %s is less than %s", mode, opts.MaxStaleness, MinMaxStaleness)
+			}
+			rpOpts = append(rpOpts, readpref.WithMaxStaleness(opts.MaxStaleness))
+		}
+		if len(opts.TagSets) > 0 {
+			rpOpts = append(rpOpts, readpref.WithTagSets(tag.NewTagSetsFromMaps(opts.TagSets)...))
+		}
+		if opts.Hedged != nil {
+			rpOpts = append(rpOpts, readpref.WithHedgeEnabled(*opts.Hedged))
+		}
+	}
+	rp, err := readpref.New(m, rpOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read preference %q: %w", mode, err)
+	}
+	return rp, nil
+}
+
+// validateCompressors 检查压缩算法名称和压缩级别，避免拼写错误时静默退回不压缩
+func validateCompressors(config *Config) error {
+	for _, name := range config.Compressors {
+		switch name {
+		case "snappy", "zlib", "zstd":
+		default:
+			return fmt.Errorf("unsupported compressor %q, expected snappy, zlib or zstd", name)
+		}
+	}
+	if level := config.ZlibLevel; level != nil && (*level < -1 || *level > 9) {
+		return fmt.Errorf("invalid zlib compression level %d, expected -1 to 9", *level)
+	}
+	if level := config.ZstdLevel; level != nil && (*level < 1 || *level > 20) {
+		return fmt.Errorf("invalid zstd compression level %d, expected 1 to 20", *level)
+	}
+	return nil
+}
+
+// parseWriteConcern 解析写关注，w 可以是 majority、节点数量或自定义标签
+func parseWriteConcern(w string, journal *bool) *writeconcern.WriteConcern {
+	wc := &writeconcern.WriteConcern{Journal: journal}
+	switch {
+	case w == "":
+	case w == "majority":
+		wc.W = "majority"
+	default:
+		if n, err := strconv.Atoi(w); err == nil {
+			wc.W = n
+		} else {
+			wc.W = w
+		}
+	}
+	return wc
+}
@@ -0,0 +1,114 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestNewReadPreference(t *testing.T) {
+	hedged := true
+	rp, err := NewReadPreference("secondaryPreferred", &ReadPreferenceOptions{
+		MaxStaleness: 2 * time.Minute,
+		TagSets:      []map[string]string{{"workload": "analytics"}, {}},
+		Hedged:       &hedged,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, readpref.SecondaryPreferredMode, rp.Mode())
+	maxStaleness, ok := rp.MaxStaleness()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, maxStaleness)
+	require.Len(t, rp.TagSets(), 2)
+	assert.True(t, rp.TagSets()[0].Contains("workload", "analytics"))
+	assert.Equal(t, &hedged, rp.HedgeEnabled())
+
+	_, err = NewReadPreference("secondary", &ReadPreferenceOptions{MaxStaleness: 30 * time.Second})
+	assert.Error(t, err)
+	_, err = NewReadPreference("primary", &ReadPreferenceOptions{TagSets: []map[string]string{{"dc": "east"}}})
+	assert.Error(t, err)
+	_, err = NewReadPreference("fastest", nil)
+	assert.Error(t, err)
+}
+
+func TestBuildClientOptionsReadPreference(t *testing.T) {
+	config := DefaultConfig()
+	config.ReadPreference = "nearest"
+	config.ReadTags = []map[string]string{{"dc": "east"}}
+	opts, err := buildClientOptions(config)
+	require.NoError(t, err)
+	assert.Equal(t, readpref.NearestMode, opts[0].ReadPreference.Mode())
+
+	config.ReadPreference = ""
+	_, err = buildClientOptions(config)
+	assert.Error(t, err)
+}
+
+func TestBuildClientOptionsCompressors(t *testing.T) {
+	level := 3
+	config := DefaultConfig()
+	config.Compressors = []string{"zstd", "snappy"}
+	config.ZstdLevel = &level
+	opts, err := buildClientOptions(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zstd", "snappy"}, opts[0].Compressors)
+	assert.Equal(t, &level, opts[0].ZstdLevel)
+
+	config.Compressors = []string{"gzip"}
+	_, err = buildClientOptions(config)
+	assert.Error(t, err)
+
+	invalid := 21
+	config.Compressors = []string{"zstd"}
+	config.ZstdLevel = &invalid
+	_, err = buildClientOptions(config)
+	assert.Error(t, err)
+}
+
+func TestBuildClientOptions(t *testing.T) {
+	journal, retry, direct := true, false, true
+	config := DefaultConfig()
+	config.ReplicaSet = "rs0"
+	config.WriteConcern = "majority"
+	config.WriteConcernJournal = &journal
+	config.RetryWrites = &retry
+	config.RetryReads = &retry
+	config.ServerSelectionTimeout = 3 * time.Second
+	config.AppName = "billing"
+	config.DirectConnection = &direct
+	config.ClientOptions = []*options.ClientOptions{options.Client().SetAppName("override")}
+
+	opts, err := buildClientOptions(config)
+	require.NoError(t, err)
+	require.Len(t, opts, 2, "extra client options are applied last")
+	assert.Equal(t, "rs0", *opts[0].ReplicaSet)
+	assert.Equal(t, &writeconcern.WriteConcern{W: "majority", Journal: &journal}, opts[0].WriteConcern)
+	assert.False(t, *opts[0].RetryWrites)
+	assert.False(t, *opts[0].RetryReads)
+	assert.Equal(t, 3*time.Second, *opts[0].ServerSelectionTimeout)
+	assert.Equal(t, "billing", *opts[0].AppName)
+	assert.True(t, *opts[0].Direct)
+	assert.Equal(t, "override", *options.MergeClientOptions(opts...).AppName)
+
+	// 未配置的选项保持驱动默认值
+	opts, err = buildClientOptions(DefaultConfig())
+	require.NoError(t, err)
+	assert.Nil(t, opts[0].ReplicaSet)
+	assert.Nil(t, opts[0].WriteConcern)
+	assert.Nil(t, opts[0].RetryWrites)
+	assert.Nil(t, opts[0].Direct)
+}
+
+func TestParseWriteConcern(t *testing.T) {
+	journal := false
+	assert.Equal(t, "majority", parseWriteConcern("majority", nil).W)
+	assert.Equal(t, 2, parseWriteConcern("2", nil).W)
+	assert.Equal(t, "multiDC", parseWriteConcern("multiDC", nil).W, "custom tag sets are passed through")
+	wc := parseWriteConcern("", &journal)
+	assert.Nil(t, wc.W)
+	assert.Equal(t, &journal, wc.Journal)
+}
@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDefaultTimeout(t *testing.T) {
+	ctx, cancel := withDefaultTimeout(t.Context())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(defaultOperationTimeout), deadline, time.Second)
+
+	// 调用方的 deadline 优先
+	parent, cancelParent := context.WithTimeout(t.Context(), time.Minute)
+	defer cancelParent()
+	ctx, cancel = withDefaultTimeout(parent)
+	defer cancel()
+	assert.Equal(t, parent, ctx)
+}
+
+func TestNewClientWithContext(t *testing.T) {
+	server := newFakeServer(t, false)
+	client, err := NewClientWithContext(t.Context(), fakeServerConfig(server))
+	require.NoError(t, err)
+	assert.Equal(t, "test", client.GetDatabaseName())
+	require.NoError(t, client.PingContext(t.Context()))
+
+	server.failPing.Store(true)
+	assert.Error(t, client.PingContext(t.Context()))
+	server.failPing.Store(false)
+
+	require.NoError(t, client.CloseContext(t.Context()))
+	assert.Error(t, client.Ping(), "the client is disconnected")
+
+	// 连接失败时遵守 ctx 的超时，不等待默认的 5 秒
+	config := fakeServerConfig(server)
+	config.URI = "mongodb://127.0.0.1:1"
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = NewClientWithContext(ctx, config)
+	assert.ErrorContains(t, err, "failed to ping MongoDB")
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	server.failPing.Store(true)
+	_, err = NewClientWithContext(t.Context(), fakeServerConfig(server))
+	assert.ErrorContains(t, err, "failed to ping MongoDB")
+}
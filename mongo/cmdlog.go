@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// CommandLogOptions 命令日志配置
+type CommandLogOptions struct {
+	// SampleRate 记录成功命令的比例，取值 0 到 1；为 0 时只记录失败和慢命令。opts 为 nil 时记录全部命令
+	SampleRate float64
+	// SlowThreshold 执行时间超过该值的命令总是以 Warn 级别记录，为 0 时不区分慢命令
+	SlowThreshold time.Duration
+	// MaxCommandLength 日志中命令文本的最大长度，超出部分截断，默认 1000
+	MaxCommandLength int
+	// RedactFields 额外需要脱敏的字段名（不区分大小写），内置 pwd、password、secret、token 等
+	RedactFields []string
+}
+
+// defaultRedactFields 命令中总是脱敏的字段；认证相关命令（saslStart、authenticate 等）的内容驱动本身不会上报
+var defaultRedactFields = []string{"pwd", "password", "secret", "token", "apikey", "payload"}
+
+// CommandLogger 记录驱动执行的命令，用于查询日志和接入 APM
+// 通过 Monitor 取得 *event.CommandMonitor 后配置到 Config.CommandMonitor：
+//
+//	config.CommandMonitor = NewCommandLogger(logger, &CommandLogOptions{
+//		SampleRate:    0.01,
+//		SlowThreshold: 200 * time.Millisecond,
+//	}).Monitor()
+//
+// 被采样的命令在完成时以 Debug 级别记录命令文本和耗时；失败和慢命令不受采样影响，以 Warn 级别记录，
+// 未被采样时不包含命令文本
+type CommandLogger struct {
+	logger Logger
+	opts   CommandLogOptions
+	redact map[string]bool
+
+	// started 被采样命令的文本，按 RequestID 在完成事件中取出
+	started sync.Map
+}
+
+// NewCommandLogger 创建命令日志记录器，logger 为空时使用 slog.Default()
+func NewCommandLogger(logger Logger, opts *CommandLogOptions) *CommandLogger {
+	if logger == nil {
+		logger = defaultLogger()
+	}
+	l := &CommandLogger{logger: logger, opts: CommandLogOptions{SampleRate: 1}, redact: make(map[string]bool)}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.MaxCommandLength <= 0 {
+		l.opts.MaxCommandLength = 1000
+	}
+	for _, field := range append(defaultRedactFields, l.opts.RedactFields...) {
+		l.redact[strings.ToLower(field)] = true
+	}
+	return l
+}
+
+// Monitor 返回驱动的命令监控
+func (l *CommandLogger) Monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   l.commandStarted,
+		Succeeded: l.commandSucceeded,
+		Failed:    l.commandFailed,
+	}
+}
+
+func (l *CommandLogger) commandStarted(ctx context.Context, e *event.CommandStartedEvent) {
+	if l.opts.SampleRate <= 0 || (l.opts.SampleRate < 1 && rand.Float64() >= l.opts.SampleRate) {
+		return
+	}
+	l.started.Store(e.RequestID, l.formatCommand(e.Command))
+}
+
+func (l *CommandLogger) commandSucceeded(ctx context.Context, e *event.CommandSucceededEvent) {
+	command, sampled := l.started.LoadAndDelete(e.RequestID)
+	slow := l.opts.SlowThreshold > 0 && e.Duration >= l.opts.SlowThreshold
+	if !sampled && !slow {
+		return
+	}
+	args := l.eventArgs(&e.CommandFinishedEvent, command)
+	if slow {
+		l.logger.WarnContext(ctx, "Slow MongoDB command", args...)
+		return
+	}
+	l.logger.DebugContext(ctx, "MongoDB command succeeded", args...)
+}
+
+func (l *CommandLogger) commandFailed(ctx context.Context, e *event.CommandFailedEvent) {
+	command, _ := l.started.LoadAndDelete(e.RequestID)
+	args := append(l.eventArgs(&e.CommandFinishedEvent, command), "failure", e.Failure)
+	l.logger.WarnContext(ctx, "MongoDB command failed", args...)
+}
+
+// eventArgs 命令完成事件的日志字段
+func (l *CommandLogger) eventArgs(e *event.CommandFinishedEvent, command any) []any {
+	args := []any{
+		"command_name", e.CommandName,
+		"database", e.DatabaseName,
+		"request_id", e.RequestID,
+		"connection_id", e.ConnectionID,
+		"duration", e.Duration,
+	}
+	if command != nil {
+		args = append(args, "command", command)
+	}
+	return args
+}
+
+// formatCommand 将命令脱敏后编码为宽松扩展 JSON，超过 MaxCommandLength 时截断
+func (l *CommandLogger) formatCommand(raw bson.Raw) string {
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return ""
+	}
+	data, err := bson.MarshalExtJSON(l.redactValue(doc), false, false)
+	if err != nil {
+		return ""
+	}
+	if len(data) > l.opts.MaxCommandLength {
+		return string(data[:l.opts.MaxCommandLength]) + "..."
+	}
+	return string(data)
+}
+
+// redactValue 递归替换敏感字段的值
+func (l *CommandLogger) redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case bson.D:
+		out := make(bson.D, len(value))
+		for i, elem := range value {
+			if l.redact[strings.ToLower(elem.Key)] {
+				out[i] = bson.E{Key: elem.Key, Value: "***"}
+				continue
+			}
+			out[i] = bson.E{Key: elem.Key, Value: l.redactValue(elem.Value)}
+		}
+		return out
+	case bson.A:
+		out := make(bson.A, len(value))
+		for i, item := range value {
+			out[i] = l.redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,69 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestCommandLoggerRedact(t *testing.T) {
+	l := NewCommandLogger(nil, &CommandLogOptions{MaxCommandLength: 80, RedactFields: []string{"ssn"}})
+	raw, err := bson.Marshal(bson.D{
+		{Key: "createUser", Value: "app"},
+		{Key: "pwd", Value: "hunter2"},
+		{Key: "documents", Value: bson.A{bson.D{{Key: "SSN", Value: "123-45-6789"}}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"createUser":"app","pwd":"***","documents":[{"SSN":"***"}]}`, l.formatCommand(raw))
+
+	raw, err = bson.Marshal(bson.D{{Key: "find", Value: "articles"}, {Key: "filter", Value: bson.D{{Key: "body", Value: strings.Repeat("x", 100)}}}})
+	require.NoError(t, err)
+	formatted := l.formatCommand(raw)
+	assert.Len(t, formatted, 83)
+	assert.True(t, strings.HasSuffix(formatted, "..."))
+}
+
+func TestCommandLoggerSampling(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	monitor := NewCommandLogger(logger, &CommandLogOptions{SlowThreshold: 100 * time.Millisecond}).Monitor()
+	command, err := bson.Marshal(bson.D{{Key: "find", Value: "articles"}})
+	require.NoError(t, err)
+
+	finished := func(id int64, d time.Duration) event.CommandFinishedEvent {
+		return event.CommandFinishedEvent{CommandName: "find", DatabaseName: "app", RequestID: id, Duration: d}
+	}
+
+	// SampleRate 为 0 时普通命令不记录
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: command, CommandName: "find", RequestID: 1})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished(1, time.Millisecond)})
+	assert.Empty(t, buf.String())
+
+	// 慢命令和失败的命令总是记录
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: command, CommandName: "find", RequestID: 2})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished(2, time.Second)})
+	assert.Contains(t, buf.String(), "Slow MongoDB command")
+	assert.NotContains(t, buf.String(), "command=")
+
+	buf.Reset()
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: finished(3, time.Millisecond), Failure: "boom"})
+	assert.Contains(t, buf.String(), "MongoDB command failed")
+	assert.Contains(t, buf.String(), "failure=boom")
+
+	// 全部采样时记录命令文本
+	buf.Reset()
+	monitor = NewCommandLogger(logger, nil).Monitor()
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: command, CommandName: "find", RequestID: 4})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: finished(4, time.Millisecond)})
+	assert.Contains(t, buf.String(), "MongoDB command succeeded")
+	assert.Contains(t, buf.String(), `{\"find\":\"articles\"}`)
+}
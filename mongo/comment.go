@@ -0,0 +1,484 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrCommentNotFound 评论不存在
+var ErrCommentNotFound = errors.New("comment not found")
+
+// CommentStatus 评论的审核状态
+type CommentStatus string
+
+const (
+	CommentPending  CommentStatus = "pending"
+	CommentApproved CommentStatus = "approved"
+	CommentRejected CommentStatus = "rejected"
+	CommentSpam     CommentStatus = "spam"
+	// CommentDeleted 已被作者删除，内容被清空，有回复时在线程中保留为占位节点
+	CommentDeleted CommentStatus = "deleted"
+)
+
+// CommentOptions 评论服务配置
+type CommentOptions struct {
+	// Collection 评论集合，默认 comments
+	Collection string
+	// ArticleCollection 文章集合，默认 articles；添加和移除评论时同步维护文章的 comments 数组
+	ArticleCollection string
+	// RequireApproval 为 true 时新评论为 CommentPending，需要审核通过后才会显示
+	RequireApproval bool
+}
+
+// ThreadQuery 读取评论线程的条件
+type ThreadQuery struct {
+	// Statuses 要显示的状态，默认只显示 CommentApproved；CommentDeleted 总是作为占位节点读取，没有可见回复时被剪除
+	Statuses []CommentStatus
+	// MaxDepth 相对于线程根部的最大层数，0 表示不限制；被截断的节点可以通过 ReplyCount 判断是否还有回复
+	MaxDepth int
+}
+
+// CommentNode 评论树的节点
+type CommentNode struct {
+	Comment `bson:",inline"`
+	Replies []*CommentNode `bson:"replies" json:"replies"`
+}
+
+// Comments 文章评论服务，评论通过 ParentID 组成回复树，Path 保存从顶层评论到自身的物化路径；
+// 按 {article_id, path} 排序即为深度优先的展示顺序，读取子树只需要一次前缀查询，不需要 $graphLookup
+//
+// 评论、父评论的 ReplyCount 和文章的 comments 数组在部署支持事务时在同一事务中修改；
+// 不支持事务时 Add 在后续写入失败后删除已插入的评论，Remove 和审核只保证最终一致
+//
+//	comments := NewComments(client, &CommentOptions{RequireApproval: true})
+//	comments.EnsureIndexes(ctx)
+//	reply := &Comment{ArticleID: article.ID, AuthorID: user.ID, ParentID: &parent.ID, Content: "同意"}
+//	err := comments.Add(ctx, reply)
+//	...
+//	comments.Moderate(ctx, reply.ID, CommentApproved)
+//	thread, err := comments.Thread(ctx, article.ID, nil)
+type Comments struct {
+	client     *Client
+	collection *mongo.Collection
+	articles   *mongo.Collection
+	opts       CommentOptions
+}
+
+// NewComments 创建评论服务
+func NewComments(client *Client, opts *CommentOptions) *Comments {
+	o := CommentOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Collection == "" {
+		o.Collection = "comments"
+	}
+	if o.ArticleCollection == "" {
+		o.ArticleCollection = "articles"
+	}
+	return &Comments{
+		client:     client,
+		collection: client.GetCollection(o.Collection),
+		articles:   client.GetCollection(o.ArticleCollection),
+		opts:       o,
+	}
+}
+
+// EnsureIndexes 创建读取线程、按状态统计和审核队列所需的索引
+func (c *Comments) EnsureIndexes(ctx context.Context) error {
+	if err := c.client.checkWritable("createIndexes", c.collection.Name()); err != nil {
+		return err
+	}
+	_, err := c.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "article_id", Value: 1}, {Key: "path", Value: 1}}, Options: options.Index().SetName("idx_article_id_path")},
+		{Keys: bson.D{{Key: "article_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}, Options: options.Index().SetName("idx_article_id_status_created_at")},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("idx_status_created_at")},
+		{Keys: bson.D{{Key: "author_id", Value: 1}}, Options: options.Index().SetName("idx_author_id")},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create comment indexes: %w", err)
+	}
+	return nil
+}
+
+// Add 添加评论，ParentID 不为空时作为回复，Path、Depth、Status 由服务填写
+func (c *Comments) Add(ctx context.Context, comment *Comment) error {
+	if comment.ArticleID.IsZero() || comment.AuthorID.IsZero() {
+		return fmt.Errorf("article and author are required to add a comment")
+	}
+	if comment.Content == "" {
+		return fmt.Errorf("comment content is empty")
+	}
+	if err := c.client.checkWritable("insert", c.collection.Name()); err != nil {
+		return err
+	}
+
+	var parent *Comment
+	if comment.ParentID != nil {
+		p, err := c.Get(ctx, *comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if p.ArticleID != comment.ArticleID {
+			return fmt.Errorf("parent comment %s belongs to another article", p.ID.Hex())
+		}
+		if p.Status == CommentRejected || p.Status == CommentSpam || p.Status == CommentDeleted {
+			return fmt.Errorf("cannot reply to %s comment %s", p.Status, p.ID.Hex())
+		}
+		parent = p
+	}
+
+	comment.ID = primitive.NewObjectID()
+	comment.Path, comment.Depth = commentPath(parent, comment.ID)
+	comment.Status = CommentApproved
+	if c.opts.RequireApproval {
+		comment.Status = CommentPending
+	}
+	comment.ReplyCount = 0
+	comment.BeforeInsert()
+	return c.client.runAtomic(ctx, func(ctx context.Context) error {
+		return c.insert(ctx, comment, parent)
+	})
+}
+
+// insert 插入评论、增加父评论的回复数并关联到文章；不在事务中时后续写入失败会撤销已完成的写入
+func (c *Comments) insert(ctx context.Context, comment *Comment, parent *Comment) error {
+	if _, err := c.collection.InsertOne(ctx, comment); err != nil {
+		return fmt.Errorf("failed to add comment: %w", err)
+	}
+
+	counted := parent != nil && comment.Status == CommentApproved
+	if counted {
+		if err := c.incReplies(ctx, parent.ID, 1); err != nil {
+			c.rollbackInsert(ctx, comment, nil)
+			return err
+		}
+	}
+	_, err := c.articles.UpdateOne(ctx, bson.M{"_id": comment.ArticleID}, bson.M{"$push": bson.M{"comments": comment.ID}})
+	if err != nil {
+		var counter *Comment
+		if counted {
+			counter = parent
+		}
+		c.rollbackInsert(ctx, comment, counter)
+		return fmt.Errorf("failed to link comment to article %s: %w", comment.ArticleID.Hex(), err)
+	}
+	return nil
+}
+
+// rollbackInsert 删除已插入的评论，parent 不为空时同时恢复它的回复数；事务中由事务回滚，不需要撤销
+func (c *Comments) rollbackInsert(ctx context.Context, comment *Comment, parent *Comment) {
+	if inTransaction(ctx) {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	if _, err := c.collection.DeleteOne(ctx, bson.M{"_id": comment.ID}); err != nil {
+		c.client.logger.ErrorContext(ctx, "Failed to roll back comment", "comment", comment.ID.Hex(), "err", err)
+		return
+	}
+	if parent != nil {
+		if err := c.incReplies(ctx, parent.ID, -1); err != nil {
+			c.client.logger.ErrorContext(ctx, "Failed to roll back reply count", "comment", parent.ID.Hex(), "err", err)
+		}
+	}
+}
+
+// Get 读取评论，不存在时返回 ErrCommentNotFound
+func (c *Comments) Get(ctx context.Context, id primitive.ObjectID) (*Comment, error) {
+	var comment Comment
+	err := c.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment %s: %w", id.Hex(), err)
+	}
+	return &comment, nil
+}
+
+// Thread 读取文章的评论树，顶层评论和每层回复都按发布时间排序
+func (c *Comments) Thread(ctx context.Context, articleID primitive.ObjectID, query *ThreadQuery) ([]*CommentNode, error) {
+	filter := bson.M{"article_id": articleID}
+	if query != nil && query.MaxDepth > 0 {
+		filter["depth"] = bson.M{"$lt": query.MaxDepth}
+	}
+	comments, err := c.find(ctx, filter, query)
+	if err != nil {
+		return nil, err
+	}
+	return buildCommentTree(comments, nil), nil
+}
+
+// Replies 读取评论下的回复子树，不包含评论本身
+func (c *Comments) Replies(ctx context.Context, id primitive.ObjectID, query *ThreadQuery) ([]*CommentNode, error) {
+	root, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{
+		"article_id": root.ArticleID,
+		"path":       bson.M{"$regex": commentDescendants(root.Path)},
+	}
+	if query != nil && query.MaxDepth > 0 {
+		filter["depth"] = bson.M{"$lte": root.Depth + query.MaxDepth}
+	}
+	comments, err := c.find(ctx, filter, query)
+	if err != nil {
+		return nil, err
+	}
+	return buildCommentTree(comments, &root.ID), nil
+}
+
+// Count 统计文章指定状态的评论数，statuses 为空时统计 CommentApproved
+func (c *Comments) Count(ctx context.Context, articleID primitive.ObjectID, statuses ...CommentStatus) (int64, error) {
+	if len(statuses) == 0 {
+		statuses = []CommentStatus{CommentApproved}
+	}
+	count, err := c.collection.CountDocuments(ctx, bson.M{"article_id": articleID, "status": bson.M{"$in": statuses}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count comments of article %s: %w", articleID.Hex(), err)
+	}
+	return count, nil
+}
+
+// CountByArticle 批量统计多篇文章已通过审核的评论数，用于文章列表页；没有评论的文章不在结果中
+func (c *Comments) CountByArticle(ctx context.Context, articleIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"article_id": bson.M{"$in": articleIDs}, "status": CommentApproved}}},
+		{{Key: "$group", Value: bson.M{"_id": "$article_id", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := c.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+	var rows []struct {
+		ArticleID primitive.ObjectID `bson:"_id"`
+		Count     int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+	counts := make(map[primitive.ObjectID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ArticleID] = row.Count
+	}
+	return counts, nil
+}
+
+// Pending 审核队列，按发布时间从早到晚返回待审核的评论
+func (c *Comments) Pending(ctx context.Context, limit int64) ([]Comment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := c.collection.Find(ctx, bson.M{"status": CommentPending}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending comments: %w", err)
+	}
+	var comments []Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, fmt.Errorf("failed to list pending comments: %w", err)
+	}
+	return comments, nil
+}
+
+// Moderate 修改评论的审核状态并维护父评论的 ReplyCount，已删除的评论不能再审核
+func (c *Comments) Moderate(ctx context.Context, id primitive.ObjectID, status CommentStatus) error {
+	switch status {
+	case CommentPending, CommentApproved, CommentRejected, CommentSpam:
+	default:
+		return fmt.Errorf("invalid moderation status %q", status)
+	}
+	return c.setStatus(ctx, id, status, bson.M{"status": status})
+}
+
+// Delete 删除评论：清空内容并标记为 CommentDeleted，回复保留在线程中
+func (c *Comments) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return c.setStatus(ctx, id, CommentDeleted, bson.M{"status": CommentDeleted, "content": ""})
+}
+
+// Remove 物理删除评论及其所有回复，并从文章的 comments 数组中移除，返回删除的数量
+func (c *Comments) Remove(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	if err := c.client.checkWritable("delete", c.collection.Name()); err != nil {
+		return 0, err
+	}
+	root, err := c.Get(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int64
+	err = c.client.runAtomic(ctx, func(ctx context.Context) error {
+		var err error
+		removed, err = c.remove(ctx, root)
+		if err != nil && inTransaction(ctx) {
+			// 事务回滚后没有评论被删除
+			removed = 0
+		}
+		return err
+	})
+	if err != nil {
+		return removed, err
+	}
+	c.client.logger.InfoContext(ctx, "Removed comment thread", "comment", id.Hex(), "article", root.ArticleID.Hex(), "count", removed)
+	return removed, nil
+}
+
+// remove 删除评论及其回复，减少父评论的回复数并从文章中移除
+func (c *Comments) remove(ctx context.Context, root *Comment) (int64, error) {
+	id := root.ID
+	filter := bson.M{"article_id": root.ArticleID, "$or": []bson.M{
+		{"_id": root.ID},
+		{"path": bson.M{"$regex": commentDescendants(root.Path)}},
+	}}
+	cursor, err := c.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find replies of comment %s: %w", id.Hex(), err)
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return 0, fmt.Errorf("failed to find replies of comment %s: %w", id.Hex(), err)
+	}
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+
+	result, err := c.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove comment %s: %w", id.Hex(), err)
+	}
+	if root.ParentID != nil && root.Status == CommentApproved {
+		if err := c.incReplies(ctx, *root.ParentID, -1); err != nil {
+			return result.DeletedCount, err
+		}
+	}
+	_, err = c.articles.UpdateOne(ctx, bson.M{"_id": root.ArticleID}, bson.M{"$pull": bson.M{"comments": bson.M{"$in": ids}}})
+	if err != nil {
+		return result.DeletedCount, fmt.Errorf("failed to unlink comments from article %s: %w", root.ArticleID.Hex(), err)
+	}
+	return result.DeletedCount, nil
+}
+
+// setStatus 更新评论状态，根据更新前的状态调整父评论的 ReplyCount
+func (c *Comments) setStatus(ctx context.Context, id primitive.ObjectID, status CommentStatus, set bson.M) error {
+	if err := c.client.checkWritable("update", c.collection.Name()); err != nil {
+		return err
+	}
+	set["updated_at"] = time.Now()
+	return c.client.runAtomic(ctx, func(ctx context.Context) error {
+		var before Comment
+		err := c.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": id, "status": bson.M{"$ne": CommentDeleted}},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetProjection(bson.M{"parent_id": 1, "status": 1}),
+		).Decode(&before)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrCommentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set comment %s to %s: %w", id.Hex(), status, err)
+		}
+		if delta := replyDelta(before.Status, status); delta != 0 && before.ParentID != nil {
+			return c.incReplies(ctx, *before.ParentID, delta)
+		}
+		return nil
+	})
+}
+
+func (c *Comments) incReplies(ctx context.Context, id primitive.ObjectID, delta int64) error {
+	if _, err := c.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"reply_count": delta}}); err != nil {
+		return fmt.Errorf("failed to update reply count of comment %s: %w", id.Hex(), err)
+	}
+	return nil
+}
+
+// find 按 Path 排序读取评论，父评论总是在回复之前
+func (c *Comments) find(ctx context.Context, filter bson.M, query *ThreadQuery) ([]Comment, error) {
+	statuses := []CommentStatus{CommentApproved}
+	if query != nil && len(query.Statuses) > 0 {
+		statuses = query.Statuses
+	}
+	filter["status"] = bson.M{"$in": append(slices.Clone(statuses), CommentDeleted)}
+	cursor, err := c.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "path", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comment thread: %w", err)
+	}
+	var comments []Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, fmt.Errorf("failed to read comment thread: %w", err)
+	}
+	return comments, nil
+}
+
+// commentPath 根据父评论计算物化路径和层数；ObjectID 按时间递增，因此同一层的回复按 Path 排序即按发布时间排序
+func commentPath(parent *Comment, id primitive.ObjectID) (string, int) {
+	if parent == nil {
+		return id.Hex(), 0
+	}
+	return parent.Path + "/" + id.Hex(), parent.Depth + 1
+}
+
+// commentDescendants 匹配 path 下所有回复的前缀正则，锚定的前缀正则可以使用 {article_id, path} 索引
+func commentDescendants(path string) string {
+	return "^" + regexp.QuoteMeta(path+"/")
+}
+
+// replyDelta 状态变化对父评论 ReplyCount 的影响，只统计已通过审核的回复
+func replyDelta(from, to CommentStatus) int64 {
+	switch {
+	case from != CommentApproved && to == CommentApproved:
+		return 1
+	case from == CommentApproved && to != CommentApproved:
+		return -1
+	}
+	return 0
+}
+
+// buildCommentTree 将按 Path 排序的评论组装为树，ParentID 等于 root 的评论为树的根；
+// 父评论不在结果中（未通过审核或超出层数）的回复随父评论一起隐藏，没有可见回复的已删除评论被剪除
+func buildCommentTree(comments []Comment, root *primitive.ObjectID) []*CommentNode {
+	nodes := make(map[primitive.ObjectID]*CommentNode, len(comments))
+	var roots []*CommentNode
+	for _, comment := range comments {
+		node := &CommentNode{Comment: comment, Replies: []*CommentNode{}}
+		switch {
+		case comment.ParentID == nil && root == nil,
+			comment.ParentID != nil && root != nil && *comment.ParentID == *root:
+			roots = append(roots, node)
+		case comment.ParentID != nil:
+			parent, ok := nodes[*comment.ParentID]
+			if !ok {
+				continue
+			}
+			parent.Replies = append(parent.Replies, node)
+		default:
+			continue
+		}
+		nodes[comment.ID] = node
+	}
+	return pruneDeleted(roots)
+}
+
+func pruneDeleted(nodes []*CommentNode) []*CommentNode {
+	kept := nodes[:0]
+	for _, node := range nodes {
+		node.Replies = pruneDeleted(node.Replies)
+		if node.Status == CommentDeleted && len(node.Replies) == 0 {
+			continue
+		}
+		kept = append(kept, node)
+	}
+	return kept
+}
@@ -0,0 +1,145 @@
+package mongo
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCommentPath(t *testing.T) {
+	root := primitive.NewObjectID()
+	path, depth := commentPath(nil, root)
+	assert.Equal(t, root.Hex(), path)
+	assert.Equal(t, 0, depth)
+
+	reply := primitive.NewObjectID()
+	path, depth = commentPath(&Comment{Path: root.Hex(), Depth: 0}, reply)
+	assert.Equal(t, root.Hex()+"/"+reply.Hex(), path)
+	assert.Equal(t, 1, depth)
+
+	descendants := regexp.MustCompile(commentDescendants(root.Hex()))
+	assert.True(t, descendants.MatchString(path))
+	assert.False(t, descendants.MatchString(root.Hex()))
+}
+
+func TestReplyDelta(t *testing.T) {
+	assert.Equal(t, int64(1), replyDelta(CommentPending, CommentApproved))
+	assert.Equal(t, int64(-1), replyDelta(CommentApproved, CommentDeleted))
+	assert.Equal(t, int64(-1), replyDelta(CommentApproved, CommentSpam))
+	assert.Equal(t, int64(0), replyDelta(CommentPending, CommentRejected))
+	assert.Equal(t, int64(0), replyDelta(CommentApproved, CommentApproved))
+}
+
+func TestBuildCommentTree(t *testing.T) {
+	newComment := func(parent *Comment, status CommentStatus) Comment {
+		c := Comment{Status: status}
+		c.ID = primitive.NewObjectID()
+		c.Path, c.Depth = commentPath(parent, c.ID)
+		if parent != nil {
+			c.ParentID = &parent.ID
+		}
+		return c
+	}
+	a := newComment(nil, CommentApproved)
+	a1 := newComment(&a, CommentApproved)
+	a1x := newComment(&a1, CommentApproved)
+	b := newComment(nil, CommentDeleted)
+	b1 := newComment(&b, CommentApproved)
+	c := newComment(nil, CommentDeleted)
+	// d 未通过审核而不在结果中，它的回复同样隐藏
+	d := newComment(nil, CommentPending)
+	d1 := newComment(&d, CommentApproved)
+
+	tree := buildCommentTree([]Comment{a, a1, a1x, b, b1, c, d1}, nil)
+	require.Len(t, tree, 2)
+	assert.Equal(t, a.ID, tree[0].ID)
+	require.Len(t, tree[0].Replies, 1)
+	assert.Equal(t, a1x.ID, tree[0].Replies[0].Replies[0].ID)
+	assert.Equal(t, b.ID, tree[1].ID)
+	assert.Equal(t, b1.ID, tree[1].Replies[0].ID)
+
+	subtree := buildCommentTree([]Comment{a1, a1x}, &a.ID)
+	require.Len(t, subtree, 1)
+	assert.Equal(t, a1.ID, subtree[0].ID)
+	assert.Len(t, subtree[0].Replies, 1)
+
+	assert.Empty(t, buildCommentTree(nil, nil))
+}
+
+func TestCommentsValidation(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	comments := NewComments(client, nil)
+	assert.Equal(t, "comments", comments.collection.Name())
+	assert.Equal(t, "articles", comments.articles.Name())
+
+	assert.Error(t, comments.Add(ctx, &Comment{Content: "hi"}))
+	assert.Error(t, comments.Add(ctx, &Comment{ArticleID: primitive.NewObjectID(), AuthorID: primitive.NewObjectID()}))
+	assert.Error(t, comments.Moderate(ctx, primitive.NewObjectID(), CommentDeleted))
+
+	client.readOnly = true
+	comment := &Comment{ArticleID: primitive.NewObjectID(), AuthorID: primitive.NewObjectID(), Content: "hi"}
+	assert.ErrorIs(t, comments.Add(ctx, comment), ErrReadOnly)
+	assert.ErrorIs(t, comments.Moderate(ctx, primitive.NewObjectID(), CommentApproved), ErrReadOnly)
+	assert.ErrorIs(t, comments.Delete(ctx, primitive.NewObjectID()), ErrReadOnly)
+	_, err := comments.Remove(ctx, primitive.NewObjectID())
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
+
+// commentServer 返回已通过审核的父评论，文章关联失败
+func commentServer(t *testing.T, replicaSet bool) (*fakeServer, *Comment) {
+	server := newFakeServer(t, replicaSet)
+	parent := &Comment{ArticleID: primitive.NewObjectID(), AuthorID: primitive.NewObjectID(), Content: "hi", Status: CommentApproved}
+	parent.ID = primitive.NewObjectID()
+	parent.Path, parent.Depth = commentPath(nil, parent.ID)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		switch {
+		case name == "find" && cmd.Lookup("find").StringValue() == "comments":
+			return fakeCursor(cmd, parent)
+		case name == "update" && cmd.Lookup("update").StringValue() == "articles":
+			return fakeWriteError(2, "bad update")
+		}
+		return nil
+	})
+	return server, parent
+}
+
+func TestCommentsAddRollsBackWithoutTransaction(t *testing.T) {
+	server, parent := commentServer(t, false)
+	comments := NewComments(server.client(t), nil)
+
+	reply := &Comment{ArticleID: parent.ArticleID, AuthorID: primitive.NewObjectID(), ParentID: &parent.ID, Content: "reply"}
+	assert.ErrorContains(t, comments.Add(t.Context(), reply), "failed to link comment")
+
+	// 删除已插入的评论并恢复父评论的回复数
+	del := server.Commands("delete")
+	require.Len(t, del, 1)
+	assert.Equal(t, reply.ID, del[0].Lookup("deletes").Array().Index(0).Value().Document().Lookup("q", "_id").ObjectID())
+	updates := server.Commands("update")
+	require.Len(t, updates, 3)
+	undo := updates[2].Lookup("updates").Array().Index(0).Value().Document()
+	assert.Equal(t, parent.ID, undo.Lookup("q", "_id").ObjectID())
+	assert.Equal(t, int64(-1), undo.Lookup("u", "$inc", "reply_count").AsInt64())
+}
+
+func TestCommentsAddInTransaction(t *testing.T) {
+	server, parent := commentServer(t, true)
+	comments := NewComments(server.client(t), nil)
+
+	reply := &Comment{ArticleID: parent.ArticleID, AuthorID: primitive.NewObjectID(), ParentID: &parent.ID, Content: "reply"}
+	assert.ErrorContains(t, comments.Add(t.Context(), reply), "failed to link comment")
+
+	// 三次写入在同一事务中，失败时由事务回滚而不是补偿删除
+	writes := server.Commands("insert", "update")
+	require.Len(t, writes, 3)
+	for _, cmd := range writes {
+		assert.Equal(t, writes[0].Lookup("txnNumber").Int64(), cmd.Lookup("txnNumber").Int64())
+	}
+	assert.Empty(t, server.Commands("delete"))
+	assert.Len(t, server.Commands("abortTransaction"), 1)
+	assert.Empty(t, server.Commands("commitTransaction"))
+}
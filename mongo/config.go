@@ -0,0 +1,212 @@
+package mongo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidConfig 配置校验失败
+var ErrInvalidConfig = errors.New("invalid config")
+
+// LoadConfigOptions 配置加载选项，按 默认值 → File → EnvFile/环境变量 的顺序叠加，后面的覆盖前面的
+type LoadConfigOptions struct {
+	// File JSON 或 YAML 配置文件，按扩展名（.json、.yaml、.yml）选择格式；为空时跳过。
+	// 字段名与 Config 的 json 标签一致，时长字段可以写成 "10s" 这样的字符串
+	File string
+	// EnvFile 在读取环境变量前加载的 .env 文件，不覆盖已经存在的环境变量；为空时跳过
+	EnvFile string
+	// EnvPrefix 环境变量前缀，例如 MONGO_ 对应 MONGO_URI、MONGO_DATABASE、MONGO_MAX_POOL_SIZE；为空时不读取环境变量
+	EnvPrefix string
+}
+
+// LoadConfig 从配置文件和环境变量加载配置，补齐默认值并校验
+//
+//	config, err := LoadConfig(&LoadConfigOptions{File: "config/mongo.yaml", EnvFile: ".env", EnvPrefix: "MONGO_"})
+//	client, err := NewClient(config)
+//
+// 支持的环境变量（以 MONGO_ 为例）：MONGO_URI、MONGO_DATABASE、MONGO_APP_NAME、MONGO_REPLICA_SET、
+// MONGO_READ_PREFERENCE、MONGO_WRITE_CONCERN、MONGO_MAX_POOL_SIZE、MONGO_MIN_POOL_SIZE、MONGO_COMPRESSORS（逗号分隔）、
+// MONGO_CONNECT_TIMEOUT、MONGO_SERVER_SELECTION_TIMEOUT、MONGO_OPERATION_TIMEOUT（例如 10s）
+func LoadConfig(opts *LoadConfigOptions) (*Config, error) {
+	o := LoadConfigOptions{}
+	if opts != nil {
+		o = *opts
+	}
+
+	config := DefaultConfig()
+	if o.File != "" {
+		if err := loadConfigFile(o.File, config); err != nil {
+			return nil, err
+		}
+	}
+	if o.EnvFile != "" {
+		if err := godotenv.Load(o.EnvFile); err != nil {
+			return nil, fmt.Errorf("failed to load env file %s: %w", o.EnvFile, err)
+		}
+	}
+	if o.EnvPrefix != "" {
+		if err := loadConfigEnv(o.EnvPrefix, config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate 校验配置：URI 格式、数据库名称、连接池大小和超时
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.URI)
+	if err != nil {
+		return fmt.Errorf("%w: malformed uri: %v", ErrInvalidConfig, err)
+	}
+	if u.Scheme != "mongodb" && u.Scheme != "mongodb+srv" {
+		return fmt.Errorf("%w: uri scheme must be mongodb or mongodb+srv, got %q", ErrInvalidConfig, u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%w: uri has no host", ErrInvalidConfig)
+	}
+	if c.Database == "" {
+		return fmt.Errorf("%w: database is required", ErrInvalidConfig)
+	}
+	if strings.ContainsAny(c.Database, `/\. "$`) {
+		return fmt.Errorf("%w: invalid database name %q", ErrInvalidConfig, c.Database)
+	}
+	// MaxPoolSize 为 0 表示不限制
+	if c.MaxPoolSize > 0 && c.MinPoolSize > c.MaxPoolSize {
+		return fmt.Errorf("%w: min pool size %d is greater than max pool size %d", ErrInvalidConfig, c.MinPoolSize, c.MaxPoolSize)
+	}
+	for name, d := range map[string]time.Duration{
+		"connect timeout":          c.ConnectTimeout,
+		"server selection timeout": c.ServerSelectionTimeout,
+		"operation timeout":        c.OperationTimeout,
+	} {
+		if d < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidConfig, name)
+		}
+	}
+	return nil
+}
+
+// loadConfigFile 读取 JSON 或 YAML 配置文件；先解码为 map 再按 json 标签写入 Config，
+// 时长字段的字符串值（例如 "10s"）在写入前转换为纳秒
+func loadConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for _, key := range durationConfigKeys() {
+		s, ok := values[key].(string)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+		}
+		values[key] = int64(d)
+	}
+
+	normalized, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := json.Unmarshal(normalized, config); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// durationConfigKeys Config 中 time.Duration 字段的 json 名称
+func durationConfigKeys() []string {
+	var keys []string
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type != reflect.TypeOf(time.Duration(0)) {
+			continue
+		}
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// loadConfigEnv 使用带前缀的环境变量覆盖配置
+func loadConfigEnv(prefix string, config *Config) error {
+	lookup := func(name string) (string, bool) {
+		value, ok := os.LookupEnv(prefix + name)
+		return value, ok && value != ""
+	}
+
+	for name, target := range map[string]*string{
+		"URI":             &config.URI,
+		"DATABASE":        &config.Database,
+		"APP_NAME":        &config.AppName,
+		"REPLICA_SET":     &config.ReplicaSet,
+		"READ_PREFERENCE": &config.ReadPreference,
+		"WRITE_CONCERN":   &config.WriteConcern,
+	} {
+		if value, ok := lookup(name); ok {
+			*target = value
+		}
+	}
+	for name, target := range map[string]*uint64{
+		"MAX_POOL_SIZE": &config.MaxPoolSize,
+		"MIN_POOL_SIZE": &config.MinPoolSize,
+	} {
+		if value, ok := lookup(name); ok {
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%w: %s%s: %v", ErrInvalidConfig, prefix, name, err)
+			}
+			*target = n
+		}
+	}
+	for name, target := range map[string]*time.Duration{
+		"CONNECT_TIMEOUT":          &config.ConnectTimeout,
+		"SERVER_SELECTION_TIMEOUT": &config.ServerSelectionTimeout,
+		"OPERATION_TIMEOUT":        &config.OperationTimeout,
+	} {
+		if value, ok := lookup(name); ok {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%w: %s%s: %v", ErrInvalidConfig, prefix, name, err)
+			}
+			*target = d
+		}
+	}
+	if value, ok := lookup("COMPRESSORS"); ok {
+		config.Compressors = strings.Split(value, ",")
+		for i := range config.Compressors {
+			config.Compressors[i] = strings.TrimSpace(config.Compressors[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "mongo.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+uri: mongodb://db1:27017,db2:27017/?replicaSet=rs0
+database: app
+max_pool_size: 50
+connect_timeout: 3s
+compressors: [zstd, snappy]
+`), 0o600))
+	config, err := LoadConfig(&LoadConfigOptions{File: yamlPath})
+	require.NoError(t, err)
+	assert.Equal(t, "app", config.Database)
+	assert.Equal(t, uint64(50), config.MaxPoolSize)
+	assert.Equal(t, uint64(5), config.MinPoolSize)
+	assert.Equal(t, 3*time.Second, config.ConnectTimeout)
+	assert.Equal(t, []string{"zstd", "snappy"}, config.Compressors)
+
+	jsonPath := filepath.Join(dir, "mongo.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"uri": "mongodb://localhost", "database": "app", "operation_timeout": 2000000000}`), 0o600))
+	config, err = LoadConfig(&LoadConfigOptions{File: jsonPath})
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, config.OperationTimeout)
+
+	_, err = LoadConfig(&LoadConfigOptions{File: filepath.Join(dir, "mongo.toml")})
+	assert.Error(t, err)
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	envPath := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envPath, []byte("TESTMONGO_DATABASE=from_file\nTESTMONGO_MIN_POOL_SIZE=2\n"), 0o600))
+	t.Setenv("TESTMONGO_URI", "mongodb+srv://cluster0.example.net")
+	t.Setenv("TESTMONGO_DATABASE", "from_env")
+	t.Setenv("TESTMONGO_OPERATION_TIMEOUT", "750ms")
+	t.Setenv("TESTMONGO_COMPRESSORS", "zstd, zlib")
+	t.Cleanup(func() { os.Unsetenv("TESTMONGO_MIN_POOL_SIZE") })
+
+	config, err := LoadConfig(&LoadConfigOptions{EnvFile: envPath, EnvPrefix: "TESTMONGO_"})
+	require.NoError(t, err)
+	assert.Equal(t, "mongodb+srv://cluster0.example.net", config.URI)
+	assert.Equal(t, "from_env", config.Database)
+	assert.Equal(t, uint64(2), config.MinPoolSize)
+	assert.Equal(t, 750*time.Millisecond, config.OperationTimeout)
+	assert.Equal(t, []string{"zstd", "zlib"}, config.Compressors)
+
+	t.Setenv("TESTMONGO_MAX_POOL_SIZE", "many")
+	_, err = LoadConfig(&LoadConfigOptions{EnvPrefix: "TESTMONGO_"})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	for name, mutate := range map[string]func(*Config){
+		"scheme":    func(c *Config) { c.URI = "http://localhost:27017" },
+		"host":      func(c *Config) { c.URI = "mongodb://" },
+		"database":  func(c *Config) { c.Database = "" },
+		"db name":   func(c *Config) { c.Database = "app.test" },
+		"pool size": func(c *Config) { c.MinPoolSize = 200 },
+		"timeout":   func(c *Config) { c.OperationTimeout = -time.Second },
+	} {
+		config := DefaultConfig()
+		mutate(config)
+		assert.ErrorIs(t, config.Validate(), ErrInvalidConfig, name)
+	}
+}
@@ -0,0 +1,183 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CopyOptions 集合复制配置
+type CopyOptions struct {
+	// Filter 只复制匹配的文档，为空时复制全部
+	Filter bson.M
+	// Transform 写入目标集合前对每个文档的转换，例如脱敏；返回 nil 时跳过该文档
+	Transform func(ctx context.Context, doc bson.M) (bson.M, error)
+	// CopyIndexes 复制前在目标集合上创建源集合的索引（_id 索引除外）
+	CopyIndexes bool
+	// Overwrite 按 _id 覆盖目标集合中已存在的文档，默认直接插入，遇到重复 _id 时报错
+	Overwrite bool
+	// BatchSize 每批复制的文档数，默认 1000
+	BatchSize int
+	// Name 和 Checkpoints 同时设置时支持中断后继续复制，参见 ForEachBatch
+	Name        string
+	Checkpoints CheckpointStore
+	// Progress 每批写入后的进度回调
+	Progress func(progress CopyProgress)
+}
+
+// CopyProgress 复制进度
+type CopyProgress struct {
+	// Total 开始复制时源集合中匹配的文档数，只在设置了 Progress 时统计
+	Total int64
+	// Copied 已写入的文档数
+	Copied int64
+	// Skipped Transform 返回 nil 而跳过的文档数
+	Skipped int64
+}
+
+// CopyCollection 将 source 中的文档按 _id 顺序分批复制到 target，source 和 target 可以属于不同的 Client，
+// 适合将生产数据的子集复制到预发环境；写入时直接使用驱动，不触发目标集合的钩子、校验和审计
+//
+//	progress, err := CopyCollection(ctx, prodUsers, stagingUsers, &CopyOptions{
+//		Filter:      bson.M{"tenant_id": "demo"},
+//		CopyIndexes: true,
+//		Transform: func(ctx context.Context, doc bson.M) (bson.M, error) {
+//			doc["email"] = "masked@example.com"
+//			return doc, nil
+//		},
+//	})
+func CopyCollection(ctx context.Context, source, target *Collection, opts *CopyOptions) (*CopyProgress, error) {
+	o := CopyOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+
+	if o.CopyIndexes {
+		if err := copyIndexes(ctx, source, target); err != nil {
+			return nil, err
+		}
+	}
+
+	progress := &CopyProgress{}
+	if o.Progress != nil {
+		filter := o.Filter
+		if filter == nil {
+			filter = bson.M{}
+		}
+		total, err := source.collection.CountDocuments(source.sessionContext(ctx), filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count source documents: %w", err)
+		}
+		progress.Total = total
+	}
+
+	_, err := source.ForEachBatch(ctx, o.Filter, o.BatchSize, func(ctx context.Context, batch []bson.Raw) error {
+		docs := make([]interface{}, 0, len(batch))
+		for _, raw := range batch {
+			var doc bson.M
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return fmt.Errorf("failed to decode document: %w", err)
+			}
+			if o.Transform != nil {
+				transformed, err := o.Transform(ctx, doc)
+				if err != nil {
+					return fmt.Errorf("failed to transform document %v: %w", doc["_id"], err)
+				}
+				if transformed == nil {
+					progress.Skipped++
+					continue
+				}
+				doc = transformed
+			}
+			docs = append(docs, doc)
+		}
+		if err := writeCopyBatch(target.sessionContext(ctx), target, docs, o.Overwrite); err != nil {
+			return err
+		}
+		progress.Copied += int64(len(docs))
+		if o.Progress != nil {
+			o.Progress(*progress)
+		}
+		return nil
+	}, &BatchOptions{Name: o.Name, Checkpoints: o.Checkpoints})
+	return progress, err
+}
+
+// writeCopyBatch 将一批文档写入目标集合
+func writeCopyBatch(ctx context.Context, target *Collection, docs []interface{}, overwrite bool) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	if !overwrite {
+		if _, err := target.collection.InsertMany(ctx, docs); err != nil {
+			return fmt.Errorf("failed to insert documents: %w", translateWriteError(err))
+		}
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(docs))
+	for _, doc := range docs {
+		id, ok := doc.(bson.M)["_id"]
+		if !ok {
+			return fmt.Errorf("document has no _id, cannot overwrite")
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	if _, err := target.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to write documents: %w", translateWriteError(err))
+	}
+	return nil
+}
+
+// copyIndexes 在目标集合上创建源集合的索引，索引定义（唯一、稀疏、TTL、部分索引等选项）原样复制
+func copyIndexes(ctx context.Context, source, target *Collection) error {
+	cursor, err := source.collection.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list source indexes: %w", err)
+	}
+	var specs []bson.M
+	if err := cursor.All(ctx, &specs); err != nil {
+		return fmt.Errorf("failed to list source indexes: %w", err)
+	}
+
+	indexes := copyableIndexSpecs(specs)
+	if len(indexes) == 0 {
+		return nil
+	}
+	err = target.collection.Database().RunCommand(ctx, bson.D{
+		{Key: "createIndexes", Value: target.collection.Name()},
+		{Key: "indexes", Value: indexes},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to create indexes on %s: %w", target.collection.Name(), err)
+	}
+	return nil
+}
+
+// copyableIndexSpecs 去掉 _id 索引以及版本号、命名空间等不能用于 createIndexes 的字段
+func copyableIndexSpecs(specs []bson.M) []bson.M {
+	indexes := make([]bson.M, 0, len(specs))
+	for _, spec := range specs {
+		if spec["name"] == "_id_" {
+			continue
+		}
+		index := bson.M{}
+		for key, value := range spec {
+			if key == "v" || key == "ns" {
+				continue
+			}
+			index[key] = value
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
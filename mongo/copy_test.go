@@ -0,0 +1,20 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCopyableIndexSpecs(t *testing.T) {
+	specs := []bson.M{
+		{"v": int32(2), "key": bson.M{"_id": int32(1)}, "name": "_id_"},
+		{"v": int32(2), "key": bson.M{"email": int32(1)}, "name": "email_1", "unique": true, "ns": "app.users"},
+		{"v": int32(2), "key": bson.M{"expires_at": int32(1)}, "name": "expires_at_1", "expireAfterSeconds": int32(0)},
+	}
+	assert.Equal(t, []bson.M{
+		{"key": bson.M{"email": int32(1)}, "name": "email_1", "unique": true},
+		{"key": bson.M{"expires_at": int32(1)}, "name": "expires_at_1", "expireAfterSeconds": int32(0)},
+	}, copyableIndexSpecs(specs))
+}
@@ -0,0 +1,143 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CountCacheOptions 计数缓存配置
+type CountCacheOptions struct {
+	// TTL 计数结果的缓存时间，默认 30 秒
+	TTL time.Duration
+	// EstimateEmptyFilter 过滤条件为空时使用 EstimatedDocumentCount 读取集合元数据，不扫描索引；
+	// 结果在非正常关闭或孤儿文档存在时可能不准确，适合列表页的总数展示
+	EstimateEmptyFilter bool
+	// Cache 计数结果的存储，默认为容量 10000 的 LRUCache；多个实例共享计数时可以使用 Redis 等外部缓存
+	Cache Cache
+}
+
+// CountCache 按集合和过滤条件缓存计数结果，避免分页列表每次请求都执行 CountDocuments
+// 通过 Collection.WithCountCache 配置后 FindWithPagination 的总数从缓存读取，结果最多滞后 TTL；
+// 写入后需要立即看到准确总数时调用 Invalidate
+//
+//	counts := NewCountCache(&CountCacheOptions{TTL: time.Minute, EstimateEmptyFilter: true})
+//	articles := NewCollection(client, "articles").WithCountCache(counts)
+//	page, err := articles.FindWithPagination(ctx, filter, 1, 20, &results)
+type CountCache struct {
+	opts CountCacheOptions
+
+	mu   sync.Mutex
+	gens map[string]int64
+}
+
+// NewCountCache 创建计数缓存
+func NewCountCache(opts *CountCacheOptions) *CountCache {
+	cc := &CountCache{gens: make(map[string]int64)}
+	if opts != nil {
+		cc.opts = *opts
+	}
+	if cc.opts.TTL <= 0 {
+		cc.opts.TTL = 30 * time.Second
+	}
+	if cc.opts.Cache == nil {
+		cc.opts.Cache = NewLRUCache(10000)
+	}
+	return cc
+}
+
+// Count 返回匹配过滤条件的文档数，优先读取缓存；estimated 表示结果来自 EstimatedDocumentCount
+func (cc *CountCache) Count(ctx context.Context, c *Collection, filter bson.M, opts ...*options.CountOptions) (count int64, estimated bool, err error) {
+	ns := c.collection.Database().Name() + "." + c.collection.Name()
+	estimated = cc.opts.EstimateEmptyFilter && len(filter) == 0
+	key, err := cc.key(ns, filter, estimated, opts)
+	if err != nil {
+		return 0, false, err
+	}
+
+	value, ok, err := cc.opts.Cache.Get(ctx, key)
+	if err != nil {
+		c.cli.logger.WarnContext(ctx, "Failed to read count cache", "key", key, "err", err)
+	}
+	if ok {
+		if count, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return count, estimated, nil
+		}
+	}
+
+	if estimated {
+		count, err = c.EstimatedDocumentCount(ctx)
+	} else {
+		if filter == nil {
+			filter = bson.M{}
+		}
+		opCtx, done, err := c.operationContext(ctx)
+		if err != nil {
+			return 0, false, err
+		}
+		defer done()
+		opts = withMaxTime(opCtx, c, opts, options.Count().SetMaxTime)
+		count, err = c.collection.CountDocuments(opCtx, filter, opts...)
+		if err != nil {
+			err = fmt.Errorf("failed to count documents: %w", err)
+		}
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if err := cc.opts.Cache.Set(ctx, key, []byte(strconv.FormatInt(count, 10)), cc.opts.TTL); err != nil {
+		c.cli.logger.WarnContext(ctx, "Failed to write count cache", "key", key, "err", err)
+	}
+	return count, estimated, nil
+}
+
+// Invalidate 使集合的所有计数缓存失效，旧条目由 TTL 或 LRU 淘汰
+func (cc *CountCache) Invalidate(c *Collection) {
+	ns := c.collection.Database().Name() + "." + c.collection.Name()
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.gens[ns]++
+}
+
+// key 计数缓存键，过滤条件按与字段顺序无关的哈希编码，collation 会影响匹配结果因此也参与编码
+func (cc *CountCache) key(ns string, filter bson.M, estimated bool, opts []*options.CountOptions) (string, error) {
+	cc.mu.Lock()
+	gen := cc.gens[ns]
+	cc.mu.Unlock()
+	prefix := "count:" + ns + ":" + strconv.FormatInt(gen, 10) + ":"
+	if estimated {
+		return prefix + "estimated", nil
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+	raw, err := bson.Marshal(filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode filter: %w", err)
+	}
+	h := sha256.New()
+	if err := hashDocument(h, raw); err != nil {
+		return "", err
+	}
+	for _, opt := range opts {
+		if opt != nil && opt.Collation != nil {
+			fmt.Fprintf(h, "%+v", *opt.Collation)
+		}
+	}
+	return prefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WithCountCache 返回使用计数缓存的集合副本，FindWithPagination 的总数从缓存读取
+func (c *Collection) WithCountCache(counts *CountCache) *Collection {
+	cp := *c
+	cp.counts = counts
+	return &cp
+}
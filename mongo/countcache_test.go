@@ -0,0 +1,58 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestCountCacheKey(t *testing.T) {
+	cc := NewCountCache(nil)
+	k1, err := cc.key("app.articles", bson.M{"status": "published", "author_id": 1}, false, nil)
+	require.NoError(t, err)
+	k2, err := cc.key("app.articles", bson.M{"author_id": 1, "status": "published"}, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	collated, err := cc.key("app.articles", bson.M{"status": "published", "author_id": 1}, false,
+		[]*options.CountOptions{options.Count().SetCollation(&options.Collation{Locale: "en", Strength: 2})})
+	require.NoError(t, err)
+	assert.NotEqual(t, k1, collated)
+
+	estimated, err := cc.key("app.articles", nil, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "count:app.articles:0:estimated", estimated)
+}
+
+func TestCountCacheHitAndInvalidate(t *testing.T) {
+	ctx := t.Context()
+	store := NewLRUCache(0)
+	cc := NewCountCache(&CountCacheOptions{TTL: time.Minute, EstimateEmptyFilter: true, Cache: store})
+	c := NewCollection(newLazyClient(t), "articles").WithCountCache(cc)
+	ns := c.collection.Database().Name() + ".articles"
+
+	key, err := cc.key(ns, bson.M{"status": "published"}, false, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, key, []byte("42"), time.Minute))
+	count, estimated, err := cc.Count(ctx, c, bson.M{"status": "published"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	assert.False(t, estimated)
+
+	key, err = cc.key(ns, nil, true, nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, key, []byte("1000"), time.Minute))
+	count, estimated, err = cc.Count(ctx, c, bson.M{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), count)
+	assert.True(t, estimated)
+
+	cc.Invalidate(c)
+	invalidated, err := cc.key(ns, nil, true, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, invalidated)
+}
@@ -0,0 +1,204 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// counterKey 计数器缓冲键，id 需要是可比较的类型，例如 ObjectID、UUID、字符串
+type counterKey struct {
+	id    interface{}
+	field string
+}
+
+// BatchCounter 批量计数器
+// 将高频的递增操作（例如文章浏览量）先在内存中合并，按固定间隔批量写入 $inc，
+// 计数器属于统计数据，批量写入时不会刷新 updated_at
+type BatchCounter struct {
+	collection *Collection
+	interval   time.Duration
+
+	mu      sync.Mutex
+	pending map[counterKey]int64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewBatchCounter 创建新的批量计数器
+func NewBatchCounter(collection *Collection, interval time.Duration) *BatchCounter {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &BatchCounter{
+		collection: collection,
+		interval:   interval,
+		pending:    make(map[counterKey]int64),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+	}
+}
+
+// Add 累加字段的增量，delta 可以为负数；id 的规则与 Collection.IncrementField 相同，字符串按集合的 ID 策略转换
+func (bc *BatchCounter) Add(id interface{}, field string, delta int64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	bc.pending[counterKey{id: id, field: field}] += delta
+}
+
+// Incr 字段加一
+func (bc *BatchCounter) Incr(id interface{}, field string) {
+	bc.Add(id, field, 1)
+}
+
+// Pending 返回尚未写入的增量数量
+func (bc *BatchCounter) Pending() int {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return len(bc.pending)
+}
+
+// Flush 立即将缓冲的增量写入数据库
+// 写入失败的增量会放回缓冲区等待下次写入；批量写入部分失败时只放回失败的文档，已经生效的增量不会重复累加
+func (bc *BatchCounter) Flush(ctx context.Context) error {
+	bc.mu.Lock()
+	pending := bc.pending
+	bc.pending = make(map[counterKey]int64)
+	bc.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// 同一文档的多个字段合并为一次 $inc
+	incs := make(map[interface{}]bson.M)
+	var invalid error
+	for key, delta := range pending {
+		if delta == 0 {
+			continue
+		}
+		if _, err := bc.collection.documentID(key.id); err != nil {
+			// ID 无法转换时重试也不会成功，丢弃该增量
+			invalid = fmt.Errorf("failed to flush counter %s of %v: %w", key.field, key.id, err)
+			continue
+		}
+		if incs[key.id] == nil {
+			incs[key.id] = bson.M{}
+		}
+		incs[key.id][key.field] = delta
+	}
+
+	// ids[i] 为第 i 个写入模型对应的文档，用于在部分失败时找回增量
+	ids := make([]interface{}, 0, len(incs))
+	models := make([]mongo.WriteModel, 0, len(incs))
+	for id, inc := range incs {
+		docID, _ := bc.collection.documentID(id)
+		ids = append(ids, id)
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": docID}).
+			SetUpdate(bson.M{"$inc": inc}))
+	}
+	if len(models) == 0 {
+		return invalid
+	}
+
+	ctx, done, err := bc.collection.writeContext(ctx, "bulkWrite")
+	if err != nil {
+		bc.requeue(ids, incs, err)
+		return err
+	}
+	defer done()
+	if _, err := bc.collection.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+		bc.requeue(ids, incs, err)
+		return fmt.Errorf("failed to flush counters: %w", err)
+	}
+	return invalid
+}
+
+// requeue 将写入失败的增量放回缓冲区
+// 写错误只影响对应的文档，其它文档的增量已经生效；其它错误（例如网络错误）无法判断哪些写入生效，全部放回
+func (bc *BatchCounter) requeue(ids []interface{}, incs map[interface{}]bson.M, err error) {
+	failed := ids
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && len(bulkErr.WriteErrors) > 0 {
+		failed = make([]interface{}, 0, len(bulkErr.WriteErrors))
+		for _, writeErr := range bulkErr.WriteErrors {
+			if writeErr.Index >= 0 && writeErr.Index < len(ids) {
+				failed = append(failed, ids[writeErr.Index])
+			}
+		}
+	} else if errors.As(err, &bulkErr) {
+		// 只有写关注错误时写入已经执行
+		return
+	}
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	for _, id := range failed {
+		for field, delta := range incs[id] {
+			bc.pending[counterKey{id: id, field: field}] += delta.(int64)
+		}
+	}
+}
+
+// Start 启动后台定时写入
+func (bc *BatchCounter) Start(ctx context.Context) {
+	bc.startOnce.Do(func() {
+		bc.collection.cli.RegisterShutdown(bc)
+		go bc.run(ctx)
+	})
+}
+
+// Stop 停止后台写入，并将剩余增量写入数据库
+func (bc *BatchCounter) Stop() {
+	bc.stopOnce.Do(func() {
+		close(bc.stopCh)
+		// 未启动过时直接写入剩余增量
+		bc.startOnce.Do(func() {
+			bc.finalFlush()
+			close(bc.doneCh)
+		})
+		<-bc.doneCh
+	})
+}
+
+// run 定时写入循环
+func (bc *BatchCounter) run(ctx context.Context) {
+	defer close(bc.doneCh)
+
+	ticker := time.NewTicker(bc.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := bc.Flush(ctx); err != nil {
+				bc.collection.cli.logger.ErrorContext(ctx, "Batch counter flush failed", "collection", bc.collection.collection.Name(), "err", err)
+			}
+		case <-bc.stopCh:
+			bc.finalFlush()
+			return
+		case <-ctx.Done():
+			bc.finalFlush()
+			return
+		}
+	}
+}
+
+// finalFlush 停止时写入剩余增量
+func (bc *BatchCounter) finalFlush() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := bc.Flush(ctx); err != nil {
+		bc.collection.cli.logger.ErrorContext(ctx, "Batch counter final flush failed", "collection", bc.collection.collection.Name(), "err", err)
+	}
+}
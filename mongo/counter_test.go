@@ -0,0 +1,203 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// flushedIncs 汇总服务端收到的所有 $inc，键为 "<_id>.<field>"
+func flushedIncs(t *testing.T, server *fakeServer) map[string]int64 {
+	t.Helper()
+	totals := make(map[string]int64)
+	for _, cmd := range server.Commands("update") {
+		updates, err := cmd.Lookup("updates").Array().Values()
+		require.NoError(t, err)
+		for _, update := range updates {
+			id := update.Document().Lookup("q", "_id")
+			elements, err := update.Document().Lookup("u", "$inc").Document().Elements()
+			require.NoError(t, err)
+			for _, element := range elements {
+				totals[id.String()+"."+element.Key()] += element.Value().Int64()
+			}
+		}
+	}
+	return totals
+}
+
+func incKey(id primitive.ObjectID, field string) string {
+	return bson.RawValue{Type: bson.TypeObjectID, Value: id[:]}.String() + "." + field
+}
+
+func TestBatchCounterFlush(t *testing.T) {
+	server := newFakeServer(t, false)
+	counter := NewBatchCounter(NewCollection(server.client(t), "posts"), time.Minute)
+
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	counter.Incr(first, "views")
+	counter.Incr(first, "views")
+	counter.Add(first, "likes", 3)
+	counter.Add(second, "views", -1)
+	counter.Add(second, "likes", 0)
+	assert.Equal(t, 4, counter.Pending())
+
+	require.NoError(t, counter.Flush(t.Context()))
+	assert.Zero(t, counter.Pending())
+
+	// 同一文档的字段合并为一个更新，增量为零的字段不写入
+	updates, err := server.Commands("update")[0].Lookup("updates").Array().Values()
+	require.NoError(t, err)
+	assert.Len(t, updates, 2)
+	assert.Equal(t, map[string]int64{
+		incKey(first, "views"):  2,
+		incKey(first, "likes"):  3,
+		incKey(second, "views"): -1,
+	}, flushedIncs(t, server))
+
+	// 没有增量时不发送命令
+	require.NoError(t, counter.Flush(t.Context()))
+	assert.Len(t, server.Commands("update"), 1)
+}
+
+func TestBatchCounterFlushRequeuesFailedUpdates(t *testing.T) {
+	server := newFakeServer(t, false)
+	var failing atomic.Bool
+	failing.Store(true)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "update" || !failing.Load() {
+			return nil
+		}
+		// 第二个更新失败，其它更新已经生效
+		updates, _ := cmd.Lookup("updates").Array().Values()
+		return bson.D{
+			{Key: "n", Value: len(updates) - 1},
+			{Key: "nModified", Value: len(updates) - 1},
+			{Key: "writeErrors", Value: bson.A{bson.D{{Key: "index", Value: 1}, {Key: "code", Value: 2}, {Key: "errmsg", Value: "bad value"}}}},
+			{Key: "ok", Value: 1},
+		}
+	})
+	counter := NewBatchCounter(NewCollection(server.client(t), "posts"), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		counter.Add(primitive.NewObjectID(), "views", int64(i+1))
+	}
+	require.Error(t, counter.Flush(t.Context()))
+	assert.Equal(t, 1, counter.Pending(), "only the failed update is re-queued")
+
+	failedUpdate := server.Commands("update")[0].Lookup("updates").Array().Index(1).Value().Document()
+	failing.Store(false)
+	require.NoError(t, counter.Flush(t.Context()))
+
+	retried, err := server.Commands("update")[1].Lookup("updates").Array().Values()
+	require.NoError(t, err)
+	require.Len(t, retried, 1)
+	assert.Equal(t, failedUpdate.Lookup("q"), retried[0].Document().Lookup("q"))
+	assert.Equal(t, failedUpdate.Lookup("u"), retried[0].Document().Lookup("u"))
+}
+
+func TestBatchCounterFlushRequeuesOnNetworkError(t *testing.T) {
+	client := newLazyClient(t)
+	client.operationTimeout = 50 * time.Millisecond
+	counter := NewBatchCounter(NewCollection(client, "posts"), time.Minute)
+
+	counter.Incr(primitive.NewObjectID(), "views")
+	counter.Incr(primitive.NewObjectID(), "views")
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+	require.Error(t, counter.Flush(ctx))
+	assert.Equal(t, 2, counter.Pending(), "all updates are re-queued when none is known to have been applied")
+}
+
+func TestBatchCounterStringIDs(t *testing.T) {
+	server := newFakeServer(t, false)
+	posts := NewCollection(server.client(t), "posts").WithIDStrategy(UUIDv4Strategy)
+	counter := NewBatchCounter(posts, time.Minute)
+
+	id := UUIDv4Strategy.NewID().(UUID)
+	counter.Incr(id, "views")
+	counter.Incr(id.String(), "views")
+	counter.Incr("not-a-uuid", "views")
+
+	// 无法转换的 ID 被丢弃，不影响其它增量
+	assert.Error(t, counter.Flush(t.Context()))
+	assert.Zero(t, counter.Pending())
+
+	updates, err := server.Commands("update")[0].Lookup("updates").Array().Values()
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	for _, update := range updates {
+		subtype, data := update.Document().Lookup("q", "_id").Binary()
+		assert.Equal(t, byte(4), subtype)
+		assert.Equal(t, id[:], data)
+	}
+}
+
+func TestBatchCounterConcurrentIncr(t *testing.T) {
+	server := newFakeServer(t, false)
+	counter := NewBatchCounter(NewCollection(server.client(t), "posts"), time.Minute)
+	id := primitive.NewObjectID()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				counter.Incr(id, "views")
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for flushing := true; flushing; {
+		select {
+		case <-done:
+			flushing = false
+		default:
+			require.NoError(t, counter.Flush(t.Context()))
+		}
+	}
+	require.NoError(t, counter.Flush(t.Context()))
+
+	assert.Zero(t, counter.Pending())
+	totals := flushedIncs(t, server)
+	assert.Equal(t, map[string]int64{incKey(id, "views"): 800}, totals)
+}
+
+func TestBatchCounterStartAndStop(t *testing.T) {
+	server := newFakeServer(t, false)
+	counter := NewBatchCounter(NewCollection(server.client(t), "posts"), 20*time.Millisecond)
+	counter.Start(t.Context())
+
+	counter.Incr(primitive.NewObjectID(), "views")
+	// 定时写入
+	assert.Eventually(t, func() bool { return len(server.Commands("update")) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Zero(t, counter.Pending())
+
+	// Stop 写入剩余增量后返回，可以重复调用
+	counter.Incr(primitive.NewObjectID(), "views")
+	counter.Stop()
+	counter.Stop()
+	assert.Zero(t, counter.Pending())
+	assert.Len(t, server.Commands("update"), 2)
+}
+
+func TestBatchCounterStopWithoutStart(t *testing.T) {
+	server := newFakeServer(t, false)
+	counter := NewBatchCounter(NewCollection(server.client(t), "posts"), time.Minute)
+
+	counter.Incr(primitive.NewObjectID(), "views")
+	counter.Stop()
+	assert.Zero(t, counter.Pending())
+	assert.Len(t, server.Commands("update"), 1)
+}
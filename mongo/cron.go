@@ -0,0 +1,191 @@
+package mongo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule 解析后的 cron 表达式
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar、dowStar 记录日期和星期字段是否为 *，两者都有限制时按“或”匹配
+	domStar, dowStar bool
+	location         *time.Location
+}
+
+// cronBounds cron 字段取值范围
+type cronBounds struct {
+	min, max int
+}
+
+var (
+	cronMinute = cronBounds{0, 59}
+	cronHour   = cronBounds{0, 23}
+	cronDom    = cronBounds{1, 31}
+	cronMonth  = cronBounds{1, 12}
+	cronDow    = cronBounds{0, 6}
+)
+
+// cronDescriptors 预定义的 cron 表达式
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron 解析标准 5 段 cron 表达式（分 时 日 月 周）
+// 支持 *、数字、范围 a-b、步长 */n 与 a-b/n、逗号列表，以及 @daily、@hourly 等预定义表达式，
+// 星期取值 0-6，0 表示周日，7 也视为周日
+func ParseCron(spec string, location *time.Location) (*CronSchedule, error) {
+	if location == nil {
+		location = time.Local
+	}
+	expr := strings.TrimSpace(spec)
+	if descriptor, ok := cronDescriptors[expr]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &CronSchedule{location: location}
+	var err error
+	if s.minute, err = parseCronField(fields[0], cronMinute); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: minute: %w", spec, err)
+	}
+	if s.hour, err = parseCronField(fields[1], cronHour); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: hour: %w", spec, err)
+	}
+	if s.dom, err = parseCronField(fields[2], cronDom); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: day of month: %w", spec, err)
+	}
+	if s.month, err = parseCronField(fields[3], cronMonth); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: month: %w", spec, err)
+	}
+	// 星期允许 7 表示周日
+	if s.dow, err = parseCronField(fields[4], cronBounds{0, 7}); err != nil {
+		return nil, fmt.Errorf("invalid cron spec %q: day of week: %w", spec, err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	if !s.canFire() {
+		return nil, fmt.Errorf("invalid cron spec %q: day of month never occurs in the selected months", spec)
+	}
+	return s, nil
+}
+
+// canFire 检查表达式是否可能触发，例如 "0 0 30 2 *" 永远不会触发；
+// 只有日期有限制而星期为 * 时才可能出现这种情况，二月按闰年的 29 天计算
+func (s *CronSchedule) canFire() bool {
+	if s.domStar || !s.dowStar {
+		return true
+	}
+	for month := time.January; month <= time.December; month++ {
+		if s.month&(1<<uint(month)) == 0 {
+			continue
+		}
+		days := time.Date(2000, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if s.dom&(1<<uint(days+1)-1) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCronField 将单个字段解析为位图
+func parseCronField(field string, bounds cronBounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		start, end := bounds.min, bounds.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(lo); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+			if end, err = strconv.Atoi(hi); err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			start = n
+			// 单个值带步长时（例如 5/15）表示从该值到最大值
+			if hasStep {
+				end = bounds.max
+			} else {
+				end = n
+			}
+		}
+
+		if start < bounds.min || end > bounds.max || start > end {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, bounds.min, bounds.max)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// Next 返回 t 之后（不含 t）的下一次触发时间，5 年内无匹配时返回零值，表示不再触发
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 检查日期是否匹配，日期和星期都有限制时满足其一即可
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
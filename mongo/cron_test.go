@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		_, err := ParseCron(spec, time.UTC)
+		assert.Error(t, err, spec)
+	}
+
+	// 日期和星期都有限制时按“或”匹配，二月 30 日不存在也会在周一触发
+	_, err := ParseCron("0 0 30 2 1", time.UTC)
+	assert.NoError(t, err)
+}
+
+func TestCronNext(t *testing.T) {
+	base := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC) // 周五
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 8 * * 1-5", time.Date(2024, 3, 18, 8, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 日期和星期都有限制时满足其一即可
+		{"0 0 20 * 6", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.spec, time.UTC)
+		if assert.NoError(t, err, tt.spec) {
+			assert.Equal(t, tt.want, schedule.Next(base), tt.spec)
+		}
+	}
+}
@@ -9,80 +9,151 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // Collection 集合操作
 type Collection struct {
 	cli        *Client
 	collection *mongo.Collection
+	session    mongo.Session
+	auditor    *Auditor
+	revisions  *revisionStore
+	idStrategy IDStrategy
+	validator  Validator
+	mirror     *Mirror
+	encryptor  *FieldEncryptor
+	counts     *CountCache
+	timeout    *time.Duration
+	limiter    *Limiter
+	limiterSet bool
+	// maxAffected UpdateMany/DeleteMany 允许影响的最大文档数，参见 WithMaxAffected
+	maxAffected int64
 }
 
 // NewCollection 创建新的集合实例
 func NewCollection(client *Client, collectionName string) *Collection {
+	return newCollection(client, client.GetCollection(collectionName))
+}
+
+// newCollection 包装驱动集合，集合可以位于客户端默认数据库以外的数据库
+func newCollection(client *Client, collection *mongo.Collection) *Collection {
 	return &Collection{
 		cli:        client,
-		collection: client.GetCollection(collectionName),
+		collection: collection,
 	}
 }
 
+// WithSession 返回绑定到会话的集合副本，之后的所有操作都会自动加入该会话（及其中的事务）
+func (c *Collection) WithSession(session mongo.Session) *Collection {
+	cp := *c
+	cp.session = session
+	return &cp
+}
+
+// WithReadPreference 返回使用指定读偏好的集合副本，覆盖客户端配置，例如将报表查询分流到从节点；
+// 读偏好通过 NewReadPreference 创建。事务内的读操作仍然使用事务的读偏好
+func (c *Collection) WithReadPreference(rp *readpref.ReadPref) *Collection {
+	cp := *c
+	cp.collection = c.collection.Database().Collection(c.collection.Name(), options.Collection().SetReadPreference(rp))
+	return &cp
+}
+
+// InSession 返回绑定到 ctx 中会话的集合副本，ctx 中没有会话时返回原集合
+// 适合在事务回调中使用，例如 users := userCol.InSession(sessCtx)
+func (c *Collection) InSession(ctx context.Context) *Collection {
+	session := mongo.SessionFromContext(ctx)
+	if session == nil {
+		return c
+	}
+	return c.WithSession(session)
+}
+
+// sessionContext 集合绑定了会话且 ctx 中没有会话时，将会话注入 ctx
+func (c *Collection) sessionContext(ctx context.Context) context.Context {
+	if c.session == nil || mongo.SessionFromContext(ctx) != nil {
+		return ctx
+	}
+	return mongo.NewSessionContext(ctx, c.session)
+}
+
 // InsertOne 插入单个文档
 func (c *Collection) InsertOne(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error) {
-	if doc, ok := document.(Document); ok {
-		doc.BeforeInsert()
+	ctx, done, err := c.writeContext(ctx, "insert")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if err := c.prepareInsert(document); err != nil {
+		return nil, err
 	}
+	restore, err := c.encryptDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
 
 	result, err := c.collection.InsertOne(ctx, document)
 	if err != nil {
-		return nil, fmt.Errorf("failed to insert document: %w", err)
+		return nil, fmt.Errorf("failed to insert document: %w", translateWriteError(err))
 	}
 
 	// 将生成的 ID 写入到 document 中
-	if doc, ok := document.(Document); ok {
-		if insertedID, ok := result.InsertedID.(primitive.ObjectID); ok {
-			doc.SetID(insertedID)
-		} else {
-			return nil, fmt.Errorf("insertedID is not ObjectID")
-		}
+	if err := setInsertedID(document, result.InsertedID); err != nil {
+		return nil, err
+	}
+	if err := c.mirrorInsert(ctx, []interface{}{document}, []interface{}{result.InsertedID}); err != nil {
+		return result, err
+	}
+	if err := c.auditInserts(ctx, []interface{}{document}, []interface{}{result.InsertedID}); err != nil {
+		return result, err
 	}
 	return result, nil
 }
 
-// InsertMany 插入多个文档
+// InsertMany 插入多个文档，超过 DefaultInsertBatchSize 个文档时自动分批提交
+// 部分批次失败时返回已插入文档的结果和 *InsertBatchError，需要调整批次大小或并行提交时使用 InsertManyBatched
 func (c *Collection) InsertMany(ctx context.Context, documents []interface{}) (*mongo.InsertManyResult, error) {
-	// 为每个文档调用 BeforeInsert 钩子
-	for _, doc := range documents {
-		if baseDoc, ok := doc.(*BaseDocument); ok {
-			baseDoc.BeforeInsert()
-		}
-	}
-
-	result, err := c.collection.InsertMany(ctx, documents)
-	if err != nil {
-		return nil, fmt.Errorf("failed to insert documents: %w", err)
-	}
-	return result, nil
+	return c.InsertManyBatched(ctx, documents, nil)
 }
 
 // FindOne 查找单个文档
-func (c *Collection) FindOne(ctx context.Context, filter bson.M, result interface{}) error {
-	err := c.collection.FindOne(ctx, filter).Decode(result)
+// 可以通过 opts 指定投影，例如 options.FindOne().SetProjection(ExcludeFields("password"))
+func (c *Collection) FindOne(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	opts = withMaxTime(ctx, c, opts, options.FindOne().SetMaxTime)
+	err = c.collection.FindOne(ctx, filter, opts...).Decode(result)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return fmt.Errorf("document not found")
 		}
 		return fmt.Errorf("failed to find document: %w", err)
 	}
-	return nil
+	return c.decryptResult(result)
 }
 
-// FindByID 根据ID查找文档
-func (c *Collection) FindByID(ctx context.Context, id primitive.ObjectID, result interface{}) error {
-	filter := bson.M{"_id": id}
-	return c.FindOne(ctx, filter, result)
+// FindByID 根据ID查找文档，id 可以是 ObjectID、UUID 等存储类型，也可以是按集合 ID 策略解析的字符串
+func (c *Collection) FindByID(ctx context.Context, id interface{}, result interface{}, opts ...*options.FindOneOptions) error {
+	docID, err := c.documentID(id)
+	if err != nil {
+		return err
+	}
+	filter := bson.M{"_id": docID}
+	return c.FindOne(ctx, filter, result, opts...)
 }
 
 // Find 查找多个文档
 func (c *Collection) Find(ctx context.Context, filter bson.M, results interface{}, opts ...*options.FindOptions) error {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	opts = withMaxTime(ctx, c, opts, options.Find().SetMaxTime)
 	cursor, err := c.collection.Find(ctx, filter, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to find documents: %w", err)
@@ -92,21 +163,42 @@ func (c *Collection) Find(ctx context.Context, filter bson.M, results interface{
 	if err := cursor.All(ctx, results); err != nil {
 		return fmt.Errorf("failed to decode documents: %w", err)
 	}
-	return nil
+	return c.decryptResult(results)
 }
 
+// DefaultPageSize 默认分页大小
+const DefaultPageSize int64 = 20
+
 // FindWithPagination 分页查找文档
-func (c *Collection) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, results interface{}) (*PaginationResult, error) {
+// opts 可以指定 sort、hint、projection、collation、maxTimeMS，其中 skip/limit 会被分页参数覆盖；
+// 为保证分页结果稳定，排序条件中没有 _id 时会自动追加 _id 升序作为最后的排序字段；
+// hint、collation 和 maxTimeMS 同样作用于计算总数的 count 操作
+func (c *Collection) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, results interface{}, opts ...*options.FindOptions) (*PaginationResult, error) {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+
 	// 计算跳过的文档数量
 	skip := (page - 1) * pageSize
+	opts = withMaxTime(ctx, c, opts, options.Find().SetMaxTime)
+	merged := mergeFindOptions(opts)
 
 	// 设置查找选项
 	findOptions := options.Find().
 		SetSkip(skip).
-		SetLimit(pageSize)
+		SetLimit(pageSize).
+		SetSort(stableSort(merged.Sort))
 
-	// 执行查找
-	cursor, err := c.collection.Find(ctx, filter, findOptions)
+	// 执行查找，分页选项放在最后以覆盖调用方传入的 skip/limit/sort
+	cursor, err := c.collection.Find(ctx, filter, append(opts, findOptions)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find documents: %w", err)
 	}
@@ -115,11 +207,35 @@ func (c *Collection) FindWithPagination(ctx context.Context, filter bson.M, page
 	if err := cursor.All(ctx, results); err != nil {
 		return nil, fmt.Errorf("failed to decode documents: %w", err)
 	}
+	if err := c.decryptResult(results); err != nil {
+		return nil, err
+	}
 
 	// 计算总数
-	total, err := c.collection.CountDocuments(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("failed to count documents: %w", err)
+	countOptions := options.Count()
+	if merged.Hint != nil {
+		countOptions.SetHint(merged.Hint)
+	}
+	if merged.Collation != nil {
+		countOptions.SetCollation(merged.Collation)
+	}
+	if merged.MaxTime != nil {
+		countOptions.SetMaxTime(*merged.MaxTime)
+	}
+	var (
+		total     int64
+		estimated bool
+	)
+	if c.counts != nil {
+		total, estimated, err = c.counts.Count(ctx, c, filter, countOptions)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		total, err = c.collection.CountDocuments(ctx, filter, countOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count documents: %w", err)
+		}
 	}
 
 	return &PaginationResult{
@@ -127,104 +243,605 @@ func (c *Collection) FindWithPagination(ctx context.Context, filter bson.M, page
 		PageSize:  pageSize,
 		Total:     total,
 		TotalPage: (total + pageSize - 1) / pageSize,
+		Estimated: estimated,
 	}, nil
 }
 
+// mergeFindOptions 合并分页需要关心的查找选项，后面的选项覆盖前面的
+func mergeFindOptions(opts []*options.FindOptions) *options.FindOptions {
+	merged := options.Find()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Sort != nil {
+			merged.Sort = opt.Sort
+		}
+		if opt.Hint != nil {
+			merged.Hint = opt.Hint
+		}
+		if opt.Collation != nil {
+			merged.Collation = opt.Collation
+		}
+		if opt.MaxTime != nil {
+			merged.MaxTime = opt.MaxTime
+		}
+	}
+	return merged
+}
+
+// stableSort 为排序条件追加 _id，保证相同排序值的文档在分页间顺序固定
+func stableSort(sort interface{}) interface{} {
+	switch s := sort.(type) {
+	case nil:
+		return bson.D{{Key: "_id", Value: 1}}
+	case bson.D:
+		for _, e := range s {
+			if e.Key == "_id" {
+				return s
+			}
+		}
+		return append(append(bson.D{}, s...), bson.E{Key: "_id", Value: 1})
+	case bson.M:
+		// 多个字段的 bson.M 顺序不确定，保持原样
+		if len(s) != 1 {
+			return s
+		}
+		for key, value := range s {
+			if key == "_id" {
+				return s
+			}
+			return bson.D{{Key: key, Value: value}, {Key: "_id", Value: 1}}
+		}
+	}
+	return sort
+}
+
 // UpdateOne 更新单个文档
 func (c *Collection) UpdateOne(ctx context.Context, filter bson.M, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	ctx, done, err := c.writeContext(ctx, "update")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	// 添加更新时间
 	if update["$set"] == nil {
 		update["$set"] = bson.M{}
 	}
 	update["$set"].(bson.M)["updated_at"] = time.Now()
 
+	before, err := c.snapshot(ctx, filter, false)
+	if err != nil {
+		return nil, err
+	}
 	result, err := c.collection.UpdateOne(ctx, filter, update, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update document: %w", err)
+		return nil, fmt.Errorf("failed to update document: %w", translateWriteError(err))
+	}
+	err = c.mirrorWrite(ctx, "updateOne", updateOutcome(result), mirrorUpdate(func(ctx context.Context, coll *mongo.Collection) (*mongo.UpdateResult, error) {
+		return coll.UpdateOne(ctx, filter, update, opts...)
+	}))
+	if err != nil {
+		return result, err
+	}
+	if result.ModifiedCount > 0 {
+		if err := c.saveRevisions(ctx, before); err != nil {
+			return result, err
+		}
+	}
+	if err := c.auditWrite(ctx, AuditUpdate, before, result.UpsertedID); err != nil {
+		return result, err
 	}
 	return result, nil
 }
 
-// UpdateByID 根据ID更新文档
-func (c *Collection) UpdateByID(ctx context.Context, id primitive.ObjectID, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
-	filter := bson.M{"_id": id}
+// UpdateByID 根据ID更新文档，id 的规则与 FindByID 相同
+func (c *Collection) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	docID, err := c.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"_id": docID}
 	return c.UpdateOne(ctx, filter, update, opts...)
 }
 
+// UpdateChanged 比较 original 和 modified 两个文档，只把变化的字段以 $set/$unset 写入 original 的 _id 对应的文档，
+// 嵌套子文档按点号路径逐字段比较，数组整体比较；_id 和 updated_at 不参与比较
+// 没有字段变化时不访问数据库，返回空的更新结果
+//
+//	original := user
+//	user.Profile.Bio = "..."
+//	result, err := users.UpdateChanged(ctx, original, user)
+func (c *Collection) UpdateChanged(ctx context.Context, original, modified interface{}) (*mongo.UpdateResult, error) {
+	if err := c.validate(modified); err != nil {
+		return nil, err
+	}
+	before, err := toBsonM(original)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := before["_id"]
+	if !ok {
+		return nil, fmt.Errorf("original document has no _id")
+	}
+	update, err := BuildChangedUpdate(original, modified)
+	if err != nil {
+		return nil, err
+	}
+	if len(update) == 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+	if set, ok := update["$set"].(bson.M); ok && c.encryptor != nil {
+		paths, err := EncryptedFields(modified)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.encryptor.encryptUpdate(set, paths); err != nil {
+			return nil, err
+		}
+	}
+	return c.UpdateOne(ctx, bson.M{"_id": id}, update)
+}
+
+// BuildChangedUpdate 计算两个文档之间变化的字段，新增或修改的字段生成 $set，被删除的字段生成 $unset，
+// 没有变化时返回空的 bson.M
+func BuildChangedUpdate(original, modified interface{}) (bson.M, error) {
+	before, err := toBsonM(original)
+	if err != nil {
+		return nil, err
+	}
+	after, err := toBsonM(modified)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{}
+	set := bson.M{}
+	unset := bson.M{}
+	for _, change := range diffDocuments(before, after, map[string]bool{"_id": true, "updated_at": true}) {
+		if _, exists := lookupPath(after, change.Field); exists {
+			set[change.Field] = change.After
+		} else {
+			unset[change.Field] = ""
+		}
+	}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update, nil
+}
+
 // UpdateMany 更新多个文档
-func (c *Collection) UpdateMany(ctx context.Context, filter bson.M, update bson.M) (*mongo.UpdateResult, error) {
+// 过滤条件为空时返回 ErrFullCollectionWrite，确实需要更新所有文档时传入 AllowFullCollection()；
+// 匹配的文档数受 WithMaxAffected 和 MassWriteOptions.MaxAffected 限制
+func (c *Collection) UpdateMany(ctx context.Context, filter bson.M, update bson.M, opts ...*MassWriteOptions) (*mongo.UpdateResult, error) {
+	ctx, done, err := c.writeContext(ctx, "update")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if err := c.guardMassWrite(ctx, "updateMany", filter, opts); err != nil {
+		return nil, err
+	}
 	// 添加更新时间
 	if update["$set"] == nil {
 		update["$set"] = bson.M{}
 	}
 	update["$set"].(bson.M)["updated_at"] = time.Now()
 
+	before, err := c.snapshot(ctx, filter, true)
+	if err != nil {
+		return nil, err
+	}
 	result, err := c.collection.UpdateMany(ctx, filter, update)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update documents: %w", err)
+		return nil, fmt.Errorf("failed to update documents: %w", translateWriteError(err))
+	}
+	err = c.mirrorWrite(ctx, "updateMany", updateOutcome(result), mirrorUpdate(func(ctx context.Context, coll *mongo.Collection) (*mongo.UpdateResult, error) {
+		return coll.UpdateMany(ctx, filter, update)
+	}))
+	if err != nil {
+		return result, err
+	}
+	if result.ModifiedCount > 0 {
+		if err := c.saveRevisions(ctx, before); err != nil {
+			return result, err
+		}
+	}
+	if err := c.auditWrite(ctx, AuditUpdate, before, nil); err != nil {
+		return result, err
 	}
 	return result, nil
 }
 
+// IncrementField 原子递增文档的数值字段，delta 可以为负数
+func (c *Collection) IncrementField(ctx context.Context, id interface{}, field string, delta int64) (*mongo.UpdateResult, error) {
+	update := bson.M{"$inc": bson.M{field: delta}}
+	return c.UpdateByID(ctx, id, update)
+}
+
+// DecrementField 原子递减文档的数值字段
+func (c *Collection) DecrementField(ctx context.Context, id interface{}, field string, delta int64) (*mongo.UpdateResult, error) {
+	return c.IncrementField(ctx, id, field, -delta)
+}
+
+// Upsert 按过滤条件更新文档，不存在时插入
+// created_at 只在插入时通过 $setOnInsert 写入，updated_at 每次都会刷新
+func (c *Collection) Upsert(ctx context.Context, filter bson.M, document interface{}) (*mongo.UpdateResult, error) {
+	ctx, done, err := c.writeContext(ctx, "update")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if err := ApplyDefaults(document); err != nil {
+		return nil, err
+	}
+	if err := c.validate(document); err != nil {
+		return nil, err
+	}
+	restore, err := c.encryptDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	fields, err := toBsonM(document)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	set := bson.M{}
+	setOnInsert := bson.M{"created_at": now}
+	for key, value := range fields {
+		switch key {
+		case "_id":
+			setOnInsert[key] = value
+		case "created_at":
+			if t, ok := value.(primitive.DateTime); ok && t.Time().IsZero() {
+				continue
+			}
+			setOnInsert[key] = value
+		case "updated_at":
+		default:
+			set[key] = value
+		}
+	}
+	set["updated_at"] = now
+
+	update := bson.M{
+		"$set":         set,
+		"$setOnInsert": setOnInsert,
+	}
+	before, err := c.snapshot(ctx, filter, false)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert document: %w", translateWriteError(err))
+	}
+	err = c.mirrorWrite(ctx, "upsert", updateOutcome(result), mirrorUpdate(func(ctx context.Context, coll *mongo.Collection) (*mongo.UpdateResult, error) {
+		return coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	}))
+	if err != nil {
+		return result, err
+	}
+	if result.ModifiedCount > 0 {
+		if err := c.saveRevisions(ctx, before); err != nil {
+			return result, err
+		}
+	}
+	if err := c.auditWrite(ctx, AuditUpdate, before, result.UpsertedID); err != nil {
+		return result, err
+	}
+
+	if doc, ok := document.(Document); ok {
+		doc.SetUpdatedAt(now)
+	}
+	if err := setInsertedID(document, result.UpsertedID); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// FindOrCreate 查找匹配的文档，不存在时使用 defaults 创建，结果解码到 result
+// 返回值 created 表示文档是否为本次新建
+func (c *Collection) FindOrCreate(ctx context.Context, filter bson.M, defaults interface{}, result interface{}) (bool, error) {
+	ctx, done, err := c.writeContext(ctx, "findAndModify")
+	if err != nil {
+		return false, err
+	}
+	defer done()
+	// 与 InsertOne 相同：生成 ID、写入默认值、调用 BeforeInsert 并校验，保证新建的文档满足同样的约束
+	if err := c.prepareInsert(defaults); err != nil {
+		return false, err
+	}
+	restore, err := c.encryptDocument(defaults)
+	if err != nil {
+		return false, err
+	}
+	setOnInsert, err := toBsonM(defaults)
+	restore()
+	if err != nil {
+		return false, err
+	}
+
+	// 按集合的 ID 策略预先生成 _id，用于判断返回的文档是否为本次插入
+	newID, hasID := setOnInsert["_id"]
+	if !hasID || isZeroID(newID) {
+		newID = c.IDStrategy().NewID()
+		setOnInsert["_id"] = newID
+	}
+	now := time.Now()
+	if t, ok := setOnInsert["created_at"].(primitive.DateTime); !ok || t.Time().IsZero() {
+		setOnInsert["created_at"] = now
+	}
+	if t, ok := setOnInsert["updated_at"].(primitive.DateTime); !ok || t.Time().IsZero() {
+		setOnInsert["updated_at"] = now
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+	raw, err := c.collection.FindOneAndUpdate(ctx, filter, bson.M{"$setOnInsert": setOnInsert}, opts).Raw()
+	if err != nil {
+		return false, fmt.Errorf("failed to find or create document: %w", translateWriteError(err))
+	}
+	if err := bson.Unmarshal(raw, result); err != nil {
+		return false, fmt.Errorf("failed to decode document: %w", err)
+	}
+	if err := c.decryptResult(result); err != nil {
+		return false, err
+	}
+
+	idDoc, err := bson.Marshal(bson.M{"_id": newID})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode document id: %w", err)
+	}
+	created := raw.Lookup("_id").Equal(bson.Raw(idDoc).Lookup("_id"))
+	if created {
+		err := c.mirrorWrite(ctx, "findOrCreate", MirrorOutcome{Upserted: 1}, mirrorUpdate(func(ctx context.Context, coll *mongo.Collection) (*mongo.UpdateResult, error) {
+			return coll.UpdateOne(ctx, filter, bson.M{"$setOnInsert": setOnInsert}, options.Update().SetUpsert(true))
+		}))
+		if err != nil {
+			return created, err
+		}
+		if err := c.auditInserts(ctx, []interface{}{raw}, nil); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
 // ReplaceOne 替换单个文档
 func (c *Collection) ReplaceOne(ctx context.Context, filter bson.M, replacement interface{}) (*mongo.UpdateResult, error) {
-	// 如果替换文档实现了 BaseDocument，调用 BeforeUpdate 钩子
-	if doc, ok := replacement.(*BaseDocument); ok {
-		doc.BeforeUpdate()
+	ctx, done, err := c.writeContext(ctx, "update")
+	if err != nil {
+		return nil, err
 	}
+	defer done()
+	// 调用 BeforeUpdate 钩子并校验替换文档
+	if err := c.prepareUpdate(replacement); err != nil {
+		return nil, err
+	}
+	restore, err := c.encryptDocument(replacement)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
 
+	before, err := c.snapshot(ctx, filter, false)
+	if err != nil {
+		return nil, err
+	}
 	result, err := c.collection.ReplaceOne(ctx, filter, replacement)
 	if err != nil {
-		return nil, fmt.Errorf("failed to replace document: %w", err)
+		return nil, fmt.Errorf("failed to replace document: %w", translateWriteError(err))
+	}
+	if c.mirror != nil {
+		snapshot, err := toBsonM(replacement)
+		if err != nil {
+			return result, err
+		}
+		err = c.mirrorWrite(ctx, "replaceOne", updateOutcome(result), mirrorUpdate(func(ctx context.Context, coll *mongo.Collection) (*mongo.UpdateResult, error) {
+			return coll.ReplaceOne(ctx, filter, snapshot)
+		}))
+		if err != nil {
+			return result, err
+		}
+	}
+	if result.ModifiedCount > 0 {
+		if err := c.saveRevisions(ctx, before); err != nil {
+			return result, err
+		}
+	}
+	if err := c.auditWrite(ctx, AuditReplace, before, result.UpsertedID); err != nil {
+		return result, err
 	}
 	return result, nil
 }
 
 // DeleteOne 删除单个文档
 func (c *Collection) DeleteOne(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error) {
+	ctx, done, err := c.writeContext(ctx, "delete")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	before, err := c.auditSnapshot(ctx, filter, false)
+	if err != nil {
+		return nil, err
+	}
 	result, err := c.collection.DeleteOne(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete document: %w", err)
 	}
+	err = c.mirrorWrite(ctx, "deleteOne", MirrorOutcome{Deleted: result.DeletedCount}, func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error) {
+		result, err := coll.DeleteOne(ctx, filter)
+		if err != nil {
+			return MirrorOutcome{}, err
+		}
+		return MirrorOutcome{Deleted: result.DeletedCount}, nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if result.DeletedCount > 0 {
+		if err := c.auditWrite(ctx, AuditDelete, before, nil); err != nil {
+			return result, err
+		}
+	}
 	return result, nil
 }
 
-// DeleteByID 根据ID删除文档
-func (c *Collection) DeleteByID(ctx context.Context, id primitive.ObjectID) (*mongo.DeleteResult, error) {
-	filter := bson.M{"_id": id}
+// DeleteByID 根据ID删除文档，id 的规则与 FindByID 相同
+func (c *Collection) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	docID, err := c.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"_id": docID}
 	return c.DeleteOne(ctx, filter)
 }
 
 // DeleteMany 删除多个文档
-func (c *Collection) DeleteMany(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error) {
+// 过滤条件为空时返回 ErrFullCollectionWrite，确实需要清空集合时传入 AllowFullCollection()；
+// 匹配的文档数受 WithMaxAffected 和 MassWriteOptions.MaxAffected 限制
+func (c *Collection) DeleteMany(ctx context.Context, filter bson.M, opts ...*MassWriteOptions) (*mongo.DeleteResult, error) {
+	ctx, done, err := c.writeContext(ctx, "delete")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if err := c.guardMassWrite(ctx, "deleteMany", filter, opts); err != nil {
+		return nil, err
+	}
+	before, err := c.auditSnapshot(ctx, filter, true)
+	if err != nil {
+		return nil, err
+	}
 	result, err := c.collection.DeleteMany(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete documents: %w", err)
 	}
+	err = c.mirrorWrite(ctx, "deleteMany", MirrorOutcome{Deleted: result.DeletedCount}, func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error) {
+		result, err := coll.DeleteMany(ctx, filter)
+		if err != nil {
+			return MirrorOutcome{}, err
+		}
+		return MirrorOutcome{Deleted: result.DeletedCount}, nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if result.DeletedCount > 0 {
+		if err := c.auditWrite(ctx, AuditDelete, before, nil); err != nil {
+			return result, err
+		}
+	}
 	return result, nil
 }
 
 // Count 计算文档数量
 func (c *Collection) Count(ctx context.Context, filter bson.M) (int64, error) {
-	count, err := c.collection.CountDocuments(ctx, filter)
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	count, err := c.collection.CountDocuments(ctx, filter, withMaxTime(ctx, c, nil, options.Count().SetMaxTime)...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count documents: %w", err)
 	}
 	return count, nil
 }
 
+// EstimatedDocumentCount 根据集合元数据返回文档总数，不扫描数据，适合不需要精确计数的超大集合；
+// 不支持过滤条件，在事务中不可用，非正常关闭或分片集合存在孤儿文档时结果可能不准确
+func (c *Collection) EstimatedDocumentCount(ctx context.Context, opts ...*options.EstimatedDocumentCountOptions) (int64, error) {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	count, err := c.collection.EstimatedDocumentCount(ctx, withMaxTime(ctx, c, opts, options.EstimatedDocumentCount().SetMaxTime)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate document count: %w", err)
+	}
+	return count, nil
+}
+
 // Exists 检查文档是否存在
 func (c *Collection) Exists(ctx context.Context, filter bson.M) (bool, error) {
-	count, err := c.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer done()
+	opts := withMaxTime(ctx, c, []*options.CountOptions{options.Count().SetLimit(1)}, options.Count().SetMaxTime)
+	count, err := c.collection.CountDocuments(ctx, filter, opts...)
 	if err != nil {
 		return false, fmt.Errorf("failed to check document existence: %w", err)
 	}
 	return count > 0, nil
 }
 
+// Distinct 获取字段的去重值
+func (c *Collection) Distinct(ctx context.Context, field string, filter bson.M, opts ...*options.DistinctOptions) ([]interface{}, error) {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if filter == nil {
+		filter = bson.M{}
+	}
+	opts = withMaxTime(ctx, c, opts, options.Distinct().SetMaxTime)
+	values, err := c.collection.Distinct(ctx, field, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get distinct values of %s: %w", field, err)
+	}
+	return values, nil
+}
+
+// DistinctAs 获取字段的去重值并解码为指定类型
+// 例如：tags, err := DistinctAs[string](ctx, articleCol, "tags", bson.M{"status": "published"})
+func DistinctAs[T any](ctx context.Context, c *Collection, field string, filter bson.M, opts ...*options.DistinctOptions) ([]T, error) {
+	values, err := c.Distinct(ctx, field, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// 借助 bson 编解码完成类型转换，兼容 int32/int64 等数值类型差异
+	raw, err := bson.Marshal(bson.M{"values": values})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode distinct values: %w", err)
+	}
+	var decoded struct {
+		Values []T `bson:"values"`
+	}
+	if err := bson.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode distinct values: %w", err)
+	}
+	return decoded.Values, nil
+}
+
 // Aggregate 聚合查询
-func (c *Collection) Aggregate(ctx context.Context, pipeline []bson.M, results interface{}) error {
-	cursor, err := c.collection.Aggregate(ctx, pipeline)
+// 大数据量的分组聚合可以通过 opts 允许使用磁盘并限制执行时间，例如：
+//
+//	opts := options.Aggregate().SetAllowDiskUse(true).SetMaxTime(30 * time.Second)
+//	err := articles.Aggregate(ctx, pipeline, &results, opts)
+func (c *Collection) Aggregate(ctx context.Context, pipeline []bson.M, results interface{}, opts ...*options.AggregateOptions) error {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	opts = withMaxTime(ctx, c, opts, options.Aggregate().SetMaxTime)
+	cursor, err := c.collection.Aggregate(ctx, pipeline, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to aggregate: %w", err)
 	}
@@ -242,4 +859,6 @@ type PaginationResult struct {
 	PageSize  int64 `json:"page_size"`
 	Total     int64 `json:"total"`
 	TotalPage int64 `json:"total_page"`
+	// Estimated 总数来自 EstimatedDocumentCount，参见 CountCacheOptions.EstimateEmptyFilter
+	Estimated bool `json:"estimated,omitempty"`
 }
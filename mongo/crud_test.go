@@ -0,0 +1,305 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestBuildChangedUpdate(t *testing.T) {
+	type profile struct {
+		Bio     string `bson:"bio"`
+		Website string `bson:"website,omitempty"`
+	}
+	type user struct {
+		Name    string   `bson:"name"`
+		Tags    []string `bson:"tags"`
+		Profile profile  `bson:"profile"`
+		Nick    *string  `bson:"nick,omitempty"`
+	}
+
+	nick := "al"
+	original := user{Name: "alice", Tags: []string{"a"}, Profile: profile{Bio: "hi", Website: "a.com"}, Nick: &nick}
+	modified := original
+	modified.Tags = []string{"a", "b"}
+	modified.Profile.Bio = "hello"
+	modified.Profile.Website = ""
+	modified.Nick = nil
+
+	update, err := BuildChangedUpdate(original, modified)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"tags": bson.A{"a", "b"}, "profile.bio": "hello"},
+		"$unset": bson.M{"profile.website": "", "nick": ""},
+	}, update)
+
+	update, err = BuildChangedUpdate(original, original)
+	require.NoError(t, err)
+	assert.Empty(t, update)
+}
+
+// upsertServer 模拟 findAndModify 的 upsert：没有已有文档时返回 $setOnInsert 插入的文档
+func upsertServer(t *testing.T, existing bson.M) *fakeServer {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "findAndModify" {
+			return nil
+		}
+		var value interface{} = existing
+		if existing == nil {
+			value = cmd.Lookup("update", "$setOnInsert").Document()
+		}
+		return bson.D{{Key: "lastErrorObject", Value: bson.D{{Key: "n", Value: 1}}}, {Key: "value", Value: value}, {Key: "ok", Value: 1}}
+	})
+	return server
+}
+
+func TestFindOrCreateUsesIDStrategy(t *testing.T) {
+	server := upsertServer(t, nil)
+	users := NewCollection(server.client(t), "users").WithIDStrategy(UUIDv4Strategy)
+
+	var result bson.M
+	created, err := users.FindOrCreate(t.Context(), bson.M{"email": "a@b.com"}, bson.M{"email": "a@b.com"}, &result)
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	id := server.Commands("findAndModify")[0].Lookup("update", "$setOnInsert", "_id")
+	subtype, data := id.Binary()
+	assert.Equal(t, byte(4), subtype, "UUID strategy generates binary subtype 4 ids")
+	assert.Len(t, data, 16)
+}
+
+func TestFindOrCreate(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		server := upsertServer(t, nil)
+		notes := NewCollection(server.client(t), "notes")
+
+		// 与 InsertOne 一样校验文档
+		var result tenantNote
+		_, err := notes.FindOrCreate(t.Context(), bson.M{"title": "a"}, &tenantNote{}, &result)
+		assert.Error(t, err)
+		assert.Empty(t, server.Commands("findAndModify"))
+
+		created, err := notes.FindOrCreate(t.Context(), bson.M{"title": "a"}, &tenantNote{Title: "a"}, &result)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.False(t, result.ID.IsZero())
+		assert.Equal(t, "draft", result.Status, "default tags are applied")
+		assert.False(t, result.CreatedAt.IsZero(), "BeforeInsert runs")
+
+		setOnInsert := server.Commands("findAndModify")[0].Lookup("update", "$setOnInsert").Document()
+		assert.Equal(t, "draft", setOnInsert.Lookup("status").StringValue())
+		assert.Equal(t, result.ID, setOnInsert.Lookup("_id").ObjectID())
+	})
+
+	t.Run("found", func(t *testing.T) {
+		existing := bson.M{"_id": NewObjectID(), "title": "a", "status": "published"}
+		server := upsertServer(t, existing)
+		notes := NewCollection(server.client(t), "notes")
+
+		var result tenantNote
+		created, err := notes.FindOrCreate(t.Context(), bson.M{"title": "a"}, &tenantNote{Title: "a"}, &result)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, existing["_id"], result.ID)
+		assert.Equal(t, "published", result.Status)
+	})
+
+	t.Run("id in defaults", func(t *testing.T) {
+		id := NewObjectID()
+		server := upsertServer(t, nil)
+		notes := NewCollection(server.client(t), "notes")
+
+		defaults := &tenantNote{Title: "a"}
+		defaults.ID = id
+		var result tenantNote
+		created, err := notes.FindOrCreate(t.Context(), bson.M{"title": "a"}, defaults, &result)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, id, result.ID)
+		assert.Equal(t, id, server.Commands("findAndModify")[0].Lookup("update", "$setOnInsert", "_id").ObjectID())
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	server := newFakeServer(t, false)
+	notes := NewCollection(server.client(t), "notes")
+
+	_, err := notes.Upsert(t.Context(), bson.M{"title": "a"}, &tenantNote{})
+	assert.Error(t, err, "documents are validated before the upsert")
+	assert.Empty(t, server.Commands("update"))
+
+	id := NewObjectID()
+	note := &tenantNote{Title: "a"}
+	note.ID = id
+	_, err = notes.Upsert(t.Context(), bson.M{"title": "a"}, note)
+	require.NoError(t, err)
+
+	update := server.Commands("update")[0].Lookup("updates").Array().Index(0).Value().Document()
+	assert.True(t, update.Lookup("upsert").Boolean())
+	// _id 和 created_at 只在插入时写入，其它字段每次都更新
+	assert.Equal(t, id, update.Lookup("u", "$setOnInsert", "_id").ObjectID())
+	assert.Equal(t, bson.TypeDateTime, update.Lookup("u", "$setOnInsert", "created_at").Type)
+	assert.Equal(t, "a", update.Lookup("u", "$set", "title").StringValue())
+	assert.Equal(t, "draft", update.Lookup("u", "$set", "status").StringValue())
+	assert.Equal(t, bson.TypeDateTime, update.Lookup("u", "$set", "updated_at").Type)
+	_, err = update.Lookup("u", "$set").Document().LookupErr("_id")
+	assert.Error(t, err)
+}
+
+func TestEstimatedDocumentCount(t *testing.T) {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "count" {
+			return bson.D{{Key: "n", Value: 42}, {Key: "ok", Value: 1}}
+		}
+		return nil
+	})
+	client := server.client(t)
+	articles := NewCollection(client, "articles")
+
+	count, err := articles.EstimatedDocumentCount(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	// 按集合元数据计数，不发送过滤条件
+	counts := server.Commands("count")
+	require.Len(t, counts, 1)
+	assert.Equal(t, "articles", counts[0].Lookup("count").StringValue())
+	_, hasQuery := counts[0].Lookup("query").DocumentOK()
+	assert.False(t, hasQuery)
+
+	registry := NewModelRegistry(client)
+	require.NoError(t, RegisterModel[User](registry, ModelOptions{}))
+	users, err := NewRepositoryFor[User](registry)
+	require.NoError(t, err)
+	count, err = users.EstimatedDocumentCount(t.Context())
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	assert.Equal(t, "users", server.Commands("count")[1].Lookup("count").StringValue())
+
+	// 计数缓存对空过滤条件使用估算值
+	cc := NewCountCache(&CountCacheOptions{TTL: time.Minute, EstimateEmptyFilter: true, Cache: NewLRUCache(0)})
+	count, estimated, err := cc.Count(t.Context(), articles, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	assert.True(t, estimated)
+	assert.Len(t, server.Commands("count"), 3)
+}
+
+func TestDistinct(t *testing.T) {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "distinct" {
+			return nil
+		}
+		if cmd.Lookup("key").StringValue() == "views" {
+			// 数值字段的去重值可能混合 int32 和 int64
+			return bson.D{{Key: "values", Value: bson.A{int32(1), int64(2)}}, {Key: "ok", Value: 1}}
+		}
+		return bson.D{{Key: "values", Value: bson.A{"go", "mongo"}}, {Key: "ok", Value: 1}}
+	})
+	articles := NewCollection(server.client(t), "articles")
+	ctx := t.Context()
+
+	values, err := articles.Distinct(ctx, "tags", bson.M{"status": "published"})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"go", "mongo"}, values)
+
+	tags, err := DistinctAs[string](ctx, articles, "tags", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "mongo"}, tags)
+	views, err := DistinctAs[int64](ctx, articles, "views", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, views)
+	_, err = DistinctAs[bool](ctx, articles, "tags", nil)
+	assert.ErrorContains(t, err, "failed to decode distinct values")
+
+	commands := server.Commands("distinct")
+	require.Len(t, commands, 4)
+	assert.Equal(t, "tags", commands[0].Lookup("key").StringValue())
+	assert.Equal(t, "published", commands[0].Lookup("query", "status").StringValue())
+	// nil 过滤条件按空条件发送
+	query, ok := commands[1].Lookup("query").DocumentOK()
+	require.True(t, ok)
+	elements, err := query.Elements()
+	require.NoError(t, err)
+	assert.Empty(t, elements)
+}
+
+func TestStableSort(t *testing.T) {
+	tests := []struct {
+		sort     interface{}
+		expected interface{}
+	}{
+		{nil, bson.D{{Key: "_id", Value: 1}}},
+		{bson.D{{Key: "score", Value: -1}}, bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}},
+		{bson.D{{Key: "_id", Value: -1}}, bson.D{{Key: "_id", Value: -1}}},
+		{bson.M{"score": -1}, bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}},
+		{bson.M{"_id": -1}, bson.M{"_id": -1}},
+		// 多个字段的 bson.M 顺序不确定，保持原样
+		{bson.M{"a": 1, "b": 1}, bson.M{"a": 1, "b": 1}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, stableSort(tt.sort), "%v", tt.sort)
+	}
+
+	// 有剩余容量时也不写入调用方的底层数组
+	sort := make(bson.D, 1, 4)
+	sort[0] = bson.E{Key: "score", Value: -1}
+	stableSort(sort)
+	assert.Empty(t, sort[:2][1].Key)
+}
+
+func TestMergeFindOptions(t *testing.T) {
+	merged := mergeFindOptions([]*options.FindOptions{
+		options.Find().SetSort(bson.D{{Key: "a", Value: 1}}).SetHint("idx_a").SetMaxTime(time.Second),
+		nil,
+		options.Find().SetSort(bson.D{{Key: "b", Value: 1}}).SetCollation(&options.Collation{Locale: "zh"}),
+	})
+	assert.Equal(t, bson.D{{Key: "b", Value: 1}}, merged.Sort, "later options win")
+	assert.Equal(t, "idx_a", merged.Hint)
+	assert.Equal(t, "zh", merged.Collation.Locale)
+	assert.Equal(t, time.Second, *merged.MaxTime)
+}
+
+func TestFindWithPaginationOptions(t *testing.T) {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "aggregate" {
+			return fakeCursor(cmd, bson.M{"_id": 1, "n": 25})
+		}
+		return nil
+	})
+	articles := NewCollection(server.client(t), "articles")
+
+	var page []bson.M
+	result, err := articles.FindWithPagination(t.Context(), bson.M{"status": "published"}, 3, 10, &page, options.Find().
+		SetSort(bson.D{{Key: "score", Value: -1}}).
+		SetHint("idx_status_score").
+		SetCollation(&options.Collation{Locale: "zh"}).
+		SetMaxTime(2*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, &PaginationResult{Page: 3, PageSize: 10, Total: 25, TotalPage: 3}, result)
+
+	find := server.Commands("find")[0]
+	assert.Equal(t, int64(20), find.Lookup("skip").AsInt64())
+	assert.Equal(t, int64(10), find.Lookup("limit").AsInt64())
+	sortKeys, err := find.Lookup("sort").Document().Elements()
+	require.NoError(t, err)
+	require.Len(t, sortKeys, 2)
+	assert.Equal(t, "score", sortKeys[0].Key())
+	assert.Equal(t, "_id", sortKeys[1].Key(), "ties are broken by _id")
+	assert.Equal(t, "idx_status_score", find.Lookup("hint").StringValue())
+	assert.Equal(t, "zh", find.Lookup("collation", "locale").StringValue())
+	assert.Equal(t, int64(2000), find.Lookup("maxTimeMS").AsInt64())
+
+	// 计数使用相同的索引、排序规则和执行时间限制
+	count := server.Commands("aggregate")[0]
+	assert.Equal(t, "idx_status_score", count.Lookup("hint").StringValue())
+	assert.Equal(t, "zh", count.Lookup("collation", "locale").StringValue())
+	assert.Equal(t, int64(2000), count.Lookup("maxTimeMS").AsInt64())
+}
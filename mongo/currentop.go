@@ -0,0 +1,111 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Operation $currentOp 返回的正在执行的操作
+type Operation struct {
+	// OpID 操作 ID，mongod 上为整数，mongos 上为 "分片名:ID" 形式的字符串，原样传给 KillOp
+	OpID             interface{} `bson:"opid" json:"opid"`
+	Type             string      `bson:"type" json:"type"`
+	Op               string      `bson:"op" json:"op"`
+	Namespace        string      `bson:"ns" json:"ns"`
+	Active           bool        `bson:"active" json:"active"`
+	MicrosecsRunning int64       `bson:"microsecs_running" json:"microsecs_running"`
+	Client           string      `bson:"client" json:"client"`
+	AppName          string      `bson:"appName" json:"app_name"`
+	Description      string      `bson:"desc" json:"desc"`
+	Command          bson.M      `bson:"command" json:"command"`
+	PlanSummary      string      `bson:"planSummary" json:"plan_summary"`
+	WaitingForLock   bool        `bson:"waitingForLock" json:"waiting_for_lock"`
+	Shard            string      `bson:"shard,omitempty" json:"shard,omitempty"`
+}
+
+// Running 已运行的时间
+func (o *Operation) Running() time.Duration {
+	return time.Duration(o.MicrosecsRunning) * time.Microsecond
+}
+
+// CurrentOpFilter 正在执行的操作的过滤条件，零值表示所有活动操作
+type CurrentOpFilter struct {
+	// MinRunning 只返回运行时间不少于该值的操作
+	MinRunning time.Duration
+	// Namespace 只返回该命名空间（db.coll）的操作
+	Namespace string
+	// Op 操作类型，例如 query、getmore、update、remove、command
+	Op string
+	// AppName 客户端的应用名
+	AppName string
+	// AllUsers 返回所有用户的操作，需要 inprog 权限；否则只返回当前用户的操作
+	AllUsers bool
+	// IncludeIdle 同时返回空闲连接和非活动操作
+	IncludeIdle bool
+	// Match 追加的 $match 条件，例如 {"waitingForLock": true}
+	Match bson.M
+}
+
+// CurrentOps 通过 $currentOp 列出正在执行的操作，按运行时间从长到短排序；
+// 与 KillOp 一样不经过并发限制，连接池占满时仍可用于排查
+//
+//	ops, err := client.CurrentOps(ctx, &CurrentOpFilter{MinRunning: 10 * time.Second, AllUsers: true})
+//	for _, op := range ops {
+//		client.KillOp(ctx, op.OpID)
+//	}
+func (c *Client) CurrentOps(ctx context.Context, filter *CurrentOpFilter) ([]Operation, error) {
+	cursor, err := c.client.Database("admin").Aggregate(ctx, currentOpPipeline(filter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list current operations: %w", err)
+	}
+	var ops []Operation
+	if err := cursor.All(ctx, &ops); err != nil {
+		return nil, fmt.Errorf("failed to decode current operations: %w", err)
+	}
+	return ops, nil
+}
+
+func currentOpPipeline(filter *CurrentOpFilter) []bson.M {
+	f := CurrentOpFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	stage := bson.M{"allUsers": f.AllUsers, "idleConnections": f.IncludeIdle}
+	match := bson.M{}
+	if !f.IncludeIdle {
+		match["active"] = true
+	}
+	if f.MinRunning > 0 {
+		match["microsecs_running"] = bson.M{"$gte": f.MinRunning.Microseconds()}
+	}
+	if f.Namespace != "" {
+		match["ns"] = f.Namespace
+	}
+	if f.Op != "" {
+		match["op"] = f.Op
+	}
+	if f.AppName != "" {
+		match["appName"] = f.AppName
+	}
+	for key, value := range f.Match {
+		match[key] = value
+	}
+	return []bson.M{
+		{"$currentOp": stage},
+		{"$match": match},
+		{"$sort": bson.D{{Key: "microsecs_running", Value: -1}}},
+	}
+}
+
+// KillOp 终止指定的操作；操作已经结束时服务端同样返回成功
+func (c *Client) KillOp(ctx context.Context, opID interface{}) error {
+	command := bson.D{{Key: "killOp", Value: 1}, {Key: "op", Value: opID}}
+	if err := c.client.Database("admin").RunCommand(ctx, command).Err(); err != nil {
+		return fmt.Errorf("failed to kill operation %v: %w", opID, err)
+	}
+	c.logger.WarnContext(ctx, "Killed operation", "opid", opID)
+	return nil
+}
@@ -0,0 +1,56 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCurrentOpPipeline(t *testing.T) {
+	pipeline := currentOpPipeline(nil)
+	assert.Equal(t, bson.M{"allUsers": false, "idleConnections": false}, pipeline[0]["$currentOp"])
+	assert.Equal(t, bson.M{"active": true}, pipeline[1]["$match"])
+
+	pipeline = currentOpPipeline(&CurrentOpFilter{
+		MinRunning: 5 * time.Second,
+		Namespace:  "app.articles",
+		Op:         "query",
+		AllUsers:   true,
+		Match:      bson.M{"waitingForLock": true},
+	})
+	assert.Equal(t, bson.M{"allUsers": true, "idleConnections": false}, pipeline[0]["$currentOp"])
+	assert.Equal(t, bson.M{
+		"active":            true,
+		"microsecs_running": bson.M{"$gte": int64(5_000_000)},
+		"ns":                "app.articles",
+		"op":                "query",
+		"waitingForLock":    true,
+	}, pipeline[1]["$match"])
+
+	idle := currentOpPipeline(&CurrentOpFilter{IncludeIdle: true})
+	assert.Empty(t, idle[1]["$match"])
+}
+
+func TestOperationDecode(t *testing.T) {
+	data, err := bson.Marshal(bson.M{
+		"opid":              int32(4242),
+		"type":              "op",
+		"op":                "query",
+		"ns":                "app.articles",
+		"active":            true,
+		"microsecs_running": int64(12_500_000),
+		"planSummary":       "COLLSCAN",
+		"command":           bson.M{"find": "articles", "filter": bson.M{"title": bson.M{"$regex": "go"}}},
+	})
+	require.NoError(t, err)
+
+	var op Operation
+	require.NoError(t, bson.Unmarshal(data, &op))
+	assert.Equal(t, int32(4242), op.OpID)
+	assert.Equal(t, 12500*time.Millisecond, op.Running())
+	assert.Equal(t, "COLLSCAN", op.PlanSummary)
+	assert.Equal(t, "articles", op.Command["find"])
+}
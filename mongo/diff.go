@@ -0,0 +1,278 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DiffOptions 集合一致性检查配置
+type DiffOptions struct {
+	// Filter 只比较匹配的文档，两边使用相同的过滤条件
+	Filter bson.M
+	// IgnoreFields 不参与比较的顶层字段，例如 updated_at
+	IgnoreFields []string
+	// Ranges 按 _id 切分的范围数，每个范围在内存中比较，默认 16
+	Ranges int
+	// Workers 并发比较的范围数，默认 4
+	Workers int
+	// MaxReported 每类差异最多记录的 _id 数，超出时只计数，默认 1000
+	MaxReported int
+}
+
+// DiffReport 一致性检查结果
+type DiffReport struct {
+	// SourceCount 和 TargetCount 两边参与比较的文档数
+	SourceCount int64 `json:"source_count"`
+	TargetCount int64 `json:"target_count"`
+	// Missing 存在于源集合、目标集合中缺失的文档 _id
+	Missing      []interface{} `json:"missing"`
+	MissingCount int64         `json:"missing_count"`
+	// Extra 只存在于目标集合的文档 _id
+	Extra      []interface{} `json:"extra"`
+	ExtraCount int64         `json:"extra_count"`
+	// Different 两边都存在但内容不同的文档 _id
+	Different      []interface{} `json:"different"`
+	DifferentCount int64         `json:"different_count"`
+	// Truncated 差异超过 MaxReported，_id 列表不完整
+	Truncated bool `json:"truncated"`
+}
+
+// Consistent 两个集合是否完全一致
+func (r *DiffReport) Consistent() bool {
+	return r.MissingCount == 0 && r.ExtraCount == 0 && r.DifferentCount == 0
+}
+
+// CompareCollections 比较两个集合（可以属于不同的 Client）的内容，用于验证迁移和双写是否一致：
+// 按源集合的 _id 分布切分范围，逐范围读取两边文档的 _id 和内容哈希进行比较，
+// 哈希与字段顺序无关，因此字段顺序不同但内容相同的文档视为一致；数值类型不同（例如 int32 和 int64）视为不同
+//
+//	report, err := CompareCollections(ctx, oldUsers, newUsers, &DiffOptions{IgnoreFields: []string{"updated_at"}})
+//	if !report.Consistent() {
+//		log.Printf("missing=%d extra=%d different=%d", report.MissingCount, report.ExtraCount, report.DifferentCount)
+//	}
+func CompareCollections(ctx context.Context, source, target *Collection, opts *DiffOptions) (*DiffReport, error) {
+	o := DiffOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Ranges <= 0 {
+		o.Ranges = 16
+	}
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.MaxReported <= 0 {
+		o.MaxReported = 1000
+	}
+
+	ranges, err := source.ScanRanges(ctx, o.Filter, "_id", o.Ranges, o.Ranges*20)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	report := &DiffReport{}
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	queue := make(chan ScanRange)
+	for i := 0; i < o.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range queue {
+				err := compareRange(ctx, source, target, o, r, report, &mu)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}()
+	}
+	for _, r := range ranges {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- r
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return report, firstErr
+	}
+	return report, nil
+}
+
+// compareRange 比较一个范围内两边的文档，并将差异合并到 report
+func compareRange(ctx context.Context, source, target *Collection, o DiffOptions, r ScanRange, report *DiffReport, mu *sync.Mutex) error {
+	filter := rangeFilter(o.Filter, "_id", r)
+	sourceHashes, err := hashDocuments(ctx, source, filter, o.IgnoreFields)
+	if err != nil {
+		return err
+	}
+	targetHashes, err := hashDocuments(ctx, target, filter, o.IgnoreFields)
+	if err != nil {
+		return err
+	}
+	missing, extra, different := diffHashes(sourceHashes, targetHashes)
+
+	mu.Lock()
+	defer mu.Unlock()
+	report.SourceCount += int64(len(sourceHashes))
+	report.TargetCount += int64(len(targetHashes))
+	report.MissingCount += int64(len(missing))
+	report.ExtraCount += int64(len(extra))
+	report.DifferentCount += int64(len(different))
+	report.Missing = appendReported(report, report.Missing, missing, o.MaxReported)
+	report.Extra = appendReported(report, report.Extra, extra, o.MaxReported)
+	report.Different = appendReported(report, report.Different, different, o.MaxReported)
+	return nil
+}
+
+// appendReported 追加差异的 _id，超过上限时标记 Truncated
+func appendReported(report *DiffReport, list []interface{}, ids []bson.RawValue, limit int) []interface{} {
+	for _, id := range ids {
+		if len(list) >= limit {
+			report.Truncated = true
+			return list
+		}
+		list = append(list, id)
+	}
+	return list
+}
+
+// documentHash 文档的 _id 和内容哈希
+type documentHash struct {
+	id   bson.RawValue
+	hash [sha256.Size]byte
+}
+
+// hashDocuments 读取过滤条件匹配的文档并计算内容哈希，以 _id 的编码为键
+func hashDocuments(ctx context.Context, c *Collection, filter bson.M, ignore []string) (map[string]documentHash, error) {
+	ctx = c.sessionContext(ctx)
+	opts := options.Find()
+	if len(ignore) > 0 {
+		projection := bson.M{}
+		for _, field := range ignore {
+			if field != "_id" {
+				projection[field] = 0
+			}
+		}
+		if len(projection) > 0 {
+			opts.SetProjection(projection)
+		}
+	}
+	cursor, err := c.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", c.collection.Name(), err)
+	}
+	defer cursor.Close(ctx)
+
+	hashes := map[string]documentHash{}
+	for cursor.Next(ctx) {
+		id, err := cursor.Current.LookupErr("_id")
+		if err != nil {
+			continue
+		}
+		h := sha256.New()
+		if err := hashDocument(h, cursor.Current); err != nil {
+			return nil, fmt.Errorf("failed to hash document %v: %w", id, err)
+		}
+		var sum [sha256.Size]byte
+		copy(sum[:], h.Sum(nil))
+		key := string(append([]byte{byte(id.Type)}, id.Value...))
+		hashes[key] = documentHash{
+			id:   bson.RawValue{Type: id.Type, Value: append([]byte(nil), id.Value...)},
+			hash: sum,
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", c.collection.Name(), err)
+	}
+	return hashes, nil
+}
+
+// hashDocument 按键排序后递归写入文档的所有元素，使哈希与字段顺序无关；数组保持原有顺序
+func hashDocument(h hash.Hash, doc bson.Raw) error {
+	elems, err := doc.Elements()
+	if err != nil {
+		return err
+	}
+	sort.Slice(elems, func(i, j int) bool { return elems[i].Key() < elems[j].Key() })
+	for _, elem := range elems {
+		h.Write([]byte(elem.Key()))
+		h.Write([]byte{0})
+		if err := hashValue(h, elem.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashValue 写入单个值，子文档递归排序
+func hashValue(h hash.Hash, value bson.RawValue) error {
+	h.Write([]byte{byte(value.Type)})
+	switch value.Type {
+	case bson.TypeEmbeddedDocument:
+		return hashDocument(h, value.Document())
+	case bson.TypeArray:
+		values, err := value.Array().Values()
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			if err := hashValue(h, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	h.Write(value.Value)
+	return nil
+}
+
+// diffHashes 比较两边的哈希，返回按 _id 编码排序的缺失、多余和不同的文档
+func diffHashes(source, target map[string]documentHash) (missing, extra, different []bson.RawValue) {
+	keys := make([]string, 0, len(source))
+	for key := range source {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		t, ok := target[key]
+		switch {
+		case !ok:
+			missing = append(missing, source[key].id)
+		case t.hash != source[key].hash:
+			different = append(different, source[key].id)
+		}
+	}
+
+	keys = keys[:0]
+	for key := range target {
+		if _, ok := source[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		extra = append(extra, target[key].id)
+	}
+	return missing, extra, different
+}
@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func hashOf(t *testing.T, doc interface{}) [sha256.Size]byte {
+	raw, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	h := sha256.New()
+	require.NoError(t, hashDocument(h, raw))
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+func TestHashDocumentIgnoresFieldOrder(t *testing.T) {
+	a := bson.D{{Key: "a", Value: 1}, {Key: "b", Value: bson.D{{Key: "x", Value: "1"}, {Key: "y", Value: "2"}}}}
+	b := bson.D{{Key: "b", Value: bson.D{{Key: "y", Value: "2"}, {Key: "x", Value: "1"}}}, {Key: "a", Value: 1}}
+	assert.Equal(t, hashOf(t, a), hashOf(t, b))
+
+	// 数组顺序和数值类型参与比较
+	assert.NotEqual(t, hashOf(t, bson.D{{Key: "tags", Value: bson.A{"x", "y"}}}), hashOf(t, bson.D{{Key: "tags", Value: bson.A{"y", "x"}}}))
+	assert.NotEqual(t, hashOf(t, bson.D{{Key: "n", Value: int32(1)}}), hashOf(t, bson.D{{Key: "n", Value: int64(1)}}))
+}
+
+func TestDiffHashes(t *testing.T) {
+	entry := func(id int32, doc bson.D) (string, documentHash) {
+		_, value, err := bson.MarshalValue(id)
+		require.NoError(t, err)
+		rv := bson.RawValue{Type: bson.TypeInt32, Value: value}
+		return string(append([]byte{byte(rv.Type)}, value...)), documentHash{id: rv, hash: hashOf(t, doc)}
+	}
+	source := map[string]documentHash{}
+	target := map[string]documentHash{}
+	for _, e := range []struct {
+		id     int32
+		doc    bson.D
+		source bool
+		target bool
+	}{
+		{1, bson.D{{Key: "v", Value: 1}}, true, true},
+		{2, bson.D{{Key: "v", Value: 2}}, true, false},
+		{3, bson.D{{Key: "v", Value: 3}}, false, true},
+	} {
+		key, h := entry(e.id, e.doc)
+		if e.source {
+			source[key] = h
+		}
+		if e.target {
+			target[key] = h
+		}
+	}
+	key, h := entry(4, bson.D{{Key: "v", Value: 4}})
+	source[key] = h
+	_, h = entry(4, bson.D{{Key: "v", Value: 5}})
+	target[key] = h
+
+	missing, extra, different := diffHashes(source, target)
+	require.Len(t, missing, 1)
+	assert.Equal(t, int32(2), missing[0].Int32())
+	require.Len(t, extra, 1)
+	assert.Equal(t, int32(3), extra[0].Int32())
+	require.Len(t, different, 1)
+	assert.Equal(t, int32(4), different[0].Int32())
+
+	report := &DiffReport{}
+	report.Missing = appendReported(report, nil, append(missing, different...), 1)
+	assert.Len(t, report.Missing, 1)
+	assert.True(t, report.Truncated)
+}
@@ -1,8 +1,12 @@
 package mongo
 
+//go:generate go run ../cmd/mongofields -type User,Article,Category,Comment -out ./fields
+
 import (
+	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -66,11 +70,149 @@ func (d *BaseDocument) BeforeUpdate() {
 	d.UpdatedAt = time.Now()
 }
 
+// GetDocumentID 获取文档ID，实现 Identifiable
+func (d *BaseDocument) GetDocumentID() interface{} {
+	return d.ID
+}
+
+// SetDocumentID 设置文档ID，实现 Identifiable
+func (d *BaseDocument) SetDocumentID(id interface{}) error {
+	objectID, ok := id.(primitive.ObjectID)
+	if !ok {
+		return fmt.Errorf("document id %v is not ObjectID", id)
+	}
+	d.ID = objectID
+	return nil
+}
+
+// UUIDDocument 使用 UUID 作为主键的基础文档结构体，插入时 ID 为空则生成 UUIDv7
+type UUIDDocument struct {
+	ID        UUID      `bson:"_id,omitempty" json:"id,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// GetDocumentID 获取文档ID
+func (d *UUIDDocument) GetDocumentID() interface{} {
+	return d.ID
+}
+
+// SetDocumentID 设置文档ID，支持 UUID、binary subtype 4 和字符串形式
+func (d *UUIDDocument) SetDocumentID(id interface{}) error {
+	switch v := id.(type) {
+	case UUID:
+		d.ID = v
+	case primitive.Binary:
+		if (v.Subtype != bsontype.BinaryUUID && v.Subtype != bsontype.BinaryUUIDOld) || len(v.Data) != len(d.ID) {
+			return fmt.Errorf("document id is not a UUID binary")
+		}
+		copy(d.ID[:], v.Data)
+	case string:
+		u, err := ParseUUID(v)
+		if err != nil {
+			return err
+		}
+		d.ID = u
+	default:
+		return fmt.Errorf("document id %v is not UUID", id)
+	}
+	return nil
+}
+
+// GetCreatedAt 获取创建时间
+func (d *UUIDDocument) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
+// GetUpdatedAt 获取更新时间
+func (d *UUIDDocument) GetUpdatedAt() time.Time {
+	return d.UpdatedAt
+}
+
+// SetUpdatedAt 设置更新时间
+func (d *UUIDDocument) SetUpdatedAt(t time.Time) {
+	d.UpdatedAt = t
+}
+
+// BeforeInsert 插入前的钩子函数
+func (d *UUIDDocument) BeforeInsert() {
+	now := time.Now()
+	if d.ID.IsZero() {
+		d.ID = NewUUIDv7()
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = now
+	}
+	d.UpdatedAt = now
+}
+
+// BeforeUpdate 更新前的钩子函数
+func (d *UUIDDocument) BeforeUpdate() {
+	d.UpdatedAt = time.Now()
+}
+
+// StringDocument 使用字符串作为主键的基础文档结构体，插入时 ID 为空则生成 ObjectID 的十六进制形式
+type StringDocument struct {
+	ID        string    `bson:"_id,omitempty" json:"id,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// GetDocumentID 获取文档ID
+func (d *StringDocument) GetDocumentID() interface{} {
+	return d.ID
+}
+
+// SetDocumentID 设置文档ID
+func (d *StringDocument) SetDocumentID(id interface{}) error {
+	s, ok := id.(string)
+	if !ok {
+		return fmt.Errorf("document id %v is not string", id)
+	}
+	d.ID = s
+	return nil
+}
+
+// GetCreatedAt 获取创建时间
+func (d *StringDocument) GetCreatedAt() time.Time {
+	return d.CreatedAt
+}
+
+// GetUpdatedAt 获取更新时间
+func (d *StringDocument) GetUpdatedAt() time.Time {
+	return d.UpdatedAt
+}
+
+// SetUpdatedAt 设置更新时间
+func (d *StringDocument) SetUpdatedAt(t time.Time) {
+	d.UpdatedAt = t
+}
+
+// BeforeInsert 插入前的钩子函数
+func (d *StringDocument) BeforeInsert() {
+	now := time.Now()
+	if d.ID == "" {
+		d.ID = primitive.NewObjectID().Hex()
+	}
+	if d.CreatedAt.IsZero() {
+		d.CreatedAt = now
+	}
+	d.UpdatedAt = now
+}
+
+// BeforeUpdate 更新前的钩子函数
+func (d *StringDocument) BeforeUpdate() {
+	d.UpdatedAt = time.Now()
+}
+
 // User 用户文档示例
+//
+//mongofields:compound status,-created_at
+//mongofields:compound profile.first_name,profile.last_name
 type User struct {
 	BaseDocument `bson:",inline"`
 	Username     string `bson:"username" json:"username"`
-	Email        string `bson:"email" json:"email"`
+	Email        string `bson:"email" json:"email" csfle:"deterministic"`
 	Password     string `bson:"password" json:"-"` // 不在JSON中显示密码
 	Status       string `bson:"status" json:"status"`
 	Profile      struct {
@@ -82,20 +224,27 @@ type User struct {
 }
 
 // Article 文章文档示例
+//
+//mongofields:compound status,-created_at
+//mongofields:compound author_id,status
+//mongofields:compound category_id,status,-created_at
 type Article struct {
 	BaseDocument `bson:",inline"`
 	Title        string               `bson:"title" json:"title"`
 	Content      string               `bson:"content" json:"content"`
-	AuthorID     primitive.ObjectID   `bson:"author_id" json:"author_id"`
+	AuthorID     primitive.ObjectID   `bson:"author_id" json:"author_id" ref:"users"`
 	Tags         []string             `bson:"tags" json:"tags"`
-	Status       string               `bson:"status" json:"status"` // draft, published, archived
+	Status       string               `bson:"status" json:"status" schema:"enum=draft|published|archived"` // draft, published, archived
 	ViewCount    int64                `bson:"view_count" json:"view_count"`
 	LikeCount    int64                `bson:"like_count" json:"like_count"`
-	CategoryID   primitive.ObjectID   `bson:"category_id,omitempty" json:"category_id,omitempty"`
+	CategoryID   primitive.ObjectID   `bson:"category_id,omitempty" json:"category_id,omitempty" ref:"categories"`
 	Comments     []primitive.ObjectID `bson:"comments" json:"comments"`
 }
 
 // Category 分类文档示例
+//
+//mongofields:compound parent_id,sort
+//mongofields:compound is_active,sort
 type Category struct {
 	BaseDocument `bson:",inline"`
 	Name         string `bson:"name" json:"name"`
@@ -103,4 +252,81 @@ type Category struct {
 	ParentID     *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
 	Sort         int    `bson:"sort" json:"sort"`
 	IsActive     bool   `bson:"is_active" json:"is_active"`
-}
\ No newline at end of file
+}
+
+// Comment 评论文档示例，ParentID 指向被回复的评论，Path 为物化路径，用于按线程读取和排序
+//
+//mongofields:compound article_id,path
+//mongofields:compound article_id,status,-created_at
+//mongofields:compound status,created_at
+type Comment struct {
+	BaseDocument `bson:",inline"`
+	ArticleID    primitive.ObjectID  `bson:"article_id" json:"article_id" ref:"articles"`
+	AuthorID     primitive.ObjectID  `bson:"author_id" json:"author_id" ref:"users"`
+	ParentID     *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty" ref:"comments"`
+	// Path 从顶层评论到自身的 ID 十六进制形式，以 / 分隔，例如 "65a1.../65a2..."
+	Path       string        `bson:"path" json:"path"`
+	Depth      int           `bson:"depth" json:"depth"` // 顶层评论为 0
+	Content    string        `bson:"content" json:"content"`
+	Status     CommentStatus `bson:"status" json:"status" schema:"enum=pending|approved|rejected|spam|deleted"`
+	ReplyCount int64         `bson:"reply_count" json:"reply_count"` // 已通过审核的直接回复数
+}
+
+// GeoPoint GeoJSON 点，坐标顺序为 [经度, 纬度]
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPoint 创建 GeoJSON 点
+func NewGeoPoint(longitude, latitude float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+// Longitude 经度
+func (p GeoPoint) Longitude() float64 {
+	if len(p.Coordinates) < 2 {
+		return 0
+	}
+	return p.Coordinates[0]
+}
+
+// Latitude 纬度
+func (p GeoPoint) Latitude() float64 {
+	if len(p.Coordinates) < 2 {
+		return 0
+	}
+	return p.Coordinates[1]
+}
+
+// GeoPolygon GeoJSON 多边形，第一个环为外边界，其余环为内部的洞
+type GeoPolygon struct {
+	Type        string        `bson:"type" json:"type"`
+	Coordinates [][][]float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// NewGeoPolygon 创建 GeoJSON 多边形，每个环由 [经度, 纬度] 点组成，首尾不相同时自动闭合
+func NewGeoPolygon(rings ...[][]float64) GeoPolygon {
+	coordinates := make([][][]float64, 0, len(rings))
+	for _, ring := range rings {
+		closed := append([][]float64{}, ring...)
+		if n := len(closed); n > 0 && !samePosition(closed[0], closed[n-1]) {
+			closed = append(closed, closed[0])
+		}
+		coordinates = append(coordinates, closed)
+	}
+	return GeoPolygon{Type: "Polygon", Coordinates: coordinates}
+}
+
+// samePosition 判断两个坐标是否相同
+func samePosition(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,288 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 客户端字段级加密算法
+const (
+	// EncryptDeterministic 确定性加密，相同明文得到相同密文，支持等值查询
+	EncryptDeterministic = "AEAD_AES_256_CBC_HMAC_SHA_512-Deterministic"
+	// EncryptRandom 随机加密，安全性更高，但加密字段无法用于查询
+	EncryptRandom = "AEAD_AES_256_CBC_HMAC_SHA_512-Random"
+)
+
+// EncryptionConfig 客户端字段级加密（CSFLE）/ Queryable Encryption 配置
+// 自动加解密依赖 libmongocrypt，编译时需要添加 cse 构建标签，
+// 并通过 CryptSharedLibPath 指定 crypt_shared 库或在本机运行 mongocryptd
+type EncryptionConfig struct {
+	// KeyVaultNamespace 数据密钥存储位置，格式为 "数据库.集合"，例如 "encryption.__keyVault"
+	KeyVaultNamespace string `json:"key_vault_namespace"`
+	// KMSProviders KMS 配置，例如 {"local": {"key": <96字节主密钥>}} 或 {"aws": {"accessKeyId": ..., "secretAccessKey": ...}}
+	KMSProviders map[string]map[string]interface{} `json:"-"`
+	// SchemaMap 各集合的加密规则，key 为 "数据库.集合"，可由 BuildEncryptionSchema 生成
+	SchemaMap map[string]interface{} `json:"-"`
+	// EncryptedFieldsMap Queryable Encryption 的加密字段配置，key 为 "数据库.集合"
+	EncryptedFieldsMap map[string]interface{} `json:"-"`
+	// BypassAutoEncryption 只自动解密，不自动加密
+	BypassAutoEncryption bool `json:"bypass_auto_encryption,omitempty"`
+	// CryptSharedLibPath crypt_shared 动态库路径
+	CryptSharedLibPath string `json:"crypt_shared_lib_path,omitempty"`
+}
+
+// AddCollectionSchema 为集合添加加密规则
+func (ec *EncryptionConfig) AddCollectionSchema(database, collection string, schema bson.M) {
+	if ec.SchemaMap == nil {
+		ec.SchemaMap = map[string]interface{}{}
+	}
+	ec.SchemaMap[database+"."+collection] = schema
+}
+
+// buildAutoEncryptionOptions 根据配置构建自动加密选项
+func buildAutoEncryptionOptions(config *EncryptionConfig) (*options.AutoEncryptionOptions, error) {
+	if config.KeyVaultNamespace == "" {
+		return nil, fmt.Errorf("key vault namespace is required for auto encryption")
+	}
+	if len(config.KMSProviders) == 0 {
+		return nil, fmt.Errorf("at least one KMS provider is required for auto encryption")
+	}
+
+	opts := options.AutoEncryption().
+		SetKeyVaultNamespace(config.KeyVaultNamespace).
+		SetKmsProviders(config.KMSProviders).
+		SetBypassAutoEncryption(config.BypassAutoEncryption)
+	if len(config.SchemaMap) > 0 {
+		opts.SetSchemaMap(config.SchemaMap)
+	}
+	if len(config.EncryptedFieldsMap) > 0 {
+		opts.SetEncryptedFieldsMap(config.EncryptedFieldsMap)
+	}
+	if config.CryptSharedLibPath != "" {
+		opts.SetExtraOptions(map[string]interface{}{
+			"cryptSharedLibPath":     config.CryptSharedLibPath,
+			"cryptSharedLibRequired": true,
+		})
+	}
+	return opts, nil
+}
+
+// BuildEncryptionSchema 根据结构体的 csfle 标签生成集合加密规则
+// 标签取值为 deterministic 或 random，例如：
+//
+//	Email string `bson:"email" csfle:"deterministic"`
+//	Phone string `bson:"phone" csfle:"random"`
+//
+// int、uint 等按取值大小编码为 int 或 long 的字段只能使用随机加密，确定性加密请使用 int32 或 int64
+func BuildEncryptionSchema(model interface{}, keyID primitive.Binary) (bson.M, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct, got %T", model)
+	}
+
+	properties := bson.M{}
+	if err := collectEncryptedProperties(t, properties); err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, fmt.Errorf("no fields tagged with csfle in %s", t.Name())
+	}
+
+	return bson.M{
+		"bsonType": "object",
+		"encryptMetadata": bson.M{
+			"keyId": bson.A{keyID},
+		},
+		"properties": properties,
+	}, nil
+}
+
+// collectEncryptedProperties 收集需要加密的字段，嵌套结构体生成嵌套规则
+func collectEncryptedProperties(t reflect.Type, properties bson.M) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		bsonTag := field.Tag.Get("bson")
+		if bsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(bsonTag, ",")
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if contains(tagParts[1:], "inline") {
+			if ft.Kind() == reflect.Struct {
+				if err := collectEncryptedProperties(ft, properties); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := tagParts[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		algorithm := field.Tag.Get("csfle")
+		if algorithm == "" {
+			// 未标记的嵌套结构体中可能包含需要加密的字段
+			if ft.Kind() == reflect.Struct && ft != timeType && ft != objectIDType && ft != decimalType {
+				nested := bson.M{}
+				if err := collectEncryptedProperties(ft, nested); err != nil {
+					return err
+				}
+				if len(nested) > 0 {
+					properties[name] = bson.M{"bsonType": "object", "properties": nested}
+				}
+			}
+			continue
+		}
+
+		prop, err := typeSchema(ft)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		bsonType := encryptedBSONType(prop["bsonType"])
+
+		switch algorithm {
+		case "deterministic":
+			if _, ok := bsonType.(bson.A); ok {
+				// int、uint 按取值大小编码为 int 或 long，确定性加密只能指定一种类型
+				return fmt.Errorf("field %s: deterministic encryption requires a single bsonType, got %v; use int32 or int64", field.Name, bsonType)
+			}
+			switch bsonType {
+			case "double", "decimal", "bool", "object", "array":
+				return fmt.Errorf("field %s: deterministic encryption does not support %v", field.Name, bsonType)
+			}
+			properties[name] = bson.M{"encrypt": bson.M{"bsonType": bsonType, "algorithm": EncryptDeterministic}}
+		case "random":
+			properties[name] = bson.M{"encrypt": bson.M{"bsonType": bsonType, "algorithm": EncryptRandom}}
+		default:
+			return fmt.Errorf("field %s: unsupported csfle algorithm %q", field.Name, algorithm)
+		}
+	}
+	return nil
+}
+
+// encryptedBSONType 去掉 typeSchema 为指针、切片和 map 追加的 null，null 值不会被加密；
+// 剩余多种类型时（例如 int 对应的 int 和 long）返回类型列表
+func encryptedBSONType(bsonType interface{}) interface{} {
+	types, ok := bsonType.(bson.A)
+	if !ok {
+		return bsonType
+	}
+	var result bson.A
+	for _, t := range types {
+		if t != "null" {
+			result = append(result, t)
+		}
+	}
+	if len(result) == 1 {
+		return result[0]
+	}
+	return result
+}
+
+// KeyVault 数据密钥管理
+type KeyVault struct {
+	encryption *mongo.ClientEncryption
+	namespace  string
+	client     *Client
+}
+
+// NewKeyVault 创建数据密钥管理器，client 为存放密钥库的集群连接
+func NewKeyVault(client *Client, config *EncryptionConfig) (*KeyVault, error) {
+	opts := options.ClientEncryption().
+		SetKeyVaultNamespace(config.KeyVaultNamespace).
+		SetKmsProviders(config.KMSProviders)
+
+	encryption, err := mongo.NewClientEncryption(client.client, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client encryption: %w", err)
+	}
+	return &KeyVault{
+		encryption: encryption,
+		namespace:  config.KeyVaultNamespace,
+		client:     client,
+	}, nil
+}
+
+// EnsureIndexes 为密钥库创建 keyAltNames 唯一索引
+func (kv *KeyVault) EnsureIndexes(ctx context.Context) error {
+	db, coll, ok := strings.Cut(kv.namespace, ".")
+	if !ok {
+		return fmt.Errorf("invalid key vault namespace %q", kv.namespace)
+	}
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "keyAltNames", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetName("idx_key_alt_names_unique").
+			SetPartialFilterExpression(bson.M{"keyAltNames": bson.M{"$exists": true}}),
+	}
+	if err := kv.checkWritable("createIndexes"); err != nil {
+		return err
+	}
+	if _, err := kv.client.client.Database(db).Collection(coll).Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create key vault index: %w", err)
+	}
+	return nil
+}
+
+// CreateDataKey 创建数据密钥，masterKey 为 KMS 主密钥信息（local 提供者传 nil）
+func (kv *KeyVault) CreateDataKey(ctx context.Context, kmsProvider string, keyAltNames []string, masterKey interface{}) (primitive.Binary, error) {
+	if err := kv.checkWritable("insert"); err != nil {
+		return primitive.Binary{}, err
+	}
+	opts := options.DataKey()
+	if len(keyAltNames) > 0 {
+		opts.SetKeyAltNames(keyAltNames)
+	}
+	if masterKey != nil {
+		opts.SetMasterKey(masterKey)
+	}
+	keyID, err := kv.encryption.CreateDataKey(ctx, kmsProvider, opts)
+	if err != nil {
+		return primitive.Binary{}, fmt.Errorf("failed to create data key: %w", err)
+	}
+	return keyID, nil
+}
+
+// checkWritable 只读模式下返回 *ReadOnlyError，密钥库可以位于其它数据库
+func (kv *KeyVault) checkWritable(operation string) error {
+	if kv.client == nil || !kv.client.readOnly {
+		return nil
+	}
+	return &ReadOnlyError{Operation: operation, Namespace: kv.namespace}
+}
+
+// GetKeyIDByAltName 根据别名获取数据密钥 ID
+func (kv *KeyVault) GetKeyIDByAltName(ctx context.Context, keyAltName string) (primitive.Binary, error) {
+	var key struct {
+		ID primitive.Binary `bson:"_id"`
+	}
+	if err := kv.encryption.GetKeyByAltName(ctx, keyAltName).Decode(&key); err != nil {
+		return primitive.Binary{}, fmt.Errorf("failed to get data key %s: %w", keyAltName, err)
+	}
+	return key.ID, nil
+}
+
+// Close 关闭密钥管理器
+func (kv *KeyVault) Close(ctx context.Context) error {
+	return kv.encryption.Close(ctx)
+}
@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildEncryptionSchema(t *testing.T) {
+	type contact struct {
+		Phone string `bson:"phone" csfle:"random"`
+		City  string `bson:"city"`
+	}
+	type patient struct {
+		BaseDocument `bson:",inline"`
+		SSN          string     `bson:"ssn" csfle:"deterministic"`
+		Alias        *string    `bson:"alias,omitempty" csfle:"deterministic"`
+		Visits       int        `bson:"visits" csfle:"random"`
+		Score        int64      `bson:"score" csfle:"deterministic"`
+		Born         time.Time  `bson:"born" csfle:"deterministic"`
+		Notes        []string   `bson:"notes" csfle:"random"`
+		Contact      contact    `bson:"contact"`
+		Guardian     *contact   `bson:"guardian,omitempty"`
+		Updated      *time.Time `bson:"updated"`
+		Name         string     `bson:"name"`
+	}
+	keyID := primitive.Binary{Subtype: 4, Data: make([]byte, 16)}
+
+	schema, err := BuildEncryptionSchema(&patient{}, keyID)
+	require.NoError(t, err)
+	assert.Equal(t, "object", schema["bsonType"])
+	assert.Equal(t, bson.M{"keyId": bson.A{keyID}}, schema["encryptMetadata"])
+
+	encrypt := func(bsonType interface{}, algorithm string) bson.M {
+		return bson.M{"encrypt": bson.M{"bsonType": bsonType, "algorithm": algorithm}}
+	}
+	assert.Equal(t, bson.M{
+		"ssn":    encrypt("string", EncryptDeterministic),
+		"alias":  encrypt("string", EncryptDeterministic),
+		"visits": encrypt(bson.A{"int", "long"}, EncryptRandom),
+		"score":  encrypt("long", EncryptDeterministic),
+		"born":   encrypt("date", EncryptDeterministic),
+		"notes":  encrypt("array", EncryptRandom),
+		"contact": bson.M{"bsonType": "object", "properties": bson.M{
+			"phone": encrypt("string", EncryptRandom),
+		}},
+		"guardian": bson.M{"bsonType": "object", "properties": bson.M{
+			"phone": encrypt("string", EncryptRandom),
+		}},
+	}, schema["properties"])
+}
+
+func TestBuildEncryptionSchemaErrors(t *testing.T) {
+	keyID := primitive.Binary{Subtype: 4, Data: make([]byte, 16)}
+	tests := []interface{}{
+		"not a struct",
+		struct {
+			Name string `bson:"name"`
+		}{},
+		struct {
+			Count int `bson:"count" csfle:"deterministic"`
+		}{},
+		struct {
+			Count uint `bson:"count" csfle:"deterministic"`
+		}{},
+		struct {
+			Price float64 `bson:"price" csfle:"deterministic"`
+		}{},
+		struct {
+			Active bool `bson:"active" csfle:"deterministic"`
+		}{},
+		struct {
+			Tags []string `bson:"tags" csfle:"deterministic"`
+		}{},
+		struct {
+			Name string `bson:"name" csfle:"aes"`
+		}{},
+	}
+	for _, model := range tests {
+		_, err := BuildEncryptionSchema(model, keyID)
+		assert.Error(t, err, "%#v", model)
+	}
+}
+
+func TestBuildAutoEncryptionOptions(t *testing.T) {
+	_, err := buildAutoEncryptionOptions(&EncryptionConfig{})
+	assert.Error(t, err)
+	_, err = buildAutoEncryptionOptions(&EncryptionConfig{KeyVaultNamespace: "encryption.__keyVault"})
+	assert.Error(t, err)
+
+	config := &EncryptionConfig{
+		KeyVaultNamespace:  "encryption.__keyVault",
+		KMSProviders:       map[string]map[string]interface{}{"local": {"key": make([]byte, 96)}},
+		CryptSharedLibPath: "/usr/lib/mongo_crypt_v1.so",
+	}
+	config.AddCollectionSchema("app", "patients", bson.M{"bsonType": "object"})
+	opts, err := buildAutoEncryptionOptions(config)
+	require.NoError(t, err)
+	assert.Equal(t, "encryption.__keyVault", opts.KeyVaultNamespace)
+	assert.Contains(t, opts.SchemaMap, "app.patients")
+	assert.Equal(t, true, opts.ExtraOptions["cryptSharedLibRequired"])
+}
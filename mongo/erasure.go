@@ -0,0 +1,267 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErasureAction 删除数据主体时对相关文档的处理方式
+type ErasureAction string
+
+const (
+	// ErasureRetain 保留文档，只在报告中统计仍引用该主体的文档数
+	ErasureRetain ErasureAction = "retain"
+	// ErasureDelete 删除文档
+	ErasureDelete ErasureAction = "delete"
+	// ErasureAnonymize 保留文档但清除或替换个人信息字段
+	ErasureAnonymize ErasureAction = "anonymize"
+	// ErasureUnlink 保留文档，只移除对主体的引用：单值引用删除字段，数组引用从数组中移除
+	ErasureUnlink ErasureAction = "unlink"
+)
+
+// ErasurePolicy 集合的个人数据删除策略，可以通过 ModelOptions.Erasure 随模型一起声明
+//
+//	RegisterModel[Comment](models, ModelOptions{Erasure: &ErasurePolicy{
+//		Action:          ErasureAnonymize,
+//		AnonymizeFields: map[string]interface{}{"author_id": nil, "author_name": "deleted user"},
+//	}})
+type ErasurePolicy struct {
+	// Action 处理方式；主体集合默认 ErasureDelete，其它集合默认 ErasureRetain
+	Action ErasureAction
+	// AnonymizeFields ErasureAnonymize 要处理的字段及替换值，值为 nil 时删除该字段
+	AnonymizeFields map[string]interface{}
+}
+
+// 删除执行状态
+const (
+	ErasureStatusSuccess = "success"
+	ErasureStatusFailed  = "failed"
+)
+
+// ErasureResult 单个集合（引用字段）的处理结果
+type ErasureResult struct {
+	Collection string `bson:"collection" json:"collection"`
+	// Field 引用主体的字段，主体集合本身为 _id
+	Field  string        `bson:"field" json:"field"`
+	Action ErasureAction `bson:"action" json:"action"`
+	// Matched 引用该主体的文档数
+	Matched int64 `bson:"matched" json:"matched"`
+	// Affected 实际删除、匿名化或解除引用的文档数
+	Affected int64  `bson:"affected" json:"affected"`
+	Error    string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// ErasureReport 一次删除请求的报告，保存在 erasure_reports 集合中作为处理记录；报告只包含主体 _id，不包含个人信息
+type ErasureReport struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubjectID  interface{}        `bson:"subject_id" json:"subject_id"`
+	Collection string             `bson:"collection" json:"collection"`
+	Status     string             `bson:"status" json:"status"`
+	Results    []ErasureResult    `bson:"results" json:"results"`
+	StartedAt  time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time          `bson:"finished_at" json:"finished_at"`
+}
+
+// ErasureOptions 个人数据删除配置
+type ErasureOptions struct {
+	// SubjectCollection 数据主体所在集合，默认 users
+	SubjectCollection string
+	// ReportsCollection 报告集合，默认 erasure_reports
+	ReportsCollection string
+}
+
+// erasureTarget 需要处理的集合及其过滤条件
+type erasureTarget struct {
+	collection string
+	field      string
+	many       bool
+	policy     ErasurePolicy
+}
+
+// Eraser 数据主体删除（GDPR 被遗忘权）：根据模型注册表中的引用关系找到所有引用该主体的集合，
+// 按各集合的策略删除、匿名化或解除引用，最后处理主体文档本身，并写入删除报告
+// 某个集合处理失败时继续处理其它集合，报告状态为 failed；处理是幂等的，失败后可以重新执行
+//
+//	eraser := NewEraser(models, nil)
+//	report, err := eraser.EraseSubject(ctx, userID)
+type Eraser struct {
+	client  *Client
+	models  *ModelRegistry
+	subject string
+	reports *Collection
+
+	mu       sync.RWMutex
+	policies map[string]ErasurePolicy
+}
+
+// NewEraser 创建数据主体删除器
+func NewEraser(models *ModelRegistry, opts *ErasureOptions) *Eraser {
+	o := ErasureOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.SubjectCollection == "" {
+		o.SubjectCollection = "users"
+	}
+	if o.ReportsCollection == "" {
+		o.ReportsCollection = "erasure_reports"
+	}
+	return &Eraser{
+		client:   models.client,
+		models:   models,
+		subject:  o.SubjectCollection,
+		reports:  NewCollection(models.client, o.ReportsCollection),
+		policies: make(map[string]ErasurePolicy),
+	}
+}
+
+// Register 为集合设置删除策略，优先于模型声明的 ModelOptions.Erasure
+func (e *Eraser) Register(collection string, policy ErasurePolicy) error {
+	if err := validateErasurePolicy(collection, policy); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.policies[collection] = policy
+	return nil
+}
+
+// validateErasurePolicy 检查策略的处理方式和匿名化字段
+func validateErasurePolicy(collection string, policy ErasurePolicy) error {
+	switch policy.Action {
+	case "", ErasureRetain, ErasureDelete, ErasureUnlink:
+	case ErasureAnonymize:
+		if len(policy.AnonymizeFields) == 0 {
+			return fmt.Errorf("erasure policy for %s requires AnonymizeFields", collection)
+		}
+	default:
+		return fmt.Errorf("erasure policy for %s has unsupported action %q", collection, policy.Action)
+	}
+	return nil
+}
+
+// policy 返回集合的删除策略
+func (e *Eraser) policy(collection string) ErasurePolicy {
+	e.mu.RLock()
+	policy, ok := e.policies[collection]
+	e.mu.RUnlock()
+	if !ok {
+		for _, model := range e.models.Models() {
+			if model.Collection() == collection && model.Options.Erasure != nil {
+				policy = *model.Options.Erasure
+			}
+		}
+	}
+	if policy.Action == "" {
+		policy.Action = ErasureRetain
+		if collection == e.subject {
+			policy.Action = ErasureDelete
+		}
+	}
+	return policy
+}
+
+// targets 返回需要处理的集合：先处理引用方，最后处理主体文档，中途失败时重新执行仍能找到所有引用
+// 主体集合中引用其它主体的文档（例如 invited_by）属于其它主体，只解除引用
+func (e *Eraser) targets() []erasureTarget {
+	var targets []erasureTarget
+	for _, rel := range e.models.Relations().referencing(e.subject) {
+		policy := e.policy(rel.Collection)
+		if rel.Collection == e.subject {
+			policy = ErasurePolicy{Action: ErasureUnlink}
+		}
+		targets = append(targets, erasureTarget{collection: rel.Collection, field: rel.Field, many: rel.Many, policy: policy})
+	}
+	return append(targets, erasureTarget{collection: e.subject, field: "_id", policy: e.policy(e.subject)})
+}
+
+// EraseSubject 删除或匿名化数据主体的个人数据，返回并保存删除报告；部分集合失败时同时返回报告和合并的错误
+func (e *Eraser) EraseSubject(ctx context.Context, subjectID interface{}) (*ErasureReport, error) {
+	report := &ErasureReport{
+		ID:         primitive.NewObjectID(),
+		SubjectID:  subjectID,
+		Collection: e.subject,
+		Status:     ErasureStatusSuccess,
+		StartedAt:  time.Now(),
+	}
+	var errs []error
+	for _, target := range e.targets() {
+		result, err := e.erase(ctx, target, subjectID)
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.FinishedAt = time.Now()
+	if len(errs) > 0 {
+		report.Status = ErasureStatusFailed
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultOperationTimeout)
+	defer cancel()
+	if _, err := e.reports.collection.InsertOne(saveCtx, report); err != nil {
+		errs = append(errs, fmt.Errorf("failed to record erasure report: %w", err))
+	}
+	return report, errors.Join(errs...)
+}
+
+// erase 处理单个集合中引用主体的文档
+func (e *Eraser) erase(ctx context.Context, target erasureTarget, subjectID interface{}) (ErasureResult, error) {
+	result := ErasureResult{Collection: target.collection, Field: target.field, Action: target.policy.Action}
+	filter := bson.M{target.field: subjectID}
+	coll := e.client.database.Collection(target.collection)
+
+	matched, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return result, fmt.Errorf("failed to count %s documents: %w", target.collection, err)
+	}
+	result.Matched = matched
+	if matched == 0 || target.policy.Action == ErasureRetain {
+		return result, nil
+	}
+
+	if target.policy.Action == ErasureDelete {
+		deleted, err := coll.DeleteMany(ctx, filter)
+		if err != nil {
+			return result, fmt.Errorf("failed to delete %s documents: %w", target.collection, err)
+		}
+		result.Affected = deleted.DeletedCount
+		return result, nil
+	}
+
+	updated, err := coll.UpdateMany(ctx, filter, erasureUpdate(target, subjectID, time.Now()))
+	if err != nil {
+		return result, fmt.Errorf("failed to %s %s documents: %w", target.policy.Action, target.collection, err)
+	}
+	result.Affected = updated.ModifiedCount
+	return result, nil
+}
+
+// erasureUpdate 构造匿名化或解除引用的更新
+func erasureUpdate(target erasureTarget, subjectID interface{}, now time.Time) bson.M {
+	if target.policy.Action == ErasureAnonymize {
+		return anonymizeUpdate(target.policy.AnonymizeFields, now)
+	}
+	if target.many {
+		return bson.M{"$pull": bson.M{target.field: subjectID}}
+	}
+	return bson.M{"$unset": bson.M{target.field: ""}}
+}
+
+// Reports 查询数据主体的删除报告，按开始时间倒序
+func (e *Eraser) Reports(ctx context.Context, subjectID interface{}) ([]ErasureReport, error) {
+	var reports []ErasureReport
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if err := e.reports.Find(ctx, bson.M{"subject_id": subjectID}, &reports, opts); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEraserTargets(t *testing.T) {
+	type member struct {
+		InvitedBy primitive.ObjectID `bson:"invited_by" ref:"members"`
+	}
+	type post struct {
+		EditorIDs []primitive.ObjectID `bson:"editor_ids" ref:"members"`
+	}
+
+	models := NewModelRegistry(newLazyClient(t))
+	require.NoError(t, models.Register(member{}, ModelOptions{Collection: "members"}))
+	require.NoError(t, models.Register(post{}, ModelOptions{Collection: "posts", Erasure: &ErasurePolicy{Action: ErasureUnlink}}))
+	require.NoError(t, RegisterModel[Article](models, ModelOptions{Erasure: &ErasurePolicy{Action: ErasureDelete}}))
+
+	eraser := NewEraser(models, &ErasureOptions{SubjectCollection: "members"})
+	assert.Error(t, eraser.Register("comments", ErasurePolicy{Action: ErasureAnonymize}))
+	assert.Error(t, eraser.Register("comments", ErasurePolicy{Action: "shred"}))
+	require.NoError(t, eraser.Register("posts", ErasurePolicy{
+		Action:          ErasureAnonymize,
+		AnonymizeFields: map[string]interface{}{"editor_ids": nil},
+	}))
+
+	targets := eraser.targets()
+	byCollection := map[string]erasureTarget{}
+	for _, target := range targets {
+		byCollection[target.collection+"."+target.field] = target
+	}
+	require.Len(t, targets, 3)
+	// 主体文档最后处理
+	assert.Equal(t, erasureTarget{collection: "members", field: "_id", policy: ErasurePolicy{Action: ErasureDelete}}, targets[2])
+	// 主体之间的引用只解除引用
+	assert.Equal(t, ErasureUnlink, byCollection["members.invited_by"].policy.Action)
+	// Register 优先于模型声明
+	assert.Equal(t, ErasureAnonymize, byCollection["posts.editor_ids"].policy.Action)
+	assert.True(t, byCollection["posts.editor_ids"].many)
+	// articles 引用的是 users，不受影响
+	_, ok := byCollection["articles.author_id"]
+	assert.False(t, ok)
+
+	assert.Equal(t, ErasureRetain, eraser.policy("comments").Action)
+}
+
+func TestErasureUpdate(t *testing.T) {
+	id := primitive.NewObjectID()
+	now := time.Now()
+
+	assert.Equal(t, bson.M{"$unset": bson.M{"author_id": ""}},
+		erasureUpdate(erasureTarget{field: "author_id", policy: ErasurePolicy{Action: ErasureUnlink}}, id, now))
+	assert.Equal(t, bson.M{"$pull": bson.M{"editor_ids": id}},
+		erasureUpdate(erasureTarget{field: "editor_ids", many: true, policy: ErasurePolicy{Action: ErasureUnlink}}, id, now))
+	assert.Equal(t, bson.M{"$set": bson.M{"anonymized_at": now, "author_name": "deleted"}},
+		erasureUpdate(erasureTarget{field: "author_id", policy: ErasurePolicy{
+			Action:          ErasureAnonymize,
+			AnonymizeFields: map[string]interface{}{"author_name": "deleted"},
+		}}, id, now))
+}
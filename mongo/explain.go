@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExplainVerbosity explain 输出详细程度
+type ExplainVerbosity string
+
+const (
+	// ExplainQueryPlanner 只返回查询计划
+	ExplainQueryPlanner ExplainVerbosity = "queryPlanner"
+	// ExplainExecutionStats 返回查询计划和执行统计
+	ExplainExecutionStats ExplainVerbosity = "executionStats"
+	// ExplainAllPlansExecution 返回所有候选计划的执行统计
+	ExplainAllPlansExecution ExplainVerbosity = "allPlansExecution"
+)
+
+// ExplainResult 查询计划分析结果
+type ExplainResult struct {
+	WinningStage        string   `json:"winning_stage"`
+	IndexesUsed         []string `json:"indexes_used"`
+	IsCollectionScan    bool     `json:"is_collection_scan"`
+	NReturned           int64    `json:"n_returned"`
+	TotalDocsExamined   int64    `json:"total_docs_examined"`
+	TotalKeysExamined   int64    `json:"total_keys_examined"`
+	ExecutionTimeMillis int64    `json:"execution_time_millis"`
+	WinningPlan         bson.M   `json:"winning_plan"`
+	Raw                 bson.M   `json:"-"`
+}
+
+// UsesIndex 检查查询计划是否使用了指定索引
+func (r *ExplainResult) UsesIndex(name string) bool {
+	for _, index := range r.IndexesUsed {
+		if index == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Explain 分析 find 查询的执行计划
+// 例如验证 idx_category_status_created_at 是否被使用：
+//
+//	result, _ := col.Explain(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}), ExplainExecutionStats)
+//	result.UsesIndex("idx_category_status_created_at")
+func (c *Collection) Explain(ctx context.Context, filter bson.M, opts *options.FindOptions, verbosity ExplainVerbosity) (*ExplainResult, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	findCmd := bson.D{
+		{Key: "find", Value: c.collection.Name()},
+		{Key: "filter", Value: filter},
+	}
+	if opts != nil {
+		if opts.Sort != nil {
+			findCmd = append(findCmd, bson.E{Key: "sort", Value: opts.Sort})
+		}
+		if opts.Projection != nil {
+			findCmd = append(findCmd, bson.E{Key: "projection", Value: opts.Projection})
+		}
+		if opts.Hint != nil {
+			findCmd = append(findCmd, bson.E{Key: "hint", Value: opts.Hint})
+		}
+		if opts.Skip != nil {
+			findCmd = append(findCmd, bson.E{Key: "skip", Value: *opts.Skip})
+		}
+		if opts.Limit != nil {
+			findCmd = append(findCmd, bson.E{Key: "limit", Value: *opts.Limit})
+		}
+		if opts.Collation != nil {
+			findCmd = append(findCmd, bson.E{Key: "collation", Value: opts.Collation.ToDocument()})
+		}
+	}
+	return c.runExplain(ctx, findCmd, verbosity)
+}
+
+// AggregateExplain 分析聚合管道的执行计划
+func (c *Collection) AggregateExplain(ctx context.Context, pipeline []bson.M, verbosity ExplainVerbosity) (*ExplainResult, error) {
+	aggregateCmd := bson.D{
+		{Key: "aggregate", Value: c.collection.Name()},
+		{Key: "pipeline", Value: pipeline},
+		{Key: "cursor", Value: bson.M{}},
+	}
+	return c.runExplain(ctx, aggregateCmd, verbosity)
+}
+
+// runExplain 执行 explain 命令并解析结果
+func (c *Collection) runExplain(ctx context.Context, cmd bson.D, verbosity ExplainVerbosity) (*ExplainResult, error) {
+	ctx = c.sessionContext(ctx)
+	if verbosity == "" {
+		verbosity = ExplainQueryPlanner
+	}
+	explainCmd := bson.D{
+		{Key: "explain", Value: cmd},
+		{Key: "verbosity", Value: string(verbosity)},
+	}
+
+	var raw bson.M
+	if err := c.collection.Database().RunCommand(ctx, explainCmd).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to explain: %w", err)
+	}
+	return parseExplain(raw), nil
+}
+
+// parseExplain 从 explain 输出中提取关键信息
+// 兼容 find 的输出结构以及聚合管道中 stages[0].$cursor 的输出结构
+func parseExplain(raw bson.M) *ExplainResult {
+	result := &ExplainResult{Raw: raw}
+
+	planner, stats := raw["queryPlanner"], raw["executionStats"]
+	if planner == nil {
+		if stages, ok := toSlice(raw["stages"]); ok && len(stages) > 0 {
+			if first, ok := toM(stages[0]); ok {
+				if cursor, ok := toM(first["$cursor"]); ok {
+					planner, stats = cursor["queryPlanner"], cursor["executionStats"]
+				}
+			}
+		}
+	}
+
+	if plannerM, ok := toM(planner); ok {
+		if winning, ok := toM(plannerM["winningPlan"]); ok {
+			result.WinningPlan = winning
+			// 6.0 之后使用 SBE 引擎时计划位于 winningPlan.queryPlan
+			if queryPlan, ok := toM(winning["queryPlan"]); ok {
+				winning = queryPlan
+			}
+			if stage, ok := winning["stage"].(string); ok {
+				result.WinningStage = stage
+			}
+			seen := map[string]bool{}
+			walkPlan(winning, func(node bson.M) {
+				if stage, _ := node["stage"].(string); stage == "COLLSCAN" {
+					result.IsCollectionScan = true
+				}
+				if name, ok := node["indexName"].(string); ok && !seen[name] {
+					seen[name] = true
+					result.IndexesUsed = append(result.IndexesUsed, name)
+				}
+			})
+		}
+	}
+
+	if statsM, ok := toM(stats); ok {
+		result.NReturned = toInt64(statsM["nReturned"])
+		result.TotalDocsExamined = toInt64(statsM["totalDocsExamined"])
+		result.TotalKeysExamined = toInt64(statsM["totalKeysExamined"])
+		result.ExecutionTimeMillis = toInt64(statsM["executionTimeMillis"])
+	}
+	return result
+}
+
+// walkPlan 深度遍历计划树中的所有节点（inputStage、inputStages、shards 等）
+func walkPlan(v interface{}, fn func(node bson.M)) {
+	if m, ok := toM(v); ok {
+		fn(m)
+		for _, child := range m {
+			walkPlan(child, fn)
+		}
+		return
+	}
+	if items, ok := toSlice(v); ok {
+		for _, item := range items {
+			walkPlan(item, fn)
+		}
+	}
+}
+
+// toM 将解码得到的文档统一转换为 bson.M
+func toM(v interface{}) (bson.M, bool) {
+	switch val := v.(type) {
+	case bson.M:
+		return val, true
+	case map[string]interface{}:
+		return bson.M(val), true
+	case bson.D:
+		m := make(bson.M, len(val))
+		for _, e := range val {
+			m[e.Key] = e.Value
+		}
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// toSlice 将解码得到的数组统一转换为 []interface{}
+func toSlice(v interface{}) ([]interface{}, bool) {
+	switch val := v.(type) {
+	case bson.A:
+		return val, true
+	case []interface{}:
+		return val, true
+	default:
+		return nil, false
+	}
+}
+
+// toInt64 将 bson 数值类型转换为 int64
+func toInt64(v interface{}) int64 {
+	switch val := v.(type) {
+	case int32:
+		return int64(val)
+	case int64:
+		return val
+	case int:
+		return int64(val)
+	case float64:
+		return int64(val)
+	default:
+		return 0
+	}
+}
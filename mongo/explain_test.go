@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseExplainFind(t *testing.T) {
+	raw := bson.M{
+		"queryPlanner": bson.M{
+			"winningPlan": bson.M{
+				"stage": "FETCH",
+				"inputStage": bson.M{
+					"stage":     "IXSCAN",
+					"indexName": "idx_category_status_created_at",
+				},
+			},
+		},
+		"executionStats": bson.M{
+			"nReturned":           int32(3),
+			"totalDocsExamined":   int32(3),
+			"totalKeysExamined":   int32(3),
+			"executionTimeMillis": int32(1),
+		},
+	}
+
+	result := parseExplain(raw)
+	assert.Equal(t, "FETCH", result.WinningStage)
+	assert.True(t, result.UsesIndex("idx_category_status_created_at"))
+	assert.False(t, result.IsCollectionScan)
+	assert.Equal(t, int64(3), result.NReturned)
+	assert.Equal(t, int64(3), result.TotalDocsExamined)
+}
+
+func TestParseExplainAggregateCursorStage(t *testing.T) {
+	raw := bson.M{
+		"stages": bson.A{
+			bson.M{"$cursor": bson.M{
+				"queryPlanner": bson.M{
+					"winningPlan": bson.M{
+						"queryPlan": bson.M{"stage": "COLLSCAN"},
+					},
+				},
+			}},
+			bson.M{"$group": bson.M{}},
+		},
+	}
+
+	result := parseExplain(raw)
+	assert.Equal(t, "COLLSCAN", result.WinningStage)
+	assert.True(t, result.IsCollectionScan)
+	assert.Empty(t, result.IndexesUsed)
+}
@@ -0,0 +1,284 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ExportFormat 导出格式
+type ExportFormat string
+
+const (
+	// ExportNDJSON 每行一个 Extended JSON 文档
+	ExportNDJSON ExportFormat = "ndjson"
+	// ExportCSV 逗号分隔，第一行为字段名
+	ExportCSV ExportFormat = "csv"
+)
+
+// ExportOptions 导出配置
+type ExportOptions struct {
+	// Format 导出格式，默认 ExportNDJSON
+	Format ExportFormat
+	// Fields 导出的字段，支持点号路径；CSV 必填，NDJSON 为空时导出完整文档
+	Fields []string
+	// Canonical NDJSON 使用 Canonical Extended JSON，默认 Relaxed 模式（数字和日期更易读）
+	Canonical bool
+	// BatchSize 每批读取的文档数，每批写入后保存一次检查点，默认 1000
+	BatchSize int
+	// Name 检查点名称，和 Checkpoints 同时设置时支持中断后继续导出
+	Name string
+	// Checkpoints 检查点存储
+	Checkpoints CheckpointStore
+}
+
+// ExportResult 导出结果
+type ExportResult struct {
+	// Documents 累计导出的文档数，继续导出时包含之前导出的部分
+	Documents int64
+	// Bytes 累计写入的字节数
+	Bytes int64
+	// Resumed 是否从检查点继续
+	Resumed bool
+}
+
+// exportCheckpoint 导出检查点
+type exportCheckpoint struct {
+	LastID    bson.RawValue `bson:"_id"`
+	Offset    int64         `bson:"offset"`
+	Documents int64         `bson:"documents"`
+	UpdatedAt time.Time     `bson:"updated_at"`
+}
+
+// Exporter 将集合按 _id 顺序流式导出为 NDJSON 或 CSV，不需要安装 mongoexport
+//
+//	exporter := NewExporter(users, ExportOptions{
+//		Format:      ExportCSV,
+//		Fields:      []string{"_id", "username", "profile.first_name", "created_at"},
+//		Name:        "users-2024",
+//		Checkpoints: NewMongoCheckpointStore(client, ""),
+//	})
+//	result, err := exporter.ExportFile(ctx, bson.M{"status": "active"}, "users.csv")
+type Exporter struct {
+	collection *Collection
+	opts       ExportOptions
+}
+
+// NewExporter 创建导出器
+func NewExporter(collection *Collection, opts ExportOptions) *Exporter {
+	if opts.Format == "" {
+		opts.Format = ExportNDJSON
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	return &Exporter{collection: collection, opts: opts}
+}
+
+// ExportFile 导出到文件；存在检查点时将文件截断到检查点记录的位置后继续追加，
+// 因此中断后再次调用得到的文件与一次性导出的结果一致；没有检查点时覆盖已有文件
+func (e *Exporter) ExportFile(ctx context.Context, filter bson.M, path string) (*ExportResult, error) {
+	checkpoint, err := e.loadCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	defer file.Close()
+
+	var offset int64
+	if checkpoint != nil {
+		offset = checkpoint.Offset
+	}
+	if err := file.Truncate(offset); err != nil {
+		return nil, fmt.Errorf("failed to truncate export file: %w", err)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek export file: %w", err)
+	}
+
+	result, err := e.export(ctx, filter, file, checkpoint, file.Sync)
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to close export file: %w", closeErr)
+	}
+	return result, err
+}
+
+// Export 导出到 w；存在检查点时从检查点之后的文档继续写入 w，
+// 调用方需要保证 w 的内容与检查点一致（例如以追加方式打开同一个文件），否则中断前最后一批可能重复
+func (e *Exporter) Export(ctx context.Context, filter bson.M, w io.Writer) (*ExportResult, error) {
+	checkpoint, err := e.loadCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return e.export(ctx, filter, w, checkpoint, nil)
+}
+
+// export 分批读取文档并写入 w，每批写入并 sync 后保存检查点
+func (e *Exporter) export(ctx context.Context, filter bson.M, w io.Writer, checkpoint *exportCheckpoint, sync func() error) (*ExportResult, error) {
+	if e.opts.Format != ExportNDJSON && e.opts.Format != ExportCSV {
+		return nil, fmt.Errorf("unsupported export format %q", e.opts.Format)
+	}
+	if e.opts.Format == ExportCSV && len(e.opts.Fields) == 0 {
+		return nil, fmt.Errorf("csv export requires fields")
+	}
+
+	result := &ExportResult{}
+	batchOpts := &BatchOptions{}
+	if checkpoint != nil {
+		result.Resumed = true
+		result.Documents = checkpoint.Documents
+		result.Bytes = checkpoint.Offset
+		batchOpts.StartAfter = checkpoint.LastID
+	}
+	if len(e.opts.Fields) > 0 {
+		projection := bson.M{}
+		for _, field := range e.opts.Fields {
+			projection[field] = 1
+		}
+		batchOpts.Projection = projection
+	}
+
+	if e.opts.Format == ExportCSV && result.Bytes == 0 {
+		n, err := e.writeCSV(w, e.opts.Fields)
+		result.Bytes += int64(n)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	_, err := e.collection.ForEachBatch(ctx, filter, e.opts.BatchSize, func(ctx context.Context, batch []bson.Raw) error {
+		var buf bytes.Buffer
+		for _, doc := range batch {
+			if err := e.encode(&buf, doc); err != nil {
+				return err
+			}
+		}
+		n, err := w.Write(buf.Bytes())
+		result.Bytes += int64(n)
+		if err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		if sync != nil {
+			if err := sync(); err != nil {
+				return fmt.Errorf("failed to sync export: %w", err)
+			}
+		}
+		result.Documents += int64(len(batch))
+		return e.saveCheckpoint(ctx, &exportCheckpoint{
+			LastID:    batch[len(batch)-1].Lookup("_id"),
+			Offset:    result.Bytes,
+			Documents: result.Documents,
+			UpdatedAt: time.Now(),
+		})
+	}, batchOpts)
+	return result, err
+}
+
+// encode 按导出格式编码单个文档
+func (e *Exporter) encode(buf *bytes.Buffer, doc bson.Raw) error {
+	if e.opts.Format == ExportNDJSON {
+		data, err := bson.MarshalExtJSON(doc, e.opts.Canonical, false)
+		if err != nil {
+			return fmt.Errorf("failed to encode document %v: %w", doc.Lookup("_id"), err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+		return nil
+	}
+
+	record := make([]string, len(e.opts.Fields))
+	for i, field := range e.opts.Fields {
+		value, err := doc.LookupErr(strings.Split(field, ".")...)
+		if err != nil {
+			continue
+		}
+		if record[i], err = csvValue(value); err != nil {
+			return fmt.Errorf("failed to encode field %s of document %v: %w", field, doc.Lookup("_id"), err)
+		}
+	}
+	_, err := e.writeCSV(buf, record)
+	return err
+}
+
+// writeCSV 写入一行 CSV，返回写入的字节数
+func (e *Exporter) writeCSV(w io.Writer, record []string) (int, error) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(record); err != nil {
+		return 0, fmt.Errorf("failed to encode csv: %w", err)
+	}
+	cw.Flush()
+	return w.Write(buf.Bytes())
+}
+
+// csvValue 将 BSON 值转换为 CSV 单元格：字符串和数字原样输出，ObjectID 输出十六进制，
+// 日期输出 RFC3339，null 输出空字符串，数组和子文档输出 Relaxed Extended JSON
+func csvValue(value bson.RawValue) (string, error) {
+	switch value.Type {
+	case bson.TypeString:
+		return value.StringValue(), nil
+	case bson.TypeObjectID:
+		return value.ObjectID().Hex(), nil
+	case bson.TypeDateTime:
+		return value.Time().UTC().Format(time.RFC3339Nano), nil
+	case bson.TypeInt32:
+		return strconv.FormatInt(int64(value.Int32()), 10), nil
+	case bson.TypeInt64:
+		return strconv.FormatInt(value.Int64(), 10), nil
+	case bson.TypeDouble:
+		return strconv.FormatFloat(value.Double(), 'f', -1, 64), nil
+	case bson.TypeBoolean:
+		return strconv.FormatBool(value.Boolean()), nil
+	case bson.TypeDecimal128:
+		return value.Decimal128().String(), nil
+	case bson.TypeNull, bson.TypeUndefined:
+		return "", nil
+	}
+	data, err := bson.MarshalExtJSON(bson.M{"v": value}, false, false)
+	if err != nil {
+		return "", err
+	}
+	// 去掉包装的 {"v": ...}
+	data = bytes.TrimPrefix(data, []byte(`{"v":`))
+	return string(bytes.TrimSuffix(data, []byte("}"))), nil
+}
+
+// loadCheckpoint 读取导出检查点，未配置或没有检查点时返回 nil
+func (e *Exporter) loadCheckpoint(ctx context.Context) (*exportCheckpoint, error) {
+	if e.opts.Name == "" || e.opts.Checkpoints == nil {
+		return nil, nil
+	}
+	token, err := e.opts.Checkpoints.Load(ctx, e.opts.Name)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	var checkpoint exportCheckpoint
+	if err := bson.Unmarshal(token, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid export checkpoint %s: %w", e.opts.Name, err)
+	}
+	return &checkpoint, nil
+}
+
+// saveCheckpoint 保存导出检查点
+func (e *Exporter) saveCheckpoint(ctx context.Context, checkpoint *exportCheckpoint) error {
+	if e.opts.Name == "" || e.opts.Checkpoints == nil {
+		return nil
+	}
+	token, err := bson.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to encode export checkpoint: %w", err)
+	}
+	return e.opts.Checkpoints.Save(ctx, e.opts.Name, token)
+}
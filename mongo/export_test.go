@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func exportTestDoc(t *testing.T) bson.Raw {
+	id, err := primitive.ObjectIDFromHex("65a1b2c3d4e5f60718293a4b")
+	require.NoError(t, err)
+	raw, err := bson.Marshal(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "name", Value: "Alice, \"A\""},
+		{Key: "age", Value: int32(30)},
+		{Key: "score", Value: 9.5},
+		{Key: "created_at", Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{Key: "profile", Value: bson.D{{Key: "city", Value: "Beijing"}}},
+		{Key: "tags", Value: bson.A{"a", "b"}},
+		{Key: "deleted", Value: nil},
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestExporterEncodeCSV(t *testing.T) {
+	e := NewExporter(nil, ExportOptions{
+		Format: ExportCSV,
+		Fields: []string{"_id", "name", "age", "score", "created_at", "profile.city", "tags", "deleted", "missing"},
+	})
+	var buf bytes.Buffer
+	require.NoError(t, e.encode(&buf, exportTestDoc(t)))
+	assert.Equal(t,
+		`65a1b2c3d4e5f60718293a4b,"Alice, ""A""",30,9.5,2024-01-02T03:04:05Z,Beijing,"[""a"",""b""]",,`+"\n",
+		buf.String())
+}
+
+func TestExporterEncodeNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewExporter(nil, ExportOptions{}).encode(&buf, exportTestDoc(t)))
+	assert.Contains(t, buf.String(), `"age":30`)
+	assert.Contains(t, buf.String(), `"created_at":{"$date":"2024-01-02T03:04:05Z"}`)
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("}\n")))
+
+	buf.Reset()
+	require.NoError(t, NewExporter(nil, ExportOptions{Canonical: true}).encode(&buf, exportTestDoc(t)))
+	assert.Contains(t, buf.String(), `"age":{"$numberInt":"30"}`)
+}
+
+func TestExporterCheckpoint(t *testing.T) {
+	store := memoryCheckpointStore{}
+	e := NewExporter(nil, ExportOptions{Name: "export", Checkpoints: store})
+	ctx := t.Context()
+
+	checkpoint, err := e.loadCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	doc := exportTestDoc(t)
+	require.NoError(t, e.saveCheckpoint(ctx, &exportCheckpoint{LastID: doc.Lookup("_id"), Offset: 42, Documents: 3}))
+	checkpoint, err = e.loadCheckpoint(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), checkpoint.Offset)
+	assert.Equal(t, int64(3), checkpoint.Documents)
+	assert.Equal(t, "65a1b2c3d4e5f60718293a4b", checkpoint.LastID.ObjectID().Hex())
+}
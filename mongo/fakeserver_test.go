@@ -0,0 +1,340 @@
+package mongo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// 线协议操作码
+const (
+	opReply = 1
+	opQuery = 2004
+	opMsg   = 2013
+)
+
+// fakeServer 进程内的最小 MongoDB 服务端：应答握手、心跳和 buildInfo，记录其它命令并返回 handler 的响应，
+// 用于在没有 MongoDB 的环境中检查发送给服务端的过滤条件、更新和事务
+type fakeServer struct {
+	addr string
+	// replicaSet 为 true 时以副本集主节点身份应答，支持会话和事务
+	replicaSet bool
+	// failPing 为 true 时 ping 返回错误
+	failPing atomic.Bool
+
+	mu       sync.Mutex
+	commands []bson.Raw
+	handler  func(name string, cmd bson.Raw) bson.D
+}
+
+// newFakeServer 启动服务端，测试结束时关闭
+func newFakeServer(t *testing.T, replicaSet bool) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{addr: listener.Addr().String(), replicaSet: replicaSet}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// client 创建连接到服务端的客户端，数据库为 test
+func (s *fakeServer) client(t *testing.T) *Client {
+	direct := true
+	client, err := NewClient(&Config{URI: "mongodb://" + s.addr, Database: "test", DirectConnection: &direct})
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// handle 设置命令的响应，fn 返回 nil 时使用默认响应
+func (s *fakeServer) handle(fn func(name string, cmd bson.Raw) bson.D) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = fn
+}
+
+// Commands 返回收到的命令，names 不为空时只返回指定名称的命令
+func (s *fakeServer) Commands(names ...string) []bson.Raw {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var commands []bson.Raw
+	for _, cmd := range s.commands {
+		if len(names) == 0 || slices.Contains(names, rawCommandName(cmd)) {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+		length := int32(binary.LittleEndian.Uint32(header[0:]))
+		requestID := int32(binary.LittleEndian.Uint32(header[4:]))
+		opCode := int32(binary.LittleEndian.Uint32(header[12:]))
+		body := make([]byte, length-16)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		var reply []byte
+		switch opCode {
+		case opQuery:
+			cmd, err := parseOpQuery(body)
+			if err != nil {
+				return
+			}
+			reply = opReplyMessage(requestID, s.respond(cmd))
+		case opMsg:
+			cmd, moreToCome, err := parseOpMsg(body)
+			if err != nil {
+				return
+			}
+			response := s.respond(cmd)
+			if moreToCome {
+				continue
+			}
+			reply = opMsgMessage(requestID, response)
+		default:
+			return
+		}
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// respond 应答握手类命令，其它命令记录后交给 handler
+func (s *fakeServer) respond(cmd bson.Raw) bson.D {
+	name := rawCommandName(cmd)
+	switch strings.ToLower(name) {
+	case "hello", "ismaster":
+		return s.hello()
+	case "buildinfo":
+		return bson.D{{Key: "version", Value: "7.0.0"}, {Key: "versionArray", Value: bson.A{7, 0, 0, 0}}, {Key: "ok", Value: 1}}
+	case "ping":
+		if s.failPing.Load() {
+			return bson.D{{Key: "ok", Value: 0}, {Key: "code", Value: 8000}, {Key: "errmsg", Value: "ping failed"}}
+		}
+		return bson.D{{Key: "ok", Value: 1}}
+	case "endsessions":
+		return bson.D{{Key: "ok", Value: 1}}
+	}
+
+	s.mu.Lock()
+	s.commands = append(s.commands, cmd)
+	handler := s.handler
+	s.mu.Unlock()
+	if handler != nil {
+		if response := handler(name, cmd); response != nil {
+			return response
+		}
+	}
+	return defaultResponse(name, cmd)
+}
+
+func (s *fakeServer) hello() bson.D {
+	response := bson.D{
+		{Key: "helloOk", Value: true},
+		{Key: "ismaster", Value: true},
+		{Key: "isWritablePrimary", Value: true},
+		{Key: "maxBsonObjectSize", Value: 16 * 1024 * 1024},
+		{Key: "maxMessageSizeBytes", Value: 48000000},
+		{Key: "maxWriteBatchSize", Value: 100000},
+		{Key: "logicalSessionTimeoutMinutes", Value: 30},
+		{Key: "minWireVersion", Value: 0},
+		{Key: "maxWireVersion", Value: 21},
+	}
+	if s.replicaSet {
+		response = append(response,
+			bson.E{Key: "setName", Value: "rs0"},
+			bson.E{Key: "setVersion", Value: 1},
+			bson.E{Key: "hosts", Value: bson.A{s.addr}},
+			bson.E{Key: "primary", Value: s.addr},
+			bson.E{Key: "me", Value: s.addr},
+		)
+	}
+	return append(response, bson.E{Key: "ok", Value: 1})
+}
+
+// defaultResponse 写命令按请求数量返回成功，查询返回空结果
+func defaultResponse(name string, cmd bson.Raw) bson.D {
+	count := func(key string) int {
+		values, _ := cmd.Lookup(key).Array().Values()
+		return len(values)
+	}
+	switch name {
+	case "insert":
+		return bson.D{{Key: "n", Value: count("documents")}, {Key: "ok", Value: 1}}
+	case "update":
+		return bson.D{{Key: "n", Value: count("updates")}, {Key: "nModified", Value: count("updates")}, {Key: "ok", Value: 1}}
+	case "delete":
+		return bson.D{{Key: "n", Value: count("deletes")}, {Key: "ok", Value: 1}}
+	case "find", "aggregate":
+		return fakeCursor(cmd)
+	case "findAndModify":
+		return bson.D{{Key: "lastErrorObject", Value: bson.D{{Key: "n", Value: 0}}}, {Key: "value", Value: nil}, {Key: "ok", Value: 1}}
+	case "count":
+		return bson.D{{Key: "n", Value: 0}, {Key: "ok", Value: 1}}
+	}
+	return bson.D{{Key: "ok", Value: 1}}
+}
+
+// fakeCursor 返回只有一批结果的游标响应
+func fakeCursor(cmd bson.Raw, docs ...interface{}) bson.D {
+	ns, _ := cmd.Lookup("$db").StringValueOK()
+	// 数据库级命令（例如 listCollections）的游标命名空间为 <db>.$cmd.<命令名>
+	if coll, ok := cmd.Index(0).Value().StringValueOK(); ok {
+		ns += "." + coll
+	} else {
+		ns += ".$cmd." + cmd.Index(0).Key()
+	}
+	batch := bson.A{}
+	for _, doc := range docs {
+		batch = append(batch, doc)
+	}
+	return bson.D{
+		{Key: "cursor", Value: bson.D{{Key: "id", Value: int64(0)}, {Key: "ns", Value: ns}, {Key: "firstBatch", Value: batch}}},
+		{Key: "ok", Value: 1},
+	}
+}
+
+// fakeWriteError 返回带写错误的写命令响应
+func fakeWriteError(code int, msg string) bson.D {
+	return bson.D{
+		{Key: "n", Value: 0},
+		{Key: "writeErrors", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "code", Value: code}, {Key: "errmsg", Value: msg}}}},
+		{Key: "ok", Value: 1},
+	}
+}
+
+func rawCommandName(cmd bson.Raw) string {
+	elem, err := cmd.IndexErr(0)
+	if err != nil {
+		return ""
+	}
+	return elem.Key()
+}
+
+// parseOpQuery 解析旧式握手使用的 OP_QUERY，只取查询文档
+func parseOpQuery(body []byte) (bson.Raw, error) {
+	rest := body[4:]
+	end := bytes.IndexByte(rest, 0)
+	if end < 0 || len(rest) < end+9 {
+		return nil, errors.New("malformed OP_QUERY")
+	}
+	rest = rest[end+9:]
+	return readDocument(rest)
+}
+
+// parseOpMsg 解析 OP_MSG，将文档序列（例如 insert 的 documents）合并到命令文档中
+func parseOpMsg(body []byte) (bson.Raw, bool, error) {
+	flags := binary.LittleEndian.Uint32(body)
+	moreToCome := flags&2 != 0
+	rest := body[4:]
+	if flags&1 != 0 {
+		rest = rest[:len(rest)-4]
+	}
+
+	var command bson.D
+	var sequences []bson.E
+	for len(rest) > 0 {
+		kind := rest[0]
+		rest = rest[1:]
+		switch kind {
+		case 0:
+			doc, err := readDocument(rest)
+			if err != nil {
+				return nil, false, err
+			}
+			if err := bson.Unmarshal(doc, &command); err != nil {
+				return nil, false, err
+			}
+			rest = rest[len(doc):]
+		case 1:
+			size := int(binary.LittleEndian.Uint32(rest))
+			section := rest[4:size]
+			rest = rest[size:]
+			end := bytes.IndexByte(section, 0)
+			identifier := string(section[:end])
+			section = section[end+1:]
+			docs := bson.A{}
+			for len(section) > 0 {
+				doc, err := readDocument(section)
+				if err != nil {
+					return nil, false, err
+				}
+				docs = append(docs, doc)
+				section = section[len(doc):]
+			}
+			sequences = append(sequences, bson.E{Key: identifier, Value: docs})
+		default:
+			return nil, false, errors.New("unsupported OP_MSG section")
+		}
+	}
+	raw, err := bson.Marshal(append(command, sequences...))
+	return raw, moreToCome, err
+}
+
+func readDocument(b []byte) (bson.Raw, error) {
+	if len(b) < 5 {
+		return nil, errors.New("truncated document")
+	}
+	size := int(binary.LittleEndian.Uint32(b))
+	if size > len(b) {
+		return nil, errors.New("truncated document")
+	}
+	return bson.Raw(b[:size]), nil
+}
+
+func opReplyMessage(responseTo int32, doc bson.D) []byte {
+	raw, _ := bson.Marshal(doc)
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, int32(0)) // responseFlags
+	binary.Write(&body, binary.LittleEndian, int64(0)) // cursorID
+	binary.Write(&body, binary.LittleEndian, int32(0)) // startingFrom
+	binary.Write(&body, binary.LittleEndian, int32(1)) // numberReturned
+	body.Write(raw)
+	return wireMessage(responseTo, opReply, body.Bytes())
+}
+
+func opMsgMessage(responseTo int32, doc bson.D) []byte {
+	raw, _ := bson.Marshal(doc)
+	body := make([]byte, 5, 5+len(raw))
+	return wireMessage(responseTo, opMsg, append(body, raw...))
+}
+
+var fakeRequestID atomic.Int32
+
+func wireMessage(responseTo, opCode int32, body []byte) []byte {
+	msg := make([]byte, 16, 16+len(body))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(16+len(body)))
+	binary.LittleEndian.PutUint32(msg[4:], uint32(fakeRequestID.Add(1)))
+	binary.LittleEndian.PutUint32(msg[8:], uint32(responseTo))
+	binary.LittleEndian.PutUint32(msg[12:], uint32(opCode))
+	return append(msg, body...)
+}
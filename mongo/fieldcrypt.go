@@ -0,0 +1,457 @@
+package mongo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrKeyNotFound 密钥提供者中没有指定版本的密钥
+	ErrKeyNotFound = errors.New("encryption key not found")
+	// ErrDecrypt 密文格式错误或无法使用对应版本的密钥解密
+	ErrDecrypt = errors.New("failed to decrypt field")
+)
+
+// encryptedPrefix 密文前缀，完整格式为 enc:<密钥版本>:<base64(nonce+密文)>
+const encryptedPrefix = "enc:"
+
+// KeyProvider 应用层字段加密的密钥来源，例如配置文件、环境变量或 KMS
+type KeyProvider interface {
+	// CurrentKey 返回加密新数据使用的密钥及其版本，版本不能包含冒号
+	CurrentKey() (version string, key []byte, err error)
+	// Key 返回指定版本的密钥，用于解密旧数据
+	Key(version string) ([]byte, error)
+}
+
+// StaticKeyProvider 固定密钥集合，Keys 为版本到 AES 密钥（16、24 或 32 字节）的映射，Current 为当前版本
+type StaticKeyProvider struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+// CurrentKey 返回当前版本的密钥
+func (p *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := p.Key(p.Current)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.Current, key, nil
+}
+
+// Key 返回指定版本的密钥
+func (p *StaticKeyProvider) Key(version string) ([]byte, error) {
+	key, ok := p.Keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: version %q", ErrKeyNotFound, version)
+	}
+	return key, nil
+}
+
+// FieldEncryptor 应用层字段加密，用于无法使用 CSFLE 的部署：结构体中标记 encrypt:"aes" 的字符串字段
+// 在写入前使用 AES-GCM 加密，读取后解密，密文中带有密钥版本，更换密钥后旧数据仍可解密，并可通过 Rotate 重新加密
+//
+//	type User struct {
+//		Phone string `bson:"phone" encrypt:"aes"`
+//	}
+//
+//	enc := NewFieldEncryptor(&StaticKeyProvider{Current: "2024", Keys: keys})
+//	users := NewCollection(client, "users").WithEncryption(enc)
+//
+// 加密使用随机 nonce，相同明文每次得到不同的密文，因此加密字段不能用于查询条件、唯一索引和 UpsertMany 的 keyFields；
+// 数组元素中的字段不处理，通过 UpdateOne 等 bson.M 更新写入加密字段时需要先调用 EncryptString
+type FieldEncryptor struct {
+	keys KeyProvider
+}
+
+// NewFieldEncryptor 创建字段加密器
+func NewFieldEncryptor(keys KeyProvider) *FieldEncryptor {
+	return &FieldEncryptor{keys: keys}
+}
+
+// EncryptString 使用当前版本的密钥加密字符串
+func (e *FieldEncryptor) EncryptString(plaintext string) (string, error) {
+	version, key, err := e.keys.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(version, ":") {
+		return "", fmt.Errorf("invalid encryption key version %q", version)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + version + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptString 解密字符串，不是密文的值（例如启用加密前写入的明文）原样返回
+func (e *FieldEncryptor) DecryptString(value string) (string, error) {
+	version, payload, ok := parseEncrypted(value)
+	if !ok {
+		return value, nil
+	}
+	key, err := e.keys.Key(version)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("%w: malformed ciphertext", ErrDecrypt)
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	return string(plaintext), nil
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// parseEncrypted 解析密文的密钥版本和载荷
+func parseEncrypted(value string) (version, payload string, ok bool) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return "", "", false
+	}
+	version, payload, ok = strings.Cut(value[len(encryptedPrefix):], ":")
+	return version, payload, ok
+}
+
+// EncryptedFields 返回模型中标记了 encrypt 标签的字段路径，嵌套结构体使用点号路径
+func EncryptedFields(model interface{}) ([]string, error) {
+	t := modelType(reflect.TypeOf(model))
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encrypted model must be a struct, got %T", model)
+	}
+	var paths []string
+	err := collectEncryptedFields(t, "", &paths)
+	return paths, err
+}
+
+// collectEncryptedFields 递归收集加密字段路径
+func collectEncryptedFields(t reflect.Type, prefix string, paths *[]string) error {
+	var err error
+	walkStructFields(t, func(field reflect.StructField, name string) {
+		if err != nil {
+			return
+		}
+		path := joinPath(prefix, name)
+		if tag := field.Tag.Get("encrypt"); tag != "" {
+			if err = checkEncryptTag(field); err == nil {
+				*paths = append(*paths, path)
+			}
+			return
+		}
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isBsonLeaf(ft) {
+			err = collectEncryptedFields(ft, path, paths)
+		}
+	})
+	return err
+}
+
+// checkEncryptTag 检查 encrypt 标签的算法和字段类型
+func checkEncryptTag(field reflect.StructField) error {
+	if tag := field.Tag.Get("encrypt"); tag != "aes" {
+		return fmt.Errorf("field %s: unsupported encrypt algorithm %q", field.Name, tag)
+	}
+	ft := field.Type
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	if ft.Kind() != reflect.String {
+		return fmt.Errorf("field %s: encrypt tag requires a string field", field.Name)
+	}
+	return nil
+}
+
+// EncryptStruct 就地加密结构体中的加密字段，返回恢复明文的函数；doc 不是结构体时为空操作
+func (e *FieldEncryptor) EncryptStruct(doc interface{}) (func(), error) {
+	var restores []func()
+	restore := func() {
+		for _, fn := range restores {
+			fn()
+		}
+	}
+	// 字段总是按明文加密，不根据值的形状判断是否已加密，否则形如 "enc:v1:..." 的用户输入会以明文写入
+	err := e.walkEncrypted(doc, func(value reflect.Value) error {
+		plaintext := value.String()
+		ciphertext, err := e.EncryptString(plaintext)
+		if err != nil {
+			return err
+		}
+		value.SetString(ciphertext)
+		restores = append(restores, func() { value.SetString(plaintext) })
+		return nil
+	})
+	if err != nil {
+		restore()
+		return func() {}, err
+	}
+	return restore, nil
+}
+
+// DecryptStruct 就地解密结构体、结构体指针切片或结构体切片中的加密字段
+func (e *FieldEncryptor) DecryptStruct(doc interface{}) error {
+	v := reflect.ValueOf(doc)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() != reflect.Struct {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if item.Kind() != reflect.Ptr {
+				item = item.Addr()
+			}
+			if err := e.DecryptStruct(item.Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return e.walkEncrypted(v.Interface(), func(value reflect.Value) error {
+		plaintext, err := e.DecryptString(value.String())
+		if err != nil {
+			return err
+		}
+		value.SetString(plaintext)
+		return nil
+	})
+}
+
+// walkEncrypted 遍历结构体指针中非 nil 的加密字段，bson.M 等非结构体文档直接跳过
+func (e *FieldEncryptor) walkEncrypted(doc interface{}, fn func(value reflect.Value) error) error {
+	v := reflect.ValueOf(doc)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Ptr {
+		if v.Kind() == reflect.Struct {
+			if paths, err := EncryptedFields(doc); err != nil || len(paths) > 0 {
+				return fmt.Errorf("document with encrypted fields must be passed by pointer, got %T", doc)
+			}
+		}
+		return nil
+	}
+	if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return walkEncryptedValues(v.Elem(), fn)
+}
+
+// walkEncryptedValues 递归处理嵌套结构体中的加密字段
+func walkEncryptedValues(v reflect.Value, fn func(value reflect.Value) error) error {
+	var err error
+	walkStructValues(v, "", func(field reflect.StructField, value reflect.Value, path string) {
+		if err != nil {
+			return
+		}
+		if tag := field.Tag.Get("encrypt"); tag != "" {
+			if err = checkEncryptTag(field); err != nil {
+				return
+			}
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					return
+				}
+				value = value.Elem()
+			}
+			err = fn(value)
+			return
+		}
+		if value.Kind() == reflect.Ptr && !value.IsNil() {
+			value = value.Elem()
+		}
+		if value.Kind() == reflect.Struct && !isBsonLeaf(value.Type()) {
+			err = walkEncryptedValues(value, fn)
+		}
+	})
+	return err
+}
+
+// encryptUpdate 加密 $set 中属于加密字段的值，$set 的键可以是加密字段本身或其所在的子文档
+func (e *FieldEncryptor) encryptUpdate(set bson.M, paths []string) error {
+	for key, value := range set {
+		for _, path := range paths {
+			if key == path {
+				s, ok := value.(string)
+				if !ok {
+					continue
+				}
+				ciphertext, err := e.EncryptString(s)
+				if err != nil {
+					return err
+				}
+				set[key] = ciphertext
+			} else if sub, ok := value.(bson.M); ok && strings.HasPrefix(path, key+".") {
+				if err := e.encryptPath(sub, strings.TrimPrefix(path, key+".")); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// encryptPath 加密嵌套文档中 path 位置的字符串
+func (e *FieldEncryptor) encryptPath(doc bson.M, path string) error {
+	head, rest, nested := strings.Cut(path, ".")
+	if nested {
+		sub, ok := doc[head].(bson.M)
+		if !ok {
+			return nil
+		}
+		return e.encryptPath(sub, rest)
+	}
+	s, ok := doc[head].(string)
+	if !ok {
+		return nil
+	}
+	ciphertext, err := e.EncryptString(s)
+	if err != nil {
+		return err
+	}
+	doc[head] = ciphertext
+	return nil
+}
+
+// RotateOptions 重新加密配置
+type RotateOptions struct {
+	// BatchSize 每批处理的文档数，默认 500
+	BatchSize int
+	// Name 和 Checkpoints 同时设置时支持中断后继续，参见 ForEachBatch
+	Name        string
+	Checkpoints CheckpointStore
+}
+
+// Rotate 使用当前版本的密钥重新加密集合中旧版本密钥加密的字段，启用加密前写入的明文也会被加密；
+// 更新时以读取到的旧值为条件，期间被其它请求修改的文档会跳过。返回重新加密的文档数
+//
+//	provider.Current = "2025"
+//	rotated, err := enc.Rotate(ctx, users, User{}, nil)
+func (e *FieldEncryptor) Rotate(ctx context.Context, c *Collection, model interface{}, opts *RotateOptions) (int64, error) {
+	o := RotateOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 500
+	}
+	paths, err := EncryptedFields(model)
+	if err != nil {
+		return 0, err
+	}
+	if len(paths) == 0 {
+		return 0, nil
+	}
+	if err := c.cli.checkWritable("update", c.collection.Name()); err != nil {
+		return 0, err
+	}
+	version, _, err := e.keys.CurrentKey()
+	if err != nil {
+		return 0, err
+	}
+
+	current := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(encryptedPrefix+version+":")}
+	stale := make([]bson.M, 0, len(paths))
+	for _, path := range paths {
+		stale = append(stale, bson.M{path: bson.M{"$type": "string", "$not": current}})
+	}
+	filter := bson.M{"$or": stale}
+
+	var rotated int64
+	_, err = c.ForEachBatch(ctx, filter, o.BatchSize, func(ctx context.Context, batch []bson.Raw) error {
+		for _, raw := range batch {
+			var doc bson.M
+			if err := bson.Unmarshal(raw, &doc); err != nil {
+				return fmt.Errorf("failed to decode document: %w", err)
+			}
+			match := bson.M{"_id": doc["_id"]}
+			set := bson.M{}
+			for _, path := range paths {
+				value, ok := lookupPath(doc, path)
+				s, isString := value.(string)
+				if !ok || !isString {
+					continue
+				}
+				if v, _, encrypted := parseEncrypted(s); encrypted && v == version {
+					continue
+				}
+				plaintext, err := e.DecryptString(s)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt %s of document %v: %w", path, doc["_id"], err)
+				}
+				ciphertext, err := e.EncryptString(plaintext)
+				if err != nil {
+					return err
+				}
+				match[path] = s
+				set[path] = ciphertext
+			}
+			if len(set) == 0 {
+				continue
+			}
+			// 通过集合包装写入，审计、镜像和并发限制同样生效；match 中的旧密文保证不会覆盖并发写入的新值
+			result, err := c.UpdateOne(ctx, match, bson.M{"$set": set})
+			if err != nil {
+				return fmt.Errorf("failed to re-encrypt document %v: %w", doc["_id"], err)
+			}
+			rotated += result.ModifiedCount
+		}
+		return nil
+	}, &BatchOptions{Name: o.Name, Checkpoints: o.Checkpoints})
+	return rotated, err
+}
+
+// WithEncryption 返回使用 enc 加密 encrypt 标签字段的集合副本：插入、替换和 Upsert 前加密，
+// 查询解码后解密，写入完成后调用方的文档恢复为明文
+func (c *Collection) WithEncryption(enc *FieldEncryptor) *Collection {
+	cp := *c
+	cp.encryptor = enc
+	return &cp
+}
+
+// encryptDocument 加密将要写入的文档，返回恢复明文的函数；未配置加密时为空操作
+func (c *Collection) encryptDocument(document interface{}) (func(), error) {
+	if c.encryptor == nil {
+		return func() {}, nil
+	}
+	return c.encryptor.EncryptStruct(document)
+}
+
+// decryptResult 解密查询结果；未配置加密时为空操作
+func (c *Collection) decryptResult(result interface{}) error {
+	if c.encryptor == nil {
+		return nil
+	}
+	return c.encryptor.DecryptStruct(result)
+}
@@ -0,0 +1,213 @@
+package mongo
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type encryptedProfile struct {
+	Phone   string  `bson:"phone" encrypt:"aes"`
+	Address *string `bson:"address" encrypt:"aes"`
+	City    string  `bson:"city"`
+}
+
+type encryptedUser struct {
+	BaseDocument `bson:",inline"`
+	Name         string           `bson:"name"`
+	SSN          string           `bson:"ssn" encrypt:"aes"`
+	Profile      encryptedProfile `bson:"profile"`
+}
+
+func newTestKeyProvider() *StaticKeyProvider {
+	return &StaticKeyProvider{Current: "v1", Keys: map[string][]byte{
+		"v1": bytes.Repeat([]byte{1}, 32),
+		"v2": bytes.Repeat([]byte{2}, 16),
+	}}
+}
+
+func TestFieldEncryptorString(t *testing.T) {
+	keys := newTestKeyProvider()
+	enc := NewFieldEncryptor(keys)
+
+	ciphertext, err := enc.EncryptString("123-45-6789")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ciphertext, "enc:v1:"))
+	again, err := enc.EncryptString("123-45-6789")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, again)
+
+	// 更换当前密钥后旧密文仍可解密
+	keys.Current = "v2"
+	plaintext, err := enc.DecryptString(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", plaintext)
+
+	plaintext, err = enc.DecryptString("legacy plaintext")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy plaintext", plaintext)
+
+	_, err = enc.DecryptString("enc:v9:AAAA")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	_, err = enc.DecryptString(ciphertext[:len(ciphertext)-4] + "AAAA")
+	assert.True(t, errors.Is(err, ErrDecrypt))
+}
+
+func TestFieldEncryptorStruct(t *testing.T) {
+	enc := NewFieldEncryptor(newTestKeyProvider())
+	address := "1 Main St"
+	user := &encryptedUser{Name: "alice", SSN: "123", Profile: encryptedProfile{Phone: "555", Address: &address, City: "Paris"}}
+
+	restore, err := enc.EncryptStruct(user)
+	require.NoError(t, err)
+	doc, err := toBsonM(user)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(doc["ssn"].(string), "enc:v1:"))
+	assert.True(t, strings.HasPrefix(doc["profile"].(bson.M)["phone"].(string), "enc:v1:"))
+	assert.True(t, strings.HasPrefix(doc["profile"].(bson.M)["address"].(string), "enc:v1:"))
+	assert.Equal(t, "Paris", doc["profile"].(bson.M)["city"])
+	assert.Equal(t, "alice", doc["name"])
+
+	restore()
+	assert.Equal(t, "123", user.SSN)
+	assert.Equal(t, "1 Main St", *user.Profile.Address)
+
+	// 解码后解密切片结果
+	raw, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	var decoded encryptedUser
+	require.NoError(t, bson.Unmarshal(raw, &decoded))
+	users := []encryptedUser{decoded, {Name: "bob"}}
+	require.NoError(t, enc.DecryptStruct(&users))
+	assert.Equal(t, "123", users[0].SSN)
+	assert.Equal(t, "555", users[0].Profile.Phone)
+	assert.Equal(t, "1 Main St", *users[0].Profile.Address)
+
+	_, err = enc.EncryptStruct(encryptedUser{SSN: "123"})
+	assert.Error(t, err)
+	_, err = enc.EncryptStruct(bson.M{"ssn": "123"})
+	assert.NoError(t, err)
+}
+
+func TestFieldEncryptorEncryptsCiphertextShapedInput(t *testing.T) {
+	enc := NewFieldEncryptor(newTestKeyProvider())
+	user := &encryptedUser{SSN: "enc:v1:abc"}
+
+	restore, err := enc.EncryptStruct(user)
+	require.NoError(t, err)
+	stored := user.SSN
+	restore()
+	assert.NotEqual(t, "enc:v1:abc", stored)
+	assert.Equal(t, "enc:v1:abc", user.SSN)
+
+	user.SSN = stored
+	require.NoError(t, enc.DecryptStruct(user))
+	assert.Equal(t, "enc:v1:abc", user.SSN)
+}
+
+func TestTenantCollectionEncryption(t *testing.T) {
+	server := newFakeServer(t, false)
+	enc := NewFieldEncryptor(newTestKeyProvider())
+	users := NewTenantCollection(server.client(t), "users", TenantOptions{Encryptor: enc})
+	ctx := WithTenant(t.Context(), "acme")
+
+	user := &encryptedUser{Name: "alice", SSN: "123"}
+	_, err := users.InsertOne(ctx, user)
+	require.NoError(t, err)
+	_, err = users.ReplaceOne(ctx, bson.M{"name": "alice"}, user)
+	require.NoError(t, err)
+	assert.Equal(t, "123", user.SSN)
+
+	inserted := server.Commands("insert")[0].Lookup("documents").Array().Index(0).Value().Document()
+	ciphertext := inserted.Lookup("ssn").StringValue()
+	assert.True(t, strings.HasPrefix(ciphertext, "enc:v1:"))
+	replaced := server.Commands("update")[0].Lookup("updates").Array().Index(0).Value().Document()
+	assert.True(t, strings.HasPrefix(replaced.Lookup("u", "ssn").StringValue(), "enc:v1:"))
+
+	// 查询结果同样解密
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "find" {
+			return fakeCursor(cmd, bson.M{"_id": user.ID, "tenant_id": "acme", "name": "alice", "ssn": ciphertext})
+		}
+		return nil
+	})
+	var found encryptedUser
+	require.NoError(t, users.FindByID(ctx, user.ID, &found))
+	assert.Equal(t, "123", found.SSN)
+}
+
+func TestEncryptedFields(t *testing.T) {
+	paths, err := EncryptedFields(encryptedUser{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ssn", "profile.phone", "profile.address"}, paths)
+
+	type invalid struct {
+		Age int `bson:"age" encrypt:"aes"`
+	}
+	_, err = EncryptedFields(invalid{})
+	assert.Error(t, err)
+
+	type unsupported struct {
+		Name string `bson:"name" encrypt:"rot13"`
+	}
+	_, err = EncryptedFields(unsupported{})
+	assert.Error(t, err)
+}
+
+func TestFieldEncryptorUpdate(t *testing.T) {
+	enc := NewFieldEncryptor(newTestKeyProvider())
+	set := bson.M{
+		"ssn":     "123",
+		"name":    "alice",
+		"profile": bson.M{"phone": "555", "city": "Paris"},
+	}
+	require.NoError(t, enc.encryptUpdate(set, []string{"ssn", "profile.phone"}))
+	assert.True(t, strings.HasPrefix(set["ssn"].(string), "enc:v1:"))
+	assert.True(t, strings.HasPrefix(set["profile"].(bson.M)["phone"].(string), "enc:v1:"))
+	assert.Equal(t, "Paris", set["profile"].(bson.M)["city"])
+	assert.Equal(t, "alice", set["name"])
+}
+
+func TestFieldEncryptorRotate(t *testing.T) {
+	old := NewFieldEncryptor(&StaticKeyProvider{Current: "v2", Keys: newTestKeyProvider().Keys})
+	stale, err := old.EncryptString("123-45-6789")
+	require.NoError(t, err)
+	id := primitive.NewObjectID()
+
+	server := newFakeServer(t, false)
+	var served atomic.Bool
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "find" && !served.Swap(true) {
+			return fakeCursor(cmd, bson.M{"_id": id, "name": "alice", "ssn": stale})
+		}
+		return nil
+	})
+	users := NewCollection(server.client(t), "users")
+
+	enc := NewFieldEncryptor(newTestKeyProvider())
+	rotated, err := enc.Rotate(t.Context(), users, encryptedUser{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rotated)
+
+	updates := server.Commands("update")
+	require.Len(t, updates, 1)
+	update := updates[0].Lookup("updates").Array().Index(0).Value().Document()
+	// 只更新仍保存旧密文的文档
+	assert.Equal(t, id, update.Lookup("q", "_id").ObjectID())
+	assert.Equal(t, stale, update.Lookup("q", "ssn").StringValue())
+
+	ciphertext := update.Lookup("u", "$set", "ssn").StringValue()
+	assert.True(t, strings.HasPrefix(ciphertext, "enc:v1:"))
+	plaintext, err := enc.DecryptString(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", plaintext)
+	// 经过 Collection.UpdateOne 写入
+	assert.Equal(t, bson.TypeDateTime, update.Lookup("u", "$set", "updated_at").Type)
+}
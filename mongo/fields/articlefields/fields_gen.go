@@ -0,0 +1,37 @@
+// Code generated by mongofields. DO NOT EDIT.
+
+// Package articlefields Article 文档的字段名
+package articlefields
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Article 文档字段
+const (
+	ID         = "_id"
+	CreatedAt  = "created_at"
+	UpdatedAt  = "updated_at"
+	Title      = "title"
+	Content    = "content"
+	AuthorID   = "author_id"
+	Tags       = "tags"
+	Status     = "status"
+	ViewCount  = "view_count"
+	LikeCount  = "like_count"
+	CategoryID = "category_id"
+	Comments   = "comments"
+)
+
+// StatusCreatedAt 复合键 {status: 1, created_at: -1}
+func StatusCreatedAt() bson.D {
+	return bson.D{{Key: Status, Value: 1}, {Key: CreatedAt, Value: -1}}
+}
+
+// AuthorIDStatus 复合键 {author_id: 1, status: 1}
+func AuthorIDStatus() bson.D {
+	return bson.D{{Key: AuthorID, Value: 1}, {Key: Status, Value: 1}}
+}
+
+// CategoryIDStatusCreatedAt 复合键 {category_id: 1, status: 1, created_at: -1}
+func CategoryIDStatusCreatedAt() bson.D {
+	return bson.D{{Key: CategoryID, Value: 1}, {Key: Status, Value: 1}, {Key: CreatedAt, Value: -1}}
+}
@@ -0,0 +1,28 @@
+// Code generated by mongofields. DO NOT EDIT.
+
+// Package categoryfields Category 文档的字段名
+package categoryfields
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Category 文档字段
+const (
+	ID          = "_id"
+	CreatedAt   = "created_at"
+	UpdatedAt   = "updated_at"
+	Name        = "name"
+	Description = "description"
+	ParentID    = "parent_id"
+	Sort        = "sort"
+	IsActive    = "is_active"
+)
+
+// ParentIDSort 复合键 {parent_id: 1, sort: 1}
+func ParentIDSort() bson.D {
+	return bson.D{{Key: ParentID, Value: 1}, {Key: Sort, Value: 1}}
+}
+
+// IsActiveSort 复合键 {is_active: 1, sort: 1}
+func IsActiveSort() bson.D {
+	return bson.D{{Key: IsActive, Value: 1}, {Key: Sort, Value: 1}}
+}
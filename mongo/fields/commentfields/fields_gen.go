@@ -0,0 +1,36 @@
+// Code generated by mongofields. DO NOT EDIT.
+
+// Package commentfields Comment 文档的字段名
+package commentfields
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Comment 文档字段
+const (
+	ID         = "_id"
+	CreatedAt  = "created_at"
+	UpdatedAt  = "updated_at"
+	ArticleID  = "article_id"
+	AuthorID   = "author_id"
+	ParentID   = "parent_id"
+	Path       = "path"
+	Depth      = "depth"
+	Content    = "content"
+	Status     = "status"
+	ReplyCount = "reply_count"
+)
+
+// ArticleIDPath 复合键 {article_id: 1, path: 1}
+func ArticleIDPath() bson.D {
+	return bson.D{{Key: ArticleID, Value: 1}, {Key: Path, Value: 1}}
+}
+
+// ArticleIDStatusCreatedAt 复合键 {article_id: 1, status: 1, created_at: -1}
+func ArticleIDStatusCreatedAt() bson.D {
+	return bson.D{{Key: ArticleID, Value: 1}, {Key: Status, Value: 1}, {Key: CreatedAt, Value: -1}}
+}
+
+// StatusCreatedAt 复合键 {status: 1, created_at: 1}
+func StatusCreatedAt() bson.D {
+	return bson.D{{Key: Status, Value: 1}, {Key: CreatedAt, Value: 1}}
+}
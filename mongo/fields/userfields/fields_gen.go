@@ -0,0 +1,32 @@
+// Code generated by mongofields. DO NOT EDIT.
+
+// Package userfields User 文档的字段名
+package userfields
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// User 文档字段
+const (
+	ID               = "_id"
+	CreatedAt        = "created_at"
+	UpdatedAt        = "updated_at"
+	Username         = "username"
+	Email            = "email"
+	Password         = "password"
+	Status           = "status"
+	Profile          = "profile"
+	ProfileFirstName = "profile.first_name"
+	ProfileLastName  = "profile.last_name"
+	ProfileAvatar    = "profile.avatar"
+	ProfileBio       = "profile.bio"
+)
+
+// StatusCreatedAt 复合键 {status: 1, created_at: -1}
+func StatusCreatedAt() bson.D {
+	return bson.D{{Key: Status, Value: 1}, {Key: CreatedAt, Value: -1}}
+}
+
+// ProfileFirstNameProfileLastName 复合键 {profile.first_name: 1, profile.last_name: 1}
+func ProfileFirstNameProfileLastName() bson.D {
+	return bson.D{{Key: ProfileFirstName, Value: 1}, {Key: ProfileLastName, Value: 1}}
+}
@@ -0,0 +1,346 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FlagRule 按上下文属性决定开关的规则，属性值属于 Values 时规则命中
+type FlagRule struct {
+	// Attribute 上下文属性名，key 表示 FlagContext.Key
+	Attribute string   `bson:"attribute" json:"attribute"`
+	Values    []string `bson:"values" json:"values"`
+	// Enabled 命中时的结果，为 false 时可以用来排除特定用户
+	Enabled bool `bson:"enabled" json:"enabled"`
+}
+
+// FeatureFlag 功能开关，保存在 feature_flags 集合中
+type FeatureFlag struct {
+	Key         string `bson:"_id" json:"key"`
+	Description string `bson:"description" json:"description"`
+	// Enabled 总开关，关闭时规则和放量比例都不生效
+	Enabled bool `bson:"enabled" json:"enabled"`
+	// Rules 按顺序匹配，第一条命中的规则决定结果
+	Rules []FlagRule `bson:"rules" json:"rules"`
+	// Percentage 没有规则命中时按 FlagContext.Key 放量的比例（0-100），为空时全部开启
+	Percentage *float64  `bson:"percentage" json:"percentage,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// FlagContext 判断开关时的上下文
+type FlagContext struct {
+	// Key 放量的分桶依据，通常是用户 ID；同一个 Key 在同一开关上的结果稳定
+	Key        string
+	Attributes map[string]string
+}
+
+// Evaluate 返回开关对上下文是否开启
+func (f *FeatureFlag) Evaluate(fc *FlagContext) bool {
+	if !f.Enabled {
+		return false
+	}
+	if fc == nil {
+		fc = &FlagContext{}
+	}
+	for _, rule := range f.Rules {
+		value, ok := fc.Attributes[rule.Attribute]
+		if rule.Attribute == "key" {
+			value, ok = fc.Key, fc.Key != ""
+		}
+		if ok && slices.Contains(rule.Values, value) {
+			return rule.Enabled
+		}
+	}
+	if f.Percentage == nil || *f.Percentage >= 100 {
+		return true
+	}
+	if fc.Key == "" || *f.Percentage <= 0 {
+		return false
+	}
+	return float64(flagBucket(f.Key, fc.Key)) < *f.Percentage*100
+}
+
+// flagBucket 将上下文稳定地分配到 0-9999 的桶，不同开关的分桶相互独立
+func flagBucket(flag, key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(flag + ":" + key))
+	return h.Sum32() % 10000
+}
+
+// FlagStoreOptions 功能开关配置
+type FlagStoreOptions struct {
+	// Collection 开关集合，默认 feature_flags
+	Collection string
+	// RefreshInterval 部署不支持变更流（单机）时定期重新加载的间隔，同时是变更流重试间隔的上限，默认 30 秒
+	RefreshInterval time.Duration
+}
+
+// FlagStore 功能开关，所有开关缓存在内存中，判断开关不访问数据库；
+// Start 之后通过变更流实时同步其他实例的修改，部署不支持变更流时退化为定期重新加载
+//
+//	flags := NewFlagStore(client, nil)
+//	if err := flags.Start(ctx); err != nil {
+//		return err
+//	}
+//	defer flags.Stop()
+//	if flags.IsEnabled("new-checkout", &FlagContext{Key: userID, Attributes: map[string]string{"plan": "pro"}}) {
+//		...
+//	}
+type FlagStore struct {
+	client     *Client
+	collection *Collection
+	opts       FlagStoreOptions
+
+	mu    sync.RWMutex
+	flags map[string]*FeatureFlag
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	doneCh    chan struct{}
+}
+
+// NewFlagStore 创建功能开关
+func NewFlagStore(client *Client, opts *FlagStoreOptions) *FlagStore {
+	o := FlagStoreOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Collection == "" {
+		o.Collection = "feature_flags"
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = 30 * time.Second
+	}
+	return &FlagStore{
+		client:     client,
+		collection: NewCollection(client, o.Collection),
+		opts:       o,
+		flags:      make(map[string]*FeatureFlag),
+		doneCh:     make(chan struct{}),
+	}
+}
+
+// IsEnabled 返回开关对上下文是否开启，开关不存在时返回 false
+func (s *FlagStore) IsEnabled(key string, fc *FlagContext) bool {
+	flag, ok := s.Flag(key)
+	return ok && flag.Evaluate(fc)
+}
+
+// Flag 返回缓存中的开关
+func (s *FlagStore) Flag(key string) (*FeatureFlag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flag, ok := s.flags[key]
+	return flag, ok
+}
+
+// Flags 返回缓存中的所有开关，按 Key 排序
+func (s *FlagStore) Flags() []*FeatureFlag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flags := make([]*FeatureFlag, 0, len(s.flags))
+	for _, flag := range s.flags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	return flags
+}
+
+// Set 创建或修改开关，并立即更新本实例的缓存
+func (s *FlagStore) Set(ctx context.Context, flag *FeatureFlag) error {
+	if flag.Key == "" {
+		return fmt.Errorf("feature flag key is required")
+	}
+	if p := flag.Percentage; p != nil && (*p < 0 || *p > 100) {
+		return fmt.Errorf("invalid percentage %v for feature flag %s", *p, flag.Key)
+	}
+	if _, err := s.collection.Upsert(ctx, bson.M{"_id": flag.Key}, flag); err != nil {
+		return fmt.Errorf("failed to save feature flag %s: %w", flag.Key, err)
+	}
+	return s.reload(ctx, flag.Key)
+}
+
+// Delete 删除开关
+func (s *FlagStore) Delete(ctx context.Context, key string) error {
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": key}); err != nil {
+		return fmt.Errorf("failed to delete feature flag %s: %w", key, err)
+	}
+	s.put(key, nil)
+	return nil
+}
+
+// Load 从数据库重新加载所有开关
+func (s *FlagStore) Load(ctx context.Context) error {
+	var flags []*FeatureFlag
+	if err := s.collection.Find(ctx, bson.M{}, &flags); err != nil {
+		return fmt.Errorf("failed to load feature flags: %w", err)
+	}
+	loaded := make(map[string]*FeatureFlag, len(flags))
+	for _, flag := range flags {
+		loaded[flag.Key] = flag
+	}
+	s.mu.Lock()
+	s.flags = loaded
+	s.mu.Unlock()
+	return nil
+}
+
+// reload 重新读取单个开关，开关已被删除时从缓存中移除
+func (s *FlagStore) reload(ctx context.Context, key string) error {
+	var flag FeatureFlag
+	err := s.collection.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&flag)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		s.put(key, nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load feature flag %s: %w", key, err)
+	}
+	s.put(key, &flag)
+	return nil
+}
+
+// put flag 为 nil 时删除
+func (s *FlagStore) put(key string, flag *FeatureFlag) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if flag == nil {
+		delete(s.flags, key)
+	} else {
+		s.flags[key] = flag
+	}
+}
+
+// Start 加载所有开关并在后台同步修改，首次加载失败时返回错误
+func (s *FlagStore) Start(ctx context.Context) error {
+	if err := s.Load(ctx); err != nil {
+		return err
+	}
+	s.startOnce.Do(func() {
+		s.client.RegisterShutdown(s)
+		ctx, s.cancel = context.WithCancel(ctx)
+		go func() {
+			defer close(s.doneCh)
+			_ = s.Run(ctx)
+		}()
+	})
+	return nil
+}
+
+// Stop 停止后台同步
+func (s *FlagStore) Stop() {
+	s.stopOnce.Do(func() {
+		s.startOnce.Do(func() {
+			close(s.doneCh)
+		})
+		if s.cancel != nil {
+			s.cancel()
+		}
+		<-s.doneCh
+	})
+}
+
+// Run 通过变更流同步开关直到 ctx 取消，变更流中断后重新打开并全量加载；
+// 部署不支持变更流时按 RefreshInterval 定期加载
+func (s *FlagStore) Run(ctx context.Context) error {
+	for attempt := 0; ; attempt++ {
+		delivered, err := s.watch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrUnsupportedFeature) {
+			s.client.logger.WarnContext(ctx, "Feature flags fall back to polling", "interval", s.opts.RefreshInterval, "err", err)
+			return s.poll(ctx)
+		}
+		if delivered {
+			attempt = 0
+		}
+		s.client.logger.WarnContext(ctx, "Feature flag change stream interrupted, reloading", "err", err)
+		backoff := time.Second << attempt
+		if backoff <= 0 || backoff > s.opts.RefreshInterval {
+			backoff = s.opts.RefreshInterval
+		}
+		if err := s.wait(ctx, backoff); err != nil {
+			return nil
+		}
+	}
+}
+
+// watch 先打开变更流再全量加载，加载期间的修改不会丢失；返回是否处理过事件
+func (s *FlagStore) watch(ctx context.Context) (bool, error) {
+	if err := s.client.requireFeature(ctx, FeatureChangeStreams); err != nil {
+		return false, err
+	}
+	stream, err := s.collection.collection.Watch(ctx, []bson.M{}, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+	if err != nil {
+		return false, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+	if err := s.Load(ctx); err != nil {
+		return false, err
+	}
+
+	delivered := false
+	for stream.Next(ctx) {
+		var event struct {
+			OperationType string       `bson:"operationType"`
+			DocumentKey   bson.M       `bson:"documentKey"`
+			FullDocument  *FeatureFlag `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return delivered, fmt.Errorf("failed to decode change event: %w", err)
+		}
+		switch event.OperationType {
+		case "insert", "update", "replace", "delete":
+			key, _ := event.DocumentKey["_id"].(string)
+			// update 查询完整文档时开关可能已被删除，FullDocument 为空
+			s.put(key, event.FullDocument)
+		default:
+			// drop、rename 等事件使变更流失效，重新打开并全量加载
+			return true, fmt.Errorf("change stream invalidated by %s", event.OperationType)
+		}
+		delivered = true
+	}
+	if err := stream.Err(); err != nil {
+		return delivered, fmt.Errorf("change stream failed: %w", err)
+	}
+	return delivered, nil
+}
+
+func (s *FlagStore) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *FlagStore) poll(ctx context.Context) error {
+	ticker := time.NewTicker(s.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Load(ctx); err != nil && ctx.Err() == nil {
+				s.client.logger.WarnContext(ctx, "Failed to reload feature flags", "err", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
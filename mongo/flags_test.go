@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlagEvaluate(t *testing.T) {
+	percentage := func(p float64) *float64 { return &p }
+
+	assert.False(t, (&FeatureFlag{Key: "off"}).Evaluate(nil))
+	assert.True(t, (&FeatureFlag{Key: "on", Enabled: true}).Evaluate(nil))
+
+	flag := &FeatureFlag{
+		Key:     "new-checkout",
+		Enabled: true,
+		Rules: []FlagRule{
+			{Attribute: "key", Values: []string{"blocked-user"}, Enabled: false},
+			{Attribute: "plan", Values: []string{"pro", "enterprise"}, Enabled: true},
+		},
+		Percentage: percentage(0),
+	}
+	assert.True(t, flag.Evaluate(&FlagContext{Key: "u1", Attributes: map[string]string{"plan": "pro"}}))
+	assert.False(t, flag.Evaluate(&FlagContext{Key: "blocked-user", Attributes: map[string]string{"plan": "pro"}}))
+	assert.False(t, flag.Evaluate(&FlagContext{Key: "u1", Attributes: map[string]string{"plan": "free"}}))
+	assert.False(t, flag.Evaluate(nil))
+
+	flag.Percentage = percentage(25)
+	assert.False(t, flag.Evaluate(&FlagContext{}), "partial rollout needs a key")
+	enabled := 0
+	for i := 0; i < 10000; i++ {
+		fc := &FlagContext{Key: fmt.Sprintf("user-%d", i)}
+		result := flag.Evaluate(fc)
+		assert.Equal(t, result, flag.Evaluate(fc), "rollout is stable per key")
+		if result {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 2500, enabled, 200)
+}
+
+func TestFlagStoreCache(t *testing.T) {
+	store := NewFlagStore(newLazyClient(t), nil)
+	assert.Equal(t, "feature_flags", store.collection.collection.Name())
+
+	store.put("b", &FeatureFlag{Key: "b", Enabled: true})
+	store.put("a", &FeatureFlag{Key: "a"})
+	assert.True(t, store.IsEnabled("b", nil))
+	assert.False(t, store.IsEnabled("a", nil))
+	assert.False(t, store.IsEnabled("missing", nil))
+	if flags := store.Flags(); assert.Len(t, flags, 2) {
+		assert.Equal(t, "a", flags[0].Key)
+	}
+	store.put("b", nil)
+	assert.False(t, store.IsEnabled("b", nil))
+
+	invalid := 120.0
+	assert.ErrorContains(t, store.Set(t.Context(), &FeatureFlag{Key: "x", Percentage: &invalid}), "invalid percentage")
+	assert.Error(t, store.Set(t.Context(), &FeatureFlag{}))
+}
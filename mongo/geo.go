@@ -0,0 +1,80 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// earthRadiusMeters 地球半径（米），用于 $centerSphere 的弧度换算
+const earthRadiusMeters = 6378100.0
+
+// BuildNearFilter 构建 $near 过滤器，结果按距离由近到远排序，距离单位为米，小于等于 0 时不限制
+// $near 不能与 CountDocuments 一起使用，分页时请使用 Find 或 GeoNearStage
+func BuildNearFilter(field string, point GeoPoint, maxDistance, minDistance float64) bson.M {
+	near := bson.M{"$geometry": point}
+	if maxDistance > 0 {
+		near["$maxDistance"] = maxDistance
+	}
+	if minDistance > 0 {
+		near["$minDistance"] = minDistance
+	}
+	return bson.M{field: bson.M{"$near": near}}
+}
+
+// BuildGeoWithinFilter 构建 $geoWithin 过滤器，查找完全位于 geometry（例如 GeoPolygon）内的文档
+func BuildGeoWithinFilter(field string, geometry interface{}) bson.M {
+	return bson.M{field: bson.M{"$geoWithin": bson.M{"$geometry": geometry}}}
+}
+
+// BuildGeoWithinRadiusFilter 构建以 center 为圆心、radius 米为半径的 $geoWithin 过滤器
+// 与 $near 不同，结果不排序，可以与 CountDocuments 一起使用
+func BuildGeoWithinRadiusFilter(field string, center GeoPoint, radius float64) bson.M {
+	sphere := bson.A{bson.A{center.Longitude(), center.Latitude()}, radius / earthRadiusMeters}
+	return bson.M{field: bson.M{"$geoWithin": bson.M{"$centerSphere": sphere}}}
+}
+
+// BuildGeoIntersectsFilter 构建 $geoIntersects 过滤器，查找与 geometry 相交的文档
+func BuildGeoIntersectsFilter(field string, geometry interface{}) bson.M {
+	return bson.M{field: bson.M{"$geoIntersects": bson.M{"$geometry": geometry}}}
+}
+
+// GeoNearOptions $geoNear 聚合阶段配置
+type GeoNearOptions struct {
+	// Near 查询中心点
+	Near GeoPoint
+	// DistanceField 输出距离（米）的字段，默认 distance
+	DistanceField string
+	// MaxDistance 最大距离（米），小于等于 0 时不限制
+	MaxDistance float64
+	// MinDistance 最小距离（米），小于等于 0 时不限制
+	MinDistance float64
+	// Query 额外的过滤条件
+	Query bson.M
+	// Key 使用的地理索引字段，集合有多个 2dsphere 索引时必须指定
+	Key string
+}
+
+// GeoNearStage 构建 $geoNear 聚合阶段，必须作为管道的第一个阶段，输出每个文档到中心点的距离
+// 例如：pipeline := []bson.M{GeoNearStage(GeoNearOptions{Near: NewGeoPoint(116.4, 39.9), MaxDistance: 5000}), {"$limit": 20}}
+func GeoNearStage(opts GeoNearOptions) bson.M {
+	if opts.DistanceField == "" {
+		opts.DistanceField = "distance"
+	}
+	stage := bson.M{
+		"near":          opts.Near,
+		"distanceField": opts.DistanceField,
+		"spherical":     true,
+	}
+	if opts.MaxDistance > 0 {
+		stage["maxDistance"] = opts.MaxDistance
+	}
+	if opts.MinDistance > 0 {
+		stage["minDistance"] = opts.MinDistance
+	}
+	if len(opts.Query) > 0 {
+		stage["query"] = opts.Query
+	}
+	if opts.Key != "" {
+		stage["key"] = opts.Key
+	}
+	return bson.M{"$geoNear": stage}
+}
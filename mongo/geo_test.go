@@ -0,0 +1,31 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewGeoPolygonClosesRings(t *testing.T) {
+	polygon := NewGeoPolygon([][]float64{{0, 0}, {1, 0}, {1, 1}})
+	assert.Equal(t, "Polygon", polygon.Type)
+	assert.Equal(t, [][][]float64{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}, polygon.Coordinates)
+
+	closed := NewGeoPolygon([][]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}})
+	assert.Len(t, closed.Coordinates[0], 4)
+}
+
+func TestGeoFilters(t *testing.T) {
+	point := NewGeoPoint(116.4, 39.9)
+	assert.Equal(t, bson.M{"location": bson.M{"$near": bson.M{"$geometry": point, "$maxDistance": 1000.0}}},
+		BuildNearFilter("location", point, 1000, 0))
+
+	within := BuildGeoWithinRadiusFilter("location", point, earthRadiusMeters)
+	assert.Equal(t, bson.A{bson.A{116.4, 39.9}, 1.0}, within["location"].(bson.M)["$geoWithin"].(bson.M)["$centerSphere"])
+
+	stage := GeoNearStage(GeoNearOptions{Near: point, Query: bson.M{"status": "open"}})["$geoNear"].(bson.M)
+	assert.Equal(t, "distance", stage["distanceField"])
+	assert.Equal(t, bson.M{"status": "open"}, stage["query"])
+	assert.NotContains(t, stage, "maxDistance")
+}
@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrFullCollectionWrite UpdateMany/DeleteMany 的过滤条件为空且没有传入 AllowFullCollection
+	ErrFullCollectionWrite = errors.New("refusing to modify entire collection")
+	// ErrTooManyAffected 匹配的文档数超过 MaxAffected
+	ErrTooManyAffected = errors.New("too many documents affected")
+)
+
+// MassWriteOptions UpdateMany/DeleteMany 的安全限制，防止误操作清空或改写整个集合
+type MassWriteOptions struct {
+	// AllowFullCollection 允许空过滤条件，即修改集合中的所有文档
+	AllowFullCollection bool
+	// MaxAffected 匹配的文档数超过该值时拒绝执行并返回 ErrTooManyAffected；
+	// 为 0 时使用 Collection.WithMaxAffected 的设置，小于 0 表示不限制
+	MaxAffected int64
+}
+
+// AllowFullCollection 返回允许空过滤条件的选项，例如 users.DeleteMany(ctx, bson.M{}, AllowFullCollection())
+func AllowFullCollection() *MassWriteOptions {
+	return &MassWriteOptions{AllowFullCollection: true}
+}
+
+// mergeMassWriteOptions 合并选项，后面的非零值覆盖前面的
+func mergeMassWriteOptions(opts []*MassWriteOptions) MassWriteOptions {
+	var merged MassWriteOptions
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.AllowFullCollection {
+			merged.AllowFullCollection = true
+		}
+		if opt.MaxAffected != 0 {
+			merged.MaxAffected = opt.MaxAffected
+		}
+	}
+	return merged
+}
+
+// WithMaxAffected 返回限制 UpdateMany/DeleteMany 影响文档数的集合副本，n <= 0 表示不限制；
+// 单次调用可以通过 MassWriteOptions.MaxAffected 覆盖
+func (c *Collection) WithMaxAffected(n int64) *Collection {
+	cp := *c
+	cp.maxAffected = n
+	return &cp
+}
+
+// checkFullCollection 过滤条件为空且没有显式允许时返回 ErrFullCollectionWrite
+func checkFullCollection(operation, collection string, filter bson.M, o MassWriteOptions) error {
+	if len(filter) == 0 && !o.AllowFullCollection {
+		return fmt.Errorf("%w: %s on %s has an empty filter, pass AllowFullCollection() to confirm", ErrFullCollectionWrite, operation, collection)
+	}
+	return nil
+}
+
+// guardMassWrite 执行批量写之前的安全检查；匹配文档数的检查与写入之间不是原子的，
+// 只用于拦截明显的误操作，需要严格保证时在事务中执行
+func (c *Collection) guardMassWrite(ctx context.Context, operation string, filter bson.M, opts []*MassWriteOptions) error {
+	o := mergeMassWriteOptions(opts)
+	if err := checkFullCollection(operation, c.collection.Name(), filter, o); err != nil {
+		return err
+	}
+
+	limit := o.MaxAffected
+	if limit == 0 {
+		limit = c.maxAffected
+	}
+	if limit <= 0 {
+		return nil
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	count, err := c.collection.CountDocuments(ctx, filter, options.Count().SetLimit(limit+1))
+	if err != nil {
+		return fmt.Errorf("failed to count documents: %w", err)
+	}
+	if count > limit {
+		return fmt.Errorf("%w: %s on %s matches more than %d documents", ErrTooManyAffected, operation, c.collection.Name(), limit)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMassWriteGuard(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	users := NewCollection(client, "users")
+
+	_, err := users.DeleteMany(ctx, bson.M{})
+	assert.ErrorIs(t, err, ErrFullCollectionWrite)
+	_, err = users.UpdateMany(ctx, nil, bson.M{"$set": bson.M{"active": false}})
+	assert.ErrorIs(t, err, ErrFullCollectionWrite)
+
+	assert.NoError(t, checkFullCollection("deleteMany", "users", bson.M{}, mergeMassWriteOptions([]*MassWriteOptions{AllowFullCollection()})))
+	assert.NoError(t, checkFullCollection("deleteMany", "users", bson.M{"active": false}, MassWriteOptions{}))
+
+	merged := mergeMassWriteOptions([]*MassWriteOptions{AllowFullCollection(), nil, {MaxAffected: 100}})
+	assert.Equal(t, MassWriteOptions{AllowFullCollection: true, MaxAffected: 100}, merged)
+
+	limited := users.WithMaxAffected(50)
+	assert.Equal(t, int64(50), limited.maxAffected)
+	assert.Zero(t, users.maxAffected)
+
+	tenants := NewTenantCollection(client, "users", TenantOptions{})
+	_, err = tenants.DeleteMany(WithTenant(ctx, "acme"), bson.M{})
+	assert.ErrorIs(t, err, ErrFullCollectionWrite)
+}
@@ -0,0 +1,210 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheckerOptions 健康检查配置
+type HealthCheckerOptions struct {
+	// Interval 检查间隔，默认 10 秒
+	Interval time.Duration
+	// Timeout 单次 Ping 超时时间，默认 2 秒
+	Timeout time.Duration
+	// FailureThreshold 健康状态下连续失败多少次后判定为不健康，默认 3 次；尚未成功过时不适用
+	FailureThreshold int
+}
+
+// HealthChecker 后台健康检查器
+// 定时 Ping 集群并记录结果，可以直接作为 Kubernetes 就绪探针的 http.Handler
+//
+// 启动后在第一次检查成功之前一直是不健康状态，保证就绪探针不会在集群不可达时放行流量；
+// 成功之后连续失败 FailureThreshold 次才判定为不健康，再次成功时立即恢复
+type HealthChecker struct {
+	client *Client
+	opts   HealthCheckerOptions
+
+	mu                  sync.RWMutex
+	checked             bool
+	healthy             bool
+	lastErr             error
+	latency             time.Duration
+	consecutiveFailures int
+	lastCheck           time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewHealthChecker 创建健康检查器
+func NewHealthChecker(client *Client, opts *HealthCheckerOptions) *HealthChecker {
+	hc := &HealthChecker{
+		client: client,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	if opts != nil {
+		hc.opts = *opts
+	}
+	if hc.opts.Interval <= 0 {
+		hc.opts.Interval = 10 * time.Second
+	}
+	if hc.opts.Timeout <= 0 {
+		hc.opts.Timeout = 2 * time.Second
+	}
+	if hc.opts.FailureThreshold <= 0 {
+		hc.opts.FailureThreshold = 3
+	}
+	return hc
+}
+
+// Check 立即执行一次健康检查
+func (hc *HealthChecker) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := hc.client.PingContext(ctx)
+	latency := time.Since(start)
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	wasHealthy, wasChecked := hc.healthy, hc.checked
+	hc.checked = true
+	hc.lastCheck = time.Now()
+	hc.lastErr = err
+	if err != nil {
+		hc.consecutiveFailures++
+		// 尚未成功过时 healthy 保持 false，阈值只用于容忍健康状态下的偶发失败
+		if hc.consecutiveFailures >= hc.opts.FailureThreshold {
+			hc.healthy = false
+		}
+	} else {
+		hc.consecutiveFailures = 0
+		hc.latency = latency
+		hc.healthy = true
+	}
+
+	switch {
+	case wasHealthy && !hc.healthy:
+		hc.client.logger.ErrorContext(ctx, "MongoDB health check failed", "failures", hc.consecutiveFailures, "err", err)
+	case !wasHealthy && !hc.healthy && hc.consecutiveFailures == hc.opts.FailureThreshold:
+		hc.client.logger.ErrorContext(ctx, "MongoDB health check has not succeeded since start", "failures", hc.consecutiveFailures, "err", err)
+	case wasChecked && !wasHealthy && hc.healthy:
+		hc.client.logger.InfoContext(ctx, "MongoDB health check recovered", "latency", latency)
+	}
+	return err
+}
+
+// Start 启动后台定时检查，启动时立即执行一次
+func (hc *HealthChecker) Start(ctx context.Context) {
+	hc.startOnce.Do(func() {
+		hc.client.RegisterShutdown(hc)
+		go hc.run(ctx)
+	})
+}
+
+// Stop 停止后台检查
+func (hc *HealthChecker) Stop() {
+	hc.stopOnce.Do(func() {
+		close(hc.stopCh)
+		hc.startOnce.Do(func() {
+			close(hc.doneCh)
+		})
+		<-hc.doneCh
+	})
+}
+
+// run 定时检查循环
+func (hc *HealthChecker) run(ctx context.Context) {
+	defer close(hc.doneCh)
+
+	_ = hc.Check(ctx)
+	ticker := time.NewTicker(hc.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = hc.Check(ctx)
+		case <-hc.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Healthy 当前是否健康，尚未有检查成功时返回 false
+func (hc *HealthChecker) Healthy() bool {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.checked && hc.healthy
+}
+
+// LastError 最近一次检查的错误
+func (hc *HealthChecker) LastError() error {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.lastErr
+}
+
+// Latency 最近一次成功检查的耗时
+func (hc *HealthChecker) Latency() time.Duration {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.latency
+}
+
+// ConsecutiveFailures 连续失败次数
+func (hc *HealthChecker) ConsecutiveFailures() int {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.consecutiveFailures
+}
+
+// LastCheck 最近一次检查的时间
+func (hc *HealthChecker) LastCheck() time.Time {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.lastCheck
+}
+
+// healthResponse 健康检查 HTTP 响应
+type healthResponse struct {
+	Status              string    `json:"status"`
+	LatencyMS           int64     `json:"latency_ms"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastCheck           time.Time `json:"last_check"`
+	Error               string    `json:"error,omitempty"`
+}
+
+// ServeHTTP 实现 http.Handler，健康时返回 200，否则返回 503，例如挂载到 /healthz
+func (hc *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	hc.mu.RLock()
+	resp := healthResponse{
+		Status:              "ok",
+		LatencyMS:           hc.latency.Milliseconds(),
+		ConsecutiveFailures: hc.consecutiveFailures,
+		LastCheck:           hc.lastCheck,
+	}
+	healthy := hc.checked && hc.healthy
+	if hc.lastErr != nil {
+		resp.Error = hc.lastErr.Error()
+	}
+	hc.mu.RUnlock()
+
+	status := http.StatusOK
+	if !healthy {
+		resp.Status = "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func healthStatus(t *testing.T, hc *HealthChecker) (int, healthResponse) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	hc.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var resp healthResponse
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&resp))
+	return recorder.Code, resp
+}
+
+func TestHealthCheckerUnhealthyUntilFirstSuccess(t *testing.T) {
+	server := newFakeServer(t, false)
+	hc := NewHealthChecker(server.client(t), &HealthCheckerOptions{FailureThreshold: 3, Timeout: time.Second})
+
+	code, _ := healthStatus(t, hc)
+	assert.Equal(t, http.StatusServiceUnavailable, code, "unhealthy before the first check")
+
+	// 启动时不可达，阈值不适用
+	server.failPing.Store(true)
+	assert.Error(t, hc.Check(t.Context()))
+	assert.False(t, hc.Healthy())
+	assert.Equal(t, 1, hc.ConsecutiveFailures())
+
+	server.failPing.Store(false)
+	require.NoError(t, hc.Check(t.Context()))
+	assert.True(t, hc.Healthy())
+	assert.Zero(t, hc.ConsecutiveFailures())
+	assert.NoError(t, hc.LastError())
+	code, resp := healthStatus(t, hc)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", resp.Status)
+}
+
+func TestHealthCheckerFailureThreshold(t *testing.T) {
+	server := newFakeServer(t, false)
+	hc := NewHealthChecker(server.client(t), &HealthCheckerOptions{FailureThreshold: 3, Timeout: time.Second})
+	require.NoError(t, hc.Check(t.Context()))
+
+	// 健康状态下容忍 FailureThreshold-1 次连续失败
+	server.failPing.Store(true)
+	for i := 1; i < 3; i++ {
+		assert.Error(t, hc.Check(t.Context()))
+		assert.True(t, hc.Healthy(), "failure %d", i)
+		assert.Equal(t, i, hc.ConsecutiveFailures())
+	}
+	assert.Error(t, hc.Check(t.Context()))
+	assert.False(t, hc.Healthy())
+	assert.Error(t, hc.LastError())
+
+	code, resp := healthStatus(t, hc)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unavailable", resp.Status)
+	assert.Equal(t, 3, resp.ConsecutiveFailures)
+	assert.NotEmpty(t, resp.Error)
+
+	// 一次成功即恢复，失败计数清零
+	server.failPing.Store(false)
+	require.NoError(t, hc.Check(t.Context()))
+	assert.True(t, hc.Healthy())
+	assert.Zero(t, hc.ConsecutiveFailures())
+
+	server.failPing.Store(true)
+	assert.Error(t, hc.Check(t.Context()))
+	assert.True(t, hc.Healthy(), "the threshold applies again after recovering")
+}
+
+func TestHealthCheckerStartAndStop(t *testing.T) {
+	server := newFakeServer(t, false)
+	hc := NewHealthChecker(server.client(t), &HealthCheckerOptions{Interval: time.Hour})
+
+	// Start 立即执行一次检查
+	hc.Start(t.Context())
+	assert.Eventually(t, hc.Healthy, time.Second, 10*time.Millisecond)
+	hc.Stop()
+	hc.Stop()
+	assert.False(t, hc.LastCheck().IsZero())
+}
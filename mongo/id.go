@@ -0,0 +1,268 @@
+package mongo
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// ErrInvalidID ID 字符串无法转换为集合使用的 ID 类型
+var ErrInvalidID = errors.New("invalid id")
+
+// UUID 以 BSON binary subtype 4 存储的 UUID
+type UUID [16]byte
+
+// NilUUID 零值 UUID
+var NilUUID UUID
+
+// NewUUIDv4 生成随机 UUID（版本 4）
+func NewUUIDv4() UUID {
+	var u UUID
+	readRandom(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u
+}
+
+// NewUUIDv7 生成按时间排序的 UUID（版本 7），前 48 位为毫秒时间戳，适合作为主键保持插入顺序
+func NewUUIDv7() UUID {
+	u := NewUUIDv4()
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(u[:6], ts[2:])
+	u[6] = (u[6] & 0x0f) | 0x70
+	return u
+}
+
+// ParseUUID 解析 xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx 或不带连字符的 32 位十六进制 UUID
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	raw := strings.ReplaceAll(s, "-", "")
+	if len(raw) != 32 || (len(s) != 32 && (len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-')) {
+		return u, fmt.Errorf("%w: invalid uuid %q", ErrInvalidID, s)
+	}
+	if _, err := hex.Decode(u[:], []byte(raw)); err != nil {
+		return u, fmt.Errorf("%w: invalid uuid %q", ErrInvalidID, s)
+	}
+	return u, nil
+}
+
+// String 返回带连字符的小写十六进制形式
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// IsZero 是否为零值，bson 的 omitempty 通过该方法判断
+func (u UUID) IsZero() bool {
+	return u == NilUUID
+}
+
+// MarshalBSONValue 编码为 binary subtype 4
+func (u UUID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.Binary, bsoncore.AppendBinary(nil, bsontype.BinaryUUID, u[:]), nil
+}
+
+// UnmarshalBSONValue 从 binary subtype 4（或旧版 subtype 3）解码
+func (u *UUID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	if t == bsontype.Null || t == bsontype.Undefined {
+		*u = NilUUID
+		return nil
+	}
+	if t != bsontype.Binary {
+		return fmt.Errorf("cannot decode %s into UUID", t)
+	}
+	subtype, bytes, _, ok := bsoncore.ReadBinary(data)
+	if !ok {
+		return errors.New("invalid binary value for UUID")
+	}
+	if (subtype != bsontype.BinaryUUID && subtype != bsontype.BinaryUUIDOld) || len(bytes) != len(u) {
+		return fmt.Errorf("cannot decode binary subtype %d of length %d into UUID", subtype, len(bytes))
+	}
+	copy(u[:], bytes)
+	return nil
+}
+
+// MarshalText 编码为字符串形式，JSON 中的 UUID 为字符串
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText 从字符串形式解码
+func (u *UUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// IDStrategy 文档 ID 策略，决定新文档的 ID 类型以及字符串形式的 ID（例如 URL 参数）如何转换为存储类型
+type IDStrategy interface {
+	// IDGenerator 生成新 ID
+	IDGenerator
+	// ParseID 将字符串转换为存储类型，格式不合法时返回 ErrInvalidID
+	ParseID(s string) (interface{}, error)
+}
+
+var (
+	// ObjectIDStrategy 使用 ObjectID，集合未设置策略时的默认策略
+	ObjectIDStrategy IDStrategy = objectIDStrategy{}
+	// UUIDv4Strategy 使用随机 UUID
+	UUIDv4Strategy IDStrategy = uuidStrategy{generate: NewUUIDv4}
+	// UUIDv7Strategy 使用按时间排序的 UUID
+	UUIDv7Strategy IDStrategy = uuidStrategy{generate: NewUUIDv7}
+	// StringIDStrategy 使用字符串 ID，新 ID 为 ObjectID 的十六进制形式
+	StringIDStrategy IDStrategy = stringIDStrategy{}
+)
+
+type objectIDStrategy struct{}
+
+func (objectIDStrategy) NewID() interface{} {
+	return primitive.NewObjectID()
+}
+
+func (objectIDStrategy) ParseID(s string) (interface{}, error) {
+	id, err := primitive.ObjectIDFromHex(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ObjectID %q", ErrInvalidID, s)
+	}
+	return id, nil
+}
+
+type uuidStrategy struct {
+	generate func() UUID
+}
+
+func (s uuidStrategy) NewID() interface{} {
+	return s.generate()
+}
+
+func (uuidStrategy) ParseID(s string) (interface{}, error) {
+	return ParseUUID(s)
+}
+
+type stringIDStrategy struct{}
+
+func (stringIDStrategy) NewID() interface{} {
+	return primitive.NewObjectID().Hex()
+}
+
+func (stringIDStrategy) ParseID(s string) (interface{}, error) {
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty id", ErrInvalidID)
+	}
+	return s, nil
+}
+
+// Identifiable 可以读写任意类型 ID 的文档，InsertOne 等操作通过该接口回填生成的 ID
+// BaseDocument、UUIDDocument 和 StringDocument 都实现了该接口
+type Identifiable interface {
+	GetDocumentID() interface{}
+	SetDocumentID(id interface{}) error
+}
+
+// isZeroID 判断 ID 是否为零值
+func isZeroID(id interface{}) bool {
+	switch v := id.(type) {
+	case nil:
+		return true
+	case primitive.ObjectID:
+		return v.IsZero()
+	case UUID:
+		return v.IsZero()
+	case string:
+		return v == ""
+	case int64:
+		return v == 0
+	}
+	return false
+}
+
+// WithIDStrategy 返回使用指定 ID 策略的集合副本
+// 插入 ID 为零值的 Identifiable 文档时由策略生成 ID，FindByID 等方法收到字符串 ID 时由策略转换
+func (c *Collection) WithIDStrategy(strategy IDStrategy) *Collection {
+	cp := *c
+	cp.idStrategy = strategy
+	return &cp
+}
+
+// IDStrategy 返回集合的 ID 策略，集合未设置时使用客户端的策略，都未设置时为 ObjectIDStrategy
+func (c *Collection) IDStrategy() IDStrategy {
+	if strategy := c.configuredIDStrategy(); strategy != nil {
+		return strategy
+	}
+	return ObjectIDStrategy
+}
+
+// configuredIDStrategy 返回集合或客户端上显式配置的 ID 策略
+func (c *Collection) configuredIDStrategy() IDStrategy {
+	if c.idStrategy != nil {
+		return c.idStrategy
+	}
+	if c.cli != nil {
+		return c.cli.idStrategy
+	}
+	return nil
+}
+
+// documentID 将 FindByID 等方法的 id 参数转换为存储类型，字符串通过 ID 策略解析，其它类型原样使用
+func (c *Collection) documentID(id interface{}) (interface{}, error) {
+	if s, ok := id.(string); ok {
+		return c.IDStrategy().ParseID(s)
+	}
+	return id, nil
+}
+
+// prepareInsert 为 ID 为零值的文档按集合或客户端配置的策略生成 ID，写入 default 标签的默认值，
+// 调用 BeforeInsert 钩子后校验文档
+func (c *Collection) prepareInsert(document interface{}) error {
+	strategy := c.configuredIDStrategy()
+	if doc, ok := document.(Identifiable); ok && strategy != nil && isZeroID(doc.GetDocumentID()) {
+		if err := doc.SetDocumentID(strategy.NewID()); err != nil {
+			return err
+		}
+	}
+	if err := ApplyDefaults(document); err != nil {
+		return err
+	}
+	if doc, ok := document.(interface{ BeforeInsert() }); ok {
+		doc.BeforeInsert()
+	}
+	return c.validate(document)
+}
+
+// setInsertedID 将插入后的 ID 回填到文档，只实现了 Document 的文档只回填 ObjectID
+func setInsertedID(document interface{}, id interface{}) error {
+	if id == nil {
+		return nil
+	}
+	if doc, ok := document.(Identifiable); ok {
+		return doc.SetDocumentID(id)
+	}
+	if doc, ok := document.(Document); ok {
+		objectID, ok := id.(primitive.ObjectID)
+		if !ok {
+			return fmt.Errorf("insertedID is not ObjectID")
+		}
+		doc.SetID(objectID)
+	}
+	return nil
+}
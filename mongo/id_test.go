@@ -0,0 +1,131 @@
+package mongo
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUUID(t *testing.T) {
+	u := NewUUIDv4()
+	assert.Equal(t, byte(0x40), u[6]&0xf0)
+	assert.Equal(t, byte(0x80), u[8]&0xc0)
+
+	parsed, err := ParseUUID(u.String())
+	require.NoError(t, err)
+	assert.Equal(t, u, parsed)
+
+	compact, err := ParseUUID("6ba7b8109dad11d180b400c04fd430c8")
+	require.NoError(t, err)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", compact.String())
+
+	for _, invalid := range []string{"", "6ba7b810-9dad-11d1-80b4", "6ba7b8109-dad-11d1-80b4-00c04fd430c8", "zba7b810-9dad-11d1-80b4-00c04fd430c8"} {
+		_, err := ParseUUID(invalid)
+		assert.ErrorIs(t, err, ErrInvalidID, invalid)
+	}
+
+	first := NewUUIDv7()
+	time.Sleep(2 * time.Millisecond)
+	second := NewUUIDv7()
+	assert.Equal(t, byte(0x70), first[6]&0xf0)
+	assert.Less(t, first.String(), second.String())
+}
+
+func TestUUIDEncoding(t *testing.T) {
+	type doc struct {
+		ID    UUID `bson:"_id,omitempty" json:"id"`
+		Other UUID `bson:"other"`
+	}
+	d := doc{ID: NewUUIDv4()}
+
+	data, err := bson.Marshal(d)
+	require.NoError(t, err)
+	raw := bson.Raw(data)
+	subtype, bytes := raw.Lookup("_id").Binary()
+	assert.Equal(t, bsontype.BinaryUUID, subtype)
+	assert.Equal(t, d.ID[:], bytes)
+
+	var decoded doc
+	require.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, d, decoded)
+
+	empty, err := bson.Marshal(doc{})
+	require.NoError(t, err)
+	_, err = bson.Raw(empty).LookupErr("_id")
+	assert.Error(t, err, "zero UUID is omitted")
+
+	text, err := json.Marshal(d)
+	require.NoError(t, err)
+	var fromJSON doc
+	require.NoError(t, json.Unmarshal(text, &fromJSON))
+	assert.Equal(t, d.ID, fromJSON.ID)
+}
+
+func TestIDStrategies(t *testing.T) {
+	oid := primitive.NewObjectID()
+	id, err := ObjectIDStrategy.ParseID(oid.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, oid, id)
+	_, err = ObjectIDStrategy.ParseID("nope")
+	assert.ErrorIs(t, err, ErrInvalidID)
+
+	assert.IsType(t, UUID{}, UUIDv7Strategy.NewID())
+	assert.IsType(t, "", StringIDStrategy.NewID())
+
+	c := &Collection{}
+	docID, err := c.documentID(oid.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, oid, docID)
+
+	u := NewUUIDv4()
+	docID, err = c.WithIDStrategy(UUIDv4Strategy).documentID(u.String())
+	require.NoError(t, err)
+	assert.Equal(t, u, docID)
+
+	docID, err = c.documentID(u)
+	require.NoError(t, err)
+	assert.Equal(t, u, docID)
+
+	ids, err := ParseIDs([]string{u.String()}, UUIDv7Strategy)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{u}, ids)
+	assert.Equal(t, u.String(), IDString(u))
+	assert.Equal(t, oid.Hex(), IDString(oid))
+}
+
+func TestPrepareInsertAndBackfill(t *testing.T) {
+	type account struct {
+		StringDocument `bson:",inline"`
+		Name           string `bson:"name"`
+	}
+
+	c := (&Collection{}).WithIDStrategy(UUIDv7Strategy)
+	uuidDoc := &UUIDDocument{}
+	require.NoError(t, c.prepareInsert(uuidDoc))
+	assert.False(t, uuidDoc.ID.IsZero())
+	assert.False(t, uuidDoc.CreatedAt.IsZero())
+
+	// 策略类型与文档 ID 类型不一致时返回错误
+	assert.Error(t, c.prepareInsert(&account{}))
+
+	acc := &account{}
+	require.NoError(t, (&Collection{}).prepareInsert(acc))
+	assert.Len(t, acc.ID, 24)
+
+	u := NewUUIDv4()
+	backfilled := &UUIDDocument{}
+	require.NoError(t, setInsertedID(backfilled, primitive.Binary{Subtype: bsontype.BinaryUUID, Data: u[:]}))
+	assert.Equal(t, u, backfilled.ID)
+
+	base := &BaseDocument{}
+	assert.Error(t, setInsertedID(base, "not-an-object-id"))
+	oid := primitive.NewObjectID()
+	require.NoError(t, setInsertedID(base, oid))
+	assert.Equal(t, oid, base.ID)
+}
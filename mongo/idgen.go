@@ -0,0 +1,217 @@
+package mongo
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IDGenerator 新文档 ID 生成器
+// 通过 Collection.WithIDStrategy 或 Config.IDStrategy 配置后，插入 ID 为零值的 Identifiable 文档时
+// 在 BeforeInsert 之前由生成器分配 ID；这里的生成器同时实现了 IDStrategy，可以直接作为 ID 策略使用
+type IDGenerator interface {
+	NewID() interface{}
+}
+
+// crockfordAlphabet ULID 使用的 Crockford Base32 字母表
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULIDGenerator 生成 26 位 ULID 字符串，前 48 位为毫秒时间戳，字典序即时间顺序
+// 同一毫秒内生成的 ID 在随机部分上递增，保证单个生成器生成的 ID 严格递增
+type ULIDGenerator struct {
+	mu     sync.Mutex
+	lastMs uint64
+	random [10]byte
+}
+
+// NewULIDGenerator 创建 ULID 生成器
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{}
+}
+
+// NewID 生成 ULID
+func (g *ULIDGenerator) NewID() interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.lastMs {
+		// 同一毫秒或时钟回拨时沿用上次的时间戳，随机部分加一
+		ms = g.lastMs
+		for i := len(g.random) - 1; i >= 0; i-- {
+			g.random[i]++
+			if g.random[i] != 0 {
+				break
+			}
+		}
+	} else {
+		readRandom(g.random[:])
+	}
+	g.lastMs = ms
+
+	var id [16]byte
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(id[:6], ts[2:])
+	copy(id[6:], g.random[:])
+	return encodeULID(id)
+}
+
+// ParseID 校验 ULID 格式并统一为大写
+func (g *ULIDGenerator) ParseID(s string) (interface{}, error) {
+	upper := strings.ToUpper(s)
+	if len(upper) != 26 || upper[0] > '7' {
+		return nil, fmt.Errorf("%w: invalid ULID %q", ErrInvalidID, s)
+	}
+	for i := 0; i < len(upper); i++ {
+		if strings.IndexByte(crockfordAlphabet, upper[i]) < 0 {
+			return nil, fmt.Errorf("%w: invalid ULID %q", ErrInvalidID, s)
+		}
+	}
+	return upper, nil
+}
+
+// encodeULID 将 128 位编码为 26 个 Crockford Base32 字符
+func encodeULID(id [16]byte) string {
+	n := new(big.Int).SetBytes(id[:])
+	base := big.NewInt(32)
+	mod := new(big.Int)
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = crockfordAlphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+// ksuidEpoch KSUID 时间戳的起始时间（2014-05-13）
+const ksuidEpoch = 1400000000
+
+// base62Alphabet KSUID 使用的 Base62 字母表，按 ASCII 顺序排列以保证字典序与数值顺序一致
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// KSUIDGenerator 生成 27 位 KSUID 字符串，由秒级时间戳和 128 位随机数组成，字典序即时间顺序（秒级）
+type KSUIDGenerator struct{}
+
+// NewKSUIDGenerator 创建 KSUID 生成器
+func NewKSUIDGenerator() *KSUIDGenerator {
+	return &KSUIDGenerator{}
+}
+
+// NewID 生成 KSUID
+func (g *KSUIDGenerator) NewID() interface{} {
+	var id [20]byte
+	binary.BigEndian.PutUint32(id[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	readRandom(id[4:])
+
+	n := new(big.Int).SetBytes(id[:])
+	base := big.NewInt(62)
+	mod := new(big.Int)
+	out := make([]byte, 27)
+	for i := len(out) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		out[i] = base62Alphabet[mod.Int64()]
+	}
+	return string(out)
+}
+
+// ParseID 校验 KSUID 格式
+func (g *KSUIDGenerator) ParseID(s string) (interface{}, error) {
+	if len(s) != 27 {
+		return nil, fmt.Errorf("%w: invalid KSUID %q", ErrInvalidID, s)
+	}
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(base62Alphabet, s[i]) < 0 {
+			return nil, fmt.Errorf("%w: invalid KSUID %q", ErrInvalidID, s)
+		}
+	}
+	// 27 位 Base62 的最大合法值为 aWgEPTl1tmebfsQzFP4bxwgy80V
+	if s > "aWgEPTl1tmebfsQzFP4bxwgy80V" {
+		return nil, fmt.Errorf("%w: invalid KSUID %q", ErrInvalidID, s)
+	}
+	return s, nil
+}
+
+// SnowflakeEpoch Snowflake ID 时间戳的起始时间
+var SnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// SnowflakeGenerator 生成 int64 Snowflake ID：41 位毫秒时间戳、10 位节点号、12 位序号
+// 每个节点每毫秒最多生成 4096 个 ID，多个进程同时写入时必须使用不同的节点号
+type SnowflakeGenerator struct {
+	node  int64
+	epoch int64
+
+	mu       sync.Mutex
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator 创建 Snowflake 生成器，node 取值范围 0-1023
+func NewSnowflakeGenerator(node int64) (*SnowflakeGenerator, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf("snowflake node must be between 0 and %d, got %d", snowflakeMaxNode, node)
+	}
+	return &SnowflakeGenerator{
+		node:  node,
+		epoch: SnowflakeEpoch.UnixMilli(),
+	}, nil
+}
+
+// NewID 生成 Snowflake ID
+func (g *SnowflakeGenerator) NewID() interface{} {
+	return g.Next()
+}
+
+// Next 生成 int64 类型的 Snowflake ID
+func (g *SnowflakeGenerator) Next() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := time.Now().UnixMilli() - g.epoch
+	if ms < g.lastMs {
+		// 时钟回拨时沿用上次的时间戳，避免生成重复 ID
+		ms = g.lastMs
+	}
+	if ms == g.lastMs {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSequence
+		if g.sequence == 0 {
+			// 当前毫秒的序号用完，等待下一毫秒
+			for ms <= g.lastMs {
+				time.Sleep(100 * time.Microsecond)
+				ms = time.Now().UnixMilli() - g.epoch
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastMs = ms
+	return ms<<(snowflakeNodeBits+snowflakeSequenceBits) | g.node<<snowflakeSequenceBits | g.sequence
+}
+
+// ParseID 将十进制字符串转换为 int64
+func (g *SnowflakeGenerator) ParseID(s string) (interface{}, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("%w: invalid snowflake id %q", ErrInvalidID, s)
+	}
+	return id, nil
+}
+
+// readRandom 读取加密随机数
+func readRandom(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Errorf("failed to read random bytes: %w", err))
+	}
+}
@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestULIDGenerator(t *testing.T) {
+	g := NewULIDGenerator()
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = g.NewID().(string)
+		assert.Len(t, ids[i], 26)
+	}
+	assert.True(t, sort.StringsAreSorted(ids), "ULIDs are strictly increasing")
+	assert.NotEqual(t, ids[0], ids[1])
+
+	parsed, err := g.ParseID(ids[0][:10] + "abcdefghjkmnpqrs")
+	require.NoError(t, err)
+	assert.Equal(t, ids[0][:10]+"ABCDEFGHJKMNPQRS", parsed)
+	for _, invalid := range []string{"", "8ZZZZZZZZZZZZZZZZZZZZZZZZZ", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		_, err := g.ParseID(invalid)
+		assert.ErrorIs(t, err, ErrInvalidID, invalid)
+	}
+}
+
+func TestKSUIDGenerator(t *testing.T) {
+	g := NewKSUIDGenerator()
+	id := g.NewID().(string)
+	assert.Len(t, id, 27)
+
+	parsed, err := g.ParseID(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, parsed)
+	for _, invalid := range []string{"", "short", "zzzzzzzzzzzzzzzzzzzzzzzzzzz", "0ujtsYcgvSTl8PAuAdqWYSMnLO!"} {
+		_, err := g.ParseID(invalid)
+		assert.ErrorIs(t, err, ErrInvalidID, invalid)
+	}
+}
+
+func TestSnowflakeGenerator(t *testing.T) {
+	_, err := NewSnowflakeGenerator(1024)
+	assert.Error(t, err)
+
+	g, err := NewSnowflakeGenerator(7)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last int64
+			for i := 0; i < 5000; i++ {
+				id := g.Next()
+				assert.Greater(t, id, last)
+				last = id
+				mu.Lock()
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, seen, 20000)
+
+	id := g.Next()
+	assert.Equal(t, int64(7), (id>>snowflakeSequenceBits)&snowflakeMaxNode)
+
+	parsed, err := g.ParseID("123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), parsed)
+	_, err = g.ParseID("-1")
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
+
+func TestClientIDStrategy(t *testing.T) {
+	type order struct {
+		StringDocument `bson:",inline"`
+	}
+
+	c := &Collection{cli: &Client{idStrategy: NewULIDGenerator()}}
+	doc := &order{}
+	require.NoError(t, c.prepareInsert(doc))
+	assert.Len(t, doc.ID, 26)
+
+	// 集合上的策略优先于客户端
+	doc = &order{}
+	require.NoError(t, c.WithIDStrategy(NewKSUIDGenerator()).prepareInsert(doc))
+	assert.Len(t, doc.ID, 27)
+
+	_, isULID := c.IDStrategy().(*ULIDGenerator)
+	assert.True(t, isULID)
+}
@@ -3,7 +3,6 @@ package mongo
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,13 +11,17 @@ import (
 
 // IndexManager 索引管理器
 type IndexManager struct {
+	client     *Client
 	collection *mongo.Collection
+	logger     Logger
 }
 
 // NewIndexManager 创建新的索引管理器
 func NewIndexManager(client *Client, collectionName string) *IndexManager {
 	return &IndexManager{
+		client:     client,
 		collection: client.GetCollection(collectionName),
+		logger:     client.logger,
 	}
 }
 
@@ -28,6 +31,9 @@ func NewIndexManager(client *Client, collectionName string) *IndexManager {
 // 稀疏索引：opts.SetSparse(true)
 // TTL索引：opts.SetExpireAfterSeconds(int32(expireAfter.Seconds()))
 func (im *IndexManager) CreateIndex(ctx context.Context, keys bson.D, opts *options.IndexOptions) (string, error) {
+	if err := im.client.checkWritable("createIndexes", im.collection.Name()); err != nil {
+		return "", err
+	}
 	indexModel := mongo.IndexModel{
 		Keys:    keys,
 		Options: opts,
@@ -38,18 +44,21 @@ func (im *IndexManager) CreateIndex(ctx context.Context, keys bson.D, opts *opti
 		return "", fmt.Errorf("failed to create index: %w", err)
 	}
 
-	log.Printf("Created index: %s", name)
+	im.logger.InfoContext(ctx, "Created index", "collection", im.collection.Name(), "name", name)
 	return name, nil
 }
 
 // CreateIndexes 创建多个索引
 func (im *IndexManager) CreateIndexes(ctx context.Context, indexModels []mongo.IndexModel) ([]string, error) {
+	if err := im.client.checkWritable("createIndexes", im.collection.Name()); err != nil {
+		return nil, err
+	}
 	names, err := im.collection.Indexes().CreateMany(ctx, indexModels)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create indexes: %w", err)
 	}
 
-	log.Printf("Created indexes: %v", names)
+	im.logger.InfoContext(ctx, "Created indexes", "collection", im.collection.Name(), "names", names)
 	return names, nil
 }
 
@@ -67,6 +76,17 @@ func (im *IndexManager) CreateTextIndex(ctx context.Context, fields []string, op
 	return im.CreateIndex(ctx, keys, opts)
 }
 
+// CreateGeoIndex 为 GeoJSON 字段创建 2dsphere 索引，$near、$geoNear 查询必须有该索引
+func (im *IndexManager) CreateGeoIndex(ctx context.Context, field string, opts *options.IndexOptions) (string, error) {
+	keys := bson.D{{Key: field, Value: "2dsphere"}}
+
+	if opts == nil {
+		opts = options.Index()
+	}
+
+	return im.CreateIndex(ctx, keys, opts)
+}
+
 // CreatePartialIndex 创建部分索引
 func (im *IndexManager) CreatePartialIndex(ctx context.Context, field string, filter bson.M, opts *options.IndexOptions) (string, error) {
 	keys := bson.D{{Key: field, Value: 1}}
@@ -188,23 +208,29 @@ func (ci *CommonIndexes) CreateArticleIndexes(ctx context.Context) error {
 
 // DropIndex 删除索引
 func (im *IndexManager) DropIndex(ctx context.Context, name string) error {
+	if err := im.client.checkWritable("dropIndexes", im.collection.Name()); err != nil {
+		return err
+	}
 	_, err := im.collection.Indexes().DropOne(ctx, name)
 	if err != nil {
 		return fmt.Errorf("failed to drop index %s: %w", name, err)
 	}
 
-	log.Printf("Dropped index: %s", name)
+	im.logger.InfoContext(ctx, "Dropped index", "collection", im.collection.Name(), "name", name)
 	return nil
 }
 
 // DropAllIndexes 删除所有索引（除了_id索引）
 func (im *IndexManager) DropAllIndexes(ctx context.Context) error {
+	if err := im.client.checkWritable("dropIndexes", im.collection.Name()); err != nil {
+		return err
+	}
 	_, err := im.collection.Indexes().DropAll(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to drop all indexes: %w", err)
 	}
 
-	log.Println("Dropped all indexes")
+	im.logger.InfoContext(ctx, "Dropped all indexes", "collection", im.collection.Name())
 	return nil
 }
 
@@ -0,0 +1,340 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/yaml.v3"
+)
+
+// IndexSpec 声明式的索引定义，用于索引文件和 IndexManager.Diff
+type IndexSpec struct {
+	// Name 索引名，为空时使用 MongoDB 的默认名称（例如 status_1_created_at_-1），比较时不检查名称
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Keys 索引键，格式与 ParseIndexKeys 相同，例如 "status,-created_at" 或 "title:text,content:text"
+	Keys string `json:"keys" yaml:"keys"`
+	// Unique 唯一索引
+	Unique bool `json:"unique,omitempty" yaml:"unique,omitempty"`
+	// Sparse 稀疏索引
+	Sparse bool `json:"sparse,omitempty" yaml:"sparse,omitempty"`
+	// ExpireAfterSeconds TTL 索引的过期秒数
+	ExpireAfterSeconds *int32 `json:"expire_after_seconds,omitempty" yaml:"expire_after_seconds,omitempty"`
+	// PartialFilter 部分索引的过滤条件
+	PartialFilter map[string]interface{} `json:"partial_filter,omitempty" yaml:"partial_filter,omitempty"`
+}
+
+// ParseIndexKeys 解析 "status,-created_at,location:2dsphere" 形式的索引键，
+// - 表示降序，字段:类型 表示 text、2dsphere、hashed 等特殊索引
+func ParseIndexKeys(s string) (bson.D, error) {
+	var keys bson.D
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if field, kind, ok := strings.Cut(part, ":"); ok {
+			if field == "" || kind == "" {
+				return nil, fmt.Errorf("invalid index key %q", part)
+			}
+			keys = append(keys, bson.E{Key: field, Value: kind})
+			continue
+		}
+		sortKeys, err := ParseSort(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index key %q", part)
+		}
+		keys = append(keys, sortKeys...)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("index keys are empty")
+	}
+	return keys, nil
+}
+
+// Model 转换为驱动的 IndexModel
+func (s IndexSpec) Model() (mongo.IndexModel, error) {
+	keys, err := ParseIndexKeys(s.Keys)
+	if err != nil {
+		return mongo.IndexModel{}, err
+	}
+	opts := options.Index()
+	if s.Name != "" {
+		opts.SetName(s.Name)
+	}
+	if s.Unique {
+		opts.SetUnique(true)
+	}
+	if s.Sparse {
+		opts.SetSparse(true)
+	}
+	if s.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*s.ExpireAfterSeconds)
+	}
+	if len(s.PartialFilter) > 0 {
+		opts.SetPartialFilterExpression(bson.M(s.PartialFilter))
+	}
+	return mongo.IndexModel{Keys: keys, Options: opts}, nil
+}
+
+// LoadIndexSpecs 读取 JSON 或 YAML 索引文件，顶层为 集合名 → 索引列表
+//
+//	users:
+//	  - name: idx_email
+//	    keys: email
+//	    unique: true
+//	  - keys: status,-created_at
+//	sessions:
+//	  - keys: expires_at
+//	    expire_after_seconds: 0
+func LoadIndexSpecs(path string) (map[string][]IndexSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index file: %w", err)
+	}
+	var specs map[string][]IndexSpec
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &specs)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &specs)
+	default:
+		return nil, fmt.Errorf("unsupported index file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse index file %s: %w", path, err)
+	}
+	for collection, list := range specs {
+		for i, spec := range list {
+			if _, err := ParseIndexKeys(spec.Keys); err != nil {
+				return nil, fmt.Errorf("invalid index %s[%d]: %w", collection, i, err)
+			}
+		}
+	}
+	return specs, nil
+}
+
+// IndexInfo 集合上已有的索引
+type IndexInfo struct {
+	Name                    string `bson:"name"`
+	Key                     bson.D `bson:"key"`
+	Unique                  bool   `bson:"unique,omitempty"`
+	Sparse                  bool   `bson:"sparse,omitempty"`
+	ExpireAfterSeconds      *int32 `bson:"expireAfterSeconds,omitempty"`
+	PartialFilterExpression bson.M `bson:"partialFilterExpression,omitempty"`
+}
+
+// KeyString 以 ParseIndexKeys 的格式返回索引键
+func (i IndexInfo) KeyString() string {
+	parts := make([]string, 0, len(i.Key))
+	for _, e := range i.Key {
+		switch v := e.Value.(type) {
+		case string:
+			parts = append(parts, e.Key+":"+v)
+		default:
+			if indexDirection(v) < 0 {
+				parts = append(parts, "-"+e.Key)
+			} else {
+				parts = append(parts, e.Key)
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// indexDirection 索引键方向可能是 int32、int64 或 float64
+func indexDirection(v interface{}) int {
+	switch n := v.(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+// Indexes 列出集合上的索引，索引键保持定义顺序
+func (im *IndexManager) Indexes(ctx context.Context) ([]IndexInfo, error) {
+	cursor, err := im.collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	var indexes []IndexInfo
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, fmt.Errorf("failed to decode indexes: %w", err)
+	}
+	return indexes, nil
+}
+
+// IndexChange 定义与已有索引键相同但选项不同的索引，需要删除后重建
+type IndexChange struct {
+	Spec     IndexSpec
+	Existing IndexInfo
+	// Reason 不一致的选项，例如 unique、expire_after_seconds
+	Reason string
+}
+
+// IndexDiff 索引定义与集合上实际索引的差异
+type IndexDiff struct {
+	// Missing 定义了但不存在的索引
+	Missing []IndexSpec
+	// Extra 存在但没有定义的索引，不包括 _id 索引
+	Extra []IndexInfo
+	// Changed 索引键相同但选项不同的索引
+	Changed []IndexChange
+}
+
+// Empty 没有任何差异
+func (d *IndexDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// Diff 比较索引定义与集合上的实际索引，按索引键匹配
+func (im *IndexManager) Diff(ctx context.Context, specs []IndexSpec) (*IndexDiff, error) {
+	existing, err := im.Indexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return diffIndexes(specs, existing)
+}
+
+func diffIndexes(specs []IndexSpec, existing []IndexInfo) (*IndexDiff, error) {
+	diff := &IndexDiff{}
+	matched := make(map[string]bool)
+	for _, spec := range specs {
+		keys, err := ParseIndexKeys(spec.Keys)
+		if err != nil {
+			return nil, err
+		}
+		spec.Keys = IndexInfo{Key: keys}.KeyString()
+		var found *IndexInfo
+		for i := range existing {
+			if existing[i].KeyString() == spec.Keys {
+				found = &existing[i]
+				break
+			}
+		}
+		if found == nil {
+			diff.Missing = append(diff.Missing, spec)
+			continue
+		}
+		matched[found.Name] = true
+		if reason := indexOptionDiff(spec, *found); reason != "" {
+			diff.Changed = append(diff.Changed, IndexChange{Spec: spec, Existing: *found, Reason: reason})
+		}
+	}
+	for _, index := range existing {
+		if index.Name != "_id_" && !matched[index.Name] {
+			diff.Extra = append(diff.Extra, index)
+		}
+	}
+	return diff, nil
+}
+
+// indexOptionDiff 返回不一致的选项名，一致时返回空字符串
+func indexOptionDiff(spec IndexSpec, index IndexInfo) string {
+	var reasons []string
+	if spec.Name != "" && spec.Name != index.Name {
+		reasons = append(reasons, "name")
+	}
+	if spec.Unique != index.Unique {
+		reasons = append(reasons, "unique")
+	}
+	if spec.Sparse != index.Sparse {
+		reasons = append(reasons, "sparse")
+	}
+	if (spec.ExpireAfterSeconds == nil) != (index.ExpireAfterSeconds == nil) ||
+		(spec.ExpireAfterSeconds != nil && *spec.ExpireAfterSeconds != *index.ExpireAfterSeconds) {
+		reasons = append(reasons, "expire_after_seconds")
+	}
+	if len(spec.PartialFilter) > 0 || len(index.PartialFilterExpression) > 0 {
+		want, _ := toBsonM(spec.PartialFilter)
+		got, _ := toBsonM(index.PartialFilterExpression)
+		if !reflect.DeepEqual(want, got) {
+			reasons = append(reasons, "partial_filter")
+		}
+	}
+	return strings.Join(reasons, ",")
+}
+
+// ApplySpecs 创建定义中缺少的索引，返回创建的索引名；选项不一致的索引不会自动重建，需要先通过 Diff 确认后删除
+func (im *IndexManager) ApplySpecs(ctx context.Context, specs []IndexSpec) ([]string, error) {
+	diff, err := im.Diff(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+	if len(diff.Missing) == 0 {
+		return nil, nil
+	}
+	models := make([]mongo.IndexModel, 0, len(diff.Missing))
+	for _, spec := range diff.Missing {
+		model, err := spec.Model()
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, model)
+	}
+	return im.CreateIndexes(ctx, models)
+}
+
+// IndexUsage $indexStats 返回的索引使用情况
+type IndexUsage struct {
+	Name string
+	// Ops 自 Since 以来索引被使用的次数，只统计当前连接的节点
+	Ops int64
+	// Since 统计开始的时间，即索引创建或节点重启的时间
+	Since time.Time
+}
+
+// UnusedIndexes 返回统计时间超过 minAge 且从未被使用的索引，不包括 _id 索引和唯一索引
+// 统计只来自当前连接的节点，副本集上应当分别检查各节点（例如通过 secondary 读偏好）后再删除
+func (im *IndexManager) UnusedIndexes(ctx context.Context, minAge time.Duration) ([]IndexUsage, error) {
+	stats, err := im.GetIndexStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := im.Indexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return unusedIndexes(stats, indexes, minAge, time.Now()), nil
+}
+
+func unusedIndexes(stats []bson.M, indexes []IndexInfo, minAge time.Duration, now time.Time) []IndexUsage {
+	unique := make(map[string]bool)
+	for _, index := range indexes {
+		unique[index.Name] = index.Unique
+	}
+
+	var unused []IndexUsage
+	for _, stat := range stats {
+		name, _ := stat["name"].(string)
+		if name == "" || name == "_id_" || unique[name] {
+			continue
+		}
+		accesses, _ := stat["accesses"].(bson.M)
+		usage := IndexUsage{Name: name, Ops: int64(indexDirection(accesses["ops"]))}
+		if since, ok := accesses["since"].(primitive.DateTime); ok {
+			usage.Since = since.Time()
+		}
+		if usage.Ops == 0 && now.Sub(usage.Since) >= minAge {
+			unused = append(unused, usage)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool { return unused[i].Name < unused[j].Name })
+	return unused
+}
@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseIndexKeys(t *testing.T) {
+	keys, err := ParseIndexKeys("status, -created_at,location:2dsphere")
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "location", Value: "2dsphere"}}, keys)
+
+	for _, bad := range []string{"", " , ", ":text", "title:"} {
+		_, err := ParseIndexKeys(bad)
+		assert.Error(t, err, bad)
+	}
+
+	info := IndexInfo{Key: bson.D{{Key: "a", Value: int32(1)}, {Key: "b", Value: float64(-1)}, {Key: "c", Value: "text"}}}
+	assert.Equal(t, "a,-b,c:text", info.KeyString())
+}
+
+func TestLoadIndexSpecs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "indexes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+users:
+  - name: idx_email
+    keys: email
+    unique: true
+  - keys: status,-created_at
+    partial_filter: {deleted: false}
+sessions:
+  - keys: expires_at
+    expire_after_seconds: 0
+`), 0o644))
+
+	specs, err := LoadIndexSpecs(path)
+	require.NoError(t, err)
+	require.Len(t, specs["users"], 2)
+	assert.Equal(t, "idx_email", specs["users"][0].Name)
+	assert.True(t, specs["users"][0].Unique)
+	assert.Equal(t, map[string]interface{}{"deleted": false}, specs["users"][1].PartialFilter)
+	require.NotNil(t, specs["sessions"][0].ExpireAfterSeconds)
+	assert.Zero(t, *specs["sessions"][0].ExpireAfterSeconds)
+
+	model, err := specs["users"][0].Model()
+	require.NoError(t, err)
+	assert.Equal(t, "idx_email", *model.Options.Name)
+	assert.True(t, *model.Options.Unique)
+
+	bad := filepath.Join(t.TempDir(), "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"users": [{"keys": ""}]}`), 0o644))
+	_, err = LoadIndexSpecs(bad)
+	assert.ErrorContains(t, err, "users[0]")
+
+	_, err = LoadIndexSpecs(filepath.Join(t.TempDir(), "indexes.toml"))
+	assert.Error(t, err)
+}
+
+func TestDiffIndexes(t *testing.T) {
+	ttl := int32(3600)
+	existing := []IndexInfo{
+		{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}},
+		{Name: "idx_email", Key: bson.D{{Key: "email", Value: int32(1)}}, Unique: true},
+		{Name: "status_1_created_at_-1", Key: bson.D{{Key: "status", Value: int32(1)}, {Key: "created_at", Value: int32(-1)}},
+			PartialFilterExpression: bson.M{"deleted": false}},
+		{Name: "expires_at_1", Key: bson.D{{Key: "expires_at", Value: int32(1)}}, ExpireAfterSeconds: &ttl},
+		{Name: "legacy_1", Key: bson.D{{Key: "legacy", Value: int32(1)}}},
+	}
+	specs := []IndexSpec{
+		{Name: "idx_email", Keys: "email", Unique: true},
+		{Keys: "status, -created_at", PartialFilter: map[string]interface{}{"deleted": false}},
+		{Keys: "expires_at"},
+		{Keys: "title:text"},
+	}
+
+	diff, err := diffIndexes(specs, existing)
+	require.NoError(t, err)
+	assert.False(t, diff.Empty())
+	require.Len(t, diff.Missing, 1)
+	assert.Equal(t, "title:text", diff.Missing[0].Keys)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "expires_at_1", diff.Changed[0].Existing.Name)
+	assert.Equal(t, "expire_after_seconds", diff.Changed[0].Reason)
+	require.Len(t, diff.Extra, 1)
+	assert.Equal(t, "legacy_1", diff.Extra[0].Name)
+
+	diff, err = diffIndexes(nil, existing[:1])
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
+
+func TestUnusedIndexes(t *testing.T) {
+	now := time.Now()
+	since := func(d time.Duration) primitive.DateTime { return primitive.NewDateTimeFromTime(now.Add(-d)) }
+	stats := []bson.M{
+		{"name": "_id_", "accesses": bson.M{"ops": int64(0), "since": since(48 * time.Hour)}},
+		{"name": "idx_email", "accesses": bson.M{"ops": int64(0), "since": since(48 * time.Hour)}},
+		{"name": "status_1", "accesses": bson.M{"ops": int64(0), "since": since(48 * time.Hour)}},
+		{"name": "legacy_1", "accesses": bson.M{"ops": int64(0), "since": since(48 * time.Hour)}},
+		{"name": "recent_1", "accesses": bson.M{"ops": int64(0), "since": since(time.Hour)}},
+		{"name": "used_1", "accesses": bson.M{"ops": int64(12), "since": since(48 * time.Hour)}},
+	}
+	indexes := []IndexInfo{{Name: "idx_email", Unique: true}}
+
+	unused := unusedIndexes(stats, indexes, 24*time.Hour, now)
+	require.Len(t, unused, 2)
+	assert.Equal(t, "legacy_1", unused[0].Name)
+	assert.Equal(t, "status_1", unused[1].Name)
+}
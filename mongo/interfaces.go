@@ -0,0 +1,74 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Reader 集合的查询操作，业务代码依赖该接口时可以在测试中替换为 MockCollection
+type Reader interface {
+	FindOne(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error
+	FindByID(ctx context.Context, id interface{}, result interface{}, opts ...*options.FindOneOptions) error
+	Find(ctx context.Context, filter bson.M, results interface{}, opts ...*options.FindOptions) error
+	FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, results interface{}, opts ...*options.FindOptions) (*PaginationResult, error)
+	Count(ctx context.Context, filter bson.M) (int64, error)
+	Exists(ctx context.Context, filter bson.M) (bool, error)
+	Distinct(ctx context.Context, field string, filter bson.M, opts ...*options.DistinctOptions) ([]interface{}, error)
+}
+
+// Writer 集合的写操作
+type Writer interface {
+	InsertOne(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error)
+	InsertMany(ctx context.Context, documents []interface{}) (*mongo.InsertManyResult, error)
+	UpdateOne(ctx context.Context, filter bson.M, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateMany(ctx context.Context, filter bson.M, update bson.M, opts ...*MassWriteOptions) (*mongo.UpdateResult, error)
+	Upsert(ctx context.Context, filter bson.M, document interface{}) (*mongo.UpdateResult, error)
+	ReplaceOne(ctx context.Context, filter bson.M, replacement interface{}) (*mongo.UpdateResult, error)
+	DeleteOne(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error)
+	DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error)
+	DeleteMany(ctx context.Context, filter bson.M, opts ...*MassWriteOptions) (*mongo.DeleteResult, error)
+}
+
+// Aggregator 集合的聚合操作
+type Aggregator interface {
+	Aggregate(ctx context.Context, pipeline []bson.M, results interface{}, opts ...*options.AggregateOptions) error
+}
+
+// CollectionAPI Collection 的常用操作集合，需要同时读写的服务可以依赖该接口
+type CollectionAPI interface {
+	Reader
+	Writer
+	Aggregator
+}
+
+// RepositoryReader Repository 的查询操作
+type RepositoryReader[T any] interface {
+	FindByID(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*T, error)
+	FindOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*T, error)
+	Find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error)
+	FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, opts ...*options.FindOptions) ([]T, *PaginationResult, error)
+	Count(ctx context.Context, filter bson.M) (int64, error)
+}
+
+// RepositoryWriter Repository 的写操作
+type RepositoryWriter[T any] interface {
+	Insert(ctx context.Context, doc *T) (*mongo.InsertOneResult, error)
+	UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateChanged(ctx context.Context, original, modified *T) (*mongo.UpdateResult, error)
+	DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error)
+}
+
+// RepositoryAPI Repository 的读写操作，测试中可以替换为 MockRepository
+type RepositoryAPI[T any] interface {
+	RepositoryReader[T]
+	RepositoryWriter[T]
+}
+
+var (
+	_ CollectionAPI       = (*Collection)(nil)
+	_ RepositoryAPI[User] = (*Repository[User])(nil)
+)
@@ -0,0 +1,173 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvalidationFunc 收到集合变更事件时调用的缓存失效回调
+type InvalidationFunc func(ctx context.Context, event *ChangeEvent) error
+
+// InvalidatorOptions 缓存失效监听配置
+type InvalidatorOptions struct {
+	// Name 监听器名称，用于日志，默认 cache-invalidator
+	Name string
+	// Checkpoints 恢复令牌存储，默认只保存在内存中：变更流中断后从上次处理的事件之后继续，进程重启后从当前时间开始。
+	// 本地缓存在进程重启后为空，通常不需要持久化恢复令牌
+	Checkpoints CheckpointStore
+}
+
+// InvalidatorMetrics 缓存失效统计
+type InvalidatorMetrics struct {
+	// Events 收到的变更事件数
+	Events int64 `json:"events"`
+	// Failed 回调返回错误的次数
+	Failed int64 `json:"failed"`
+}
+
+// CacheInvalidator 通过一个数据库级变更流监听多个集合，文档变更时调用注册的回调或删除缓存键，
+// 使多实例部署中各实例的本地缓存保持一致；回调失败只记录日志，不会阻塞后续事件，旧数据最终由缓存 TTL 清理
+//
+//	invalidator := NewCacheInvalidator(client, nil)
+//	invalidator.RegisterCache(cachedUsers)
+//	invalidator.InvalidateKeys("settings", localCache, func(event *ChangeEvent) []string {
+//		return []string{"settings:" + IDString(event.DocumentKey["_id"])}
+//	})
+//	invalidator.Start(ctx)
+//	defer invalidator.Stop()
+type CacheInvalidator struct {
+	client *Client
+	opts   InvalidatorOptions
+
+	mu       sync.RWMutex
+	handlers map[string][]InvalidationFunc
+
+	events atomic.Int64
+	failed atomic.Int64
+
+	forwarder *ChangeStreamForwarder
+}
+
+// NewCacheInvalidator 创建缓存失效监听器，需要在 Start 之前注册回调
+func NewCacheInvalidator(client *Client, opts *InvalidatorOptions) *CacheInvalidator {
+	i := &CacheInvalidator{
+		client:   client,
+		handlers: make(map[string][]InvalidationFunc),
+	}
+	if opts != nil {
+		i.opts = *opts
+	}
+	if i.opts.Name == "" {
+		i.opts.Name = "cache-invalidator"
+	}
+	if i.opts.Checkpoints == nil {
+		i.opts.Checkpoints = &localCheckpointStore{}
+	}
+	return i
+}
+
+// On 注册集合的失效回调，同一集合可以注册多个回调，按注册顺序调用
+func (i *CacheInvalidator) On(collection string, fn InvalidationFunc) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.handlers[collection] = append(i.handlers[collection], fn)
+}
+
+// InvalidateKeys 集合中的文档变更时从 cache 中删除 keys 返回的缓存键
+func (i *CacheInvalidator) InvalidateKeys(collection string, cache Cache, keys func(event *ChangeEvent) []string) {
+	i.On(collection, func(ctx context.Context, event *ChangeEvent) error {
+		if k := keys(event); len(k) > 0 {
+			return cache.Delete(ctx, k...)
+		}
+		return nil
+	})
+}
+
+// RegisterCache 按变更事件使 CachedCollection 的缓存失效
+func (i *CacheInvalidator) RegisterCache(c *CachedCollection) {
+	i.On(c.collection.collection.Name(), c.invalidateEvent)
+}
+
+// Collections 返回已注册回调的集合，按名称排序
+func (i *CacheInvalidator) Collections() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	names := make([]string, 0, len(i.handlers))
+	for name := range i.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Metrics 返回缓存失效统计
+func (i *CacheInvalidator) Metrics() InvalidatorMetrics {
+	return InvalidatorMetrics{Events: i.events.Load(), Failed: i.failed.Load()}
+}
+
+// Start 在后台监听已注册集合的变更流
+func (i *CacheInvalidator) Start(ctx context.Context) error {
+	if i.forwarder != nil {
+		return nil
+	}
+	collections := i.Collections()
+	if len(collections) == 0 {
+		return fmt.Errorf("cache invalidator %s has no collections registered", i.opts.Name)
+	}
+	forwarder, err := NewChangeStreamForwarder(i.client, "", SinkFunc(i.dispatch), i.opts.Checkpoints, ForwarderOptions{
+		Name:         i.opts.Name,
+		Pipeline:     invalidationPipeline(collections),
+		FullDocument: options.Default,
+	})
+	if err != nil {
+		return err
+	}
+	i.forwarder = forwarder
+	forwarder.Start(ctx)
+	return nil
+}
+
+// Stop 停止监听
+func (i *CacheInvalidator) Stop() {
+	if i.forwarder != nil {
+		i.forwarder.Stop()
+	}
+}
+
+// invalidationPipeline 只接收已注册集合的事件以及删除数据库事件
+func invalidationPipeline(collections []string) []bson.M {
+	return []bson.M{{"$match": bson.M{"$or": []bson.M{
+		{"ns.coll": bson.M{"$in": collections}},
+		{"operationType": "dropDatabase"},
+	}}}}
+}
+
+// dispatch 将事件分发给集合的回调，删除数据库事件分发给所有回调
+func (i *CacheInvalidator) dispatch(ctx context.Context, event *ChangeEvent) error {
+	i.events.Add(1)
+	i.mu.RLock()
+	var handlers []InvalidationFunc
+	if event.OperationType == "dropDatabase" {
+		for _, fns := range i.handlers {
+			handlers = append(handlers, fns...)
+		}
+	} else {
+		handlers = append(handlers, i.handlers[event.Namespace.Collection]...)
+	}
+	i.mu.RUnlock()
+
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			i.failed.Add(1)
+			i.client.logger.WarnContext(ctx, "Cache invalidation failed", "invalidator", i.opts.Name,
+				"collection", event.Namespace.Collection, "operation", event.OperationType, "err", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCacheInvalidatorDispatch(t *testing.T) {
+	ctx := t.Context()
+	invalidator := NewCacheInvalidator(newLazyClient(t), nil)
+	assert.Error(t, invalidator.Start(ctx))
+
+	cache := NewLRUCache(0)
+	require.NoError(t, cache.Set(ctx, "settings:site", []byte("x"), time.Minute))
+	invalidator.InvalidateKeys("settings", cache, func(event *ChangeEvent) []string {
+		return []string{"settings:" + IDString(event.DocumentKey["_id"])}
+	})
+	var calls []string
+	invalidator.On("users", func(ctx context.Context, event *ChangeEvent) error {
+		calls = append(calls, event.OperationType)
+		return errors.New("boom")
+	})
+	assert.Equal(t, []string{"settings", "users"}, invalidator.Collections())
+
+	require.NoError(t, invalidator.dispatch(ctx, &ChangeEvent{
+		OperationType: "update",
+		Namespace:     ChangeNamespace{Collection: "settings"},
+		DocumentKey:   bson.M{"_id": "site"},
+	}))
+	_, ok, _ := cache.Get(ctx, "settings:site")
+	assert.False(t, ok)
+
+	// 回调失败不阻塞变更流
+	require.NoError(t, invalidator.dispatch(ctx, &ChangeEvent{OperationType: "delete", Namespace: ChangeNamespace{Collection: "users"}}))
+	require.NoError(t, invalidator.dispatch(ctx, &ChangeEvent{OperationType: "insert", Namespace: ChangeNamespace{Collection: "orders"}}))
+	require.NoError(t, invalidator.dispatch(ctx, &ChangeEvent{OperationType: "dropDatabase"}))
+	assert.Equal(t, []string{"delete", "dropDatabase"}, calls)
+	assert.Equal(t, InvalidatorMetrics{Events: 4, Failed: 2}, invalidator.Metrics())
+}
+
+func TestCacheInvalidatorRegisterCache(t *testing.T) {
+	client := newLazyClient(t)
+	cached := NewCachedCollection(NewCollection(client, "users"), NewLRUCache(0), nil)
+	invalidator := NewCacheInvalidator(client, nil)
+	invalidator.RegisterCache(cached)
+
+	before, err := cached.queryKey(bson.M{})
+	require.NoError(t, err)
+	require.NoError(t, invalidator.dispatch(t.Context(), &ChangeEvent{OperationType: "insert", Namespace: ChangeNamespace{Collection: "users"}}))
+	after, err := cached.queryKey(bson.M{})
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+
+	assert.Equal(t, []bson.M{{"$match": bson.M{"$or": []bson.M{
+		{"ns.coll": bson.M{"$in": []string{"users"}}},
+		{"operationType": "dropDatabase"},
+	}}}}, invalidationPipeline(invalidator.Collections()))
+}
@@ -0,0 +1,133 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrLimiterTimeout 在排队时间内没有获得执行许可
+var ErrLimiterTimeout = errors.New("concurrency limit queue timeout")
+
+// LimiterOptions 并发限制配置
+type LimiterOptions struct {
+	// MaxConcurrent 同时执行的最大操作数，默认 10
+	MaxConcurrent int
+	// QueueTimeout 等待执行许可的最长时间，超时返回 ErrLimiterTimeout，默认 1 秒；
+	// ctx 的 deadline 更早时以 ctx 为准
+	QueueTimeout time.Duration
+}
+
+// LimiterMetrics 并发限制统计
+type LimiterMetrics struct {
+	// InFlight 正在执行的操作数
+	InFlight int64 `json:"in_flight"`
+	// Waiting 正在排队的操作数
+	Waiting int64 `json:"waiting"`
+	// Acquired 获得许可的操作总数
+	Acquired int64 `json:"acquired"`
+	// Rejected 排队超时或 ctx 取消而放弃的操作总数
+	Rejected int64 `json:"rejected"`
+}
+
+// Limiter 限制同时执行的数据库操作数（舱壁隔离），避免单个热点接口占满整个连接池
+// 通过 Collection.WithLimiter 按集合配置，或通过 Config.Limiter 作用于客户端的所有集合；
+// 多个集合传入同一个 Limiter 时共享并发配额
+//
+//	search := NewCollection(client, "articles").WithLimiter(NewLimiter(&LimiterOptions{MaxConcurrent: 20}))
+//	err := search.Find(ctx, filter, &results)
+//	if errors.Is(err, ErrLimiterTimeout) {
+//		// 返回 503，提示客户端稍后重试
+//	}
+type Limiter struct {
+	opts LimiterOptions
+	sem  chan struct{}
+
+	waiting  atomic.Int64
+	acquired atomic.Int64
+	rejected atomic.Int64
+}
+
+// limiterKey 标记 ctx 已持有某个 Limiter 的许可，嵌套调用不再重复获取，避免配额耗尽时自我死锁
+type limiterKey struct{ l *Limiter }
+
+// NewLimiter 创建并发限制器
+func NewLimiter(opts *LimiterOptions) *Limiter {
+	l := &Limiter{}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.MaxConcurrent <= 0 {
+		l.opts.MaxConcurrent = 10
+	}
+	if l.opts.QueueTimeout <= 0 {
+		l.opts.QueueTimeout = time.Second
+	}
+	l.sem = make(chan struct{}, l.opts.MaxConcurrent)
+	return l
+}
+
+// Acquire 等待执行许可，返回携带许可标记的 ctx 和释放函数；ctx 已持有该 Limiter 的许可时立即返回
+func (l *Limiter) Acquire(ctx context.Context) (context.Context, func(), error) {
+	if ctx.Value(limiterKey{l}) != nil {
+		return ctx, func() {}, nil
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+	default:
+		l.waiting.Add(1)
+		timer := time.NewTimer(l.opts.QueueTimeout)
+		select {
+		case l.sem <- struct{}{}:
+			timer.Stop()
+			l.waiting.Add(-1)
+		case <-timer.C:
+			l.waiting.Add(-1)
+			l.rejected.Add(1)
+			return ctx, nil, fmt.Errorf("%w: %d operations in flight", ErrLimiterTimeout, l.opts.MaxConcurrent)
+		case <-ctx.Done():
+			timer.Stop()
+			l.waiting.Add(-1)
+			l.rejected.Add(1)
+			return ctx, nil, ctx.Err()
+		}
+	}
+	l.acquired.Add(1)
+
+	var released atomic.Bool
+	release := func() {
+		if released.CompareAndSwap(false, true) {
+			<-l.sem
+		}
+	}
+	return context.WithValue(ctx, limiterKey{l}, true), release, nil
+}
+
+// Metrics 返回并发限制统计
+func (l *Limiter) Metrics() LimiterMetrics {
+	return LimiterMetrics{
+		InFlight: int64(len(l.sem)),
+		Waiting:  l.waiting.Load(),
+		Acquired: l.acquired.Load(),
+		Rejected: l.rejected.Load(),
+	}
+}
+
+// WithLimiter 返回使用并发限制器的集合副本，覆盖 Config.Limiter；传入 nil 表示不限制
+func (c *Collection) WithLimiter(l *Limiter) *Collection {
+	cp := *c
+	cp.limiter = l
+	cp.limiterSet = true
+	return &cp
+}
+
+// operationLimiter 集合生效的并发限制器，WithLimiter 优先于客户端配置
+func (c *Collection) operationLimiter() *Limiter {
+	if c.limiterSet || c.cli == nil {
+		return c.limiter
+	}
+	return c.cli.limiter
+}
@@ -0,0 +1,53 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterAcquire(t *testing.T) {
+	l := NewLimiter(&LimiterOptions{MaxConcurrent: 1, QueueTimeout: 20 * time.Millisecond})
+
+	ctx, release, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), l.Metrics().InFlight)
+
+	// 嵌套调用复用已持有的许可
+	_, nested, err := l.Acquire(ctx)
+	require.NoError(t, err)
+	nested()
+	assert.Equal(t, int64(1), l.Metrics().InFlight)
+
+	_, _, err = l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrLimiterTimeout)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = l.Acquire(cancelled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	release()
+	release()
+	_, again, err := l.Acquire(context.Background())
+	require.NoError(t, err)
+	again()
+	assert.Equal(t, LimiterMetrics{Acquired: 2, Rejected: 2}, l.Metrics())
+}
+
+func TestCollectionLimiter(t *testing.T) {
+	shared := NewLimiter(&LimiterOptions{MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond})
+	c := &Collection{cli: &Client{limiter: shared}}
+	assert.Same(t, shared, c.operationLimiter())
+	assert.Nil(t, c.WithLimiter(nil).operationLimiter())
+
+	_, done, err := c.operationContext(context.Background())
+	require.NoError(t, err)
+	_, _, err = c.operationContext(context.Background())
+	assert.ErrorIs(t, err, ErrLimiterTimeout)
+	done()
+	assert.Zero(t, shared.Metrics().InFlight)
+}
@@ -0,0 +1,262 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LoadOperation 压测中的一种操作，rng 为当前 worker 独享的随机数生成器
+type LoadOperation struct {
+	Name string
+	// Weight 该操作在混合负载中的权重，例如 insert 50、find 30 表示插入和查询的比例为 5:3
+	Weight int
+	Run    func(ctx context.Context, rng *rand.Rand) error
+}
+
+// LoadTestOptions 压测选项
+type LoadTestOptions struct {
+	Operations []LoadOperation
+	// Concurrency 并发 worker 数，默认 10
+	Concurrency int
+	// Duration 压测时长，默认 30 秒；同时设置 Requests 时先达到的条件结束压测
+	Duration time.Duration
+	// Requests 总请求数，为 0 时只按 Duration 结束
+	Requests int64
+	// Seed 随机种子，相同的种子产生相同的操作序列（每个 worker 内），默认使用当前时间
+	Seed int64
+}
+
+// LoadStats 一种操作（或全部操作）的统计
+type LoadStats struct {
+	Operation string        `json:"operation"`
+	Count     int64         `json:"count"`
+	Errors    int64         `json:"errors"`
+	OpsPerSec float64       `json:"ops_per_sec"`
+	Mean      time.Duration `json:"mean"`
+	P50       time.Duration `json:"p50"`
+	P90       time.Duration `json:"p90"`
+	P99       time.Duration `json:"p99"`
+	Max       time.Duration `json:"max"`
+	// FirstError 第一个错误，便于排查配置问题
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// LoadTestReport 压测结果
+type LoadTestReport struct {
+	Elapsed     time.Duration `json:"elapsed"`
+	Concurrency int           `json:"concurrency"`
+	Total       LoadStats     `json:"total"`
+	Operations  []LoadStats   `json:"operations"`
+}
+
+// WriteTable 以表格形式输出结果
+func (r *LoadTestReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "operation\tcount\terrors\tops/s\tmean\tp50\tp90\tp99\tmax\t\n")
+	for _, s := range append(slices.Clone(r.Operations), r.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t%s\t\n", s.Operation, s.Count, s.Errors, s.OpsPerSec,
+			roundLatency(s.Mean), roundLatency(s.P50), roundLatency(s.P90), roundLatency(s.P99), roundLatency(s.Max))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, s := range r.Operations {
+		if s.FirstError != "" {
+			if _, err := fmt.Fprintf(w, "%s first error: %s\n", s.Operation, s.FirstError); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func roundLatency(d time.Duration) time.Duration {
+	if d >= time.Millisecond {
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// loadSamples 一个 worker 上一种操作的耗时记录
+type loadSamples struct {
+	latencies []time.Duration
+	errors    int64
+	firstErr  error
+}
+
+// RunLoadTest 按权重随机选择操作并发执行，结束后汇总吞吐量和延迟分位数；
+// 单个操作失败只计入错误数，ctx 取消时提前结束并返回已完成部分的结果
+//
+//	report, err := RunLoadTest(ctx, &LoadTestOptions{
+//		Concurrency: 32,
+//		Duration:    time.Minute,
+//		Operations: []LoadOperation{
+//			InsertOperation(users, 50, func(rng *rand.Rand) interface{} { return bson.M{"k": rng.Intn(1e6)} }),
+//			FindOperation(users, 50, func(rng *rand.Rand) bson.M { return bson.M{"k": rng.Intn(1e6)} }),
+//		},
+//	})
+//	report.WriteTable(os.Stdout)
+func RunLoadTest(ctx context.Context, opts *LoadTestOptions) (*LoadTestReport, error) {
+	o := LoadTestOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 10
+	}
+	if o.Duration <= 0 {
+		o.Duration = 30 * time.Second
+	}
+	if o.Seed == 0 {
+		o.Seed = time.Now().UnixNano()
+	}
+	totalWeight := 0
+	for _, op := range o.Operations {
+		if op.Weight < 0 || op.Run == nil {
+			return nil, fmt.Errorf("invalid load operation %q", op.Name)
+		}
+		totalWeight += op.Weight
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("load test requires at least one operation with positive weight")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.Duration)
+	defer cancel()
+
+	var issued atomic.Int64
+	samples := make([][]loadSamples, o.Concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < o.Concurrency; w++ {
+		samples[w] = make([]loadSamples, len(o.Operations))
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(o.Seed + int64(worker)))
+			for ctx.Err() == nil {
+				if o.Requests > 0 && issued.Add(1) > o.Requests {
+					return
+				}
+				i := pickOperation(o.Operations, totalWeight, rng)
+				began := time.Now()
+				err := o.Operations[i].Run(ctx, rng)
+				elapsed := time.Since(began)
+				// 压测结束时被取消的操作不计入结果
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+				s := &samples[worker][i]
+				s.latencies = append(s.latencies, elapsed)
+				if err != nil {
+					s.errors++
+					if s.firstErr == nil {
+						s.firstErr = err
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &LoadTestReport{Elapsed: elapsed, Concurrency: o.Concurrency}
+	var all []time.Duration
+	var allErrors int64
+	for i, op := range o.Operations {
+		var merged loadSamples
+		for w := range samples {
+			s := samples[w][i]
+			merged.latencies = append(merged.latencies, s.latencies...)
+			merged.errors += s.errors
+			if merged.firstErr == nil {
+				merged.firstErr = s.firstErr
+			}
+		}
+		all = append(all, merged.latencies...)
+		allErrors += merged.errors
+		report.Operations = append(report.Operations, summarizeLoad(op.Name, merged, elapsed))
+	}
+	report.Total = summarizeLoad("total", loadSamples{latencies: all, errors: allErrors}, elapsed)
+	return report, nil
+}
+
+func pickOperation(ops []LoadOperation, totalWeight int, rng *rand.Rand) int {
+	n := rng.Intn(totalWeight)
+	for i, op := range ops {
+		if n < op.Weight {
+			return i
+		}
+		n -= op.Weight
+	}
+	return len(ops) - 1
+}
+
+// summarizeLoad 计算统计值，分位数使用最近秩法
+func summarizeLoad(name string, s loadSamples, elapsed time.Duration) LoadStats {
+	stats := LoadStats{Operation: name, Count: int64(len(s.latencies)), Errors: s.errors}
+	if s.firstErr != nil {
+		stats.FirstError = s.firstErr.Error()
+	}
+	if len(s.latencies) == 0 {
+		return stats
+	}
+	slices.Sort(s.latencies)
+	var sum time.Duration
+	for _, d := range s.latencies {
+		sum += d
+	}
+	percentile := func(p float64) time.Duration {
+		i := int(float64(len(s.latencies))*p+0.999999) - 1
+		return s.latencies[max(i, 0)]
+	}
+	stats.OpsPerSec = float64(len(s.latencies)) / elapsed.Seconds()
+	stats.Mean = sum / time.Duration(len(s.latencies))
+	stats.P50 = percentile(0.50)
+	stats.P90 = percentile(0.90)
+	stats.P99 = percentile(0.99)
+	stats.Max = s.latencies[len(s.latencies)-1]
+	return stats
+}
+
+// InsertOperation 插入 newDocument 生成的文档
+func InsertOperation(c *Collection, weight int, newDocument func(rng *rand.Rand) interface{}) LoadOperation {
+	return LoadOperation{Name: "insert", Weight: weight, Run: func(ctx context.Context, rng *rand.Rand) error {
+		_, err := c.InsertOne(ctx, newDocument(rng))
+		return err
+	}}
+}
+
+// FindOperation 按 filter 生成的条件查询，结果以原始 BSON 读取，不计入解码开销
+func FindOperation(c *Collection, weight int, filter func(rng *rand.Rand) bson.M, opts ...*options.FindOptions) LoadOperation {
+	return LoadOperation{Name: "find", Weight: weight, Run: func(ctx context.Context, rng *rand.Rand) error {
+		_, err := c.FindRaw(ctx, filter(rng), opts...)
+		return err
+	}}
+}
+
+// UpdateOperation 对 filter 匹配的第一个文档执行 update
+func UpdateOperation(c *Collection, weight int, filter func(rng *rand.Rand) bson.M, update func(rng *rand.Rand) bson.M) LoadOperation {
+	return LoadOperation{Name: "update", Weight: weight, Run: func(ctx context.Context, rng *rand.Rand) error {
+		_, err := c.UpdateOne(ctx, filter(rng), update(rng))
+		return err
+	}}
+}
+
+// AggregateOperation 执行 pipeline 生成的聚合管道
+func AggregateOperation(c *Collection, weight int, pipeline func(rng *rand.Rand) []bson.M, opts ...*options.AggregateOptions) LoadOperation {
+	return LoadOperation{Name: "aggregate", Weight: weight, Run: func(ctx context.Context, rng *rand.Rand) error {
+		_, err := c.AggregateRaw(ctx, pipeline(rng), opts...)
+		return err
+	}}
+}
@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLoadTest(t *testing.T) {
+	sleep := func(d time.Duration, err error) func(ctx context.Context, rng *rand.Rand) error {
+		return func(ctx context.Context, rng *rand.Rand) error {
+			time.Sleep(d)
+			return err
+		}
+	}
+	report, err := RunLoadTest(t.Context(), &LoadTestOptions{
+		Concurrency: 4,
+		Duration:    5 * time.Second,
+		Requests:    400,
+		Seed:        1,
+		Operations: []LoadOperation{
+			{Name: "fast", Weight: 3, Run: sleep(0, nil)},
+			{Name: "slow", Weight: 1, Run: sleep(time.Millisecond, errors.New("boom"))},
+			{Name: "never", Weight: 0, Run: sleep(0, nil)},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(400), report.Total.Count)
+	fast, slow := report.Operations[0], report.Operations[1]
+	assert.Equal(t, int64(0), report.Operations[2].Count)
+	assert.InDelta(t, 300, fast.Count, 60)
+	assert.Equal(t, slow.Count, slow.Errors)
+	assert.Equal(t, "boom", slow.FirstError)
+	assert.GreaterOrEqual(t, slow.P50, time.Millisecond)
+	assert.LessOrEqual(t, slow.P50, slow.P99)
+	assert.LessOrEqual(t, slow.P99, slow.Max)
+	assert.Greater(t, report.Total.OpsPerSec, 0.0)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteTable(&buf))
+	assert.Contains(t, buf.String(), "slow first error: boom")
+
+	_, err = RunLoadTest(t.Context(), &LoadTestOptions{})
+	assert.Error(t, err)
+}
+
+func TestSummarizeLoad(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	stats := summarizeLoad("find", loadSamples{latencies: latencies}, time.Second)
+	assert.Equal(t, 50*time.Millisecond, stats.P50)
+	assert.Equal(t, 90*time.Millisecond, stats.P90)
+	assert.Equal(t, 99*time.Millisecond, stats.P99)
+	assert.Equal(t, 100*time.Millisecond, stats.Max)
+	assert.Equal(t, 50500*time.Microsecond, stats.Mean)
+	assert.Equal(t, 100.0, stats.OpsPerSec)
+}
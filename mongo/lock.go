@@ -0,0 +1,117 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DistributedLock 基于 MongoDB 的分布式锁
+// 锁以 _id 唯一约束保证互斥，持有者异常退出后锁在 TTL 到期后可被其他实例获取
+type DistributedLock struct {
+	collection *Collection
+	owner      string
+}
+
+// NewDistributedLock 创建分布式锁，collectionName 为空时使用 locks 集合
+func NewDistributedLock(client *Client, collectionName string) *DistributedLock {
+	if collectionName == "" {
+		collectionName = "locks"
+	}
+	hostname, _ := os.Hostname()
+	return &DistributedLock{
+		collection: NewCollection(client, collectionName),
+		owner:      fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), primitive.NewObjectID().Hex()),
+	}
+}
+
+// Owner 返回当前实例的持有者标识
+func (l *DistributedLock) Owner() string {
+	return l.owner
+}
+
+// EnsureIndexes 创建过期锁的 TTL 索引，过期锁由 MongoDB 后台清理
+func (l *DistributedLock) EnsureIndexes(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("idx_expires_at_ttl").SetExpireAfterSeconds(0),
+	}
+	ctx, done, err := l.collection.writeContext(ctx, "createIndexes")
+	if err != nil {
+		return err
+	}
+	defer done()
+	if _, err := l.collection.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create lock index: %w", err)
+	}
+	return nil
+}
+
+// Acquire 尝试获取锁，锁已被其他实例持有时返回 false
+// 当前实例已持有锁时会延长过期时间
+func (l *DistributedLock) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	ctx, done, err := l.collection.writeContext(ctx, "update")
+	if err != nil {
+		return false, err
+	}
+	defer done()
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"owner": l.owner},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"owner":       l.owner,
+		"expires_at":  now.Add(ttl),
+		"acquired_at": now,
+	}}
+
+	_, err = l.collection.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// 锁被其他实例持有时，upsert 会因 _id 冲突失败
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Refresh 延长当前实例持有的锁，锁已丢失时返回 false
+func (l *DistributedLock) Refresh(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	ctx, done, err := l.collection.writeContext(ctx, "update")
+	if err != nil {
+		return false, err
+	}
+	defer done()
+	result, err := l.collection.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "owner": l.owner},
+		bson.M{"$set": bson.M{"expires_at": time.Now().Add(ttl)}})
+	if err != nil {
+		return false, fmt.Errorf("failed to refresh lock %s: %w", name, err)
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Release 释放当前实例持有的锁
+func (l *DistributedLock) Release(ctx context.Context, name string) error {
+	ctx, done, err := l.collection.writeContext(ctx, "delete")
+	if err != nil {
+		return err
+	}
+	defer done()
+	if _, err := l.collection.collection.DeleteOne(ctx, bson.M{"_id": name, "owner": l.owner}); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", name, err)
+	}
+	return nil
+}
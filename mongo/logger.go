@@ -0,0 +1,20 @@
+package mongo
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger 结构化日志接口
+// 方法签名与 *slog.Logger 一致，可以直接传入 slog.Default() 或自定义的 *slog.Logger
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...any)
+	InfoContext(ctx context.Context, msg string, args ...any)
+	WarnContext(ctx context.Context, msg string, args ...any)
+	ErrorContext(ctx context.Context, msg string, args ...any)
+}
+
+// defaultLogger 返回默认日志实现
+func defaultLogger() Logger {
+	return slog.Default()
+}
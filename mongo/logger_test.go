@@ -0,0 +1,86 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recordingLogger 记录日志级别和消息，用于确认内部日志通过配置的 Logger 输出
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) record(level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprintf("%s %s %v", level, msg, args))
+}
+
+func (l *recordingLogger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.record("DEBUG", msg, args)
+}
+
+func (l *recordingLogger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.record("INFO", msg, args)
+}
+
+func (l *recordingLogger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.record("WARN", msg, args)
+}
+
+func (l *recordingLogger) ErrorContext(ctx context.Context, msg string, args ...any) {
+	l.record("ERROR", msg, args)
+}
+
+func (l *recordingLogger) Entries() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+func TestConfigLogger(t *testing.T) {
+	server := newFakeServer(t, false)
+	logger := &recordingLogger{}
+	config := fakeServerConfig(server)
+	config.Logger = logger
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	assert.Same(t, logger, client.Logger())
+
+	indexes := NewIndexManager(client, "articles")
+	_, err = indexes.CreateIndex(t.Context(), bson.D{{Key: "status", Value: 1}}, options.Index().SetName("idx_status"))
+	require.NoError(t, err)
+	require.NoError(t, indexes.DropIndex(t.Context(), "idx_status"))
+
+	// 后台组件的错误同样通过配置的 Logger 输出
+	client.readOnly = true
+	counter := NewBatchCounter(NewCollection(client, "articles"), time.Minute)
+	counter.Incr(primitive.NewObjectID(), "views")
+	counter.Stop()
+
+	entries := logger.Entries()
+	require.Len(t, entries, 4)
+	assert.Contains(t, entries[0], "INFO Successfully connected to MongoDB")
+	assert.Contains(t, entries[1], "INFO Created index [collection articles name idx_status]")
+	assert.Contains(t, entries[2], "INFO Dropped index [collection articles name idx_status]")
+	assert.Contains(t, entries[3], "ERROR Batch counter final flush failed")
+}
+
+func TestDefaultLogger(t *testing.T) {
+	server := newFakeServer(t, false)
+	client, err := NewClient(fakeServerConfig(server))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	assert.NotNil(t, client.Logger(), "falls back to slog.Default()")
+}
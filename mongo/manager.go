@@ -0,0 +1,171 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrClientNotFound 未注册的客户端名称
+var ErrClientNotFound = errors.New("client not found")
+
+// ErrClientManagerClosed 客户端管理器已关闭
+var ErrClientManagerClosed = errors.New("client manager is closed")
+
+// ClientStatus 命名客户端的状态
+type ClientStatus struct {
+	Name      string        `json:"name"`
+	Connected bool          `json:"connected"`
+	Healthy   bool          `json:"healthy"`
+	Latency   time.Duration `json:"latency"`
+	LastError string        `json:"last_error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// managedClient 受管理的客户端
+type managedClient struct {
+	mu      sync.Mutex
+	config  *Config
+	client  *Client
+	lastErr error
+	closed  bool
+}
+
+// ClientManager 命名客户端管理器
+// 统一管理多个集群连接（例如 primary、analytics、archive），首次使用时才建立连接；
+// Close 之后 Get 和 Register 返回 ErrClientManagerClosed，不会重新建立连接
+type ClientManager struct {
+	mu      sync.RWMutex
+	clients map[string]*managedClient
+	closed  bool
+}
+
+// NewClientManager 创建客户端管理器
+func NewClientManager(configs map[string]*Config) *ClientManager {
+	m := &ClientManager{
+		clients: make(map[string]*managedClient, len(configs)),
+	}
+	for name, config := range configs {
+		m.clients[name] = &managedClient{config: config}
+	}
+	return m
+}
+
+// NewClientManagerFromJSON 从 JSON 配置创建客户端管理器
+// 配置格式：{"primary": {"uri": "...", "database": "app"}, "analytics": {...}}
+func NewClientManagerFromJSON(data []byte) (*ClientManager, error) {
+	var configs map[string]*Config
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse client manager config: %w", err)
+	}
+	return NewClientManager(configs), nil
+}
+
+// Register 注册命名客户端配置
+func (m *ClientManager) Register(name string, config *Config) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClientManagerClosed
+	}
+	if _, ok := m.clients[name]; ok {
+		return fmt.Errorf("client %s already registered", name)
+	}
+	m.clients[name] = &managedClient{config: config}
+	return nil
+}
+
+// Names 返回所有已注册的客户端名称
+func (m *ClientManager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get 获取命名客户端，首次获取时建立连接；管理器已关闭时返回 ErrClientManagerClosed
+func (m *ClientManager) Get(ctx context.Context, name string) (*Client, error) {
+	m.mu.RLock()
+	mc, ok := m.clients[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, name)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	// Close 在持有 mc.mu 时标记关闭，与并发的 Get 不会漏掉新建的连接
+	if mc.closed {
+		return nil, fmt.Errorf("%w: %s", ErrClientManagerClosed, name)
+	}
+	if mc.client != nil {
+		return mc.client, nil
+	}
+
+	client, err := NewClientWithContext(ctx, mc.config)
+	if err != nil {
+		mc.lastErr = err
+		return nil, fmt.Errorf("failed to connect client %s: %w", name, err)
+	}
+	mc.client = client
+	mc.lastErr = nil
+	return client, nil
+}
+
+// Status 返回所有客户端的健康状态，只对已建立连接的客户端执行 Ping
+func (m *ClientManager) Status(ctx context.Context) []ClientStatus {
+	names := m.Names()
+	statuses := make([]ClientStatus, 0, len(names))
+	for _, name := range names {
+		m.mu.RLock()
+		mc := m.clients[name]
+		m.mu.RUnlock()
+
+		mc.mu.Lock()
+		client, lastErr := mc.client, mc.lastErr
+		mc.mu.Unlock()
+
+		status := ClientStatus{Name: name, Connected: client != nil, CheckedAt: time.Now()}
+		if client != nil {
+			start := time.Now()
+			lastErr = client.PingContext(ctx)
+			status.Latency = time.Since(start)
+			status.Healthy = lastErr == nil
+		}
+		if lastErr != nil {
+			status.LastError = lastErr.Error()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Close 通过 Client.Shutdown 关闭所有已建立的连接，先停止各客户端注册的后台组件
+// 关闭后管理器不能再使用，重复调用返回 nil
+func (m *ClientManager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+
+	var errs []error
+	for name, mc := range m.clients {
+		mc.mu.Lock()
+		mc.closed = true
+		if mc.client != nil {
+			if err := mc.client.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close client %s: %w", name, err))
+			}
+			mc.client = nil
+		}
+		mc.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeServerConfig(server *fakeServer) *Config {
+	direct := true
+	return &Config{URI: "mongodb://" + server.addr, Database: "test", DirectConnection: &direct}
+}
+
+func TestClientManagerConnectsLazily(t *testing.T) {
+	server := newFakeServer(t, false)
+	manager := NewClientManager(map[string]*Config{"primary": fakeServerConfig(server)})
+	t.Cleanup(func() { manager.Close(t.Context()) })
+
+	assert.Equal(t, []string{"primary"}, manager.Names())
+	statuses := manager.Status(t.Context())
+	require.Len(t, statuses, 1)
+	assert.False(t, statuses[0].Connected, "no connection before the first Get")
+	assert.False(t, statuses[0].Healthy)
+
+	client, err := manager.Get(t.Context(), "primary")
+	require.NoError(t, err)
+	again, err := manager.Get(t.Context(), "primary")
+	require.NoError(t, err)
+	assert.Same(t, client, again, "connects once")
+
+	statuses = manager.Status(t.Context())
+	assert.True(t, statuses[0].Connected)
+	assert.True(t, statuses[0].Healthy)
+
+	_, err = manager.Get(t.Context(), "analytics")
+	assert.ErrorIs(t, err, ErrClientNotFound)
+	assert.Error(t, manager.Register("primary", fakeServerConfig(server)))
+}
+
+func TestClientManagerClose(t *testing.T) {
+	server := newFakeServer(t, false)
+	manager := NewClientManager(map[string]*Config{
+		"primary":   fakeServerConfig(server),
+		"analytics": fakeServerConfig(server),
+	})
+
+	client, err := manager.Get(t.Context(), "primary")
+	require.NoError(t, err)
+	stopped := false
+	client.RegisterShutdown(stopperFunc(func() { stopped = true }))
+
+	require.NoError(t, manager.Close(t.Context()))
+	assert.True(t, stopped, "Close runs the client's shutdown hooks")
+	assert.Error(t, client.Ping(), "the connection is closed")
+	require.NoError(t, manager.Close(t.Context()))
+
+	// 关闭后不再建立连接，包括从未连接过的客户端
+	_, err = manager.Get(t.Context(), "primary")
+	assert.ErrorIs(t, err, ErrClientManagerClosed)
+	_, err = manager.Get(t.Context(), "analytics")
+	assert.ErrorIs(t, err, ErrClientManagerClosed)
+	for _, status := range manager.Status(t.Context()) {
+		assert.False(t, status.Connected, status.Name)
+	}
+	assert.ErrorIs(t, manager.Register("archive", fakeServerConfig(server)), ErrClientManagerClosed)
+}
@@ -0,0 +1,285 @@
+package mongo
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaskStrategy 字段脱敏方式
+type MaskStrategy string
+
+const (
+	// MaskNull 将字段置为 null，适合令牌、密码哈希等不需要保留的字段
+	MaskNull MaskStrategy = "null"
+	// MaskHash 替换为带盐的哈希，相同的值得到相同的结果，可以保留跨集合的关联
+	MaskHash MaskStrategy = "hash"
+	// MaskEmail 替换为 <哈希>@example.com，保持邮箱格式和唯一性
+	MaskEmail MaskStrategy = "email"
+	// MaskScramble 打乱字符串中的字符，相同的值得到相同的结果，适合姓名等需要保留长度和字符集的字段
+	MaskScramble MaskStrategy = "scramble"
+	// MaskReplace 替换为 MaskRule.Value
+	MaskReplace MaskStrategy = "replace"
+)
+
+// MaskRule 单个字段的脱敏规则，Field 为点号路径，路径经过数组时处理数组中的每个元素
+type MaskRule struct {
+	Field    string
+	Strategy MaskStrategy
+	Value    interface{}
+}
+
+// MaskOptions 脱敏配置
+type MaskOptions struct {
+	// Rules 每个集合的脱敏规则
+	Rules map[string][]MaskRule
+	// Salt 哈希和打乱使用的盐，不同环境使用不同的盐可以避免通过哈希反查原值
+	Salt string
+	// Collections Copy 要复制的集合，默认复制源数据库中的所有集合（system. 开头的集合除外），
+	// 没有规则的集合原样复制
+	Collections []string
+	// CopyIndexes Copy 时在目标集合上创建源集合的索引
+	CopyIndexes bool
+	// BatchSize 每批处理的文档数，默认 1000
+	BatchSize int
+	// Progress 每个集合每批处理后的进度回调
+	Progress func(collection string, progress CopyProgress)
+}
+
+// MaskReport 每个集合处理的文档数
+type MaskReport struct {
+	Collections map[string]int64 `json:"collections"`
+}
+
+// Masker 为非生产环境生成脱敏数据：按集合规则对文档中的邮箱、姓名、令牌等字段做哈希、打乱或置空，
+// 可以将生产数据库复制为脱敏副本（Copy），也可以直接改写预发数据库（Rewrite）
+//
+//	masker, err := NewMasker(&MaskOptions{Salt: "staging", Rules: map[string][]MaskRule{
+//		"users": {
+//			{Field: "email", Strategy: MaskEmail},
+//			{Field: "profile.first_name", Strategy: MaskScramble},
+//			{Field: "tokens.value", Strategy: MaskNull},
+//		},
+//	}})
+//	report, err := masker.Copy(ctx, prodClient, stagingClient)
+type Masker struct {
+	opts MaskOptions
+}
+
+// NewMasker 创建脱敏器，规则中的脱敏方式无效时返回错误
+func NewMasker(opts *MaskOptions) (*Masker, error) {
+	o := MaskOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 1000
+	}
+	for collection, rules := range o.Rules {
+		for _, rule := range rules {
+			if rule.Field == "" || rule.Field == "_id" {
+				return nil, fmt.Errorf("mask rule for %s has invalid field %q", collection, rule.Field)
+			}
+			switch rule.Strategy {
+			case MaskNull, MaskHash, MaskEmail, MaskScramble, MaskReplace:
+			default:
+				return nil, fmt.Errorf("mask rule %s.%s has unsupported strategy %q", collection, rule.Field, rule.Strategy)
+			}
+		}
+	}
+	return &Masker{opts: o}, nil
+}
+
+// MaskDocument 按集合的规则就地脱敏文档，没有规则的集合原样返回
+func (m *Masker) MaskDocument(collection string, doc bson.M) bson.M {
+	for _, rule := range m.opts.Rules[collection] {
+		maskPath(doc, strings.Split(rule.Field, "."), func(value interface{}) interface{} {
+			return m.maskValue(rule, value)
+		})
+	}
+	return doc
+}
+
+// maskPath 对路径上的值调用 fn，路径经过数组时逐个元素处理，字段不存在时跳过
+func maskPath(value interface{}, parts []string, fn func(value interface{}) interface{}) {
+	switch v := value.(type) {
+	case bson.M:
+		current, ok := v[parts[0]]
+		if !ok {
+			return
+		}
+		if len(parts) == 1 {
+			v[parts[0]] = fn(current)
+			return
+		}
+		maskPath(current, parts[1:], fn)
+	case bson.A:
+		for _, item := range v {
+			maskPath(item, parts, fn)
+		}
+	}
+}
+
+// maskValue 按规则处理单个值，null 值保持不变；打乱和哈希用于数组字段时逐个元素处理
+func (m *Masker) maskValue(rule MaskRule, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if items, ok := value.(bson.A); ok && rule.Strategy != MaskNull && rule.Strategy != MaskReplace {
+		masked := make(bson.A, len(items))
+		for i, item := range items {
+			masked[i] = m.maskValue(rule, item)
+		}
+		return masked
+	}
+
+	switch rule.Strategy {
+	case MaskNull:
+		return nil
+	case MaskReplace:
+		return rule.Value
+	case MaskHash:
+		return m.hash(value)
+	case MaskEmail:
+		return m.hash(value)[:16] + "@example.com"
+	case MaskScramble:
+		s, ok := value.(string)
+		if !ok {
+			return m.hash(value)
+		}
+		return m.scramble(s)
+	}
+	return value
+}
+
+// hash 带盐的 HMAC-SHA256，非字符串值按其字面形式计算
+func (m *Masker) hash(value interface{}) string {
+	mac := hmac.New(sha256.New, []byte(m.opts.Salt))
+	fmt.Fprint(mac, value)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// scramble 以值的哈希为种子打乱字符，结果是确定的
+func (m *Masker) scramble(s string) string {
+	runes := []rune(s)
+	if len(runes) < 2 {
+		return s
+	}
+	mac := hmac.New(sha256.New, []byte(m.opts.Salt))
+	mac.Write([]byte(s))
+	seed := int64(binary.BigEndian.Uint64(mac.Sum(nil)))
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(runes), func(i, j int) { runes[i], runes[j] = runes[j], runes[i] })
+	return string(runes)
+}
+
+// Copy 将源数据库中的集合脱敏后复制到目标数据库，目标中已存在的文档按 _id 覆盖，可以重复执行
+func (m *Masker) Copy(ctx context.Context, source, target *Client) (*MaskReport, error) {
+	if source.client == target.client && source.dbName == target.dbName {
+		return nil, fmt.Errorf("mask source and target must be different databases")
+	}
+	collections := m.opts.Collections
+	if len(collections) == 0 {
+		var err error
+		if collections, err = listCollections(ctx, source); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &MaskReport{Collections: make(map[string]int64)}
+	for _, name := range collections {
+		progress, err := CopyCollection(ctx, NewCollection(source, name), NewCollection(target, name), &CopyOptions{
+			Transform: func(ctx context.Context, doc bson.M) (bson.M, error) {
+				return m.MaskDocument(name, doc), nil
+			},
+			CopyIndexes: m.opts.CopyIndexes,
+			Overwrite:   true,
+			BatchSize:   m.opts.BatchSize,
+			Progress:    m.progress(name),
+		})
+		if progress != nil {
+			report.Collections[name] = progress.Copied
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to copy masked %s: %w", name, err)
+		}
+	}
+	return report, nil
+}
+
+// Rewrite 就地脱敏数据库中配置了规则的集合，只应在已经从生产环境复制出来的数据库上执行
+func (m *Masker) Rewrite(ctx context.Context, client *Client) (*MaskReport, error) {
+	names := make([]string, 0, len(m.opts.Rules))
+	for name := range m.opts.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &MaskReport{Collections: make(map[string]int64)}
+	for _, name := range names {
+		c := NewCollection(client, name)
+		progress := CopyProgress{}
+		report.Collections[name] = 0
+		_, err := c.ForEachBatch(ctx, nil, m.opts.BatchSize, func(ctx context.Context, batch []bson.Raw) error {
+			models := make([]mongo.WriteModel, 0, len(batch))
+			for _, raw := range batch {
+				var doc bson.M
+				if err := bson.Unmarshal(raw, &doc); err != nil {
+					return fmt.Errorf("failed to decode document: %w", err)
+				}
+				models = append(models, mongo.NewReplaceOneModel().
+					SetFilter(bson.M{"_id": doc["_id"]}).
+					SetReplacement(m.MaskDocument(name, doc)))
+			}
+			if _, err := c.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+				return fmt.Errorf("failed to write masked documents: %w", err)
+			}
+			progress.Copied += int64(len(batch))
+			report.Collections[name] = progress.Copied
+			if fn := m.progress(name); fn != nil {
+				fn(progress)
+			}
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to rewrite masked %s: %w", name, err)
+		}
+	}
+	return report, nil
+}
+
+// progress 返回集合的进度回调
+func (m *Masker) progress(collection string) func(CopyProgress) {
+	if m.opts.Progress == nil {
+		return nil
+	}
+	return func(progress CopyProgress) {
+		m.opts.Progress(collection, progress)
+	}
+}
+
+// listCollections 列出数据库中的普通集合，跳过视图和 system. 开头的集合
+func listCollections(ctx context.Context, client *Client) ([]string, error) {
+	names, err := client.database.ListCollectionNames(ctx, bson.M{"type": "collection"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	collections := make([]string, 0, len(names))
+	for _, name := range names {
+		if !strings.HasPrefix(name, "system.") {
+			collections = append(collections, name)
+		}
+	}
+	sort.Strings(collections)
+	return collections, nil
+}
@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewMaskerValidatesRules(t *testing.T) {
+	_, err := NewMasker(&MaskOptions{Rules: map[string][]MaskRule{"users": {{Field: "email", Strategy: "blur"}}}})
+	assert.Error(t, err)
+	_, err = NewMasker(&MaskOptions{Rules: map[string][]MaskRule{"users": {{Field: "_id", Strategy: MaskHash}}}})
+	assert.Error(t, err)
+}
+
+func TestMaskDocument(t *testing.T) {
+	masker, err := NewMasker(&MaskOptions{Salt: "staging", Rules: map[string][]MaskRule{"users": {
+		{Field: "email", Strategy: MaskEmail},
+		{Field: "profile.first_name", Strategy: MaskScramble},
+		{Field: "tokens.value", Strategy: MaskNull},
+		{Field: "phones", Strategy: MaskHash},
+		{Field: "status", Strategy: MaskReplace, Value: "masked"},
+		{Field: "missing.field", Strategy: MaskNull},
+	}}})
+	require.NoError(t, err)
+
+	raw, err := bson.Marshal(bson.M{
+		"_id":     1,
+		"email":   "alice@corp.com",
+		"profile": bson.M{"first_name": "Alexandra", "bio": "hello"},
+		"tokens":  bson.A{bson.M{"value": "secret1", "kind": "api"}, bson.M{"value": "secret2"}},
+		"phones":  bson.A{"555-1234", nil},
+		"status":  "active",
+	})
+	require.NoError(t, err)
+	var doc bson.M
+	require.NoError(t, bson.Unmarshal(raw, &doc))
+
+	masked := masker.MaskDocument("users", doc)
+	email := masked["email"].(string)
+	assert.True(t, strings.HasSuffix(email, "@example.com"))
+	assert.Len(t, email, len("@example.com")+16)
+
+	firstName := masked["profile"].(bson.M)["first_name"].(string)
+	assert.NotEqual(t, "Alexandra", firstName)
+	assert.Equal(t, sortedRunes("Alexandra"), sortedRunes(firstName))
+	assert.Equal(t, "hello", masked["profile"].(bson.M)["bio"])
+
+	tokens := masked["tokens"].(bson.A)
+	assert.Nil(t, tokens[0].(bson.M)["value"])
+	assert.Equal(t, "api", tokens[0].(bson.M)["kind"])
+	assert.Nil(t, tokens[1].(bson.M)["value"])
+
+	phones := masked["phones"].(bson.A)
+	assert.Len(t, phones[0], 64)
+	assert.Nil(t, phones[1])
+	assert.Equal(t, "masked", masked["status"])
+	assert.Equal(t, int32(1), masked["_id"])
+
+	// 相同的值得到相同的结果，保留跨集合的关联
+	again := masker.MaskDocument("users", bson.M{"email": "alice@corp.com", "profile": bson.M{"first_name": "Alexandra"}})
+	assert.Equal(t, email, again["email"])
+	assert.Equal(t, firstName, again["profile"].(bson.M)["first_name"])
+
+	other, err := NewMasker(&MaskOptions{Salt: "other", Rules: map[string][]MaskRule{"users": {{Field: "email", Strategy: MaskEmail}}}})
+	require.NoError(t, err)
+	assert.NotEqual(t, email, other.MaskDocument("users", bson.M{"email": "alice@corp.com"})["email"])
+
+	// 没有规则的集合原样返回
+	assert.Equal(t, bson.M{"email": "bob@corp.com"}, masker.MaskDocument("orders", bson.M{"email": "bob@corp.com"}))
+}
+
+func sortedRunes(s string) string {
+	runes := []rune(s)
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return string(runes)
+}
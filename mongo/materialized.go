@@ -0,0 +1,229 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MaterializedViewOptions 物化视图配置
+type MaterializedViewOptions struct {
+	// Source 源集合
+	Source string
+	// Pipeline 计算视图内容的聚合管道，不能包含 $merge 或 $out
+	Pipeline []bson.M
+	// Target 保存结果的目标集合
+	Target string
+	// On $merge 匹配已有文档的字段，默认 _id；使用其它字段时目标集合上需要有对应的唯一索引
+	On []string
+	// WhenMatched 匹配到已有文档时的处理方式，默认 replace
+	WhenMatched string
+	// WhenNotMatched 没有匹配到文档时的处理方式，默认 insert
+	WhenNotMatched string
+	// RemoveStale 刷新后删除本次管道没有输出的旧文档，例如已经没有文章使用的标签
+	RemoveStale bool
+	// Replace 使用 $out 整体替换目标集合，目标集合必须与源集合在同一个数据库
+	Replace bool
+	// RefreshInterval 后台刷新间隔，大于 0 时 Start 会定时刷新
+	RefreshInterval time.Duration
+}
+
+// MaterializedView 物化视图
+// 通过 $merge（或 $out）把聚合结果预先写入目标集合，查询时直接读取目标集合，避免每次请求重复计算：
+//
+//	view, err := NewMaterializedView(client, MaterializedViewOptions{
+//		Source:          "articles",
+//		Pipeline:        []bson.M{{"$unwind": "$tags"}, {"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+//		Target:          "tag_stats",
+//		RemoveStale:     true,
+//		RefreshInterval: 5 * time.Minute,
+//	})
+//	view.Start(ctx)
+//	defer view.Stop()
+//
+// Refresh 的签名与 CronJobFunc 一致，也可以注册到 Scheduler 按 cron 表达式刷新
+type MaterializedView struct {
+	client *Client
+	opts   MaterializedViewOptions
+
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	lastRefresh time.Time
+	lastErr     error
+	duration    time.Duration
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// refreshedAtField 记录文档最近一次刷新时间的字段，用于删除过期文档
+const refreshedAtField = "refreshed_at"
+
+// NewMaterializedView 创建物化视图
+func NewMaterializedView(client *Client, opts MaterializedViewOptions) (*MaterializedView, error) {
+	if opts.Source == "" || opts.Target == "" {
+		return nil, errors.New("materialized view requires source and target collections")
+	}
+	if opts.Source == opts.Target {
+		return nil, errors.New("materialized view target must differ from source")
+	}
+	for _, stage := range opts.Pipeline {
+		if _, ok := stage["$merge"]; ok {
+			return nil, errors.New("materialized view pipeline must not contain $merge")
+		}
+		if _, ok := stage["$out"]; ok {
+			return nil, errors.New("materialized view pipeline must not contain $out")
+		}
+	}
+	if len(opts.On) == 0 {
+		opts.On = []string{"_id"}
+	}
+	if opts.WhenMatched == "" {
+		opts.WhenMatched = "replace"
+	}
+	if opts.WhenNotMatched == "" {
+		opts.WhenNotMatched = "insert"
+	}
+	return &MaterializedView{
+		client: client,
+		opts:   opts,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}, nil
+}
+
+// Collection 返回保存视图结果的目标集合
+func (v *MaterializedView) Collection() *Collection {
+	return NewCollection(v.client, v.opts.Target)
+}
+
+// Refresh 立即重新计算视图，同一时间只会执行一次刷新
+func (v *MaterializedView) Refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	start := time.Now()
+	err := v.refresh(ctx, start)
+
+	v.mu.Lock()
+	v.lastErr = err
+	v.duration = time.Since(start)
+	if err == nil {
+		v.lastRefresh = start
+	}
+	v.mu.Unlock()
+
+	if err != nil {
+		v.client.logger.ErrorContext(ctx, "Materialized view refresh failed", "source", v.opts.Source, "target", v.opts.Target, "err", err)
+	}
+	return err
+}
+
+// refresh 执行聚合写入目标集合，并按需删除过期文档
+func (v *MaterializedView) refresh(ctx context.Context, start time.Time) error {
+	source := v.client.GetCollection(v.opts.Source)
+	cursor, err := source.Aggregate(ctx, v.pipeline(start))
+	if err != nil {
+		return fmt.Errorf("failed to refresh materialized view %s: %w", v.opts.Target, err)
+	}
+	_ = cursor.Close(ctx)
+
+	if v.opts.RemoveStale && !v.opts.Replace {
+		filter := bson.M{"$or": bson.A{
+			bson.M{refreshedAtField: bson.M{"$lt": start}},
+			bson.M{refreshedAtField: bson.M{"$exists": false}},
+		}}
+		if _, err := v.client.GetCollection(v.opts.Target).DeleteMany(ctx, filter); err != nil {
+			return fmt.Errorf("failed to remove stale documents from %s: %w", v.opts.Target, err)
+		}
+	}
+	return nil
+}
+
+// pipeline 构建带写入阶段的完整管道
+func (v *MaterializedView) pipeline(start time.Time) []bson.M {
+	pipeline := make([]bson.M, 0, len(v.opts.Pipeline)+2)
+	pipeline = append(pipeline, v.opts.Pipeline...)
+	if v.opts.Replace {
+		return append(pipeline, bson.M{"$out": v.opts.Target})
+	}
+	if v.opts.RemoveStale {
+		pipeline = append(pipeline, bson.M{"$addFields": bson.M{refreshedAtField: start}})
+	}
+	return append(pipeline, bson.M{"$merge": bson.M{
+		"into":           bson.M{"db": v.client.GetDatabaseName(), "coll": v.opts.Target},
+		"on":             v.opts.On,
+		"whenMatched":    v.opts.WhenMatched,
+		"whenNotMatched": v.opts.WhenNotMatched,
+	}})
+}
+
+// LastRefresh 最近一次成功刷新的开始时间
+func (v *MaterializedView) LastRefresh() time.Time {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.lastRefresh
+}
+
+// LastError 最近一次刷新的错误
+func (v *MaterializedView) LastError() error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.lastErr
+}
+
+// Duration 最近一次刷新的耗时
+func (v *MaterializedView) Duration() time.Duration {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.duration
+}
+
+// Start 启动后台定时刷新，启动时立即刷新一次；RefreshInterval 未设置时不执行任何操作
+func (v *MaterializedView) Start(ctx context.Context) {
+	if v.opts.RefreshInterval <= 0 {
+		return
+	}
+	v.startOnce.Do(func() {
+		v.client.RegisterShutdown(v)
+		go v.run(ctx)
+	})
+}
+
+// Stop 停止后台刷新，等待正在执行的刷新完成
+func (v *MaterializedView) Stop() {
+	v.stopOnce.Do(func() {
+		close(v.stopCh)
+		v.startOnce.Do(func() {
+			close(v.doneCh)
+		})
+		<-v.doneCh
+	})
+}
+
+// run 定时刷新循环
+func (v *MaterializedView) run(ctx context.Context) {
+	defer close(v.doneCh)
+
+	_ = v.Refresh(ctx)
+	ticker := time.NewTicker(v.opts.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = v.Refresh(ctx)
+		case <-v.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewMaterializedViewValidation(t *testing.T) {
+	_, err := NewMaterializedView(nil, MaterializedViewOptions{Source: "articles"})
+	assert.Error(t, err)
+	_, err = NewMaterializedView(nil, MaterializedViewOptions{Source: "articles", Target: "articles"})
+	assert.Error(t, err)
+	_, err = NewMaterializedView(nil, MaterializedViewOptions{
+		Source:   "articles",
+		Target:   "tag_stats",
+		Pipeline: []bson.M{{"$out": "other"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestMaterializedViewPipeline(t *testing.T) {
+	group := bson.M{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}
+	view, err := NewMaterializedView(&Client{dbName: "blog"}, MaterializedViewOptions{
+		Source:      "articles",
+		Target:      "tag_stats",
+		Pipeline:    []bson.M{group},
+		RemoveStale: true,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	assert.Equal(t, []bson.M{
+		group,
+		{"$addFields": bson.M{"refreshed_at": start}},
+		{"$merge": bson.M{
+			"into":           bson.M{"db": "blog", "coll": "tag_stats"},
+			"on":             []string{"_id"},
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}},
+	}, view.pipeline(start))
+
+	view.opts.Replace = true
+	assert.Equal(t, []bson.M{group, {"$out": "tag_stats"}}, view.pipeline(start))
+}
@@ -0,0 +1,865 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MemoryRepositoryOptions 内存仓库选项
+type MemoryRepositoryOptions struct {
+	// IDStrategy 新文档 ID 和字符串 ID 的解析策略，默认 ObjectIDStrategy
+	IDStrategy IDStrategy
+}
+
+// MemoryRepository 基于内存的 RepositoryAPI 实现，用于不依赖 MongoDB 的快速单元测试
+//
+// 它不是完整的查询引擎，只支持常用的子集：
+//   - 过滤：字段相等（包括点号路径和数组元素匹配）、$eq、$ne、$gt、$gte、$lt、$lte、$in、$nin、
+//     $exists、$regex（及 $options）、$size、$not、$and、$or、$nor
+//   - 更新：$set、$unset、$inc、$push（支持 $each）、$currentDate，UpdateOptions 只支持 Upsert，
+//     插入时 $setOnInsert 生效
+//   - 查询选项：Sort、Skip、Limit；Projection、Collation、Hint 等其他选项被忽略
+//
+// 不支持的操作符返回错误而不是静默忽略。不执行模型注册的校验器和钩子，没有索引和唯一约束（_id 除外），
+// 也没有事务；涉及这些行为的测试应当使用真实的 MongoDB
+//
+//	var users RepositoryAPI[User] = NewMemoryRepository[User](nil)
+//	svc := NewUserService(users)
+type MemoryRepository[T any] struct {
+	mu       sync.RWMutex
+	docs     []bson.M
+	strategy IDStrategy
+}
+
+var _ RepositoryAPI[User] = (*MemoryRepository[User])(nil)
+
+// NewMemoryRepository 创建空的内存仓库，opts 可以为 nil
+func NewMemoryRepository[T any](opts *MemoryRepositoryOptions) *MemoryRepository[T] {
+	r := &MemoryRepository[T]{strategy: ObjectIDStrategy}
+	if opts != nil && opts.IDStrategy != nil {
+		r.strategy = opts.IDStrategy
+	}
+	return r
+}
+
+// Reset 删除所有文档
+func (r *MemoryRepository[T]) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.docs = nil
+}
+
+// Insert 插入文档，ID 为零值时按 IDStrategy 生成并回填；_id 重复时返回 *DuplicateKeyError
+func (r *MemoryRepository[T]) Insert(ctx context.Context, doc *T) (*mongo.InsertOneResult, error) {
+	if d, ok := interface{}(doc).(Identifiable); ok && isZeroID(d.GetDocumentID()) {
+		if err := d.SetDocumentID(r.strategy.NewID()); err != nil {
+			return nil, err
+		}
+	}
+	if err := ApplyDefaults(doc); err != nil {
+		return nil, err
+	}
+	if d, ok := interface{}(doc).(interface{ BeforeInsert() }); ok {
+		d.BeforeInsert()
+	}
+	m, err := toBsonM(doc)
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := m["_id"]; !ok || isZeroID(id) {
+		m["_id"] = r.strategy.NewID()
+		if err := setInsertedID(doc, m["_id"]); err != nil {
+			return nil, err
+		}
+		// 重新编码，使生成的 ID 与过滤条件中的值类型一致（例如 UUID 编码为 Binary）
+		if m, err = normalizeDocument(m); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.insertLocked(m); err != nil {
+		return nil, err
+	}
+	return &mongo.InsertOneResult{InsertedID: m["_id"]}, nil
+}
+
+func (r *MemoryRepository[T]) insertLocked(m bson.M) error {
+	for _, existing := range r.docs {
+		if valuesEqual(existing["_id"], m["_id"]) {
+			return &DuplicateKeyError{Index: "_id_", Fields: []string{"_id"}, Values: bson.M{"_id": m["_id"]}}
+		}
+	}
+	r.docs = append(r.docs, m)
+	return nil
+}
+
+// FindByID 根据 ID 查找文档，字符串 ID 按 IDStrategy 解析
+func (r *MemoryRepository[T]) FindByID(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*T, error) {
+	docID, err := r.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, bson.M{"_id": docID}, opts...)
+}
+
+// FindOne 查找单个文档，只使用 opts 中的 Sort 和 Skip
+func (r *MemoryRepository[T]) FindOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	find := options.Find().SetLimit(1)
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Sort != nil {
+			find.Sort = opt.Sort
+		}
+		if opt.Skip != nil {
+			find.Skip = opt.Skip
+		}
+	}
+	docs, err := r.Find(ctx, filter, find)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("document not found")
+	}
+	return &docs[0], nil
+}
+
+// Find 查找多个文档，只使用 opts 中的 Sort、Skip 和 Limit
+func (r *MemoryRepository[T]) Find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	var sortSpec interface{}
+	var skip, limit int64
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Sort != nil {
+			sortSpec = opt.Sort
+		}
+		if opt.Skip != nil {
+			skip = *opt.Skip
+		}
+		if opt.Limit != nil {
+			limit = *opt.Limit
+		}
+	}
+
+	r.mu.RLock()
+	matched, err := r.matchLocked(filter)
+	r.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if sortSpec != nil {
+		if err := sortDocuments(matched, sortSpec); err != nil {
+			return nil, err
+		}
+	}
+	if skip > 0 {
+		if skip >= int64(len(matched)) {
+			matched = nil
+		} else {
+			matched = matched[skip:]
+		}
+	}
+	if limit < 0 {
+		limit = -limit
+	}
+	if limit > 0 && limit < int64(len(matched)) {
+		matched = matched[:limit]
+	}
+
+	docs := make([]T, 0, len(matched))
+	for _, m := range matched {
+		var doc T
+		if err := decodeDocument(m, &doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// FindWithPagination 分页查找文档，与 Collection.FindWithPagination 一样追加 _id 排序保证顺序稳定
+func (r *MemoryRepository[T]) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, opts ...*options.FindOptions) ([]T, *PaginationResult, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	merged := mergeFindOptions(opts)
+	docs, err := r.Find(ctx, filter, options.Find().
+		SetSort(stableSort(merged.Sort)).
+		SetSkip((page-1)*pageSize).
+		SetLimit(pageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+	total, err := r.Count(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, &PaginationResult{
+		Page:      page,
+		PageSize:  pageSize,
+		Total:     total,
+		TotalPage: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// Count 统计匹配的文档数量
+func (r *MemoryRepository[T]) Count(ctx context.Context, filter bson.M) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matched, err := r.matchLocked(filter)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(matched)), nil
+}
+
+// UpdateByID 根据 ID 执行更新，opts 中只支持 Upsert
+func (r *MemoryRepository[T]) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	docID, err := r.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	upsert := false
+	for _, opt := range opts {
+		if opt != nil && opt.Upsert != nil {
+			upsert = *opt.Upsert
+		}
+	}
+	normalized, err := normalizeDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	normalizedID, err := normalizeValue(docID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if !valuesEqual(doc["_id"], normalizedID) {
+			continue
+		}
+		updated, err := applyUpdate(copyDocument(doc), normalized, false)
+		if err != nil {
+			return nil, err
+		}
+		result := &mongo.UpdateResult{MatchedCount: 1}
+		if !reflect.DeepEqual(doc, updated) {
+			r.docs[i] = updated
+			result.ModifiedCount = 1
+		}
+		return result, nil
+	}
+	if !upsert {
+		return &mongo.UpdateResult{}, nil
+	}
+	inserted, err := applyUpdate(bson.M{"_id": normalizedID}, normalized, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.insertLocked(inserted); err != nil {
+		return nil, err
+	}
+	return &mongo.UpdateResult{UpsertedCount: 1, UpsertedID: normalizedID}, nil
+}
+
+// UpdateChanged 只写入 modified 相对 original 变化的字段，参见 BuildChangedUpdate
+func (r *MemoryRepository[T]) UpdateChanged(ctx context.Context, original, modified *T) (*mongo.UpdateResult, error) {
+	before, err := toBsonM(original)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := before["_id"]
+	if !ok {
+		return nil, fmt.Errorf("original document has no _id")
+	}
+	update, err := BuildChangedUpdate(original, modified)
+	if err != nil {
+		return nil, err
+	}
+	if len(update) == 0 {
+		return &mongo.UpdateResult{}, nil
+	}
+	return r.UpdateByID(ctx, id, update)
+}
+
+// DeleteByID 根据 ID 删除文档
+func (r *MemoryRepository[T]) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	docID, err := r.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	normalizedID, err := normalizeValue(docID)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, doc := range r.docs {
+		if valuesEqual(doc["_id"], normalizedID) {
+			r.docs = append(r.docs[:i:i], r.docs[i+1:]...)
+			return &mongo.DeleteResult{DeletedCount: 1}, nil
+		}
+	}
+	return &mongo.DeleteResult{}, nil
+}
+
+func (r *MemoryRepository[T]) documentID(id interface{}) (interface{}, error) {
+	if s, ok := id.(string); ok {
+		return r.strategy.ParseID(s)
+	}
+	return id, nil
+}
+
+// matchLocked 返回匹配 filter 的文档副本，保持插入顺序
+func (r *MemoryRepository[T]) matchLocked(filter bson.M) ([]bson.M, error) {
+	normalized, err := normalizeDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	var matched []bson.M
+	for _, doc := range r.docs {
+		ok, err := matchDocument(doc, normalized)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, copyDocument(doc))
+		}
+	}
+	return matched, nil
+}
+
+// normalizeDocument 经过一次 BSON 编解码，使过滤条件和文档中的值类型一致（例如 time.Time 转换为 DateTime）
+func normalizeDocument(doc bson.M) (bson.M, error) {
+	if doc == nil {
+		return bson.M{}, nil
+	}
+	return toBsonM(doc)
+}
+
+func normalizeValue(v interface{}) (interface{}, error) {
+	m, err := toBsonM(bson.M{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	return m["v"], nil
+}
+
+func decodeDocument(m bson.M, out interface{}) error {
+	data, err := bson.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode document: %w", err)
+	}
+	if err := bson.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	return nil
+}
+
+// copyDocument 深拷贝文档，避免调用方修改存储的数据
+func copyDocument(doc bson.M) bson.M {
+	out := make(bson.M, len(doc))
+	for k, v := range doc {
+		out[k] = copyValue(v)
+	}
+	return out
+}
+
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.M:
+		return copyDocument(v)
+	case primitive.A:
+		out := make(primitive.A, len(v))
+		for i := range v {
+			out[i] = copyValue(v[i])
+		}
+		return out
+	}
+	return v
+}
+
+// matchDocument 判断文档是否匹配过滤条件
+func matchDocument(doc bson.M, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		var (
+			ok  bool
+			err error
+		)
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, key, cond)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("memory repository: unsupported query operator %s", key)
+			}
+			value, present := lookupPath(doc, key)
+			ok, err = matchCondition(value, present, cond)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, op string, cond interface{}) (bool, error) {
+	clauses, ok := cond.(primitive.A)
+	if !ok || len(clauses) == 0 {
+		return false, fmt.Errorf("memory repository: %s requires a non-empty array", op)
+	}
+	for _, clause := range clauses {
+		sub, ok := clause.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("memory repository: %s entries must be documents", op)
+		}
+		matched, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// isOperatorDocument 判断条件是否为 {$op: ...} 形式
+func isOperatorDocument(cond interface{}) (bson.M, bool) {
+	m, ok := cond.(bson.M)
+	if !ok || len(m) == 0 {
+		return nil, false
+	}
+	for key := range m {
+		if !strings.HasPrefix(key, "$") {
+			return nil, false
+		}
+	}
+	return m, true
+}
+
+// matchCondition 判断字段值是否满足条件，数组字段的任意元素满足即可，与 MongoDB 一致
+func matchCondition(value interface{}, present bool, cond interface{}) (bool, error) {
+	ops, isOps := isOperatorDocument(cond)
+	if !isOps {
+		if re, ok := cond.(primitive.Regex); ok {
+			return matchRegex(value, re.Pattern, re.Options)
+		}
+		return anyCandidate(value, present, func(v interface{}) bool { return valuesEqual(v, cond) }), nil
+	}
+
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$eq":
+			ok = anyCandidate(value, present, func(v interface{}) bool { return valuesEqual(v, arg) })
+		case "$ne":
+			ok = !anyCandidate(value, present, func(v interface{}) bool { return valuesEqual(v, arg) })
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = anyCandidate(value, present, func(v interface{}) bool {
+				c, comparable := compareValues(v, arg)
+				if !comparable {
+					return false
+				}
+				switch op {
+				case "$gt":
+					return c > 0
+				case "$gte":
+					return c >= 0
+				case "$lt":
+					return c < 0
+				default:
+					return c <= 0
+				}
+			})
+		case "$in", "$nin":
+			list, isList := arg.(primitive.A)
+			if !isList {
+				return false, fmt.Errorf("memory repository: %s requires an array", op)
+			}
+			in := anyCandidate(value, present, func(v interface{}) bool {
+				for _, item := range list {
+					if valuesEqual(v, item) {
+						return true
+					}
+				}
+				return false
+			})
+			ok = in == (op == "$in")
+		case "$exists":
+			ok = present == truthy(arg)
+		case "$regex":
+			pattern, isString := arg.(string)
+			if re, isRegex := arg.(primitive.Regex); isRegex {
+				pattern = re.Pattern
+				isString = true
+			}
+			if !isString {
+				return false, fmt.Errorf("memory repository: $regex requires a string")
+			}
+			flags, _ := ops["$options"].(string)
+			var err error
+			if ok, err = matchRegex(value, pattern, flags); err != nil {
+				return false, err
+			}
+		case "$options":
+			continue
+		case "$size":
+			arr, isArray := value.(primitive.A)
+			size, isNumber := toFloat(arg)
+			ok = isArray && isNumber && float64(len(arr)) == size
+		case "$not":
+			matched, err := matchCondition(value, present, arg)
+			if err != nil {
+				return false, err
+			}
+			ok = !matched
+		default:
+			return false, fmt.Errorf("memory repository: unsupported query operator %s", op)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// anyCandidate 依次检查字段值本身和数组元素
+func anyCandidate(value interface{}, present bool, match func(interface{}) bool) bool {
+	if !present {
+		return match(nil)
+	}
+	if match(value) {
+		return true
+	}
+	if arr, ok := value.(primitive.A); ok {
+		for _, item := range arr {
+			if match(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchRegex(value interface{}, pattern, flags string) (bool, error) {
+	prefix := ""
+	for _, f := range flags {
+		switch f {
+		case 'i', 'm', 's':
+			prefix += string(f)
+		}
+	}
+	if prefix != "" {
+		pattern = "(?" + prefix + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("memory repository: invalid regex: %w", err)
+	}
+	return anyCandidate(value, true, func(v interface{}) bool {
+		s, ok := v.(string)
+		return ok && re.MatchString(s)
+	}), nil
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case nil:
+		return false
+	}
+	f, ok := toFloat(v)
+	return !ok || f != 0
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// compareValues 比较同类值，不同类型（数字之间除外）不可比较
+func compareValues(a, b interface{}) (int, bool) {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), true
+		}
+	case primitive.DateTime:
+		if b, ok := b.(primitive.DateTime); ok {
+			return compareInt64(int64(a), int64(b)), true
+		}
+	case primitive.ObjectID:
+		if b, ok := b.(primitive.ObjectID); ok {
+			return bytes.Compare(a[:], b[:]), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			switch {
+			case a == b:
+				return 0, true
+			case !a:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if c, ok := compareValues(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// typeOrder MongoDB 跨类型排序的顺序，缺失字段与 null 相同
+func typeOrder(v interface{}) int {
+	if _, ok := toFloat(v); ok {
+		return 2
+	}
+	switch v.(type) {
+	case nil, primitive.Null:
+		return 1
+	case string:
+		return 3
+	case bson.M:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	}
+	return 10
+}
+
+func compareForSort(a, b interface{}) int {
+	if c, ok := compareValues(a, b); ok {
+		return c
+	}
+	return typeOrder(a) - typeOrder(b)
+}
+
+// sortDocuments 按 bson.D 或单字段 bson.M 排序，多字段 bson.M 顺序不确定因此返回错误
+func sortDocuments(docs []bson.M, spec interface{}) error {
+	var keys bson.D
+	switch s := spec.(type) {
+	case bson.D:
+		keys = s
+	case bson.M:
+		if len(s) > 1 {
+			return fmt.Errorf("memory repository: multi-field sort must use bson.D")
+		}
+		for k, v := range s {
+			keys = bson.D{{Key: k, Value: v}}
+		}
+	default:
+		return fmt.Errorf("memory repository: unsupported sort type %T", spec)
+	}
+	for _, key := range keys {
+		if _, ok := toFloat(key.Value); !ok {
+			return fmt.Errorf("memory repository: unsupported sort value for %s", key.Key)
+		}
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, key := range keys {
+			a, _ := lookupPath(docs[i], key.Key)
+			b, _ := lookupPath(docs[j], key.Key)
+			c := compareForSort(a, b)
+			if c == 0 {
+				continue
+			}
+			if dir, _ := toFloat(key.Value); dir < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	return nil
+}
+
+// applyUpdate 对文档执行更新操作符，inserting 为 true 时 $setOnInsert 生效
+func applyUpdate(doc bson.M, update bson.M, inserting bool) (bson.M, error) {
+	if len(update) == 0 {
+		return nil, fmt.Errorf("memory repository: update document must not be empty")
+	}
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return nil, fmt.Errorf("memory repository: update document must contain only operators, got %s", op)
+		}
+		for path, value := range fields {
+			if path == "_id" && op != "$setOnInsert" {
+				current, _ := lookupPath(doc, path)
+				if op != "$set" || !valuesEqual(current, value) {
+					return nil, fmt.Errorf("memory repository: cannot modify _id")
+				}
+				continue
+			}
+			switch op {
+			case "$set":
+				setPath(doc, path, value)
+			case "$setOnInsert":
+				if inserting {
+					setPath(doc, path, value)
+				}
+			case "$unset":
+				unsetPath(doc, path)
+			case "$inc":
+				current, present := lookupPath(doc, path)
+				sum, err := addNumbers(current, present, value)
+				if err != nil {
+					return nil, fmt.Errorf("memory repository: $inc %s: %w", path, err)
+				}
+				setPath(doc, path, sum)
+			case "$push":
+				current, present := lookupPath(doc, path)
+				arr, isArray := current.(primitive.A)
+				if present && !isArray {
+					return nil, fmt.Errorf("memory repository: $push %s: field is not an array", path)
+				}
+				if each, ok := value.(bson.M); ok {
+					if items, ok := each["$each"].(primitive.A); ok {
+						arr = append(arr, items...)
+						setPath(doc, path, arr)
+						continue
+					}
+				}
+				setPath(doc, path, append(arr, value))
+			case "$currentDate":
+				setPath(doc, path, primitive.NewDateTimeFromTime(time.Now()))
+			default:
+				return nil, fmt.Errorf("memory repository: unsupported update operator %s", op)
+			}
+		}
+	}
+	return doc, nil
+}
+
+func addNumbers(current interface{}, present bool, delta interface{}) (interface{}, error) {
+	d, ok := toFloat(delta)
+	if !ok {
+		return nil, fmt.Errorf("increment must be a number")
+	}
+	if !present {
+		return delta, nil
+	}
+	c, ok := toFloat(current)
+	if !ok {
+		return nil, fmt.Errorf("field is not a number")
+	}
+	_, currentFloat := current.(float64)
+	_, deltaFloat := delta.(float64)
+	if currentFloat || deltaFloat {
+		return c + d, nil
+	}
+	sum := c + d
+	if sum >= math.MinInt32 && sum <= math.MaxInt32 {
+		if _, ok := current.(int32); ok {
+			if _, ok := delta.(int32); ok {
+				return int32(sum), nil
+			}
+		}
+	}
+	return int64(sum), nil
+}
+
+// setPath 按点号路径写入值，中间不存在的子文档自动创建
+func setPath(doc bson.M, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(bson.M)
+		if !ok {
+			next = bson.M{}
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func unsetPath(doc bson.M, path string) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(bson.M)
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
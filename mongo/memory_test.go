@@ -0,0 +1,117 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type memoryItem struct {
+	BaseDocument `bson:",inline"`
+	Name         string   `bson:"name"`
+	Price        float64  `bson:"price"`
+	Stock        int64    `bson:"stock"`
+	Tags         []string `bson:"tags"`
+	Meta         struct {
+		Color string `bson:"color"`
+	} `bson:"meta"`
+}
+
+func seedMemoryItems(t *testing.T) *MemoryRepository[memoryItem] {
+	repo := NewMemoryRepository[memoryItem](nil)
+	for i, name := range []string{"apple", "banana", "cherry", "durian"} {
+		item := &memoryItem{Name: name, Price: float64(i + 1), Stock: int64(10 * i), Tags: []string{"fruit"}}
+		if i%2 == 0 {
+			item.Tags = append(item.Tags, "red")
+			item.Meta.Color = "red"
+		}
+		_, err := repo.Insert(t.Context(), item)
+		require.NoError(t, err)
+		require.False(t, item.ID.IsZero())
+	}
+	return repo
+}
+
+func TestMemoryRepositoryFind(t *testing.T) {
+	ctx := t.Context()
+	repo := seedMemoryItems(t)
+
+	cases := []struct {
+		filter bson.M
+		want   []string
+	}{
+		{bson.M{"name": "banana"}, []string{"banana"}},
+		{bson.M{"price": bson.M{"$gte": 2, "$lt": 4}}, []string{"banana", "cherry"}},
+		{bson.M{"tags": "red"}, []string{"apple", "cherry"}},
+		{bson.M{"meta.color": "red"}, []string{"apple", "cherry"}},
+		{bson.M{"name": bson.M{"$in": []string{"apple", "durian"}}}, []string{"apple", "durian"}},
+		{bson.M{"name": bson.M{"$nin": []string{"apple", "durian"}}}, []string{"banana", "cherry"}},
+		{bson.M{"$or": []bson.M{{"name": "apple"}, {"stock": bson.M{"$gt": 20}}}}, []string{"apple", "durian"}},
+		{bson.M{"name": bson.M{"$regex": "^B", "$options": "i"}}, []string{"banana"}},
+		{bson.M{"tags": bson.M{"$size": 1}}, []string{"banana", "durian"}},
+		{bson.M{"missing": bson.M{"$exists": false}, "stock": bson.M{"$ne": 0}}, []string{"banana", "cherry", "durian"}},
+	}
+	for _, tc := range cases {
+		items, err := repo.Find(ctx, tc.filter)
+		require.NoError(t, err, tc.filter)
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Name)
+		}
+		assert.Equal(t, tc.want, names, tc.filter)
+	}
+
+	_, err := repo.Find(ctx, bson.M{"name": bson.M{"$elemMatch": bson.M{}}})
+	assert.Error(t, err)
+
+	items, err := repo.Find(ctx, nil, options.Find().SetSort(bson.D{{Key: "price", Value: -1}}).SetSkip(1).SetLimit(2))
+	require.NoError(t, err)
+	assert.Equal(t, "cherry", items[0].Name)
+	assert.Equal(t, "banana", items[1].Name)
+
+	page, result, err := repo.FindWithPagination(ctx, bson.M{}, 2, 3)
+	require.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, &PaginationResult{Page: 2, PageSize: 3, Total: 4, TotalPage: 2}, result)
+}
+
+func TestMemoryRepositoryWrite(t *testing.T) {
+	ctx := t.Context()
+	repo := seedMemoryItems(t)
+
+	apple, err := repo.FindOne(ctx, bson.M{"name": "apple"})
+	require.NoError(t, err)
+
+	_, err = repo.Insert(ctx, apple)
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+
+	result, err := repo.UpdateByID(ctx, apple.ID.Hex(), bson.M{"$inc": bson.M{"stock": 5}, "$push": bson.M{"tags": "sale"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+
+	updated, err := repo.FindByID(ctx, apple.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), updated.Stock)
+	assert.Equal(t, []string{"fruit", "red", "sale"}, updated.Tags)
+
+	modified := *updated
+	modified.Price = 9.5
+	result, err = repo.UpdateChanged(ctx, updated, &modified)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.ModifiedCount)
+	n, err := repo.Count(ctx, bson.M{"price": 9.5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = repo.UpdateByID(ctx, apple.ID, bson.M{"$rename": bson.M{"name": "title"}})
+	assert.Error(t, err)
+
+	deleted, err := repo.DeleteByID(ctx, apple.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted.DeletedCount)
+	_, err = repo.FindByID(ctx, apple.ID)
+	assert.EqualError(t, err, "document not found")
+}
@@ -0,0 +1,336 @@
+package mongo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrMigrationLocked 其他实例正在执行迁移
+	ErrMigrationLocked = errors.New("migration is locked by another process")
+	// ErrIrreversibleMigration 迁移没有定义 Down，无法回滚
+	ErrIrreversibleMigration = errors.New("migration is irreversible")
+)
+
+// Migration 一次数据库结构或数据变更
+type Migration struct {
+	// Version 版本号，按字典序执行，建议使用 20060102150405 格式的时间戳
+	Version     string
+	Description string
+	Up          func(ctx context.Context, client *Client) error
+	// Down 回滚操作，为 nil 时迁移不可回滚
+	Down func(ctx context.Context, client *Client) error
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Version     string
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+	// Missing 已执行但当前迁移列表中不存在，通常是迁移文件被删除或分支不一致
+	Missing bool
+}
+
+// MigratorOptions 迁移选项
+type MigratorOptions struct {
+	// Collection 记录已执行迁移的集合，默认 schema_migrations
+	Collection string
+	// LockTTL 迁移锁的过期时间，默认 10 分钟；执行进程异常退出后锁在到期后释放
+	LockTTL time.Duration
+}
+
+// migrationRecord 已执行迁移的记录
+type migrationRecord struct {
+	Version     string    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Migrator 按版本顺序执行迁移，并在集合中记录已执行的版本；
+// Up、Down 期间持有分布式锁，多个实例同时启动时只有一个执行迁移
+//
+//	migrator, err := NewMigrator(client, []Migration{
+//		{Version: "20240102150405", Description: "add email index", Up: addEmailIndex, Down: dropEmailIndex},
+//	}, nil)
+//	applied, err := migrator.Up(ctx, "")
+type Migrator struct {
+	client     *Client
+	records    *Collection
+	lock       *DistributedLock
+	lockTTL    time.Duration
+	migrations []Migration
+}
+
+// NewMigrator 创建迁移执行器，版本号重复或 Up 为空时返回错误
+func NewMigrator(client *Client, migrations []Migration, opts *MigratorOptions) (*Migrator, error) {
+	o := MigratorOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Collection == "" {
+		o.Collection = "schema_migrations"
+	}
+	if o.LockTTL <= 0 {
+		o.LockTTL = 10 * time.Minute
+	}
+
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, m := range sorted {
+		if m.Version == "" || m.Up == nil {
+			return nil, fmt.Errorf("invalid migration %q: version and Up are required", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %s", m.Version)
+		}
+	}
+
+	return &Migrator{
+		client:     client,
+		records:    NewCollection(client, o.Collection),
+		lock:       NewDistributedLock(client, o.Collection+"_lock"),
+		lockTTL:    o.LockTTL,
+		migrations: sorted,
+	}, nil
+}
+
+// Status 返回所有迁移的状态，按版本排序
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(m.migrations, applied), nil
+}
+
+func migrationStatus(migrations []Migration, applied map[string]migrationRecord) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	known := make(map[string]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range applied {
+		if !known[version] {
+			statuses = append(statuses, MigrationStatus{
+				Version: version, Description: record.Description, Applied: true, AppliedAt: record.AppliedAt, Missing: true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// Up 按顺序执行未执行的迁移，target 不为空时只执行版本不大于 target 的迁移；
+// 某个迁移失败时停止，返回已经成功执行的版本
+func (m *Migrator) Up(ctx context.Context, target string) ([]string, error) {
+	var done []string
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if target != "" && migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := migration.Up(ctx, m.client); err != nil {
+				return fmt.Errorf("migration %s failed: %w", migration.Version, err)
+			}
+			record := migrationRecord{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+			if _, err := m.records.InsertOne(ctx, record); err != nil {
+				return fmt.Errorf("failed to record migration %s: %w", migration.Version, err)
+			}
+			done = append(done, migration.Version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回已经回滚的版本
+func (m *Migrator) Down(ctx context.Context, steps int) ([]string, error) {
+	var done []string
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+		versions := make([]string, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+		if steps < len(versions) {
+			versions = versions[:max(steps, 0)]
+		}
+
+		for _, version := range versions {
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("cannot roll back migration %s: not found", version)
+			}
+			if migration.Down == nil {
+				return fmt.Errorf("cannot roll back migration %s: %w", version, ErrIrreversibleMigration)
+			}
+			if err := migration.Down(ctx, m.client); err != nil {
+				return fmt.Errorf("rollback of migration %s failed: %w", version, err)
+			}
+			if _, err := m.records.DeleteOne(ctx, bson.M{"_id": version}); err != nil {
+				return fmt.Errorf("failed to remove migration record %s: %w", version, err)
+			}
+			done = append(done, version)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (m *Migrator) find(version string) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[string]migrationRecord, error) {
+	var records []migrationRecord
+	if err := m.records.Find(ctx, bson.M{}, &records, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})); err != nil {
+		return nil, fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	applied := make(map[string]migrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	const name = "migrations"
+	ok, err := m.lock.Acquire(ctx, name, m.lockTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMigrationLocked
+	}
+	defer m.lock.Release(context.WithoutCancel(ctx), name)
+	return fn()
+}
+
+// migrationFilePattern 迁移文件名：<版本>_<名称>.json
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.json$`)
+
+// migrationFile 迁移文件的内容，up、down 为按顺序执行的数据库命令（Extended JSON）
+type migrationFile struct {
+	Description string    `bson:"description"`
+	Up          []bson.D  `bson:"up"`
+	Down        *[]bson.D `bson:"down"`
+}
+
+// LoadMigrations 读取目录中的 <版本>_<名称>.json 迁移文件，其他文件被忽略；
+// 文件中的 up、down 是依次通过 runCommand 执行的命令，省略 down 时迁移不可回滚
+//
+//	{
+//	  "description": "add email index",
+//	  "up": [{"createIndexes": "users", "indexes": [{"key": {"email": 1}, "name": "idx_email", "unique": true}]}],
+//	  "down": [{"dropIndexes": "users", "index": "idx_email"}]
+//	}
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration directory: %w", err)
+	}
+	var migrations []Migration
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		var file migrationFile
+		if err := bson.UnmarshalExtJSON(data, false, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse migration %s: %w", entry.Name(), err)
+		}
+		migration := Migration{
+			Version:     match[1],
+			Description: file.Description,
+			Up:          runCommands(file.Up),
+		}
+		if migration.Description == "" {
+			migration.Description = strings.ReplaceAll(match[2], "_", " ")
+		}
+		if file.Down != nil {
+			migration.Down = runCommands(*file.Down)
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// runCommands 依次执行数据库命令，命令名必须是文档的第一个字段
+func runCommands(commands []bson.D) func(ctx context.Context, client *Client) error {
+	return func(ctx context.Context, client *Client) error {
+		for i, command := range commands {
+			if err := client.GetDatabase().RunCommand(ctx, command).Err(); err != nil {
+				return fmt.Errorf("command %d (%s): %w", i+1, commandName(command), err)
+			}
+		}
+		return nil
+	}
+}
+
+func commandName(command bson.D) string {
+	if len(command) == 0 {
+		return "empty"
+	}
+	return command[0].Key
+}
+
+// CreateMigrationFile 在目录中生成以当前时间为版本号的迁移文件骨架，返回文件路径
+func CreateMigrationFile(dir, name string, now time.Time) (string, error) {
+	slug := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", fmt.Errorf("invalid migration name %q", name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create migration directory: %w", err)
+	}
+	path := filepath.Join(dir, now.UTC().Format("20060102150405")+"_"+slug+".json")
+	description, _ := json.Marshal(name)
+	content := fmt.Sprintf("{\n  \"description\": %s,\n  \"up\": [],\n  \"down\": []\n}\n", description)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create migration file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		return "", fmt.Errorf("failed to write migration file: %w", err)
+	}
+	return path, nil
+}
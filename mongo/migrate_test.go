@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMigratorValidation(t *testing.T) {
+	up := func(ctx context.Context, client *Client) error { return nil }
+	client := newLazyClient(t)
+
+	migrator, err := NewMigrator(client, []Migration{{Version: "2", Up: up}, {Version: "1", Up: up}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1", migrator.migrations[0].Version)
+
+	_, err = NewMigrator(client, []Migration{{Version: "1", Up: up}, {Version: "1", Up: up}}, nil)
+	assert.ErrorContains(t, err, "duplicate migration version 1")
+	_, err = NewMigrator(client, []Migration{{Version: "1"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestMigrationStatus(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	statuses := migrationStatus(
+		[]Migration{{Version: "1", Description: "one"}, {Version: "3", Description: "three"}},
+		map[string]migrationRecord{
+			"1": {Version: "1", Description: "one", AppliedAt: at},
+			"2": {Version: "2", Description: "two", AppliedAt: at},
+		},
+	)
+	assert.Equal(t, []MigrationStatus{
+		{Version: "1", Description: "one", Applied: true, AppliedAt: at},
+		{Version: "2", Description: "two", Applied: true, AppliedAt: at, Missing: true},
+		{Version: "3", Description: "three"},
+	}, statuses)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := t.TempDir()
+	path, err := CreateMigrationFile(dir, "Add email index", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20240102150405_add_email_index.json"), path)
+	_, err = CreateMigrationFile(dir, "Add email index", time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC))
+	assert.Error(t, err, "existing files are not overwritten")
+	_, err = CreateMigrationFile(dir, "!!", time.Now())
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "20240103000000_backfill.json"),
+		[]byte(`{"up": [{"update": "users", "updates": [{"q": {}, "u": {"$set": {"active": true}}, "multi": true}]}]}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o644))
+
+	migrations, err := LoadMigrations(dir)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "20240102150405", migrations[0].Version)
+	assert.Equal(t, "Add email index", migrations[0].Description)
+	assert.NotNil(t, migrations[0].Down)
+	assert.Equal(t, "backfill", migrations[1].Description)
+	assert.Nil(t, migrations[1].Down, "missing down makes the migration irreversible")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "20240104000000_broken.json"), []byte(`{"up": `), 0o644))
+	_, err = LoadMigrations(dir)
+	assert.ErrorContains(t, err, "20240104000000_broken.json")
+}
@@ -0,0 +1,294 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MirrorMode 镜像写入模式
+type MirrorMode int
+
+const (
+	// MirrorAsync 主库写入成功后将写操作放入队列，由后台 worker 写入副本，不影响主库写入的延迟
+	MirrorAsync MirrorMode = iota
+	// MirrorSync 主库写入成功后立即在当前请求中写入副本
+	MirrorSync
+)
+
+// MirrorOptions 镜像写入配置
+type MirrorOptions struct {
+	// Mode 镜像模式，默认 MirrorAsync
+	Mode MirrorMode
+	// QueueSize 异步模式的队列长度，队列满时丢弃并计入 Dropped，默认 1000
+	QueueSize int
+	// Workers 异步模式的 worker 数，默认 1；大于 1 时不再保证写入副本的顺序
+	Workers int
+	// Timeout 单个镜像写操作的超时时间，默认 5 秒
+	Timeout time.Duration
+	// FailOnError 同步模式下副本写入失败时是否向调用方返回错误，此时主库已经写入成功
+	FailOnError bool
+	// OnDivergence 副本写入失败或影响的文档数与主库不一致时的回调，会在 worker 或请求 goroutine 中调用
+	OnDivergence func(divergence MirrorDivergence)
+}
+
+// MirrorOutcome 写操作影响的文档数，用于比较主库和副本的结果
+type MirrorOutcome struct {
+	Inserted int64 `json:"inserted"`
+	Matched  int64 `json:"matched"`
+	Modified int64 `json:"modified"`
+	Upserted int64 `json:"upserted"`
+	Deleted  int64 `json:"deleted"`
+}
+
+// MirrorDivergence 主库和副本不一致的写操作
+type MirrorDivergence struct {
+	Collection string
+	Operation  string
+	Primary    MirrorOutcome
+	Secondary  MirrorOutcome
+	// Err 副本写入失败时的错误
+	Err error
+}
+
+// MirrorMetrics 镜像写入统计
+type MirrorMetrics struct {
+	// Mirrored 成功写入副本的操作数
+	Mirrored int64 `json:"mirrored"`
+	// Failed 写入副本失败的操作数
+	Failed int64 `json:"failed"`
+	// Diverged 副本影响的文档数与主库不一致的操作数（不含失败）
+	Diverged int64 `json:"diverged"`
+	// Dropped 异步队列已满或已停止而丢弃的操作数
+	Dropped int64 `json:"dropped"`
+	// Pending 异步队列中等待写入的操作数
+	Pending int64 `json:"pending"`
+}
+
+// mirrorApply 在副本集合上重放写操作
+type mirrorApply func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error)
+
+// mirrorOp 待写入副本的操作
+type mirrorOp struct {
+	collection string
+	operation  string
+	primary    MirrorOutcome
+	apply      mirrorApply
+}
+
+// Mirror 将集合上的写操作镜像到另一个 Client（其它集群或数据库）的同名集合，用于集群间在线迁移：
+// 迁移期间应用同时写入新旧集群，配合 CopyCollection 复制历史数据，通过 Metrics 和 OnDivergence 观察两边是否一致。
+// 只有主库写入成功的操作才会镜像，镜像失败不会回滚主库；事务中的写操作会立即镜像，不随事务回滚
+//
+//	mirror := NewMirror(newClient, &MirrorOptions{Mode: MirrorAsync})
+//	mirror.Start(ctx)
+//	defer mirror.Stop()
+//	users := NewCollection(oldClient, "users").WithMirror(mirror)
+type Mirror struct {
+	client *Client
+	opts   MirrorOptions
+	queue  chan mirrorOp
+
+	mirrored atomic.Int64
+	failed   atomic.Int64
+	diverged atomic.Int64
+	dropped  atomic.Int64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewMirror 创建镜像写入器，异步模式需要调用 Start 启动后台 worker
+func NewMirror(secondary *Client, opts *MirrorOptions) *Mirror {
+	m := &Mirror{
+		client: secondary,
+		stopCh: make(chan struct{}),
+	}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.QueueSize <= 0 {
+		m.opts.QueueSize = 1000
+	}
+	if m.opts.Workers <= 0 {
+		m.opts.Workers = 1
+	}
+	if m.opts.Timeout <= 0 {
+		m.opts.Timeout = 5 * time.Second
+	}
+	m.queue = make(chan mirrorOp, m.opts.QueueSize)
+	return m
+}
+
+// Start 启动异步模式的后台 worker
+func (m *Mirror) Start(ctx context.Context) {
+	m.startOnce.Do(func() {
+		m.client.RegisterShutdown(m)
+		for i := 0; i < m.opts.Workers; i++ {
+			m.wg.Add(1)
+			go m.run(ctx)
+		}
+	})
+}
+
+// Stop 停止接收新的镜像操作，并等待队列中已有的操作写入副本
+func (m *Mirror) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+		m.wg.Wait()
+	})
+}
+
+// Metrics 返回镜像写入统计
+func (m *Mirror) Metrics() MirrorMetrics {
+	return MirrorMetrics{
+		Mirrored: m.mirrored.Load(),
+		Failed:   m.failed.Load(),
+		Diverged: m.diverged.Load(),
+		Dropped:  m.dropped.Load(),
+		Pending:  int64(len(m.queue)),
+	}
+}
+
+// run worker 循环，停止时写完队列中剩余的操作
+func (m *Mirror) run(ctx context.Context) {
+	defer m.wg.Done()
+	for {
+		select {
+		case op := <-m.queue:
+			_ = m.apply(context.WithoutCancel(ctx), op)
+		case <-m.stopCh:
+			for {
+				select {
+				case op := <-m.queue:
+					_ = m.apply(context.WithoutCancel(ctx), op)
+				default:
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// submit 按模式提交镜像操作，只有同步模式且 FailOnError 时返回副本的错误
+func (m *Mirror) submit(ctx context.Context, op mirrorOp) error {
+	if m.opts.Mode == MirrorSync {
+		err := m.apply(context.WithoutCancel(ctx), op)
+		if err != nil && m.opts.FailOnError {
+			return err
+		}
+		return nil
+	}
+
+	select {
+	case <-m.stopCh:
+		m.dropped.Add(1)
+		return nil
+	default:
+	}
+	select {
+	case m.queue <- op:
+	default:
+		m.dropped.Add(1)
+		m.client.logger.WarnContext(ctx, "Mirror queue is full, write dropped", "collection", op.collection, "operation", op.operation)
+	}
+	return nil
+}
+
+// apply 在副本上执行操作并比较结果
+func (m *Mirror) apply(ctx context.Context, op mirrorOp) error {
+	ctx, cancel := context.WithTimeout(ctx, m.opts.Timeout)
+	defer cancel()
+
+	secondary, err := op.apply(ctx, m.client.database.Collection(op.collection))
+	switch {
+	case err != nil:
+		m.failed.Add(1)
+		err = fmt.Errorf("failed to mirror %s on %s: %w", op.operation, op.collection, err)
+		m.client.logger.ErrorContext(ctx, "Mirror write failed", "collection", op.collection, "operation", op.operation, "err", err)
+	case secondary != op.primary:
+		m.mirrored.Add(1)
+		m.diverged.Add(1)
+	default:
+		m.mirrored.Add(1)
+		return nil
+	}
+
+	if m.opts.OnDivergence != nil {
+		m.opts.OnDivergence(MirrorDivergence{
+			Collection: op.collection,
+			Operation:  op.operation,
+			Primary:    op.primary,
+			Secondary:  secondary,
+			Err:        err,
+		})
+	}
+	return err
+}
+
+// WithMirror 返回将写操作镜像到 mirror 的集合副本，副本集合与当前集合同名
+func (c *Collection) WithMirror(mirror *Mirror) *Collection {
+	cp := *c
+	cp.mirror = mirror
+	return &cp
+}
+
+// mirrorWrite 主库写入成功后镜像写操作，未配置镜像时为空操作
+func (c *Collection) mirrorWrite(ctx context.Context, operation string, primary MirrorOutcome, apply mirrorApply) error {
+	if c.mirror == nil {
+		return nil
+	}
+	return c.mirror.submit(ctx, mirrorOp{
+		collection: c.collection.Name(),
+		operation:  operation,
+		primary:    primary,
+		apply:      apply,
+	})
+}
+
+// updateOutcome 将更新结果转换为 MirrorOutcome
+func updateOutcome(result *mongo.UpdateResult) MirrorOutcome {
+	if result == nil {
+		return MirrorOutcome{}
+	}
+	return MirrorOutcome{Matched: result.MatchedCount, Modified: result.ModifiedCount, Upserted: result.UpsertedCount}
+}
+
+// mirrorUpdate 镜像 UpdateOne/UpdateMany/ReplaceOne 等返回 UpdateResult 的操作
+func mirrorUpdate(fn func(ctx context.Context, coll *mongo.Collection) (*mongo.UpdateResult, error)) mirrorApply {
+	return func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error) {
+		result, err := fn(ctx, coll)
+		return updateOutcome(result), err
+	}
+}
+
+// mirrorInsert 镜像插入操作，documents 在提交时转换为带主库 _id 的 bson.M 快照，避免调用方之后修改文档
+func (c *Collection) mirrorInsert(ctx context.Context, documents []interface{}, ids []interface{}) error {
+	if c.mirror == nil || len(ids) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(ids))
+	for i, id := range ids {
+		doc, err := toBsonM(documents[i])
+		if err != nil {
+			return err
+		}
+		doc["_id"] = id
+		docs = append(docs, doc)
+	}
+	return c.mirrorWrite(ctx, "insert", MirrorOutcome{Inserted: int64(len(docs))}, func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error) {
+		result, err := coll.InsertMany(ctx, docs)
+		if result == nil {
+			return MirrorOutcome{}, err
+		}
+		return MirrorOutcome{Inserted: int64(len(result.InsertedIDs))}, err
+	})
+}
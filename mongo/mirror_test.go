@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newLazyClient 返回延迟建立连接的客户端，只要不执行数据库操作就不需要 MongoDB
+func newLazyClient(t *testing.T) *Client {
+	driver, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	require.NoError(t, err)
+	t.Cleanup(func() { driver.Disconnect(context.Background()) })
+	return &Client{client: driver, database: driver.Database("test"), dbName: "test", logger: defaultLogger()}
+}
+
+func mirrorTestOp(outcome MirrorOutcome, err error) mirrorOp {
+	return mirrorOp{
+		collection: "users",
+		operation:  "updateOne",
+		primary:    MirrorOutcome{Matched: 1, Modified: 1},
+		apply: func(ctx context.Context, coll *mongo.Collection) (MirrorOutcome, error) {
+			return outcome, err
+		},
+	}
+}
+
+func TestMirrorSync(t *testing.T) {
+	var divergences []MirrorDivergence
+	m := NewMirror(newLazyClient(t), &MirrorOptions{
+		Mode:         MirrorSync,
+		FailOnError:  true,
+		OnDivergence: func(d MirrorDivergence) { divergences = append(divergences, d) },
+	})
+	ctx := context.Background()
+
+	require.NoError(t, m.submit(ctx, mirrorTestOp(MirrorOutcome{Matched: 1, Modified: 1}, nil)))
+	require.NoError(t, m.submit(ctx, mirrorTestOp(MirrorOutcome{}, nil)))
+	boom := errors.New("boom")
+	err := m.submit(ctx, mirrorTestOp(MirrorOutcome{}, boom))
+	assert.ErrorIs(t, err, boom)
+
+	assert.Equal(t, MirrorMetrics{Mirrored: 2, Failed: 1, Diverged: 1}, m.Metrics())
+	require.Len(t, divergences, 2)
+	assert.Equal(t, "users", divergences[0].Collection)
+	assert.Nil(t, divergences[0].Err)
+	assert.ErrorIs(t, divergences[1].Err, boom)
+}
+
+func TestMirrorAsync(t *testing.T) {
+	m := NewMirror(newLazyClient(t), &MirrorOptions{QueueSize: 2})
+	ctx := context.Background()
+
+	// 未启动时队列满后丢弃
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.submit(ctx, mirrorTestOp(MirrorOutcome{Matched: 1, Modified: 1}, nil)))
+	}
+	assert.Equal(t, MirrorMetrics{Dropped: 1, Pending: 2}, m.Metrics())
+
+	// 停止时写完队列中剩余的操作，之后提交的操作被丢弃
+	m.Start(ctx)
+	m.Stop()
+	require.NoError(t, m.submit(ctx, mirrorTestOp(MirrorOutcome{}, nil)))
+	assert.Equal(t, MirrorMetrics{Mirrored: 2, Dropped: 2}, m.Metrics())
+}
+
+func TestCollectionWithoutMirror(t *testing.T) {
+	c := &Collection{}
+	assert.NoError(t, c.mirrorWrite(context.Background(), "insert", MirrorOutcome{}, nil))
+	assert.NoError(t, c.mirrorInsert(context.Background(), nil, nil))
+}
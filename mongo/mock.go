@@ -0,0 +1,328 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrMockNotConfigured 调用了 MockCollection/MockRepository 中没有设置的方法
+var ErrMockNotConfigured = errors.New("mock method not configured")
+
+// MockCall 记录的一次调用，Args 不包含 ctx 和可变的 opts 参数
+type MockCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// mockRecorder 记录调用，零值可用
+type mockRecorder struct {
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+func (r *mockRecorder) record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, MockCall{Method: method, Args: args})
+}
+
+// Calls 返回按调用顺序记录的所有调用
+func (r *mockRecorder) Calls() []MockCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]MockCall(nil), r.calls...)
+}
+
+// CallCount 返回指定方法被调用的次数
+func (r *mockRecorder) CallCount(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, call := range r.calls {
+		if call.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func notConfigured(method string) error {
+	return fmt.Errorf("%w: %s", ErrMockNotConfigured, method)
+}
+
+// MockCollection 手写的 CollectionAPI 测试替身，每个方法对应一个 Func 字段，
+// 没有设置的方法返回 ErrMockNotConfigured；所有调用都会被记录，可以通过 Calls 和 CallCount 断言
+//
+//	users := &MockCollection{
+//		FindOneFunc: func(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error {
+//			*result.(*User) = User{Username: "alice"}
+//			return nil
+//		},
+//	}
+//	svc := NewUserService(users)
+type MockCollection struct {
+	mockRecorder
+
+	FindOneFunc            func(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error
+	FindByIDFunc           func(ctx context.Context, id interface{}, result interface{}, opts ...*options.FindOneOptions) error
+	FindFunc               func(ctx context.Context, filter bson.M, results interface{}, opts ...*options.FindOptions) error
+	FindWithPaginationFunc func(ctx context.Context, filter bson.M, page, pageSize int64, results interface{}, opts ...*options.FindOptions) (*PaginationResult, error)
+	CountFunc              func(ctx context.Context, filter bson.M) (int64, error)
+	ExistsFunc             func(ctx context.Context, filter bson.M) (bool, error)
+	DistinctFunc           func(ctx context.Context, field string, filter bson.M, opts ...*options.DistinctOptions) ([]interface{}, error)
+
+	InsertOneFunc  func(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error)
+	InsertManyFunc func(ctx context.Context, documents []interface{}) (*mongo.InsertManyResult, error)
+	UpdateOneFunc  func(ctx context.Context, filter bson.M, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateByIDFunc func(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateManyFunc func(ctx context.Context, filter bson.M, update bson.M, opts ...*MassWriteOptions) (*mongo.UpdateResult, error)
+	UpsertFunc     func(ctx context.Context, filter bson.M, document interface{}) (*mongo.UpdateResult, error)
+	ReplaceOneFunc func(ctx context.Context, filter bson.M, replacement interface{}) (*mongo.UpdateResult, error)
+	DeleteOneFunc  func(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error)
+	DeleteByIDFunc func(ctx context.Context, id interface{}) (*mongo.DeleteResult, error)
+	DeleteManyFunc func(ctx context.Context, filter bson.M, opts ...*MassWriteOptions) (*mongo.DeleteResult, error)
+
+	AggregateFunc func(ctx context.Context, pipeline []bson.M, results interface{}, opts ...*options.AggregateOptions) error
+}
+
+var _ CollectionAPI = (*MockCollection)(nil)
+
+func (m *MockCollection) FindOne(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error {
+	m.record("FindOne", filter)
+	if m.FindOneFunc == nil {
+		return notConfigured("FindOne")
+	}
+	return m.FindOneFunc(ctx, filter, result, opts...)
+}
+
+func (m *MockCollection) FindByID(ctx context.Context, id interface{}, result interface{}, opts ...*options.FindOneOptions) error {
+	m.record("FindByID", id)
+	if m.FindByIDFunc == nil {
+		return notConfigured("FindByID")
+	}
+	return m.FindByIDFunc(ctx, id, result, opts...)
+}
+
+func (m *MockCollection) Find(ctx context.Context, filter bson.M, results interface{}, opts ...*options.FindOptions) error {
+	m.record("Find", filter)
+	if m.FindFunc == nil {
+		return notConfigured("Find")
+	}
+	return m.FindFunc(ctx, filter, results, opts...)
+}
+
+func (m *MockCollection) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, results interface{}, opts ...*options.FindOptions) (*PaginationResult, error) {
+	m.record("FindWithPagination", filter, page, pageSize)
+	if m.FindWithPaginationFunc == nil {
+		return nil, notConfigured("FindWithPagination")
+	}
+	return m.FindWithPaginationFunc(ctx, filter, page, pageSize, results, opts...)
+}
+
+func (m *MockCollection) Count(ctx context.Context, filter bson.M) (int64, error) {
+	m.record("Count", filter)
+	if m.CountFunc == nil {
+		return 0, notConfigured("Count")
+	}
+	return m.CountFunc(ctx, filter)
+}
+
+func (m *MockCollection) Exists(ctx context.Context, filter bson.M) (bool, error) {
+	m.record("Exists", filter)
+	if m.ExistsFunc == nil {
+		return false, notConfigured("Exists")
+	}
+	return m.ExistsFunc(ctx, filter)
+}
+
+func (m *MockCollection) Distinct(ctx context.Context, field string, filter bson.M, opts ...*options.DistinctOptions) ([]interface{}, error) {
+	m.record("Distinct", field, filter)
+	if m.DistinctFunc == nil {
+		return nil, notConfigured("Distinct")
+	}
+	return m.DistinctFunc(ctx, field, filter, opts...)
+}
+
+func (m *MockCollection) InsertOne(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error) {
+	m.record("InsertOne", document)
+	if m.InsertOneFunc == nil {
+		return nil, notConfigured("InsertOne")
+	}
+	return m.InsertOneFunc(ctx, document)
+}
+
+func (m *MockCollection) InsertMany(ctx context.Context, documents []interface{}) (*mongo.InsertManyResult, error) {
+	m.record("InsertMany", documents)
+	if m.InsertManyFunc == nil {
+		return nil, notConfigured("InsertMany")
+	}
+	return m.InsertManyFunc(ctx, documents)
+}
+
+func (m *MockCollection) UpdateOne(ctx context.Context, filter bson.M, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.record("UpdateOne", filter, update)
+	if m.UpdateOneFunc == nil {
+		return nil, notConfigured("UpdateOne")
+	}
+	return m.UpdateOneFunc(ctx, filter, update, opts...)
+}
+
+func (m *MockCollection) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.record("UpdateByID", id, update)
+	if m.UpdateByIDFunc == nil {
+		return nil, notConfigured("UpdateByID")
+	}
+	return m.UpdateByIDFunc(ctx, id, update, opts...)
+}
+
+func (m *MockCollection) UpdateMany(ctx context.Context, filter bson.M, update bson.M, opts ...*MassWriteOptions) (*mongo.UpdateResult, error) {
+	m.record("UpdateMany", filter, update)
+	if m.UpdateManyFunc == nil {
+		return nil, notConfigured("UpdateMany")
+	}
+	return m.UpdateManyFunc(ctx, filter, update, opts...)
+}
+
+func (m *MockCollection) Upsert(ctx context.Context, filter bson.M, document interface{}) (*mongo.UpdateResult, error) {
+	m.record("Upsert", filter, document)
+	if m.UpsertFunc == nil {
+		return nil, notConfigured("Upsert")
+	}
+	return m.UpsertFunc(ctx, filter, document)
+}
+
+func (m *MockCollection) ReplaceOne(ctx context.Context, filter bson.M, replacement interface{}) (*mongo.UpdateResult, error) {
+	m.record("ReplaceOne", filter, replacement)
+	if m.ReplaceOneFunc == nil {
+		return nil, notConfigured("ReplaceOne")
+	}
+	return m.ReplaceOneFunc(ctx, filter, replacement)
+}
+
+func (m *MockCollection) DeleteOne(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error) {
+	m.record("DeleteOne", filter)
+	if m.DeleteOneFunc == nil {
+		return nil, notConfigured("DeleteOne")
+	}
+	return m.DeleteOneFunc(ctx, filter)
+}
+
+func (m *MockCollection) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	m.record("DeleteByID", id)
+	if m.DeleteByIDFunc == nil {
+		return nil, notConfigured("DeleteByID")
+	}
+	return m.DeleteByIDFunc(ctx, id)
+}
+
+func (m *MockCollection) DeleteMany(ctx context.Context, filter bson.M, opts ...*MassWriteOptions) (*mongo.DeleteResult, error) {
+	m.record("DeleteMany", filter)
+	if m.DeleteManyFunc == nil {
+		return nil, notConfigured("DeleteMany")
+	}
+	return m.DeleteManyFunc(ctx, filter, opts...)
+}
+
+func (m *MockCollection) Aggregate(ctx context.Context, pipeline []bson.M, results interface{}, opts ...*options.AggregateOptions) error {
+	m.record("Aggregate", pipeline)
+	if m.AggregateFunc == nil {
+		return notConfigured("Aggregate")
+	}
+	return m.AggregateFunc(ctx, pipeline, results, opts...)
+}
+
+// MockRepository 手写的 RepositoryAPI 测试替身，用法与 MockCollection 相同
+type MockRepository[T any] struct {
+	mockRecorder
+
+	FindByIDFunc           func(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*T, error)
+	FindOneFunc            func(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*T, error)
+	FindFunc               func(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error)
+	FindWithPaginationFunc func(ctx context.Context, filter bson.M, page, pageSize int64, opts ...*options.FindOptions) ([]T, *PaginationResult, error)
+	CountFunc              func(ctx context.Context, filter bson.M) (int64, error)
+
+	InsertFunc        func(ctx context.Context, doc *T) (*mongo.InsertOneResult, error)
+	UpdateByIDFunc    func(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateChangedFunc func(ctx context.Context, original, modified *T) (*mongo.UpdateResult, error)
+	DeleteByIDFunc    func(ctx context.Context, id interface{}) (*mongo.DeleteResult, error)
+}
+
+var _ RepositoryAPI[User] = (*MockRepository[User])(nil)
+
+func (m *MockRepository[T]) FindByID(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*T, error) {
+	m.record("FindByID", id)
+	if m.FindByIDFunc == nil {
+		return nil, notConfigured("FindByID")
+	}
+	return m.FindByIDFunc(ctx, id, opts...)
+}
+
+func (m *MockRepository[T]) FindOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	m.record("FindOne", filter)
+	if m.FindOneFunc == nil {
+		return nil, notConfigured("FindOne")
+	}
+	return m.FindOneFunc(ctx, filter, opts...)
+}
+
+func (m *MockRepository[T]) Find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	m.record("Find", filter)
+	if m.FindFunc == nil {
+		return nil, notConfigured("Find")
+	}
+	return m.FindFunc(ctx, filter, opts...)
+}
+
+func (m *MockRepository[T]) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, opts ...*options.FindOptions) ([]T, *PaginationResult, error) {
+	m.record("FindWithPagination", filter, page, pageSize)
+	if m.FindWithPaginationFunc == nil {
+		return nil, nil, notConfigured("FindWithPagination")
+	}
+	return m.FindWithPaginationFunc(ctx, filter, page, pageSize, opts...)
+}
+
+func (m *MockRepository[T]) Count(ctx context.Context, filter bson.M) (int64, error) {
+	m.record("Count", filter)
+	if m.CountFunc == nil {
+		return 0, notConfigured("Count")
+	}
+	return m.CountFunc(ctx, filter)
+}
+
+func (m *MockRepository[T]) Insert(ctx context.Context, doc *T) (*mongo.InsertOneResult, error) {
+	m.record("Insert", doc)
+	if m.InsertFunc == nil {
+		return nil, notConfigured("Insert")
+	}
+	return m.InsertFunc(ctx, doc)
+}
+
+func (m *MockRepository[T]) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	m.record("UpdateByID", id, update)
+	if m.UpdateByIDFunc == nil {
+		return nil, notConfigured("UpdateByID")
+	}
+	return m.UpdateByIDFunc(ctx, id, update, opts...)
+}
+
+func (m *MockRepository[T]) UpdateChanged(ctx context.Context, original, modified *T) (*mongo.UpdateResult, error) {
+	m.record("UpdateChanged", original, modified)
+	if m.UpdateChangedFunc == nil {
+		return nil, notConfigured("UpdateChanged")
+	}
+	return m.UpdateChangedFunc(ctx, original, modified)
+}
+
+func (m *MockRepository[T]) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	m.record("DeleteByID", id)
+	if m.DeleteByIDFunc == nil {
+		return nil, notConfigured("DeleteByID")
+	}
+	return m.DeleteByIDFunc(ctx, id)
+}
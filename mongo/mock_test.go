@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// countActiveUsers 依赖接口的业务函数示例
+func countActiveUsers(ctx context.Context, users Reader) (int64, error) {
+	return users.Count(ctx, bson.M{"status": "active"})
+}
+
+func TestMockCollection(t *testing.T) {
+	ctx := t.Context()
+	users := &MockCollection{
+		CountFunc: func(ctx context.Context, filter bson.M) (int64, error) {
+			return 3, nil
+		},
+		FindOneFunc: func(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error {
+			*result.(*User) = User{Username: "alice"}
+			return nil
+		},
+	}
+
+	n, err := countActiveUsers(ctx, users)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+
+	var user User
+	assert.NoError(t, users.FindOne(ctx, bson.M{"username": "alice"}, &user))
+	assert.Equal(t, "alice", user.Username)
+
+	_, err = users.DeleteMany(ctx, bson.M{"status": "inactive"})
+	assert.ErrorIs(t, err, ErrMockNotConfigured)
+
+	assert.Equal(t, 1, users.CallCount("Count"))
+	assert.Equal(t, []MockCall{
+		{Method: "Count", Args: []interface{}{bson.M{"status": "active"}}},
+		{Method: "FindOne", Args: []interface{}{bson.M{"username": "alice"}}},
+		{Method: "DeleteMany", Args: []interface{}{bson.M{"status": "inactive"}}},
+	}, users.Calls())
+}
+
+func TestMockRepository(t *testing.T) {
+	ctx := t.Context()
+	var repo RepositoryAPI[User] = &MockRepository[User]{
+		FindByIDFunc: func(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*User, error) {
+			return &User{Username: "bob"}, nil
+		},
+	}
+
+	user, err := repo.FindByID(ctx, "u1")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", user.Username)
+
+	_, err = repo.Insert(ctx, &User{})
+	assert.ErrorIs(t, err, ErrMockNotConfigured)
+}
@@ -0,0 +1,163 @@
+package mongo
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/description"
+)
+
+// MonitorHooks 连接池和拓扑事件回调，用于在连接池耗尽、节点故障和主节点切换时告警
+// 回调在驱动内部的 goroutine 中同步执行，不应阻塞，也不应在回调中执行数据库操作
+//
+//	config.Monitor = &MonitorHooks{
+//		CheckoutFailed: func(address, reason string) { alerts.Inc("mongo_checkout_failed", address, reason) },
+//		PrimaryChanged: func(previous, current string) { alerts.Notify("mongo failover", previous, current) },
+//	}
+type MonitorHooks struct {
+	// ConnectionCreated 建立新连接
+	ConnectionCreated func(address string, connectionID uint64)
+	// ConnectionClosed 连接关闭，reason 为 idle、stale、connectionError、poolClosed 等
+	ConnectionClosed func(address string, connectionID uint64, reason string)
+	// CheckoutFailed 从连接池获取连接失败，reason 为 timeout 时通常意味着连接池已耗尽
+	CheckoutFailed func(address, reason string)
+	// PoolCleared 连接池因网络错误或节点状态变化被清空
+	PoolCleared func(address string)
+	// ServerChanged 节点类型变化，例如 RSSecondary 变为 RSPrimary 或 Unknown
+	ServerChanged func(address, previous, current string)
+	// PrimaryChanged 副本集主节点变化，没有主节点时地址为空字符串
+	PrimaryChanged func(previous, current string)
+	// HeartbeatFailed 节点心跳失败，connectionID 为心跳连接的地址和编号
+	HeartbeatFailed func(connectionID string, err error)
+}
+
+// PoolStats 连接池统计，汇总客户端连接的所有节点
+type PoolStats struct {
+	// Open 当前打开的连接数
+	Open int64 `json:"open"`
+	// CheckedOut 当前被操作占用的连接数
+	CheckedOut int64 `json:"checked_out"`
+	// CheckoutFailed 获取连接失败的总次数
+	CheckoutFailed int64 `json:"checkout_failed"`
+	// Cleared 连接池被清空的总次数
+	Cleared int64 `json:"cleared"`
+}
+
+// clientMonitor 接收驱动的连接池和拓扑事件，维护连接池统计、记录日志并调用 MonitorHooks
+type clientMonitor struct {
+	hooks  MonitorHooks
+	logger Logger
+
+	open           atomic.Int64
+	checkedOut     atomic.Int64
+	checkoutFailed atomic.Int64
+	cleared        atomic.Int64
+}
+
+// newClientMonitor 创建客户端事件监控，hooks 为空时只维护统计和日志
+func newClientMonitor(hooks *MonitorHooks, logger Logger) *clientMonitor {
+	m := &clientMonitor{logger: logger}
+	if hooks != nil {
+		m.hooks = *hooks
+	}
+	return m
+}
+
+// poolMonitor 驱动的连接池监控
+func (m *clientMonitor) poolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: m.poolEvent}
+}
+
+// serverMonitor 驱动的拓扑监控
+func (m *clientMonitor) serverMonitor() *event.ServerMonitor {
+	return &event.ServerMonitor{
+		ServerDescriptionChanged:   m.serverChanged,
+		TopologyDescriptionChanged: m.topologyChanged,
+		ServerHeartbeatFailed:      m.heartbeatFailed,
+	}
+}
+
+func (m *clientMonitor) poolEvent(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		m.open.Add(1)
+		if m.hooks.ConnectionCreated != nil {
+			m.hooks.ConnectionCreated(e.Address, e.ConnectionID)
+		}
+	case event.ConnectionClosed:
+		m.open.Add(-1)
+		if m.hooks.ConnectionClosed != nil {
+			m.hooks.ConnectionClosed(e.Address, e.ConnectionID, e.Reason)
+		}
+	case event.GetSucceeded:
+		m.checkedOut.Add(1)
+	case event.ConnectionReturned:
+		m.checkedOut.Add(-1)
+	case event.GetFailed:
+		m.checkoutFailed.Add(1)
+		m.logger.WarnContext(context.Background(), "Failed to check out connection", "address", e.Address, "reason", e.Reason)
+		if m.hooks.CheckoutFailed != nil {
+			m.hooks.CheckoutFailed(e.Address, e.Reason)
+		}
+	case event.PoolCleared:
+		m.cleared.Add(1)
+		m.logger.WarnContext(context.Background(), "Connection pool cleared", "address", e.Address, "err", e.Error)
+		if m.hooks.PoolCleared != nil {
+			m.hooks.PoolCleared(e.Address)
+		}
+	}
+}
+
+func (m *clientMonitor) serverChanged(e *event.ServerDescriptionChangedEvent) {
+	previous, current := e.PreviousDescription.Kind, e.NewDescription.Kind
+	if previous == current || m.hooks.ServerChanged == nil {
+		return
+	}
+	m.hooks.ServerChanged(e.Address.String(), previous.String(), current.String())
+}
+
+func (m *clientMonitor) topologyChanged(e *event.TopologyDescriptionChangedEvent) {
+	previous, current := primaryAddress(e.PreviousDescription), primaryAddress(e.NewDescription)
+	if previous == current {
+		return
+	}
+	m.logger.InfoContext(context.Background(), "Primary changed", "previous", previous, "current", current)
+	if m.hooks.PrimaryChanged != nil {
+		m.hooks.PrimaryChanged(previous, current)
+	}
+}
+
+func (m *clientMonitor) heartbeatFailed(e *event.ServerHeartbeatFailedEvent) {
+	if m.hooks.HeartbeatFailed != nil {
+		m.hooks.HeartbeatFailed(e.ConnectionID, e.Failure)
+	}
+}
+
+// stats 返回连接池统计
+func (m *clientMonitor) stats() PoolStats {
+	return PoolStats{
+		Open:           m.open.Load(),
+		CheckedOut:     m.checkedOut.Load(),
+		CheckoutFailed: m.checkoutFailed.Load(),
+		Cleared:        m.cleared.Load(),
+	}
+}
+
+// primaryAddress 拓扑中主节点的地址，没有主节点时返回空字符串
+func primaryAddress(t description.Topology) string {
+	for _, server := range t.Servers {
+		if server.Kind == description.RSPrimary {
+			return server.Addr.String()
+		}
+	}
+	return ""
+}
+
+// PoolStats 返回连接池统计；通过 Config.ClientOptions 覆盖了 PoolMonitor 时统计不再更新
+func (c *Client) PoolStats() PoolStats {
+	if c.monitor == nil {
+		return PoolStats{}
+	}
+	return c.monitor.stats()
+}
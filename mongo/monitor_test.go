@@ -0,0 +1,60 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/address"
+	"go.mongodb.org/mongo-driver/mongo/description"
+)
+
+func TestClientMonitorPoolEvents(t *testing.T) {
+	var created, failed []string
+	m := newClientMonitor(&MonitorHooks{
+		ConnectionCreated: func(address string, connectionID uint64) { created = append(created, address) },
+		CheckoutFailed:    func(address, reason string) { failed = append(failed, reason) },
+	}, defaultLogger())
+	pool := m.poolMonitor()
+
+	pool.Event(&event.PoolEvent{Type: event.ConnectionCreated, Address: "db1:27017", ConnectionID: 1})
+	pool.Event(&event.PoolEvent{Type: event.ConnectionCreated, Address: "db1:27017", ConnectionID: 2})
+	pool.Event(&event.PoolEvent{Type: event.GetSucceeded, Address: "db1:27017"})
+	pool.Event(&event.PoolEvent{Type: event.GetFailed, Address: "db1:27017", Reason: event.ReasonTimedOut})
+	pool.Event(&event.PoolEvent{Type: event.ConnectionClosed, Address: "db1:27017", ConnectionID: 2, Reason: event.ReasonIdle})
+	pool.Event(&event.PoolEvent{Type: event.PoolCleared, Address: "db1:27017"})
+
+	assert.Equal(t, PoolStats{Open: 1, CheckedOut: 1, CheckoutFailed: 1, Cleared: 1}, m.stats())
+	assert.Equal(t, []string{"db1:27017", "db1:27017"}, created)
+	assert.Equal(t, []string{event.ReasonTimedOut}, failed)
+}
+
+func TestClientMonitorTopologyEvents(t *testing.T) {
+	var primaries [][2]string
+	var servers []string
+	m := newClientMonitor(&MonitorHooks{
+		PrimaryChanged: func(previous, current string) { primaries = append(primaries, [2]string{previous, current}) },
+		ServerChanged:  func(address, previous, current string) { servers = append(servers, address+":"+previous+"->"+current) },
+	}, defaultLogger())
+	server := m.serverMonitor()
+
+	topology := func(primary string) description.Topology {
+		return description.Topology{Servers: []description.Server{
+			{Addr: address.Address("db1:27017"), Kind: description.RSSecondary},
+			{Addr: address.Address(primary), Kind: description.RSPrimary},
+		}}
+	}
+	server.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{PreviousDescription: topology("db2:27017"), NewDescription: topology("db2:27017")})
+	server.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{PreviousDescription: topology("db2:27017"), NewDescription: topology("db3:27017")})
+	server.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{PreviousDescription: topology("db3:27017"), NewDescription: description.Topology{}})
+	assert.Equal(t, [][2]string{{"db2:27017", "db3:27017"}, {"db3:27017", ""}}, primaries)
+
+	server.ServerDescriptionChanged(&event.ServerDescriptionChangedEvent{
+		Address:             address.Address("db2:27017"),
+		PreviousDescription: description.Server{Kind: description.RSPrimary},
+		NewDescription:      description.Server{Kind: description.RSSecondary},
+	})
+	assert.Equal(t, []string{"db2:27017:RSPrimary->RSSecondary"}, servers)
+
+	assert.Equal(t, PoolStats{}, (&Client{}).PoolStats())
+}
@@ -0,0 +1,537 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrNotificationNotFound 通知不存在或不属于该用户
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// Notification 用户收件箱中的一条通知
+type Notification struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID string             `bson:"user_id" json:"user_id"`
+	// Type 通知类型，例如 comment、follow、system
+	Type      string     `bson:"type" json:"type"`
+	Title     string     `bson:"title" json:"title"`
+	Body      string     `bson:"body,omitempty" json:"body,omitempty"`
+	Data      bson.M     `bson:"data,omitempty" json:"data,omitempty"`
+	Read      bool       `bson:"read" json:"read"`
+	ReadAt    *time.Time `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// NotificationQuery 通知分页查询条件
+type NotificationQuery struct {
+	UnreadOnly bool
+	// Cursor 上一页返回的 NextCursor，为空时从最新的通知开始
+	Cursor string
+	// Limit 每页数量，默认 20
+	Limit int64
+}
+
+// NotificationPage 按时间从新到旧排列的一页通知
+type NotificationPage struct {
+	Items []Notification `json:"items"`
+	// NextCursor 下一页的游标，为空表示没有更多通知
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NotificationEventType 推送事件类型
+type NotificationEventType string
+
+const (
+	// NotificationCreated 收到新通知
+	NotificationCreated NotificationEventType = "created"
+	// NotificationUpdated 通知被标记为已读或未读
+	NotificationUpdated NotificationEventType = "updated"
+	// NotificationUnreadChanged 未读数变化
+	NotificationUnreadChanged NotificationEventType = "unread_changed"
+)
+
+// NotificationEvent 推送给订阅者的事件
+type NotificationEvent struct {
+	Type   NotificationEventType `json:"type"`
+	UserID string                `json:"user_id"`
+	// Notification created、updated 事件的通知
+	Notification *Notification `json:"notification,omitempty"`
+	// Unread unread_changed 事件的最新未读数
+	Unread int64 `json:"unread"`
+}
+
+// NotificationOptions 通知配置
+type NotificationOptions struct {
+	// Collection 通知集合，默认 notifications
+	Collection string
+	// CounterCollection 未读数集合，默认 notification_counters
+	CounterCollection string
+	// Buffer 每个订阅的事件缓冲，缓冲已满时丢弃新事件，默认 16
+	Buffer int
+	// RetryBackoff 变更流出错后的首次重试间隔，之后按指数增长，默认 1 秒
+	RetryBackoff time.Duration
+	// MaxRetryBackoff 重试间隔上限，默认 30 秒
+	MaxRetryBackoff time.Duration
+}
+
+// Notifications 用户通知收件箱：写入通知、标记已读、维护未读数，并通过变更流向订阅者推送更新；
+// 未读数保存在单独的计数器文档中，随通知状态的变化原子增减，读取时不需要 count 查询；
+// 部署支持事务时通知和未读数在同一事务中写入，否则是两次独立的写入，未读数只保证最终一致，
+// 两次写入之间出错或进程退出造成的偏差需要通过 RecountUnread 修复
+//
+// 所有订阅共享一个变更流，Start 之后 Subscribe 返回的订阅会收到该用户的事件，适合转发到 WebSocket 或 SSE：
+//
+//	inbox := NewNotifications(client, nil)
+//	inbox.Start(ctx)
+//	sub := inbox.Subscribe(userID)
+//	defer sub.Close()
+//	for event := range sub.Events() {
+//		conn.WriteJSON(event)
+//	}
+type Notifications struct {
+	client   *Client
+	items    *mongo.Collection
+	counters *mongo.Collection
+	opts     NotificationOptions
+
+	mu          sync.RWMutex
+	subscribers map[string]map[*NotificationSubscription]struct{}
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	doneCh    chan struct{}
+}
+
+// NewNotifications 创建通知收件箱
+func NewNotifications(client *Client, opts *NotificationOptions) *Notifications {
+	o := NotificationOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Collection == "" {
+		o.Collection = "notifications"
+	}
+	if o.CounterCollection == "" {
+		o.CounterCollection = "notification_counters"
+	}
+	if o.Buffer <= 0 {
+		o.Buffer = 16
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = time.Second
+	}
+	if o.MaxRetryBackoff <= 0 {
+		o.MaxRetryBackoff = 30 * time.Second
+	}
+	return &Notifications{
+		client:      client,
+		items:       client.GetCollection(o.Collection),
+		counters:    client.GetCollection(o.CounterCollection),
+		opts:        o,
+		subscribers: make(map[string]map[*NotificationSubscription]struct{}),
+		doneCh:      make(chan struct{}),
+	}
+}
+
+// EnsureIndexes 创建按用户分页查询的索引
+func (n *Notifications) EnsureIndexes(ctx context.Context) error {
+	if err := n.client.checkWritable("createIndexes", n.items.Name()); err != nil {
+		return err
+	}
+	_, err := n.items.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_user_id_recent")},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "_id", Value: -1}}, Options: options.Index().SetName("idx_user_id_read_recent")},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create notification indexes: %w", err)
+	}
+	return nil
+}
+
+// Send 写入通知并增加用户的未读数，返回写入的通知
+func (n *Notifications) Send(ctx context.Context, notification *Notification) (*Notification, error) {
+	if notification.UserID == "" {
+		return nil, fmt.Errorf("notification user is required")
+	}
+	if err := n.client.checkWritable("insert", n.items.Name()); err != nil {
+		return nil, err
+	}
+	item := *notification
+	item.ID = primitive.NewObjectID()
+	item.Read = false
+	item.ReadAt = nil
+	item.CreatedAt = time.Now()
+	err := n.client.runAtomic(ctx, func(ctx context.Context) error {
+		if _, err := n.items.InsertOne(ctx, item); err != nil {
+			return fmt.Errorf("failed to send notification: %w", err)
+		}
+		return n.adjustUnread(ctx, item.UserID, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// List 按时间从新到旧分页返回用户的通知
+func (n *Notifications) List(ctx context.Context, userID string, query *NotificationQuery) (*NotificationPage, error) {
+	filter, limit, err := notificationFilter(userID, query)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := n.items.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	var items []Notification
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode notifications: %w", err)
+	}
+	page := &NotificationPage{Items: items}
+	// 多取一条用于判断是否还有下一页
+	if int64(len(items)) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = page.Items[limit-1].ID.Hex()
+	}
+	return page, nil
+}
+
+func notificationFilter(userID string, query *NotificationQuery) (bson.M, int64, error) {
+	q := NotificationQuery{}
+	if query != nil {
+		q = *query
+	}
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	filter := bson.M{"user_id": userID}
+	if q.UnreadOnly {
+		filter["read"] = false
+	}
+	if q.Cursor != "" {
+		id, err := primitive.ObjectIDFromHex(q.Cursor)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid notification cursor %q", q.Cursor)
+		}
+		filter["_id"] = bson.M{"$lt": id}
+	}
+	return filter, q.Limit, nil
+}
+
+// UnreadCount 返回用户的未读数
+func (n *Notifications) UnreadCount(ctx context.Context, userID string) (int64, error) {
+	var counter struct {
+		Unread int64 `bson:"unread"`
+	}
+	err := n.counters.FindOne(ctx, bson.M{"_id": userID}).Decode(&counter)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, fmt.Errorf("failed to get unread count: %w", err)
+	}
+	return counter.Unread, nil
+}
+
+// MarkRead 标记通知为已读，通知已经是已读时不改变未读数
+func (n *Notifications) MarkRead(ctx context.Context, userID string, id primitive.ObjectID) error {
+	return n.setRead(ctx, userID, id, true, bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}})
+}
+
+// MarkUnread 标记通知为未读
+func (n *Notifications) MarkUnread(ctx context.Context, userID string, id primitive.ObjectID) error {
+	return n.setRead(ctx, userID, id, false, bson.M{"$set": bson.M{"read": false}, "$unset": bson.M{"read_at": ""}})
+}
+
+// setRead 只有状态实际改变时才调整未读数，并发标记同一条通知时未读数不会重复增减
+func (n *Notifications) setRead(ctx context.Context, userID string, id primitive.ObjectID, read bool, update bson.M) error {
+	if err := n.client.checkWritable("update", n.items.Name()); err != nil {
+		return err
+	}
+	return n.client.runAtomic(ctx, func(ctx context.Context) error {
+		result, err := n.items.UpdateOne(ctx, bson.M{"_id": id, "user_id": userID, "read": !read}, update)
+		if err != nil {
+			return fmt.Errorf("failed to update notification: %w", err)
+		}
+		if result.MatchedCount == 0 {
+			count, err := n.items.CountDocuments(ctx, bson.M{"_id": id, "user_id": userID})
+			if err != nil {
+				return fmt.Errorf("failed to update notification: %w", err)
+			}
+			if count == 0 {
+				return ErrNotificationNotFound
+			}
+			return nil
+		}
+		delta := int64(1)
+		if read {
+			delta = -1
+		}
+		return n.adjustUnread(ctx, userID, delta)
+	})
+}
+
+// MarkAllRead 标记用户的所有通知为已读，返回标记的数量
+func (n *Notifications) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	if err := n.client.checkWritable("update", n.items.Name()); err != nil {
+		return 0, err
+	}
+	var marked int64
+	err := n.client.runAtomic(ctx, func(ctx context.Context) error {
+		result, err := n.items.UpdateMany(ctx,
+			bson.M{"user_id": userID, "read": false},
+			bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}})
+		if err != nil {
+			return fmt.Errorf("failed to mark notifications read: %w", err)
+		}
+		marked = result.ModifiedCount
+		if marked == 0 {
+			return nil
+		}
+		return n.adjustUnread(ctx, userID, -marked)
+	})
+	return marked, err
+}
+
+// Delete 删除通知，删除未读通知时减少未读数
+func (n *Notifications) Delete(ctx context.Context, userID string, id primitive.ObjectID) error {
+	if err := n.client.checkWritable("delete", n.items.Name()); err != nil {
+		return err
+	}
+	return n.client.runAtomic(ctx, func(ctx context.Context) error {
+		var deleted Notification
+		err := n.items.FindOneAndDelete(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&deleted)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotificationNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete notification: %w", err)
+		}
+		if !deleted.Read {
+			return n.adjustUnread(ctx, userID, -1)
+		}
+		return nil
+	})
+}
+
+// RecountUnread 按通知重新计算未读数，用于修复不支持事务的部署上进程在写入通知和更新计数器之间异常退出造成的偏差
+func (n *Notifications) RecountUnread(ctx context.Context, userID string) (int64, error) {
+	if err := n.client.checkWritable("update", n.counters.Name()); err != nil {
+		return 0, err
+	}
+	unread, err := n.items.CountDocuments(ctx, bson.M{"user_id": userID, "read": false})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	_, err = n.counters.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"unread": unread}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update unread count: %w", err)
+	}
+	return unread, nil
+}
+
+func (n *Notifications) adjustUnread(ctx context.Context, userID string, delta int64) error {
+	_, err := n.counters.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"unread": delta}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to update unread count: %w", err)
+	}
+	return nil
+}
+
+// NotificationSubscription 一个用户的推送订阅
+type NotificationSubscription struct {
+	inbox   *Notifications
+	userID  string
+	events  chan *NotificationEvent
+	dropped atomic.Int64
+	once    sync.Once
+}
+
+// Events 事件通道，Close 后关闭
+func (s *NotificationSubscription) Events() <-chan *NotificationEvent {
+	return s.events
+}
+
+// Dropped 因缓冲已满而丢弃的事件数，丢弃后客户端应当重新拉取列表和未读数
+func (s *NotificationSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close 取消订阅并关闭事件通道
+func (s *NotificationSubscription) Close() {
+	s.once.Do(func() {
+		s.inbox.mu.Lock()
+		defer s.inbox.mu.Unlock()
+		delete(s.inbox.subscribers[s.userID], s)
+		if len(s.inbox.subscribers[s.userID]) == 0 {
+			delete(s.inbox.subscribers, s.userID)
+		}
+		close(s.events)
+	})
+}
+
+// Subscribe 订阅用户的通知事件，需要先调用 Start；订阅者处理过慢时丢弃新事件而不阻塞其他订阅者
+func (n *Notifications) Subscribe(userID string) *NotificationSubscription {
+	sub := &NotificationSubscription{inbox: n, userID: userID, events: make(chan *NotificationEvent, n.opts.Buffer)}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.subscribers[userID] == nil {
+		n.subscribers[userID] = make(map[*NotificationSubscription]struct{})
+	}
+	n.subscribers[userID][sub] = struct{}{}
+	return sub
+}
+
+// publish 投递事件给用户的所有订阅，在持有读锁期间发送，Close 不会与发送并发
+func (n *Notifications) publish(event *NotificationEvent) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for sub := range n.subscribers[event.UserID] {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Start 在后台监听通知和未读数的变化并推送给订阅者
+func (n *Notifications) Start(ctx context.Context) {
+	n.startOnce.Do(func() {
+		n.client.RegisterShutdown(n)
+		ctx, n.cancel = context.WithCancel(ctx)
+		go func() {
+			defer close(n.doneCh)
+			_ = n.Run(ctx)
+		}()
+	})
+}
+
+// Stop 停止推送，已有的订阅不会关闭
+func (n *Notifications) Stop() {
+	n.stopOnce.Do(func() {
+		n.startOnce.Do(func() {
+			close(n.doneCh)
+		})
+		if n.cancel != nil {
+			n.cancel()
+		}
+		<-n.doneCh
+	})
+}
+
+// Run 持续读取变更流直到 ctx 取消，出错时从最后处理的事件之后恢复；部署不支持变更流时返回 *UnsupportedFeatureError
+func (n *Notifications) Run(ctx context.Context) error {
+	var token bson.Raw
+	for attempt := 0; ; attempt++ {
+		delivered, err := n.watch(ctx, &token)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrUnsupportedFeature) {
+			n.client.logger.WarnContext(ctx, "Notification push stopped", "err", err)
+			return err
+		}
+		if delivered {
+			attempt = 0
+		}
+		n.client.logger.WarnContext(ctx, "Notification change stream interrupted, resuming", "err", err)
+		backoff := n.opts.RetryBackoff << attempt
+		if backoff <= 0 || backoff > n.opts.MaxRetryBackoff {
+			backoff = n.opts.MaxRetryBackoff
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// notificationChange 变更流原始事件
+type notificationChange struct {
+	OperationType string          `bson:"operationType"`
+	Namespace     ChangeNamespace `bson:"ns"`
+	FullDocument  bson.RawValue   `bson:"fullDocument"`
+}
+
+func (n *Notifications) watch(ctx context.Context, token *bson.Raw) (bool, error) {
+	if err := n.client.requireFeature(ctx, FeatureChangeStreams); err != nil {
+		return false, err
+	}
+	streamOpts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if *token != nil {
+		streamOpts.SetStartAfter(*token)
+	}
+	stream, err := n.client.GetDatabase().Watch(ctx, notificationPipeline(n.items.Name(), n.counters.Name()), streamOpts)
+	if err != nil {
+		return false, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	delivered := false
+	for stream.Next(ctx) {
+		var change notificationChange
+		if err := stream.Decode(&change); err != nil {
+			return delivered, fmt.Errorf("failed to decode change event: %w", err)
+		}
+		event, err := n.notificationEvent(&change)
+		if err != nil {
+			return delivered, err
+		}
+		if event != nil {
+			n.publish(event)
+		}
+		*token = append(bson.Raw(nil), stream.ResumeToken()...)
+		delivered = true
+	}
+	if err := stream.Err(); err != nil {
+		return delivered, fmt.Errorf("change stream failed: %w", err)
+	}
+	return delivered, nil
+}
+
+// notificationPipeline 只接收通知的插入和修改，以及未读数的变化
+func notificationPipeline(items, counters string) []bson.M {
+	return []bson.M{{"$match": bson.M{
+		"operationType": bson.M{"$in": bson.A{"insert", "update", "replace"}},
+		"ns.coll":       bson.M{"$in": bson.A{items, counters}},
+	}}}
+}
+
+// notificationEvent 将变更事件转换为推送事件，文档已被删除时返回 nil
+func (n *Notifications) notificationEvent(change *notificationChange) (*NotificationEvent, error) {
+	doc := rawDocument(change.FullDocument)
+	if doc == nil {
+		return nil, nil
+	}
+	if change.Namespace.Collection == n.counters.Name() {
+		var counter struct {
+			UserID string `bson:"_id"`
+			Unread int64  `bson:"unread"`
+		}
+		if err := bson.Unmarshal(doc, &counter); err != nil {
+			return nil, fmt.Errorf("failed to decode unread count: %w", err)
+		}
+		return &NotificationEvent{Type: NotificationUnreadChanged, UserID: counter.UserID, Unread: counter.Unread}, nil
+	}
+
+	var notification Notification
+	if err := bson.Unmarshal(doc, &notification); err != nil {
+		return nil, fmt.Errorf("failed to decode notification: %w", err)
+	}
+	eventType := NotificationUpdated
+	if change.OperationType == "insert" {
+		eventType = NotificationCreated
+	}
+	return &NotificationEvent{Type: eventType, UserID: notification.UserID, Notification: &notification}, nil
+}
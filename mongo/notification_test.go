@@ -0,0 +1,146 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNotificationFilter(t *testing.T) {
+	filter, limit, err := notificationFilter("u1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"user_id": "u1"}, filter)
+	assert.Equal(t, int64(20), limit)
+
+	cursor := primitive.NewObjectID()
+	filter, limit, err = notificationFilter("u1", &NotificationQuery{UnreadOnly: true, Cursor: cursor.Hex(), Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"user_id": "u1", "read": false, "_id": bson.M{"$lt": cursor}}, filter)
+	assert.Equal(t, int64(5), limit)
+
+	_, _, err = notificationFilter("u1", &NotificationQuery{Cursor: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestNotificationEvent(t *testing.T) {
+	inbox := NewNotifications(newLazyClient(t), nil)
+	rawValue := func(doc bson.D) bson.RawValue {
+		return bson.Raw(bsonDoc(t, bson.D{{Key: "v", Value: doc}})).Lookup("v")
+	}
+
+	event, err := inbox.notificationEvent(&notificationChange{
+		OperationType: "update",
+		Namespace:     ChangeNamespace{Database: "test", Collection: "notification_counters"},
+		FullDocument:  rawValue(bson.D{{Key: "_id", Value: "u1"}, {Key: "unread", Value: int64(3)}}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &NotificationEvent{Type: NotificationUnreadChanged, UserID: "u1", Unread: 3}, event)
+
+	event, err = inbox.notificationEvent(&notificationChange{
+		OperationType: "insert",
+		Namespace:     ChangeNamespace{Database: "test", Collection: "notifications"},
+		FullDocument:  rawValue(bson.D{{Key: "user_id", Value: "u1"}, {Key: "type", Value: "comment"}, {Key: "title", Value: "New reply"}}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, NotificationCreated, event.Type)
+	assert.Equal(t, "New reply", event.Notification.Title)
+
+	event, err = inbox.notificationEvent(&notificationChange{OperationType: "update", Namespace: ChangeNamespace{Collection: "notifications"}})
+	require.NoError(t, err)
+	assert.Nil(t, event, "notifications deleted before lookup are skipped")
+}
+
+func TestNotificationSubscription(t *testing.T) {
+	inbox := NewNotifications(newLazyClient(t), &NotificationOptions{Buffer: 1})
+	sub := inbox.Subscribe("u1")
+	other := inbox.Subscribe("u2")
+
+	inbox.publish(&NotificationEvent{Type: NotificationUnreadChanged, UserID: "u1", Unread: 1})
+	inbox.publish(&NotificationEvent{Type: NotificationUnreadChanged, UserID: "u1", Unread: 2})
+	event := <-sub.Events()
+	assert.Equal(t, int64(1), event.Unread)
+	assert.Equal(t, int64(1), sub.Dropped())
+	assert.Empty(t, other.Events())
+
+	sub.Close()
+	sub.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	assert.NotContains(t, inbox.subscribers, "u1")
+	inbox.publish(&NotificationEvent{UserID: "u1"})
+	other.Close()
+}
+
+func TestNotificationsReadOnly(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	client.readOnly = true
+	inbox := NewNotifications(client, nil)
+
+	_, err := inbox.Send(ctx, &Notification{UserID: "u1", Title: "hello"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, inbox.MarkRead(ctx, "u1", primitive.NewObjectID()), ErrReadOnly)
+	_, err = inbox.MarkAllRead(ctx, "u1")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = inbox.Send(ctx, &Notification{Title: "hello"})
+	assert.ErrorContains(t, err, "user is required")
+}
+
+func TestNotificationsTransactionalCounter(t *testing.T) {
+	server := newFakeServer(t, true)
+	inbox := NewNotifications(server.client(t), nil)
+	ctx := t.Context()
+
+	// 通知和未读数在同一事务中写入
+	item, err := inbox.Send(ctx, &Notification{UserID: "u1", Title: "hello"})
+	require.NoError(t, err)
+	insert := server.Commands("insert")[0]
+	inc := server.Commands("update")[0]
+	assert.Equal(t, "notification_counters", inc.Lookup("update").StringValue())
+	assert.Equal(t, insert.Lookup("lsid").String(), inc.Lookup("lsid").String())
+	assert.Equal(t, insert.Lookup("txnNumber").Int64(), inc.Lookup("txnNumber").Int64())
+	assert.True(t, insert.Lookup("startTransaction").Boolean())
+	assert.Len(t, server.Commands("commitTransaction"), 1)
+
+	// 未读数更新失败时通知随事务回滚
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "update" && cmd.Lookup("update").StringValue() == "notification_counters" {
+			return fakeWriteError(2, "bad update")
+		}
+		return nil
+	})
+	_, err = inbox.Send(ctx, &Notification{UserID: "u1", Title: "again"})
+	assert.ErrorContains(t, err, "failed to update unread count")
+	assert.Len(t, server.Commands("abortTransaction"), 1)
+	assert.Len(t, server.Commands("commitTransaction"), 1)
+
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "findAndModify" {
+			doc := bson.M{"_id": item.ID, "user_id": "u1", "read": false}
+			return bson.D{{Key: "lastErrorObject", Value: bson.D{{Key: "n", Value: 1}}}, {Key: "value", Value: doc}, {Key: "ok", Value: 1}}
+		}
+		return nil
+	})
+	require.NoError(t, inbox.Delete(ctx, "u1", item.ID))
+	del := server.Commands("findAndModify")[0]
+	dec := server.Commands("update")[2]
+	assert.Equal(t, int64(-1), dec.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$inc", "unread").AsInt64())
+	assert.Equal(t, del.Lookup("txnNumber").Int64(), dec.Lookup("txnNumber").Int64())
+	assert.Len(t, server.Commands("commitTransaction"), 2)
+}
+
+func TestNotificationsWithoutTransactions(t *testing.T) {
+	server := newFakeServer(t, false)
+	inbox := NewNotifications(server.client(t), nil)
+
+	_, err := inbox.Send(t.Context(), &Notification{UserID: "u1", Title: "hello"})
+	require.NoError(t, err)
+	require.Len(t, server.Commands("insert", "update"), 2)
+	for _, cmd := range server.Commands("insert", "update") {
+		_, inTxn := cmd.Lookup("txnNumber").Int64OK()
+		assert.False(t, inTxn)
+	}
+}
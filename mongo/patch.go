@@ -0,0 +1,345 @@
+package mongo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrInvalidPatch 补丁格式不合法或修改了不允许的路径
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// PatchOperation JSON Patch（RFC 6902）操作
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+	From  string      `json:"from,omitempty"`
+}
+
+// PatchOptions 补丁路径限制
+type PatchOptions struct {
+	// AllowedPaths 允许修改的字段（点号路径），路径本身或其子路径都允许，为空时允许除 ForbiddenPaths 外的任意路径
+	AllowedPaths []string
+	// ForbiddenPaths 不允许修改的字段，为 nil 时为 _id、created_at
+	ForbiddenPaths []string
+}
+
+// PatchUpdate 补丁转换结果
+type PatchUpdate struct {
+	// Update 更新文档，可以直接传给 UpdateOne
+	Update bson.M
+	// Conditions JSON Patch 中 test 操作转换的相等条件，应合并到更新的过滤条件中，
+	// 条件不满足时更新不会匹配到文档
+	Conditions bson.M
+}
+
+// ParseJSONPatch 解析 JSON Patch 文档，数字按整数或浮点数保留原始类型
+func ParseJSONPatch(data []byte) ([]PatchOperation, error) {
+	var ops []PatchOperation
+	if err := decodeJSON(data, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i := range ops {
+		ops[i].Value = normalizeJSONValue(ops[i].Value)
+	}
+	return ops, nil
+}
+
+// JSONPatchToUpdate 将 JSON Patch 操作转换为更新文档：
+// add/replace 转换为 $set，add 到数组末尾（/-）或指定位置转换为 $push，remove 转换为 $unset，
+// test 转换为过滤条件；move 和 copy 无法用单个更新表达，返回 ErrInvalidPatch
+//
+//	ops, err := ParseJSONPatch(body)
+//	patch, err := JSONPatchToUpdate(ops, &PatchOptions{AllowedPaths: []string{"title", "tags", "profile"}})
+//	result, err := articles.UpdateOne(ctx, MergeBsonM(bson.M{"_id": id}, patch.Conditions), patch.Update)
+func JSONPatchToUpdate(ops []PatchOperation, opts *PatchOptions) (*PatchUpdate, error) {
+	builder := newPatchBuilder(opts)
+	for i, op := range ops {
+		segments, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i, err)
+		}
+
+		switch op.Op {
+		case "add":
+			last := segments[len(segments)-1]
+			if last == "-" {
+				err = builder.push(segments[:len(segments)-1], op.Value, -1)
+			} else if index, isIndex := arrayIndex(last); isIndex {
+				err = builder.push(segments[:len(segments)-1], op.Value, index)
+			} else {
+				err = builder.set(segments, op.Value)
+			}
+		case "replace":
+			err = builder.set(segments, op.Value)
+		case "remove":
+			if _, isIndex := arrayIndex(segments[len(segments)-1]); isIndex {
+				err = errors.New("removing array elements by index is not supported")
+			} else {
+				err = builder.unset(segments)
+			}
+		case "test":
+			err = builder.test(segments, op.Value)
+		case "move", "copy":
+			err = fmt.Errorf("operation %s is not supported", op.Op)
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s %s): %v", ErrInvalidPatch, i, op.Op, op.Path, err)
+		}
+	}
+	return builder.result(), nil
+}
+
+// MergePatchToUpdate 将 JSON Merge Patch（RFC 7396）转换为更新文档：
+// null 转换为 $unset，嵌套对象递归展开为点号路径，其它值转换为 $set
+// 目标字段原来不是对象时，嵌套对象的点号路径更新会失败，需要整体替换时请直接传入完整值
+func MergePatchToUpdate(patch map[string]interface{}, opts *PatchOptions) (bson.M, error) {
+	builder := newPatchBuilder(opts)
+	if err := builder.merge(nil, patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return builder.result().Update, nil
+}
+
+// ParseMergePatch 解析 JSON Merge Patch 文档，数字按整数或浮点数保留原始类型
+func ParseMergePatch(data []byte) (map[string]interface{}, error) {
+	var patch map[string]interface{}
+	if err := decodeJSON(data, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	normalized, _ := normalizeJSONValue(patch).(map[string]interface{})
+	return normalized, nil
+}
+
+// patchBuilder 收集补丁转换出的更新操作
+type patchBuilder struct {
+	opts       PatchOptions
+	sets       bson.M
+	unsets     bson.M
+	pushes     bson.M
+	conditions bson.M
+	paths      []string
+}
+
+// newPatchBuilder 创建补丁构建器
+func newPatchBuilder(opts *PatchOptions) *patchBuilder {
+	b := &patchBuilder{
+		sets:       bson.M{},
+		unsets:     bson.M{},
+		pushes:     bson.M{},
+		conditions: bson.M{},
+	}
+	if opts != nil {
+		b.opts = *opts
+	}
+	if b.opts.ForbiddenPaths == nil {
+		b.opts.ForbiddenPaths = []string{"_id", "created_at"}
+	}
+	return b
+}
+
+// merge 递归展开 merge patch
+func (b *patchBuilder) merge(prefix []string, patch map[string]interface{}) error {
+	for key, value := range patch {
+		segments := append(append([]string{}, prefix...), key)
+		if err := validateSegment(key); err != nil {
+			return err
+		}
+		var err error
+		switch v := value.(type) {
+		case nil:
+			err = b.unset(segments)
+		case map[string]interface{}:
+			err = b.merge(segments, v)
+		default:
+			err = b.set(segments, v)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(segments, "."), err)
+		}
+	}
+	return nil
+}
+
+// set 设置字段值
+func (b *patchBuilder) set(segments []string, value interface{}) error {
+	path, err := b.claim(segments)
+	if err != nil {
+		return err
+	}
+	b.sets[path] = value
+	return nil
+}
+
+// unset 删除字段
+func (b *patchBuilder) unset(segments []string) error {
+	path, err := b.claim(segments)
+	if err != nil {
+		return err
+	}
+	b.unsets[path] = ""
+	return nil
+}
+
+// push 向数组添加元素，position 小于 0 时添加到末尾
+func (b *patchBuilder) push(segments []string, value interface{}, position int) error {
+	if len(segments) == 0 {
+		return errors.New("cannot push to document root")
+	}
+	path, err := b.claim(segments)
+	if err != nil {
+		return err
+	}
+	each := bson.M{"$each": bson.A{value}}
+	if position >= 0 {
+		each["$position"] = position
+	}
+	b.pushes[path] = each
+	return nil
+}
+
+// test 将 test 操作转换为相等条件，不占用更新路径
+func (b *patchBuilder) test(segments []string, value interface{}) error {
+	path, err := b.checkPath(segments)
+	if err != nil {
+		return err
+	}
+	b.conditions[path] = value
+	return nil
+}
+
+// claim 检查路径并记录，同一个更新中路径之间不能相同或互为父子路径
+func (b *patchBuilder) claim(segments []string) (string, error) {
+	path, err := b.checkPath(segments)
+	if err != nil {
+		return "", err
+	}
+	for _, existing := range b.paths {
+		if pathsOverlap(existing, path) {
+			return "", fmt.Errorf("path %s conflicts with %s", path, existing)
+		}
+	}
+	b.paths = append(b.paths, path)
+	return path, nil
+}
+
+// checkPath 检查路径是否允许修改并返回点号路径
+func (b *patchBuilder) checkPath(segments []string) (string, error) {
+	if len(segments) == 0 {
+		return "", errors.New("cannot modify document root")
+	}
+	path := strings.Join(segments, ".")
+	for _, forbidden := range b.opts.ForbiddenPaths {
+		if pathsOverlap(forbidden, path) {
+			return "", fmt.Errorf("path %s is not allowed", path)
+		}
+	}
+	if len(b.opts.AllowedPaths) == 0 {
+		return path, nil
+	}
+	for _, allowed := range b.opts.AllowedPaths {
+		if path == allowed || strings.HasPrefix(path, allowed+".") {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("path %s is not allowed", path)
+}
+
+// result 生成更新文档
+func (b *patchBuilder) result() *PatchUpdate {
+	update := bson.M{}
+	if len(b.sets) > 0 {
+		update["$set"] = b.sets
+	}
+	if len(b.unsets) > 0 {
+		update["$unset"] = b.unsets
+	}
+	if len(b.pushes) > 0 {
+		update["$push"] = b.pushes
+	}
+	return &PatchUpdate{Update: update, Conditions: b.conditions}
+}
+
+// pathsOverlap 判断两个点号路径是否相同或互为父子路径
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+// parsePointer 解析 JSON Pointer（RFC 6901）
+func parsePointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+	parts := strings.Split(pointer[1:], "/")
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		segment := strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		if err := validateSegment(segment); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// validateSegment 拒绝空字段名、$ 开头和包含点号的字段名，防止注入更新操作符或越级修改
+func validateSegment(segment string) error {
+	if segment == "" {
+		return errors.New("empty path segment")
+	}
+	if strings.HasPrefix(segment, "$") {
+		return fmt.Errorf("path segment %q must not start with $", segment)
+	}
+	if strings.Contains(segment, ".") {
+		return fmt.Errorf("path segment %q must not contain .", segment)
+	}
+	return nil
+}
+
+// arrayIndex 判断路径段是否为数组下标
+func arrayIndex(segment string) (int, bool) {
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 || (len(segment) > 1 && segment[0] == '0') {
+		return 0, false
+	}
+	return index, true
+}
+
+// decodeJSON 解码 JSON，数字保留为 json.Number
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// normalizeJSONValue 将 json.Number 转换为 int64 或 float64
+func normalizeJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
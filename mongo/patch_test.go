@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestJSONPatchToUpdate(t *testing.T) {
+	ops, err := ParseJSONPatch([]byte(`[
+		{"op": "replace", "path": "/title", "value": "new"},
+		{"op": "add", "path": "/profile/a~1b", "value": 3},
+		{"op": "add", "path": "/tags/-", "value": "go"},
+		{"op": "add", "path": "/links/0", "value": 1.5},
+		{"op": "remove", "path": "/summary"},
+		{"op": "test", "path": "/version", "value": 2}
+	]`))
+	require.NoError(t, err)
+
+	patch, err := JSONPatchToUpdate(ops, nil)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"title": "new", "profile.a/b": int64(3)},
+		"$unset": bson.M{"summary": ""},
+		"$push": bson.M{
+			"tags":  bson.M{"$each": bson.A{"go"}},
+			"links": bson.M{"$each": bson.A{1.5}, "$position": 0},
+		},
+	}, patch.Update)
+	assert.Equal(t, bson.M{"version": int64(2)}, patch.Conditions)
+}
+
+func TestJSONPatchToUpdateRejects(t *testing.T) {
+	cases := map[string]PatchOperation{
+		"operator":  {Op: "replace", Path: "/$where", Value: 1},
+		"dotted":    {Op: "replace", Path: "/a.b", Value: 1},
+		"id":        {Op: "replace", Path: "/_id", Value: 1},
+		"root":      {Op: "replace", Path: "/", Value: 1},
+		"no slash":  {Op: "replace", Path: "title", Value: 1},
+		"move":      {Op: "move", Path: "/a", From: "/b"},
+		"index":     {Op: "remove", Path: "/tags/1"},
+		"unknown":   {Op: "merge", Path: "/a"},
+		"allowlist": {Op: "replace", Path: "/role", Value: "admin"},
+	}
+	opts := &PatchOptions{AllowedPaths: []string{"a", "b", "title", "tags"}}
+	for name, op := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := JSONPatchToUpdate([]PatchOperation{op}, opts)
+			assert.True(t, errors.Is(err, ErrInvalidPatch), err)
+		})
+	}
+
+	_, err := JSONPatchToUpdate([]PatchOperation{
+		{Op: "replace", Path: "/profile", Value: bson.M{}},
+		{Op: "replace", Path: "/profile/name", Value: "x"},
+	}, nil)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestMergePatchToUpdate(t *testing.T) {
+	patch, err := ParseMergePatch([]byte(`{"title": "new", "summary": null, "profile": {"age": 30, "bio": null}, "tags": ["a"]}`))
+	require.NoError(t, err)
+
+	update, err := MergePatchToUpdate(patch, &PatchOptions{AllowedPaths: []string{"title", "summary", "profile", "tags"}})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"title": "new", "profile.age": int64(30), "tags": []interface{}{"a"}},
+		"$unset": bson.M{"summary": "", "profile.bio": ""},
+	}, update)
+
+	_, err = MergePatchToUpdate(map[string]interface{}{"profile": map[string]interface{}{"$inc": 1}}, nil)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+	_, err = MergePatchToUpdate(map[string]interface{}{"created_at": nil}, nil)
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
@@ -0,0 +1,328 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Populate 引用填充规则
+// 将 Field 中保存的 _id（单个或数组）替换为 Collection 中对应的文档，写入 As 字段
+type Populate struct {
+	// Field 保存引用 _id 的字段
+	Field string
+	// Collection 被引用的集合
+	Collection string
+	// As 填充结果写入的字段，为空时使用 Field
+	As string
+	// Many 引用字段是否为 _id 数组
+	Many bool
+	// Projection 被引用文档的投影
+	Projection bson.M
+	// Populate 被引用文档上继续填充的引用，用于控制填充深度
+	Populate []Populate
+}
+
+// as 返回填充结果写入的字段
+func (p Populate) as() string {
+	if p.As != "" {
+		return p.As
+	}
+	return p.Field
+}
+
+// PopulateFromStruct 根据结构体字段的 ref 标签生成填充规则，depth 为填充深度，小于 1 时按 1 处理
+// 标签格式为 ref:"<集合名>[,as=<字段名>][,ondelete=<处理方式>]"，ondelete 的含义见 RelationRegistry.RegisterModel，as 省略时去掉字段名的 _id/_ids 后缀，例如：
+//
+//	AuthorID primitive.ObjectID   `bson:"author_id" ref:"users"`              // 填充到 author
+//	TagIDs   []primitive.ObjectID `bson:"tag_ids" ref:"tags"`                 // 填充到 tags
+//	Comments []primitive.ObjectID `bson:"comments" ref:"comments,as=comment_docs"`
+//
+// 结构体中存在与 as 同名的结构体字段时，会继续读取该字段类型上的 ref 标签生成下一层规则
+func PopulateFromStruct(model interface{}, depth int) ([]Populate, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("populate model must be a struct, got %T", model)
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return populateFromType(t, depth)
+}
+
+// populateFromType 递归收集结构体的填充规则
+func populateFromType(t reflect.Type, depth int) ([]Populate, error) {
+	fields := make(map[string]reflect.Type)
+	walkStructFields(t, func(field reflect.StructField, name string) {
+		fields[name] = field.Type
+	})
+
+	var specs []Populate
+	var err error
+	walkStructFields(t, func(field reflect.StructField, name string) {
+		tag := field.Tag.Get("ref")
+		if tag == "" || err != nil {
+			return
+		}
+		spec, _, parseErr := parseRefTag(name, tag, field.Type)
+		if parseErr != nil {
+			err = fmt.Errorf("invalid ref tag on field %s: %w", field.Name, parseErr)
+			return
+		}
+		if depth > 1 {
+			if target, ok := fields[spec.as()]; ok {
+				for target.Kind() == reflect.Ptr || target.Kind() == reflect.Slice {
+					target = target.Elem()
+				}
+				if target.Kind() == reflect.Struct {
+					spec.Populate, err = populateFromType(target, depth-1)
+				}
+			}
+		}
+		specs = append(specs, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return specs, nil
+}
+
+// parseRefTag 解析 ref 标签，返回填充规则和删除被引用文档时的处理方式
+func parseRefTag(field, tag string, fieldType reflect.Type) (Populate, OnDelete, error) {
+	parts := strings.Split(tag, ",")
+	spec := Populate{
+		Field:      field,
+		Collection: strings.TrimSpace(parts[0]),
+		Many:       fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array && fieldType != reflect.TypeOf(primitive.ObjectID{}),
+	}
+	onDelete := OnDeleteNoAction
+	if spec.Collection == "" {
+		return spec, onDelete, fmt.Errorf("missing collection name")
+	}
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "as":
+			spec.As = value
+		case "ondelete":
+			var err error
+			if onDelete, err = parseOnDelete(value); err != nil {
+				return spec, onDelete, err
+			}
+		default:
+			return spec, onDelete, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	if spec.As == "" {
+		switch {
+		case strings.HasSuffix(field, "_ids"):
+			spec.As = strings.TrimSuffix(field, "_ids") + "s"
+		case strings.HasSuffix(field, "_id"):
+			spec.As = strings.TrimSuffix(field, "_id")
+		}
+	}
+	return spec, onDelete, nil
+}
+
+// walkStructFields 遍历结构体导出字段，inline 字段展开到当前层级，name 为字段的 bson 名称
+func walkStructFields(t reflect.Type, fn func(field reflect.StructField, name string)) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		bsonTag := field.Tag.Get("bson")
+		if bsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(bsonTag, ",")
+
+		if contains(tagParts[1:], "inline") {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				walkStructFields(ft, fn)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := tagParts[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fn(field, name)
+	}
+}
+
+// PopulateStages 生成通过 $lookup 填充引用的聚合阶段，可以追加到任意聚合管道之后：
+//
+//	pipeline := append([]bson.M{{"$match": bson.M{"status": "published"}}}, PopulateStages(specs)...)
+//	err := articles.Aggregate(ctx, pipeline, &results)
+//
+// 数组引用的填充结果不保证与原数组顺序一致，需要保持顺序时使用 FindWithPopulate
+func PopulateStages(populate []Populate) []bson.M {
+	var stages []bson.M
+	for _, spec := range populate {
+		var match bson.M
+		if spec.Many {
+			match = bson.M{"$expr": bson.M{"$in": bson.A{"$_id", bson.M{"$ifNull": bson.A{"$$ref", bson.A{}}}}}}
+		} else {
+			match = bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$ref"}}}
+		}
+		pipeline := bson.A{bson.M{"$match": match}}
+		for _, stage := range PopulateStages(spec.Populate) {
+			pipeline = append(pipeline, stage)
+		}
+		if len(spec.Projection) > 0 {
+			pipeline = append(pipeline, bson.M{"$project": spec.Projection})
+		}
+
+		stages = append(stages, bson.M{"$lookup": bson.M{
+			"from":     spec.Collection,
+			"let":      bson.M{"ref": "$" + spec.Field},
+			"pipeline": pipeline,
+			"as":       spec.as(),
+		}})
+		if !spec.Many {
+			stages = append(stages, bson.M{"$addFields": bson.M{
+				spec.as(): bson.M{"$arrayElemAt": bson.A{"$" + spec.as(), 0}},
+			}})
+		}
+	}
+	return stages
+}
+
+// FindWithPopulate 查找多个文档并填充引用
+// 每条规则对应一次批量 $in 查询，不会对每个文档单独查询被引用的集合，数组引用保持原数组顺序
+func (c *Collection) FindWithPopulate(ctx context.Context, filter bson.M, results interface{}, populate []Populate, opts ...*options.FindOptions) error {
+	var docs []bson.M
+	if err := c.Find(ctx, filter, &docs, opts...); err != nil {
+		return err
+	}
+	if err := c.populateDocuments(ctx, docs, populate); err != nil {
+		return err
+	}
+	return decodeDocuments(docs, results)
+}
+
+// FindOneWithPopulate 查找单个文档并填充引用
+func (c *Collection) FindOneWithPopulate(ctx context.Context, filter bson.M, result interface{}, populate []Populate, opts ...*options.FindOneOptions) error {
+	var doc bson.M
+	if err := c.FindOne(ctx, filter, &doc, opts...); err != nil {
+		return err
+	}
+	if err := c.populateDocuments(ctx, []bson.M{doc}, populate); err != nil {
+		return err
+	}
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode populated document: %w", err)
+	}
+	if err := bson.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode populated document: %w", err)
+	}
+	return nil
+}
+
+// populateDocuments 按规则批量查询被引用的文档并写回 docs
+func (c *Collection) populateDocuments(ctx context.Context, docs []bson.M, populate []Populate) error {
+	ctx = c.sessionContext(ctx)
+	for _, spec := range populate {
+		var ids []interface{}
+		seen := make(map[interface{}]bool)
+		for _, doc := range docs {
+			for _, id := range refIDs(doc[spec.Field]) {
+				if key := auditKey(id); !seen[key] {
+					seen[key] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+
+		byID := make(map[interface{}]bson.M)
+		if len(ids) > 0 {
+			target := &Collection{
+				cli:        c.cli,
+				collection: c.collection.Database().Collection(spec.Collection),
+				session:    c.session,
+			}
+			opts := options.Find()
+			if len(spec.Projection) > 0 {
+				opts.SetProjection(spec.Projection)
+			}
+			var refs []bson.M
+			if err := target.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, &refs, opts); err != nil {
+				return fmt.Errorf("failed to populate %s: %w", spec.Field, err)
+			}
+			if err := target.populateDocuments(ctx, refs, spec.Populate); err != nil {
+				return err
+			}
+			for _, ref := range refs {
+				byID[auditKey(ref["_id"])] = ref
+			}
+		}
+
+		for _, doc := range docs {
+			value, ok := doc[spec.Field]
+			if !ok {
+				continue
+			}
+			if !spec.Many {
+				// 被引用的文档不存在时，原地填充置为 nil，填充到其它字段时不写入
+				if ref, found := byID[auditKey(value)]; found {
+					doc[spec.as()] = ref
+				} else if spec.as() != spec.Field {
+					delete(doc, spec.as())
+				} else {
+					doc[spec.as()] = nil
+				}
+				continue
+			}
+			populated := bson.A{}
+			for _, id := range refIDs(value) {
+				if ref, found := byID[auditKey(id)]; found {
+					populated = append(populated, ref)
+				}
+			}
+			doc[spec.as()] = populated
+		}
+	}
+	return nil
+}
+
+// refIDs 将引用字段的值展开为 _id 列表
+func refIDs(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case bson.A:
+		return v
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+// decodeDocuments 将文档列表解码到 results 指向的切片
+func decodeDocuments(docs []bson.M, results interface{}) error {
+	data, err := bson.Marshal(bson.M{"items": docs})
+	if err != nil {
+		return fmt.Errorf("failed to encode populated documents: %w", err)
+	}
+	if err := bson.Raw(data).Lookup("items").Unmarshal(results); err != nil {
+		return fmt.Errorf("failed to decode populated documents: %w", err)
+	}
+	return nil
+}
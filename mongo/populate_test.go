@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPopulateFromStruct(t *testing.T) {
+	specs, err := PopulateFromStruct(&Article{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []Populate{
+		{Field: "author_id", Collection: "users", As: "author"},
+		{Field: "category_id", Collection: "categories", As: "category"},
+	}, specs)
+}
+
+func TestPopulateFromStructNested(t *testing.T) {
+	type comment struct {
+		AuthorID primitive.ObjectID `bson:"author_id" ref:"users"`
+	}
+	type post struct {
+		CommentIDs []primitive.ObjectID `bson:"comment_ids" ref:"comments"`
+		Comments   []comment            `bson:"comments,omitempty"`
+		EditorID   primitive.ObjectID   `bson:"editor" ref:"users,as=editor_doc"`
+	}
+
+	specs, err := PopulateFromStruct(post{}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []Populate{
+		{Field: "comment_ids", Collection: "comments", As: "comments", Many: true, Populate: []Populate{
+			{Field: "author_id", Collection: "users", As: "author"},
+		}},
+		{Field: "editor", Collection: "users", As: "editor_doc"},
+	}, specs)
+
+	specs, err = PopulateFromStruct(post{}, 1)
+	require.NoError(t, err)
+	assert.Nil(t, specs[0].Populate)
+}
+
+func TestPopulateFromStructInvalidTag(t *testing.T) {
+	type bad struct {
+		UserID primitive.ObjectID `bson:"user_id" ref:"users,by=name"`
+	}
+	_, err := PopulateFromStruct(bad{}, 1)
+	assert.Error(t, err)
+}
+
+func TestPopulateStages(t *testing.T) {
+	stages := PopulateStages([]Populate{{Field: "author_id", Collection: "users", As: "author", Projection: ExcludeFields("password")}})
+	require.Len(t, stages, 2)
+	lookup := stages[0]["$lookup"].(bson.M)
+	assert.Equal(t, "users", lookup["from"])
+	assert.Equal(t, bson.M{"ref": "$author_id"}, lookup["let"])
+	assert.Equal(t, "author", lookup["as"])
+	assert.Len(t, lookup["pipeline"], 2)
+	assert.Equal(t, bson.M{"$addFields": bson.M{"author": bson.M{"$arrayElemAt": bson.A{"$author", 0}}}}, stages[1])
+}
+
+func TestDecodeDocuments(t *testing.T) {
+	type author struct {
+		Username string `bson:"username"`
+	}
+	type article struct {
+		Title  string  `bson:"title"`
+		Author *author `bson:"author"`
+	}
+
+	var results []article
+	docs := []bson.M{{"title": "a", "author": bson.M{"username": "tom"}}, {"title": "b"}}
+	require.NoError(t, decodeDocuments(docs, &results))
+	assert.Equal(t, []article{{Title: "a", Author: &author{Username: "tom"}}, {Title: "b"}}, results)
+
+	results = nil
+	require.NoError(t, decodeDocuments(nil, &results))
+	assert.Empty(t, results)
+}
@@ -0,0 +1,203 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProfilingLevel 数据库分析器级别
+type ProfilingLevel int
+
+const (
+	// ProfileOff 关闭分析器
+	ProfileOff ProfilingLevel = 0
+	// ProfileSlow 只记录超过 slowms 的操作
+	ProfileSlow ProfilingLevel = 1
+	// ProfileAll 记录所有操作，对性能影响较大，只应短时间开启
+	ProfileAll ProfilingLevel = 2
+)
+
+// ProfilingStatus 分析器的当前设置
+type ProfilingStatus struct {
+	Level      ProfilingLevel `bson:"was" json:"level"`
+	SlowMS     int64          `bson:"slowms" json:"slowms"`
+	SampleRate float64        `bson:"sampleRate" json:"sample_rate"`
+}
+
+// ProfileEntry system.profile 中的一条记录
+type ProfileEntry struct {
+	Op             string    `bson:"op" json:"op"`
+	Namespace      string    `bson:"ns" json:"ns"`
+	Command        bson.M    `bson:"command" json:"command"`
+	Millis         int64     `bson:"millis" json:"millis"`
+	PlanSummary    string    `bson:"planSummary" json:"plan_summary"`
+	KeysExamined   int64     `bson:"keysExamined" json:"keys_examined"`
+	DocsExamined   int64     `bson:"docsExamined" json:"docs_examined"`
+	NReturned      int64     `bson:"nreturned" json:"nreturned"`
+	ResponseLength int64     `bson:"responseLength" json:"response_length"`
+	Timestamp      time.Time `bson:"ts" json:"ts"`
+	Client         string    `bson:"client" json:"client"`
+	AppName        string    `bson:"appName" json:"app_name"`
+	User           string    `bson:"user" json:"user"`
+}
+
+// Duration 操作耗时
+func (e *ProfileEntry) Duration() time.Duration {
+	return time.Duration(e.Millis) * time.Millisecond
+}
+
+// ProfileFilter system.profile 查询条件
+type ProfileFilter struct {
+	// Namespace 只返回该命名空间（db.coll）的记录
+	Namespace string
+	// Op 操作类型，例如 query、update、remove、command
+	Op string
+	// MinDuration 只返回耗时不少于该值的记录
+	MinDuration time.Duration
+	// Since 只返回该时间之后的记录
+	Since time.Time
+	// Limit 最多返回的记录数，默认 100
+	Limit int64
+}
+
+// CollectionSlowOps 一个集合的慢操作汇总
+type CollectionSlowOps struct {
+	Namespace   string `json:"ns"`
+	Count       int64  `json:"count"`
+	TotalMillis int64  `json:"total_millis"`
+	MaxMillis   int64  `json:"max_millis"`
+	// Operations 最慢的若干条记录，按耗时从长到短排序
+	Operations []ProfileEntry `json:"operations"`
+}
+
+// Profiler 管理数据库分析器并查询 system.profile
+// 分析器的设置只对当前连接的节点生效，副本集上需要分别在各节点设置
+type Profiler struct {
+	client *Client
+}
+
+// NewProfiler 创建分析器管理
+func NewProfiler(client *Client) *Profiler {
+	return &Profiler{client: client}
+}
+
+// Status 返回当前的分析器设置
+func (p *Profiler) Status(ctx context.Context) (*ProfilingStatus, error) {
+	var status ProfilingStatus
+	err := p.client.GetDatabase().RunCommand(ctx, bson.D{{Key: "profile", Value: -1}}).Decode(&status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get profiling level: %w", err)
+	}
+	return &status, nil
+}
+
+// SetLevel 设置分析器级别和慢操作阈值，slow 为 0 时保持原阈值；返回修改前的设置
+// 注意 slowms 是节点级别的设置，同样影响日志中慢查询的记录
+func (p *Profiler) SetLevel(ctx context.Context, level ProfilingLevel, slow time.Duration) (*ProfilingStatus, error) {
+	if level < ProfileOff || level > ProfileAll {
+		return nil, fmt.Errorf("invalid profiling level %d", level)
+	}
+	command := bson.D{{Key: "profile", Value: int32(level)}}
+	if slow > 0 {
+		command = append(command, bson.E{Key: "slowms", Value: slow.Milliseconds()})
+	}
+	var previous ProfilingStatus
+	if err := p.client.GetDatabase().RunCommand(ctx, command).Decode(&previous); err != nil {
+		return nil, fmt.Errorf("failed to set profiling level: %w", err)
+	}
+	p.client.logger.InfoContext(ctx, "Set profiling level", "database", p.client.GetDatabaseName(), "level", level, "slowms", slow.Milliseconds())
+	return &previous, nil
+}
+
+// Entries 查询 system.profile，按时间从新到旧排序
+func (p *Profiler) Entries(ctx context.Context, filter *ProfileFilter) ([]ProfileEntry, error) {
+	query, limit := profileQuery(filter)
+	opts := options.Find().SetSort(bson.D{{Key: "ts", Value: -1}}).SetLimit(limit)
+	return p.find(ctx, query, opts)
+}
+
+// TopSlow 按集合汇总慢操作，每个集合保留最慢的 perCollection 条记录，集合按总耗时从高到低排序；
+// filter.Limit 限制参与汇总的记录数（按耗时从高到低选取）
+func (p *Profiler) TopSlow(ctx context.Context, filter *ProfileFilter, perCollection int) ([]CollectionSlowOps, error) {
+	query, limit := profileQuery(filter)
+	opts := options.Find().SetSort(bson.D{{Key: "millis", Value: -1}}).SetLimit(limit)
+	entries, err := p.find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	return groupSlowOps(entries, perCollection), nil
+}
+
+func (p *Profiler) find(ctx context.Context, query bson.M, opts *options.FindOptions) ([]ProfileEntry, error) {
+	cursor, err := p.client.GetDatabase().Collection("system.profile").Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query system.profile: %w", err)
+	}
+	var entries []ProfileEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode system.profile: %w", err)
+	}
+	return entries, nil
+}
+
+func profileQuery(filter *ProfileFilter) (bson.M, int64) {
+	f := ProfileFilter{}
+	if filter != nil {
+		f = *filter
+	}
+	if f.Limit <= 0 {
+		f.Limit = 100
+	}
+	query := bson.M{}
+	if f.Namespace != "" {
+		query["ns"] = f.Namespace
+	}
+	if f.Op != "" {
+		query["op"] = f.Op
+	}
+	if f.MinDuration > 0 {
+		query["millis"] = bson.M{"$gte": f.MinDuration.Milliseconds()}
+	}
+	if !f.Since.IsZero() {
+		query["ts"] = bson.M{"$gte": f.Since}
+	}
+	return query, f.Limit
+}
+
+// groupSlowOps entries 已按耗时从高到低排序
+func groupSlowOps(entries []ProfileEntry, perCollection int) []CollectionSlowOps {
+	if perCollection <= 0 {
+		perCollection = 5
+	}
+	groups := make(map[string]*CollectionSlowOps)
+	for _, entry := range entries {
+		group, ok := groups[entry.Namespace]
+		if !ok {
+			group = &CollectionSlowOps{Namespace: entry.Namespace}
+			groups[entry.Namespace] = group
+		}
+		group.Count++
+		group.TotalMillis += entry.Millis
+		group.MaxMillis = max(group.MaxMillis, entry.Millis)
+		if len(group.Operations) < perCollection {
+			group.Operations = append(group.Operations, entry)
+		}
+	}
+
+	result := make([]CollectionSlowOps, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalMillis != result[j].TotalMillis {
+			return result[i].TotalMillis > result[j].TotalMillis
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
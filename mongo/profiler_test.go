@@ -0,0 +1,53 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestProfileQuery(t *testing.T) {
+	query, limit := profileQuery(nil)
+	assert.Empty(t, query)
+	assert.Equal(t, int64(100), limit)
+
+	since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	query, limit = profileQuery(&ProfileFilter{Namespace: "app.articles", Op: "query", MinDuration: 250 * time.Millisecond, Since: since, Limit: 10})
+	assert.Equal(t, bson.M{
+		"ns":     "app.articles",
+		"op":     "query",
+		"millis": bson.M{"$gte": int64(250)},
+		"ts":     bson.M{"$gte": since},
+	}, query)
+	assert.Equal(t, int64(10), limit)
+}
+
+func TestGroupSlowOps(t *testing.T) {
+	entries := []ProfileEntry{
+		{Namespace: "app.articles", Millis: 900},
+		{Namespace: "app.users", Millis: 800},
+		{Namespace: "app.users", Millis: 700},
+		{Namespace: "app.articles", Millis: 300},
+		{Namespace: "app.users", Millis: 200},
+	}
+	groups := groupSlowOps(entries, 2)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "app.users", groups[0].Namespace)
+	assert.Equal(t, int64(3), groups[0].Count)
+	assert.Equal(t, int64(1700), groups[0].TotalMillis)
+	assert.Equal(t, int64(800), groups[0].MaxMillis)
+	require.Len(t, groups[0].Operations, 2)
+	assert.Equal(t, int64(700), groups[0].Operations[1].Millis)
+	assert.Equal(t, "app.articles", groups[1].Namespace)
+	assert.Equal(t, 900*time.Millisecond, groups[1].Operations[0].Duration())
+}
+
+func TestProfilerSetLevelValidation(t *testing.T) {
+	profiler := NewProfiler(newLazyClient(t))
+	_, err := profiler.SetLevel(context.Background(), ProfilingLevel(3), 0)
+	assert.ErrorContains(t, err, "invalid profiling level 3")
+}
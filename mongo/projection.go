@@ -0,0 +1,74 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// IncludeFields 构建只返回指定字段的投影
+func IncludeFields(fields ...string) bson.M {
+	projection := bson.M{}
+	for _, field := range fields {
+		projection[field] = 1
+	}
+	return projection
+}
+
+// ExcludeFields 构建排除指定字段的投影，例如 ExcludeFields("password", "content")
+func ExcludeFields(fields ...string) bson.M {
+	projection := bson.M{}
+	for _, field := range fields {
+		projection[field] = 0
+	}
+	return projection
+}
+
+// ProjectionFromStruct 根据目标结构体的 bson 标签构建投影，只返回结构体中声明的字段
+// 适用于使用精简结构体（例如列表页的 ArticleSummary）接收查询结果的场景
+func ProjectionFromStruct(model interface{}) bson.M {
+	projection := bson.M{}
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return projection
+	}
+	collectProjectionFields(t, projection)
+	return projection
+}
+
+// collectProjectionFields 收集结构体字段名，inline 字段展开到当前层级
+func collectProjectionFields(t reflect.Type, projection bson.M) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		bsonTag := field.Tag.Get("bson")
+		if bsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(bsonTag, ",")
+
+		if contains(tagParts[1:], "inline") {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectProjectionFields(ft, projection)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := tagParts[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		projection[name] = 1
+	}
+}
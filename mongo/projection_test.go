@@ -0,0 +1,54 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestProjectionBuilders(t *testing.T) {
+	assert.Equal(t, bson.M{"title": 1, "author": 1}, IncludeFields("title", "author"))
+	assert.Equal(t, bson.M{"password": 0, "content": 0}, ExcludeFields("password", "content"))
+
+	type summary struct {
+		BaseDocument `bson:",inline"`
+		Title        string `bson:"title"`
+		Author       string `bson:"author,omitempty"`
+		Score        float64
+		Secret       string `bson:"-"`
+		internal     string
+	}
+	expected := bson.M{"_id": 1, "created_at": 1, "updated_at": 1, "title": 1, "author": 1, "score": 1}
+	assert.Equal(t, expected, ProjectionFromStruct(summary{internal: "x"}))
+	// 结果切片和指针按元素类型处理
+	assert.Equal(t, expected, ProjectionFromStruct(&[]*summary{}))
+	assert.Empty(t, ProjectionFromStruct(bson.M{}))
+	assert.Empty(t, ProjectionFromStruct(nil))
+}
+
+func TestFindProjection(t *testing.T) {
+	server := newFakeServer(t, false)
+	articles := NewCollection(server.client(t), "articles")
+	ctx := t.Context()
+
+	var article bson.M
+	err := articles.FindByID(ctx, primitive.NewObjectID(), &article, options.FindOne().SetProjection(ExcludeFields("content")))
+	assert.Error(t, err, "the fake server has no documents")
+
+	var page []bson.M
+	_, err = articles.FindWithPagination(ctx, bson.M{}, 2, 10, &page,
+		options.Find().SetProjection(IncludeFields("title")).SetSkip(100).SetLimit(1))
+	require.NoError(t, err)
+
+	finds := server.Commands("find")
+	require.Len(t, finds, 2)
+	assert.Equal(t, int32(0), finds[0].Lookup("projection", "content").Int32())
+	assert.Equal(t, int32(1), finds[1].Lookup("projection", "title").Int32())
+	// 分页参数覆盖调用方的 skip/limit
+	assert.Equal(t, int64(10), finds[1].Lookup("skip").AsInt64())
+	assert.Equal(t, int64(10), finds[1].Lookup("limit").AsInt64())
+}
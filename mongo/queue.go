@@ -0,0 +1,541 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrJobLeaseLost 任务锁定超时后已被其它 worker 重新领取，本次执行结果不再写入
+var ErrJobLeaseLost = errors.New("job lease lost")
+
+// JobStatus 任务状态
+type JobStatus string
+
+const (
+	// JobPending 等待执行（包括延迟任务和等待重试的任务）
+	JobPending JobStatus = "pending"
+	// JobRunning 已被 worker 领取，正在执行
+	JobRunning JobStatus = "running"
+	// JobCompleted 执行成功
+	JobCompleted JobStatus = "completed"
+	// JobDead 超过最大重试次数，进入死信状态
+	JobDead JobStatus = "dead"
+)
+
+// Job 队列任务
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Queue       string             `bson:"queue" json:"queue"`
+	Type        string             `bson:"type" json:"type"`
+	Payload     bson.Raw           `bson:"payload,omitempty" json:"-"`
+	Status      JobStatus          `bson:"status" json:"status"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	MaxAttempts int                `bson:"max_attempts" json:"max_attempts"`
+	RunAt       time.Time          `bson:"run_at" json:"run_at"`
+	LockedBy    string             `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
+	LockedUntil time.Time          `bson:"locked_until,omitempty" json:"locked_until,omitempty"`
+	// Lease 每次领取生成的租约，Complete 和 Fail 只更新租约仍然有效的任务
+	Lease       string     `bson:"lease,omitempty" json:"-"`
+	LastError   string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// Decode 解码任务负载
+func (j *Job) Decode(v interface{}) error {
+	if len(j.Payload) == 0 {
+		return nil
+	}
+	if err := bson.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode job payload: %w", err)
+	}
+	return nil
+}
+
+// JobHandler 任务处理函数，返回错误时任务会按退避策略重试
+type JobHandler func(ctx context.Context, job *Job) error
+
+// EnqueueOptions 入队选项
+type EnqueueOptions struct {
+	// Delay 延迟执行时间
+	Delay time.Duration
+	// RunAt 指定执行时间，优先于 Delay
+	RunAt time.Time
+	// MaxAttempts 最大尝试次数，默认使用队列配置
+	MaxAttempts int
+}
+
+// JobQueueOptions 任务队列配置
+type JobQueueOptions struct {
+	// Collection 任务集合名称，默认 jobs
+	Collection string
+	// Queue 队列名称，多个队列可以共用一个集合，默认 default
+	Queue string
+	// Workers 并发 worker 数量，默认 1
+	Workers int
+	// PollInterval 队列为空时的轮询间隔，默认 1 秒
+	PollInterval time.Duration
+	// VisibilityTimeout 任务领取后的锁定时间，超时未完成的任务会被其他 worker 重新领取，默认 30 秒
+	VisibilityTimeout time.Duration
+	// MaxAttempts 默认最大尝试次数，默认 5 次
+	MaxAttempts int
+	// RetryBackoff 首次重试的等待时间，之后按指数增长，默认 5 秒
+	RetryBackoff time.Duration
+	// MaxRetryBackoff 重试等待的上限，默认 10 分钟
+	MaxRetryBackoff time.Duration
+	// SweepInterval 将锁定超时且用完尝试次数的任务转入死信的最小间隔，同一进程的 worker 共享，默认等于 VisibilityTimeout
+	SweepInterval time.Duration
+}
+
+// JobQueue 基于 MongoDB 的持久化任务队列
+// 通过 FindOneAndUpdate 原子领取任务，支持延迟任务、失败重试和死信
+type JobQueue struct {
+	collection *Collection
+	opts       JobQueueOptions
+	workerID   string
+
+	mu       sync.RWMutex
+	handlers map[string]JobHandler
+
+	sweepMu   sync.Mutex
+	lastSweep time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewJobQueue 创建任务队列
+func NewJobQueue(client *Client, opts *JobQueueOptions) *JobQueue {
+	q := &JobQueue{
+		handlers: make(map[string]JobHandler),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.Collection == "" {
+		q.opts.Collection = "jobs"
+	}
+	if q.opts.Queue == "" {
+		q.opts.Queue = "default"
+	}
+	if q.opts.Workers <= 0 {
+		q.opts.Workers = 1
+	}
+	if q.opts.PollInterval <= 0 {
+		q.opts.PollInterval = time.Second
+	}
+	if q.opts.VisibilityTimeout <= 0 {
+		q.opts.VisibilityTimeout = 30 * time.Second
+	}
+	if q.opts.MaxAttempts <= 0 {
+		q.opts.MaxAttempts = 5
+	}
+	if q.opts.RetryBackoff <= 0 {
+		q.opts.RetryBackoff = 5 * time.Second
+	}
+	if q.opts.MaxRetryBackoff <= 0 {
+		q.opts.MaxRetryBackoff = 10 * time.Minute
+	}
+	if q.opts.SweepInterval <= 0 {
+		q.opts.SweepInterval = q.opts.VisibilityTimeout
+	}
+
+	hostname, _ := os.Hostname()
+	q.workerID = fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), primitive.NewObjectID().Hex())
+	q.collection = NewCollection(client, q.opts.Collection)
+	return q
+}
+
+// EnsureIndexes 创建任务领取所需的索引
+func (q *JobQueue) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "queue", Value: 1}, {Key: "status", Value: 1}, {Key: "run_at", Value: 1}},
+			Options: options.Index().SetName("idx_queue_status_run_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "queue", Value: 1}, {Key: "status", Value: 1}, {Key: "locked_until", Value: 1}},
+			Options: options.Index().SetName("idx_queue_status_locked_until"),
+		},
+	}
+	ctx, done, err := q.collection.writeContext(ctx, "createIndexes")
+	if err != nil {
+		return err
+	}
+	defer done()
+	if _, err := q.collection.collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create job indexes: %w", err)
+	}
+	return nil
+}
+
+// Handle 注册任务处理函数，只有注册过处理函数的任务类型才会被领取
+func (q *JobQueue) Handle(jobType string, handler JobHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// Enqueue 任务入队
+func (q *JobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts *EnqueueOptions) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:          primitive.NewObjectID(),
+		Queue:       q.opts.Queue,
+		Type:        jobType,
+		Status:      JobPending,
+		MaxAttempts: q.opts.MaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if payload != nil {
+		raw, err := bson.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+		}
+		job.Payload = raw
+	}
+	if opts != nil {
+		if !opts.RunAt.IsZero() {
+			job.RunAt = opts.RunAt
+		} else if opts.Delay > 0 {
+			job.RunAt = now.Add(opts.Delay)
+		}
+		if opts.MaxAttempts > 0 {
+			job.MaxAttempts = opts.MaxAttempts
+		}
+	}
+
+	ctx, done, err := q.collection.writeContext(ctx, "insert")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if _, err := q.collection.collection.InsertOne(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim 原子领取一个到期的任务，包括锁定超时的任务，没有可领取的任务时返回 nil
+func (q *JobQueue) Claim(ctx context.Context) (*Job, error) {
+	q.mu.RLock()
+	types := make([]string, 0, len(q.handlers))
+	for jobType := range q.handlers {
+		types = append(types, jobType)
+	}
+	q.mu.RUnlock()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	ctx, done, err := q.collection.writeContext(ctx, "findAndModify")
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	now := time.Now()
+	if q.sweepDue(now) {
+		if err := q.buryExpired(ctx, types, now); err != nil {
+			q.resetSweep()
+			return nil, err
+		}
+	}
+	filter := bson.M{
+		"queue": q.opts.Queue,
+		"type":  bson.M{"$in": types},
+		"$or": bson.A{
+			bson.M{"status": JobPending, "run_at": bson.M{"$lte": now}},
+			bson.M{"status": JobRunning, "locked_until": bson.M{"$lte": now}, "$expr": bson.M{"$lt": bson.A{"$attempts", "$max_attempts"}}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       JobRunning,
+			"locked_by":    q.workerID,
+			"locked_until": now.Add(q.opts.VisibilityTimeout),
+			"lease":        primitive.NewObjectID().Hex(),
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job Job
+	err = q.collection.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return &job, nil
+}
+
+// sweepDue 返回本次领取是否需要清理超时任务，并记录清理时间；
+// 领取条件已经排除了用完尝试次数的超时任务，清理只影响死信出现的时间，不需要每次领取都扫描集合
+func (q *JobQueue) sweepDue(now time.Time) bool {
+	q.sweepMu.Lock()
+	defer q.sweepMu.Unlock()
+	if !q.lastSweep.IsZero() && now.Sub(q.lastSweep) < q.opts.SweepInterval {
+		return false
+	}
+	q.lastSweep = now
+	return true
+}
+
+// resetSweep 清理失败时让下一次领取重新清理
+func (q *JobQueue) resetSweep() {
+	q.sweepMu.Lock()
+	defer q.sweepMu.Unlock()
+	q.lastSweep = time.Time{}
+}
+
+// buryExpired 将锁定超时且已用完尝试次数的任务转入死信，例如 worker 在最后一次尝试中崩溃
+func (q *JobQueue) buryExpired(ctx context.Context, types []string, now time.Time) error {
+	_, err := q.collection.collection.UpdateMany(ctx,
+		bson.M{
+			"queue":        q.opts.Queue,
+			"type":         bson.M{"$in": types},
+			"status":       JobRunning,
+			"locked_until": bson.M{"$lte": now},
+			"$expr":        bson.M{"$gte": bson.A{"$attempts", "$max_attempts"}},
+		},
+		bson.M{
+			"$set":   bson.M{"status": JobDead, "last_error": "visibility timeout exceeded on last attempt", "updated_at": now},
+			"$unset": bson.M{"locked_by": "", "locked_until": "", "lease": ""},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to move expired jobs to dead: %w", err)
+	}
+	return nil
+}
+
+// Complete 标记任务执行成功，任务已被其它 worker 重新领取时返回 ErrJobLeaseLost
+func (q *JobQueue) Complete(ctx context.Context, job *Job) error {
+	ctx, done, err := q.collection.writeContext(ctx, "update")
+	if err != nil {
+		return err
+	}
+	defer done()
+	now := time.Now()
+	result, err := q.collection.collection.UpdateOne(ctx,
+		q.leaseFilter(job),
+		bson.M{
+			"$set":   bson.M{"status": JobCompleted, "completed_at": now, "updated_at": now},
+			"$unset": bson.M{"locked_by": "", "locked_until": "", "lease": ""},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: job %s", ErrJobLeaseLost, job.ID.Hex())
+	}
+	return nil
+}
+
+// Fail 标记任务执行失败，未超过最大尝试次数时按指数退避重新排队，否则进入死信状态；
+// 任务已被其它 worker 重新领取时返回 ErrJobLeaseLost
+func (q *JobQueue) Fail(ctx context.Context, job *Job, cause error) error {
+	ctx, done, err := q.collection.writeContext(ctx, "update")
+	if err != nil {
+		return err
+	}
+	defer done()
+	now := time.Now()
+	set := bson.M{"updated_at": now}
+	if cause != nil {
+		set["last_error"] = cause.Error()
+	}
+	if job.Attempts >= job.MaxAttempts {
+		set["status"] = JobDead
+	} else {
+		set["status"] = JobPending
+		set["run_at"] = now.Add(q.retryBackoff(job.Attempts))
+	}
+
+	result, err := q.collection.collection.UpdateOne(ctx,
+		q.leaseFilter(job),
+		bson.M{"$set": set, "$unset": bson.M{"locked_by": "", "locked_until": "", "lease": ""}})
+	if err != nil {
+		return fmt.Errorf("failed to fail job: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("%w: job %s", ErrJobLeaseLost, job.ID.Hex())
+	}
+	return nil
+}
+
+// leaseFilter 匹配仍由本次领取持有的任务；workerID 由同一队列的所有 worker 共享，不能区分两次领取
+func (q *JobQueue) leaseFilter(job *Job) bson.M {
+	return bson.M{"_id": job.ID, "status": JobRunning, "lease": job.Lease}
+}
+
+// retryBackoff 计算第 attempts 次失败后的等待时间
+func (q *JobQueue) retryBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := q.opts.RetryBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > q.opts.MaxRetryBackoff {
+		backoff = q.opts.MaxRetryBackoff
+	}
+	return backoff
+}
+
+// DeadJobs 查询死信任务
+func (q *JobQueue) DeadJobs(ctx context.Context, limit int64) ([]*Job, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	var jobs []*Job
+	if err := q.collection.Find(ctx, bson.M{"queue": q.opts.Queue, "status": JobDead}, &jobs, opts); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Requeue 将死信任务重新放回队列，并重置尝试次数
+func (q *JobQueue) Requeue(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done, err := q.collection.writeContext(ctx, "update")
+	if err != nil {
+		return err
+	}
+	defer done()
+	now := time.Now()
+	result, err := q.collection.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "queue": q.opts.Queue, "status": JobDead},
+		bson.M{"$set": bson.M{"status": JobPending, "attempts": 0, "run_at": now, "updated_at": now}})
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("dead job %s not found", id.Hex())
+	}
+	return nil
+}
+
+// Start 启动 worker 池
+func (q *JobQueue) Start(ctx context.Context) {
+	q.startOnce.Do(func() {
+		q.collection.cli.RegisterShutdown(q)
+		var wg sync.WaitGroup
+		for i := 0; i < q.opts.Workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				q.work(ctx)
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(q.doneCh)
+		}()
+	})
+}
+
+// Stop 停止 worker 池，等待正在执行的任务结束
+func (q *JobQueue) Stop() {
+	q.stopOnce.Do(func() {
+		close(q.stopCh)
+		q.startOnce.Do(func() {
+			close(q.doneCh)
+		})
+		<-q.doneCh
+	})
+}
+
+// work worker 循环
+func (q *JobQueue) work(ctx context.Context) {
+	for {
+		select {
+		case <-q.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		job, err := q.Claim(ctx)
+		if err != nil {
+			q.collection.cli.logger.ErrorContext(ctx, "Job claim failed", "queue", q.opts.Queue, "err", err)
+		}
+		if job == nil {
+			select {
+			case <-time.After(q.opts.PollInterval):
+			case <-q.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		q.process(ctx, job)
+	}
+}
+
+// process 执行任务并记录结果
+func (q *JobQueue) process(ctx context.Context, job *Job) {
+	q.mu.RLock()
+	handler := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.opts.VisibilityTimeout)
+	err := runJobHandler(jobCtx, handler, job)
+	cancel()
+
+	// 任务结果使用独立的上下文写入，避免停止时丢失
+	writeCtx, writeCancel := context.WithTimeout(context.WithoutCancel(ctx), defaultOperationTimeout)
+	defer writeCancel()
+	if err != nil {
+		q.collection.cli.logger.WarnContext(ctx, "Job failed", "queue", q.opts.Queue, "type", job.Type, "id", job.ID.Hex(), "attempts", job.Attempts, "err", err)
+		if ferr := q.Fail(writeCtx, job, err); ferr != nil {
+			q.logResultError(ctx, job, "Job fail update failed", ferr)
+		}
+		return
+	}
+	if cerr := q.Complete(writeCtx, job); cerr != nil {
+		q.logResultError(ctx, job, "Job complete update failed", cerr)
+	}
+}
+
+// logResultError 记录写入任务结果的错误，租约丢失是锁定超时的正常结果，只记录警告
+func (q *JobQueue) logResultError(ctx context.Context, job *Job, msg string, err error) {
+	if errors.Is(err, ErrJobLeaseLost) {
+		q.collection.cli.logger.WarnContext(ctx, "Job lease lost before the result was saved", "queue", q.opts.Queue, "id", job.ID.Hex(), "attempts", job.Attempts)
+		return
+	}
+	q.collection.cli.logger.ErrorContext(ctx, msg, "id", job.ID.Hex(), "err", err)
+}
+
+// runJobHandler 执行处理函数，将 panic 转换为错误
+func runJobHandler(ctx context.Context, handler JobHandler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panic: %v", r)
+		}
+	}()
+	if handler == nil {
+		return fmt.Errorf("no handler registered for job type %s", job.Type)
+	}
+	return handler(ctx, job)
+}
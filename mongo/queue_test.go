@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJobQueueRetryBackoff(t *testing.T) {
+	q := NewJobQueue(newLazyClient(t), &JobQueueOptions{RetryBackoff: time.Second, MaxRetryBackoff: time.Minute})
+	assert.Equal(t, time.Second, q.retryBackoff(0))
+	assert.Equal(t, time.Second, q.retryBackoff(1))
+	assert.Equal(t, 4*time.Second, q.retryBackoff(3))
+	assert.Equal(t, time.Minute, q.retryBackoff(10))
+	// 位移溢出时使用上限
+	assert.Equal(t, time.Minute, q.retryBackoff(80))
+}
+
+func TestJobQueueLease(t *testing.T) {
+	server := newFakeServer(t, false)
+	jobID := primitive.NewObjectID()
+	var lease string
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		switch name {
+		case "findAndModify":
+			lease = cmd.Lookup("update", "$set", "lease").StringValue()
+			job := bson.M{"_id": jobID, "queue": "default", "type": "email", "status": JobRunning, "attempts": 1, "max_attempts": 5, "lease": lease}
+			return bson.D{{Key: "lastErrorObject", Value: bson.D{{Key: "n", Value: 1}}}, {Key: "value", Value: job}, {Key: "ok", Value: 1}}
+		case "update":
+			// 只有租约匹配的更新命中文档
+			q := cmd.Lookup("updates").Array().Index(0).Value().Document().Lookup("q")
+			if l, ok := q.Document().Lookup("lease").StringValueOK(); ok && l != lease {
+				return bson.D{{Key: "n", Value: 0}, {Key: "nModified", Value: 0}, {Key: "ok", Value: 1}}
+			}
+		}
+		return nil
+	})
+	q := NewJobQueue(server.client(t), nil)
+	q.Handle("email", func(ctx context.Context, job *Job) error { return nil })
+	ctx := t.Context()
+
+	job, err := q.Claim(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.NotEmpty(t, job.Lease)
+
+	// 领取前先将用完尝试次数的超时任务转入死信，超时任务只有还能重试时才会被重新领取
+	bury := server.Commands("update")[0].Lookup("updates").Array().Index(0).Value().Document()
+	assert.Equal(t, string(JobRunning), bury.Lookup("q", "status").StringValue())
+	assert.Equal(t, "$attempts", bury.Lookup("q", "$expr", "$gte").Array().Index(0).Value().StringValue())
+	assert.Equal(t, string(JobDead), bury.Lookup("u", "$set", "status").StringValue())
+	claim := server.Commands("findAndModify")[0]
+	assert.Equal(t, "$max_attempts", claim.Lookup("query", "$or").Array().Index(1).Value().Document().Lookup("$expr", "$lt").Array().Index(1).Value().StringValue())
+
+	require.NoError(t, q.Complete(ctx, job))
+	complete := server.Commands("update")[1].Lookup("updates").Array().Index(0).Value().Document()
+	assert.Equal(t, lease, complete.Lookup("q", "lease").StringValue())
+
+	// 锁定超时后任务被重新领取，旧的执行结果不能覆盖新的领取
+	stale := *job
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	assert.ErrorIs(t, q.Complete(ctx, &stale), ErrJobLeaseLost)
+	assert.ErrorIs(t, q.Fail(ctx, &stale, errors.New("boom")), ErrJobLeaseLost)
+}
+
+func TestJobQueueSweepInterval(t *testing.T) {
+	server := newFakeServer(t, false)
+	var failSweep atomic.Bool
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "update" && failSweep.Load() {
+			return fakeWriteError(2, "sweep failed")
+		}
+		return nil
+	})
+	q := NewJobQueue(server.client(t), &JobQueueOptions{SweepInterval: time.Hour})
+	q.Handle("email", func(ctx context.Context, job *Job) error { return nil })
+	ctx := t.Context()
+
+	// 一个间隔内只清理一次
+	for i := 0; i < 3; i++ {
+		job, err := q.Claim(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, job)
+	}
+	assert.Len(t, server.Commands("update"), 1)
+	assert.Len(t, server.Commands("findAndModify"), 3)
+
+	// 间隔到期后再次清理，清理失败时下一次领取重试
+	q.lastSweep = time.Now().Add(-time.Hour)
+	failSweep.Store(true)
+	_, err := q.Claim(ctx)
+	assert.Error(t, err)
+	failSweep.Store(false)
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	_, err = q.Claim(ctx)
+	require.NoError(t, err)
+	assert.Len(t, server.Commands("update"), 3)
+
+	assert.Equal(t, 30*time.Second, NewJobQueue(server.client(t), nil).opts.SweepInterval, "defaults to the visibility timeout")
+}
@@ -0,0 +1,195 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrRateLimited 超过速率限制，可以通过 errors.Is 判断
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimiterOptions 速率限制配置
+type RateLimiterOptions struct {
+	// Collection 计数器集合，默认 rate_limits
+	Collection string
+	// Limit 每个窗口内允许的请求数，默认 60
+	Limit int64
+	// Window 窗口长度，默认 1 分钟
+	Window time.Duration
+}
+
+// RateLimitResult 一次速率限制检查的结果
+type RateLimitResult struct {
+	Allowed bool  `json:"allowed"`
+	Limit   int64 `json:"limit"`
+	// Remaining 当前窗口内估计还能通过的请求数
+	Remaining int64 `json:"remaining"`
+	// RetryAfter 被拒绝时建议的等待时间，可以用于 Retry-After 响应头
+	RetryAfter time.Duration `json:"retry_after"`
+}
+
+// RateLimiter 多实例共享的滑动窗口速率限制，不依赖 Redis；
+// 每个键每个固定窗口一个计数器文档，通过 $inc 原子计数，按上一窗口的计数加权估算滑动窗口内的请求数，
+// 过期的计数器由 TTL 索引清理
+//
+//	limiter := NewRateLimiter(client, &RateLimiterOptions{Limit: 100, Window: time.Minute})
+//	limiter.EnsureIndexes(ctx)
+//	result, err := limiter.Allow(ctx, "login:"+ip)
+//	if err == nil && !result.Allowed {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())+1))
+//		w.WriteHeader(http.StatusTooManyRequests)
+//	}
+type RateLimiter struct {
+	client     *Client
+	collection *mongo.Collection
+	opts       RateLimiterOptions
+}
+
+// NewRateLimiter 创建速率限制
+func NewRateLimiter(client *Client, opts *RateLimiterOptions) *RateLimiter {
+	o := RateLimiterOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Collection == "" {
+		o.Collection = "rate_limits"
+	}
+	if o.Limit <= 0 {
+		o.Limit = 60
+	}
+	if o.Window <= 0 {
+		o.Window = time.Minute
+	}
+	return &RateLimiter{client: client, collection: client.GetCollection(o.Collection), opts: o}
+}
+
+// EnsureIndexes 创建过期计数器的 TTL 索引
+func (r *RateLimiter) EnsureIndexes(ctx context.Context) error {
+	if err := r.client.checkWritable("createIndexes", r.collection.Name()); err != nil {
+		return err
+	}
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("idx_expires_at_ttl").SetExpireAfterSeconds(0),
+	}
+	if _, err := r.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create rate limit index: %w", err)
+	}
+	return nil
+}
+
+// Allow 检查并记录一次请求
+func (r *RateLimiter) Allow(ctx context.Context, key string) (*RateLimitResult, error) {
+	return r.AllowN(ctx, key, 1)
+}
+
+// AllowN 检查并记录 n 次请求，被拒绝的请求不计入配额
+func (r *RateLimiter) AllowN(ctx context.Context, key string, n int64) (*RateLimitResult, error) {
+	if err := r.client.checkWritable("update", r.collection.Name()); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	start := now.Truncate(r.opts.Window)
+	currentID := rateLimitID(key, start)
+
+	current, err := r.increment(ctx, key, currentID, start, n)
+	if err != nil {
+		return nil, err
+	}
+	var previous struct {
+		Count int64 `bson:"count"`
+	}
+	err = r.collection.FindOne(ctx, bson.M{"_id": rateLimitID(key, start.Add(-r.opts.Window))}).Decode(&previous)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to read rate limit counter: %w", err)
+	}
+
+	result := slidingWindow(previous.Count, current-n, now.Sub(start), r.opts.Window, r.opts.Limit, n)
+	if !result.Allowed {
+		// 被拒绝的请求不占用配额，否则持续超限的客户端永远无法恢复
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": currentID}, bson.M{"$inc": bson.M{"count": -n}}); err != nil {
+			return nil, fmt.Errorf("failed to update rate limit counter: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// Check 检查并记录一次请求，被拒绝时返回包装了 ErrRateLimited 的错误，用于不需要 RateLimitResult 的场景
+func (r *RateLimiter) Check(ctx context.Context, key string) error {
+	result, err := r.Allow(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return fmt.Errorf("%w for %s, retry after %v", ErrRateLimited, key, result.RetryAfter)
+	}
+	return nil
+}
+
+// Reset 清除键的计数，例如登录成功后清除失败次数
+func (r *RateLimiter) Reset(ctx context.Context, key string) error {
+	if err := r.client.checkWritable("delete", r.collection.Name()); err != nil {
+		return err
+	}
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"key": key}); err != nil {
+		return fmt.Errorf("failed to reset rate limit %s: %w", key, err)
+	}
+	return nil
+}
+
+// increment 原子递增当前窗口的计数器并返回递增后的值
+func (r *RateLimiter) increment(ctx context.Context, key, id string, start time.Time, n int64) (int64, error) {
+	update := bson.M{
+		"$inc": bson.M{"count": n},
+		// 上一窗口的计数在下一个窗口仍然需要，因此保留两个窗口
+		"$setOnInsert": bson.M{"key": key, "expires_at": start.Add(2 * r.opts.Window)},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After).SetProjection(bson.M{"count": 1})
+	var counter struct {
+		Count int64 `bson:"count"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// 并发 upsert 同一个新文档时其中一个会失败，此时文档已经存在，重试即可
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to update rate limit counter: %w", err)
+	}
+	return counter.Count, nil
+}
+
+func rateLimitID(key string, start time.Time) string {
+	return key + ":" + strconv.FormatInt(start.Unix(), 10)
+}
+
+// slidingWindow 按上一窗口剩余部分的比例加权估算请求数，current 不包含本次的 n 个请求
+func slidingWindow(previous, current int64, elapsed, window time.Duration, limit, n int64) *RateLimitResult {
+	weight := 1 - float64(elapsed)/float64(window)
+	estimated := float64(previous)*weight + float64(current)
+	result := &RateLimitResult{Limit: limit}
+	if estimated+float64(n) <= float64(limit) {
+		result.Allowed = true
+		result.Remaining = int64(math.Floor(float64(limit) - estimated - float64(n)))
+		return result
+	}
+
+	result.Remaining = max(int64(math.Floor(float64(limit)-estimated)), 0)
+	// 当前窗口的计数已经超限时只能等到下一个窗口，否则等到上一窗口的权重下降到足够低
+	room := float64(limit - current - n)
+	if room < 0 || previous == 0 {
+		result.RetryAfter = window - elapsed
+	} else {
+		wait := float64(window)*(1-room/float64(previous)) - float64(elapsed)
+		result.RetryAfter = max(time.Duration(math.Ceil(wait)), 0)
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	window := time.Minute
+
+	result := slidingWindow(0, 0, 0, window, 10, 1)
+	assert.Equal(t, &RateLimitResult{Allowed: true, Limit: 10, Remaining: 9}, result)
+
+	// 上一窗口 10 次，当前窗口过去一半，估算 5 次
+	result = slidingWindow(10, 3, 30*time.Second, window, 10, 1)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, int64(1), result.Remaining)
+
+	result = slidingWindow(10, 4, 30*time.Second, window, 10, 2)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, int64(1), result.Remaining)
+	// 需要上一窗口的权重降到 0.4，即窗口过去 60%
+	assert.Equal(t, 6*time.Second, result.RetryAfter)
+
+	result = slidingWindow(0, 10, 15*time.Second, window, 10, 1)
+	assert.False(t, result.Allowed)
+	assert.Zero(t, result.Remaining)
+	assert.Equal(t, 45*time.Second, result.RetryAfter)
+}
+
+func TestRateLimiterDefaults(t *testing.T) {
+	client := newLazyClient(t)
+	limiter := NewRateLimiter(client, nil)
+	assert.Equal(t, "rate_limits", limiter.collection.Name())
+	assert.Equal(t, int64(60), limiter.opts.Limit)
+	assert.Equal(t, time.Minute, limiter.opts.Window)
+	assert.Equal(t, "login:1.2.3.4:1704207840", rateLimitID("login:1.2.3.4", time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)))
+
+	client.readOnly = true
+	assert.ErrorIs(t, limiter.Check(t.Context(), "login:1.2.3.4"), ErrReadOnly)
+}
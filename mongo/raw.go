@@ -0,0 +1,154 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// FindOneRaw 查找单个文档并返回未解码的 BSON，适合直接转发文档的代理类服务；
+// 不经过 WithEncryption 的解密
+func (c *Collection) FindOneRaw(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (bson.Raw, error) {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	opts = withMaxTime(ctx, c, opts, options.FindOne().SetMaxTime)
+	raw, err := c.collection.FindOne(ctx, filter, opts...).Raw()
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("document not found")
+		}
+		return nil, fmt.Errorf("failed to find document: %w", err)
+	}
+	return raw, nil
+}
+
+// FindRaw 查找多个文档并返回未解码的 BSON，结果全部读入内存；大结果集使用 FindRawIter
+func (c *Collection) FindRaw(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]bson.Raw, error) {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	opts = withMaxTime(ctx, c, opts, options.Find().SetMaxTime)
+	cursor, err := c.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents: %w", err)
+	}
+	return collectRaw(ctx, cursor)
+}
+
+// AggregateRaw 聚合查询并返回未解码的 BSON，结果全部读入内存；大结果集使用 AggregateRawIter
+func (c *Collection) AggregateRaw(ctx context.Context, pipeline []bson.M, opts ...*options.AggregateOptions) ([]bson.Raw, error) {
+	ctx, done, err := c.operationContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	opts = withMaxTime(ctx, c, opts, options.Aggregate().SetMaxTime)
+	cursor, err := c.collection.Aggregate(ctx, pipeline, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate: %w", err)
+	}
+	return collectRaw(ctx, cursor)
+}
+
+// FindRawIter 以迭代器形式逐个返回未解码的文档，内存占用与结果集大小无关；
+// 产出的 bson.Raw 只在当次迭代内有效，需要保留时先复制，出错时产出 nil 和错误后结束
+//
+//	for raw, err := range users.FindRawIter(ctx, bson.M{"status": "active"}) {
+//		if err != nil {
+//			return err
+//		}
+//		w.Write([]byte(raw.String()))
+//	}
+func (c *Collection) FindRawIter(ctx context.Context, filter bson.M, opts ...*options.FindOptions) iter.Seq2[bson.Raw, error] {
+	return func(yield func(bson.Raw, error) bool) {
+		ctx := c.sessionContext(ctx)
+		cursor, err := c.collection.Find(ctx, filter, opts...)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to find documents: %w", err))
+			return
+		}
+		iterateRaw(ctx, cursor, yield)
+	}
+}
+
+// AggregateRawIter 以迭代器形式逐个返回聚合结果，规则与 FindRawIter 相同
+func (c *Collection) AggregateRawIter(ctx context.Context, pipeline []bson.M, opts ...*options.AggregateOptions) iter.Seq2[bson.Raw, error] {
+	return func(yield func(bson.Raw, error) bool) {
+		ctx := c.sessionContext(ctx)
+		cursor, err := c.collection.Aggregate(ctx, pipeline, opts...)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to aggregate: %w", err))
+			return
+		}
+		iterateRaw(ctx, cursor, yield)
+	}
+}
+
+// collectRaw 读取游标中的所有文档，cursor.Current 会被下一批覆盖，因此逐个复制
+func collectRaw(ctx context.Context, cursor *mongo.Cursor) ([]bson.Raw, error) {
+	defer cursor.Close(ctx)
+	docs := make([]bson.Raw, 0, cursor.RemainingBatchLength())
+	for cursor.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read documents: %w", err)
+	}
+	return docs, nil
+}
+
+// iterateRaw 逐个产出游标中的文档，调用方提前结束迭代时关闭游标
+func iterateRaw(ctx context.Context, cursor *mongo.Cursor, yield func(bson.Raw, error) bool) {
+	defer cursor.Close(context.WithoutCancel(ctx))
+	for cursor.Next(ctx) {
+		if !yield(cursor.Current, nil) {
+			return
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		yield(nil, fmt.Errorf("failed to read documents: %w", err))
+	}
+}
+
+// WriteRawJSON 将文档以 JSON 数组的形式流式写入 w，返回写入的文档数；
+// canonical 为 true 时使用规范扩展 JSON 保留类型信息，否则使用宽松格式（ObjectID 写为 {"$oid": ...}）
+//
+//	n, err := WriteRawJSON(w, articles.FindRawIter(ctx, filter), false)
+func WriteRawJSON(w io.Writer, docs iter.Seq2[bson.Raw, error], canonical bool) (int64, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+	var count int64
+	for raw, err := range docs {
+		if err != nil {
+			return count, err
+		}
+		data, err := bson.MarshalExtJSON(raw, canonical, false)
+		if err != nil {
+			return count, fmt.Errorf("failed to encode document as JSON: %w", err)
+		}
+		if count > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return count, err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return count, err
+	}
+	return count, nil
+}
@@ -0,0 +1,58 @@
+package mongo
+
+import (
+	"errors"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func rawSeq(docs []bson.Raw, err error) iter.Seq2[bson.Raw, error] {
+	return func(yield func(bson.Raw, error) bool) {
+		for _, doc := range docs {
+			if !yield(doc, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func TestWriteRawJSON(t *testing.T) {
+	id, err := primitive.ObjectIDFromHex("64b7f0c2a1b2c3d4e5f60718")
+	require.NoError(t, err)
+	first, err := bson.Marshal(bson.D{{Key: "_id", Value: id}, {Key: "count", Value: int32(3)}})
+	require.NoError(t, err)
+	second, err := bson.Marshal(bson.D{{Key: "name", Value: "b"}})
+	require.NoError(t, err)
+
+	var sb strings.Builder
+	n, err := WriteRawJSON(&sb, rawSeq([]bson.Raw{first, second}, nil), false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.JSONEq(t, `[{"_id":{"$oid":"64b7f0c2a1b2c3d4e5f60718"},"count":3},{"name":"b"}]`, sb.String())
+
+	sb.Reset()
+	_, err = WriteRawJSON(&sb, rawSeq([]bson.Raw{first}, nil), true)
+	require.NoError(t, err)
+	assert.Contains(t, sb.String(), `{"$numberInt":"3"}`)
+
+	sb.Reset()
+	boom := errors.New("cursor failed")
+	n, err = WriteRawJSON(&sb, rawSeq([]bson.Raw{first}, boom), false)
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, int64(1), n)
+
+	sb.Reset()
+	n, err = WriteRawJSON(&sb, rawSeq(nil, nil), false)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, "[]", sb.String())
+}
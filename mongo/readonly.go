@@ -0,0 +1,48 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly 只读客户端拒绝写操作，可以通过 errors.Is 判断
+var ErrReadOnly = errors.New("client is read-only")
+
+// ReadOnlyError 只读客户端拒绝的写操作
+type ReadOnlyError struct {
+	// Operation 被拒绝的操作，例如 insert、update、delete、dropIndex
+	Operation string
+	// Namespace 操作的目标，格式为 数据库.集合
+	Namespace string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s on %s rejected: %v", e.Operation, e.Namespace, ErrReadOnly)
+}
+
+// Is 使 errors.Is(err, ErrReadOnly) 成立
+func (e *ReadOnlyError) Is(target error) bool {
+	return target == ErrReadOnly
+}
+
+// ReadOnly 返回客户端是否处于只读模式，参见 Config.ReadOnly
+func (c *Client) ReadOnly() bool {
+	return c.readOnly
+}
+
+// checkWritable 只读模式下返回 *ReadOnlyError
+func (c *Client) checkWritable(operation, collection string) error {
+	if c == nil || !c.readOnly {
+		return nil
+	}
+	return &ReadOnlyError{Operation: operation, Namespace: c.dbName + "." + collection}
+}
+
+// writeContext 写操作的 operationContext，只读模式下直接返回 *ReadOnlyError
+func (c *Collection) writeContext(ctx context.Context, operation string) (context.Context, func(), error) {
+	if err := c.cli.checkWritable(operation, c.collection.Name()); err != nil {
+		return ctx, nil, err
+	}
+	return c.operationContext(ctx)
+}
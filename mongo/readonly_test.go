@@ -0,0 +1,83 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReadOnlyClient(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	client.readOnly = true
+	assert.True(t, client.ReadOnly())
+	users := NewCollection(client, "users")
+
+	_, err := users.InsertOne(ctx, bson.M{"name": "alice"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	var roErr *ReadOnlyError
+	if assert.True(t, errors.As(err, &roErr)) {
+		assert.Equal(t, ReadOnlyError{Operation: "insert", Namespace: "test.users"}, *roErr)
+	}
+
+	_, err = users.InsertMany(ctx, []interface{}{bson.M{"name": "bob"}})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = users.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"active": false}})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = users.DeleteMany(ctx, bson.M{})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = users.UpsertMany(ctx, []interface{}{bson.M{"email": "a@example.com"}}, "email")
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	assert.ErrorIs(t, NewIndexManager(client, "users").DropAllIndexes(ctx), ErrReadOnly)
+	assert.ErrorIs(t, NewSchemaManager(client).RemoveSchema(ctx, "users"), ErrReadOnly)
+	assert.ErrorIs(t, NewCollectionAdmin(client).DropView(ctx, "active_users"), ErrReadOnly)
+}
+
+func TestReadOnlyHelpers(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	client.readOnly = true
+
+	queue := NewJobQueue(client, nil)
+	queue.Handle("email", func(ctx context.Context, job *Job) error { return nil })
+	_, err := queue.Enqueue(ctx, "email", nil, nil)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = queue.Claim(ctx)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	job := &Job{ID: primitive.NewObjectID()}
+	assert.ErrorIs(t, queue.Complete(ctx, job), ErrReadOnly)
+	assert.ErrorIs(t, queue.Fail(ctx, job, errors.New("boom")), ErrReadOnly)
+	assert.ErrorIs(t, queue.Requeue(ctx, job.ID), ErrReadOnly)
+	assert.ErrorIs(t, queue.EnsureIndexes(ctx), ErrReadOnly)
+
+	// Rotate 在扫描集合前拒绝
+	_, err = NewFieldEncryptor(newTestKeyProvider()).Rotate(ctx, NewCollection(client, "users"), encryptedUser{}, nil)
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	lock := NewDistributedLock(client, "locks")
+	_, err = lock.Acquire(ctx, "job", time.Minute)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = lock.Refresh(ctx, "job", time.Minute)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, lock.Release(ctx, "job"), ErrReadOnly)
+
+	assert.ErrorIs(t, NewMongoCheckpointStore(client, "checkpoints").Save(ctx, "orders", bson.Raw{}), ErrReadOnly)
+
+	counter := NewBatchCounter(NewCollection(client, "posts"), time.Minute)
+	counter.Incr(primitive.NewObjectID(), "views")
+	assert.ErrorIs(t, counter.Flush(ctx), ErrReadOnly)
+	assert.Equal(t, 1, counter.Pending(), "rejected increments stay queued")
+
+	vault := &KeyVault{client: client, namespace: "encryption.__keyVault"}
+	err = vault.EnsureIndexes(ctx)
+	var roErr *ReadOnlyError
+	if assert.True(t, errors.As(err, &roErr)) {
+		assert.Equal(t, "encryption.__keyVault", roErr.Namespace)
+	}
+}
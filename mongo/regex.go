@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrRegexNotIndexable 正则表达式无法有效利用索引，执行时会扫描整个索引或集合
+var ErrRegexNotIndexable = errors.New("regex pattern cannot use index efficiently")
+
+// RegexSearch 基于用户输入的正则搜索条件，输入中的正则元字符都会被转义
+//
+//	filter, err := PrefixSearch("username", input).StrictFilter() // 只允许能使用索引的前缀匹配
+//	filter := ContainsSearch("title", input).CaseInsensitive().Filter()
+type RegexSearch struct {
+	field           string
+	input           string
+	anchored        bool
+	caseInsensitive bool
+}
+
+// PrefixSearch 前缀匹配，区分大小写时可以使用字段上的普通索引
+func PrefixSearch(field, input string) *RegexSearch {
+	return &RegexSearch{field: field, input: input, anchored: true}
+}
+
+// ContainsSearch 包含匹配，无法有效使用索引，只适合数据量较小或已通过其它条件缩小范围的查询
+func ContainsSearch(field, input string) *RegexSearch {
+	return &RegexSearch{field: field, input: input}
+}
+
+// CaseInsensitive 忽略大小写，忽略大小写的正则无法有效使用索引
+// 需要忽略大小写的前缀搜索时，建议保存一个小写字段并对小写输入做 PrefixSearch
+func (r *RegexSearch) CaseInsensitive() *RegexSearch {
+	r.caseInsensitive = true
+	return r
+}
+
+// Pattern 返回转义后的正则表达式
+func (r *RegexSearch) Pattern() string {
+	pattern := regexp.QuoteMeta(r.input)
+	if r.anchored {
+		return "^" + pattern
+	}
+	return pattern
+}
+
+// IndexSafe 返回查询能否有效使用索引：必须是区分大小写、输入不为空的前缀匹配
+func (r *RegexSearch) IndexSafe() bool {
+	return r.anchored && !r.caseInsensitive && r.input != ""
+}
+
+// Filter 构建过滤器，不检查能否使用索引
+func (r *RegexSearch) Filter() bson.M {
+	if r.caseInsensitive {
+		return BuildRegexFilter(r.field, r.Pattern(), "i")
+	}
+	return BuildRegexFilter(r.field, r.Pattern())
+}
+
+// StrictFilter 构建过滤器，查询无法有效使用索引时返回 ErrRegexNotIndexable
+// 适合直接使用用户输入构建查询的接口，避免意外的全集合扫描
+func (r *RegexSearch) StrictFilter() (bson.M, error) {
+	if !r.IndexSafe() {
+		return nil, ErrRegexNotIndexable
+	}
+	return r.Filter(), nil
+}
+
+// CheckRegexIndexable 检查已有的正则表达式能否有效使用索引
+// 只有以 ^ 或 \A 开头、紧跟非空字面量前缀、不含 | 分支且不带 i 选项的正则才能通过索引范围扫描
+func CheckRegexIndexable(pattern string, options ...string) error {
+	if strings.Contains(strings.Join(options, ""), "i") {
+		return ErrRegexNotIndexable
+	}
+	var rest string
+	switch {
+	case strings.HasPrefix(pattern, "^"):
+		rest = pattern[1:]
+	case strings.HasPrefix(pattern, `\A`):
+		rest = pattern[2:]
+	default:
+		return ErrRegexNotIndexable
+	}
+	if rest == "" || strings.ContainsAny(rest[:1], `.*+?()[]{}|^$\`) {
+		return ErrRegexNotIndexable
+	}
+	// 服务端不为包含 | 的正则计算前缀范围，顶层的 | 还会产生不受 ^ 锚定的分支，例如 ^a|b 匹配任意位置的 b
+	if hasAlternation(rest) {
+		return ErrRegexNotIndexable
+	}
+	return nil
+}
+
+// hasAlternation 检查正则中是否有未转义且不在字符类中的 |
+func hasAlternation(pattern string) bool {
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '|':
+			if !inClass {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRegexSearch(t *testing.T) {
+	prefix := PrefixSearch("username", "a.b*")
+	assert.Equal(t, `^a\.b\*`, prefix.Pattern())
+	assert.True(t, prefix.IndexSafe())
+	filter, err := prefix.StrictFilter()
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"username": bson.M{"$regex": `^a\.b\*`}}, filter)
+
+	_, err = PrefixSearch("username", "").StrictFilter()
+	assert.ErrorIs(t, err, ErrRegexNotIndexable)
+	_, err = PrefixSearch("username", "tom").CaseInsensitive().StrictFilter()
+	assert.ErrorIs(t, err, ErrRegexNotIndexable)
+
+	contains := ContainsSearch("title", "(go)").CaseInsensitive()
+	assert.False(t, contains.IndexSafe())
+	assert.Equal(t, bson.M{"title": bson.M{"$regex": `\(go\)`, "$options": "i"}}, contains.Filter())
+}
+
+func TestCheckRegexIndexable(t *testing.T) {
+	assert.NoError(t, CheckRegexIndexable("^abc"))
+	assert.NoError(t, CheckRegexIndexable(`\Aabc.*`))
+	assert.Error(t, CheckRegexIndexable("abc"))
+	assert.Error(t, CheckRegexIndexable("^.*abc"))
+	assert.Error(t, CheckRegexIndexable("^"))
+	assert.Error(t, CheckRegexIndexable("^abc", "i"))
+	assert.Error(t, CheckRegexIndexable("^a|b"))
+	assert.Error(t, CheckRegexIndexable("^ab|.*"))
+	assert.Error(t, CheckRegexIndexable("^a(b|c)"))
+	assert.NoError(t, CheckRegexIndexable(`^a\|b`))
+	assert.NoError(t, CheckRegexIndexable("^a[|]b"))
+}
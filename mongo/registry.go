@@ -0,0 +1,353 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrModelNotRegistered 模型类型没有注册
+var ErrModelNotRegistered = errors.New("model not registered")
+
+// ModelHook 写入前对文档执行的钩子，doc 为指向模型的指针，返回错误时取消写入
+type ModelHook func(ctx context.Context, doc interface{}) error
+
+// ModelOptions 模型注册配置
+type ModelOptions struct {
+	// Collection 集合名称，默认为类型名的蛇形复数形式，例如 User 为 users、ArticleTag 为 article_tags
+	Collection string
+	// Indexes 集合索引，由 EnsureModel 创建
+	Indexes []mongo.IndexModel
+	// Schema 为 true 时由 EnsureModel 根据结构体生成 $jsonSchema 校验规则并应用到集合
+	Schema bool
+	// ValidationLevel 和 ValidationAction 为 Schema 的校验级别和处理方式，默认 strict 和 error
+	ValidationLevel  ValidationLevel
+	ValidationAction ValidationAction
+	// Validators 在 Repository 插入和更新文档前执行的校验
+	Validators []ModelHook
+	// BeforeInsert 在 Validators 之后、插入前执行的钩子
+	BeforeInsert []ModelHook
+	// BeforeUpdate 在 Validators 之后、更新前执行的钩子
+	BeforeUpdate []ModelHook
+	// IDStrategy 文档 ID 策略，默认使用 ObjectID
+	IDStrategy IDStrategy
+	// Retention 数据保留规则，由 RetentionEngine.RegisterModels 注册
+	Retention *RetentionRule
+	// Erasure 删除数据主体时该集合的处理方式，由 Eraser 使用
+	Erasure *ErasurePolicy
+	// Encryption 应用层字段加密，模型中标记 encrypt 标签的字段在写入前加密、读取后解密
+	Encryption *FieldEncryptor
+}
+
+// ModelInfo 已注册的模型
+type ModelInfo struct {
+	Type    reflect.Type
+	Options ModelOptions
+}
+
+// Collection 返回模型所在集合名称
+func (m *ModelInfo) Collection() string {
+	return m.Options.Collection
+}
+
+// ModelRegistry 模型注册表，集中维护结构体类型与集合、索引、校验规则和钩子的对应关系
+// 模型只需要注册一次，之后通过 NewRepositoryFor 获取类型安全的仓库，不再在各处硬编码集合名称：
+//
+//	models := NewModelRegistry(client)
+//	RegisterModel[User](models, ModelOptions{Indexes: userIndexes, Schema: true})
+//	if err := models.EnsureAll(ctx); err != nil { ... }
+//
+//	users, err := NewRepositoryFor[User](models)
+//	user, err := users.FindByID(ctx, id)
+//
+// 模型的 ref 标签同时注册到 Relations 返回的引用关系注册表
+type ModelRegistry struct {
+	client    *Client
+	relations *RelationRegistry
+
+	mu     sync.RWMutex
+	models map[reflect.Type]*ModelInfo
+}
+
+// NewModelRegistry 创建模型注册表
+func NewModelRegistry(client *Client) *ModelRegistry {
+	return &ModelRegistry{
+		client:    client,
+		relations: NewRelationRegistry(client),
+		models:    make(map[reflect.Type]*ModelInfo),
+	}
+}
+
+// Register 注册模型，model 为结构体或结构体指针；同一类型或同一集合只能注册一次
+func (r *ModelRegistry) Register(model interface{}, opts ModelOptions) error {
+	t := modelType(reflect.TypeOf(model))
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("model must be a struct, got %T", model)
+	}
+	if opts.Collection == "" {
+		opts.Collection = defaultCollectionName(t.Name())
+	}
+	if opts.ValidationLevel == "" {
+		opts.ValidationLevel = ValidationLevelStrict
+	}
+	if opts.ValidationAction == "" {
+		opts.ValidationAction = ValidationActionError
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.models[t]; ok {
+		return fmt.Errorf("model %s is already registered", t)
+	}
+	for _, info := range r.models {
+		if info.Options.Collection == opts.Collection {
+			return fmt.Errorf("collection %s is already registered for model %s", opts.Collection, info.Type)
+		}
+	}
+	if err := r.relations.RegisterModel(opts.Collection, reflect.New(t).Interface()); err != nil {
+		return err
+	}
+	r.models[t] = &ModelInfo{Type: t, Options: opts}
+	return nil
+}
+
+// RegisterModel 以类型参数注册模型
+func RegisterModel[T any](r *ModelRegistry, opts ModelOptions) error {
+	var model T
+	return r.Register(model, opts)
+}
+
+// Lookup 返回模型的注册信息，model 可以是结构体、结构体指针或切片
+func (r *ModelRegistry) Lookup(model interface{}) (*ModelInfo, error) {
+	t := modelType(reflect.TypeOf(model))
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.models[t]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrModelNotRegistered, t)
+	}
+	return info, nil
+}
+
+// Collection 返回模型所在集合
+func (r *ModelRegistry) Collection(model interface{}) (*Collection, error) {
+	info, err := r.Lookup(model)
+	if err != nil {
+		return nil, err
+	}
+	return NewCollection(r.client, info.Options.Collection).
+		WithIDStrategy(info.Options.IDStrategy).
+		WithEncryption(info.Options.Encryption), nil
+}
+
+// Models 返回所有已注册的模型，按集合名称排序
+func (r *ModelRegistry) Models() []*ModelInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	models := make([]*ModelInfo, 0, len(r.models))
+	for _, info := range r.models {
+		models = append(models, info)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Options.Collection < models[j].Options.Collection
+	})
+	return models
+}
+
+// Relations 返回根据模型 ref 标签注册的引用关系
+func (r *ModelRegistry) Relations() *RelationRegistry {
+	return r.relations
+}
+
+// EnsureModel 创建模型的索引，并在开启 Schema 时应用校验规则
+func (r *ModelRegistry) EnsureModel(ctx context.Context, info *ModelInfo) error {
+	if info.Options.Schema {
+		err := NewSchemaManager(r.client).ApplySchemaFromStruct(ctx, info.Options.Collection, reflect.New(info.Type).Interface(),
+			info.Options.ValidationLevel, info.Options.ValidationAction)
+		if err != nil {
+			return err
+		}
+	}
+	if len(info.Options.Indexes) > 0 {
+		if _, err := NewIndexManager(r.client, info.Options.Collection).CreateIndexes(ctx, info.Options.Indexes); err != nil {
+			return fmt.Errorf("failed to ensure indexes of %s: %w", info.Options.Collection, err)
+		}
+	}
+	return nil
+}
+
+// EnsureAll 为所有已注册的模型创建索引和校验规则，适合在服务启动时调用
+func (r *ModelRegistry) EnsureAll(ctx context.Context) error {
+	for _, info := range r.Models() {
+		if err := r.EnsureModel(ctx, info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Repository 已注册模型的类型安全仓库
+type Repository[T any] struct {
+	model      *ModelInfo
+	collection *Collection
+}
+
+// NewRepositoryFor 根据注册表中 T 的注册信息创建仓库，T 没有注册时返回 ErrModelNotRegistered
+func NewRepositoryFor[T any](r *ModelRegistry) (*Repository[T], error) {
+	var model T
+	info, err := r.Lookup(model)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := r.Collection(model)
+	if err != nil {
+		return nil, err
+	}
+	return &Repository[T]{model: info, collection: collection}, nil
+}
+
+// Model 返回模型的注册信息
+func (r *Repository[T]) Model() *ModelInfo {
+	return r.model
+}
+
+// Collection 返回底层集合，用于执行仓库没有封装的操作
+func (r *Repository[T]) Collection() *Collection {
+	return r.collection
+}
+
+// WithSession 返回绑定到会话的仓库副本
+func (r *Repository[T]) WithSession(session mongo.Session) *Repository[T] {
+	return &Repository[T]{model: r.model, collection: r.collection.WithSession(session)}
+}
+
+// Insert 执行校验和插入钩子后插入文档
+func (r *Repository[T]) Insert(ctx context.Context, doc *T) (*mongo.InsertOneResult, error) {
+	if err := r.runHooks(ctx, doc, r.model.Options.BeforeInsert); err != nil {
+		return nil, err
+	}
+	return r.collection.InsertOne(ctx, doc)
+}
+
+// FindByID 根据 ID 查找文档
+func (r *Repository[T]) FindByID(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*T, error) {
+	var doc T
+	if err := r.collection.FindByID(ctx, id, &doc, opts...); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// FindOne 查找单个文档
+func (r *Repository[T]) FindOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (*T, error) {
+	var doc T
+	if err := r.collection.FindOne(ctx, filter, &doc, opts...); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Find 查找多个文档
+func (r *Repository[T]) Find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	docs := []T{}
+	if err := r.collection.Find(ctx, filter, &docs, opts...); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// FindWithPagination 分页查找文档
+func (r *Repository[T]) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, opts ...*options.FindOptions) ([]T, *PaginationResult, error) {
+	docs := []T{}
+	result, err := r.collection.FindWithPagination(ctx, filter, page, pageSize, &docs, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return docs, result, nil
+}
+
+// UpdateByID 根据 ID 执行更新操作，update 为原始更新文档，不会执行校验和钩子
+func (r *Repository[T]) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	return r.collection.UpdateByID(ctx, id, update, opts...)
+}
+
+// UpdateChanged 对 modified 执行校验和更新钩子后，只写入与 original 相比变化的字段
+func (r *Repository[T]) UpdateChanged(ctx context.Context, original, modified *T) (*mongo.UpdateResult, error) {
+	if err := r.runHooks(ctx, modified, r.model.Options.BeforeUpdate); err != nil {
+		return nil, err
+	}
+	return r.collection.UpdateChanged(ctx, original, modified)
+}
+
+// DeleteByID 根据 ID 删除文档
+func (r *Repository[T]) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	return r.collection.DeleteByID(ctx, id)
+}
+
+// Count 统计文档数量
+func (r *Repository[T]) Count(ctx context.Context, filter bson.M) (int64, error) {
+	return r.collection.Count(ctx, filter)
+}
+
+// EstimatedDocumentCount 根据集合元数据返回文档总数，参见 Collection.EstimatedDocumentCount
+func (r *Repository[T]) EstimatedDocumentCount(ctx context.Context) (int64, error) {
+	return r.collection.EstimatedDocumentCount(ctx)
+}
+
+// runHooks 依次执行校验和钩子
+func (r *Repository[T]) runHooks(ctx context.Context, doc *T, hooks []ModelHook) error {
+	for _, validate := range r.model.Options.Validators {
+		if err := validate(ctx, doc); err != nil {
+			return fmt.Errorf("validation failed for %s: %w", r.model.Type, err)
+		}
+	}
+	for _, hook := range hooks {
+		if err := hook(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// modelType 去掉指针和切片，返回模型的结构体类型
+func modelType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// defaultCollectionName 将类型名转换为蛇形复数，例如 ArticleTag 转换为 article_tags
+func defaultCollectionName(typeName string) string {
+	var b strings.Builder
+	runes := []rune(typeName)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// 连续大写（例如 HTTPLog）只在单词边界处分隔
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
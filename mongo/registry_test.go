@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestDefaultCollectionName(t *testing.T) {
+	cases := map[string]string{
+		"User":       "users",
+		"Category":   "categories",
+		"Day":        "days",
+		"ArticleTag": "article_tags",
+		"HTTPLog":    "http_logs",
+		"Box":        "boxes",
+		"Status":     "statuses",
+	}
+	for typeName, want := range cases {
+		assert.Equal(t, want, defaultCollectionName(typeName), typeName)
+	}
+}
+
+func TestModelRegistry(t *testing.T) {
+	registry := NewModelRegistry(&Client{})
+	require.NoError(t, RegisterModel[Article](registry, ModelOptions{}))
+	require.NoError(t, registry.Register(&User{}, ModelOptions{Collection: "members"}))
+
+	assert.Error(t, RegisterModel[Article](registry, ModelOptions{Collection: "posts"}))
+	assert.Error(t, RegisterModel[Category](registry, ModelOptions{Collection: "members"}))
+	assert.Error(t, registry.Register(42, ModelOptions{}))
+
+	info, err := registry.Lookup([]*Article{})
+	require.NoError(t, err)
+	assert.Equal(t, "articles", info.Collection())
+	assert.Equal(t, ValidationLevelStrict, info.Options.ValidationLevel)
+
+	_, err = registry.Lookup(Category{})
+	assert.True(t, errors.Is(err, ErrModelNotRegistered))
+
+	models := registry.Models()
+	require.Len(t, models, 2)
+	assert.Equal(t, "articles", models[0].Collection())
+	assert.Equal(t, "members", models[1].Collection())
+
+	// Article 的 ref 标签注册为引用关系
+	assert.Len(t, registry.Relations().Relations(), 2)
+}
+
+func TestRepositoryHooks(t *testing.T) {
+	// 驱动客户端延迟建立连接，校验失败的写入不会访问数据库
+	driver, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	require.NoError(t, err)
+	defer driver.Disconnect(context.Background())
+	registry := NewModelRegistry(&Client{client: driver, database: driver.Database("test"), dbName: "test"})
+	var calls []string
+	require.NoError(t, RegisterModel[User](registry, ModelOptions{
+		Validators: []ModelHook{func(ctx context.Context, doc interface{}) error {
+			calls = append(calls, "validate")
+			if doc.(*User).Email == "" {
+				return errors.New("email is required")
+			}
+			return nil
+		}},
+		BeforeInsert: []ModelHook{func(ctx context.Context, doc interface{}) error {
+			calls = append(calls, "insert")
+			return nil
+		}},
+	}))
+
+	users, err := NewRepositoryFor[User](registry)
+	require.NoError(t, err)
+	assert.Equal(t, "users", users.Model().Collection())
+
+	_, err = users.Insert(context.Background(), &User{})
+	assert.ErrorContains(t, err, "email is required")
+	assert.Equal(t, []string{"validate"}, calls)
+
+	calls = nil
+	require.NoError(t, users.runHooks(context.Background(), &User{Email: "a@b.c"}, users.model.Options.BeforeInsert))
+	assert.Equal(t, []string{"validate", "insert"}, calls)
+
+	_, err = NewRepositoryFor[Category](registry)
+	assert.ErrorIs(t, err, ErrModelNotRegistered)
+}
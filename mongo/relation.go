@@ -0,0 +1,319 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDeleteRestricted 被删除的文档仍被 OnDeleteRestrict 关系引用
+var ErrDeleteRestricted = errors.New("delete restricted by existing references")
+
+// OnDelete 删除被引用文档时对引用方的处理方式
+type OnDelete int
+
+const (
+	// OnDeleteNoAction 不处理引用方，只由 FindOrphans 检查
+	OnDeleteNoAction OnDelete = iota
+	// OnDeleteRestrict 存在引用方时拒绝删除
+	OnDeleteRestrict
+	// OnDeleteCascade 级联删除引用方
+	OnDeleteCascade
+	// OnDeleteSetNull 将引用字段置为 null，数组引用从数组中移除
+	OnDeleteSetNull
+)
+
+// parseOnDelete 解析 ref 标签中的 ondelete 选项
+func parseOnDelete(value string) (OnDelete, error) {
+	switch strings.ToLower(value) {
+	case "", "noaction":
+		return OnDeleteNoAction, nil
+	case "restrict":
+		return OnDeleteRestrict, nil
+	case "cascade":
+		return OnDeleteCascade, nil
+	case "setnull":
+		return OnDeleteSetNull, nil
+	}
+	return OnDeleteNoAction, fmt.Errorf("unknown ondelete action %q", value)
+}
+
+// Relation 集合间的引用关系，Collection.Field 保存 References 集合文档的 _id
+type Relation struct {
+	Collection string
+	Field      string
+	References string
+	// Many 引用字段是否为 _id 数组
+	Many     bool
+	OnDelete OnDelete
+}
+
+// RestrictedError 删除被 OnDeleteRestrict 关系阻止时返回的错误
+type RestrictedError struct {
+	Relation Relation
+	Count    int64
+}
+
+func (e *RestrictedError) Error() string {
+	return fmt.Sprintf("cannot delete from %s: %d documents in %s reference it via %s",
+		e.Relation.References, e.Count, e.Relation.Collection, e.Relation.Field)
+}
+
+// Unwrap 使 errors.Is(err, ErrDeleteRestricted) 成立
+func (e *RestrictedError) Unwrap() error {
+	return ErrDeleteRestricted
+}
+
+// CascadeResult 级联删除结果
+type CascadeResult struct {
+	// Deleted 每个集合删除的文档数量
+	Deleted map[string]int64 `json:"deleted"`
+	// Nullified 每个集合被置空引用的文档数量
+	Nullified map[string]int64 `json:"nullified"`
+}
+
+// OrphanReference 引用了不存在文档的孤儿引用
+type OrphanReference struct {
+	Relation   Relation    `json:"relation"`
+	DocumentID interface{} `json:"document_id"`
+	MissingID  interface{} `json:"missing_id"`
+}
+
+// RelationRegistry 引用关系注册表
+// 注册关系后通过 DeleteWithCascade 删除文档会按关系级联处理引用方，通过 FindOrphans 检查引用完整性：
+//
+//	relations := NewRelationRegistry(client)
+//	relations.Register(Relation{Collection: "articles", Field: "author_id", References: "users", OnDelete: OnDeleteCascade})
+//	result, err := relations.DeleteWithCascade(ctx, "users", bson.M{"_id": userID})
+type RelationRegistry struct {
+	client *Client
+	tm     *TransactionManager
+
+	mu        sync.RWMutex
+	relations []Relation
+}
+
+// NewRelationRegistry 创建引用关系注册表
+func NewRelationRegistry(client *Client) *RelationRegistry {
+	return &RelationRegistry{
+		client: client,
+		tm:     NewTransactionManager(client),
+	}
+}
+
+// Register 注册引用关系
+func (r *RelationRegistry) Register(relations ...Relation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relations = append(r.relations, relations...)
+}
+
+// RegisterModel 根据模型结构体的 ref 标签注册引用关系，collectionName 为模型所在集合
+// ondelete 可选 noaction（默认）、restrict、cascade、setnull，例如：
+//
+//	AuthorID primitive.ObjectID `bson:"author_id" ref:"users,ondelete=cascade"`
+func (r *RelationRegistry) RegisterModel(collectionName string, model interface{}) error {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fmt.Errorf("relation model must be a struct, got %T", model)
+	}
+
+	var relations []Relation
+	var err error
+	walkStructFields(t, func(field reflect.StructField, name string) {
+		tag := field.Tag.Get("ref")
+		if tag == "" || err != nil {
+			return
+		}
+		spec, onDelete, parseErr := parseRefTag(name, tag, field.Type)
+		if parseErr != nil {
+			err = fmt.Errorf("invalid ref tag on field %s: %w", field.Name, parseErr)
+			return
+		}
+		relations = append(relations, Relation{
+			Collection: collectionName,
+			Field:      spec.Field,
+			References: spec.Collection,
+			Many:       spec.Many,
+			OnDelete:   onDelete,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	r.Register(relations...)
+	return nil
+}
+
+// Relations 返回所有已注册的引用关系
+func (r *RelationRegistry) Relations() []Relation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Relation(nil), r.relations...)
+}
+
+// referencing 返回引用指定集合的关系
+func (r *RelationRegistry) referencing(collectionName string) []Relation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var relations []Relation
+	for _, rel := range r.relations {
+		if rel.References == collectionName {
+			relations = append(relations, rel)
+		}
+	}
+	return relations
+}
+
+// DeleteWithCascade 删除匹配的文档，并按注册的关系处理引用方
+// 所有删除和置空在同一个事务中执行，任意 OnDeleteRestrict 关系存在引用方时整体回滚并返回 *RestrictedError；
+// ctx 中已有会话时直接加入该会话，否则新开启事务
+func (r *RelationRegistry) DeleteWithCascade(ctx context.Context, collectionName string, filter bson.M) (*CascadeResult, error) {
+	var result *CascadeResult
+	run := func(ctx context.Context) error {
+		result = &CascadeResult{Deleted: make(map[string]int64), Nullified: make(map[string]int64)}
+		visited := make(map[string]map[interface{}]bool)
+		return r.cascade(ctx, collectionName, filter, visited, result)
+	}
+
+	if mongo.SessionFromContext(ctx) != nil {
+		if err := run(ctx); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	err := r.tm.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		return run(sessCtx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cascade 删除匹配的文档并递归处理引用方，visited 记录已处理的文档，避免循环引用导致无限递归
+func (r *RelationRegistry) cascade(ctx context.Context, collectionName string, filter bson.M, visited map[string]map[interface{}]bool, result *CascadeResult) error {
+	c := NewCollection(r.client, collectionName)
+
+	var docs []bson.M
+	if err := c.Find(ctx, filter, &docs, options.Find().SetProjection(bson.M{"_id": 1})); err != nil {
+		return err
+	}
+	if visited[collectionName] == nil {
+		visited[collectionName] = make(map[interface{}]bool)
+	}
+	var ids []interface{}
+	for _, doc := range docs {
+		key := auditKey(doc["_id"])
+		if visited[collectionName][key] {
+			continue
+		}
+		visited[collectionName][key] = true
+		ids = append(ids, doc["_id"])
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for _, rel := range r.referencing(collectionName) {
+		refFilter := bson.M{rel.Field: bson.M{"$in": ids}}
+		switch rel.OnDelete {
+		case OnDeleteRestrict:
+			count, err := NewCollection(r.client, rel.Collection).Count(ctx, refFilter)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return &RestrictedError{Relation: rel, Count: count}
+			}
+		case OnDeleteCascade:
+			if err := r.cascade(ctx, rel.Collection, refFilter, visited, result); err != nil {
+				return err
+			}
+		case OnDeleteSetNull:
+			update := bson.M{"$set": bson.M{rel.Field: nil}}
+			if rel.Many {
+				update = bson.M{"$pull": bson.M{rel.Field: bson.M{"$in": ids}}}
+			}
+			updated, err := NewCollection(r.client, rel.Collection).UpdateMany(ctx, refFilter, update)
+			if err != nil {
+				return err
+			}
+			result.Nullified[rel.Collection] += updated.ModifiedCount
+		}
+	}
+
+	deleted, err := c.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+	result.Deleted[collectionName] += deleted.DeletedCount
+	return nil
+}
+
+// FindOrphans 检查所有已注册关系，返回引用了不存在文档的孤儿引用
+// limit 为每个关系最多返回的数量，小于等于 0 时不限制
+func (r *RelationRegistry) FindOrphans(ctx context.Context, limit int64) ([]OrphanReference, error) {
+	var orphans []OrphanReference
+	for _, rel := range r.Relations() {
+		found, err := r.findOrphans(ctx, rel, limit)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, found...)
+	}
+	return orphans, nil
+}
+
+// findOrphans 检查单个关系的孤儿引用
+func (r *RelationRegistry) findOrphans(ctx context.Context, rel Relation, limit int64) ([]OrphanReference, error) {
+	pipeline := orphanPipeline(rel, limit)
+	var rows []struct {
+		ID    interface{} `bson:"_id"`
+		Value interface{} `bson:"value"`
+	}
+	if err := NewCollection(r.client, rel.Collection).Aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, fmt.Errorf("failed to check references %s.%s: %w", rel.Collection, rel.Field, err)
+	}
+
+	orphans := make([]OrphanReference, 0, len(rows))
+	for _, row := range rows {
+		orphans = append(orphans, OrphanReference{Relation: rel, DocumentID: row.ID, MissingID: row.Value})
+	}
+	return orphans, nil
+}
+
+// orphanPipeline 构建查找孤儿引用的聚合管道
+func orphanPipeline(rel Relation, limit int64) []bson.M {
+	pipeline := []bson.M{
+		{"$match": bson.M{rel.Field: bson.M{"$exists": true, "$ne": nil}}},
+		{"$project": bson.M{"value": "$" + rel.Field}},
+	}
+	if rel.Many {
+		pipeline = append(pipeline, bson.M{"$unwind": "$value"})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$lookup": bson.M{
+			"from":         rel.References,
+			"localField":   "value",
+			"foreignField": "_id",
+			"as":           "ref",
+		}},
+		bson.M{"$match": bson.M{"ref": bson.M{"$size": 0}}},
+		bson.M{"$project": bson.M{"value": 1}},
+	)
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	return pipeline
+}
@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRegisterModel(t *testing.T) {
+	type post struct {
+		BaseDocument `bson:",inline"`
+		AuthorID     primitive.ObjectID   `bson:"author_id" ref:"users,ondelete=cascade"`
+		TagIDs       []primitive.ObjectID `bson:"tag_ids" ref:"tags,ondelete=setnull"`
+		CategoryID   primitive.ObjectID   `bson:"category_id" ref:"categories,ondelete=restrict"`
+		ReviewerID   primitive.ObjectID   `bson:"reviewer_id" ref:"users"`
+	}
+
+	registry := NewRelationRegistry(nil)
+	require.NoError(t, registry.RegisterModel("posts", &post{}))
+	assert.Equal(t, []Relation{
+		{Collection: "posts", Field: "author_id", References: "users", OnDelete: OnDeleteCascade},
+		{Collection: "posts", Field: "tag_ids", References: "tags", Many: true, OnDelete: OnDeleteSetNull},
+		{Collection: "posts", Field: "category_id", References: "categories", OnDelete: OnDeleteRestrict},
+		{Collection: "posts", Field: "reviewer_id", References: "users", OnDelete: OnDeleteNoAction},
+	}, registry.Relations())
+	assert.Len(t, registry.referencing("users"), 2)
+}
+
+func TestRegisterModelInvalidOnDelete(t *testing.T) {
+	type post struct {
+		AuthorID primitive.ObjectID `bson:"author_id" ref:"users,ondelete=explode"`
+	}
+	assert.Error(t, NewRelationRegistry(nil).RegisterModel("posts", post{}))
+}
+
+func TestRestrictedError(t *testing.T) {
+	var err error = &RestrictedError{Relation: Relation{Collection: "articles", Field: "author_id", References: "users"}, Count: 2}
+	assert.True(t, errors.Is(err, ErrDeleteRestricted))
+	assert.Contains(t, err.Error(), "articles")
+}
+
+func TestOrphanPipeline(t *testing.T) {
+	single := orphanPipeline(Relation{Collection: "articles", Field: "author_id", References: "users"}, 10)
+	assert.Len(t, single, 6)
+	many := orphanPipeline(Relation{Collection: "articles", Field: "tag_ids", References: "tags", Many: true}, 0)
+	assert.Len(t, many, 6)
+	assert.Equal(t, "$value", many[2]["$unwind"])
+}
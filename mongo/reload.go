@@ -0,0 +1,208 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConfigSource 返回最新的客户端配置，例如读取配置文件或配置中心
+type ConfigSource func(ctx context.Context) (*Config, error)
+
+// FileConfigSource 从 JSON 或 YAML 文件读取配置，格式与 LoadConfig 的 File 相同
+func FileConfigSource(path string) ConfigSource {
+	return func(ctx context.Context) (*Config, error) {
+		return LoadConfig(&LoadConfigOptions{File: path})
+	}
+}
+
+// ReloadOptions 配置热更新选项
+type ReloadOptions struct {
+	// Interval 检查配置变化的间隔，默认 30 秒
+	Interval time.Duration
+	// DrainTimeout 切换后等待旧客户端上进行中操作完成的最长时间，超时后强制关闭旧连接，默认 30 秒
+	DrainTimeout time.Duration
+	// Customize 对每次读取的配置做补充，用于设置 Logger、IDStrategy、Monitor 等无法写入配置文件的字段
+	Customize func(config *Config)
+	// OnReload 切换到新客户端后的回调，可以在其中重建依赖客户端的集合或仓库
+	OnReload func(previous, current *Client)
+}
+
+// ReloadMetrics 配置热更新统计
+type ReloadMetrics struct {
+	// Reloads 成功切换客户端的次数
+	Reloads int64 `json:"reloads"`
+	// Failures 读取配置或建立新连接失败的次数，失败时继续使用旧客户端
+	Failures int64 `json:"failures"`
+}
+
+// ReloadableClient 随配置变化重建底层连接的客户端，用于不停机更换 URI、连接池大小或轮换凭据
+// 配置变化时先用新配置建立并 Ping 新客户端，成功后原子切换，旧客户端在 DrainTimeout 内等待进行中的操作完成后关闭；
+// 新配置无法连接时保留旧客户端并记录日志。集合和仓库绑定创建时的客户端，应当在每次使用时通过 Client 获取，
+// 或者在 OnReload 中重建
+//
+//	rc, err := NewReloadableClient(ctx, FileConfigSource("/etc/app/mongo.json"), &ReloadOptions{Interval: time.Minute})
+//	rc.Start(ctx)
+//	defer rc.Close(ctx)
+//	users := NewCollection(rc.Client(), "users")
+type ReloadableClient struct {
+	source ConfigSource
+	opts   ReloadOptions
+	// connect 建立客户端，测试中可以替换
+	connect func(ctx context.Context, config *Config) (*Client, error)
+
+	mu          sync.Mutex
+	current     atomic.Pointer[Client]
+	fingerprint [sha256.Size]byte
+
+	reloads  atomic.Int64
+	failures atomic.Int64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+	drains    sync.WaitGroup
+}
+
+// NewReloadableClient 读取配置并建立第一个客户端，首次连接失败时返回错误
+func NewReloadableClient(ctx context.Context, source ConfigSource, opts *ReloadOptions) (*ReloadableClient, error) {
+	r := newReloadableClient(source, opts, NewClientWithContext)
+	if _, err := r.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newReloadableClient(source ConfigSource, opts *ReloadOptions, connect func(context.Context, *Config) (*Client, error)) *ReloadableClient {
+	r := &ReloadableClient{source: source, connect: connect, stopCh: make(chan struct{})}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Interval <= 0 {
+		r.opts.Interval = 30 * time.Second
+	}
+	if r.opts.DrainTimeout <= 0 {
+		r.opts.DrainTimeout = 30 * time.Second
+	}
+	return r
+}
+
+// Client 返回当前生效的客户端
+func (r *ReloadableClient) Client() *Client {
+	return r.current.Load()
+}
+
+// Reload 立即读取配置，配置有变化时切换到新客户端，返回是否发生了切换
+func (r *ReloadableClient) Reload(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, err := r.source(ctx)
+	if err != nil {
+		r.failures.Add(1)
+		return false, fmt.Errorf("failed to load config: %w", err)
+	}
+	// 指纹只覆盖可序列化的字段，Customize 设置的运行时对象不参与比较
+	data, err := json.Marshal(config)
+	if err != nil {
+		r.failures.Add(1)
+		return false, fmt.Errorf("failed to encode config: %w", err)
+	}
+	fingerprint := sha256.Sum256(data)
+	previous := r.current.Load()
+	if previous != nil && fingerprint == r.fingerprint {
+		return false, nil
+	}
+
+	if r.opts.Customize != nil {
+		r.opts.Customize(config)
+	}
+	client, err := r.connect(ctx, config)
+	if err != nil {
+		r.failures.Add(1)
+		return false, fmt.Errorf("failed to connect with reloaded config: %w", err)
+	}
+	r.current.Store(client)
+	r.fingerprint = fingerprint
+	if previous == nil {
+		return true, nil
+	}
+
+	r.reloads.Add(1)
+	client.logger.InfoContext(ctx, "Reloaded MongoDB client", "database", config.Database)
+	if r.opts.OnReload != nil {
+		r.opts.OnReload(previous, client)
+	}
+	r.drain(ctx, previous)
+	return true, nil
+}
+
+// drain 在后台等待旧客户端上的操作完成后关闭，驱动断开连接时会等待已借出的连接归还直到 ctx 超时
+func (r *ReloadableClient) drain(ctx context.Context, previous *Client) {
+	r.drains.Add(1)
+	go func() {
+		defer r.drains.Done()
+		drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.opts.DrainTimeout)
+		defer cancel()
+		if err := previous.CloseContext(drainCtx); err != nil {
+			previous.logger.WarnContext(drainCtx, "Failed to close previous MongoDB client", "err", err)
+		}
+	}()
+}
+
+// Start 启动后台检查，按 Interval 调用 Reload，失败只记录日志
+func (r *ReloadableClient) Start(ctx context.Context) {
+	r.startOnce.Do(func() {
+		r.wg.Add(1)
+		go r.run(ctx)
+	})
+}
+
+func (r *ReloadableClient) run(ctx context.Context) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := r.Reload(ctx); err != nil {
+				r.Client().logger.WarnContext(ctx, "Failed to reload MongoDB config", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// Stop 停止后台检查，并等待正在关闭的旧客户端
+func (r *ReloadableClient) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+		r.wg.Wait()
+		r.drains.Wait()
+	})
+}
+
+// Close 停止后台检查并关闭当前客户端
+func (r *ReloadableClient) Close(ctx context.Context) error {
+	r.Stop()
+	if client := r.Client(); client != nil {
+		return client.CloseContext(ctx)
+	}
+	return nil
+}
+
+// Metrics 返回热更新统计
+func (r *ReloadableClient) Metrics() ReloadMetrics {
+	return ReloadMetrics{
+		Reloads:  r.reloads.Load(),
+		Failures: r.failures.Load(),
+	}
+}
@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadableClient(t *testing.T) {
+	ctx := t.Context()
+	path := filepath.Join(t.TempDir(), "mongo.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"uri": "mongodb://db1:27017", "database": "app"}`), 0o600))
+
+	var connectErr error
+	var reloaded [][2]string
+	r := newReloadableClient(FileConfigSource(path), &ReloadOptions{
+		Customize: func(config *Config) { config.Logger = defaultLogger() },
+		OnReload: func(previous, current *Client) {
+			reloaded = append(reloaded, [2]string{previous.dbName, current.dbName})
+		},
+	}, func(ctx context.Context, config *Config) (*Client, error) {
+		if connectErr != nil {
+			return nil, connectErr
+		}
+		return &Client{dbName: config.Database, logger: config.Logger}, nil
+	})
+
+	changed, err := r.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	first := r.Client()
+	assert.Equal(t, "app", first.dbName)
+
+	// 配置未变化时不重建
+	changed, err = r.Reload(ctx)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Same(t, first, r.Client())
+
+	// 新配置无法连接时保留旧客户端
+	require.NoError(t, os.WriteFile(path, []byte(`{"uri": "mongodb://db2:27017", "database": "app_v2"}`), 0o600))
+	connectErr = errors.New("connection refused")
+	_, err = r.Reload(ctx)
+	assert.Error(t, err)
+	assert.Same(t, first, r.Client())
+
+	connectErr = nil
+	changed, err = r.Reload(ctx)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "app_v2", r.Client().dbName)
+	assert.Equal(t, [][2]string{{"app", "app_v2"}}, reloaded)
+	assert.Equal(t, ReloadMetrics{Reloads: 1, Failures: 1}, r.Metrics())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err = r.Reload(ctx)
+	assert.Error(t, err)
+	require.NoError(t, r.Close(ctx))
+}
@@ -0,0 +1,300 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RetentionAction 保留期满后的处理方式
+type RetentionAction string
+
+const (
+	// RetentionDelete 删除文档
+	RetentionDelete RetentionAction = "delete"
+	// RetentionArchive 移动到归档集合
+	RetentionArchive RetentionAction = "archive"
+	// RetentionAnonymize 保留文档但清除或替换个人信息字段
+	RetentionAnonymize RetentionAction = "anonymize"
+)
+
+// RetentionRule 集合的数据保留规则，可以通过 ModelOptions.Retention 随模型一起声明
+//
+//	RegisterModel[User](models, ModelOptions{Retention: &RetentionRule{
+//		AgeField:        "last_login_at",
+//		MaxAge:          3 * 365 * 24 * time.Hour,
+//		Action:          RetentionAnonymize,
+//		AnonymizeFields: map[string]interface{}{"email": nil, "profile.first_name": "deleted"},
+//	}})
+type RetentionRule struct {
+	// Name 规则名称，默认为集合名
+	Name string
+	// Collection 集合名称，通过模型声明时自动填写
+	Collection string
+	// AgeField 判断数据年龄的时间字段，默认 created_at
+	AgeField string
+	// MaxAge 保留时长，必填
+	MaxAge time.Duration
+	// Filter 额外的过滤条件，只有匹配的文档受该规则约束
+	Filter bson.M
+	// Action 处理方式，默认 RetentionDelete
+	Action RetentionAction
+	// ArchiveCollection RetentionArchive 的归档集合，默认为集合名加 _archive 后缀
+	ArchiveCollection string
+	// AnonymizeFields RetentionAnonymize 要处理的字段及替换值，值为 nil 时删除该字段
+	AnonymizeFields map[string]interface{}
+	// UseTTLIndex 为 true 时 RetentionDelete 规则通过 TTL 索引由服务端删除，Enforce 不再处理；
+	// Filter 作为 TTL 索引的 partialFilterExpression，AgeField 必须是日期类型
+	UseTTLIndex bool
+	// BatchSize 删除和归档时每批处理的文档数，默认 500
+	BatchSize int
+}
+
+// RetentionResult 单条规则的执行结果
+type RetentionResult struct {
+	Rule       string          `json:"rule"`
+	Collection string          `json:"collection"`
+	Action     RetentionAction `json:"action"`
+	// TTL 规则由 TTL 索引执行，没有在本次执行中处理
+	TTL bool `json:"ttl,omitempty"`
+	// Matched 到期的文档数
+	Matched int64 `json:"matched"`
+	// Affected 实际删除、归档或匿名化的文档数，dry run 时为 0
+	Affected int64  `json:"affected"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RetentionReport 一次执行的汇总报告
+type RetentionReport struct {
+	DryRun     bool              `json:"dry_run"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Results    []RetentionResult `json:"results"`
+}
+
+// RetentionOptions 保留策略引擎配置
+type RetentionOptions struct {
+	// RunsCollection 删除和归档的执行记录集合，默认 archive_runs
+	RunsCollection string
+}
+
+// RetentionEngine 声明式数据保留引擎：集合通过规则声明保留期和到期后的处理方式，
+// 由 Enforce 统一执行并汇报每条规则处理的文档数；删除和归档复用 Archiver，执行记录写入 archive_runs
+type RetentionEngine struct {
+	client   *Client
+	archiver *Archiver
+
+	mu    sync.RWMutex
+	rules map[string]RetentionRule
+}
+
+// NewRetentionEngine 创建保留策略引擎
+func NewRetentionEngine(client *Client, opts *RetentionOptions) *RetentionEngine {
+	o := RetentionOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	return &RetentionEngine{
+		client:   client,
+		archiver: NewArchiver(client, &ArchiverOptions{RunsCollection: o.RunsCollection}),
+		rules:    make(map[string]RetentionRule),
+	}
+}
+
+// Register 注册保留规则，同名规则会被覆盖
+func (e *RetentionEngine) Register(rule RetentionRule) error {
+	if rule.Collection == "" {
+		return fmt.Errorf("retention rule requires a collection")
+	}
+	if rule.Name == "" {
+		rule.Name = rule.Collection
+	}
+	if rule.MaxAge <= 0 {
+		return fmt.Errorf("retention rule %s requires a positive MaxAge", rule.Name)
+	}
+	if rule.AgeField == "" {
+		rule.AgeField = "created_at"
+	}
+	if rule.Action == "" {
+		rule.Action = RetentionDelete
+	}
+
+	switch rule.Action {
+	case RetentionDelete, RetentionArchive:
+		if rule.UseTTLIndex && rule.Action != RetentionDelete {
+			return fmt.Errorf("retention rule %s: TTL index only supports the delete action", rule.Name)
+		}
+		if rule.UseTTLIndex {
+			break
+		}
+		action := ArchiveDelete
+		if rule.Action == RetentionArchive {
+			action = ArchiveMove
+		}
+		err := e.archiver.Register(ArchivePolicy{
+			Name:              rule.Name,
+			Collection:        rule.Collection,
+			AgeField:          rule.AgeField,
+			OlderThan:         rule.MaxAge,
+			Filter:            rule.Filter,
+			Action:            action,
+			ArchiveCollection: rule.ArchiveCollection,
+			BatchSize:         rule.BatchSize,
+		})
+		if err != nil {
+			return err
+		}
+	case RetentionAnonymize:
+		if len(rule.AnonymizeFields) == 0 {
+			return fmt.Errorf("retention rule %s requires AnonymizeFields", rule.Name)
+		}
+		if rule.UseTTLIndex {
+			return fmt.Errorf("retention rule %s: TTL index only supports the delete action", rule.Name)
+		}
+	default:
+		return fmt.Errorf("retention rule %s has unsupported action %q", rule.Name, rule.Action)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules[rule.Name] = rule
+	return nil
+}
+
+// RegisterModels 注册模型注册表中通过 ModelOptions.Retention 声明的规则
+func (e *RetentionEngine) RegisterModels(models *ModelRegistry) error {
+	for _, model := range models.Models() {
+		if model.Options.Retention == nil {
+			continue
+		}
+		rule := *model.Options.Retention
+		rule.Collection = model.Collection()
+		if err := e.Register(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rules 返回已注册的规则，按名称排序
+func (e *RetentionEngine) Rules() []RetentionRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]RetentionRule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// EnsureTTLIndexes 为 UseTTLIndex 的规则创建 TTL 索引
+// 同一字段已经存在过期时间不同的 TTL 索引时创建会失败，需要先删除旧索引
+func (e *RetentionEngine) EnsureTTLIndexes(ctx context.Context) error {
+	for _, rule := range e.Rules() {
+		if !rule.UseTTLIndex {
+			continue
+		}
+		opts := options.Index().
+			SetName("retention_" + rule.AgeField + "_ttl").
+			SetExpireAfterSeconds(int32(rule.MaxAge / time.Second))
+		if len(rule.Filter) > 0 {
+			opts.SetPartialFilterExpression(rule.Filter)
+		}
+		_, err := e.client.database.Collection(rule.Collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: rule.AgeField, Value: 1}},
+			Options: opts,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create TTL index for retention rule %s: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// Enforce 执行所有规则，某条规则失败时继续执行其它规则；dryRun 时只统计到期的文档数
+func (e *RetentionEngine) Enforce(ctx context.Context, dryRun bool) (*RetentionReport, error) {
+	report := &RetentionReport{DryRun: dryRun, StartedAt: time.Now()}
+	var errs []error
+	for _, rule := range e.Rules() {
+		result, err := e.enforce(ctx, rule, dryRun)
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.FinishedAt = time.Now()
+	return report, errors.Join(errs...)
+}
+
+// enforce 执行单条规则
+func (e *RetentionEngine) enforce(ctx context.Context, rule RetentionRule, dryRun bool) (RetentionResult, error) {
+	result := RetentionResult{Rule: rule.Name, Collection: rule.Collection, Action: rule.Action, TTL: rule.UseTTLIndex}
+	if rule.UseTTLIndex {
+		return result, nil
+	}
+
+	if rule.Action != RetentionAnonymize {
+		run, err := e.archiver.Run(ctx, rule.Name, dryRun)
+		if run != nil {
+			result.Matched = run.Matched
+			result.Affected = run.Deleted
+		}
+		return result, err
+	}
+
+	filter := anonymizeFilter(rule, time.Now().Add(-rule.MaxAge))
+	coll := e.client.database.Collection(rule.Collection)
+	matched, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return result, fmt.Errorf("retention rule %s: failed to count documents: %w", rule.Name, err)
+	}
+	result.Matched = matched
+	if dryRun || matched == 0 {
+		return result, nil
+	}
+	updated, err := coll.UpdateMany(ctx, filter, anonymizeUpdate(rule.AnonymizeFields, time.Now()))
+	if err != nil {
+		return result, fmt.Errorf("retention rule %s: failed to anonymize documents: %w", rule.Name, err)
+	}
+	result.Affected = updated.ModifiedCount
+	return result, nil
+}
+
+// anonymizeFilter 到期且尚未匿名化的文档
+func anonymizeFilter(rule RetentionRule, cutoff time.Time) bson.M {
+	conditions := []bson.M{
+		{rule.AgeField: bson.M{"$lt": cutoff}},
+		{"anonymized_at": bson.M{"$exists": false}},
+	}
+	if len(rule.Filter) > 0 {
+		conditions = append([]bson.M{rule.Filter}, conditions...)
+	}
+	return bson.M{"$and": conditions}
+}
+
+// anonymizeUpdate 替换或删除个人信息字段，并写入 anonymized_at 避免重复处理
+func anonymizeUpdate(fields map[string]interface{}, now time.Time) bson.M {
+	set := bson.M{"anonymized_at": now}
+	unset := bson.M{}
+	for field, value := range fields {
+		if value == nil {
+			unset[field] = ""
+		} else {
+			set[field] = value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
@@ -0,0 +1,75 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRetentionEngineRegister(t *testing.T) {
+	e := NewRetentionEngine(newLazyClient(t), nil)
+
+	assert.Error(t, e.Register(RetentionRule{MaxAge: time.Hour}))
+	assert.Error(t, e.Register(RetentionRule{Collection: "logs"}))
+	assert.Error(t, e.Register(RetentionRule{Collection: "users", MaxAge: time.Hour, Action: RetentionAnonymize}))
+	assert.Error(t, e.Register(RetentionRule{Collection: "logs", MaxAge: time.Hour, Action: RetentionArchive, UseTTLIndex: true}))
+	assert.Error(t, e.Register(RetentionRule{Collection: "logs", MaxAge: time.Hour, Action: "shred"}))
+
+	require.NoError(t, e.Register(RetentionRule{Collection: "logs", MaxAge: time.Hour, UseTTLIndex: true}))
+	require.NoError(t, e.Register(RetentionRule{Collection: "articles", MaxAge: time.Hour, Action: RetentionArchive}))
+
+	rules := e.Rules()
+	require.Len(t, rules, 2)
+	assert.Equal(t, "articles", rules[0].Name)
+	assert.Equal(t, "created_at", rules[0].AgeField)
+	assert.Equal(t, RetentionDelete, rules[1].Action)
+
+	// 删除和归档规则注册为归档策略，TTL 规则除外
+	policies := e.archiver.Policies()
+	require.Len(t, policies, 1)
+	assert.Equal(t, ArchiveMove, policies[0].Action)
+
+	// TTL 规则不在 Enforce 中处理
+	result, err := e.enforce(t.Context(), rules[1], false)
+	require.NoError(t, err)
+	assert.True(t, result.TTL)
+}
+
+func TestRetentionEngineRegisterModels(t *testing.T) {
+	client := newLazyClient(t)
+	models := NewModelRegistry(client)
+	require.NoError(t, RegisterModel[User](models, ModelOptions{Retention: &RetentionRule{
+		AgeField:        "last_login_at",
+		MaxAge:          time.Hour,
+		Action:          RetentionAnonymize,
+		AnonymizeFields: map[string]interface{}{"email": nil},
+	}}))
+	require.NoError(t, RegisterModel[Article](models, ModelOptions{}))
+
+	e := NewRetentionEngine(client, nil)
+	require.NoError(t, e.RegisterModels(models))
+	rules := e.Rules()
+	require.Len(t, rules, 1)
+	assert.Equal(t, "users", rules[0].Collection)
+	assert.Equal(t, "users", rules[0].Name)
+}
+
+func TestAnonymizeUpdate(t *testing.T) {
+	now := time.Now()
+	update := anonymizeUpdate(map[string]interface{}{"email": nil, "name": "deleted"}, now)
+	assert.Equal(t, bson.M{
+		"$set":   bson.M{"anonymized_at": now, "name": "deleted"},
+		"$unset": bson.M{"email": ""},
+	}, update)
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := anonymizeFilter(RetentionRule{AgeField: "last_login_at", Filter: bson.M{"status": "inactive"}}, cutoff)
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"status": "inactive"},
+		{"last_login_at": bson.M{"$lt": cutoff}},
+		{"anonymized_at": bson.M{"$exists": false}},
+	}}, filter)
+}
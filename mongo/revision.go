@@ -0,0 +1,302 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+// ErrRevisionNotFound 指定的历史版本不存在
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Revision 文档的历史版本，保存的是更新或替换之前的完整文档
+type Revision struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	DocumentID interface{}        `bson:"document_id" json:"document_id"`
+	Version    int64              `bson:"version" json:"version"`
+	Document   bson.M             `bson:"document" json:"document"`
+	Actor      string             `bson:"actor,omitempty" json:"actor,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Decode 将历史版本解码到 v
+func (r *Revision) Decode(v interface{}) error {
+	data, err := bson.Marshal(r.Document)
+	if err != nil {
+		return fmt.Errorf("failed to encode revision: %w", err)
+	}
+	if err := bson.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode revision: %w", err)
+	}
+	return nil
+}
+
+// RevisionOptions 版本历史配置
+type RevisionOptions struct {
+	// Collection 历史版本集合名称，默认 <集合名>_revisions
+	Collection string
+	// MaxRevisions 每个文档保留的最大版本数，超出时删除最旧的版本，小于等于 0 时不限制
+	MaxRevisions int64
+}
+
+// revisionStore 集合的历史版本存储
+type revisionStore struct {
+	collection *mongo.Collection
+	opts       RevisionOptions
+}
+
+// maxRevisionRetries 版本号冲突时的最大重试次数
+const maxRevisionRetries = 5
+
+// WithRevisions 返回开启版本历史的集合副本
+// 之后通过该集合执行的 UpdateOne/UpdateMany/Upsert/ReplaceOne 会在修改前把旧文档保存为一个版本（内容未变化的文档不保存），
+// 版本与原操作使用相同的上下文写入，在事务中会随事务一起提交或回滚：
+//
+//	articles := NewCollection(client, "articles").WithRevisions(nil)
+//	revisions, err := articles.Revisions(ctx, articleID, 10)
+//	err = articles.RestoreRevision(ctx, articleID, revisions[1].Version)
+func (c *Collection) WithRevisions(opts *RevisionOptions) *Collection {
+	store := &revisionStore{}
+	if opts != nil {
+		store.opts = *opts
+	}
+	if store.opts.Collection == "" {
+		store.opts.Collection = c.collection.Name() + "_revisions"
+	}
+	store.collection = c.collection.Database().Collection(store.opts.Collection)
+
+	cp := *c
+	cp.revisions = store
+	return &cp
+}
+
+// EnsureRevisionIndexes 创建历史版本集合的唯一索引，保证同一文档的版本号不重复
+func (c *Collection) EnsureRevisionIndexes(ctx context.Context) error {
+	if c.revisions == nil {
+		return fmt.Errorf("revisions are not enabled for collection %s", c.collection.Name())
+	}
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "document_id", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetName("uk_document_id_version").SetUnique(true),
+	}
+	if _, err := c.revisions.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create revision index: %w", err)
+	}
+	return nil
+}
+
+// Revisions 按版本号倒序返回文档的历史版本，limit 小于等于 0 时不限制数量
+func (c *Collection) Revisions(ctx context.Context, documentID interface{}, limit int64) ([]Revision, error) {
+	if c.revisions == nil {
+		return nil, fmt.Errorf("revisions are not enabled for collection %s", c.collection.Name())
+	}
+	ctx = c.sessionContext(ctx)
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := c.revisions.collection.Find(ctx, bson.M{"document_id": documentID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find revisions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var revisions []Revision
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, fmt.Errorf("failed to decode revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// Revision 获取文档的指定版本，不存在时返回 ErrRevisionNotFound
+func (c *Collection) Revision(ctx context.Context, documentID interface{}, version int64) (*Revision, error) {
+	if c.revisions == nil {
+		return nil, fmt.Errorf("revisions are not enabled for collection %s", c.collection.Name())
+	}
+	ctx = c.sessionContext(ctx)
+	var revision Revision
+	err := c.revisions.collection.FindOne(ctx, bson.M{"document_id": documentID, "version": version}).Decode(&revision)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("failed to find revision: %w", err)
+	}
+	return &revision, nil
+}
+
+// DiffRevisions 比较文档两个版本之间的字段变更，to 小于等于 0 时与当前文档比较
+func (c *Collection) DiffRevisions(ctx context.Context, documentID interface{}, from, to int64) ([]AuditChange, error) {
+	older, err := c.Revision(ctx, documentID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var newer bson.M
+	if to > 0 {
+		revision, err := c.Revision(ctx, documentID, to)
+		if err != nil {
+			return nil, err
+		}
+		newer = revision.Document
+	} else {
+		docs, err := c.loadDocuments(c.sessionContext(ctx), bson.M{"_id": documentID}, false)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) > 0 {
+			newer = docs[0]
+		}
+	}
+	return diffDocuments(older.Document, newer, nil), nil
+}
+
+// RestoreRevision 将文档恢复到指定版本，恢复前的当前文档会保存为一个新版本
+// 文档已被删除时重新插入该版本
+func (c *Collection) RestoreRevision(ctx context.Context, documentID interface{}, version int64) error {
+	revision, err := c.Revision(ctx, documentID, version)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.ReplaceOne(ctx, bson.M{"_id": documentID}, revision.Document)
+	if err != nil {
+		return fmt.Errorf("failed to restore revision %d: %w", version, err)
+	}
+	if result.MatchedCount == 0 {
+		if _, err := c.InsertOne(ctx, revision.Document); err != nil {
+			return fmt.Errorf("failed to restore revision %d: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// saveRevisions 将修改前的文档保存为新版本
+// 按 _id 重新读取修改后的文档，只为内容发生变化的文档保存版本；
+// updated_at 每次更新都会改变，比较时忽略，避免未实际修改的匹配文档产生重复版本
+func (c *Collection) saveRevisions(ctx context.Context, before []bson.M) error {
+	if c.revisions == nil || len(before) == 0 {
+		return nil
+	}
+	ids := make([]interface{}, 0, len(before))
+	for _, doc := range before {
+		ids = append(ids, doc["_id"])
+	}
+	after, err := c.auditReload(ctx, ids)
+	if err != nil {
+		return err
+	}
+	current := make(map[interface{}]bson.M, len(after))
+	for _, doc := range after {
+		current[auditKey(doc["_id"])] = doc
+	}
+	for _, doc := range before {
+		if changed, ok := current[auditKey(doc["_id"])]; ok && len(diffDocuments(doc, changed, revisionIgnoredFields)) == 0 {
+			continue
+		}
+		if err := c.revisions.save(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revisionIgnoredFields 判断文档是否变化时忽略的字段
+var revisionIgnoredFields = map[string]bool{"updated_at": true}
+
+// save 保存一个版本，版本号为该文档当前最大版本号加一，并发写入导致版本号冲突时重试
+// 事务中的写错误会使事务中止，无法在事务内重试，此时返回带 TransientTransactionError 标签的错误，
+// 由 TransactionManager 重试整个事务
+func (s *revisionStore) save(ctx context.Context, doc bson.M) error {
+	documentID := doc["_id"]
+	actor, _ := ActorFromContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		version, err := s.latestVersion(ctx, documentID)
+		if err != nil {
+			return err
+		}
+		revision := Revision{
+			DocumentID: documentID,
+			Version:    version + 1,
+			Document:   doc,
+			Actor:      actor,
+			CreatedAt:  time.Now(),
+		}
+		_, err = s.collection.InsertOne(ctx, revision)
+		if err == nil {
+			return s.prune(ctx, documentID, revision.Version)
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to save revision: %w", err)
+		}
+		if inTransaction(ctx) {
+			return fmt.Errorf("failed to save revision: %w", revisionConflictError{err: err})
+		}
+		if attempt+1 >= maxRevisionRetries {
+			return fmt.Errorf("failed to save revision: %w", err)
+		}
+	}
+}
+
+// revisionConflictError 事务中版本号冲突的错误，标记为临时事务错误以便重试整个事务
+type revisionConflictError struct {
+	err error
+}
+
+func (e revisionConflictError) Error() string {
+	return "revision version conflict: " + e.err.Error()
+}
+
+func (e revisionConflictError) Unwrap() error {
+	return e.err
+}
+
+// HasErrorLabel 实现 mongo.LabeledError
+func (e revisionConflictError) HasErrorLabel(label string) bool {
+	return label == driver.TransientTransactionError
+}
+
+// inTransaction 返回 ctx 中的会话是否正在执行事务
+func inTransaction(ctx context.Context) bool {
+	session, ok := mongo.SessionFromContext(ctx).(mongo.XSession)
+	return ok && session.ClientSession().TransactionRunning()
+}
+
+// latestVersion 返回文档当前最大的版本号，没有版本时返回 0
+func (s *revisionStore) latestVersion(ctx context.Context, documentID interface{}) (int64, error) {
+	var latest Revision
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"version": 1})
+	err := s.collection.FindOne(ctx, bson.M{"document_id": documentID}, opts).Decode(&latest)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to find latest revision: %w", err)
+	}
+	return latest.Version, nil
+}
+
+// prune 删除超出保留数量的旧版本
+func (s *revisionStore) prune(ctx context.Context, documentID interface{}, latest int64) error {
+	if s.opts.MaxRevisions <= 0 || latest <= s.opts.MaxRevisions {
+		return nil
+	}
+	filter := bson.M{
+		"document_id": documentID,
+		"version":     bson.M{"$lte": latest - s.opts.MaxRevisions},
+	}
+	if _, err := s.collection.DeleteMany(ctx, filter); err != nil {
+		return fmt.Errorf("failed to prune revisions: %w", err)
+	}
+	return nil
+}
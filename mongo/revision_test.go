@@ -0,0 +1,93 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+func TestRevisionsOnlyForChangedDocuments(t *testing.T) {
+	server := newFakeServer(t, false)
+	changed, unchanged := NewObjectID(), NewObjectID()
+	before := time.Now().Add(-time.Hour)
+	finds := 0
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "find" || cmd.Lookup("find").StringValue() != "notes" {
+			return nil
+		}
+		finds++
+		if finds == 1 {
+			return fakeCursor(cmd,
+				bson.M{"_id": changed, "status": "draft", "updated_at": before},
+				bson.M{"_id": unchanged, "status": "published", "updated_at": before},
+			)
+		}
+		// 更新后 updated_at 都发生变化，只有 changed 的内容被修改
+		return fakeCursor(cmd,
+			bson.M{"_id": changed, "status": "published", "updated_at": time.Now()},
+			bson.M{"_id": unchanged, "status": "published", "updated_at": time.Now()},
+		)
+	})
+	notes := NewCollection(server.client(t), "notes").WithRevisions(nil)
+
+	_, err := notes.UpdateMany(t.Context(), bson.M{"status": bson.M{"$exists": true}}, bson.M{"$set": bson.M{"status": "published"}})
+	require.NoError(t, err)
+
+	inserts := server.Commands("insert")
+	require.Len(t, inserts, 1)
+	assert.Equal(t, "notes_revisions", inserts[0].Lookup("insert").StringValue())
+	revision := inserts[0].Lookup("documents").Array().Index(0).Value().Document()
+	assert.Equal(t, changed, revision.Lookup("document_id").ObjectID())
+	assert.Equal(t, "draft", revision.Lookup("document", "status").StringValue())
+}
+
+func TestRevisionConflictRetries(t *testing.T) {
+	server := newFakeServer(t, true)
+	id := NewObjectID()
+	finds := 0
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		switch name {
+		case "find":
+			if cmd.Lookup("find").StringValue() == "notes" {
+				// 依次返回更新前和更新后的文档
+				finds++
+				status := "draft"
+				if finds%2 == 0 {
+					status = "published"
+				}
+				return fakeCursor(cmd, bson.M{"_id": id, "status": status})
+			}
+		case "insert":
+			return fakeWriteError(11000, "E11000 duplicate key error")
+		}
+		return nil
+	})
+	client := server.client(t)
+	notes := NewCollection(client, "notes").WithRevisions(nil)
+	update := func() bson.M { return bson.M{"$set": bson.M{"status": "published"}} }
+
+	// 事务外版本号冲突时重新读取版本号并重试
+	_, err := notes.UpdateOne(t.Context(), bson.M{"_id": id}, update())
+	require.Error(t, err)
+	assert.True(t, mongo.IsDuplicateKeyError(err))
+	assert.Len(t, server.Commands("insert"), maxRevisionRetries)
+
+	// 事务中的写错误会中止事务，不在事务内重试，而是交给事务管理器重试整个事务
+	tm := NewTransactionManager(client)
+	attempts, retries := 0, 1
+	err = tm.WithTransactionOptions(t.Context(), &TxnOptions{MaxRetries: &retries, RetryBackoff: time.Millisecond}, func(sessCtx mongo.SessionContext) error {
+		attempts++
+		_, err := notes.UpdateOne(sessCtx, bson.M{"_id": id}, update())
+		return err
+	})
+	require.Error(t, err)
+	assert.True(t, hasErrorLabel(err, driver.TransientTransactionError))
+	assert.Equal(t, 2, attempts)
+	assert.Len(t, server.Commands("insert"), maxRevisionRetries+2)
+	assert.Equal(t, int64(1), tm.Metrics().TransientRetries)
+}
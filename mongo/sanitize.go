@@ -0,0 +1,162 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrUnsafeFilter 过滤条件中包含不允许的操作符或字段
+var ErrUnsafeFilter = errors.New("unsafe filter")
+
+// SanitizeMode 遇到不允许的键时的处理方式
+type SanitizeMode int
+
+const (
+	// SanitizeReject 返回 ErrUnsafeFilter
+	SanitizeReject SanitizeMode = iota
+	// SanitizeStrip 丢弃不允许的键，继续处理其它键
+	SanitizeStrip
+)
+
+// forbiddenOperators 会在服务端执行代码的操作符，任何配置下都不允许
+var forbiddenOperators = map[string]bool{
+	"$where":       true,
+	"$function":    true,
+	"$accumulator": true,
+}
+
+// logicalOperators 值为过滤条件数组的逻辑操作符
+var logicalOperators = map[string]bool{
+	"$and": true,
+	"$or":  true,
+	"$nor": true,
+}
+
+// FilterSanitizer 不可信输入（例如 HTTP 请求体）的过滤条件清理器
+// 默认拒绝所有 $ 开头的键和带点号的字段路径，防止 {"password": {"$ne": null}} 这类操作符注入：
+//
+//	sanitizer := &FilterSanitizer{AllowedFields: []string{"status", "tags"}, AllowedOperators: []string{"$in"}}
+//	filter, err := sanitizer.Sanitize(body)
+type FilterSanitizer struct {
+	// Mode 遇到不允许的键时的处理方式，默认 SanitizeReject
+	Mode SanitizeMode
+	// AllowedFields 允许过滤的顶层字段，为空时允许任意字段
+	AllowedFields []string
+	// AllowedOperators 允许使用的查询操作符，例如 $in、$gte、$or；$where、$function、$accumulator 始终不允许
+	AllowedOperators []string
+	// AllowDottedPaths 是否允许 profile.city 这类点号路径，开启后路径同样受 AllowedFields 限制
+	AllowDottedPaths bool
+}
+
+// SanitizeFilter 使用默认配置清理过滤条件，只允许字段的相等匹配
+func SanitizeFilter(input map[string]interface{}) (bson.M, error) {
+	return (&FilterSanitizer{}).Sanitize(input)
+}
+
+// Sanitize 清理过滤条件
+func (s *FilterSanitizer) Sanitize(input map[string]interface{}) (bson.M, error) {
+	return s.sanitizeFilter(input, true, false)
+}
+
+// SanitizeDocument 清理用于写入的文档（例如 $set 的内容），任何层级都不允许出现操作符
+func (s *FilterSanitizer) SanitizeDocument(input map[string]interface{}) (bson.M, error) {
+	return s.sanitizeFilter(input, true, true)
+}
+
+// sanitizeFilter 清理过滤条件的一层，topLevel 为 true 时检查字段白名单
+func (s *FilterSanitizer) sanitizeFilter(input map[string]interface{}, topLevel, document bool) (bson.M, error) {
+	result := bson.M{}
+	for key, value := range input {
+		if strings.HasPrefix(key, "$") {
+			if document || !s.operatorAllowed(key) {
+				if err := s.reject("operator %s is not allowed", key); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			// $and、$or、$nor 的值为过滤条件数组，其中的字段同样受白名单限制
+			sanitized, err := s.sanitizeValue(value, logicalOperators[key], document)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = sanitized
+			continue
+		}
+
+		if strings.Contains(key, ".") && !s.AllowDottedPaths {
+			if err := s.reject("dotted path %s is not allowed", key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if topLevel && !s.fieldAllowed(key) {
+			if err := s.reject("field %s is not allowed", key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		sanitized, err := s.sanitizeValue(value, false, document)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = sanitized
+	}
+	return result, nil
+}
+
+// sanitizeValue 清理字段值，嵌套文档中的操作符按同样规则检查，filters 为 true 时数组元素按过滤条件处理
+func (s *FilterSanitizer) sanitizeValue(value interface{}, filters, document bool) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.sanitizeFilter(v, filters, document)
+	case bson.M:
+		return s.sanitizeFilter(v, filters, document)
+	case []interface{}:
+		sanitized := make(bson.A, 0, len(v))
+		for _, item := range v {
+			cleaned, err := s.sanitizeValue(item, filters, document)
+			if err != nil {
+				return nil, err
+			}
+			sanitized = append(sanitized, cleaned)
+		}
+		return sanitized, nil
+	case bson.A:
+		return s.sanitizeValue([]interface{}(v), filters, document)
+	default:
+		return value, nil
+	}
+}
+
+// operatorAllowed 检查操作符是否在白名单中
+func (s *FilterSanitizer) operatorAllowed(operator string) bool {
+	if forbiddenOperators[operator] {
+		return false
+	}
+	return contains(s.AllowedOperators, operator)
+}
+
+// fieldAllowed 检查字段是否在白名单中，点号路径的任意前缀在白名单中即可
+func (s *FilterSanitizer) fieldAllowed(field string) bool {
+	if len(s.AllowedFields) == 0 {
+		return true
+	}
+	for _, allowed := range s.AllowedFields {
+		if field == allowed || strings.HasPrefix(field, allowed+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// reject 按模式处理不允许的键，SanitizeStrip 时返回 nil 表示跳过该键
+func (s *FilterSanitizer) reject(format string, args ...interface{}) error {
+	if s.Mode == SanitizeStrip {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsafeFilter, fmt.Sprintf(format, args...))
+}
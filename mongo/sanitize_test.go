@@ -0,0 +1,61 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSanitizeFilterRejectsOperators(t *testing.T) {
+	_, err := SanitizeFilter(map[string]interface{}{"password": map[string]interface{}{"$ne": nil}})
+	assert.ErrorIs(t, err, ErrUnsafeFilter)
+
+	_, err = SanitizeFilter(map[string]interface{}{"$where": "sleep(1000)"})
+	assert.ErrorIs(t, err, ErrUnsafeFilter)
+
+	_, err = SanitizeFilter(map[string]interface{}{"profile.city": "x"})
+	assert.ErrorIs(t, err, ErrUnsafeFilter)
+
+	filter, err := SanitizeFilter(map[string]interface{}{"status": "published"})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"status": "published"}, filter)
+}
+
+func TestFilterSanitizerAllowlist(t *testing.T) {
+	s := &FilterSanitizer{
+		AllowedFields:    []string{"status", "tags", "profile"},
+		AllowedOperators: []string{"$in", "$or", "$where"},
+		AllowDottedPaths: true,
+	}
+	filter, err := s.Sanitize(map[string]interface{}{
+		"tags":         map[string]interface{}{"$in": []interface{}{"go", "db"}},
+		"profile.city": "sh",
+		"$or":          []interface{}{map[string]interface{}{"status": "draft"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"tags":         bson.M{"$in": bson.A{"go", "db"}},
+		"profile.city": "sh",
+		"$or":          bson.A{bson.M{"status": "draft"}},
+	}, filter)
+
+	_, err = s.Sanitize(map[string]interface{}{"$or": []interface{}{map[string]interface{}{"password": "x"}}})
+	assert.ErrorIs(t, err, ErrUnsafeFilter)
+	_, err = s.Sanitize(map[string]interface{}{"$where": "true"})
+	assert.ErrorIs(t, err, ErrUnsafeFilter)
+	_, err = s.SanitizeDocument(map[string]interface{}{"tags": map[string]interface{}{"$in": []interface{}{"go"}}})
+	assert.ErrorIs(t, err, ErrUnsafeFilter)
+}
+
+func TestFilterSanitizerStrip(t *testing.T) {
+	s := &FilterSanitizer{Mode: SanitizeStrip, AllowedFields: []string{"status"}}
+	filter, err := s.Sanitize(map[string]interface{}{
+		"status":   map[string]interface{}{"$ne": "x", "nested": 1},
+		"password": "x",
+		"$where":   "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"status": bson.M{"nested": 1}}, filter)
+}
@@ -0,0 +1,390 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BatchFunc 批处理回调，返回错误时停止遍历，该批次不会记录到检查点
+type BatchFunc func(ctx context.Context, batch []bson.Raw) error
+
+// BatchOptions ForEachBatch 配置
+type BatchOptions struct {
+	// Name 检查点名称，和 Checkpoints 同时设置时开启断点续跑
+	Name string
+	// Checkpoints 检查点存储，每批处理成功后保存该批最后一个文档的 _id
+	// 可以和变更流共用 NewMongoCheckpointStore 创建的存储
+	Checkpoints CheckpointStore
+	// StartAfter 从 _id 大于该值的文档开始遍历，存在检查点时以检查点为准
+	StartAfter interface{}
+	// Projection 投影，需要保留 _id
+	Projection interface{}
+	// Throttle 每批处理完成后的等待时间，用于降低大批量修复数据时对线上的影响
+	Throttle time.Duration
+}
+
+// ForEachBatch 按 _id 升序分批遍历过滤条件匹配的文档，每批最多 batchSize 个，每批调用一次 fn
+// 每批通过 _id > 上一批最后一个 _id 查询，不依赖长时间打开的游标，遍历过程中可以修改文档；
+// 配置了检查点时每批成功后保存进度，中断后使用相同的 Name 再次调用会从上次成功的批次之后继续。
+// 检查点在遍历完成后保留，再次运行只会处理 _id 更大的文档，需要重新处理时使用新的 Name
+//
+//	processed, err := users.ForEachBatch(ctx, bson.M{"status": "active"}, 500, func(ctx context.Context, batch []bson.Raw) error {
+//		users, err := DecodeBatch[User](batch)
+//		...
+//	}, &BatchOptions{Name: "backfill-nickname", Checkpoints: NewMongoCheckpointStore(client, "")})
+//
+// 返回本次调用处理成功的文档数
+func (c *Collection) ForEachBatch(ctx context.Context, filter bson.M, batchSize int, fn BatchFunc, opts ...*BatchOptions) (int64, error) {
+	ctx = c.sessionContext(ctx)
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+	o := BatchOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			o = *opt
+		}
+	}
+	checkpointing := o.Name != "" && o.Checkpoints != nil
+
+	lastID := o.StartAfter
+	if checkpointing {
+		id, err := loadBatchCheckpoint(ctx, o.Checkpoints, o.Name)
+		if err != nil {
+			return 0, err
+		}
+		if id != nil {
+			lastID = id
+		}
+	}
+
+	var processed int64
+	for {
+		findOpts := options.Find().
+			SetSort(bson.D{{Key: "_id", Value: 1}}).
+			SetLimit(int64(batchSize))
+		if o.Projection != nil {
+			findOpts.SetProjection(o.Projection)
+		}
+		cursor, err := c.collection.Find(ctx, afterIDFilter(filter, lastID), findOpts)
+		if err != nil {
+			return processed, fmt.Errorf("failed to load batch: %w", err)
+		}
+		var batch []bson.Raw
+		for cursor.Next(ctx) {
+			batch = append(batch, append(bson.Raw(nil), cursor.Current...))
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return processed, fmt.Errorf("failed to load batch: %w", err)
+		}
+		if len(batch) == 0 {
+			return processed, nil
+		}
+
+		if err := fn(ctx, batch); err != nil {
+			return processed, fmt.Errorf("batch after _id %v failed: %w", lastID, err)
+		}
+		processed += int64(len(batch))
+		lastID = batch[len(batch)-1].Lookup("_id")
+		if checkpointing {
+			if err := saveBatchCheckpoint(ctx, o.Checkpoints, o.Name, lastID); err != nil {
+				return processed, err
+			}
+		}
+		if len(batch) < batchSize {
+			return processed, nil
+		}
+
+		if o.Throttle > 0 {
+			select {
+			case <-ctx.Done():
+				return processed, ctx.Err()
+			case <-time.After(o.Throttle):
+			}
+		}
+	}
+}
+
+// DecodeBatch 将 ForEachBatch 的一批原始文档解码为 T
+func DecodeBatch[T any](batch []bson.Raw) ([]T, error) {
+	results := make([]T, len(batch))
+	for i, raw := range batch {
+		if err := bson.Unmarshal(raw, &results[i]); err != nil {
+			return nil, fmt.Errorf("failed to decode document: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// afterIDFilter 在过滤条件上追加 _id > lastID，lastID 为 nil 时原样返回
+func afterIDFilter(filter bson.M, lastID interface{}) bson.M {
+	if lastID == nil {
+		if filter == nil {
+			return bson.M{}
+		}
+		return filter
+	}
+	after := bson.M{"_id": bson.M{"$gt": lastID}}
+	if len(filter) == 0 {
+		return after
+	}
+	return bson.M{"$and": []bson.M{filter, after}}
+}
+
+// loadBatchCheckpoint 读取检查点中保存的 _id，没有检查点时返回 nil
+func loadBatchCheckpoint(ctx context.Context, store CheckpointStore, name string) (interface{}, error) {
+	token, err := store.Load(ctx, name)
+	if err != nil || token == nil {
+		return nil, err
+	}
+	value, err := token.LookupErr("_id")
+	if err != nil {
+		return nil, fmt.Errorf("invalid batch checkpoint %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// saveBatchCheckpoint 将 _id 保存为 {_id: lastID} 形式的检查点
+func saveBatchCheckpoint(ctx context.Context, store CheckpointStore, name string, lastID interface{}) error {
+	token, err := bson.Marshal(bson.M{"_id": lastID})
+	if err != nil {
+		return fmt.Errorf("failed to encode batch checkpoint: %w", err)
+	}
+	return store.Save(ctx, name, token)
+}
+
+// ScanFunc ParallelScan 对每个文档调用的处理函数，会在多个 goroutine 中并发调用
+type ScanFunc func(ctx context.Context, doc bson.Raw) error
+
+// ParallelScanOptions 并行扫描配置
+type ParallelScanOptions struct {
+	// Field 切分范围的字段，默认 _id；分片集合可以使用分片键，所有文档都必须包含该字段
+	Field string
+	// Workers 并发处理的范围数，默认 runtime.NumCPU()
+	Workers int
+	// Ranges 切分的范围数，默认 Workers 的 4 倍，范围越多负载越均衡
+	Ranges int
+	// SampleSize 用于计算范围边界的采样文档数，默认 Ranges 的 20 倍
+	SampleSize int
+	// BatchSize 游标每批返回的文档数
+	BatchSize int32
+	// Projection 投影
+	Projection interface{}
+	// ContinueOnError 某个范围失败后是否继续处理其它范围，默认第一个错误出现后取消剩余的范围
+	ContinueOnError bool
+}
+
+// ScanRange 扫描范围 [Min, Max)，第一个范围没有下界（Min 为空），最后一个范围没有上界（Max 为空）
+type ScanRange struct {
+	Index int
+	Min   bson.RawValue
+	Max   bson.RawValue
+}
+
+// RangeFailure 处理失败的范围
+type RangeFailure struct {
+	Range ScanRange
+	Err   error
+}
+
+// ParallelScanError 并行扫描中失败的范围
+type ParallelScanError struct {
+	Failures []RangeFailure
+}
+
+// Error 实现 error 接口
+func (e *ParallelScanError) Error() string {
+	return fmt.Sprintf("parallel scan failed in %d range(s): %v", len(e.Failures), e.Failures[0].Err)
+}
+
+// Unwrap 返回各范围的错误
+func (e *ParallelScanError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// ParallelScan 将过滤条件匹配的文档按 Field 切分为多个范围，由固定数量的 worker 并发处理，适合千万级文档的
+// CPU 密集型迁移；范围边界通过 $sample 采样计算，不需要全表排序。
+// 单个文档的处理顺序不确定，fn 需要是并发安全的；返回处理成功的文档数，失败的范围汇总在 *ParallelScanError 中
+//
+//	processed, err := events.ParallelScan(ctx, bson.M{"version": 1}, func(ctx context.Context, doc bson.Raw) error {
+//		return migrate(ctx, doc)
+//	}, &ParallelScanOptions{Workers: 16})
+func (c *Collection) ParallelScan(ctx context.Context, filter bson.M, fn ScanFunc, opts *ParallelScanOptions) (int64, error) {
+	o := ParallelScanOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Field == "" {
+		o.Field = "_id"
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.Ranges <= 0 {
+		o.Ranges = o.Workers * 4
+	}
+	if o.SampleSize <= 0 {
+		o.SampleSize = o.Ranges * 20
+	}
+
+	ranges, err := c.ScanRanges(ctx, filter, o.Field, o.Ranges, o.SampleSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		processed atomic.Int64
+		mu        sync.Mutex
+		failures  []RangeFailure
+		wg        sync.WaitGroup
+	)
+	queue := make(chan ScanRange)
+	for i := 0; i < o.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range queue {
+				err := c.scanRange(ctx, filter, o, r, fn, &processed)
+				if err == nil {
+					continue
+				}
+				mu.Lock()
+				failures = append(failures, RangeFailure{Range: r, Err: err})
+				mu.Unlock()
+				if !o.ContinueOnError {
+					cancel()
+				}
+			}
+		}()
+	}
+	for _, r := range ranges {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- r
+	}
+	close(queue)
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Slice(failures, func(i, j int) bool { return failures[i].Range.Index < failures[j].Range.Index })
+		return processed.Load(), &ParallelScanError{Failures: failures}
+	}
+	return processed.Load(), nil
+}
+
+// ScanRanges 通过 $sample 采样计算 field 上的范围边界，返回覆盖全部文档的 n 个以内的范围
+// 采样结果中重复的边界会被合并，文档较少时返回的范围数可能小于 n
+func (c *Collection) ScanRanges(ctx context.Context, filter bson.M, field string, n, sampleSize int) ([]ScanRange, error) {
+	ctx = c.sessionContext(ctx)
+	pipeline := []bson.M{}
+	if len(filter) > 0 {
+		pipeline = append(pipeline, bson.M{"$match": filter})
+	}
+	pipeline = append(pipeline,
+		bson.M{"$sample": bson.M{"size": sampleSize}},
+		bson.M{"$project": bson.M{"_id": 0, "key": "$" + field}},
+		bson.M{"$sort": bson.M{"key": 1}},
+	)
+	cursor, err := c.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample scan ranges: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var samples []bson.RawValue
+	for cursor.Next(ctx) {
+		if key, err := cursor.Current.LookupErr("key"); err == nil {
+			samples = append(samples, bson.RawValue{Type: key.Type, Value: append([]byte(nil), key.Value...)})
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sample scan ranges: %w", err)
+	}
+	return splitScanRanges(samples, n), nil
+}
+
+// splitScanRanges 按分位数从已排序的采样值中选取边界
+func splitScanRanges(samples []bson.RawValue, n int) []ScanRange {
+	var bounds []bson.RawValue
+	for i := 1; i < n && len(samples) > 0; i++ {
+		bound := samples[i*len(samples)/n]
+		if len(bounds) > 0 && bounds[len(bounds)-1].Equal(bound) {
+			continue
+		}
+		bounds = append(bounds, bound)
+	}
+
+	ranges := make([]ScanRange, 0, len(bounds)+1)
+	var min bson.RawValue
+	for _, bound := range bounds {
+		ranges = append(ranges, ScanRange{Index: len(ranges), Min: min, Max: bound})
+		min = bound
+	}
+	return append(ranges, ScanRange{Index: len(ranges), Min: min})
+}
+
+// rangeFilter 在过滤条件上追加范围条件
+func rangeFilter(filter bson.M, field string, r ScanRange) bson.M {
+	cond := bson.M{}
+	if r.Min.Type != 0 {
+		cond["$gte"] = r.Min
+	}
+	if r.Max.Type != 0 {
+		cond["$lt"] = r.Max
+	}
+	if len(cond) == 0 {
+		if filter == nil {
+			return bson.M{}
+		}
+		return filter
+	}
+	if len(filter) == 0 {
+		return bson.M{field: cond}
+	}
+	return bson.M{"$and": []bson.M{filter, {field: cond}}}
+}
+
+// scanRange 遍历一个范围内的文档，会话不能并发使用，因此不绑定集合的会话
+func (c *Collection) scanRange(ctx context.Context, filter bson.M, o ParallelScanOptions, r ScanRange, fn ScanFunc, processed *atomic.Int64) error {
+	findOpts := options.Find()
+	if o.BatchSize > 0 {
+		findOpts.SetBatchSize(o.BatchSize)
+	}
+	if o.Projection != nil {
+		findOpts.SetProjection(o.Projection)
+	}
+	cursor, err := c.collection.Find(ctx, rangeFilter(filter, o.Field, r), findOpts)
+	if err != nil {
+		return fmt.Errorf("failed to scan range %d: %w", r.Index, err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		if err := fn(ctx, cursor.Current); err != nil {
+			return fmt.Errorf("range %d, _id %v: %w", r.Index, cursor.Current.Lookup("_id"), err)
+		}
+		processed.Add(1)
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to scan range %d: %w", r.Index, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryCheckpointStore map[string]bson.Raw
+
+func (m memoryCheckpointStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	return m[name], nil
+}
+
+func (m memoryCheckpointStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	m[name] = token
+	return nil
+}
+
+func TestAfterIDFilter(t *testing.T) {
+	assert.Equal(t, bson.M{}, afterIDFilter(nil, nil))
+	assert.Equal(t, bson.M{"a": 1}, afterIDFilter(bson.M{"a": 1}, nil))
+	assert.Equal(t, bson.M{"_id": bson.M{"$gt": 5}}, afterIDFilter(nil, 5))
+	assert.Equal(t, bson.M{"$and": []bson.M{{"a": 1}, {"_id": bson.M{"$gt": 5}}}}, afterIDFilter(bson.M{"a": 1}, 5))
+}
+
+func TestBatchCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store := memoryCheckpointStore{}
+
+	id, err := loadBatchCheckpoint(ctx, store, "job")
+	require.NoError(t, err)
+	assert.Nil(t, id)
+
+	oid := primitive.NewObjectID()
+	raw, err := bson.Marshal(bson.M{"_id": oid})
+	require.NoError(t, err)
+	require.NoError(t, saveBatchCheckpoint(ctx, store, "job", bson.Raw(raw).Lookup("_id")))
+
+	id, err = loadBatchCheckpoint(ctx, store, "job")
+	require.NoError(t, err)
+	assert.Equal(t, oid, id.(bson.RawValue).ObjectID())
+}
+
+func TestDecodeBatch(t *testing.T) {
+	raw, err := bson.Marshal(bson.M{"title": "hello"})
+	require.NoError(t, err)
+	articles, err := DecodeBatch[Article]([]bson.Raw{raw})
+	require.NoError(t, err)
+	require.Len(t, articles, 1)
+	assert.Equal(t, "hello", articles[0].Title)
+}
+
+func TestSplitScanRanges(t *testing.T) {
+	var samples []bson.RawValue
+	for i := 0; i < 10; i++ {
+		_, value, err := bson.MarshalValue(int32(i / 2 * 2))
+		require.NoError(t, err)
+		samples = append(samples, bson.RawValue{Type: bson.TypeInt32, Value: value})
+	}
+
+	ranges := splitScanRanges(samples, 5)
+	// 采样值为 0,0,2,2,4,4,6,6,8,8，分位数边界为 2,4,6,8
+	require.Len(t, ranges, 5)
+	assert.Equal(t, bsontype.Type(0), ranges[0].Min.Type)
+	assert.Equal(t, int32(2), ranges[0].Max.Int32())
+	assert.Equal(t, int32(8), ranges[4].Min.Int32())
+	assert.Equal(t, bsontype.Type(0), ranges[4].Max.Type)
+
+	// 重复的边界被合并：0,2,4,6,8
+	ranges = splitScanRanges(samples, 10)
+	assert.Len(t, ranges, 6)
+
+	ranges = splitScanRanges(nil, 4)
+	require.Len(t, ranges, 1)
+	assert.Equal(t, bson.M{"a": 1}, rangeFilter(bson.M{"a": 1}, "_id", ranges[0]))
+}
+
+func TestRangeFilter(t *testing.T) {
+	min := bson.RawValue{Type: bson.TypeInt32, Value: []byte{1, 0, 0, 0}}
+	max := bson.RawValue{Type: bson.TypeInt32, Value: []byte{9, 0, 0, 0}}
+
+	assert.Equal(t, bson.M{"sk": bson.M{"$gte": min, "$lt": max}}, rangeFilter(nil, "sk", ScanRange{Min: min, Max: max}))
+	assert.Equal(t, bson.M{"$and": []bson.M{{"a": 1}, {"sk": bson.M{"$lt": max}}}}, rangeFilter(bson.M{"a": 1}, "sk", ScanRange{Max: max}))
+}
@@ -0,0 +1,376 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CronJobFunc 定时任务函数
+type CronJobFunc func(ctx context.Context) error
+
+// 定时任务执行结果
+const (
+	CronStatusSuccess = "success"
+	CronStatusFailed  = "failed"
+)
+
+// CronJobState 定时任务状态，保存在状态集合中，多个实例共享
+type CronJobState struct {
+	Name         string     `bson:"_id" json:"name"`
+	Spec         string     `bson:"spec" json:"spec"`
+	NextRunAt    time.Time  `bson:"next_run_at" json:"next_run_at"`
+	LastRunAt    *time.Time `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastStatus   string     `bson:"last_status,omitempty" json:"last_status,omitempty"`
+	LastError    string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastDuration int64      `bson:"last_duration_ms,omitempty" json:"last_duration_ms,omitempty"`
+	UpdatedAt    time.Time  `bson:"updated_at" json:"updated_at"`
+}
+
+// CronExecution 定时任务执行记录
+type CronExecution struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Job        string             `bson:"job" json:"job"`
+	Instance   string             `bson:"instance" json:"instance"`
+	StartedAt  time.Time          `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time          `bson:"finished_at" json:"finished_at"`
+	DurationMS int64              `bson:"duration_ms" json:"duration_ms"`
+	Status     string             `bson:"status" json:"status"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// SchedulerOptions 调度器配置
+type SchedulerOptions struct {
+	// StateCollection 任务状态集合，默认 cron_jobs
+	StateCollection string
+	// HistoryCollection 执行记录集合，默认 cron_history
+	HistoryCollection string
+	// LockCollection 分布式锁集合，默认 locks
+	LockCollection string
+	// CheckInterval 检查到期任务的间隔，默认 10 秒
+	CheckInterval time.Duration
+	// LockTTL 执行任务时持有锁的时间，同时也是单次执行的超时时间，默认 5 分钟
+	LockTTL time.Duration
+	// Location 解析 cron 表达式使用的时区，默认本地时区
+	Location *time.Location
+}
+
+// cronEntry 已注册的定时任务
+type cronEntry struct {
+	name     string
+	spec     string
+	schedule *CronSchedule
+	fn       CronJobFunc
+}
+
+// Scheduler 基于 MongoDB 的定时任务调度器
+// 任务的下次执行时间保存在状态集合中，到期时通过分布式锁保证同一时刻只有一个实例执行
+type Scheduler struct {
+	client  *Client
+	opts    SchedulerOptions
+	state   *Collection
+	history *Collection
+	lock    *DistributedLock
+
+	mu      sync.RWMutex
+	entries map[string]*cronEntry
+	running map[string]bool
+	wg      sync.WaitGroup
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewScheduler 创建定时任务调度器
+func NewScheduler(client *Client, opts *SchedulerOptions) *Scheduler {
+	s := &Scheduler{
+		client:  client,
+		entries: make(map[string]*cronEntry),
+		running: make(map[string]bool),
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.StateCollection == "" {
+		s.opts.StateCollection = "cron_jobs"
+	}
+	if s.opts.HistoryCollection == "" {
+		s.opts.HistoryCollection = "cron_history"
+	}
+	if s.opts.CheckInterval <= 0 {
+		s.opts.CheckInterval = 10 * time.Second
+	}
+	if s.opts.LockTTL <= 0 {
+		s.opts.LockTTL = 5 * time.Minute
+	}
+	if s.opts.Location == nil {
+		s.opts.Location = time.Local
+	}
+
+	s.state = NewCollection(client, s.opts.StateCollection)
+	s.history = NewCollection(client, s.opts.HistoryCollection)
+	s.lock = NewDistributedLock(client, s.opts.LockCollection)
+	return s
+}
+
+// EnsureIndexes 创建执行记录和分布式锁所需的索引
+func (s *Scheduler) EnsureIndexes(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "job", Value: 1}, {Key: "started_at", Value: -1}},
+		Options: options.Index().SetName("idx_job_started_at"),
+	}
+	if _, err := s.history.collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("failed to create cron history index: %w", err)
+	}
+	return s.lock.EnsureIndexes(ctx)
+}
+
+// Register 注册命名定时任务
+func (s *Scheduler) Register(name, spec string, fn CronJobFunc) error {
+	schedule, err := ParseCron(spec, s.opts.Location)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[name]; ok {
+		return fmt.Errorf("cron job %s already registered", name)
+	}
+	s.entries[name] = &cronEntry{name: name, spec: spec, schedule: schedule, fn: fn}
+	return nil
+}
+
+// Jobs 返回所有已注册任务的状态
+func (s *Scheduler) Jobs(ctx context.Context) ([]CronJobState, error) {
+	var states []CronJobState
+	if err := s.state.Find(ctx, bson.M{"_id": bson.M{"$in": s.names()}}, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// History 查询任务的执行记录，按开始时间倒序
+func (s *Scheduler) History(ctx context.Context, name string, limit int64) ([]CronExecution, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	var executions []CronExecution
+	if err := s.history.Find(ctx, bson.M{"job": name}, &executions, opts); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start(ctx context.Context) {
+	s.startOnce.Do(func() {
+		s.client.RegisterShutdown(s)
+		go s.run(ctx)
+	})
+}
+
+// Stop 停止调度器，等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.startOnce.Do(func() {
+			close(s.doneCh)
+		})
+		<-s.doneCh
+	})
+}
+
+// run 定时检查循环
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.doneCh)
+	defer s.wg.Wait()
+
+	s.tick(ctx)
+	ticker := time.NewTicker(s.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(ctx)
+		case <-s.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// tick 检查所有任务，到期的任务在独立的 goroutine 中执行
+func (s *Scheduler) tick(ctx context.Context) {
+	now := time.Now()
+	for _, name := range s.names() {
+		s.mu.RLock()
+		entry, running := s.entries[name], s.running[name]
+		s.mu.RUnlock()
+		if running {
+			continue
+		}
+
+		state, err := s.loadState(ctx, entry, now)
+		if err != nil {
+			s.client.logger.ErrorContext(ctx, "Failed to load cron job state", "job", name, "err", err)
+			continue
+		}
+		// NextRunAt 为零值表示表达式不会再触发
+		if state.NextRunAt.IsZero() || now.Before(state.NextRunAt) {
+			continue
+		}
+
+		s.mu.Lock()
+		s.running[name] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.running, name)
+				s.mu.Unlock()
+			}()
+			s.fire(ctx, entry, state)
+		}()
+	}
+}
+
+// loadState 读取任务状态，首次运行或表达式变更时计算下次执行时间
+func (s *Scheduler) loadState(ctx context.Context, entry *cronEntry, now time.Time) (*CronJobState, error) {
+	var state CronJobState
+	err := s.state.collection.FindOne(ctx, bson.M{"_id": entry.name}).Decode(&state)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to find cron job state: %w", err)
+	}
+	if err == nil && state.Spec == entry.spec {
+		return &state, nil
+	}
+
+	next := entry.schedule.Next(now)
+	_, err = s.state.collection.UpdateOne(ctx,
+		bson.M{"_id": entry.name},
+		bson.M{"$set": bson.M{"spec": entry.spec, "next_run_at": next, "updated_at": now}},
+		options.Update().SetUpsert(true))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to save cron job state: %w", err)
+	}
+	state.Name, state.Spec, state.NextRunAt = entry.name, entry.spec, next
+	return &state, nil
+}
+
+// fire 获取锁并执行到期任务
+func (s *Scheduler) fire(ctx context.Context, entry *cronEntry, state *CronJobState) {
+	lockName := "cron:" + entry.name
+	acquired, err := s.lock.Acquire(ctx, lockName, s.opts.LockTTL)
+	if err != nil {
+		s.client.logger.ErrorContext(ctx, "Failed to acquire cron job lock", "job", entry.name, "err", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultOperationTimeout)
+		defer cancel()
+		if err := s.lock.Release(releaseCtx, lockName); err != nil {
+			s.client.logger.ErrorContext(ctx, "Failed to release cron job lock", "job", entry.name, "err", err)
+		}
+	}()
+
+	// 以下次执行时间作为版本号推进状态，其他实例在本实例之前已经执行过时放弃本次执行
+	startedAt := time.Now()
+	result, err := s.state.collection.UpdateOne(ctx,
+		bson.M{"_id": entry.name, "next_run_at": state.NextRunAt},
+		bson.M{"$set": bson.M{
+			"next_run_at": entry.schedule.Next(startedAt),
+			"last_run_at": startedAt,
+			"updated_at":  startedAt,
+		}})
+	if err != nil {
+		s.client.logger.ErrorContext(ctx, "Failed to advance cron job state", "job", entry.name, "err", err)
+		return
+	}
+	if result.MatchedCount == 0 {
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, s.opts.LockTTL)
+	runErr := runCronJob(jobCtx, entry.fn)
+	cancel()
+	finishedAt := time.Now()
+
+	execution := CronExecution{
+		Job:        entry.name,
+		Instance:   s.lock.Owner(),
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		DurationMS: finishedAt.Sub(startedAt).Milliseconds(),
+		Status:     CronStatusSuccess,
+	}
+	if runErr != nil {
+		execution.Status = CronStatusFailed
+		execution.Error = runErr.Error()
+		s.client.logger.WarnContext(ctx, "Cron job failed", "job", entry.name, "err", runErr)
+	}
+	s.record(ctx, execution)
+}
+
+// record 写入执行记录并更新任务最近一次执行结果
+func (s *Scheduler) record(ctx context.Context, execution CronExecution) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultOperationTimeout)
+	defer cancel()
+
+	if _, err := s.history.collection.InsertOne(ctx, execution); err != nil {
+		s.client.logger.ErrorContext(ctx, "Failed to record cron job execution", "job", execution.Job, "err", err)
+	}
+	_, err := s.state.collection.UpdateOne(ctx,
+		bson.M{"_id": execution.Job},
+		bson.M{"$set": bson.M{
+			"last_status":      execution.Status,
+			"last_error":       execution.Error,
+			"last_duration_ms": execution.DurationMS,
+			"updated_at":       execution.FinishedAt,
+		}})
+	if err != nil {
+		s.client.logger.ErrorContext(ctx, "Failed to update cron job state", "job", execution.Job, "err", err)
+	}
+}
+
+// names 返回已注册任务名称
+func (s *Scheduler) names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runCronJob 执行任务函数，将 panic 转换为错误
+func runCronJob(ctx context.Context, fn CronJobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cron job panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,33 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSchedulerSkipsJobsThatNeverFire(t *testing.T) {
+	server := newFakeServer(t, false)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "find" {
+			// 修复前保存的状态：表达式不会再触发时 next_run_at 为零值
+			return fakeCursor(cmd, bson.M{"_id": "report", "spec": "0 0 1 1 *", "next_run_at": time.Time{}})
+		}
+		return nil
+	})
+	scheduler := NewScheduler(server.client(t), &SchedulerOptions{Location: time.UTC})
+	fired := false
+	require.NoError(t, scheduler.Register("report", "0 0 1 1 *", func(ctx context.Context) error {
+		fired = true
+		return nil
+	}))
+
+	scheduler.tick(t.Context())
+	scheduler.wg.Wait()
+	assert.False(t, fired)
+	assert.Len(t, server.Commands(), 1, "only the state is read, the lock is never acquired")
+}
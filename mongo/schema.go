@@ -0,0 +1,374 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ValidationLevel 校验级别
+type ValidationLevel string
+
+const (
+	// ValidationLevelOff 关闭校验
+	ValidationLevelOff ValidationLevel = "off"
+	// ValidationLevelStrict 对所有插入和更新进行校验
+	ValidationLevelStrict ValidationLevel = "strict"
+	// ValidationLevelModerate 只校验已经满足规则的文档，历史不合规文档更新时不校验
+	ValidationLevelModerate ValidationLevel = "moderate"
+)
+
+// ValidationAction 校验失败时的处理方式
+type ValidationAction string
+
+const (
+	// ValidationActionError 拒绝不合规的写入
+	ValidationActionError ValidationAction = "error"
+	// ValidationActionWarn 允许写入，只在服务端日志中告警
+	ValidationActionWarn ValidationAction = "warn"
+)
+
+// ValidationInfo 集合当前的校验配置
+type ValidationInfo struct {
+	Collection string           `json:"collection"`
+	Validator  bson.M           `json:"validator,omitempty"`
+	Level      ValidationLevel  `json:"level"`
+	Action     ValidationAction `json:"action"`
+}
+
+// SchemaManager 集合 $jsonSchema 校验管理器
+type SchemaManager struct {
+	client *Client
+}
+
+// NewSchemaManager 创建新的校验管理器
+func NewSchemaManager(client *Client) *SchemaManager {
+	return &SchemaManager{
+		client: client,
+	}
+}
+
+// ApplySchema 为集合设置 $jsonSchema 校验规则
+// 集合已存在时通过 collMod 修改，不存在时带校验规则创建集合
+func (sm *SchemaManager) ApplySchema(ctx context.Context, collectionName string, schema bson.M, level ValidationLevel, action ValidationAction) error {
+	if err := sm.client.checkWritable("collMod", collectionName); err != nil {
+		return err
+	}
+	if level == "" {
+		level = ValidationLevelStrict
+	}
+	if action == "" {
+		action = ValidationActionError
+	}
+	validator := bson.M{"$jsonSchema": schema}
+
+	exists, err := sm.collectionExists(ctx, collectionName)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		opts := options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel(string(level)).
+			SetValidationAction(string(action))
+		if err := sm.client.GetDatabase().CreateCollection(ctx, collectionName, opts); err != nil {
+			return fmt.Errorf("failed to create collection %s with validator: %w", collectionName, err)
+		}
+		return nil
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: collectionName},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: string(level)},
+		{Key: "validationAction", Value: string(action)},
+	}
+	if err := sm.client.GetDatabase().RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to apply validator to %s: %w", collectionName, err)
+	}
+	return nil
+}
+
+// ApplySchemaFromStruct 根据结构体的 bson 标签生成 $jsonSchema 并应用到集合
+func (sm *SchemaManager) ApplySchemaFromStruct(ctx context.Context, collectionName string, model interface{}, level ValidationLevel, action ValidationAction) error {
+	schema, err := GenerateJSONSchema(model)
+	if err != nil {
+		return err
+	}
+	return sm.ApplySchema(ctx, collectionName, schema, level, action)
+}
+
+// RemoveSchema 移除集合的校验规则
+func (sm *SchemaManager) RemoveSchema(ctx context.Context, collectionName string) error {
+	if err := sm.client.checkWritable("collMod", collectionName); err != nil {
+		return err
+	}
+	cmd := bson.D{
+		{Key: "collMod", Value: collectionName},
+		{Key: "validator", Value: bson.M{}},
+		{Key: "validationLevel", Value: string(ValidationLevelOff)},
+	}
+	if err := sm.client.GetDatabase().RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to remove validator from %s: %w", collectionName, err)
+	}
+	return nil
+}
+
+// GetValidation 获取集合当前的校验规则、级别和处理方式
+func (sm *SchemaManager) GetValidation(ctx context.Context, collectionName string) (*ValidationInfo, error) {
+	cursor, err := sm.client.GetDatabase().ListCollections(ctx, bson.M{"name": collectionName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var specs []struct {
+		Name    string `bson:"name"`
+		Options struct {
+			Validator        bson.M `bson:"validator"`
+			ValidationLevel  string `bson:"validationLevel"`
+			ValidationAction string `bson:"validationAction"`
+		} `bson:"options"`
+	}
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, fmt.Errorf("failed to decode collection specs: %w", err)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("collection %s not found", collectionName)
+	}
+
+	spec := specs[0]
+	info := &ValidationInfo{
+		Collection: spec.Name,
+		Validator:  spec.Options.Validator,
+		Level:      ValidationLevel(spec.Options.ValidationLevel),
+		Action:     ValidationAction(spec.Options.ValidationAction),
+	}
+	// 未显式设置时服务端使用 strict/error
+	if info.Level == "" {
+		info.Level = ValidationLevelStrict
+	}
+	if info.Action == "" {
+		info.Action = ValidationActionError
+	}
+	return info, nil
+}
+
+// collectionExists 检查集合是否存在
+func (sm *SchemaManager) collectionExists(ctx context.Context, collectionName string) (bool, error) {
+	names, err := sm.client.GetDatabase().ListCollectionNames(ctx, bson.M{"name": collectionName})
+	if err != nil {
+		return false, fmt.Errorf("failed to list collection names: %w", err)
+	}
+	return len(names) > 0, nil
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	decimalType  = reflect.TypeOf(primitive.Decimal128{})
+	dateTimeType = reflect.TypeOf(primitive.DateTime(0))
+)
+
+// GenerateJSONSchema 根据结构体的 bson 标签生成 $jsonSchema
+// 未标记 omitempty 的非指针字段视为必填字段；
+// 可通过 schema 标签补充约束，例如 `schema:"enum=draft|published|archived"`、
+// `schema:"minLength=3,maxLength=32"`、`schema:"minimum=0"`、`schema:"description=用户名"`
+func GenerateJSONSchema(model interface{}) (bson.M, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct, got %T", model)
+	}
+	return structSchema(t)
+}
+
+// structSchema 生成结构体对应的 object 规则
+func structSchema(t reflect.Type) (bson.M, error) {
+	properties := bson.M{}
+	var required []string
+
+	if err := collectStructProperties(t, properties, &required); err != nil {
+		return nil, err
+	}
+
+	schema := bson.M{
+		"bsonType":   "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// collectStructProperties 收集结构体字段规则，inline 字段展开到当前层级
+func collectStructProperties(t reflect.Type, properties bson.M, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		bsonTag := field.Tag.Get("bson")
+		if bsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(bsonTag, ",")
+		name := tagParts[0]
+		omitEmpty := contains(tagParts[1:], "omitempty")
+		inline := contains(tagParts[1:], "inline")
+
+		if inline {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := collectStructProperties(ft, properties, required); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		prop, err := typeSchema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if err := applySchemaTag(prop, field.Tag.Get("schema")); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		properties[name] = prop
+
+		if !omitEmpty && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// typeSchema 生成 Go 类型对应的规则
+func typeSchema(t reflect.Type) (bson.M, error) {
+	switch t {
+	case timeType, dateTimeType:
+		return bson.M{"bsonType": "date"}, nil
+	case objectIDType:
+		return bson.M{"bsonType": "objectId"}, nil
+	case decimalType:
+		return bson.M{"bsonType": "decimal"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		prop, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		prop["bsonType"] = appendBSONType(prop["bsonType"], "null")
+		return prop, nil
+	case reflect.String:
+		return bson.M{"bsonType": "string"}, nil
+	case reflect.Bool:
+		return bson.M{"bsonType": "bool"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return bson.M{"bsonType": "int"}, nil
+	case reflect.Int, reflect.Uint, reflect.Uint32:
+		// int 在取值范围允许时编码为 int32，否则为 int64
+		return bson.M{"bsonType": bson.A{"int", "long"}}, nil
+	case reflect.Int64, reflect.Uint64:
+		return bson.M{"bsonType": "long"}, nil
+	case reflect.Float32, reflect.Float64:
+		return bson.M{"bsonType": "double"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return bson.M{"bsonType": "binData"}, nil
+		}
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		prop := bson.M{"bsonType": "array", "items": items}
+		if t.Kind() == reflect.Slice {
+			// nil 切片编码为 null
+			prop["bsonType"] = bson.A{"array", "null"}
+		}
+		return prop, nil
+	case reflect.Map:
+		// nil map 编码为 null
+		return bson.M{"bsonType": bson.A{"object", "null"}}, nil
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Interface:
+		return bson.M{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// appendBSONType 为 bsonType 追加类型
+func appendBSONType(current interface{}, extra string) bson.A {
+	switch v := current.(type) {
+	case string:
+		return bson.A{v, extra}
+	case bson.A:
+		return append(v, extra)
+	default:
+		return bson.A{extra}
+	}
+}
+
+// applySchemaTag 解析 schema 标签中的附加约束
+func applySchemaTag(prop bson.M, tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid schema tag %q", part)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "enum":
+			values := bson.A{}
+			for _, v := range strings.Split(value, "|") {
+				values = append(values, v)
+			}
+			prop["enum"] = values
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			prop[key] = n
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			prop[key] = n
+		case "pattern", "description":
+			prop[key] = value
+		default:
+			return fmt.Errorf("unsupported schema constraint %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package mongo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTypeSchema(t *testing.T) {
+	type address struct {
+		City string `bson:"city"`
+	}
+	tests := []struct {
+		value    interface{}
+		expected bson.M
+	}{
+		{"", bson.M{"bsonType": "string"}},
+		{true, bson.M{"bsonType": "bool"}},
+		{int8(0), bson.M{"bsonType": "int"}},
+		{int16(0), bson.M{"bsonType": "int"}},
+		{int32(0), bson.M{"bsonType": "int"}},
+		{uint8(0), bson.M{"bsonType": "int"}},
+		{uint16(0), bson.M{"bsonType": "int"}},
+		{0, bson.M{"bsonType": bson.A{"int", "long"}}},
+		{uint(0), bson.M{"bsonType": bson.A{"int", "long"}}},
+		{uint32(0), bson.M{"bsonType": bson.A{"int", "long"}}},
+		{int64(0), bson.M{"bsonType": "long"}},
+		{uint64(0), bson.M{"bsonType": "long"}},
+		{float32(0), bson.M{"bsonType": "double"}},
+		{float64(0), bson.M{"bsonType": "double"}},
+		{time.Time{}, bson.M{"bsonType": "date"}},
+		{primitive.DateTime(0), bson.M{"bsonType": "date"}},
+		{primitive.ObjectID{}, bson.M{"bsonType": "objectId"}},
+		{primitive.Decimal128{}, bson.M{"bsonType": "decimal"}},
+		{[]byte{}, bson.M{"bsonType": "binData"}},
+		{[]string{}, bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}}},
+		{[2]int64{}, bson.M{"bsonType": "array", "items": bson.M{"bsonType": "long"}}},
+		{map[string]int{}, bson.M{"bsonType": bson.A{"object", "null"}}},
+		{new(string), bson.M{"bsonType": bson.A{"string", "null"}}},
+		{new(int), bson.M{"bsonType": bson.A{"int", "long", "null"}}},
+		{new(time.Time), bson.M{"bsonType": bson.A{"date", "null"}}},
+		{address{}, bson.M{"bsonType": "object", "properties": bson.M{"city": bson.M{"bsonType": "string"}}, "required": []string{"city"}}},
+	}
+	for _, tt := range tests {
+		prop, err := typeSchema(reflect.TypeOf(tt.value))
+		require.NoError(t, err, "%T", tt.value)
+		assert.Equal(t, tt.expected, prop, "%T", tt.value)
+	}
+
+	var value interface{}
+	prop, err := typeSchema(reflect.TypeOf(&value).Elem())
+	require.NoError(t, err)
+	assert.Empty(t, prop)
+
+	_, err = typeSchema(reflect.TypeOf(make(chan int)))
+	assert.Error(t, err)
+	_, err = typeSchema(reflect.TypeOf(func() {}))
+	assert.Error(t, err)
+}
+
+func TestApplySchemaTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected bson.M
+	}{
+		{"", bson.M{}},
+		{"enum=draft|published|archived", bson.M{"enum": bson.A{"draft", "published", "archived"}}},
+		{"minLength=3, maxLength=32", bson.M{"minLength": int64(3), "maxLength": int64(32)}},
+		{"minItems=1,maxItems=10", bson.M{"minItems": int64(1), "maxItems": int64(10)}},
+		{"minimum=0,maximum=99.5", bson.M{"minimum": float64(0), "maximum": 99.5}},
+		{"pattern=^[a-z]+$", bson.M{"pattern": "^[a-z]+$"}},
+		{"description=用户名", bson.M{"description": "用户名"}},
+	}
+	for _, tt := range tests {
+		prop := bson.M{}
+		require.NoError(t, applySchemaTag(prop, tt.tag), tt.tag)
+		assert.Equal(t, tt.expected, prop, tt.tag)
+	}
+
+	for _, tag := range []string{"minLength", "minLength=abc", "maximum=high", "format=email"} {
+		assert.Error(t, applySchemaTag(bson.M{}, tag), tag)
+	}
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	type profile struct {
+		Bio string `bson:"bio,omitempty" schema:"maxLength=200"`
+	}
+	type user struct {
+		BaseDocument `bson:",inline"`
+		Name         string     `bson:"name" schema:"minLength=3"`
+		Email        string     `bson:"email,omitempty"`
+		Age          int        `bson:"age" schema:"minimum=0"`
+		Status       string     `bson:"status" schema:"enum=active|disabled"`
+		DeletedAt    *time.Time `bson:"deleted_at"`
+		Profile      profile    `bson:"profile"`
+		Secret       string     `bson:"-"`
+		Nickname     string
+		internal     string
+	}
+
+	schema, err := GenerateJSONSchema(&user{internal: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "object", schema["bsonType"])
+	properties := schema["properties"].(bson.M)
+
+	// inline 字段展开到当前层级
+	assert.Equal(t, bson.M{"bsonType": "objectId"}, properties["_id"])
+	assert.Equal(t, bson.M{"bsonType": "date"}, properties["created_at"])
+	assert.Equal(t, bson.M{"bsonType": "string", "minLength": int64(3)}, properties["name"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"int", "long"}, "minimum": float64(0)}, properties["age"])
+	assert.Equal(t, bson.M{"bsonType": "string", "enum": bson.A{"active", "disabled"}}, properties["status"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"date", "null"}}, properties["deleted_at"])
+	assert.Equal(t, bson.M{
+		"bsonType":   "object",
+		"properties": bson.M{"bio": bson.M{"bsonType": "string", "maxLength": int64(200)}},
+	}, properties["profile"])
+	assert.Contains(t, properties, "nickname", "fields without a bson name use the lowercased field name")
+	assert.NotContains(t, properties, "secret")
+	assert.NotContains(t, properties, "internal")
+
+	// omitempty 字段和指针字段不是必填字段
+	required := schema["required"].([]string)
+	assert.Subset(t, required, []string{"name", "age", "status", "profile", "nickname"})
+	assert.NotContains(t, required, "email")
+	assert.NotContains(t, required, "deleted_at")
+
+	_, err = GenerateJSONSchema("not a struct")
+	assert.Error(t, err)
+	_, err = GenerateJSONSchema(nil)
+	assert.Error(t, err)
+	_, err = GenerateJSONSchema(struct {
+		Tags []string `bson:"tags" schema:"unknown=1"`
+	}{})
+	assert.Error(t, err)
+}
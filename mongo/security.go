@@ -0,0 +1,214 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Role 角色，DB 为空时使用客户端的数据库
+type Role struct {
+	Role string `bson:"role" json:"role"`
+	DB   string `bson:"db" json:"db"`
+}
+
+// ReadRole 只读角色
+func ReadRole(db string) Role {
+	return Role{Role: "read", DB: db}
+}
+
+// ReadWriteRole 读写角色，应用账号通常只需要对自己的数据库授予该角色
+func ReadWriteRole(db string) Role {
+	return Role{Role: "readWrite", DB: db}
+}
+
+// UserOptions 创建或修改用户的选项
+type UserOptions struct {
+	// Password 密码，修改用户时为空表示不修改
+	Password string
+	// Roles 角色；修改用户时为 nil 表示不修改，非 nil 时替换全部角色
+	Roles []Role
+	// CustomData 用户的附加信息，例如负责人、用途
+	CustomData bson.M
+	// Mechanisms SCRAM 机制，默认由服务端决定（SCRAM-SHA-1 和 SCRAM-SHA-256）
+	Mechanisms []string
+}
+
+// UserInfo usersInfo 返回的用户信息，不包含凭据
+type UserInfo struct {
+	User       string   `bson:"user" json:"user"`
+	DB         string   `bson:"db" json:"db"`
+	Roles      []Role   `bson:"roles" json:"roles"`
+	CustomData bson.M   `bson:"customData,omitempty" json:"custom_data,omitempty"`
+	Mechanisms []string `bson:"mechanisms" json:"mechanisms"`
+}
+
+// RoleInfo rolesInfo 返回的角色信息
+type RoleInfo struct {
+	Role           string `bson:"role" json:"role"`
+	DB             string `bson:"db" json:"db"`
+	IsBuiltin      bool   `bson:"isBuiltin" json:"is_builtin"`
+	Roles          []Role `bson:"roles" json:"roles"`
+	InheritedRoles []Role `bson:"inheritedRoles" json:"inherited_roles"`
+}
+
+// SecurityAdmin 用户与角色管理，用户创建在客户端的数据库中（即该用户的认证数据库）
+// 执行账号需要 userAdmin 或 userAdminAnyDatabase 角色；密码只通过命令发送，不会写入日志
+//
+//	security := NewSecurityAdmin(client)
+//	err := security.CreateUser(ctx, "orders-api", &UserOptions{
+//		Password: os.Getenv("ORDERS_DB_PASSWORD"),
+//		Roles:    []Role{ReadWriteRole("orders"), ReadRole("catalog")},
+//	})
+type SecurityAdmin struct {
+	client *Client
+}
+
+// NewSecurityAdmin 创建用户与角色管理
+func NewSecurityAdmin(client *Client) *SecurityAdmin {
+	return &SecurityAdmin{client: client}
+}
+
+// CreateUser 创建用户，必须设置密码和至少一个角色
+func (s *SecurityAdmin) CreateUser(ctx context.Context, name string, opts *UserOptions) error {
+	if opts == nil || opts.Password == "" {
+		return fmt.Errorf("password is required to create user %s", name)
+	}
+	if len(opts.Roles) == 0 {
+		return fmt.Errorf("at least one role is required to create user %s", name)
+	}
+	command := append(bson.D{{Key: "createUser", Value: name}}, s.userFields(opts)...)
+	if err := s.run(ctx, "createUser", command); err != nil {
+		return fmt.Errorf("failed to create user %s: %w", name, err)
+	}
+	s.client.logger.InfoContext(ctx, "Created user", "user", name, "roles", s.roles(opts.Roles))
+	return nil
+}
+
+// UpdateUser 修改用户的密码、角色或附加信息，未设置的字段保持不变
+func (s *SecurityAdmin) UpdateUser(ctx context.Context, name string, opts *UserOptions) error {
+	if opts == nil {
+		return nil
+	}
+	fields := s.userFields(opts)
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := s.run(ctx, "updateUser", append(bson.D{{Key: "updateUser", Value: name}}, fields...)); err != nil {
+		return fmt.Errorf("failed to update user %s: %w", name, err)
+	}
+	s.client.logger.InfoContext(ctx, "Updated user", "user", name, "password_changed", opts.Password != "")
+	return nil
+}
+
+// DropUser 删除用户
+func (s *SecurityAdmin) DropUser(ctx context.Context, name string) error {
+	if err := s.run(ctx, "dropUser", bson.D{{Key: "dropUser", Value: name}}); err != nil {
+		return fmt.Errorf("failed to drop user %s: %w", name, err)
+	}
+	s.client.logger.InfoContext(ctx, "Dropped user", "user", name)
+	return nil
+}
+
+// GrantRoles 为用户追加角色
+func (s *SecurityAdmin) GrantRoles(ctx context.Context, name string, roles ...Role) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	command := bson.D{{Key: "grantRolesToUser", Value: name}, {Key: "roles", Value: s.roles(roles)}}
+	if err := s.run(ctx, "grantRolesToUser", command); err != nil {
+		return fmt.Errorf("failed to grant roles to user %s: %w", name, err)
+	}
+	s.client.logger.InfoContext(ctx, "Granted roles", "user", name, "roles", s.roles(roles))
+	return nil
+}
+
+// RevokeRoles 撤销用户的角色
+func (s *SecurityAdmin) RevokeRoles(ctx context.Context, name string, roles ...Role) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	command := bson.D{{Key: "revokeRolesFromUser", Value: name}, {Key: "roles", Value: s.roles(roles)}}
+	if err := s.run(ctx, "revokeRolesFromUser", command); err != nil {
+		return fmt.Errorf("failed to revoke roles from user %s: %w", name, err)
+	}
+	s.client.logger.InfoContext(ctx, "Revoked roles", "user", name, "roles", s.roles(roles))
+	return nil
+}
+
+// GetUser 返回用户信息，用户不存在时返回 nil
+func (s *SecurityAdmin) GetUser(ctx context.Context, name string) (*UserInfo, error) {
+	users, err := s.usersInfo(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return &users[0], nil
+}
+
+// ListUsers 列出当前数据库中的用户
+func (s *SecurityAdmin) ListUsers(ctx context.Context) ([]UserInfo, error) {
+	return s.usersInfo(ctx, 1)
+}
+
+func (s *SecurityAdmin) usersInfo(ctx context.Context, filter interface{}) ([]UserInfo, error) {
+	var result struct {
+		Users []UserInfo `bson:"users"`
+	}
+	if err := s.client.GetDatabase().RunCommand(ctx, bson.D{{Key: "usersInfo", Value: filter}}).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return result.Users, nil
+}
+
+// ListRoles 列出当前数据库中的角色，includeBuiltin 时同时返回 read、readWrite 等内置角色
+func (s *SecurityAdmin) ListRoles(ctx context.Context, includeBuiltin bool) ([]RoleInfo, error) {
+	var result struct {
+		Roles []RoleInfo `bson:"roles"`
+	}
+	command := bson.D{{Key: "rolesInfo", Value: 1}, {Key: "showBuiltinRoles", Value: includeBuiltin}}
+	if err := s.client.GetDatabase().RunCommand(ctx, command).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return result.Roles, nil
+}
+
+// userFields createUser、updateUser 共用的字段，未设置的选项不出现在命令中
+func (s *SecurityAdmin) userFields(opts *UserOptions) bson.D {
+	var fields bson.D
+	if opts.Password != "" {
+		fields = append(fields, bson.E{Key: "pwd", Value: opts.Password})
+	}
+	if opts.Roles != nil {
+		fields = append(fields, bson.E{Key: "roles", Value: s.roles(opts.Roles)})
+	}
+	if opts.CustomData != nil {
+		fields = append(fields, bson.E{Key: "customData", Value: opts.CustomData})
+	}
+	if len(opts.Mechanisms) > 0 {
+		fields = append(fields, bson.E{Key: "mechanisms", Value: opts.Mechanisms})
+	}
+	return fields
+}
+
+// roles 填充默认数据库
+func (s *SecurityAdmin) roles(roles []Role) []Role {
+	result := make([]Role, len(roles))
+	for i, role := range roles {
+		if role.DB == "" {
+			role.DB = s.client.GetDatabaseName()
+		}
+		result[i] = role
+	}
+	return result
+}
+
+func (s *SecurityAdmin) run(ctx context.Context, operation string, command bson.D) error {
+	if err := s.client.checkWritable(operation, "system.users"); err != nil {
+		return err
+	}
+	return s.client.GetDatabase().RunCommand(ctx, command).Err()
+}
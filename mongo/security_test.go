@@ -0,0 +1,46 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSecurityAdminUserFields(t *testing.T) {
+	security := NewSecurityAdmin(newLazyClient(t))
+
+	fields := security.userFields(&UserOptions{
+		Password:   "s3cret",
+		Roles:      []Role{{Role: "readWrite"}, ReadRole("catalog")},
+		CustomData: bson.M{"owner": "orders-team"},
+	})
+	assert.Equal(t, bson.D{
+		{Key: "pwd", Value: "s3cret"},
+		{Key: "roles", Value: []Role{{Role: "readWrite", DB: "test"}, {Role: "read", DB: "catalog"}}},
+		{Key: "customData", Value: bson.M{"owner": "orders-team"}},
+	}, fields)
+
+	assert.Empty(t, security.userFields(&UserOptions{}), "unset options are not sent")
+	assert.Equal(t, bson.D{{Key: "roles", Value: []Role{}}}, security.userFields(&UserOptions{Roles: []Role{}}), "empty roles remove all roles")
+}
+
+func TestSecurityAdminValidation(t *testing.T) {
+	ctx := context.Background()
+	client := newLazyClient(t)
+	security := NewSecurityAdmin(client)
+
+	assert.ErrorContains(t, security.CreateUser(ctx, "app", nil), "password is required")
+	assert.ErrorContains(t, security.CreateUser(ctx, "app", &UserOptions{Password: "x"}), "at least one role")
+	assert.NoError(t, security.UpdateUser(ctx, "app", &UserOptions{}))
+	assert.NoError(t, security.GrantRoles(ctx, "app"))
+
+	client.readOnly = true
+	err := security.DropUser(ctx, "app")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	var readOnly *ReadOnlyError
+	require.ErrorAs(t, err, &readOnly)
+	assert.Equal(t, "dropUser", readOnly.Operation)
+}
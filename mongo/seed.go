@@ -0,0 +1,234 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSeedLocked 其他实例正在写入初始化数据
+var ErrSeedLocked = errors.New("seeding is locked by another process")
+
+// SeedData 写入一个集合的初始化数据
+type SeedData struct {
+	Collection string
+	// Keys 判断文档是否已存在的字段，默认 _id；文档必须包含这些字段
+	Keys      []string
+	Documents []interface{}
+}
+
+// SeedSet 一组具名的初始化数据，例如初始分类、管理员账号
+type SeedSet struct {
+	Name        string
+	Description string
+	// Environments 只在这些环境中写入，为空时所有环境都写入
+	Environments []string
+	Data         []SeedData
+	// Apply 自定义写入，在 Data 之后执行，例如需要计算密码哈希的账号；中断后会重新执行，需要可重复执行
+	Apply func(ctx context.Context, client *Client) error
+}
+
+// SeederOptions 初始化数据选项
+type SeederOptions struct {
+	// Environment 当前环境，例如 development、staging、production，默认 default
+	Environment string
+	// Collection 记录已写入数据的集合，默认 seed_history
+	Collection string
+	// LockTTL 锁的过期时间，默认 5 分钟
+	LockTTL time.Duration
+}
+
+// SeedStatus 初始化数据在当前环境的写入状态
+type SeedStatus struct {
+	Name        string
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+	// Skipped 不在当前环境写入
+	Skipped bool
+}
+
+// seedRecord 已写入的初始化数据记录
+type seedRecord struct {
+	ID          string    `bson:"_id"`
+	Name        string    `bson:"name"`
+	Environment string    `bson:"environment"`
+	Documents   int       `bson:"documents"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Seeder 每个环境只写入一次初始化数据，已写入的数据集记录在集合中；
+// 文档按 Keys upsert，写入中断后重新执行不会产生重复文档，Run 期间持有分布式锁
+//
+//	seeder, err := NewSeeder(client, []SeedSet{
+//		{Name: "categories", Data: []SeedData{{Collection: "categories", Keys: []string{"slug"}, Documents: categories}}},
+//		{Name: "admin-user", Environments: []string{"development"}, Apply: createAdmin},
+//	}, &SeederOptions{Environment: os.Getenv("APP_ENV")})
+//	applied, err := seeder.Run(ctx)
+type Seeder struct {
+	client      *Client
+	records     *Collection
+	lock        *DistributedLock
+	lockTTL     time.Duration
+	environment string
+	sets        []SeedSet
+}
+
+// NewSeeder 创建初始化数据执行器，按 sets 的顺序写入，名称重复或内容为空时返回错误
+func NewSeeder(client *Client, sets []SeedSet, opts *SeederOptions) (*Seeder, error) {
+	o := SeederOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Environment == "" {
+		o.Environment = "default"
+	}
+	if o.Collection == "" {
+		o.Collection = "seed_history"
+	}
+	if o.LockTTL <= 0 {
+		o.LockTTL = 5 * time.Minute
+	}
+
+	names := make(map[string]bool, len(sets))
+	for _, set := range sets {
+		if set.Name == "" || (len(set.Data) == 0 && set.Apply == nil) {
+			return nil, fmt.Errorf("invalid seed set %q: name and data or Apply are required", set.Name)
+		}
+		if names[set.Name] {
+			return nil, fmt.Errorf("duplicate seed set %s", set.Name)
+		}
+		names[set.Name] = true
+		for _, data := range set.Data {
+			if data.Collection == "" {
+				return nil, fmt.Errorf("invalid seed set %s: collection is required", set.Name)
+			}
+		}
+	}
+
+	return &Seeder{
+		client:      client,
+		records:     NewCollection(client, o.Collection),
+		lock:        NewDistributedLock(client, o.Collection+"_lock"),
+		lockTTL:     o.LockTTL,
+		environment: o.Environment,
+		sets:        sets,
+	}, nil
+}
+
+// Status 返回所有数据集在当前环境的写入状态
+func (s *Seeder) Status(ctx context.Context) ([]SeedStatus, error) {
+	applied, err := s.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]SeedStatus, 0, len(s.sets))
+	for _, set := range s.sets {
+		status := SeedStatus{Name: set.Name, Description: set.Description, Skipped: !s.included(set)}
+		if record, ok := applied[set.Name]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Run 写入当前环境中尚未写入的数据集，某个数据集失败时停止，返回已经成功写入的名称
+func (s *Seeder) Run(ctx context.Context) ([]string, error) {
+	var done []string
+	err := s.withLock(ctx, func() error {
+		applied, err := s.applied(ctx)
+		if err != nil {
+			return err
+		}
+		for _, set := range s.sets {
+			if _, ok := applied[set.Name]; ok || !s.included(set) {
+				continue
+			}
+			if err := s.apply(ctx, set); err != nil {
+				return err
+			}
+			done = append(done, set.Name)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Reapply 重新写入已写入过的数据集，例如修正了初始数据后；已存在的文档被整体替换
+func (s *Seeder) Reapply(ctx context.Context, name string) error {
+	index := slices.IndexFunc(s.sets, func(set SeedSet) bool { return set.Name == name })
+	if index < 0 {
+		return fmt.Errorf("seed set %s not found", name)
+	}
+	return s.withLock(ctx, func() error {
+		return s.apply(ctx, s.sets[index])
+	})
+}
+
+func (s *Seeder) apply(ctx context.Context, set SeedSet) error {
+	count := 0
+	for _, data := range set.Data {
+		if len(data.Documents) == 0 {
+			continue
+		}
+		keys := data.Keys
+		if len(keys) == 0 {
+			keys = []string{"_id"}
+		}
+		if _, err := NewCollection(s.client, data.Collection).UpsertMany(ctx, data.Documents, keys...); err != nil {
+			return fmt.Errorf("seed set %s failed on %s: %w", set.Name, data.Collection, err)
+		}
+		count += len(data.Documents)
+	}
+	if set.Apply != nil {
+		if err := set.Apply(ctx, s.client); err != nil {
+			return fmt.Errorf("seed set %s failed: %w", set.Name, err)
+		}
+	}
+
+	_, err := s.records.collection.UpdateOne(ctx,
+		bson.M{"_id": s.environment + "/" + set.Name},
+		bson.M{"$set": bson.M{"name": set.Name, "environment": s.environment, "documents": count, "applied_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to record seed set %s: %w", set.Name, err)
+	}
+	s.client.logger.InfoContext(ctx, "Applied seed set", "seed", set.Name, "environment", s.environment, "documents", count)
+	return nil
+}
+
+func (s *Seeder) included(set SeedSet) bool {
+	return len(set.Environments) == 0 || slices.Contains(set.Environments, s.environment)
+}
+
+func (s *Seeder) applied(ctx context.Context) (map[string]seedRecord, error) {
+	var records []seedRecord
+	if err := s.records.Find(ctx, bson.M{"environment": s.environment}, &records); err != nil {
+		return nil, fmt.Errorf("failed to load applied seed sets: %w", err)
+	}
+	applied := make(map[string]seedRecord, len(records))
+	for _, record := range records {
+		applied[record.Name] = record
+	}
+	return applied, nil
+}
+
+func (s *Seeder) withLock(ctx context.Context, fn func() error) error {
+	const name = "seed"
+	ok, err := s.lock.Acquire(ctx, name, s.lockTTL)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSeedLocked
+	}
+	defer s.lock.Release(context.WithoutCancel(ctx), name)
+	return fn()
+}
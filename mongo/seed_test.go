@@ -0,0 +1,34 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNewSeederValidation(t *testing.T) {
+	client := newLazyClient(t)
+	apply := func(ctx context.Context, client *Client) error { return nil }
+	categories := SeedData{Collection: "categories", Keys: []string{"slug"}, Documents: []interface{}{bson.M{"slug": "books"}}}
+
+	seeder, err := NewSeeder(client, []SeedSet{
+		{Name: "categories", Data: []SeedData{categories}},
+		{Name: "admin-user", Environments: []string{"development"}, Apply: apply},
+	}, &SeederOptions{Environment: "production"})
+	require.NoError(t, err)
+	assert.Equal(t, "seed_history", seeder.records.collection.Name())
+	assert.True(t, seeder.included(seeder.sets[0]))
+	assert.False(t, seeder.included(seeder.sets[1]))
+
+	_, err = NewSeeder(client, []SeedSet{{Name: "a", Apply: apply}, {Name: "a", Apply: apply}}, nil)
+	assert.ErrorContains(t, err, "duplicate seed set a")
+	_, err = NewSeeder(client, []SeedSet{{Name: "empty"}}, nil)
+	assert.Error(t, err)
+	_, err = NewSeeder(client, []SeedSet{{Name: "a", Data: []SeedData{{Documents: categories.Documents}}}}, nil)
+	assert.ErrorContains(t, err, "collection is required")
+
+	assert.ErrorContains(t, seeder.Reapply(t.Context(), "missing"), "seed set missing not found")
+}
@@ -0,0 +1,185 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrUnsupportedFeature 当前部署不支持的功能，可以通过 errors.Is 判断
+var ErrUnsupportedFeature = errors.New("feature not supported by deployment")
+
+// Feature 依赖部署类型或服务端版本的功能
+type Feature string
+
+const (
+	FeatureTransactions  Feature = "transactions"
+	FeatureChangeStreams Feature = "change streams"
+	FeatureTimeSeries    Feature = "time series collections"
+)
+
+// UnsupportedFeatureError 当前部署不支持的功能
+type UnsupportedFeatureError struct {
+	Feature Feature
+	// Reason 不支持的原因，例如 "standalone server"、"requires MongoDB 4.2+ on sharded clusters"
+	Reason string
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("%s unavailable (%s): %v", e.Feature, e.Reason, ErrUnsupportedFeature)
+}
+
+// Is 使 errors.Is(err, ErrUnsupportedFeature) 成立
+func (e *UnsupportedFeatureError) Is(target error) bool {
+	return target == ErrUnsupportedFeature
+}
+
+// TopologyType 部署类型
+type TopologyType string
+
+const (
+	TopologyStandalone TopologyType = "standalone"
+	TopologyReplicaSet TopologyType = "replicaSet"
+	TopologySharded    TopologyType = "sharded"
+)
+
+// ServerInfo 服务端版本、部署类型和支持的功能
+type ServerInfo struct {
+	Version      string       `json:"version"`
+	VersionArray []int        `json:"version_array"`
+	Topology     TopologyType `json:"topology"`
+	// SetName 副本集名称，只在副本集上设置
+	SetName        string `json:"set_name,omitempty"`
+	MaxWireVersion int32  `json:"max_wire_version"`
+
+	SupportsTransactions  bool `json:"supports_transactions"`
+	SupportsChangeStreams bool `json:"supports_change_streams"`
+	SupportsTimeSeries    bool `json:"supports_time_series"`
+
+	// sessions 部署是否支持逻辑会话，事务依赖会话
+	sessions bool
+}
+
+// AtLeast 服务端版本不低于 major.minor
+func (s *ServerInfo) AtLeast(major, minor int) bool {
+	var v [2]int
+	copy(v[:], s.VersionArray)
+	if v[0] != major {
+		return v[0] > major
+	}
+	return v[1] >= minor
+}
+
+// Supports 返回功能是否可用，不可用时返回 *UnsupportedFeatureError
+func (s *ServerInfo) Supports(feature Feature) error {
+	reason := ""
+	switch feature {
+	case FeatureTransactions:
+		switch {
+		case s.Topology == TopologyStandalone:
+			reason = "standalone server, transactions require a replica set or sharded cluster"
+		case !s.sessions:
+			reason = "logical sessions are not enabled"
+		case s.Topology == TopologyReplicaSet && !s.AtLeast(4, 0):
+			reason = "requires MongoDB 4.0+ on replica sets, server is " + s.Version
+		case s.Topology == TopologySharded && !s.AtLeast(4, 2):
+			reason = "requires MongoDB 4.2+ on sharded clusters, server is " + s.Version
+		}
+	case FeatureChangeStreams:
+		if s.Topology == TopologyStandalone {
+			reason = "standalone server, change streams require a replica set or sharded cluster"
+		}
+	case FeatureTimeSeries:
+		if !s.AtLeast(5, 0) {
+			reason = "requires MongoDB 5.0+, server is " + s.Version
+		}
+	default:
+		return fmt.Errorf("unknown feature %q", feature)
+	}
+	if reason != "" {
+		return &UnsupportedFeatureError{Feature: feature, Reason: reason}
+	}
+	return nil
+}
+
+// serverInfoCache 部署信息在连接期间不会变化，检测一次后由 WithDatabase 返回的客户端共享
+type serverInfoCache struct {
+	mu   sync.Mutex
+	info *ServerInfo
+}
+
+// ServerInfo 通过 buildInfo 和 hello 检测服务端版本、部署类型和支持的功能，成功后缓存结果
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	if c.server != nil {
+		c.server.mu.Lock()
+		defer c.server.mu.Unlock()
+		if c.server.info != nil {
+			return c.server.info, nil
+		}
+	}
+
+	admin := c.client.Database("admin")
+	var build struct {
+		Version      string `bson:"version"`
+		VersionArray []int  `bson:"versionArray"`
+	}
+	if err := admin.RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&build); err != nil {
+		return nil, fmt.Errorf("failed to get server build info: %w", err)
+	}
+	var hello helloResult
+	if err := admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		// hello 在 4.4.2 之前不可用
+		if err := admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+			return nil, fmt.Errorf("failed to get server topology: %w", err)
+		}
+	}
+
+	info := newServerInfo(build.Version, build.VersionArray, &hello)
+	if c.server != nil {
+		c.server.info = info
+	}
+	return info, nil
+}
+
+// helloResult hello 命令中用于判断部署类型的字段
+type helloResult struct {
+	SetName                      string `bson:"setName"`
+	Msg                          string `bson:"msg"`
+	MaxWireVersion               int32  `bson:"maxWireVersion"`
+	LogicalSessionTimeoutMinutes *int64 `bson:"logicalSessionTimeoutMinutes"`
+}
+
+func newServerInfo(version string, versionArray []int, hello *helloResult) *ServerInfo {
+	info := &ServerInfo{
+		Version:        version,
+		VersionArray:   versionArray,
+		Topology:       TopologyStandalone,
+		SetName:        hello.SetName,
+		MaxWireVersion: hello.MaxWireVersion,
+		sessions:       hello.LogicalSessionTimeoutMinutes != nil,
+	}
+	switch {
+	case hello.Msg == "isdbgrid":
+		info.Topology = TopologySharded
+	case hello.SetName != "":
+		info.Topology = TopologyReplicaSet
+	}
+	info.SupportsTransactions = info.Supports(FeatureTransactions) == nil
+	info.SupportsChangeStreams = info.Supports(FeatureChangeStreams) == nil
+	info.SupportsTimeSeries = info.Supports(FeatureTimeSeries) == nil
+	return info
+}
+
+// requireFeature 部署不支持功能时返回 *UnsupportedFeatureError；
+// 检测失败时不拦截，由后续操作返回实际的错误
+func (c *Client) requireFeature(ctx context.Context, feature Feature) error {
+	info, err := c.ServerInfo(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "Failed to detect server capabilities", "feature", feature, "err", err)
+		return nil
+	}
+	return info.Supports(feature)
+}
@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewServerInfo(t *testing.T) {
+	sessions := int64(30)
+
+	standalone := newServerInfo("7.0.2", []int{7, 0, 2, 0}, &helloResult{MaxWireVersion: 21, LogicalSessionTimeoutMinutes: &sessions})
+	assert.Equal(t, TopologyStandalone, standalone.Topology)
+	assert.False(t, standalone.SupportsTransactions)
+	assert.False(t, standalone.SupportsChangeStreams)
+	assert.True(t, standalone.SupportsTimeSeries)
+
+	replicaSet := newServerInfo("4.0.28", []int{4, 0, 28, 0}, &helloResult{SetName: "rs0", LogicalSessionTimeoutMinutes: &sessions})
+	assert.Equal(t, TopologyReplicaSet, replicaSet.Topology)
+	assert.Equal(t, "rs0", replicaSet.SetName)
+	assert.True(t, replicaSet.SupportsTransactions)
+	assert.True(t, replicaSet.SupportsChangeStreams)
+	assert.False(t, replicaSet.SupportsTimeSeries)
+
+	sharded := newServerInfo("4.0.28", []int{4, 0, 28, 0}, &helloResult{Msg: "isdbgrid", LogicalSessionTimeoutMinutes: &sessions})
+	assert.Equal(t, TopologySharded, sharded.Topology)
+	assert.False(t, sharded.SupportsTransactions)
+	assert.True(t, sharded.SupportsChangeStreams)
+
+	noSessions := newServerInfo("6.0.1", []int{6, 0, 1, 0}, &helloResult{SetName: "rs0"})
+	assert.False(t, noSessions.SupportsTransactions)
+
+	err := standalone.Supports(FeatureTransactions)
+	assert.ErrorIs(t, err, ErrUnsupportedFeature)
+	var featureErr *UnsupportedFeatureError
+	if assert.True(t, errors.As(err, &featureErr)) {
+		assert.Equal(t, FeatureTransactions, featureErr.Feature)
+	}
+	assert.ErrorContains(t, sharded.Supports(FeatureTransactions), "requires MongoDB 4.2+ on sharded clusters, server is 4.0.28")
+	assert.Error(t, standalone.Supports(Feature("sharding")))
+}
+
+func TestServerInfoAtLeast(t *testing.T) {
+	info := &ServerInfo{VersionArray: []int{4, 4, 6, 0}}
+	assert.True(t, info.AtLeast(4, 4))
+	assert.True(t, info.AtLeast(4, 2))
+	assert.True(t, info.AtLeast(3, 6))
+	assert.False(t, info.AtLeast(4, 5))
+	assert.False(t, info.AtLeast(5, 0))
+	assert.False(t, (&ServerInfo{}).AtLeast(3, 6))
+}
+
+func TestTransactionUnsupportedOnStandalone(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	client.server = &serverInfoCache{info: newServerInfo("7.0.2", []int{7, 0, 2, 0}, &helloResult{})}
+
+	info, err := client.ServerInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, TopologyStandalone, info.Topology)
+
+	called := false
+	err = NewTransactionManager(client).WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedFeature)
+	err = NewTransactionalRepository(client, "orders").WithTransaction(ctx, func(sessCtx mongo.SessionContext, repo *Collection) error {
+		called = true
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedFeature)
+	assert.False(t, called)
+
+	cdc, err := NewCDC(client, memoryCheckpoints{}, CDCOptions{Name: "search"})
+	if assert.NoError(t, err) {
+		assert.ErrorIs(t, cdc.Run(ctx), ErrUnsupportedFeature)
+	}
+	assert.Same(t, client.server, client.WithDatabase("other").server)
+}
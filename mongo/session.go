@@ -0,0 +1,292 @@
+package mongo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrSessionNotFound 会话不存在或已过期
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session 会话，Data 为应用自定义的会话内容
+type Session[T any] struct {
+	ID string `bson:"_id" json:"id"`
+	// UserID 会话所属的用户，用于 DestroyUser 注销用户的所有会话
+	UserID    string    `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Data      T         `bson:"data" json:"data"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	// Deadline 最长有效期，续期不会超过该时间，零值表示不限制
+	Deadline time.Time `bson:"deadline,omitempty" json:"deadline,omitempty"`
+}
+
+// SessionOptions 会话选项
+type SessionOptions struct {
+	// Collection 会话集合，默认 sessions
+	Collection string
+	// IdleTimeout 会话空闲多久后过期，默认 24 小时
+	IdleTimeout time.Duration
+	// Lifetime 会话从创建起的最长有效期，为 0 时不限制
+	Lifetime time.Duration
+	// Rolling 每次 Get 都顺延过期时间（滚动过期）；关闭时只有 Touch、Save 会顺延
+	Rolling bool
+}
+
+// Sessions Web 应用的会话存储，过期会话由 TTL 索引清理，读取时同样会排除已过期但尚未清理的会话
+//
+//	sessions := NewSessions[CartSession](client, &SessionOptions{IdleTimeout: 30 * time.Minute, Rolling: true})
+//	sessions.EnsureIndexes(ctx)
+//	session, err := sessions.Create(ctx, userID, CartSession{Items: items})
+//	http.SetCookie(w, &http.Cookie{Name: "sid", Value: session.ID, HttpOnly: true, Secure: true})
+type Sessions[T any] struct {
+	client     *Client
+	collection *mongo.Collection
+	opts       SessionOptions
+}
+
+// NewSessions 创建会话存储
+func NewSessions[T any](client *Client, opts *SessionOptions) *Sessions[T] {
+	o := SessionOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Collection == "" {
+		o.Collection = "sessions"
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = 24 * time.Hour
+	}
+	return &Sessions[T]{client: client, collection: client.GetCollection(o.Collection), opts: o}
+}
+
+// EnsureIndexes 创建过期会话的 TTL 索引和 user_id 索引
+func (s *Sessions[T]) EnsureIndexes(ctx context.Context) error {
+	if err := s.client.checkWritable("createIndexes", s.collection.Name()); err != nil {
+		return err
+	}
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("idx_expires_at_ttl").SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("idx_user_id").SetSparse(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session indexes: %w", err)
+	}
+	return nil
+}
+
+// Create 创建会话，ID 为 256 位随机数，可以直接作为 Cookie 的值
+func (s *Sessions[T]) Create(ctx context.Context, userID string, data T) (*Session[T], error) {
+	if err := s.client.checkWritable("insert", s.collection.Name()); err != nil {
+		return nil, err
+	}
+	id, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	session := &Session[T]{ID: id, UserID: userID, Data: data, CreatedAt: now}
+	if s.opts.Lifetime > 0 {
+		session.Deadline = now.Add(s.opts.Lifetime)
+	}
+	session.ExpiresAt = s.expiresAt(now, session.Deadline)
+	if _, err := s.collection.InsertOne(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return session, nil
+}
+
+// Get 返回未过期的会话，开启 Rolling 时同时顺延过期时间；会话不存在或已过期时返回 ErrSessionNotFound
+func (s *Sessions[T]) Get(ctx context.Context, id string) (*Session[T], error) {
+	if s.opts.Rolling && !s.client.ReadOnly() {
+		return s.touch(ctx, id, nil)
+	}
+	var session Session[T]
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &session, nil
+}
+
+// Touch 顺延会话的过期时间，返回新的过期时间
+func (s *Sessions[T]) Touch(ctx context.Context, id string) (time.Time, error) {
+	session, err := s.touch(ctx, id, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return session.ExpiresAt, nil
+}
+
+// Save 替换会话内容并顺延过期时间
+func (s *Sessions[T]) Save(ctx context.Context, id string, data T) error {
+	// 管道更新中的值会被当作表达式解析，$literal 避免以 $ 开头的字符串被当作字段路径
+	_, err := s.touch(ctx, id, bson.M{"data": bson.M{"$literal": data}})
+	return err
+}
+
+// touch 原子地修改未过期的会话并顺延过期时间，过期时间不超过 Deadline
+func (s *Sessions[T]) touch(ctx context.Context, id string, set bson.M) (*Session[T], error) {
+	if err := s.client.checkWritable("update", s.collection.Name()); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if set == nil {
+		set = bson.M{}
+	}
+	// $min 忽略不存在的 deadline，没有最长有效期时直接使用 now + IdleTimeout
+	set["expires_at"] = bson.M{"$min": bson.A{now.Add(s.opts.IdleTimeout), "$deadline"}}
+	var session Session[T]
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "expires_at": bson.M{"$gt": now}},
+		bson.A{bson.M{"$set": set}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	return &session, nil
+}
+
+// Destroy 删除会话，会话不存在时不返回错误
+func (s *Sessions[T]) Destroy(ctx context.Context, id string) error {
+	if err := s.client.checkWritable("delete", s.collection.Name()); err != nil {
+		return err
+	}
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to destroy session: %w", err)
+	}
+	return nil
+}
+
+// DestroyUser 删除用户的所有会话，例如修改密码后注销所有设备，返回删除的数量
+func (s *Sessions[T]) DestroyUser(ctx context.Context, userID string) (int64, error) {
+	if err := s.client.checkWritable("delete", s.collection.Name()); err != nil {
+		return 0, err
+	}
+	result, err := s.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to destroy sessions of user %s: %w", userID, err)
+	}
+	return result.DeletedCount, nil
+}
+
+func (s *Sessions[T]) expiresAt(now, deadline time.Time) time.Time {
+	expires := now.Add(s.opts.IdleTimeout)
+	if !deadline.IsZero() && deadline.Before(expires) {
+		return deadline
+	}
+	return expires
+}
+
+// SessionMiddlewareStore 供 Web 会话中间件使用的存储，方法签名与 github.com/alexedwards/scs/v2 的
+// Store、CtxStore、IterableStore 接口一致；会话内容由中间件编码，过期时间由中间件决定
+//
+//	manager := scs.New()
+//	manager.Store = NewSessionMiddlewareStore(client, "")
+type SessionMiddlewareStore struct {
+	sessions *Sessions[[]byte]
+}
+
+// NewSessionMiddlewareStore 创建中间件会话存储，collection 为空时使用 sessions 集合
+func NewSessionMiddlewareStore(client *Client, collection string) *SessionMiddlewareStore {
+	return &SessionMiddlewareStore{sessions: NewSessions[[]byte](client, &SessionOptions{Collection: collection})}
+}
+
+// Find 返回会话内容，会话不存在或已过期时 found 为 false
+func (m *SessionMiddlewareStore) Find(token string) ([]byte, bool, error) {
+	return m.FindCtx(context.Background(), token)
+}
+
+// FindCtx 同 Find
+func (m *SessionMiddlewareStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	var session Session[[]byte]
+	err := m.sessions.collection.FindOne(ctx, bson.M{"_id": token, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find session: %w", err)
+	}
+	return session.Data, true, nil
+}
+
+// Commit 保存会话内容和过期时间，会话不存在时创建
+func (m *SessionMiddlewareStore) Commit(token string, b []byte, expiry time.Time) error {
+	return m.CommitCtx(context.Background(), token, b, expiry)
+}
+
+// CommitCtx 同 Commit
+func (m *SessionMiddlewareStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	coll := m.sessions.collection
+	if err := m.sessions.client.checkWritable("update", coll.Name()); err != nil {
+		return err
+	}
+	_, err := coll.UpdateOne(ctx,
+		bson.M{"_id": token},
+		bson.M{"$set": bson.M{"data": b, "expires_at": expiry}, "$setOnInsert": bson.M{"created_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to commit session: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除会话
+func (m *SessionMiddlewareStore) Delete(token string) error {
+	return m.sessions.Destroy(context.Background(), token)
+}
+
+// DeleteCtx 同 Delete
+func (m *SessionMiddlewareStore) DeleteCtx(ctx context.Context, token string) error {
+	return m.sessions.Destroy(ctx, token)
+}
+
+// All 返回所有未过期的会话
+func (m *SessionMiddlewareStore) All() (map[string][]byte, error) {
+	return m.AllCtx(context.Background())
+}
+
+// AllCtx 同 All
+func (m *SessionMiddlewareStore) AllCtx(ctx context.Context) (map[string][]byte, error) {
+	cursor, err := m.sessions.collection.Find(ctx, bson.M{"expires_at": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	var sessions []Session[[]byte]
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode sessions: %w", err)
+	}
+	result := make(map[string][]byte, len(sessions))
+	for _, session := range sessions {
+		result[session.ID] = session.Data
+	}
+	return result, nil
+}
+
+// EnsureIndexes 创建过期会话的 TTL 索引
+func (m *SessionMiddlewareStore) EnsureIndexes(ctx context.Context) error {
+	return m.sessions.EnsureIndexes(ctx)
+}
+
+// newOpaqueToken 生成 256 位随机令牌，使用 URL 安全的 base64 编码
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
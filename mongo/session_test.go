@@ -0,0 +1,59 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scsStore 与 github.com/alexedwards/scs/v2 的 Store、CtxStore 接口相同
+type scsStore interface {
+	Find(token string) ([]byte, bool, error)
+	Commit(token string, b []byte, expiry time.Time) error
+	Delete(token string) error
+	FindCtx(ctx context.Context, token string) ([]byte, bool, error)
+	CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error
+	DeleteCtx(ctx context.Context, token string) error
+}
+
+var _ scsStore = (*SessionMiddlewareStore)(nil)
+
+func TestSessionExpiry(t *testing.T) {
+	client := newLazyClient(t)
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	sessions := NewSessions[map[string]string](client, nil)
+	assert.Equal(t, "sessions", sessions.collection.Name())
+	assert.Equal(t, now.Add(24*time.Hour), sessions.expiresAt(now, time.Time{}))
+
+	sessions = NewSessions[map[string]string](client, &SessionOptions{IdleTimeout: time.Hour, Lifetime: 90 * time.Minute})
+	assert.Equal(t, now.Add(time.Hour), sessions.expiresAt(now, now.Add(90*time.Minute)))
+	assert.Equal(t, now.Add(90*time.Minute), sessions.expiresAt(now.Add(time.Hour), now.Add(90*time.Minute)))
+}
+
+func TestOpaqueToken(t *testing.T) {
+	a, err := newOpaqueToken()
+	require.NoError(t, err)
+	b, err := newOpaqueToken()
+	require.NoError(t, err)
+	assert.Len(t, a, 43)
+	assert.NotEqual(t, a, b)
+}
+
+func TestSessionsReadOnly(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	client.readOnly = true
+	sessions := NewSessions[string](client, nil)
+
+	_, err := sessions.Create(ctx, "u1", "data")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = sessions.Touch(ctx, "sid")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, sessions.Save(ctx, "sid", "data"), ErrReadOnly)
+	assert.ErrorIs(t, sessions.Destroy(ctx, "sid"), ErrReadOnly)
+	assert.ErrorIs(t, NewSessionMiddlewareStore(client, "").Commit("sid", []byte("data"), time.Now()), ErrReadOnly)
+}
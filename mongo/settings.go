@@ -0,0 +1,366 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrSettingNotFound 配置项不存在
+	ErrSettingNotFound = errors.New("setting not found")
+	// ErrSettingConflict 配置项在读取之后已被修改（或已被创建），需要重新读取后再修改
+	ErrSettingConflict = errors.New("setting was modified concurrently")
+)
+
+// Setting settings 集合中的一个配置项
+type Setting struct {
+	Key   string        `bson:"_id" json:"key"`
+	Value bson.RawValue `bson:"value" json:"-"`
+	// Version 每次修改加 1，用于乐观并发控制
+	Version   int64     `bson:"version" json:"version"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Decode 将配置值解码到 v
+func (s *Setting) Decode(v interface{}) error {
+	return s.Value.Unmarshal(v)
+}
+
+// SettingsStore 运行时可调的应用配置，每个配置项是 settings 集合中的一个文档，带有版本号；
+// 修改时必须提供读取到的版本号，版本不一致时返回 ErrSettingConflict，避免多个管理端互相覆盖
+//
+//	store := NewSettingsStore(client, "")
+//	setting, err := store.Get(ctx, "rate_limit")
+//	_, err = store.Set(ctx, "rate_limit", 200, setting.Version)
+type SettingsStore struct {
+	client     *Client
+	collection *mongo.Collection
+}
+
+// NewSettingsStore 创建配置存储，collection 为空时使用 settings 集合
+func NewSettingsStore(client *Client, collection string) *SettingsStore {
+	if collection == "" {
+		collection = "settings"
+	}
+	return &SettingsStore{client: client, collection: client.GetCollection(collection)}
+}
+
+// Get 读取配置项，不存在时返回 ErrSettingNotFound
+func (s *SettingsStore) Get(ctx context.Context, key string) (*Setting, error) {
+	var setting Setting
+	err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&setting)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: %s", ErrSettingNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get setting %s: %w", key, err)
+	}
+	return &setting, nil
+}
+
+// List 读取所有配置项，按 Key 排序
+func (s *SettingsStore) List(ctx context.Context) ([]Setting, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settings: %w", err)
+	}
+	var settings []Setting
+	if err := cursor.All(ctx, &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	return settings, nil
+}
+
+// Set 修改配置项并返回新的版本号；version 为读取时的版本号，为 0 表示创建新的配置项
+func (s *SettingsStore) Set(ctx context.Context, key string, value interface{}, version int64) (int64, error) {
+	if err := s.client.checkWritable("update", s.collection.Name()); err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if version == 0 {
+		_, err := s.collection.InsertOne(ctx, bson.M{"_id": key, "value": value, "version": int64(1), "updated_at": now})
+		if mongo.IsDuplicateKeyError(err) {
+			return 0, fmt.Errorf("%w: %s already exists", ErrSettingConflict, key)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to create setting %s: %w", key, err)
+		}
+		s.client.logger.InfoContext(ctx, "Created setting", "key", key)
+		return 1, nil
+	}
+
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": key, "version": version},
+		bson.M{"$set": bson.M{"value": value, "updated_at": now}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update setting %s: %w", key, err)
+	}
+	if result.MatchedCount == 0 {
+		return 0, fmt.Errorf("%w: %s is not at version %d", ErrSettingConflict, key, version)
+	}
+	s.client.logger.InfoContext(ctx, "Updated setting", "key", key, "version", version+1)
+	return version + 1, nil
+}
+
+// Delete 删除配置项，version 与当前版本不一致时返回 ErrSettingConflict
+func (s *SettingsStore) Delete(ctx context.Context, key string, version int64) error {
+	if err := s.client.checkWritable("delete", s.collection.Name()); err != nil {
+		return err
+	}
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "version": version})
+	if err != nil {
+		return fmt.Errorf("failed to delete setting %s: %w", key, err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: %s is not at version %d", ErrSettingConflict, key, version)
+	}
+	s.client.logger.InfoContext(ctx, "Deleted setting", "key", key)
+	return nil
+}
+
+// LiveSettingsOptions 配置热加载选项
+type LiveSettingsOptions[T any] struct {
+	// RefreshInterval 部署不支持变更流（单机）时定期重新加载的间隔，同时是变更流重试间隔的上限，默认 30 秒
+	RefreshInterval time.Duration
+	// OnChange 配置变化后的回调，在后台 goroutine 中调用
+	OnChange func(previous, current *T)
+}
+
+// LiveSettings 将 settings 集合加载到结构体 T 中，并通过变更流实时更新；
+// 配置项的 Key 对应 T 的顶层 bson 字段名，集合中不存在的配置项使用 defaults 中的值
+//
+//	type AppSettings struct {
+//		RateLimit   int      `bson:"rate_limit"`
+//		Maintenance bool     `bson:"maintenance"`
+//		Banners     []string `bson:"banners"`
+//	}
+//	live, err := NewLiveSettings(NewSettingsStore(client, ""), AppSettings{RateLimit: 100}, nil)
+//	if err := live.Start(ctx); err != nil {
+//		return err
+//	}
+//	defer live.Stop()
+//	limit := live.Current().RateLimit
+type LiveSettings[T any] struct {
+	store    *SettingsStore
+	defaults bson.Raw
+	opts     LiveSettingsOptions[T]
+
+	current  atomic.Pointer[T]
+	mu       sync.Mutex
+	versions map[string]int64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	cancel    context.CancelFunc
+	doneCh    chan struct{}
+}
+
+// NewLiveSettings 创建配置热加载，defaults 必须能编码为 BSON 文档
+func NewLiveSettings[T any](store *SettingsStore, defaults T, opts *LiveSettingsOptions[T]) (*LiveSettings[T], error) {
+	raw, err := bson.Marshal(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode default settings: %w", err)
+	}
+	l := &LiveSettings[T]{store: store, defaults: raw, doneCh: make(chan struct{})}
+	if opts != nil {
+		l.opts = *opts
+	}
+	if l.opts.RefreshInterval <= 0 {
+		l.opts.RefreshInterval = 30 * time.Second
+	}
+	l.current.Store(&defaults)
+	return l, nil
+}
+
+// Current 返回当前配置，返回的值在配置变化时整体替换，调用方不应修改
+func (l *LiveSettings[T]) Current() *T {
+	return l.current.Load()
+}
+
+// Version 返回配置项当前的版本号，配置项不存在时返回 0
+func (l *LiveSettings[T]) Version(key string) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.versions[key]
+}
+
+// Load 从数据库重新加载配置，配置项无法解码到 T 时返回错误并保留当前配置
+func (l *LiveSettings[T]) Load(ctx context.Context) error {
+	settings, err := l.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	next, err := mergeSettings[T](l.defaults, settings)
+	if err != nil {
+		return err
+	}
+	versions := make(map[string]int64, len(settings))
+	for _, setting := range settings {
+		versions[setting.Key] = setting.Version
+	}
+
+	l.mu.Lock()
+	changed := l.versions != nil && !maps.Equal(l.versions, versions)
+	l.versions = versions
+	previous := l.current.Swap(next)
+	l.mu.Unlock()
+	if changed && l.opts.OnChange != nil {
+		l.opts.OnChange(previous, next)
+	}
+	return nil
+}
+
+// mergeSettings 用配置项替换 defaults 中的同名字段后解码，defaults 中没有的配置项追加在后面
+func mergeSettings[T any](defaults bson.Raw, settings []Setting) (*T, error) {
+	values := make(map[string]bson.RawValue, len(settings))
+	for _, setting := range settings {
+		values[setting.Key] = setting.Value
+	}
+	elements, err := defaults.Elements()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode default settings: %w", err)
+	}
+	doc := make(bson.D, 0, len(elements)+len(settings))
+	for _, element := range elements {
+		key := element.Key()
+		if value, ok := values[key]; ok {
+			doc = append(doc, bson.E{Key: key, Value: value})
+			delete(values, key)
+			continue
+		}
+		doc = append(doc, bson.E{Key: key, Value: element.Value()})
+	}
+	for _, setting := range settings {
+		if value, ok := values[setting.Key]; ok {
+			doc = append(doc, bson.E{Key: setting.Key, Value: value})
+		}
+	}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	result := new(T)
+	if err := bson.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to decode settings: %w", err)
+	}
+	return result, nil
+}
+
+// Start 加载配置并在后台同步修改，首次加载失败时返回错误
+func (l *LiveSettings[T]) Start(ctx context.Context) error {
+	if err := l.Load(ctx); err != nil {
+		return err
+	}
+	l.startOnce.Do(func() {
+		l.store.client.RegisterShutdown(l)
+		ctx, l.cancel = context.WithCancel(ctx)
+		go func() {
+			defer close(l.doneCh)
+			_ = l.Run(ctx)
+		}()
+	})
+	return nil
+}
+
+// Stop 停止后台同步
+func (l *LiveSettings[T]) Stop() {
+	l.stopOnce.Do(func() {
+		l.startOnce.Do(func() {
+			close(l.doneCh)
+		})
+		if l.cancel != nil {
+			l.cancel()
+		}
+		<-l.doneCh
+	})
+}
+
+// Run 通过变更流同步配置直到 ctx 取消，变更流中断后重新打开并全量加载；
+// 部署不支持变更流时按 RefreshInterval 定期加载
+func (l *LiveSettings[T]) Run(ctx context.Context) error {
+	logger := l.store.client.logger
+	for attempt := 0; ; attempt++ {
+		delivered, err := l.watch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrUnsupportedFeature) {
+			logger.WarnContext(ctx, "Live settings fall back to polling", "interval", l.opts.RefreshInterval, "err", err)
+			return l.poll(ctx)
+		}
+		if delivered {
+			attempt = 0
+		}
+		logger.WarnContext(ctx, "Settings change stream interrupted, reloading", "err", err)
+		backoff := time.Second << attempt
+		if backoff <= 0 || backoff > l.opts.RefreshInterval {
+			backoff = l.opts.RefreshInterval
+		}
+		if err := l.wait(ctx, backoff); err != nil {
+			return nil
+		}
+	}
+}
+
+// watch 先打开变更流再全量加载，之后每个事件都重新加载全部配置（配置项通常很少）；返回是否处理过事件
+func (l *LiveSettings[T]) watch(ctx context.Context) (bool, error) {
+	if err := l.store.client.requireFeature(ctx, FeatureChangeStreams); err != nil {
+		return false, err
+	}
+	stream, err := l.store.collection.Watch(ctx, []bson.M{})
+	if err != nil {
+		return false, fmt.Errorf("failed to open change stream: %w", err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+	if err := l.Load(ctx); err != nil {
+		return false, err
+	}
+
+	delivered := false
+	for stream.Next(ctx) {
+		if err := l.Load(ctx); err != nil {
+			return delivered, err
+		}
+		delivered = true
+	}
+	if err := stream.Err(); err != nil {
+		return delivered, fmt.Errorf("change stream failed: %w", err)
+	}
+	return delivered, nil
+}
+
+func (l *LiveSettings[T]) wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *LiveSettings[T]) poll(ctx context.Context) error {
+	ticker := time.NewTicker(l.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := l.Load(ctx); err != nil && ctx.Err() == nil {
+				l.store.client.logger.WarnContext(ctx, "Failed to reload settings", "err", err)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
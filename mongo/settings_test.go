@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type testAppSettings struct {
+	RateLimit   int      `bson:"rate_limit"`
+	Maintenance bool     `bson:"maintenance"`
+	Banners     []string `bson:"banners"`
+}
+
+func settingValue(t *testing.T, value interface{}) bson.RawValue {
+	t.Helper()
+	return bson.Raw(bsonDoc(t, bson.D{{Key: "v", Value: value}})).Lookup("v")
+}
+
+func TestMergeSettings(t *testing.T) {
+	defaults := testAppSettings{RateLimit: 100, Banners: []string{"welcome"}}
+	raw, err := bson.Marshal(defaults)
+	require.NoError(t, err)
+
+	merged, err := mergeSettings[testAppSettings](raw, []Setting{
+		{Key: "maintenance", Value: settingValue(t, true), Version: 3},
+		{Key: "unknown", Value: settingValue(t, "ignored"), Version: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testAppSettings{RateLimit: 100, Maintenance: true, Banners: []string{"welcome"}}, *merged)
+
+	_, err = mergeSettings[testAppSettings](raw, []Setting{{Key: "rate_limit", Value: settingValue(t, "fast")}})
+	assert.Error(t, err, "values that do not fit the struct are rejected")
+}
+
+func TestLiveSettingsDefaults(t *testing.T) {
+	client := newLazyClient(t)
+	live, err := NewLiveSettings(NewSettingsStore(client, ""), testAppSettings{RateLimit: 100}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 100, live.Current().RateLimit)
+	assert.Zero(t, live.Version("rate_limit"))
+
+	_, err = NewLiveSettings[interface{}](NewSettingsStore(client, ""), 42, nil)
+	assert.Error(t, err)
+
+	client.readOnly = true
+	_, err = NewSettingsStore(client, "").Set(t.Context(), "rate_limit", 200, 1)
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
@@ -0,0 +1,124 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClientShutdown 客户端正在关闭，不再接受新的操作
+var ErrClientShutdown = errors.New("client is shutting down")
+
+// Stopper 可以停止的后台组件，Stop 应当幂等并等待组件内正在进行的工作结束
+type Stopper interface {
+	Stop()
+}
+
+// lifecycle 客户端的后台组件和进行中的操作，零值可用
+type lifecycle struct {
+	mu         sync.Mutex
+	components []Stopper
+	inflight   int
+	closing    bool
+	idle       chan struct{}
+}
+
+// RegisterShutdown 注册在 Shutdown 时停止的后台组件；HealthChecker、Scheduler、JobQueue、Mirror、
+// ChangeStreamForwarder、CDC、MaterializedView、BatchCounter、FlagStore、LiveSettings、Notifications 在 Start 时自动注册，自定义组件可以手动注册
+func (c *Client) RegisterShutdown(components ...Stopper) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	c.lifecycle.components = append(c.lifecycle.components, components...)
+}
+
+// beginOperation 记录一个进行中的操作，客户端正在关闭时返回 ErrClientShutdown
+func (c *Client) beginOperation() error {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	if c.lifecycle.closing {
+		return ErrClientShutdown
+	}
+	c.lifecycle.inflight++
+	return nil
+}
+
+// endOperation 结束一个进行中的操作，关闭过程中最后一个操作结束时通知 Shutdown
+func (c *Client) endOperation() {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	c.lifecycle.inflight--
+	if c.lifecycle.closing && c.lifecycle.inflight == 0 && c.lifecycle.idle != nil {
+		close(c.lifecycle.idle)
+		c.lifecycle.idle = nil
+	}
+}
+
+// Shutdown 优雅关闭客户端，适合在收到 SIGTERM 时调用：
+//  1. 按注册的逆序停止后台组件（健康检查、变更流、调度器等），组件停止时可以继续写入数据库
+//  2. 拒绝新的集合操作（返回 ErrClientShutdown），等待进行中的操作完成
+//  3. 断开连接
+//
+// ctx 到期时不再等待未停止的组件和未完成的操作，直接断开连接并返回 ctx 的错误
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+//	defer cancel()
+//	if err := client.Shutdown(ctx); err != nil {
+//		logger.Warn("mongo shutdown", "err", err)
+//	}
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.lifecycle.mu.Lock()
+	components := c.lifecycle.components
+	c.lifecycle.components = nil
+	c.lifecycle.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		if err := stopWithContext(ctx, components[i]); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %T: %w", components[i], err))
+		}
+	}
+
+	c.lifecycle.mu.Lock()
+	c.lifecycle.closing = true
+	var idle chan struct{}
+	if c.lifecycle.inflight > 0 {
+		if c.lifecycle.idle == nil {
+			c.lifecycle.idle = make(chan struct{})
+		}
+		idle = c.lifecycle.idle
+	}
+	inflight := c.lifecycle.inflight
+	c.lifecycle.mu.Unlock()
+
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("failed to wait for %d in-flight operations: %w", inflight, ctx.Err()))
+		}
+	}
+
+	if err := c.CloseContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to disconnect: %w", err))
+	}
+	if len(errs) > 0 && c.logger != nil {
+		c.logger.WarnContext(ctx, "MongoDB client shutdown incomplete", "err", errors.Join(errs...))
+	}
+	return errors.Join(errs...)
+}
+
+// stopWithContext 调用 Stop 并等待返回，ctx 到期时不再等待
+func stopWithContext(ctx context.Context, component Stopper) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		component.Stop()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
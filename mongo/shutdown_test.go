@@ -0,0 +1,53 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stopperFunc func()
+
+func (f stopperFunc) Stop() { f() }
+
+func TestClientShutdown(t *testing.T) {
+	client := &Client{logger: defaultLogger()}
+	var stopped []string
+	client.RegisterShutdown(stopperFunc(func() { stopped = append(stopped, "scheduler") }))
+	client.RegisterShutdown(stopperFunc(func() { stopped = append(stopped, "watcher") }))
+
+	c := &Collection{cli: client}
+	_, done, err := c.operationContext(context.Background())
+	require.NoError(t, err)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		done()
+	}()
+
+	require.NoError(t, client.Shutdown(context.Background()))
+	assert.Equal(t, []string{"watcher", "scheduler"}, stopped)
+
+	_, _, err = c.operationContext(context.Background())
+	assert.ErrorIs(t, err, ErrClientShutdown)
+}
+
+func TestClientShutdownDeadline(t *testing.T) {
+	client := &Client{logger: defaultLogger()}
+	block := make(chan struct{})
+	defer close(block)
+	client.RegisterShutdown(stopperFunc(func() { <-block }))
+
+	c := &Collection{cli: client}
+	_, done, err := c.operationContext(context.Background())
+	require.NoError(t, err)
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "in-flight operations")
+}
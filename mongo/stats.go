@@ -0,0 +1,140 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DatabaseStats dbStats 命令的结果，大小单位为字节
+type DatabaseStats struct {
+	Database    string  `bson:"db" json:"db"`
+	Collections int64   `bson:"collections" json:"collections"`
+	Views       int64   `bson:"views" json:"views"`
+	Objects     int64   `bson:"objects" json:"objects"`
+	AvgObjSize  float64 `bson:"avgObjSize" json:"avg_obj_size"`
+	DataSize    int64   `bson:"dataSize" json:"data_size"`
+	StorageSize int64   `bson:"storageSize" json:"storage_size"`
+	Indexes     int64   `bson:"indexes" json:"indexes"`
+	IndexSize   int64   `bson:"indexSize" json:"index_size"`
+	// TotalSize 数据和索引占用的存储空间，MongoDB 4.4 之前为 0
+	TotalSize   int64 `bson:"totalSize" json:"total_size"`
+	FsUsedSize  int64 `bson:"fsUsedSize" json:"fs_used_size"`
+	FsTotalSize int64 `bson:"fsTotalSize" json:"fs_total_size"`
+}
+
+// CollectionStats collStats 命令的结果，大小单位为字节
+type CollectionStats struct {
+	Namespace string `bson:"ns" json:"ns"`
+	Count     int64  `bson:"count" json:"count"`
+	// Size 未压缩的数据大小
+	Size       int64   `bson:"size" json:"size"`
+	AvgObjSize float64 `bson:"avgObjSize" json:"avg_obj_size"`
+	// StorageSize 数据占用的存储空间（压缩后）
+	StorageSize    int64            `bson:"storageSize" json:"storage_size"`
+	TotalIndexSize int64            `bson:"totalIndexSize" json:"total_index_size"`
+	TotalSize      int64            `bson:"totalSize" json:"total_size"`
+	NIndexes       int64            `bson:"nindexes" json:"nindexes"`
+	IndexSizes     map[string]int64 `bson:"indexSizes" json:"index_sizes"`
+	Capped         bool             `bson:"capped" json:"capped"`
+}
+
+// StorageReport 数据库存储使用报告
+type StorageReport struct {
+	Database DatabaseStats `json:"database"`
+	// Collections 各集合的统计，按数据和索引占用的存储空间从大到小排序
+	Collections []CollectionStats `json:"collections"`
+}
+
+// DBStats 返回当前数据库的统计信息
+func (ca *CollectionAdmin) DBStats(ctx context.Context) (*DatabaseStats, error) {
+	var stats DatabaseStats
+	err := ca.client.GetDatabase().RunCommand(ctx, bson.D{{Key: "dbStats", Value: 1}, {Key: "scale", Value: 1}}).Decode(&stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// CollStats 返回集合的统计信息
+func (ca *CollectionAdmin) CollStats(ctx context.Context, name string) (*CollectionStats, error) {
+	var stats CollectionStats
+	err := ca.client.GetDatabase().RunCommand(ctx, bson.D{{Key: "collStats", Value: name}, {Key: "scale", Value: 1}}).Decode(&stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats of %s: %w", name, err)
+	}
+	if stats.TotalSize == 0 {
+		stats.TotalSize = stats.StorageSize + stats.TotalIndexSize
+	}
+	return &stats, nil
+}
+
+// StorageReport 汇总数据库中所有集合（不包括视图）的存储使用情况
+func (ca *CollectionAdmin) StorageReport(ctx context.Context) (*StorageReport, error) {
+	db, err := ca.DBStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names, err := ca.client.GetDatabase().ListCollectionNames(ctx, bson.M{"type": "collection"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	report := &StorageReport{Database: *db}
+	for _, name := range names {
+		stats, err := ca.CollStats(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		report.Collections = append(report.Collections, *stats)
+	}
+	sortCollectionStats(report.Collections)
+	return report, nil
+}
+
+func sortCollectionStats(stats []CollectionStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalSize != stats[j].TotalSize {
+			return stats[i].TotalSize > stats[j].TotalSize
+		}
+		return stats[i].Namespace < stats[j].Namespace
+	})
+}
+
+// WriteTable 以表格形式输出报告，share 为集合占数据库总存储的比例
+func (r *StorageReport) WriteTable(w io.Writer) error {
+	total := r.Database.TotalSize
+	if total == 0 {
+		total = r.Database.StorageSize + r.Database.IndexSize
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "collection\tdocuments\tavg_obj\tdata\tstorage\tindexes\ttotal\tshare\t\n")
+	for _, c := range r.Collections {
+		share := 0.0
+		if total > 0 {
+			share = float64(c.TotalSize) / float64(total) * 100
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%.1f%%\t\n", c.Namespace, c.Count, formatBytes(int64(c.AvgObjSize)),
+			formatBytes(c.Size), formatBytes(c.StorageSize), formatBytes(c.TotalIndexSize), formatBytes(c.TotalSize), share)
+	}
+	fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t\t\n", r.Database.Database, r.Database.Objects, formatBytes(int64(r.Database.AvgObjSize)),
+		formatBytes(r.Database.DataSize), formatBytes(r.Database.StorageSize), formatBytes(r.Database.IndexSize), formatBytes(total))
+	return tw.Flush()
+}
+
+// formatBytes 以 1024 为进制格式化字节数，例如 1.5MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,59 @@
+package mongo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStatsDecode(t *testing.T) {
+	// dbStats 的大小字段是 double，collStats 是整数
+	data, err := bson.Marshal(bson.M{
+		"db": "app", "collections": int32(3), "objects": int64(1200), "avgObjSize": 512.5,
+		"dataSize": 615000.0, "storageSize": 409600.0, "indexes": int32(5), "indexSize": 102400.0, "totalSize": 512000.0, "ok": 1.0,
+	})
+	require.NoError(t, err)
+	var db DatabaseStats
+	require.NoError(t, bson.Unmarshal(data, &db))
+	assert.Equal(t, int64(615000), db.DataSize)
+	assert.Equal(t, 512.5, db.AvgObjSize)
+
+	data, err = bson.Marshal(bson.M{
+		"ns": "app.users", "count": int32(1000), "size": int32(500000), "avgObjSize": int32(500),
+		"storageSize": int32(200000), "totalIndexSize": int32(60000), "nindexes": int32(2),
+		"indexSizes": bson.M{"_id_": int32(40000), "idx_email": int32(20000)},
+	})
+	require.NoError(t, err)
+	var coll CollectionStats
+	require.NoError(t, bson.Unmarshal(data, &coll))
+	assert.Equal(t, int64(20000), coll.IndexSizes["idx_email"])
+	assert.Equal(t, float64(500), coll.AvgObjSize)
+}
+
+func TestStorageReportTable(t *testing.T) {
+	stats := []CollectionStats{
+		{Namespace: "app.logs", TotalSize: 1024},
+		{Namespace: "app.users", Count: 10, TotalSize: 3 * 1024, StorageSize: 2048, TotalIndexSize: 1024},
+	}
+	sortCollectionStats(stats)
+	assert.Equal(t, "app.users", stats[0].Namespace)
+
+	report := &StorageReport{Database: DatabaseStats{Database: "app", StorageSize: 3 * 1024, IndexSize: 1024}, Collections: stats}
+	var out bytes.Buffer
+	require.NoError(t, report.WriteTable(&out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Contains(t, lines[1], "75.0%")
+	assert.Contains(t, lines[2], "25.0%")
+	assert.Contains(t, lines[3], "4.0KiB")
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512B", formatBytes(512))
+	assert.Equal(t, "1.5KiB", formatBytes(1536))
+	assert.Equal(t, "2.0GiB", formatBytes(2<<30))
+}
@@ -0,0 +1,149 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TailOptions 可追踪游标配置
+type TailOptions struct {
+	// Filter 过滤条件
+	Filter bson.M
+	// MaxAwaitTime 服务端等待新文档的最长时间，默认 1 秒
+	MaxAwaitTime time.Duration
+	// RetryInterval 游标失效（例如集合为空或读取位置被覆盖）后重新打开游标前的等待时间，默认 1 秒
+	RetryInterval time.Duration
+}
+
+// TailableCursor 固定大小集合上的可追踪游标
+// 读完已有文档后阻塞等待新插入的文档，游标失效时从最后读取的 _id 之后重新打开：
+//
+//	tail := logs.Tail(nil)
+//	defer tail.Close(ctx)
+//	for tail.Next(ctx) {
+//		var entry LogEntry
+//		if err := tail.Decode(&entry); err != nil { ... }
+//	}
+//	if err := tail.Err(); err != nil { ... }
+//
+// 只能用于固定大小集合，适合在不支持 change stream 的单机或旧版本服务端上实现简单的日志和消息流
+type TailableCursor struct {
+	collection *Collection
+	opts       TailOptions
+	cursor     *mongo.Cursor
+	lastID     bson.RawValue
+	err        error
+}
+
+// Tail 在固定大小集合上打开可追踪游标，游标在第一次调用 Next 时打开
+func (c *Collection) Tail(opts *TailOptions) *TailableCursor {
+	t := &TailableCursor{collection: c}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.MaxAwaitTime <= 0 {
+		t.opts.MaxAwaitTime = time.Second
+	}
+	if t.opts.RetryInterval <= 0 {
+		t.opts.RetryInterval = time.Second
+	}
+	return t
+}
+
+// Next 阻塞直到有新文档、ctx 结束或发生错误，没有更多文档时返回 false，原因通过 Err 获取
+func (t *TailableCursor) Next(ctx context.Context) bool {
+	if t.err != nil {
+		return false
+	}
+	for {
+		if t.cursor == nil {
+			if err := t.open(ctx); err != nil {
+				t.err = err
+				return false
+			}
+		}
+		if t.cursor.Next(ctx) {
+			t.lastID = t.cursor.Current.Lookup("_id")
+			return true
+		}
+		if err := ctx.Err(); err != nil {
+			t.err = err
+			return false
+		}
+
+		// 游标出错或已失效，关闭后稍后从最后读取的位置重新打开
+		if err := t.cursor.Err(); err != nil {
+			t.collection.cli.logger.WarnContext(ctx, "Tailable cursor interrupted, reopening", "collection", t.collection.collection.Name(), "err", err)
+		}
+		if t.cursor.ID() == 0 || t.cursor.Err() != nil {
+			_ = t.cursor.Close(ctx)
+			t.cursor = nil
+			select {
+			case <-time.After(t.opts.RetryInterval):
+			case <-ctx.Done():
+				t.err = ctx.Err()
+				return false
+			}
+		}
+	}
+}
+
+// open 打开游标，已读取过文档时只读取之后插入的文档
+func (t *TailableCursor) open(ctx context.Context) error {
+	filter := bson.M{}
+	for key, value := range t.opts.Filter {
+		filter[key] = value
+	}
+	if t.lastID.Type != 0 {
+		filter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": t.lastID}}}}
+	}
+
+	opts := options.Find().
+		SetCursorType(options.TailableAwait).
+		SetMaxAwaitTime(t.opts.MaxAwaitTime)
+	cursor, err := t.collection.collection.Find(t.collection.sessionContext(ctx), filter, opts)
+	if err != nil {
+		return fmt.Errorf("failed to open tailable cursor on %s: %w", t.collection.collection.Name(), err)
+	}
+	t.cursor = cursor
+	return nil
+}
+
+// Current 返回当前文档的原始 BSON
+func (t *TailableCursor) Current() bson.Raw {
+	if t.cursor == nil {
+		return nil
+	}
+	return t.cursor.Current
+}
+
+// Decode 解码当前文档
+func (t *TailableCursor) Decode(v interface{}) error {
+	if t.cursor == nil {
+		return fmt.Errorf("tailable cursor has no current document")
+	}
+	if err := t.cursor.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	return nil
+}
+
+// Err 返回导致 Next 返回 false 的错误
+func (t *TailableCursor) Err() error {
+	return t.err
+}
+
+// Close 关闭游标
+func (t *TailableCursor) Close(ctx context.Context) error {
+	if t.cursor == nil {
+		return nil
+	}
+	err := t.cursor.Close(ctx)
+	t.cursor = nil
+	return err
+}
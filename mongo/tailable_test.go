@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTailableCursor(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	server := newFakeServer(t, false)
+	var opened atomic.Int32
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "find" {
+			return nil
+		}
+		// 每次打开返回一批文档后游标失效，第三次打开时结束测试
+		switch opened.Add(1) {
+		case 1:
+			return fakeCursor(cmd, bson.M{"_id": 1, "msg": "a"}, bson.M{"_id": 2, "msg": "b"})
+		case 2:
+			return fakeCursor(cmd, bson.M{"_id": 3, "msg": "c"})
+		}
+		cancel()
+		return fakeCursor(cmd)
+	})
+	logs := NewCollection(server.client(t), "logs")
+
+	tail := logs.Tail(&TailOptions{Filter: bson.M{"level": "error"}, RetryInterval: time.Millisecond})
+	defer tail.Close(ctx)
+	var messages []string
+	for tail.Next(ctx) {
+		var entry struct {
+			Msg string `bson:"msg"`
+		}
+		require.NoError(t, tail.Decode(&entry))
+		messages = append(messages, entry.Msg)
+	}
+	assert.ErrorIs(t, tail.Err(), context.Canceled)
+	assert.Equal(t, []string{"a", "b", "c"}, messages)
+	assert.False(t, tail.Next(ctx), "the cursor stays finished after an error")
+
+	finds := server.Commands("find")
+	require.Len(t, finds, 3)
+	assert.True(t, finds[0].Lookup("tailable").Boolean())
+	assert.True(t, finds[0].Lookup("awaitData").Boolean())
+	assert.Equal(t, "error", finds[0].Lookup("filter", "level").StringValue())
+
+	// 重新打开时只读取最后读到的 _id 之后的文档
+	for i, lastID := range []int32{2, 3} {
+		and := finds[i+1].Lookup("filter", "$and").Array()
+		assert.Equal(t, "error", and.Index(0).Value().Document().Lookup("level").StringValue())
+		assert.Equal(t, lastID, and.Index(1).Value().Document().Lookup("_id", "$gt").Int32())
+	}
+}
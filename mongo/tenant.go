@@ -0,0 +1,543 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrTenantRequired 上下文中没有租户信息
+	ErrTenantRequired = errors.New("tenant is required")
+	// ErrTenantMismatch 文档或更新操作试图写入其它租户的数据
+	ErrTenantMismatch = errors.New("tenant mismatch")
+)
+
+// tenantContextKey 租户上下文键
+type tenantContextKey struct{}
+
+// WithTenant 将租户 ID 写入上下文
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext 从上下文中获取租户 ID
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// TenantResolver 从上下文中解析租户 ID
+type TenantResolver interface {
+	ResolveTenant(ctx context.Context) (string, error)
+}
+
+// TenantResolverFunc 函数形式的租户解析器
+type TenantResolverFunc func(ctx context.Context) (string, error)
+
+// ResolveTenant 实现 TenantResolver 接口
+func (f TenantResolverFunc) ResolveTenant(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// ContextTenantResolver 读取 WithTenant 写入的租户 ID
+var ContextTenantResolver TenantResolver = TenantResolverFunc(func(ctx context.Context) (string, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return "", ErrTenantRequired
+	}
+	return tenantID, nil
+})
+
+// TenantIsolation 租户隔离方式
+type TenantIsolation int
+
+const (
+	// TenantIsolationField 共享集合，通过租户字段隔离
+	TenantIsolationField TenantIsolation = iota
+	// TenantIsolationDatabase 每个租户使用独立的数据库
+	TenantIsolationDatabase
+)
+
+// TenantOptions 多租户配置
+type TenantOptions struct {
+	Isolation TenantIsolation
+	// Field 字段隔离时的租户字段名，默认 tenant_id
+	Field string
+	// DatabasePrefix 数据库隔离时的数据库名前缀，默认为 "<客户端数据库名>_"
+	DatabasePrefix string
+	// Resolver 租户解析器，默认 ContextTenantResolver
+	Resolver TenantResolver
+	// IDStrategy 文档 ID 策略，默认使用 ObjectID，规则与 Collection.WithIDStrategy 相同
+	IDStrategy IDStrategy
+	// Encryptor 不为空时加密 encrypt 标签字段，规则与 Collection.WithEncryption 相同
+	Encryptor *FieldEncryptor
+}
+
+// TenantCollection 多租户集合
+// 所有读操作都会限定在当前租户范围内，所有写操作都会写入当前租户；
+// 没有租户信息时直接返回 ErrTenantRequired，不会退化为访问全部数据
+type TenantCollection struct {
+	client *Client
+	name   string
+	opts   TenantOptions
+}
+
+// NewTenantCollection 创建多租户集合
+func NewTenantCollection(client *Client, collectionName string, opts TenantOptions) *TenantCollection {
+	if opts.Field == "" {
+		opts.Field = "tenant_id"
+	}
+	if opts.DatabasePrefix == "" {
+		opts.DatabasePrefix = client.GetDatabaseName() + "_"
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = ContextTenantResolver
+	}
+	return &TenantCollection{
+		client: client,
+		name:   collectionName,
+		opts:   opts,
+	}
+}
+
+// resolve 解析当前租户并返回对应的集合
+func (tc *TenantCollection) resolve(ctx context.Context) (*Collection, string, error) {
+	tenantID, err := tc.opts.Resolver.ResolveTenant(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if tenantID == "" {
+		return nil, "", ErrTenantRequired
+	}
+
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		if strings.ContainsAny(tenantID, "/\\. \"$*<>:|?") {
+			return nil, "", fmt.Errorf("invalid tenant id %q for database isolation", tenantID)
+		}
+		database := tc.client.client.Database(tc.opts.DatabasePrefix + tenantID)
+		return newCollection(tc.client, database.Collection(tc.name)).WithIDStrategy(tc.opts.IDStrategy).WithEncryption(tc.opts.Encryptor), tenantID, nil
+	}
+	return NewCollection(tc.client, tc.name).WithIDStrategy(tc.opts.IDStrategy).WithEncryption(tc.opts.Encryptor), tenantID, nil
+}
+
+// documentID 按 ID 策略转换 id 参数
+func (tc *TenantCollection) documentID(id interface{}) (interface{}, error) {
+	if s, ok := id.(string); ok {
+		strategy := tc.opts.IDStrategy
+		if strategy == nil {
+			strategy = ObjectIDStrategy
+		}
+		return strategy.ParseID(s)
+	}
+	return id, nil
+}
+
+// scopeFilter 为过滤条件加上租户限制
+func (tc *TenantCollection) scopeFilter(tenantID string, filter bson.M) bson.M {
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return filter
+	}
+	scoped := bson.M{}
+	for key, value := range filter {
+		scoped[key] = value
+	}
+	scoped[tc.opts.Field] = tenantID
+	return scoped
+}
+
+// scopeDocument 为待写入的文档设置租户字段
+func (tc *TenantCollection) scopeDocument(tenantID string, document interface{}) (interface{}, error) {
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return document, nil
+	}
+	m, err := toBsonM(document)
+	if err != nil {
+		return nil, err
+	}
+	if existing, ok := m[tc.opts.Field]; ok && existing != "" && existing != tenantID {
+		return nil, fmt.Errorf("%w: document belongs to tenant %v", ErrTenantMismatch, existing)
+	}
+	m[tc.opts.Field] = tenantID
+	return m, nil
+}
+
+// scopeEncrypted 加密文档后设置租户字段，返回的 bson.M 中为密文，调用方的文档恢复为明文
+func (tc *TenantCollection) scopeEncrypted(c *Collection, tenantID string, document interface{}) (interface{}, error) {
+	restore, err := c.encryptDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	defer restore()
+	return tc.scopeDocument(tenantID, document)
+}
+
+// checkUpdate 禁止更新操作修改租户字段
+func (tc *TenantCollection) checkUpdate(update bson.M) error {
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return nil
+	}
+	for _, fields := range update {
+		m, ok := toM(fields)
+		if !ok {
+			continue
+		}
+		for key, value := range m {
+			if key == tc.opts.Field || strings.HasPrefix(key, tc.opts.Field+".") || value == tc.opts.Field {
+				return fmt.Errorf("%w: update must not modify %s", ErrTenantMismatch, tc.opts.Field)
+			}
+		}
+	}
+	return nil
+}
+
+// InsertOne 插入单个文档
+func (tc *TenantCollection) InsertOne(ctx context.Context, document interface{}) (*mongo.InsertOneResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return c.InsertOne(ctx, document)
+	}
+
+	if err := c.prepareInsert(document); err != nil {
+		return nil, err
+	}
+	scoped, err := tc.scopeEncrypted(c, tenantID, document)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.InsertOne(ctx, scoped)
+	if err != nil {
+		return nil, err
+	}
+	if err := setInsertedID(document, result.InsertedID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InsertMany 插入多个文档
+func (tc *TenantCollection) InsertMany(ctx context.Context, documents []interface{}) (*mongo.InsertManyResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return c.InsertMany(ctx, documents)
+	}
+
+	scoped := make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		if err := c.prepareInsert(document); err != nil {
+			return nil, err
+		}
+		m, err := tc.scopeEncrypted(c, tenantID, document)
+		if err != nil {
+			return nil, err
+		}
+		scoped = append(scoped, m)
+	}
+	return c.InsertMany(ctx, scoped)
+}
+
+// FindOne 查找单个文档
+func (tc *TenantCollection) FindOne(ctx context.Context, filter bson.M, result interface{}, opts ...*options.FindOneOptions) error {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return c.FindOne(ctx, tc.scopeFilter(tenantID, filter), result, opts...)
+}
+
+// FindByID 根据ID查找文档，id 的规则与 Collection.FindByID 相同
+func (tc *TenantCollection) FindByID(ctx context.Context, id interface{}, result interface{}, opts ...*options.FindOneOptions) error {
+	docID, err := tc.documentID(id)
+	if err != nil {
+		return err
+	}
+	return tc.FindOne(ctx, bson.M{"_id": docID}, result, opts...)
+}
+
+// Find 查找多个文档
+func (tc *TenantCollection) Find(ctx context.Context, filter bson.M, results interface{}, opts ...*options.FindOptions) error {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	return c.Find(ctx, tc.scopeFilter(tenantID, filter), results, opts...)
+}
+
+// FindWithPagination 分页查找文档
+func (tc *TenantCollection) FindWithPagination(ctx context.Context, filter bson.M, page, pageSize int64, results interface{}, opts ...*options.FindOptions) (*PaginationResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.FindWithPagination(ctx, tc.scopeFilter(tenantID, filter), page, pageSize, results, opts...)
+}
+
+// Count 计算文档数量
+func (tc *TenantCollection) Count(ctx context.Context, filter bson.M) (int64, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return c.Count(ctx, tc.scopeFilter(tenantID, filter))
+}
+
+// Exists 检查文档是否存在
+func (tc *TenantCollection) Exists(ctx context.Context, filter bson.M) (bool, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return false, err
+	}
+	return c.Exists(ctx, tc.scopeFilter(tenantID, filter))
+}
+
+// Distinct 获取字段的去重值
+func (tc *TenantCollection) Distinct(ctx context.Context, field string, filter bson.M, opts ...*options.DistinctOptions) ([]interface{}, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Distinct(ctx, field, tc.scopeFilter(tenantID, filter), opts...)
+}
+
+// UpdateOne 更新单个文档
+func (tc *TenantCollection) UpdateOne(ctx context.Context, filter bson.M, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tc.checkUpdate(update); err != nil {
+		return nil, err
+	}
+	return c.UpdateOne(ctx, tc.scopeFilter(tenantID, filter), update, opts...)
+}
+
+// UpdateByID 根据ID更新文档，id 的规则与 Collection.FindByID 相同
+func (tc *TenantCollection) UpdateByID(ctx context.Context, id interface{}, update bson.M, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	docID, err := tc.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	return tc.UpdateOne(ctx, bson.M{"_id": docID}, update, opts...)
+}
+
+// UpdateMany 更新多个文档，过滤条件为空（即租户的所有文档）时同样需要 AllowFullCollection()
+func (tc *TenantCollection) UpdateMany(ctx context.Context, filter bson.M, update bson.M, opts ...*MassWriteOptions) (*mongo.UpdateResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tc.checkUpdate(update); err != nil {
+		return nil, err
+	}
+	if err := checkFullCollection("updateMany", c.collection.Name(), filter, mergeMassWriteOptions(opts)); err != nil {
+		return nil, err
+	}
+	return c.UpdateMany(ctx, tc.scopeFilter(tenantID, filter), update, opts...)
+}
+
+// Upsert 按过滤条件更新文档，不存在时插入
+func (tc *TenantCollection) Upsert(ctx context.Context, filter bson.M, document interface{}) (*mongo.UpdateResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return c.Upsert(ctx, filter, document)
+	}
+
+	// 默认值、校验和字段加密依赖结构体标签，必须在转换为 bson.M 之前完成
+	if err := ApplyDefaults(document); err != nil {
+		return nil, err
+	}
+	if err := c.validate(document); err != nil {
+		return nil, err
+	}
+	scoped, err := tc.scopeEncrypted(c, tenantID, document)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.Upsert(ctx, tc.scopeFilter(tenantID, filter), scoped)
+	if err != nil {
+		return nil, err
+	}
+	if doc, ok := document.(Document); ok {
+		doc.SetUpdatedAt(time.Now())
+	}
+	if err := setInsertedID(document, result.UpsertedID); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ReplaceOne 替换单个文档
+func (tc *TenantCollection) ReplaceOne(ctx context.Context, filter bson.M, replacement interface{}) (*mongo.UpdateResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		return c.ReplaceOne(ctx, filter, replacement)
+	}
+
+	if err := c.prepareUpdate(replacement); err != nil {
+		return nil, err
+	}
+	scoped, err := tc.scopeEncrypted(c, tenantID, replacement)
+	if err != nil {
+		return nil, err
+	}
+	return c.ReplaceOne(ctx, tc.scopeFilter(tenantID, filter), scoped)
+}
+
+// DeleteOne 删除单个文档
+func (tc *TenantCollection) DeleteOne(ctx context.Context, filter bson.M) (*mongo.DeleteResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.DeleteOne(ctx, tc.scopeFilter(tenantID, filter))
+}
+
+// DeleteByID 根据ID删除文档，id 的规则与 Collection.FindByID 相同
+func (tc *TenantCollection) DeleteByID(ctx context.Context, id interface{}) (*mongo.DeleteResult, error) {
+	docID, err := tc.documentID(id)
+	if err != nil {
+		return nil, err
+	}
+	return tc.DeleteOne(ctx, bson.M{"_id": docID})
+}
+
+// DeleteMany 删除多个文档，过滤条件为空（即租户的所有文档）时同样需要 AllowFullCollection()
+func (tc *TenantCollection) DeleteMany(ctx context.Context, filter bson.M, opts ...*MassWriteOptions) (*mongo.DeleteResult, error) {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFullCollection("deleteMany", c.collection.Name(), filter, mergeMassWriteOptions(opts)); err != nil {
+		return nil, err
+	}
+	return c.DeleteMany(ctx, tc.scopeFilter(tenantID, filter), opts...)
+}
+
+// crossCollectionStages 可能读写其它集合、绕过租户过滤的聚合阶段
+var crossCollectionStages = []string{"$lookup", "$graphLookup", "$unionWith", "$out", "$merge"}
+
+// Aggregate 聚合查询，字段隔离时在管道最前面加入租户过滤
+// 字段隔离模式下禁止使用 $lookup、$graphLookup、$unionWith、$out、$merge，包括 $facet 等阶段内的子管道，避免跨租户访问；
+// 数据库隔离模式下允许这些阶段访问租户数据库中的其它集合，但禁止通过 db 指定其它数据库
+func (tc *TenantCollection) Aggregate(ctx context.Context, pipeline []bson.M, results interface{}, opts ...*options.AggregateOptions) error {
+	c, tenantID, err := tc.resolve(ctx)
+	if err != nil {
+		return err
+	}
+	if tc.opts.Isolation == TenantIsolationDatabase {
+		if err := checkTenantDatabasePipeline(pipeline, c.collection.Database().Name()); err != nil {
+			return err
+		}
+		return c.Aggregate(ctx, pipeline, results, opts...)
+	}
+
+	if err := checkTenantPipeline(pipeline); err != nil {
+		return err
+	}
+	scoped := append([]bson.M{{"$match": bson.M{tc.opts.Field: tenantID}}}, pipeline...)
+	return c.Aggregate(ctx, scoped, results, opts...)
+}
+
+// checkTenantPipeline 检查管道中任意深度的阶段，$facet 的子管道、$lookup 和 $unionWith 的 pipeline 都会被检查
+func checkTenantPipeline(pipeline []bson.M) error {
+	raw, err := bson.Marshal(bson.M{"pipeline": pipeline})
+	if err != nil {
+		return fmt.Errorf("failed to check tenant scoped pipeline: %w", err)
+	}
+	if name, ok := findCrossCollectionStage(raw); ok {
+		return fmt.Errorf("%w: stage %s is not allowed in tenant scoped aggregation", ErrTenantMismatch, name)
+	}
+	return nil
+}
+
+// checkTenantDatabasePipeline 检查管道中任意深度的跨集合阶段是否通过 db 访问租户数据库以外的数据库
+func checkTenantDatabasePipeline(pipeline []bson.M, database string) error {
+	raw, err := bson.Marshal(bson.M{"pipeline": pipeline})
+	if err != nil {
+		return fmt.Errorf("failed to check tenant scoped pipeline: %w", err)
+	}
+	if name, ok := findForeignDatabase(raw, database); ok {
+		return fmt.Errorf("%w: stage %s must not access another database", ErrTenantMismatch, name)
+	}
+	return nil
+}
+
+// findForeignDatabase 递归查找指定了其它数据库的跨集合阶段，
+// 例如 {$out: {db: ...}}、{$merge: {into: {db: ...}}}、{$lookup: {from: {db: ...}}}、{$unionWith: {coll: {db: ...}}}
+func findForeignDatabase(doc bson.Raw, database string) (string, bool) {
+	elements, err := doc.Elements()
+	if err != nil {
+		return "", false
+	}
+	for _, element := range elements {
+		value := element.Value()
+		if slices.Contains(crossCollectionStages, element.Key()) && value.Type == bsontype.EmbeddedDocument {
+			spec := value.Document()
+			for _, target := range []bson.RawValue{spec.Lookup("db"), spec.Lookup("from", "db"), spec.Lookup("into", "db"), spec.Lookup("coll", "db")} {
+				if name, ok := target.StringValueOK(); target.Type != 0 && (!ok || name != database) {
+					return element.Key(), true
+				}
+			}
+		}
+		var nested bson.Raw
+		switch value.Type {
+		case bsontype.EmbeddedDocument:
+			nested = value.Document()
+		case bsontype.Array:
+			nested = bson.Raw(value.Array())
+		default:
+			continue
+		}
+		if name, ok := findForeignDatabase(nested, database); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// findCrossCollectionStage 递归查找文档中作为键出现的跨集合阶段，字段名不能以 $ 开头，因此不会误判
+func findCrossCollectionStage(doc bson.Raw) (string, bool) {
+	elements, err := doc.Elements()
+	if err != nil {
+		return "", false
+	}
+	for _, element := range elements {
+		if slices.Contains(crossCollectionStages, element.Key()) {
+			return element.Key(), true
+		}
+		value := element.Value()
+		var nested bson.Raw
+		switch value.Type {
+		case bsontype.EmbeddedDocument:
+			nested = value.Document()
+		case bsontype.Array:
+			nested = bson.Raw(value.Array())
+		default:
+			continue
+		}
+		if name, ok := findCrossCollectionStage(nested); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,166 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type tenantNote struct {
+	BaseDocument `bson:",inline"`
+	TenantID     string `bson:"tenant_id,omitempty"`
+	Title        string `bson:"title" validate:"required"`
+	Status       string `bson:"status" default:"draft"`
+}
+
+func TestTenantCollectionScoping(t *testing.T) {
+	server := newFakeServer(t, false)
+	notes := NewTenantCollection(server.client(t), "notes", TenantOptions{})
+	ctx := WithTenant(t.Context(), "acme")
+
+	var found []tenantNote
+	require.NoError(t, notes.Find(ctx, bson.M{"title": "a"}, &found))
+	_, err := notes.UpdateOne(ctx, bson.M{"title": "a"}, bson.M{"$set": bson.M{"title": "b"}})
+	require.NoError(t, err)
+	_, err = notes.DeleteOne(ctx, bson.M{"title": "b"})
+	require.NoError(t, err)
+
+	find := server.Commands("find")
+	require.Len(t, find, 1)
+	assert.Equal(t, "acme", find[0].Lookup("filter", "tenant_id").StringValue())
+	update := server.Commands("update")
+	require.Len(t, update, 1)
+	assert.Equal(t, "acme", update[0].Lookup("updates").Array().Index(0).Value().Document().Lookup("q", "tenant_id").StringValue())
+	del := server.Commands("delete")
+	require.Len(t, del, 1)
+	assert.Equal(t, "acme", del[0].Lookup("deletes").Array().Index(0).Value().Document().Lookup("q", "tenant_id").StringValue())
+
+	note := &tenantNote{Title: "hello"}
+	_, err = notes.InsertOne(ctx, note)
+	require.NoError(t, err)
+	assert.False(t, note.ID.IsZero())
+	inserted := server.Commands("insert")[0].Lookup("documents").Array().Index(0).Value().Document()
+	assert.Equal(t, "acme", inserted.Lookup("tenant_id").StringValue())
+	assert.Equal(t, "draft", inserted.Lookup("status").StringValue())
+	assert.Empty(t, note.TenantID, "caller document is not modified")
+}
+
+func TestTenantCollectionReplaceAndUpsert(t *testing.T) {
+	server := newFakeServer(t, false)
+	notes := NewTenantCollection(server.client(t), "notes", TenantOptions{})
+	ctx := WithTenant(t.Context(), "acme")
+
+	// 校验和默认值在转换为 bson.M 之前执行
+	_, err := notes.ReplaceOne(ctx, bson.M{"title": "a"}, &tenantNote{})
+	assert.Error(t, err)
+	_, err = notes.Upsert(ctx, bson.M{"title": "a"}, &tenantNote{})
+	assert.Error(t, err)
+	assert.Empty(t, server.Commands("update"))
+
+	_, err = notes.ReplaceOne(ctx, bson.M{"title": "a"}, &tenantNote{Title: "a"})
+	require.NoError(t, err)
+	_, err = notes.Upsert(ctx, bson.M{"title": "b"}, &tenantNote{Title: "b"})
+	require.NoError(t, err)
+
+	updates := server.Commands("update")
+	require.Len(t, updates, 2)
+	replace := updates[0].Lookup("updates").Array().Index(0).Value().Document()
+	assert.Equal(t, "acme", replace.Lookup("q", "tenant_id").StringValue())
+	assert.Equal(t, "acme", replace.Lookup("u", "tenant_id").StringValue())
+	upsert := updates[1].Lookup("updates").Array().Index(0).Value().Document()
+	assert.Equal(t, "acme", upsert.Lookup("q", "tenant_id").StringValue())
+	assert.Equal(t, "acme", upsert.Lookup("u", "$set", "tenant_id").StringValue())
+	assert.Equal(t, "draft", upsert.Lookup("u", "$set", "status").StringValue())
+}
+
+func TestTenantCollectionRejectsOtherTenants(t *testing.T) {
+	client := newLazyClient(t)
+	notes := NewTenantCollection(client, "notes", TenantOptions{})
+	ctx := WithTenant(t.Context(), "acme")
+
+	_, err := notes.InsertOne(t.Context(), &tenantNote{Title: "a"})
+	assert.ErrorIs(t, err, ErrTenantRequired)
+	_, err = notes.InsertOne(ctx, &tenantNote{TenantID: "other", Title: "a"})
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	_, err = notes.ReplaceOne(ctx, bson.M{}, &tenantNote{TenantID: "other", Title: "a"})
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	_, err = notes.Upsert(ctx, bson.M{}, &tenantNote{TenantID: "other", Title: "a"})
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	_, err = notes.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{"tenant_id": "other"}})
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+}
+
+func TestTenantAggregateRejectsCrossCollectionStages(t *testing.T) {
+	client := newLazyClient(t)
+	notes := NewTenantCollection(client, "notes", TenantOptions{})
+	ctx := WithTenant(t.Context(), "acme")
+
+	pipelines := map[string][]bson.M{
+		"top level": {{"$lookup": bson.M{"from": "users", "localField": "a", "foreignField": "b", "as": "c"}}},
+		"facet": {{"$facet": bson.M{"users": bson.A{
+			bson.M{"$unionWith": "users"},
+		}}}},
+		"nested facet": {{"$facet": bson.M{"outer": bson.A{
+			bson.M{"$facet": bson.M{"inner": bson.A{bson.D{{Key: "$graphLookup", Value: bson.M{"from": "users"}}}}}},
+		}}}},
+		"union pipeline": {{"$unionWith": bson.M{"coll": "notes", "pipeline": bson.A{bson.M{"$lookup": bson.M{"from": "users"}}}}}},
+		"facet merge":    {{"$facet": bson.M{"a": []bson.M{{"$merge": "other"}}}}},
+	}
+	for name, pipeline := range pipelines {
+		t.Run(name, func(t *testing.T) {
+			var results []bson.M
+			assert.ErrorIs(t, notes.Aggregate(ctx, pipeline, &results), ErrTenantMismatch)
+		})
+	}
+
+	assert.NoError(t, checkTenantPipeline([]bson.M{
+		{"$match": bson.M{"status": "published"}},
+		{"$facet": bson.M{"count": bson.A{bson.M{"$count": "n"}}}},
+	}))
+}
+
+func TestTenantDatabaseAggregateRejectsOtherDatabases(t *testing.T) {
+	server := newFakeServer(t, false)
+	notes := NewTenantCollection(server.client(t), "notes", TenantOptions{Isolation: TenantIsolationDatabase})
+	ctx := WithTenant(t.Context(), "acme")
+
+	pipelines := map[string][]bson.M{
+		"out":           {{"$out": bson.M{"db": "test_other", "coll": "notes"}}},
+		"merge":         {{"$merge": bson.M{"into": bson.M{"db": "test_other", "coll": "notes"}}}},
+		"lookup":        {{"$lookup": bson.M{"from": bson.M{"db": "test_other", "coll": "users"}, "as": "u"}}},
+		"union":         {{"$unionWith": bson.M{"coll": bson.M{"db": "test_other", "coll": "notes"}}}},
+		"graph lookup":  {{"$graphLookup": bson.M{"from": bson.M{"db": "test_other", "coll": "users"}}}},
+		"nested lookup": {{"$facet": bson.M{"a": bson.A{bson.M{"$lookup": bson.M{"from": "users", "pipeline": bson.A{bson.M{"$unionWith": bson.M{"coll": bson.M{"db": "admin", "coll": "system.users"}}}}}}}}}},
+	}
+	for name, pipeline := range pipelines {
+		t.Run(name, func(t *testing.T) {
+			var results []bson.M
+			assert.ErrorIs(t, notes.Aggregate(ctx, pipeline, &results), ErrTenantMismatch)
+		})
+	}
+	assert.Empty(t, server.Commands("aggregate"))
+
+	// 租户数据库内的跨集合阶段允许执行
+	var results []bson.M
+	require.NoError(t, notes.Aggregate(ctx, []bson.M{
+		{"$lookup": bson.M{"from": "users", "localField": "a", "foreignField": "b", "as": "c"}},
+		{"$merge": bson.M{"into": bson.M{"db": "test_acme", "coll": "summary"}}},
+	}, &results))
+	aggregate := server.Commands("aggregate")
+	require.Len(t, aggregate, 1)
+	assert.Equal(t, "test_acme", aggregate[0].Lookup("$db").StringValue())
+}
+
+func TestTenantDatabaseCollectionDefaults(t *testing.T) {
+	client := newLazyClient(t)
+	client.operationTimeout = time.Minute
+	notes := NewTenantCollection(client, "notes", TenantOptions{Isolation: TenantIsolationDatabase, IDStrategy: UUIDv4Strategy})
+	c, _, err := notes.resolve(WithTenant(t.Context(), "acme"))
+	require.NoError(t, err)
+	assert.Equal(t, "test_acme", c.collection.Database().Name())
+	assert.Equal(t, time.Minute, c.operationTimeout())
+	assert.IsType(t, UUID{}, c.IDStrategy().NewID())
+}
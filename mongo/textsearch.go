@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TextSearchOptions 全文搜索选项
+type TextSearchOptions struct {
+	// Filter 与全文搜索同时生效的过滤条件
+	Filter bson.M
+	// Language 分词和词干使用的语言，默认使用文本索引的语言
+	Language string
+	// CaseSensitive 是否区分大小写
+	CaseSensitive bool
+	// DiacriticSensitive 是否区分变音符号
+	DiacriticSensitive bool
+	// ScoreField 写入相关度得分的字段，默认 score
+	ScoreField string
+	// Projection 结果投影，会自动加上得分字段，与得分字段冲突的路径被忽略
+	Projection bson.M
+	// Page 页码，从 1 开始
+	Page int64
+	// PageSize 每页数量，默认 DefaultPageSize
+	PageSize int64
+}
+
+// TextSearch 使用文本索引搜索文档，结果按相关度得分从高到低分页返回
+// 结果结构体可以声明得分字段接收相关度，例如 Score float64 `bson:"score"`；集合上需要先通过 CreateTextIndex 创建文本索引
+func (c *Collection) TextSearch(ctx context.Context, query string, results interface{}, opts *TextSearchOptions) (*PaginationResult, error) {
+	if opts == nil {
+		opts = &TextSearchOptions{}
+	}
+	scoreField := opts.ScoreField
+	if scoreField == "" {
+		scoreField = "score"
+	}
+
+	filter := bson.M{}
+	for key, value := range opts.Filter {
+		filter[key] = value
+	}
+	filter["$text"] = textSearchExpression(query, opts)
+
+	score := bson.M{"$meta": "textScore"}
+	projection := bson.M{}
+	for key, value := range opts.Projection {
+		// 与得分字段相同或互为父子路径的投影会覆盖得分或导致路径冲突，忽略
+		if key == scoreField || strings.HasPrefix(key, scoreField+".") || strings.HasPrefix(scoreField, key+".") {
+			continue
+		}
+		projection[key] = value
+	}
+	projection[scoreField] = score
+
+	findOptions := options.Find().
+		SetProjection(projection).
+		SetSort(bson.D{{Key: scoreField, Value: score}})
+	return c.FindWithPagination(ctx, filter, opts.Page, opts.PageSize, results, findOptions)
+}
+
+// textSearchExpression 构建 $text 查询表达式
+func textSearchExpression(query string, opts *TextSearchOptions) bson.M {
+	text := bson.M{"$search": query}
+	if opts.Language != "" {
+		text["$language"] = opts.Language
+	}
+	if opts.CaseSensitive {
+		text["$caseSensitive"] = true
+	}
+	if opts.DiacriticSensitive {
+		text["$diacriticSensitive"] = true
+	}
+	return text
+}
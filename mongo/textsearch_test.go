@@ -0,0 +1,63 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTextSearchExpression(t *testing.T) {
+	tests := []struct {
+		opts     TextSearchOptions
+		expected bson.M
+	}{
+		{TextSearchOptions{}, bson.M{"$search": "mongo go"}},
+		{TextSearchOptions{Language: "english"}, bson.M{"$search": "mongo go", "$language": "english"}},
+		{TextSearchOptions{CaseSensitive: true}, bson.M{"$search": "mongo go", "$caseSensitive": true}},
+		{TextSearchOptions{DiacriticSensitive: true}, bson.M{"$search": "mongo go", "$diacriticSensitive": true}},
+		{
+			TextSearchOptions{Language: "none", CaseSensitive: true, DiacriticSensitive: true},
+			bson.M{"$search": "mongo go", "$language": "none", "$caseSensitive": true, "$diacriticSensitive": true},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, textSearchExpression("mongo go", &tt.opts))
+	}
+}
+
+func TestTextSearchCommand(t *testing.T) {
+	server := newFakeServer(t, false)
+	articles := NewCollection(server.client(t), "articles")
+
+	var results []bson.M
+	page, err := articles.TextSearch(t.Context(), "mongo", &results, &TextSearchOptions{
+		Filter: bson.M{"status": "published", "$text": bson.M{"$search": "ignored"}},
+		// 调用方投影不能覆盖或排除得分字段
+		Projection: bson.M{"title": 1, "relevance": 0, "relevance.value": 1},
+		ScoreField: "relevance",
+		Page:       2,
+		PageSize:   5,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.Page)
+
+	find := server.Commands("find")
+	require.Len(t, find, 1)
+	cmd := find[0]
+	assert.Equal(t, "published", cmd.Lookup("filter", "status").StringValue())
+	assert.Equal(t, "mongo", cmd.Lookup("filter", "$text", "$search").StringValue())
+	assert.Equal(t, int32(1), cmd.Lookup("projection", "title").Int32())
+	assert.Equal(t, "textScore", cmd.Lookup("projection", "relevance", "$meta").StringValue())
+	_, err = cmd.Lookup("projection").Document().LookupErr("relevance.value")
+	assert.Error(t, err)
+	// 按得分排序，_id 作为稳定排序的次要键
+	sort, err := cmd.Lookup("sort").Document().Elements()
+	require.NoError(t, err)
+	require.NotEmpty(t, sort)
+	assert.Equal(t, "relevance", sort[0].Key())
+	assert.Equal(t, "textScore", sort[0].Value().Document().Lookup("$meta").StringValue())
+	assert.Equal(t, int64(5), cmd.Lookup("skip").AsInt64())
+	assert.Equal(t, int64(5), cmd.Lookup("limit").AsInt64())
+}
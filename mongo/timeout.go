@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout 返回使用指定操作超时的集合副本，覆盖 Config.OperationTimeout；d <= 0 表示不附加超时
+// 适合报表等允许长时间运行的查询，例如 reports := articles.WithTimeout(2 * time.Minute)
+func (c *Collection) WithTimeout(d time.Duration) *Collection {
+	cp := *c
+	cp.timeout = &d
+	return &cp
+}
+
+// operationTimeout 集合生效的操作超时，WithTimeout 优先于客户端配置
+func (c *Collection) operationTimeout() time.Duration {
+	if c.timeout != nil {
+		return *c.timeout
+	}
+	if c.cli == nil {
+		return 0
+	}
+	return c.cli.operationTimeout
+}
+
+// operationContext 为单次操作准备 ctx：注入会话，记录进行中的操作供 Client.Shutdown 等待，
+// 在配置了并发限制时等待执行许可，并在配置了操作超时且 ctx 没有 deadline 时附加超时（排队时间不计入操作超时）；
+// 调用方已经设置的 deadline 保持不变，返回的 done 必须在操作结束后调用以释放许可
+func (c *Collection) operationContext(ctx context.Context) (context.Context, func(), error) {
+	ctx = c.sessionContext(ctx)
+	release := func() {}
+	if c.cli != nil {
+		if err := c.cli.beginOperation(); err != nil {
+			return ctx, nil, err
+		}
+		release = c.cli.endOperation
+	}
+	if l := c.operationLimiter(); l != nil {
+		limited, releaseLimiter, err := l.Acquire(ctx)
+		if err != nil {
+			release()
+			return ctx, nil, err
+		}
+		ctx = limited
+		endOperation := release
+		release = func() {
+			releaseLimiter()
+			endOperation()
+		}
+	}
+
+	timeout := c.operationTimeout()
+	if timeout <= 0 {
+		return ctx, release, nil
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, release, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		release()
+	}, nil
+}
+
+// maxTime 根据 ctx 剩余时间计算服务端 maxTimeMS，使服务端在客户端放弃等待后同样终止查询、释放连接；
+// 未配置操作超时或 ctx 没有 deadline 时返回 0，调用方在 opts 中显式设置的 MaxTime 优先
+func (c *Collection) maxTime(ctx context.Context) time.Duration {
+	if c.operationTimeout() <= 0 {
+		return 0
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(deadline)
+	if remaining < time.Millisecond {
+		// maxTimeMS 为 0 表示不限制，已经过期的 ctx 交给驱动返回 deadline 错误
+		return 0
+	}
+	return remaining
+}
+
+// withMaxTime 将 maxTime 作为第一个选项插入 opts，驱动按顺序合并选项，调用方传入的 MaxTime 会覆盖它
+func withMaxTime[T any](ctx context.Context, c *Collection, opts []*T, newOpt func(time.Duration) *T) []*T {
+	d := c.maxTime(ctx)
+	if d <= 0 {
+		return opts
+	}
+	return append([]*T{newOpt(d)}, opts...)
+}
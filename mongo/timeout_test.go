@@ -0,0 +1,87 @@
+package mongo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestOperationContext(t *testing.T) {
+	c := &Collection{cli: &Client{operationTimeout: time.Second}}
+
+	ctx, done, err := c.operationContext(context.Background())
+	require.NoError(t, err)
+	defer done()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+
+	// 调用方的 deadline 保持不变
+	callerCtx, callerCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer callerCancel()
+	ctx, done, err = c.operationContext(callerCtx)
+	require.NoError(t, err)
+	defer done()
+	assert.Equal(t, callerCtx, ctx)
+
+	ctx, done, err = c.WithTimeout(0).operationContext(context.Background())
+	require.NoError(t, err)
+	defer done()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+
+	ctx, done, err = (&Collection{cli: &Client{}}).operationContext(context.Background())
+	require.NoError(t, err)
+	defer done()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestWithMaxTime(t *testing.T) {
+	c := &Collection{cli: &Client{operationTimeout: time.Second}}
+	ctx, done, err := c.operationContext(context.Background())
+	require.NoError(t, err)
+	defer done()
+
+	opts := withMaxTime(ctx, c, nil, options.Find().SetMaxTime)
+	merged := mergeFindOptions(opts)
+	if assert.NotNil(t, merged.MaxTime) {
+		assert.InDelta(t, time.Second, *merged.MaxTime, float64(100*time.Millisecond))
+	}
+
+	// 调用方显式设置的 MaxTime 优先
+	opts = withMaxTime(ctx, c, []*options.FindOptions{options.Find().SetMaxTime(5 * time.Second)}, options.Find().SetMaxTime)
+	assert.Equal(t, 5*time.Second, *mergeFindOptions(opts).MaxTime)
+
+	assert.Empty(t, withMaxTime(context.Background(), c, nil, options.Find().SetMaxTime))
+	assert.Empty(t, withMaxTime(ctx, &Collection{cli: &Client{}}, nil, options.Find().SetMaxTime))
+}
+
+func TestEstimatedDocumentCountUsesOperationContext(t *testing.T) {
+	server := newFakeServer(t, false)
+	client := server.client(t)
+	articles := NewCollection(client, "articles")
+
+	// 与 Count 一样附加服务端 maxTimeMS
+	_, err := articles.WithTimeout(time.Minute).EstimatedDocumentCount(context.Background())
+	require.NoError(t, err)
+	count := server.Commands("count")
+	require.Len(t, count, 1)
+	maxTime, ok := count[0].Lookup("maxTimeMS").AsInt64OK()
+	require.True(t, ok)
+	assert.InDelta(t, time.Minute.Milliseconds(), maxTime, 1000)
+
+	// 并发限制同样生效
+	limiter := NewLimiter(&LimiterOptions{MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond})
+	limited := articles.WithLimiter(limiter)
+	_, release, err := limiter.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+	_, err = limited.EstimatedDocumentCount(context.Background())
+	assert.ErrorIs(t, err, ErrLimiterTimeout)
+	assert.Len(t, server.Commands("count"), 1)
+}
@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig TLS 连接配置
+type TLSConfig struct {
+	// CAFile 用于校验服务端证书的 CA 证书文件（PEM），为空时使用系统根证书
+	CAFile string `json:"ca_file,omitempty"`
+	// CertFile/KeyFile 客户端证书和私钥（PEM），用于双向 TLS 或 X.509 认证
+	CertFile string `json:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty"`
+	// InsecureSkipVerify 跳过服务端证书校验，仅用于测试环境
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+	// ServerName 校验证书时使用的主机名（SNI），为空时使用连接地址中的主机名
+	ServerName string `json:"server_name,omitempty"`
+}
+
+// buildTLSConfig 根据配置构建 tls.Config
+func buildTLSConfig(config *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		ServerName:         config.ServerName,
+	}
+
+	if config.CAFile != "" {
+		caPEM, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in CA file %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		if config.CertFile == "" || config.KeyFile == "" {
+			return nil, fmt.Errorf("both cert_file and key_file are required for client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,84 @@
+package mongo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate 在临时目录生成自签名证书和私钥，返回 PEM 文件路径
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mongo.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	config, err := buildTLSConfig(&TLSConfig{ServerName: "db.internal", InsecureSkipVerify: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, "db.internal", config.ServerName)
+	assert.True(t, config.InsecureSkipVerify)
+	assert.Nil(t, config.RootCAs, "system roots are used without a CA file")
+	assert.Empty(t, config.Certificates)
+
+	config, err = buildTLSConfig(&TLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+	assert.False(t, config.InsecureSkipVerify)
+}
+
+func TestBuildTLSConfigErrors(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+
+	tests := map[string]*TLSConfig{
+		"missing CA file":   {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"invalid CA file":   {CAFile: empty},
+		"cert without key":  {CertFile: certFile},
+		"key without cert":  {KeyFile: keyFile},
+		"mismatched key":    {CertFile: certFile, KeyFile: empty},
+		"missing cert file": {CertFile: filepath.Join(t.TempDir(), "missing.pem"), KeyFile: keyFile},
+	}
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildTLSConfig(config)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrTokenInvalid 令牌不存在、已使用、已过期或用途不符
+var ErrTokenInvalid = errors.New("token is invalid or expired")
+
+// TokenPurpose 令牌用途，不同用途的令牌不能互相使用
+type TokenPurpose string
+
+const (
+	TokenRefresh           TokenPurpose = "refresh"
+	TokenPasswordReset     TokenPurpose = "password_reset"
+	TokenEmailVerification TokenPurpose = "email_verification"
+)
+
+// Token 令牌记录，集合中只保存令牌的 SHA-256 摘要
+type Token struct {
+	Hash    string       `bson:"_id" json:"-"`
+	UserID  string       `bson:"user_id" json:"user_id"`
+	Purpose TokenPurpose `bson:"purpose" json:"purpose"`
+	// Metadata 签发时附带的信息，例如待验证的邮箱地址、设备名称
+	Metadata  bson.M    `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+// TokenStore 签发一次性的不透明令牌，用于刷新令牌、重置密码链接、邮箱验证等；
+// 令牌只能通过 Consume 使用一次（FindOneAndDelete 保证并发请求中只有一个成功），过期令牌由 TTL 索引清理
+//
+//	tokens := NewTokenStore(client, "")
+//	tokens.EnsureIndexes(ctx)
+//	raw, err := tokens.Issue(ctx, user.ID, TokenPasswordReset, time.Hour, nil)
+//	sendResetEmail(user.Email, "https://example.com/reset?token="+raw)
+//	...
+//	token, err := tokens.Consume(ctx, r.FormValue("token"), TokenPasswordReset)
+//	if errors.Is(err, ErrTokenInvalid) {
+//		// 链接无效或已使用
+//	}
+type TokenStore struct {
+	client     *Client
+	collection *mongo.Collection
+}
+
+// NewTokenStore 创建令牌存储，collection 为空时使用 tokens 集合
+func NewTokenStore(client *Client, collection string) *TokenStore {
+	if collection == "" {
+		collection = "tokens"
+	}
+	return &TokenStore{client: client, collection: client.GetCollection(collection)}
+}
+
+// EnsureIndexes 创建过期令牌的 TTL 索引和按用户撤销令牌的索引
+func (s *TokenStore) EnsureIndexes(ctx context.Context) error {
+	if err := s.client.checkWritable("createIndexes", s.collection.Name()); err != nil {
+		return err
+	}
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("idx_expires_at_ttl").SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}}, Options: options.Index().SetName("idx_user_id_purpose")},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create token indexes: %w", err)
+	}
+	return nil
+}
+
+// Issue 签发令牌，返回的原始令牌只在此时可见，应当直接发送给用户
+func (s *TokenStore) Issue(ctx context.Context, userID string, purpose TokenPurpose, ttl time.Duration, metadata bson.M) (string, error) {
+	if userID == "" || purpose == "" {
+		return "", fmt.Errorf("user and purpose are required to issue a token")
+	}
+	if ttl <= 0 {
+		return "", fmt.Errorf("invalid token ttl %v", ttl)
+	}
+	if err := s.client.checkWritable("insert", s.collection.Name()); err != nil {
+		return "", err
+	}
+	raw, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := Token{
+		Hash:      hashToken(raw),
+		UserID:    userID,
+		Purpose:   purpose,
+		Metadata:  metadata,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if _, err := s.collection.InsertOne(ctx, token); err != nil {
+		return "", fmt.Errorf("failed to issue %s token: %w", purpose, err)
+	}
+	return raw, nil
+}
+
+// Consume 验证并删除令牌，令牌无效时返回 ErrTokenInvalid；同一令牌并发使用时只有一次成功
+func (s *TokenStore) Consume(ctx context.Context, raw string, purpose TokenPurpose) (*Token, error) {
+	if err := s.client.checkWritable("delete", s.collection.Name()); err != nil {
+		return nil, err
+	}
+	var token Token
+	err := s.collection.FindOneAndDelete(ctx, tokenFilter(raw, purpose)).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume token: %w", err)
+	}
+	return &token, nil
+}
+
+// Verify 验证令牌但不使用，例如在显示重置密码表单前检查链接是否有效
+func (s *TokenStore) Verify(ctx context.Context, raw string, purpose TokenPurpose) (*Token, error) {
+	var token Token
+	err := s.collection.FindOne(ctx, tokenFilter(raw, purpose)).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify token: %w", err)
+	}
+	return &token, nil
+}
+
+// Revoke 撤销令牌，令牌不存在时不返回错误
+func (s *TokenStore) Revoke(ctx context.Context, raw string) error {
+	if err := s.client.checkWritable("delete", s.collection.Name()); err != nil {
+		return err
+	}
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": hashToken(raw)}); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser 撤销用户指定用途的所有令牌，purpose 为空时撤销所有用途，返回撤销的数量；
+// 例如修改密码后撤销所有刷新令牌和未使用的重置链接
+func (s *TokenStore) RevokeUser(ctx context.Context, userID string, purpose TokenPurpose) (int64, error) {
+	if err := s.client.checkWritable("delete", s.collection.Name()); err != nil {
+		return 0, err
+	}
+	filter := bson.M{"user_id": userID}
+	if purpose != "" {
+		filter["purpose"] = purpose
+	}
+	result, err := s.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke tokens of user %s: %w", userID, err)
+	}
+	s.client.logger.InfoContext(ctx, "Revoked tokens", "user", userID, "purpose", purpose, "count", result.DeletedCount)
+	return result.DeletedCount, nil
+}
+
+// tokenFilter TTL 索引每分钟清理一次，查询时同样排除已过期的令牌
+func tokenFilter(raw string, purpose TokenPurpose) bson.M {
+	return bson.M{"_id": hashToken(raw), "purpose": purpose, "expires_at": bson.M{"$gt": time.Now()}}
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
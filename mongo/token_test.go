@@ -0,0 +1,37 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTokenFilter(t *testing.T) {
+	filter := tokenFilter("secret", TokenPasswordReset)
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", filter["_id"])
+	assert.Equal(t, TokenPasswordReset, filter["purpose"])
+	assert.Contains(t, filter["expires_at"], "$gt")
+	assert.NotEqual(t, hashToken("secret"), hashToken("secret2"))
+}
+
+func TestTokenStoreValidation(t *testing.T) {
+	ctx := t.Context()
+	client := newLazyClient(t)
+	tokens := NewTokenStore(client, "")
+	assert.Equal(t, "tokens", tokens.collection.Name())
+
+	_, err := tokens.Issue(ctx, "", TokenRefresh, time.Hour, nil)
+	assert.Error(t, err)
+	_, err = tokens.Issue(ctx, "u1", TokenRefresh, 0, nil)
+	assert.Error(t, err)
+
+	client.readOnly = true
+	_, err = tokens.Issue(ctx, "u1", TokenEmailVerification, time.Hour, bson.M{"email": "a@example.com"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = tokens.Consume(ctx, "secret", TokenEmailVerification)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = tokens.RevokeUser(ctx, "u1", "")
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
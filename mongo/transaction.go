@@ -2,15 +2,55 @@ package mongo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
 )
 
+// TxnOptions 事务选项，未设置的字段沿用客户端默认值
+type TxnOptions struct {
+	// ReadConcern 事务读关注，例如 readconcern.Snapshot()
+	ReadConcern *readconcern.ReadConcern
+	// WriteConcern 事务写关注，例如 writeconcern.Majority()
+	WriteConcern *writeconcern.WriteConcern
+	// ReadPreference 事务读偏好，事务内只能读主节点
+	ReadPreference *readpref.ReadPref
+	// MaxCommitTime commitTransaction 的最长执行时间
+	MaxCommitTime time.Duration
+	// MaxRetries 遇到临时错误时的最大重试次数，nil 时默认 3 次，指向 0 时不重试
+	MaxRetries *int
+	// RetryBackoff 首次重试前的等待时间，之后按指数增长，默认 50 毫秒
+	RetryBackoff time.Duration
+	// MaxRetryBackoff 单次重试等待的上限，默认 1 秒
+	MaxRetryBackoff time.Duration
+}
+
+// TxnMetrics 事务执行统计
+type TxnMetrics struct {
+	Started          int64 `json:"started"`
+	Committed        int64 `json:"committed"`
+	Aborted          int64 `json:"aborted"`
+	TransientRetries int64 `json:"transient_retries"`
+	CommitRetries    int64 `json:"commit_retries"`
+}
+
 // TransactionManager 事务管理器
 type TransactionManager struct {
 	client *Client
+
+	started          atomic.Int64
+	committed        atomic.Int64
+	aborted          atomic.Int64
+	transientRetries atomic.Int64
+	commitRetries    atomic.Int64
 }
 
 // NewTransactionManager 创建新的事务管理器
@@ -23,30 +63,174 @@ func NewTransactionManager(client *Client) *TransactionManager {
 // TransactionFunc 事务函数类型
 type TransactionFunc func(sessCtx mongo.SessionContext) error
 
-// WithTransaction 执行事务
+// WithTransaction 执行事务，使用默认事务选项
 func (tm *TransactionManager) WithTransaction(ctx context.Context, fn TransactionFunc) error {
+	return tm.WithTransactionOptions(ctx, nil, fn)
+}
+
+// WithTransactionOptions 使用指定选项执行事务
+// 事务函数返回带 TransientTransactionError 标签的错误时整个事务会重试，
+// 提交返回 UnknownTransactionCommitResult 时只重试提交，因此 fn 需要是可重复执行的；
+// 部署不支持事务（例如单机）时返回 *UnsupportedFeatureError
+func (tm *TransactionManager) WithTransactionOptions(ctx context.Context, opts *TxnOptions, fn TransactionFunc) error {
+	cfg := normalizeTxnOptions(opts)
+	if err := tm.client.requireFeature(ctx, FeatureTransactions); err != nil {
+		return err
+	}
+
 	session, err := tm.client.client.StartSession()
 	if err != nil {
 		return fmt.Errorf("failed to start session: %w", err)
 	}
 	defer session.EndSession(ctx)
 
-	// 设置事务选项
-	txnOpts := options.Transaction().
-		SetReadPreference(nil).
-		SetWriteConcern(nil).
-		SetReadConcern(nil)
+	txnOpts := options.Transaction()
+	if cfg.ReadConcern != nil {
+		txnOpts.SetReadConcern(cfg.ReadConcern)
+	}
+	if cfg.WriteConcern != nil {
+		txnOpts.SetWriteConcern(cfg.WriteConcern)
+	}
+	if cfg.ReadPreference != nil {
+		txnOpts.SetReadPreference(cfg.ReadPreference)
+	}
+	if cfg.MaxCommitTime > 0 {
+		txnOpts.SetMaxCommitTime(&cfg.MaxCommitTime)
+	}
 
-	// 执行事务
-	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		return nil, fn(sessCtx)
-	}, txnOpts)
+	for attempt := 0; ; attempt++ {
+		if err := session.StartTransaction(txnOpts); err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		tm.started.Add(1)
+		sessCtx := mongo.NewSessionContext(ctx, session)
 
-	if err != nil {
-		return fmt.Errorf("transaction failed: %w", err)
+		if err := fn(sessCtx); err != nil {
+			_ = session.AbortTransaction(sessCtx)
+			tm.aborted.Add(1)
+			if hasErrorLabel(err, driver.TransientTransactionError) && attempt < *cfg.MaxRetries {
+				tm.transientRetries.Add(1)
+				tm.client.logger.WarnContext(ctx, "Retrying transaction after transient error", "attempt", attempt+1, "err", err)
+				if werr := sleepBackoff(ctx, cfg, attempt); werr != nil {
+					return fmt.Errorf("transaction failed: %w", err)
+				}
+				continue
+			}
+			return fmt.Errorf("transaction failed: %w", err)
+		}
+
+		err := tm.commit(sessCtx, session, cfg)
+		if err == nil {
+			tm.committed.Add(1)
+			return nil
+		}
+		tm.aborted.Add(1)
+		if hasErrorLabel(err, driver.TransientTransactionError) && attempt < *cfg.MaxRetries {
+			tm.transientRetries.Add(1)
+			tm.client.logger.WarnContext(ctx, "Retrying transaction after transient commit error", "attempt", attempt+1, "err", err)
+			if werr := sleepBackoff(ctx, cfg, attempt); werr != nil {
+				return fmt.Errorf("failed to commit transaction: %w", err)
+			}
+			continue
+		}
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+}
+
+// commit 提交事务，结果未知时重试提交
+func (tm *TransactionManager) commit(sessCtx mongo.SessionContext, session mongo.Session, cfg TxnOptions) error {
+	for attempt := 0; ; attempt++ {
+		err := session.CommitTransaction(sessCtx)
+		if err == nil {
+			return nil
+		}
+		var cmdErr mongo.CommandError
+		if errors.As(err, &cmdErr) && cmdErr.IsMaxTimeMSExpiredError() {
+			return err
+		}
+		if !hasErrorLabel(err, driver.UnknownTransactionCommitResult) || attempt >= *cfg.MaxRetries {
+			return err
+		}
+		tm.commitRetries.Add(1)
+		tm.client.logger.WarnContext(sessCtx, "Retrying transaction commit with unknown result", "attempt", attempt+1, "err", err)
+		if werr := sleepBackoff(sessCtx, cfg, attempt); werr != nil {
+			return err
+		}
 	}
+}
 
-	return nil
+// Metrics 返回事务执行统计
+func (tm *TransactionManager) Metrics() TxnMetrics {
+	return TxnMetrics{
+		Started:          tm.started.Load(),
+		Committed:        tm.committed.Load(),
+		Aborted:          tm.aborted.Load(),
+		TransientRetries: tm.transientRetries.Load(),
+		CommitRetries:    tm.commitRetries.Load(),
+	}
+}
+
+// normalizeTxnOptions 填充事务选项默认值
+func normalizeTxnOptions(opts *TxnOptions) TxnOptions {
+	var cfg TxnOptions
+	if opts != nil {
+		cfg = *opts
+	}
+	if cfg.MaxRetries == nil {
+		retries := 3
+		cfg.MaxRetries = &retries
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 50 * time.Millisecond
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = time.Second
+	}
+	return cfg
+}
+
+// sleepBackoff 按指数退避等待，上下文取消时提前返回
+func sleepBackoff(ctx context.Context, cfg TxnOptions, attempt int) error {
+	backoff := cfg.RetryBackoff << attempt
+	if backoff <= 0 || backoff > cfg.MaxRetryBackoff {
+		backoff = cfg.MaxRetryBackoff
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// hasErrorLabel 检查错误是否带有指定标签
+func hasErrorLabel(err error, label string) bool {
+	var labeled mongo.LabeledError
+	return errors.As(err, &labeled) && labeled.HasErrorLabel(label)
+}
+
+// Collection 返回绑定到 ctx 中事务会话的集合，在事务回调中使用：
+//
+//	tm.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+//		users := tm.Collection(sessCtx, "users")
+//		_, err := users.UpdateOne(ctx, filter, update) // 即使传入外层 ctx 也在事务中执行
+//		return err
+//	})
+func (tm *TransactionManager) Collection(ctx context.Context, collectionName string) *Collection {
+	return NewCollection(tm.client, collectionName).InSession(ctx)
+}
+
+// runAtomic 将 fn 中的多次写入作为一个整体执行：ctx 中已有会话时直接加入该会话，
+// 部署支持事务时在新事务中执行，否则直接执行，此时各次写入之间不保证原子性；fn 需要是可重复执行的
+func (c *Client) runAtomic(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil || c.requireFeature(ctx, FeatureTransactions) != nil {
+		return fn(ctx)
+	}
+	return NewTransactionManager(c).WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		return fn(sessCtx)
+	})
 }
 
 // WithSession 使用会话执行操作
@@ -63,7 +247,6 @@ func (tm *TransactionManager) WithSession(ctx context.Context, fn func(mongo.Ses
 // TransactionalRepository 支持事务的仓储
 type TransactionalRepository struct {
 	*Collection
-	session mongo.Session
 }
 
 // NewTransactionalRepository 创建支持事务的仓储
@@ -73,8 +256,11 @@ func NewTransactionalRepository(client *Client, collectionName string) *Transact
 	}
 }
 
-// WithTransaction 在事务中执行操作
+// WithTransaction 在事务中执行操作，部署不支持事务时返回 *UnsupportedFeatureError
 func (tr *TransactionalRepository) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext, repo *Collection) error) error {
+	if err := tr.cli.requireFeature(ctx, FeatureTransactions); err != nil {
+		return err
+	}
 	session, err := tr.cli.client.StartSession()
 	if err != nil {
 		return fmt.Errorf("failed to start session: %w", err)
@@ -84,12 +270,8 @@ func (tr *TransactionalRepository) WithTransaction(ctx context.Context, fn func(
 	txnOpts := options.Transaction()
 
 	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		// 创建一个在事务上下文中的仓储
-		txnRepo := &Collection{
-			cli:        tr.cli,
-			collection: tr.collection,
-		}
-		return nil, fn(sessCtx, txnRepo)
+		// 仓储绑定到事务会话，即使回调中使用外层 ctx 也会加入事务
+		return nil, fn(sessCtx, tr.Collection.WithSession(session))
 	}, txnOpts)
 
 	return err
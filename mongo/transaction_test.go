@@ -0,0 +1,148 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver"
+)
+
+func TestNormalizeTxnOptions(t *testing.T) {
+	assert.Equal(t, 3, *normalizeTxnOptions(nil).MaxRetries)
+	assert.Equal(t, 3, *normalizeTxnOptions(&TxnOptions{}).MaxRetries)
+	retries := 0
+	cfg := normalizeTxnOptions(&TxnOptions{MaxRetries: &retries})
+	assert.Equal(t, 0, *cfg.MaxRetries, "retries can be disabled")
+	assert.Equal(t, 50*time.Millisecond, cfg.RetryBackoff)
+	assert.Equal(t, time.Second, cfg.MaxRetryBackoff)
+}
+
+func TestTransactionRetriesTransientErrors(t *testing.T) {
+	server := newFakeServer(t, true)
+	tm := NewTransactionManager(server.client(t))
+	transient := mongo.CommandError{Code: 112, Message: "WriteConflict", Labels: []string{driver.TransientTransactionError}}
+
+	attempts := 0
+	err := tm.WithTransactionOptions(t.Context(), &TxnOptions{RetryBackoff: time.Millisecond}, func(sessCtx mongo.SessionContext) error {
+		attempts++
+		if attempts < 3 {
+			return transient
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, TxnMetrics{Started: 3, Committed: 1, Aborted: 2, TransientRetries: 2}, tm.Metrics())
+
+	// MaxRetries 为 0 时不重试
+	retries := 0
+	attempts = 0
+	err = tm.WithTransactionOptions(t.Context(), &TxnOptions{MaxRetries: &retries}, func(sessCtx mongo.SessionContext) error {
+		attempts++
+		return transient
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+
+	// 非临时错误直接返回
+	attempts = 0
+	boom := errors.New("boom")
+	err = tm.WithTransaction(t.Context(), func(sessCtx mongo.SessionContext) error {
+		attempts++
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestTransactionRetriesUnknownCommitResult(t *testing.T) {
+	server := newFakeServer(t, true)
+	commits := 0
+	failCommits := 2
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name != "commitTransaction" {
+			return nil
+		}
+		commits++
+		if commits > failCommits {
+			return nil
+		}
+		return bson.D{
+			{Key: "ok", Value: 0},
+			{Key: "code", Value: 1},
+			{Key: "errmsg", Value: "commit result unknown"},
+			{Key: "errorLabels", Value: bson.A{driver.UnknownTransactionCommitResult}},
+		}
+	})
+	tm := NewTransactionManager(server.client(t))
+	// 事务中没有操作时驱动不发送 commitTransaction
+	write := func(sessCtx mongo.SessionContext) error {
+		_, err := tm.Collection(sessCtx, "notes").InsertOne(sessCtx, bson.M{"title": "a"})
+		return err
+	}
+
+	// 只重试提交，不重新执行事务函数
+	attempts := 0
+	err := tm.WithTransactionOptions(t.Context(), &TxnOptions{RetryBackoff: time.Millisecond}, func(sessCtx mongo.SessionContext) error {
+		attempts++
+		return write(sessCtx)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, 3, commits)
+	assert.Equal(t, int64(2), tm.Metrics().CommitRetries)
+
+	retries := 0
+	commits = 0
+	err = tm.WithTransactionOptions(t.Context(), &TxnOptions{MaxRetries: &retries}, write)
+	assert.ErrorContains(t, err, "failed to commit transaction")
+	assert.Equal(t, 1, commits)
+}
+
+func TestCollectionsJoinTransactionSession(t *testing.T) {
+	server := newFakeServer(t, true)
+	client := server.client(t)
+	tm := NewTransactionManager(client)
+	ctx := t.Context()
+
+	users := NewCollection(client, "users")
+	assert.Same(t, users, users.InSession(ctx), "no session in ctx")
+
+	// 绑定到事务会话的集合即使使用外层 ctx 也在事务中执行
+	err := tm.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		if _, err := tm.Collection(sessCtx, "users").InsertOne(ctx, bson.M{"name": "alice"}); err != nil {
+			return err
+		}
+		_, err := users.InSession(sessCtx).UpdateOne(ctx, bson.M{"name": "alice"}, bson.M{"$set": bson.M{"active": true}})
+		return err
+	})
+	require.NoError(t, err)
+
+	repo := NewTransactionalRepository(client, "orders")
+	err = repo.WithTransaction(ctx, func(sessCtx mongo.SessionContext, orders *Collection) error {
+		_, err := orders.DeleteOne(ctx, bson.M{"status": "draft"})
+		return err
+	})
+	require.NoError(t, err)
+
+	writes := server.Commands("insert", "update", "delete")
+	require.Len(t, writes, 3)
+	// 事务中的命令带 autocommit: false；txnNumber 也用于可重试写入，不能用来判断
+	for _, cmd := range writes {
+		autocommit, inTxn := cmd.Lookup("autocommit").BooleanOK()
+		assert.True(t, inTxn && !autocommit, rawCommandName(cmd))
+	}
+	assert.Equal(t, writes[0].Lookup("lsid"), writes[1].Lookup("lsid"), "both collections share the transaction session")
+	assert.Len(t, server.Commands("commitTransaction"), 2)
+
+	// 会话外的操作不加入事务
+	_, err = users.InsertOne(ctx, bson.M{"name": "bob"})
+	require.NoError(t, err)
+	_, inTxn := server.Commands("insert")[1].Lookup("autocommit").BooleanOK()
+	assert.False(t, inTxn)
+}
@@ -0,0 +1,237 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrDuplicateKey 违反唯一约束，具体字段通过 DuplicateKeyError 获取
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// DuplicateKeyError 违反唯一约束的错误，由 InsertOne、UpdateOne 等写操作从 E11000 错误转换而来，
+// 也由 EnsureUnique 预检查返回；errors.Is(err, ErrDuplicateKey) 为 true，
+// 并且保留了驱动的原始错误，mongo.IsDuplicateKeyError 仍然可用
+type DuplicateKeyError struct {
+	// Index 冲突的索引名，预检查时为空
+	Index string
+	// Fields 冲突的字段，按索引键顺序排列
+	Fields []string
+	// Values 冲突的字段值，服务端未返回时为空
+	Values bson.M
+	// Err 驱动返回的原始错误，预检查时为 nil
+	Err error
+}
+
+// Error 返回适合直接展示的错误信息，例如 "email already exists"
+func (e *DuplicateKeyError) Error() string {
+	if len(e.Fields) > 0 {
+		return strings.Join(e.Fields, ", ") + " already exists"
+	}
+	if e.Index != "" {
+		return fmt.Sprintf("duplicate key on index %s", e.Index)
+	}
+	return ErrDuplicateKey.Error()
+}
+
+// Is 支持 errors.Is(err, ErrDuplicateKey)
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+// Unwrap 返回驱动的原始错误
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+// Field 返回第一个冲突字段，未知时为空字符串
+func (e *DuplicateKeyError) Field() string {
+	if len(e.Fields) == 0 {
+		return ""
+	}
+	return e.Fields[0]
+}
+
+// AsDuplicateKeyError 从错误链中取出 DuplicateKeyError，err 是驱动的原始 E11000 错误时也会转换
+func AsDuplicateKeyError(err error) (*DuplicateKeyError, bool) {
+	var dup *DuplicateKeyError
+	if errors.As(err, &dup) {
+		return dup, true
+	}
+	if dup = parseDuplicateKeyError(err); dup != nil {
+		return dup, true
+	}
+	return nil, false
+}
+
+// translateWriteError 将写操作返回的 E11000 错误转换为 DuplicateKeyError，其它错误原样返回
+func translateWriteError(err error) error {
+	if dup := parseDuplicateKeyError(err); dup != nil {
+		return dup
+	}
+	return err
+}
+
+// duplicateKeyCodes 服务端表示唯一约束冲突的错误码
+var duplicateKeyCodes = []int{11000, 11001, 12582}
+
+// parseDuplicateKeyError 从驱动错误中解析冲突的索引、字段和值
+func parseDuplicateKeyError(err error) *DuplicateKeyError {
+	if err == nil || !mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+
+	var writeErr *mongo.WriteError
+	var we mongo.WriteException
+	var bwe mongo.BulkWriteException
+	var ce mongo.CommandError
+	switch {
+	case errors.As(err, &we):
+		for i := range we.WriteErrors {
+			if isDuplicateKeyCode(we.WriteErrors[i].Code) {
+				writeErr = &we.WriteErrors[i]
+				break
+			}
+		}
+	case errors.As(err, &bwe):
+		for i := range bwe.WriteErrors {
+			if isDuplicateKeyCode(bwe.WriteErrors[i].Code) {
+				writeErr = &bwe.WriteErrors[i].WriteError
+				break
+			}
+		}
+	case errors.As(err, &ce):
+		dup := parseDuplicateKeyMessage(ce.Message)
+		dup.Err = err
+		return dup
+	}
+
+	if writeErr == nil {
+		return &DuplicateKeyError{Err: err}
+	}
+	dup := parseDuplicateKeyMessage(writeErr.Message)
+	dup.Err = err
+
+	// 4.2 及以上版本的服务端在写错误中返回 keyPattern 和 keyValue
+	if pattern, ok := writeErr.Raw.Lookup("keyPattern").DocumentOK(); ok {
+		if elems, err := pattern.Elements(); err == nil {
+			dup.Fields = dup.Fields[:0]
+			for _, elem := range elems {
+				dup.Fields = append(dup.Fields, elem.Key())
+			}
+		}
+	}
+	if value, ok := writeErr.Raw.Lookup("keyValue").DocumentOK(); ok {
+		values := bson.M{}
+		if err := bson.Unmarshal(value, &values); err == nil {
+			dup.Values = values
+		}
+	}
+	return dup
+}
+
+// isDuplicateKeyCode 是否为唯一约束冲突的错误码
+func isDuplicateKeyCode(code int) bool {
+	for _, c := range duplicateKeyCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicateKeyIndexPattern 匹配 E11000 错误信息中的索引名
+var duplicateKeyIndexPattern = regexp.MustCompile(`index: (\S+)`)
+
+// indexKeyPattern 匹配索引名中的 字段_方向 片段，例如 tenant_id_1_email_-1
+var indexKeyPattern = regexp.MustCompile(`(.+?)_(-?1|text|2d|2dsphere|hashed)(?:_|$)`)
+
+// parseDuplicateKeyMessage 从 E11000 错误信息中解析索引名，并从默认索引名推断字段
+// 自定义名称的索引无法推断字段，此时 Fields 为空
+func parseDuplicateKeyMessage(message string) *DuplicateKeyError {
+	dup := &DuplicateKeyError{}
+	match := duplicateKeyIndexPattern.FindStringSubmatch(message)
+	if match == nil {
+		return dup
+	}
+	dup.Index = match[1]
+	if dup.Index == "_id_" {
+		dup.Fields = []string{"_id"}
+		return dup
+	}
+	rest := dup.Index
+	for rest != "" {
+		loc := indexKeyPattern.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[0] != 0 {
+			// 自定义索引名，无法推断字段
+			dup.Fields = nil
+			return dup
+		}
+		dup.Fields = append(dup.Fields, rest[loc[2]:loc[3]])
+		rest = rest[loc[1]:]
+	}
+	return dup
+}
+
+// EnsureUnique 在写入前检查是否已有其它文档与 document 在 fields 上的取值完全相同（多个字段按组合唯一判断），
+// 存在时返回 DuplicateKeyError；document 带有 _id 时会排除自身，可以用于更新前的检查
+//
+//	if err := users.EnsureUnique(ctx, user, "email"); err != nil {
+//		return err // email already exists
+//	}
+//
+// 预检查和写入之间存在竞争，仍然需要唯一索引兜底，写入时的 E11000 错误同样会转换为 DuplicateKeyError
+func (c *Collection) EnsureUnique(ctx context.Context, document interface{}, fields ...string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("at least one field is required")
+	}
+	doc, err := toBsonM(document)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{}
+	values := bson.M{}
+	for _, field := range fields {
+		value, _ := lookupPath(doc, field)
+		filter[field] = value
+		values[field] = value
+	}
+	if id, ok := doc["_id"]; ok && !isZeroID(id) {
+		filter["_id"] = bson.M{"$ne": id}
+	}
+
+	exists, err := c.Exists(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &DuplicateKeyError{Fields: fields, Values: values}
+	}
+	return nil
+}
+
+// EnsureUniqueIndex 在 fields 上创建唯一索引（已存在时为空操作），返回索引名
+func (c *Collection) EnsureUniqueIndex(ctx context.Context, fields ...string) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("at least one field is required")
+	}
+	keys := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: 1})
+	}
+	name, err := c.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create unique index: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,75 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestParseDuplicateKeyMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		index   string
+		fields  []string
+	}{
+		{`E11000 duplicate key error collection: app.users index: email_1 dup key: { email: "a@b.com" }`, "email_1", []string{"email"}},
+		{`E11000 duplicate key error collection: app.users index: tenant_id_1_email_-1 dup key: { }`, "tenant_id_1_email_-1", []string{"tenant_id", "email"}},
+		{`E11000 duplicate key error collection: app.users index: _id_ dup key: { _id: 1 }`, "_id_", []string{"_id"}},
+		{`E11000 duplicate key error collection: app.users index: uniq_email dup key: { }`, "uniq_email", nil},
+		{`E11000 duplicate key error`, "", nil},
+	}
+	for _, tt := range tests {
+		dup := parseDuplicateKeyMessage(tt.message)
+		assert.Equal(t, tt.index, dup.Index, tt.message)
+		assert.Equal(t, tt.fields, dup.Fields, tt.message)
+	}
+}
+
+func TestTranslateWriteError(t *testing.T) {
+	raw, err := bson.Marshal(bson.M{
+		"code":       11000,
+		"keyPattern": bson.M{"email": 1},
+		"keyValue":   bson.M{"email": "a@b.com"},
+	})
+	require.NoError(t, err)
+	driverErr := mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+		Code:    11000,
+		Message: `E11000 duplicate key error collection: app.users index: uniq_email dup key: { email: "a@b.com" }`,
+		Raw:     raw,
+	}}}
+
+	err = fmt.Errorf("failed to insert document: %w", translateWriteError(driverErr))
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.True(t, mongo.IsDuplicateKeyError(err), "driver error is still reachable")
+
+	dup, ok := AsDuplicateKeyError(err)
+	require.True(t, ok)
+	assert.Equal(t, "uniq_email", dup.Index)
+	assert.Equal(t, "email", dup.Field())
+	assert.Equal(t, bson.M{"email": "a@b.com"}, dup.Values)
+	assert.Equal(t, "email already exists", dup.Error())
+
+	other := errors.New("boom")
+	assert.Equal(t, other, translateWriteError(other))
+	_, ok = AsDuplicateKeyError(other)
+	assert.False(t, ok)
+}
+
+func TestTranslateBulkWriteError(t *testing.T) {
+	driverErr := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{
+		WriteError: mongo.WriteError{
+			Index:   1,
+			Code:    11000,
+			Message: `E11000 duplicate key error collection: app.users index: tenant_id_1_email_1 dup key: { }`,
+		},
+	}}}
+	dup, ok := AsDuplicateKeyError(driverErr)
+	require.True(t, ok)
+	assert.Equal(t, []string{"tenant_id", "email"}, dup.Fields)
+	assert.Equal(t, "tenant_id, email already exists", dup.Error())
+}
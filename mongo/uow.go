@@ -0,0 +1,118 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// uowOperation 工作单元中待执行的操作
+type uowOperation struct {
+	kind       string
+	collection *Collection
+	apply      func(ctx context.Context, c *Collection) error
+}
+
+// UnitOfWork 工作单元
+// 记录跨多个集合的新增、更新、删除操作，在 Commit 时放入同一个事务中执行：
+//
+//	uow := NewUnitOfWork(client, nil)
+//	uow.RegisterNew(users, user)
+//	uow.RegisterUpdate(categories, bson.M{"_id": categoryID}, bson.M{"$inc": bson.M{"article_count": 1}})
+//	uow.RegisterDelete(articles, bson.M{"_id": draftID})
+//	err := uow.Commit(ctx)
+type UnitOfWork struct {
+	tm   *TransactionManager
+	opts *TxnOptions
+
+	mu         sync.Mutex
+	operations []uowOperation
+}
+
+// NewUnitOfWork 创建工作单元，opts 为 nil 时使用默认事务选项
+func NewUnitOfWork(client *Client, opts *TxnOptions) *UnitOfWork {
+	return &UnitOfWork{
+		tm:   NewTransactionManager(client),
+		opts: opts,
+	}
+}
+
+// RegisterNew 登记待插入的文档
+func (u *UnitOfWork) RegisterNew(c *Collection, document interface{}) {
+	u.register("insert", c, func(ctx context.Context, c *Collection) error {
+		_, err := c.InsertOne(ctx, document)
+		return err
+	})
+}
+
+// RegisterUpdate 登记待执行的更新，opts 参见 Collection.UpdateMany
+func (u *UnitOfWork) RegisterUpdate(c *Collection, filter bson.M, update bson.M, opts ...*MassWriteOptions) {
+	u.register("update", c, func(ctx context.Context, c *Collection) error {
+		_, err := c.UpdateMany(ctx, filter, update, opts...)
+		return err
+	})
+}
+
+// RegisterReplace 登记待替换的文档
+func (u *UnitOfWork) RegisterReplace(c *Collection, filter bson.M, replacement interface{}) {
+	u.register("replace", c, func(ctx context.Context, c *Collection) error {
+		_, err := c.ReplaceOne(ctx, filter, replacement)
+		return err
+	})
+}
+
+// RegisterDelete 登记待删除的文档，opts 参见 Collection.DeleteMany
+func (u *UnitOfWork) RegisterDelete(c *Collection, filter bson.M, opts ...*MassWriteOptions) {
+	u.register("delete", c, func(ctx context.Context, c *Collection) error {
+		_, err := c.DeleteMany(ctx, filter, opts...)
+		return err
+	})
+}
+
+// register 登记操作
+func (u *UnitOfWork) register(kind string, c *Collection, apply func(ctx context.Context, c *Collection) error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.operations = append(u.operations, uowOperation{kind: kind, collection: c, apply: apply})
+}
+
+// Len 返回待提交的操作数量
+func (u *UnitOfWork) Len() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.operations)
+}
+
+// Commit 在一个事务中按登记顺序执行所有操作，任意操作失败则整体回滚
+// 提交成功后清空已登记的操作，失败时保留以便调用方重试或 Rollback
+func (u *UnitOfWork) Commit(ctx context.Context) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.operations) == 0 {
+		return nil
+	}
+
+	err := u.tm.WithTransactionOptions(ctx, u.opts, func(sessCtx mongo.SessionContext) error {
+		for i, op := range u.operations {
+			if err := op.apply(sessCtx, op.collection.InSession(sessCtx)); err != nil {
+				return fmt.Errorf("operation %d (%s on %s) failed: %w", i, op.kind, op.collection.collection.Name(), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit unit of work: %w", err)
+	}
+	u.operations = nil
+	return nil
+}
+
+// Rollback 丢弃所有未提交的操作
+func (u *UnitOfWork) Rollback() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.operations = nil
+}
@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// registerArticleWork 登记插入文章、更新分类计数、删除草稿三个操作
+func registerArticleWork(uow *UnitOfWork, client *Client) {
+	categoryID, draftID := primitive.NewObjectID(), primitive.NewObjectID()
+	uow.RegisterNew(NewCollection(client, "articles"), bson.M{"title": "hello"})
+	uow.RegisterUpdate(NewCollection(client, "categories"), bson.M{"_id": categoryID}, bson.M{"$inc": bson.M{"article_count": 1}})
+	uow.RegisterDelete(NewCollection(client, "drafts"), bson.M{"_id": draftID})
+}
+
+func TestUnitOfWorkCommit(t *testing.T) {
+	server := newFakeServer(t, true)
+	client := server.client(t)
+	uow := NewUnitOfWork(client, nil)
+
+	// 没有登记的操作时不开启事务
+	require.NoError(t, uow.Commit(t.Context()))
+	assert.Empty(t, server.Commands("commitTransaction"))
+
+	registerArticleWork(uow, client)
+	assert.Equal(t, 3, uow.Len())
+	require.NoError(t, uow.Commit(t.Context()))
+	assert.Zero(t, uow.Len())
+
+	// 按登记顺序在同一事务中执行
+	writes := server.Commands("insert", "update", "delete")
+	require.Len(t, writes, 3)
+	assert.Equal(t, []string{"insert", "update", "delete"}, []string{rawCommandName(writes[0]), rawCommandName(writes[1]), rawCommandName(writes[2])})
+	assert.Equal(t, []string{"articles", "categories", "drafts"}, []string{
+		writes[0].Lookup("insert").StringValue(),
+		writes[1].Lookup("update").StringValue(),
+		writes[2].Lookup("delete").StringValue(),
+	})
+	for _, cmd := range writes {
+		assert.Equal(t, writes[0].Lookup("txnNumber").Int64(), cmd.Lookup("txnNumber").Int64())
+	}
+	assert.Len(t, server.Commands("commitTransaction"), 1)
+}
+
+func TestUnitOfWorkRollsBackOnFailure(t *testing.T) {
+	server := newFakeServer(t, true)
+	server.handle(func(name string, cmd bson.Raw) bson.D {
+		if name == "update" {
+			return fakeWriteError(2, "bad update")
+		}
+		return nil
+	})
+	client := server.client(t)
+	uow := NewUnitOfWork(client, nil)
+	registerArticleWork(uow, client)
+
+	err := uow.Commit(t.Context())
+	assert.ErrorContains(t, err, "operation 1 (update on categories) failed")
+
+	// 失败的操作之后不再执行，整个事务回滚
+	assert.Len(t, server.Commands("insert"), 1)
+	assert.Empty(t, server.Commands("delete"))
+	assert.Len(t, server.Commands("abortTransaction"), 1)
+	assert.Empty(t, server.Commands("commitTransaction"))
+
+	// 失败后保留登记的操作，Rollback 清空
+	assert.Equal(t, 3, uow.Len())
+	uow.Rollback()
+	assert.Zero(t, uow.Len())
+	require.NoError(t, uow.Commit(t.Context()))
+	assert.Len(t, server.Commands("insert"), 1)
+}
+
+func TestUnitOfWorkWithoutTransactions(t *testing.T) {
+	server := newFakeServer(t, false)
+	client := server.client(t)
+	uow := NewUnitOfWork(client, nil)
+	registerArticleWork(uow, client)
+
+	err := uow.Commit(t.Context())
+	var unsupported *UnsupportedFeatureError
+	if assert.True(t, errors.As(err, &unsupported)) {
+		assert.Equal(t, FeatureTransactions, unsupported.Feature)
+	}
+	assert.Empty(t, server.Commands("insert", "update", "delete"))
+	assert.Equal(t, 3, uow.Len())
+}
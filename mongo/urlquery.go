@@ -0,0 +1,315 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidQuery URL 查询参数不合法
+var ErrInvalidQuery = errors.New("invalid query")
+
+// QueryFieldType URL 查询字段的值类型
+type QueryFieldType string
+
+const (
+	QueryString   QueryFieldType = "string"
+	QueryInt      QueryFieldType = "int"
+	QueryFloat    QueryFieldType = "float"
+	QueryBool     QueryFieldType = "bool"
+	QueryTime     QueryFieldType = "time"
+	QueryObjectID QueryFieldType = "objectid"
+)
+
+// QueryField URL 查询中允许使用的字段
+type QueryField struct {
+	// Field 对应的文档字段，默认与参数名相同
+	Field string
+	// Type 值类型，默认 QueryString
+	Type QueryFieldType
+	// Operators 允许的操作符：eq、ne、in、nin、gt、gte、lt、lte、exists、prefix，默认只允许 eq 和 in
+	Operators []string
+	// Sortable 是否允许按该字段排序
+	Sortable bool
+}
+
+// URLQuerySchema URL 查询参数的字段白名单和分页配置
+// 将 ?status=active&tags[in]=go,db&created_at[gte]=2024-01-01&sort=-created_at&page=2&limit=20
+// 转换为过滤条件、排序和分页参数：
+//
+//	schema := &URLQuerySchema{Fields: map[string]QueryField{
+//		"status":     {},
+//		"tags":       {Operators: []string{"eq", "in"}},
+//		"created_at": {Type: QueryTime, Operators: []string{"gte", "lte"}, Sortable: true},
+//	}}
+//	query, err := schema.Parse(r.URL.Query())
+//	page, err := articles.FindWithPagination(ctx, query.Filter, query.Page, query.PageSize, &results, query.FindOptions())
+type URLQuerySchema struct {
+	// Fields 允许过滤和排序的参数，不在其中的参数会返回 ErrInvalidQuery
+	Fields map[string]QueryField
+	// DefaultSort 没有 sort 参数时的排序
+	DefaultSort bson.D
+	// DefaultLimit 没有 limit 参数时的每页数量，默认 DefaultPageSize
+	DefaultLimit int64
+	// MaxLimit 每页数量上限，默认 100
+	MaxLimit int64
+}
+
+// URLQuery 解析后的查询
+type URLQuery struct {
+	Filter   bson.M
+	Sort     bson.D
+	Page     int64
+	PageSize int64
+}
+
+// FindOptions 返回带排序的查询选项，分页由 FindWithPagination 处理
+func (q *URLQuery) FindOptions() *options.FindOptions {
+	opts := options.Find()
+	if len(q.Sort) > 0 {
+		opts.SetSort(q.Sort)
+	}
+	return opts
+}
+
+// urlQueryOperators 参数操作符到查询操作符的映射
+var urlQueryOperators = map[string]string{
+	"ne":  "$ne",
+	"in":  "$in",
+	"nin": "$nin",
+	"gt":  "$gt",
+	"gte": "$gte",
+	"lt":  "$lt",
+	"lte": "$lte",
+}
+
+// Parse 解析 URL 查询参数
+func (s *URLQuerySchema) Parse(values url.Values) (*URLQuery, error) {
+	query := &URLQuery{Filter: bson.M{}, Sort: s.DefaultSort, Page: 1, PageSize: s.DefaultLimit}
+	if query.PageSize <= 0 {
+		query.PageSize = DefaultPageSize
+	}
+	maxLimit := s.MaxLimit
+	if maxLimit <= 0 {
+		maxLimit = 100
+	}
+
+	for key, raw := range values {
+		if len(raw) == 0 {
+			continue
+		}
+		switch key {
+		case "sort":
+			sort, err := s.parseSort(raw[len(raw)-1])
+			if err != nil {
+				return nil, err
+			}
+			query.Sort = sort
+			continue
+		case "page":
+			page, err := parsePositiveInt(key, raw[len(raw)-1])
+			if err != nil {
+				return nil, err
+			}
+			query.Page = page
+			continue
+		case "limit":
+			limit, err := parsePositiveInt(key, raw[len(raw)-1])
+			if err != nil {
+				return nil, err
+			}
+			if limit > maxLimit {
+				limit = maxLimit
+			}
+			query.PageSize = limit
+			continue
+		}
+
+		name, op := splitQueryKey(key)
+		field, ok := s.Fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %s", ErrInvalidQuery, name)
+		}
+		if op == "eq" && len(raw) > 1 {
+			op = "in"
+			raw = []string{strings.Join(raw, ",")}
+		}
+		if !fieldOperatorAllowed(field, op) {
+			return nil, fmt.Errorf("%w: operator %s is not allowed on %s", ErrInvalidQuery, op, name)
+		}
+		if err := addQueryCondition(query.Filter, name, field, op, raw[len(raw)-1]); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// parseSort 解析 sort 参数，例如 -created_at,title
+func (s *URLQuerySchema) parseSort(value string) (bson.D, error) {
+	var sort bson.D
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		order := 1
+		if strings.HasPrefix(part, "-") {
+			order = -1
+			part = part[1:]
+		} else {
+			part = strings.TrimPrefix(part, "+")
+		}
+		field, ok := s.Fields[part]
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("%w: cannot sort by %s", ErrInvalidQuery, part)
+		}
+		sort = append(sort, bson.E{Key: queryFieldName(part, field), Value: order})
+	}
+	return sort, nil
+}
+
+// splitQueryKey 拆分 tags[in] 形式的参数名
+func splitQueryKey(key string) (string, string) {
+	if i := strings.Index(key, "["); i > 0 && strings.HasSuffix(key, "]") {
+		return key[:i], key[i+1 : len(key)-1]
+	}
+	return key, "eq"
+}
+
+// fieldOperatorAllowed 检查字段是否允许使用操作符
+func fieldOperatorAllowed(field QueryField, op string) bool {
+	if len(field.Operators) == 0 {
+		return op == "eq" || op == "in"
+	}
+	return contains(field.Operators, op)
+}
+
+// queryFieldName 返回参数对应的文档字段
+func queryFieldName(name string, field QueryField) string {
+	if field.Field != "" {
+		return field.Field
+	}
+	return name
+}
+
+// addQueryCondition 将一个参数条件写入过滤器，同一字段的多个操作符合并
+func addQueryCondition(filter bson.M, name string, field QueryField, op, raw string) error {
+	target := queryFieldName(name, field)
+	existing, hasExisting := filter[target]
+
+	var condition interface{}
+	switch op {
+	case "eq":
+		value, err := parseQueryValue(name, field.Type, raw)
+		if err != nil {
+			return err
+		}
+		if hasExisting {
+			return fmt.Errorf("%w: conflicting conditions on %s", ErrInvalidQuery, name)
+		}
+		filter[target] = value
+		return nil
+	case "in", "nin":
+		values := bson.A{}
+		for _, part := range strings.Split(raw, ",") {
+			value, err := parseQueryValue(name, field.Type, part)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		condition = values
+	case "exists":
+		exists, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%w: %s[exists] must be a boolean", ErrInvalidQuery, name)
+		}
+		condition = exists
+	case "prefix":
+		if field.Type != "" && field.Type != QueryString {
+			return fmt.Errorf("%w: prefix is only supported on string fields", ErrInvalidQuery)
+		}
+		regex := PrefixSearch(target, raw)
+		if !regex.IndexSafe() {
+			return fmt.Errorf("%w: %s[prefix] must not be empty", ErrInvalidQuery, name)
+		}
+		condition = regex.Pattern()
+		op = "regex"
+	default:
+		if _, ok := urlQueryOperators[op]; !ok {
+			return fmt.Errorf("%w: unknown operator %s", ErrInvalidQuery, op)
+		}
+		value, err := parseQueryValue(name, field.Type, raw)
+		if err != nil {
+			return err
+		}
+		condition = value
+	}
+
+	operator := "$" + op
+	if mapped, ok := urlQueryOperators[op]; ok {
+		operator = mapped
+	}
+	conditions, ok := existing.(bson.M)
+	if !hasExisting {
+		conditions = bson.M{}
+		filter[target] = conditions
+	} else if !ok {
+		return fmt.Errorf("%w: conflicting conditions on %s", ErrInvalidQuery, name)
+	}
+	conditions[operator] = condition
+	return nil
+}
+
+// parseQueryValue 按字段类型转换参数值
+func parseQueryValue(name string, fieldType QueryFieldType, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	var (
+		value interface{}
+		err   error
+	)
+	switch fieldType {
+	case "", QueryString:
+		return raw, nil
+	case QueryInt:
+		value, err = strconv.ParseInt(raw, 10, 64)
+	case QueryFloat:
+		value, err = strconv.ParseFloat(raw, 64)
+	case QueryBool:
+		value, err = strconv.ParseBool(raw)
+	case QueryTime:
+		value, err = parseQueryTime(raw)
+	case QueryObjectID:
+		value, err = primitive.ObjectIDFromHex(raw)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %s of %s", ErrInvalidQuery, fieldType, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s value %q for %s", ErrInvalidQuery, fieldType, raw, name)
+	}
+	return value, nil
+}
+
+// parseQueryTime 解析 RFC3339 时间或 2006-01-02 格式的日期
+func parseQueryTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// parsePositiveInt 解析正整数参数
+func parsePositiveInt(name, raw string) (int64, error) {
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("%w: %s must be a positive integer", ErrInvalidQuery, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,64 @@
+package mongo
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestURLQuerySchemaParse(t *testing.T) {
+	schema := &URLQuerySchema{Fields: map[string]QueryField{
+		"status":     {},
+		"tags":       {Operators: []string{"eq", "in"}},
+		"views":      {Field: "view_count", Type: QueryInt, Operators: []string{"gte", "lt"}, Sortable: true},
+		"created_at": {Type: QueryTime, Operators: []string{"gte"}, Sortable: true},
+		"title":      {Operators: []string{"prefix"}},
+	}}
+
+	values, err := url.ParseQuery("status=active&tags[in]=go,db&views[gte]=10&views[lt]=100&created_at[gte]=2024-01-01&title[prefix]=Go+&sort=-created_at,views&page=2&limit=500")
+	require.NoError(t, err)
+	query, err := schema.Parse(values)
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.M{
+		"status":     "active",
+		"tags":       bson.M{"$in": bson.A{"go", "db"}},
+		"view_count": bson.M{"$gte": int64(10), "$lt": int64(100)},
+		"created_at": bson.M{"$gte": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"title":      bson.M{"$regex": `^Go `},
+	}, query.Filter)
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}, {Key: "view_count", Value: 1}}, query.Sort)
+	assert.Equal(t, int64(2), query.Page)
+	assert.Equal(t, int64(100), query.PageSize)
+}
+
+func TestURLQuerySchemaRejects(t *testing.T) {
+	schema := &URLQuerySchema{Fields: map[string]QueryField{
+		"status": {},
+		"views":  {Type: QueryInt, Operators: []string{"gte"}},
+	}}
+	for _, raw := range []string{
+		"password=x",
+		"status[ne]=x",
+		"views[gte]=abc",
+		"sort=status",
+		"page=0",
+	} {
+		values, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+		_, err = schema.Parse(values)
+		assert.ErrorIs(t, err, ErrInvalidQuery, raw)
+	}
+}
+
+func TestURLQueryRepeatedValues(t *testing.T) {
+	schema := &URLQuerySchema{Fields: map[string]QueryField{"status": {}}}
+	query, err := schema.Parse(url.Values{"status": {"draft", "published"}})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"status": bson.M{"$in": bson.A{"draft", "published"}}}, query.Filter)
+	assert.Equal(t, DefaultPageSize, query.PageSize)
+}
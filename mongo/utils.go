@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -100,6 +101,160 @@ func BuildUpdateSet(data interface{}) bson.M {
 	return update
 }
 
+// BuildNestedUpdateSet 构建更新操作的 $set 部分，嵌套结构体和 map 递归展开为点号路径，
+// 例如 User.Profile.FirstName 生成 profile.first_name，只更新子文档中的字段而不是整体替换：
+//   - 与 BuildUpdateSet 一样只处理带 bson 标签的导出字段，omitempty 的零值字段和顶层 _id 会被跳过
+//   - bson:",inline" 的嵌入结构体展开到当前层级
+//   - 指针和接口取其指向的值，nil 设置为 null
+//   - map 按 key 展开，空 map 不产生更新；key 以 $ 开头或包含点号时整体设置
+//   - 切片、time.Time、ObjectID 等 bson 基础类型以及自定义序列化的类型整体设置
+//   - 字段标签 update:"replace" 关闭展开，整体替换该字段
+func BuildNestedUpdateSet(data interface{}) bson.M {
+	update := bson.M{}
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return update
+		}
+		value = value.Elem()
+	}
+
+	setFields := bson.M{}
+	switch value.Kind() {
+	case reflect.Struct:
+		flattenStruct("", value, setFields, true)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return update
+		}
+		flattenMap("", value, setFields)
+	default:
+		return update
+	}
+
+	if len(setFields) > 0 {
+		update["$set"] = setFields
+	}
+	return update
+}
+
+// flattenStruct 展开结构体字段，top 为 true 时跳过 _id
+func flattenStruct(prefix string, value reflect.Value, fields bson.M, top bool) {
+	valueType := value.Type()
+	for i := 0; i < value.NumField(); i++ {
+		field := valueType.Field(i)
+		fieldValue := value.Field(i)
+
+		bsonTag := field.Tag.Get("bson")
+		if bsonTag == "" || bsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(bsonTag, ",")
+
+		if contains(tagParts[1:], "inline") {
+			for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+				fieldValue = fieldValue.Elem()
+			}
+			switch fieldValue.Kind() {
+			case reflect.Struct:
+				flattenStruct(prefix, fieldValue, fields, top)
+			case reflect.Map:
+				flattenMap(prefix, fieldValue, fields)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		fieldName := tagParts[0]
+		if fieldName == "" {
+			fieldName = strings.ToLower(field.Name)
+		}
+		if top && fieldName == "_id" {
+			continue
+		}
+		if contains(tagParts[1:], "omitempty") && fieldValue.IsZero() {
+			continue
+		}
+		flattenValue(joinPath(prefix, fieldName), fieldValue, fields, field.Tag.Get("update") == "replace")
+	}
+}
+
+// flattenMap 按 key 展开 map
+func flattenMap(prefix string, value reflect.Value, fields bson.M) {
+	for _, key := range value.MapKeys() {
+		name := key.String()
+		if name == "" || strings.HasPrefix(name, "$") || strings.Contains(name, ".") {
+			if prefix != "" {
+				fields[prefix] = value.Interface()
+			}
+			return
+		}
+	}
+	for _, key := range value.MapKeys() {
+		flattenValue(joinPath(prefix, key.String()), value.MapIndex(key), fields, false)
+	}
+}
+
+// flattenValue 展开单个值，replace 为 true 时整体设置
+func flattenValue(path string, value reflect.Value, fields bson.M, replace bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			fields[path] = nil
+			return
+		}
+		value = value.Elem()
+	}
+	if !replace {
+		switch {
+		case value.Kind() == reflect.Struct && !isBsonLeaf(value.Type()):
+			flattenStruct(path, value, fields, false)
+			return
+		case value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String:
+			flattenMap(path, value, fields)
+			return
+		}
+	}
+	fields[path] = value.Interface()
+}
+
+// isBsonLeaf 判断结构体是否应作为整体编码，而不是按字段展开
+func isBsonLeaf(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Time{}) || t.PkgPath() == reflect.TypeOf(primitive.ObjectID{}).PkgPath() {
+		return true
+	}
+	ptr := reflect.PtrTo(t)
+	marshaler := reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	valueMarshaler := reflect.TypeOf((*bson.ValueMarshaler)(nil)).Elem()
+	return t.Implements(marshaler) || ptr.Implements(marshaler) ||
+		t.Implements(valueMarshaler) || ptr.Implements(valueMarshaler)
+}
+
+// joinPath 拼接点号路径
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// lookupPath 按点号路径读取嵌套文档中的值
+func lookupPath(doc bson.M, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var current interface{} = doc
+	for _, part := range parts {
+		m, ok := current.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
 // BuildFilter 构建查询过滤器
 func BuildFilter(conditions map[string]interface{}) bson.M {
 	filter := bson.M{}
@@ -112,6 +267,8 @@ func BuildFilter(conditions map[string]interface{}) bson.M {
 }
 
 // BuildSort 构建排序条件
+//
+// Deprecated: map 的遍历顺序不固定，多字段排序时优先级不确定，请使用 BuildOrderedSort 或 ParseSort
 func BuildSort(sorts map[string]int) bson.D {
 	var sortDoc bson.D
 	for field, order := range sorts {
@@ -120,6 +277,55 @@ func BuildSort(sorts map[string]int) bson.D {
 	return sortDoc
 }
 
+// SortField 排序字段，Order 为 1 升序、-1 降序
+type SortField struct {
+	Field string
+	Order int
+}
+
+// Asc 升序排序字段
+func Asc(field string) SortField {
+	return SortField{Field: field, Order: 1}
+}
+
+// Desc 降序排序字段
+func Desc(field string) SortField {
+	return SortField{Field: field, Order: -1}
+}
+
+// BuildOrderedSort 按声明顺序构建排序条件，例如 BuildOrderedSort(Desc("created_at"), Asc("title"))
+func BuildOrderedSort(fields ...SortField) bson.D {
+	sortDoc := make(bson.D, 0, len(fields))
+	for _, f := range fields {
+		sortDoc = append(sortDoc, bson.E{Key: f.Field, Value: f.Order})
+	}
+	return sortDoc
+}
+
+// ParseSort 解析 "-created_at,+title,views" 形式的排序字符串，- 表示降序，+ 或无前缀表示升序
+func ParseSort(s string) (bson.D, error) {
+	var fields []SortField
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field := Asc(part[1:])
+		switch part[0] {
+		case '-':
+			field.Order = -1
+		case '+':
+		default:
+			field.Field = part
+		}
+		if field.Field == "" || strings.HasPrefix(field.Field, "$") {
+			return nil, fmt.Errorf("invalid sort field %q", part)
+		}
+		fields = append(fields, field)
+	}
+	return BuildOrderedSort(fields...), nil
+}
+
 // contains 检查字符串切片是否包含指定字符串
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -169,6 +375,38 @@ func ToObjectID(v interface{}) (primitive.ObjectID, error) {
 	}
 }
 
+// IDString 将 ObjectID、UUID 或字符串 ID 转换为字符串形式，结果可以通过集合的 ID 策略解析回存储类型
+func IDString(id interface{}) string {
+	switch v := id.(type) {
+	case primitive.ObjectID:
+		return v.Hex()
+	case UUID:
+		return v.String()
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ParseIDs 按 ID 策略将字符串数组转换为 ID 数组，strategy 为 nil 时使用 ObjectIDStrategy
+func ParseIDs(strs []string, strategy IDStrategy) ([]interface{}, error) {
+	if strategy == nil {
+		strategy = ObjectIDStrategy
+	}
+	ids := make([]interface{}, 0, len(strs))
+	for _, s := range strs {
+		id, err := strategy.ParseID(s)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 // BuildRegexFilter 构建正则表达式过滤器
 func BuildRegexFilter(field, pattern string, options ...string) bson.M {
 	regex := bson.M{"$regex": pattern}
@@ -198,4 +436,34 @@ func BuildRangeFilter(field string, min, max interface{}) bson.M {
 // BuildTextSearchFilter 构建文本搜索过滤器
 func BuildTextSearchFilter(text string) bson.M {
 	return bson.M{"$text": bson.M{"$search": text}}
-}
\ No newline at end of file
+}
+
+// ParseFilter 将 MongoDB Extended JSON 字符串解析为过滤器，同时支持 canonical 和 relaxed 格式，
+// 例如 {"_id": {"$oid": "..."}, "created_at": {"$gte": {"$date": "2024-01-01T00:00:00Z"}}}
+// 空字符串返回空过滤器；来自不可信来源的过滤器应再经过 FilterSanitizer 检查
+func ParseFilter(jsonStr string) (bson.M, error) {
+	if strings.TrimSpace(jsonStr) == "" {
+		return bson.M{}, nil
+	}
+	var filter bson.M
+	if err := bson.UnmarshalExtJSON([]byte(jsonStr), false, &filter); err != nil {
+		return nil, fmt.Errorf("failed to parse filter: %w", err)
+	}
+	return filter, nil
+}
+
+// toBsonM 将结构体或 map 编码后转换为 bson.M
+func toBsonM(v interface{}) (bson.M, error) {
+	if v == nil {
+		return bson.M{}, nil
+	}
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode document: %w", err)
+	}
+	var m bson.M
+	if err := bson.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,111 @@
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	filter, err := ParseFilter(`{
+		"_id": {"$oid": "` + id.Hex() + `"},
+		"status": {"$in": ["draft", "published"]},
+		"created_at": {"$gte": {"$date": "2024-01-01T00:00:00Z"}},
+		"views": {"$numberLong": "10"}
+	}`)
+	require.NoError(t, err)
+
+	date := primitive.NewDateTimeFromTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, bson.M{
+		"_id":        id,
+		"status":     bson.M{"$in": bson.A{"draft", "published"}},
+		"created_at": bson.M{"$gte": date},
+		"views":      int64(10),
+	}, filter)
+
+	empty, err := ParseFilter("  ")
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{}, empty)
+
+	_, err = ParseFilter(`{"_id": `)
+	assert.Error(t, err)
+	_, err = ParseFilter(`["a"]`)
+	assert.Error(t, err)
+}
+
+func TestOrderedSort(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}, {Key: "title", Value: 1}},
+		BuildOrderedSort(Desc("created_at"), Asc("title")))
+
+	sort, err := ParseSort(" -created_at, +title ,views,")
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{
+		{Key: "created_at", Value: -1},
+		{Key: "title", Value: 1},
+		{Key: "views", Value: 1},
+	}, sort)
+
+	for _, invalid := range []string{"-", "+", "$natural", "-$score"} {
+		_, err := ParseSort(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+type nestedAddress struct {
+	City string `bson:"city"`
+	Zip  string `bson:"zip,omitempty"`
+}
+
+type nestedUpdate struct {
+	BaseDocument `bson:",inline"`
+	Name         string                 `bson:"name"`
+	Profile      nestedAddress          `bson:"profile"`
+	Billing      *nestedAddress         `bson:"billing,omitempty"`
+	Shipping     *nestedAddress         `bson:"shipping"`
+	Location     nestedAddress          `bson:"location" update:"replace"`
+	Meta         map[string]interface{} `bson:"meta"`
+	Tags         []string               `bson:"tags"`
+	Point        GeoPoint               `bson:"point"`
+	Count        int                    `bson:"count,omitempty"`
+	internal     string
+}
+
+func TestBuildNestedUpdateSet(t *testing.T) {
+	now := time.Now()
+	doc := &nestedUpdate{
+		Name:     "alice",
+		Profile:  nestedAddress{City: "Paris"},
+		Location: nestedAddress{City: "Rome", Zip: "00100"},
+		Meta:     map[string]interface{}{"source": "web", "flags": map[string]int{"beta": 1}},
+		Tags:     []string{"a"},
+		Point:    NewGeoPoint(1, 2),
+		internal: "x",
+	}
+	doc.ID = primitive.NewObjectID()
+	doc.UpdatedAt = now
+
+	update := BuildNestedUpdateSet(doc)
+	assert.Equal(t, bson.M{"$set": bson.M{
+		"created_at":        time.Time{},
+		"updated_at":        now,
+		"name":              "alice",
+		"profile.city":      "Paris",
+		"shipping":          nil,
+		"location":          nestedAddress{City: "Rome", Zip: "00100"},
+		"meta.source":       "web",
+		"meta.flags.beta":   1,
+		"tags":              []string{"a"},
+		"point.type":        "Point",
+		"point.coordinates": []float64{1, 2},
+	}}, update)
+
+	assert.Equal(t, bson.M{"$set": bson.M{"a.b": 1, "c": bson.M{"$x": 2}}},
+		BuildNestedUpdateSet(map[string]interface{}{"a": map[string]int{"b": 1}, "c": bson.M{"$x": 2}}))
+	assert.Equal(t, bson.M{}, BuildNestedUpdateSet((*nestedUpdate)(nil)))
+	assert.Equal(t, bson.M{}, BuildNestedUpdateSet(42))
+}
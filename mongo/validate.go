@@ -0,0 +1,420 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrValidation 文档字段校验失败，具体字段通过 ValidationError 获取
+var ErrValidation = errors.New("validation failed")
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// Field 字段的 bson 路径，例如 profile.email、items.0.sku
+	Field string `json:"field"`
+	// Rule 未通过的规则，例如 required、min
+	Rule string `json:"rule"`
+	// Param 规则参数，例如 min=3 中的 3
+	Param string `json:"param,omitempty"`
+	// Message 可读的错误描述
+	Message string `json:"message"`
+}
+
+// ValidationError 文档校验错误，包含所有未通过的字段，errors.Is(err, ErrValidation) 为 true
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(messages, "; "))
+}
+
+// Unwrap 返回 ErrValidation
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// Validator 文档校验器，可以接入 go-playground/validator 等第三方库：
+//
+//	v := validator.New()
+//	articles := NewCollection(client, "articles").WithValidator(ValidatorFunc(func(doc interface{}) error {
+//		return v.Struct(doc)
+//	}))
+type Validator interface {
+	Validate(doc interface{}) error
+}
+
+// ValidatorFunc 函数形式的校验器
+type ValidatorFunc func(doc interface{}) error
+
+// Validate 实现 Validator
+func (f ValidatorFunc) Validate(doc interface{}) error {
+	return f(doc)
+}
+
+// TagValidator 根据 validate 标签校验结构体，集合未设置校验器时使用，规则以逗号分隔：
+//
+//	Title  string `bson:"title" validate:"required,min=3,max=120"`
+//	Status string `bson:"status" validate:"oneof=draft|published|archived"`
+//	Email  string `bson:"email" validate:"required,email"`
+//	Code   string `bson:"code" validate:"pattern=^[A-Z]{3}$"`
+//
+// 支持 required、min、max、len（数值比较大小，字符串、切片和 map 比较长度）、oneof、email、pattern；
+// 零值字段只检查 required，其它规则跳过；嵌套结构体、结构体指针和结构体切片会递归校验
+type TagValidator struct{}
+
+// Validate 实现 Validator
+func (TagValidator) Validate(doc interface{}) error {
+	v := reflect.ValueOf(doc)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+	if err := validateStruct(v, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// ValidateStruct 使用 TagValidator 校验结构体
+func ValidateStruct(doc interface{}) error {
+	return TagValidator{}.Validate(doc)
+}
+
+// validateStruct 递归校验结构体字段，标签本身写错时返回普通错误
+func validateStruct(v reflect.Value, prefix string, errs *[]FieldError) error {
+	var tagErr error
+	walkStructValues(v, prefix, func(field reflect.StructField, value reflect.Value, path string) {
+		if tagErr != nil {
+			return
+		}
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := validateField(value, path, tag, errs); err != nil {
+				tagErr = fmt.Errorf("invalid validate tag on field %s: %w", field.Name, err)
+				return
+			}
+		}
+		tagErr = validateNested(value, path, errs)
+	})
+	return tagErr
+}
+
+// validateNested 递归校验嵌套结构体及其指针和切片
+func validateNested(value reflect.Value, path string, errs *[]FieldError) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if isBsonLeaf(value.Type()) {
+			return nil
+		}
+		return validateStruct(value, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := validateNested(value.Index(i), joinPath(path, strconv.Itoa(i)), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateField 按标签规则校验单个字段
+func validateField(value reflect.Value, path, tag string, errs *[]FieldError) error {
+	rules := strings.Split(tag, ",")
+	if value.IsZero() {
+		if contains(rules, "required") {
+			*errs = append(*errs, FieldError{Field: path, Rule: "required", Message: "is required"})
+		}
+		return nil
+	}
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		var (
+			ok      bool
+			message string
+			err     error
+		)
+		switch name {
+		case "", "required":
+			continue
+		case "min", "max", "len":
+			ok, message, err = checkSize(value, name, param)
+		case "oneof":
+			options := strings.Split(param, "|")
+			ok = contains(options, fmt.Sprint(value.Interface()))
+			message = "must be one of " + strings.Join(options, ", ")
+		case "email":
+			ok = value.Kind() == reflect.String && emailPattern.MatchString(value.String())
+			message = "must be a valid email address"
+		case "pattern":
+			var re *regexp.Regexp
+			if re, err = compileValidatePattern(param); err == nil {
+				ok = value.Kind() == reflect.String && re.MatchString(value.String())
+				message = "must match " + param
+			}
+		default:
+			err = fmt.Errorf("unknown rule %q", name)
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			*errs = append(*errs, FieldError{Field: path, Rule: name, Param: param, Message: message})
+		}
+	}
+	return nil
+}
+
+// checkSize 检查 min、max、len 规则
+func checkSize(value reflect.Value, rule, param string) (bool, string, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid %s parameter %q", rule, param)
+	}
+
+	var actual float64
+	unit := ""
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	case reflect.String:
+		actual = float64(utf8.RuneCountInString(value.String()))
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual = float64(value.Len())
+		unit = " items"
+	default:
+		return false, "", fmt.Errorf("rule %s is not supported on %s", rule, value.Kind())
+	}
+
+	switch rule {
+	case "min":
+		return actual >= limit, fmt.Sprintf("must be at least %s%s", param, unit), nil
+	case "max":
+		return actual <= limit, fmt.Sprintf("must be at most %s%s", param, unit), nil
+	default:
+		return actual == limit, fmt.Sprintf("must be exactly %s%s", param, unit), nil
+	}
+}
+
+// emailPattern 简单的邮箱格式检查
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// validatePatterns 已编译的 pattern 规则
+var validatePatterns sync.Map
+
+// compileValidatePattern 编译并缓存 pattern 规则
+func compileValidatePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := validatePatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	validatePatterns.Store(pattern, re)
+	return re, nil
+}
+
+// ApplyDefaults 将 default 标签的值写入零值字段，doc 必须为结构体指针：
+//
+//	Status   string        `bson:"status" default:"draft"`
+//	Tags     []string      `bson:"tags" default:"news|tech"`
+//	TTL      time.Duration `bson:"ttl" default:"24h"`
+//	PublishAt time.Time    `bson:"publish_at" default:"now"`
+//
+// 支持字符串、整数、浮点数、布尔值、time.Duration、time.Time（now 或 RFC3339）以及以 | 分隔的字符串切片，
+// 嵌套结构体和非 nil 的结构体指针会递归处理
+func ApplyDefaults(doc interface{}) error {
+	v := reflect.ValueOf(doc)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	return applyDefaults(v)
+}
+
+// applyDefaults 递归写入默认值
+func applyDefaults(v reflect.Value) error {
+	var err error
+	walkStructValues(v, "", func(field reflect.StructField, value reflect.Value, path string) {
+		if err != nil {
+			return
+		}
+		if tag, ok := field.Tag.Lookup("default"); ok && value.IsZero() && value.CanSet() {
+			if setErr := setDefault(value, tag); setErr != nil {
+				err = fmt.Errorf("invalid default tag on field %s: %w", field.Name, setErr)
+				return
+			}
+		}
+		target := value
+		if target.Kind() == reflect.Ptr && !target.IsNil() {
+			target = target.Elem()
+		}
+		if target.Kind() == reflect.Struct && !isBsonLeaf(target.Type()) {
+			err = applyDefaults(target)
+		}
+	})
+	return err
+}
+
+// setDefault 按字段类型解析默认值
+func setDefault(value reflect.Value, tag string) error {
+	switch value.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(tag)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	case time.Time:
+		if tag == "now" {
+			value.Set(reflect.ValueOf(time.Now()))
+			return nil
+		}
+		t, err := time.Parse(time.RFC3339, tag)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(tag)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(tag)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(tag, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(tag, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(tag, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", value.Type())
+		}
+		items := strings.Split(tag, "|")
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			slice.Index(i).SetString(item)
+		}
+		value.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// walkStructValues 遍历结构体的导出字段及其 bson 路径，inline 嵌入的结构体展开到当前层级
+func walkStructValues(v reflect.Value, prefix string, fn func(field reflect.StructField, value reflect.Value, path string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		bsonTag := field.Tag.Get("bson")
+		if bsonTag == "-" {
+			continue
+		}
+		tagParts := strings.Split(bsonTag, ",")
+
+		if contains(tagParts[1:], "inline") {
+			for value.Kind() == reflect.Ptr && !value.IsNil() {
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				walkStructValues(value, prefix, fn)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := tagParts[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fn(field, value, joinPath(prefix, name))
+	}
+}
+
+// WithValidator 返回使用指定校验器的集合副本，替换默认的 TagValidator
+func (c *Collection) WithValidator(validator Validator) *Collection {
+	cp := *c
+	cp.validator = validator
+	return &cp
+}
+
+// validate 使用集合的校验器校验文档，bson.M 等非结构体文档不做校验
+func (c *Collection) validate(document interface{}) error {
+	if c.validator != nil {
+		return c.validator.Validate(document)
+	}
+	return TagValidator{}.Validate(document)
+}
+
+// prepareUpdate 调用 BeforeUpdate 钩子并校验完整的替换文档
+func (c *Collection) prepareUpdate(document interface{}) error {
+	if doc, ok := document.(interface{ BeforeUpdate() }); ok {
+		doc.BeforeUpdate()
+	}
+	return c.validate(document)
+}
@@ -0,0 +1,109 @@
+package mongo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type validatedAddress struct {
+	City string `bson:"city" validate:"required"`
+	Zip  string `bson:"zip" validate:"pattern=^[0-9]{6}$"`
+}
+
+type validatedItem struct {
+	SKU string `bson:"sku" validate:"required,len=4"`
+}
+
+type validatedDoc struct {
+	BaseDocument `bson:",inline"`
+	Title        string            `bson:"title" validate:"required,min=3,max=10"`
+	Status       string            `bson:"status" default:"draft" validate:"oneof=draft|published"`
+	Email        string            `bson:"email" validate:"email"`
+	Score        int               `bson:"score" default:"10" validate:"min=1,max=100"`
+	Tags         []string          `bson:"tags" default:"news|tech" validate:"max=3"`
+	TTL          time.Duration     `bson:"ttl" default:"24h"`
+	Address      validatedAddress  `bson:"address"`
+	Items        []validatedItem   `bson:"items"`
+	Extra        *validatedAddress `bson:"extra,omitempty"`
+}
+
+func TestApplyDefaults(t *testing.T) {
+	doc := &validatedDoc{Score: 50}
+	require.NoError(t, ApplyDefaults(doc))
+
+	assert.Equal(t, "draft", doc.Status)
+	assert.Equal(t, 50, doc.Score, "non-zero fields keep their value")
+	assert.Equal(t, []string{"news", "tech"}, doc.Tags)
+	assert.Equal(t, 24*time.Hour, doc.TTL)
+
+	bad := &struct {
+		Count int `default:"many"`
+	}{}
+	assert.Error(t, ApplyDefaults(bad))
+}
+
+func TestValidateStruct(t *testing.T) {
+	doc := &validatedDoc{
+		Title:   "ok title",
+		Status:  "draft",
+		Score:   10,
+		Address: validatedAddress{City: "Shanghai", Zip: "200000"},
+	}
+	require.NoError(t, ValidateStruct(doc))
+
+	doc.Title = "no"
+	doc.Status = "deleted"
+	doc.Email = "not-an-email"
+	doc.Score = 0
+	doc.Address = validatedAddress{Zip: "abc"}
+	doc.Items = []validatedItem{{SKU: "A1"}}
+	doc.Extra = &validatedAddress{}
+
+	err := ValidateStruct(doc)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrValidation))
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	failed := map[string]string{}
+	for _, fe := range verr.Errors {
+		failed[fe.Field] = fe.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"title":        "min",
+		"status":       "oneof",
+		"email":        "email",
+		"address.city": "required",
+		"address.zip":  "pattern",
+		"items.0.sku":  "len",
+		"extra.city":   "required",
+	}, failed, "zero score is skipped because it is not required")
+}
+
+func TestValidateStructInvalidTag(t *testing.T) {
+	doc := &struct {
+		Name string `validate:"unknown"`
+	}{Name: "x"}
+	err := ValidateStruct(doc)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrValidation))
+}
+
+func TestPrepareInsertValidates(t *testing.T) {
+	c := &Collection{}
+	doc := &validatedDoc{Title: "hello", Address: validatedAddress{City: "Beijing"}}
+	require.NoError(t, c.prepareInsert(doc))
+	assert.Equal(t, "draft", doc.Status)
+	assert.False(t, doc.CreatedAt.IsZero())
+
+	err := c.prepareInsert(&validatedDoc{})
+	assert.True(t, errors.Is(err, ErrValidation))
+
+	custom := errors.New("custom")
+	c = c.WithValidator(ValidatorFunc(func(doc interface{}) error { return custom }))
+	assert.Equal(t, custom, c.prepareInsert(&validatedDoc{}))
+}
@@ -0,0 +1,66 @@
+package mongo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookSink 以 HTTP POST JSON 的方式投递变更事件
+// 返回 2xx 视为投递成功；408、429 和 5xx 会触发转发器重试，其它 4xx 表示请求本身有问题，
+// 重试也不会成功，返回 ErrPermanentDelivery 让转发器跳过该事件
+type WebhookSink struct {
+	url     string
+	client  *http.Client
+	headers map[string]string
+}
+
+// NewWebhookSink 创建 Webhook 投递目标，httpClient 为 nil 时使用 10 秒超时的默认客户端
+func NewWebhookSink(url string, httpClient *http.Client, headers map[string]string) *WebhookSink {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &WebhookSink{
+		url:     url,
+		client:  httpClient,
+		headers: headers,
+	}
+}
+
+// Publish 实现 Sink
+func (s *WebhookSink) Publish(ctx context.Context, event *ChangeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%w: failed to marshal change event: %v", ErrPermanentDelivery, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return fmt.Errorf("%w: webhook returned status %d", ErrPermanentDelivery, resp.StatusCode)
+	}
+	return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSink(t *testing.T) {
+	status := http.StatusOK
+	var received map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, nil, map[string]string{"Authorization": "Bearer secret"})
+	event := &ChangeEvent{OperationType: "insert", Namespace: ChangeNamespace{Database: "app", Collection: "users"}}
+	require.NoError(t, sink.Publish(t.Context(), event))
+	assert.Equal(t, "Bearer secret", auth)
+	assert.Equal(t, "insert", received["operation_type"])
+
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		err := sink.Publish(t.Context(), event)
+		require.Error(t, err, tt.status)
+		assert.Equal(t, tt.permanent, errors.Is(err, ErrPermanentDelivery), tt.status)
+	}
+}
+
+func TestWebhookSinkUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	err := NewWebhookSink(server.URL, nil, nil).Publish(t.Context(), &ChangeEvent{})
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrPermanentDelivery))
+}
@@ -0,0 +1,230 @@
+package testsupport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureOptions 测试数据选项
+type FixtureOptions struct {
+	// Isolate 在客户端数据库名后追加测试专属的后缀，数据写入独立的数据库，测试结束后删除整个数据库；
+	// 通过 Fixtures.Client 获取绑定到该数据库的客户端
+	Isolate bool
+	// DropDatabase 测试结束后删除整个数据库，而不是只删除加载的文档；Isolate 时总是删除
+	DropDatabase bool
+}
+
+// Fixtures 测试数据，记录加载的文档 ID 并在测试结束时清理
+//
+//	fx := testsupport.NewFixtures(t, client, &testsupport.FixtureOptions{Isolate: true})
+//	fx.LoadFile("testdata/users.yaml")
+//	fx.Load("articles", &mongo.Article{Title: "hello"})
+//	users := mongo.NewCollection(fx.Client(), "users")
+type Fixtures struct {
+	t      testing.TB
+	client *mongo.Client
+	drop   bool
+
+	mu       sync.Mutex
+	inserted map[string][]interface{}
+	cleaned  bool
+}
+
+// NewFixtures 创建测试数据，测试结束时自动清理
+func NewFixtures(t testing.TB, client *mongo.Client, opts *FixtureOptions) *Fixtures {
+	t.Helper()
+	o := FixtureOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	f := &Fixtures{t: t, client: client, drop: o.DropDatabase, inserted: make(map[string][]interface{})}
+	if o.Isolate {
+		f.client = client.WithDatabase(DatabaseName(client.GetDatabaseName()+"_", t.Name()))
+		f.drop = true
+	}
+	t.Cleanup(f.Cleanup)
+	return f
+}
+
+// Client 返回加载数据使用的客户端，Isolate 时绑定到测试专属的数据库
+func (f *Fixtures) Client() *mongo.Client {
+	return f.client
+}
+
+// Load 将文档插入集合并返回插入的 ID，插入失败时测试立即失败；
+// 文档可以是结构体指针（生成的 ID 会回填）或 bson.M
+func (f *Fixtures) Load(collection string, docs ...interface{}) []interface{} {
+	f.t.Helper()
+	if len(docs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	result, err := mongo.NewCollection(f.client, collection).InsertMany(ctx, docs)
+	if result != nil {
+		f.track(collection, result.InsertedIDs)
+	}
+	if err != nil {
+		f.t.Fatalf("failed to load fixtures into %s: %v", collection, err)
+	}
+	return result.InsertedIDs
+}
+
+// LoadFile 从 JSON 或 YAML 文件加载数据，文件顶层为 集合名 → 文档列表；
+// ObjectID 和时间分别写成 {$oid: "..."} 和 {$date: "2024-01-02T15:04:05Z"}
+//
+//	users:
+//	  - _id: {$oid: 665f1c2e9b1d8a3f4c2e1a01}
+//	    username: alice
+//	    created_at: {$date: 2024-01-02T15:04:05Z}
+func (f *Fixtures) LoadFile(path string) map[string][]interface{} {
+	f.t.Helper()
+	collections, err := ParseFixtureFile(path)
+	if err != nil {
+		f.t.Fatalf("%v", err)
+	}
+	names := make([]string, 0, len(collections))
+	for name := range collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ids := make(map[string][]interface{}, len(collections))
+	for _, name := range names {
+		ids[name] = f.Load(name, collections[name]...)
+	}
+	return ids
+}
+
+// IDs 返回集合中已加载文档的 ID
+func (f *Fixtures) IDs(collection string) []interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]interface{}(nil), f.inserted[collection]...)
+}
+
+func (f *Fixtures) track(collection string, ids []interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inserted[collection] = append(f.inserted[collection], ids...)
+}
+
+// Cleanup 删除加载的文档（或整个数据库），已经通过 t.Cleanup 注册，重复调用无效
+func (f *Fixtures) Cleanup() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cleaned {
+		return
+	}
+	f.cleaned = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if f.drop {
+		if err := f.client.GetDatabase().Drop(ctx); err != nil {
+			f.t.Errorf("failed to drop fixture database %s: %v", f.client.GetDatabaseName(), err)
+		}
+		return
+	}
+	for collection, ids := range f.inserted {
+		if len(ids) == 0 {
+			continue
+		}
+		_, err := mongo.NewCollection(f.client, collection).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			f.t.Errorf("failed to clean fixtures in %s: %v", collection, err)
+		}
+	}
+}
+
+// ParseFixtureFile 解析 LoadFile 使用的 JSON 或 YAML 文件，按扩展名选择格式
+func ParseFixtureFile(path string) (map[string][]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+	var raw map[string][]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported fixture file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file %s: %w", path, err)
+	}
+
+	collections := make(map[string][]interface{}, len(raw))
+	for name, docs := range raw {
+		converted := make([]interface{}, 0, len(docs))
+		for i, doc := range docs {
+			value, err := convertFixtureValue(doc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse fixture file %s: %s[%d]: %w", path, name, i, err)
+			}
+			m, ok := value.(bson.M)
+			if !ok {
+				return nil, fmt.Errorf("failed to parse fixture file %s: %s[%d] is not a document", path, name, i)
+			}
+			converted = append(converted, m)
+		}
+		collections[name] = converted
+	}
+	return collections, nil
+}
+
+// convertFixtureValue 将解码后的 map 转换为 bson.M，并处理 $oid、$date
+func convertFixtureValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			if oid, ok := v["$oid"].(string); ok {
+				return primitive.ObjectIDFromHex(oid)
+			}
+			if date, ok := v["$date"]; ok {
+				switch d := date.(type) {
+				case string:
+					return time.Parse(time.RFC3339, d)
+				case time.Time:
+					return d, nil
+				}
+				return nil, fmt.Errorf("invalid $date %v", date)
+			}
+		}
+		doc := make(bson.M, len(v))
+		for key, item := range v {
+			converted, err := convertFixtureValue(item)
+			if err != nil {
+				return nil, err
+			}
+			doc[key] = converted
+		}
+		return doc, nil
+	case []interface{}:
+		arr := make(bson.A, len(v))
+		for i, item := range v {
+			converted, err := convertFixtureValue(item)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = converted
+		}
+		return arr, nil
+	}
+	return value, nil
+}
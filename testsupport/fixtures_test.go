@@ -0,0 +1,39 @@
+package testsupport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseFixtureFile(t *testing.T) {
+	collections, err := ParseFixtureFile("testdata/fixtures.yaml")
+	require.NoError(t, err)
+	require.Len(t, collections["users"], 2)
+	require.Len(t, collections["articles"], 1)
+
+	oid, _ := primitive.ObjectIDFromHex("665f1c2e9b1d8a3f4c2e1a01")
+	alice := collections["users"][0].(bson.M)
+	assert.Equal(t, oid, alice["_id"])
+	assert.Equal(t, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC), alice["created_at"])
+	assert.Equal(t, bson.M{"first_name": "Alice"}, alice["profile"])
+	assert.Equal(t, bson.A{"admin", "staff"}, collections["users"][1].(bson.M)["tags"])
+	assert.Equal(t, oid, collections["articles"][0].(bson.M)["author_id"])
+
+	_, err = ParseFixtureFile("testdata/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestFixtures(t *testing.T) {
+	client := NewClient(t, nil)
+	fx := NewFixtures(t, client, &FixtureOptions{Isolate: true})
+	assert.NotEqual(t, client.GetDatabaseName(), fx.Client().GetDatabaseName())
+
+	ids := fx.LoadFile("testdata/fixtures.yaml")
+	assert.Len(t, ids["users"], 2)
+	assert.Equal(t, ids["users"], fx.IDs("users"))
+}
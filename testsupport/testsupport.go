@@ -0,0 +1,218 @@
+// Package testsupport 为集成测试提供 MongoDB 环境：每个测试得到绑定到独立临时数据库的 *mongo.Client，
+// 测试结束后自动删除数据库并断开连接
+//
+// 设置了 MONGODB_TEST_URI 时直接使用该实例；否则通过 docker 启动一个 MongoDB 容器，同一个测试进程内的所有测试共享该容器。
+// 两者都不可用时跳过测试，因此 go test ./... 在没有数据库的环境中也能通过
+//
+//	func TestMain(m *testing.M) {
+//		testsupport.Main(m)
+//	}
+//
+//	func TestCreateUser(t *testing.T) {
+//		client := testsupport.NewClient(t, nil)
+//		users := mongo.NewCollection(client, "users")
+//		...
+//	}
+package testsupport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JustinRoc/mongodbL/mongo"
+)
+
+// URIEnv 指定已有 MongoDB 实例的环境变量，设置后不再启动容器
+const URIEnv = "MONGODB_TEST_URI"
+
+// Options 测试客户端选项
+type Options struct {
+	// URI 使用的 MongoDB 地址，默认读取 MONGODB_TEST_URI，为空时启动容器
+	URI string
+	// Image 启动容器使用的镜像，默认 mongo:7
+	Image string
+	// DatabasePrefix 临时数据库名前缀，默认 test_
+	DatabasePrefix string
+	// StartTimeout 等待容器就绪的最长时间，默认 60 秒
+	StartTimeout time.Duration
+	// Customize 在建立连接前修改客户端配置，例如设置 Logger、OperationTimeout
+	Customize func(config *mongo.Config)
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.URI == "" {
+		opts.URI = os.Getenv(URIEnv)
+	}
+	if opts.Image == "" {
+		opts.Image = "mongo:7"
+	}
+	if opts.DatabasePrefix == "" {
+		opts.DatabasePrefix = "test_"
+	}
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = 60 * time.Second
+	}
+	return opts
+}
+
+// NewClient 返回绑定到独立临时数据库的客户端，测试结束时删除数据库并断开连接；
+// 没有可用的 MongoDB 时调用 t.Skip
+func NewClient(t testing.TB, opts *Options) *mongo.Client {
+	t.Helper()
+	o := opts.withDefaults()
+
+	uri := o.URI
+	if uri == "" {
+		var err error
+		if uri, err = shared.start(o.Image, o.StartTimeout); err != nil {
+			t.Skipf("MongoDB is not available (set %s or install docker): %v", URIEnv, err)
+		}
+	}
+
+	config := mongo.DefaultConfig()
+	config.URI = uri
+	config.Database = DatabaseName(o.DatabasePrefix, t.Name())
+	config.ConnectTimeout = 10 * time.Second
+	config.ServerSelectionTimeout = 10 * time.Second
+	if o.Customize != nil {
+		o.Customize(config)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	client, err := mongo.NewClientWithContext(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to connect to MongoDB: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := client.GetDatabase().Drop(ctx); err != nil {
+			t.Errorf("failed to drop test database %s: %v", config.Database, err)
+		}
+		if err := client.CloseContext(ctx); err != nil {
+			t.Errorf("failed to disconnect from MongoDB: %v", err)
+		}
+	})
+	return client
+}
+
+// DatabaseName 根据测试名生成唯一的数据库名，只保留字母、数字和下划线，并追加随机后缀避免并行测试冲突；
+// 结果不超过 MongoDB 数据库名的 63 字节限制
+func DatabaseName(prefix, testName string) string {
+	var b strings.Builder
+	for _, r := range testName {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	tail := "_" + hex.EncodeToString(suffix)
+	if limit := max(63-len(prefix)-len(tail), 0); len(name) > limit {
+		name = name[:limit]
+	}
+	return prefix + name + tail
+}
+
+// Main 运行测试并在结束后删除本进程启动的容器，应当在 TestMain 中调用
+func Main(m *testing.M) {
+	code := m.Run()
+	shared.stop()
+	os.Exit(code)
+}
+
+// container 本进程共享的 MongoDB 容器
+type container struct {
+	once sync.Once
+	id   string
+	uri  string
+	err  error
+}
+
+var shared container
+
+// start 第一次调用时启动容器并等待可以连接，之后返回同一个地址
+func (c *container) start(image string, timeout time.Duration) (string, error) {
+	c.once.Do(func() {
+		if _, err := exec.LookPath("docker"); err != nil {
+			c.err = err
+			return
+		}
+		// 只绑定本机随机端口，--rm 保证容器停止后被删除
+		out, err := docker("run", "-d", "--rm", "-p", "127.0.0.1::27017", image)
+		if err != nil {
+			c.err = err
+			return
+		}
+		c.id = out
+		port, err := docker("port", c.id, "27017/tcp")
+		if err != nil {
+			c.err = err
+			return
+		}
+		// 输出形如 127.0.0.1:49153，可能有多行（IPv4 和 IPv6）
+		addr, _, _ := strings.Cut(port, "\n")
+		c.uri = "mongodb://" + strings.TrimSpace(addr) + "/?directConnection=true"
+		c.err = waitReady(c.uri, timeout)
+	})
+	return c.uri, c.err
+}
+
+func (c *container) stop() {
+	if c.id != "" {
+		docker("rm", "-f", c.id)
+	}
+}
+
+// waitReady 轮询直到容器内的 MongoDB 可以响应 Ping
+func waitReady(uri string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		config := mongo.DefaultConfig()
+		config.URI = uri
+		config.Database = "admin"
+		config.ServerSelectionTimeout = 2 * time.Second
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		client, err := mongo.NewClientWithContext(ctx, config)
+		if err == nil {
+			client.CloseContext(ctx)
+			cancel()
+			return nil
+		}
+		cancel()
+		if time.Now().After(deadline) {
+			return fmt.Errorf("MongoDB container not ready after %s: %w", timeout, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func docker(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,17 @@
+package testsupport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabaseName(t *testing.T) {
+	name := DatabaseName("test_", "TestSuite/insert user.v2")
+	assert.True(t, strings.HasPrefix(name, "test_TestSuite_insert_user_v2_"), name)
+	assert.NotEqual(t, name, DatabaseName("test_", "TestSuite/insert user.v2"))
+
+	long := DatabaseName("test_", strings.Repeat("x", 100))
+	assert.Len(t, long, 63)
+}